@@ -0,0 +1,181 @@
+package commands
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"twooms/i18n"
+	"twooms/storage"
+)
+
+// dailyCapacity returns the minutes of work that fit in a day
+// (daily_capacity in the config, e.g. "6h"), or storage's default limit
+// when it's unset or invalid
+func dailyCapacity() int {
+	setting := strings.TrimSpace(GetConfig().DailyCapacity)
+	if setting == "" {
+		return storage.DailyLoadLimit
+	}
+	minutes, err := parseBudget(setting)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: invalid daily_capacity %q, using %s\n", setting, storage.FormatMinutes(storage.DailyLoadLimit))
+		return storage.DailyLoadLimit
+	}
+	return minutes
+}
+
+func init() {
+	Register(&Command{
+		Name:        "/agenda",
+		Shorthand:   "/ag",
+		Description: "Show a day-by-day agenda of tasks due in the next 7 days (week, the default) or the 7 after (next-week), with each day's total and a warning when it's over capacity",
+		Examples:    []string{"/agenda", "/agenda next-week", "/agenda week work"},
+		Access:      AccessRead,
+		Params: []Param{
+			{Name: "range", Type: ParamTypeString, Description: "week (the next 7 days, with overdue tasks) or next-week (the 7 days after)", Required: false, Enum: []string{"week", "next-week"}},
+			{Name: "project_id", Type: ParamTypeString, Description: "Optional project ID to filter by", Required: false},
+		},
+		Handler: func(args []string) bool {
+			if len(args) > 2 {
+				fmt.Println(i18n.T("agenda.usage"))
+				return false
+			}
+
+			today := dateOnly(time.Now())
+			start, overdue := today, true
+			if len(args) > 0 {
+				switch strings.ToLower(args[0]) {
+				case "week":
+					args = args[1:]
+				case "next-week":
+					start, overdue = today.AddDate(0, 0, 7), false
+					args = args[1:]
+				default:
+					// A lone argument that isn't a range is the project
+					if len(args) > 1 {
+						fmt.Println(i18n.T("agenda.usage"))
+						return false
+					}
+				}
+			}
+
+			var projectID string
+			if len(args) > 0 {
+				resolved, err := GetStore().ResolveProjectID(args[0])
+				if err != nil {
+					fmt.Printf("Error: %v\n", err)
+					return false
+				}
+				projectID = resolved
+			}
+
+			printAgenda(start, projectID, overdue, dailyCapacity())
+			return false
+		},
+	})
+}
+
+// printAgenda prints the open tasks due on each of the seven days from
+// start, with each day's total load against capacity. Overdue tasks come
+// first when includeOverdue is set. Days count every project's work, as
+// /capacity does, even when the listing is filtered to one project.
+func printAgenda(start time.Time, projectID string, includeOverdue bool, capacity int) {
+	tasks, err := GetStore().ListAllTasks()
+	if err != nil {
+		fmt.Printf("Error listing tasks: %v\n", err)
+		return
+	}
+
+	end := start.AddDate(0, 0, 7)
+	byDay := make(map[time.Time][]*storage.Task)
+	load := make(map[time.Time]int)
+	var overdue []*storage.Task
+	for _, t := range tasks {
+		if t.Done || t.DueDate == nil {
+			continue
+		}
+		day := dateOnly(*t.DueDate)
+		shown := projectID == "" || t.ProjectID == projectID
+		switch {
+		case day.Before(start):
+			if includeOverdue && shown {
+				overdue = append(overdue, t)
+			}
+		case day.Before(end):
+			load[day] += storage.TaskLoad(t)
+			if shown {
+				byDay[day] = append(byDay[day], t)
+			}
+		}
+	}
+
+	title := fmt.Sprintf("Agenda for %s to %s", start.Format("Mon Jan 2"), end.AddDate(0, 0, -1).Format("Mon Jan 2"))
+	if projectID != "" {
+		title += " in " + projectName(projectID)
+	}
+	fmt.Printf("%s (%s per day; unestimated tasks count as 30m):\n", title, storage.FormatMinutes(capacity))
+
+	if len(overdue) > 0 {
+		fmt.Printf("\nOverdue (%d):\n", len(overdue))
+		printAgendaTasks(overdue, projectID, true)
+	}
+
+	today := dateOnly(time.Now())
+	var over []string
+	for day := start; day.Before(end); day = day.AddDate(0, 0, 1) {
+		label := day.Format("Mon Jan 2")
+		switch {
+		case day.Equal(today):
+			label += " (today)"
+		case day.Equal(today.AddDate(0, 0, 1)):
+			label += " (tomorrow)"
+		}
+		total := fmt.Sprintf("%s / %s", storage.FormatMinutes(load[day]), storage.FormatMinutes(capacity))
+		if load[day] > capacity {
+			total = colorOverdue(fmt.Sprintf("%s, over by %s", total, storage.FormatMinutes(load[day]-capacity)))
+			over = append(over, day.Format("Mon"))
+		}
+		fmt.Printf("\n%s: %s\n", label, total)
+		if len(byDay[day]) == 0 {
+			fmt.Println("  Nothing due")
+			continue
+		}
+		printAgendaTasks(byDay[day], projectID, false)
+	}
+
+	if len(over) > 0 {
+		fmt.Printf("\nOver capacity: %s. Move some work with /defer or /due.\n", strings.Join(over, ", "))
+	}
+}
+
+// printAgendaTasks lists tasks earliest due first, with tasks that have a
+// due time before those without on the same day. Under a day only due
+// times are shown; withDate shows the whole due date.
+func printAgendaTasks(tasks []*storage.Task, projectID string, withDate bool) {
+	sort.SliceStable(tasks, func(i, j int) bool {
+		return storage.DueAt(*tasks[i].DueDate, time.Local).Before(storage.DueAt(*tasks[j].DueDate, time.Local))
+	})
+	for _, t := range tasks {
+		var extras []string
+		switch {
+		case withDate:
+			extras = append(extras, "due "+storage.FormatDue(*t.DueDate))
+		case storage.HasDueTime(*t.DueDate):
+			extras = append(extras, t.DueDate.Format("15:04"))
+		}
+		if t.Duration != 0 {
+			extras = append(extras, t.Duration.String())
+		}
+		if projectID == "" {
+			extras = append(extras, projectName(t.ProjectID))
+		}
+		extraStr := ""
+		if len(extras) > 0 {
+			extraStr = " (" + strings.Join(extras, ", ") + ")"
+		}
+		fmt.Println("  " + taskLine(t, "[ ]", storage.DisplayName(t.Name)+extraStr+formatTags(t)+blockedMark(t)))
+	}
+}
@@ -0,0 +1,215 @@
+package commands
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"twooms/i18n"
+	"twooms/storage"
+)
+
+func init() {
+	Register(&Command{
+		Name:        "/archive",
+		Shorthand:   "/ar",
+		Description: "Archive a done task, or all done tasks in a project, to hide them from lists, schedules, and totals",
+		Params: []Param{
+			{Name: "task_id", Type: ParamTypeString, Description: "The ID of a done task to archive, or --done to archive every done task in project_id", Required: true},
+			{Name: "project_id", Type: ParamTypeString, Description: "With --done, the ID or shortcut of the project", Required: false},
+		},
+		Handler: func(args []string) bool {
+			if len(args) == 0 || (args[0] == "--done" && len(args) < 2) {
+				fmt.Println(i18n.T("archive.usage"))
+				return false
+			}
+
+			if args[0] == "--done" {
+				archiveDone(args[1])
+				return false
+			}
+
+			taskID, err := GetStore().ResolveTaskID(args[0])
+			if err != nil {
+				fmt.Printf("Error: %v\n", err)
+				return false
+			}
+			task, err := GetStore().GetTask(taskID)
+			if err != nil {
+				fmt.Printf("Error: %v\n", err)
+				return false
+			}
+
+			if err := GetStore().ArchiveTasks([]string{taskID}); err != nil {
+				fmt.Printf("Error: %v\n", err)
+				return false
+			}
+			fmt.Printf("Archived task %s\n", task.Name)
+			return false
+		},
+	})
+
+	Register(&Command{
+		Name:        "/archived",
+		Shorthand:   "/ars",
+		Description: "List archived tasks in a project",
+		Access:      AccessRead,
+		Params: []Param{
+			{Name: "project_id", Type: ParamTypeString, Description: "The ID or shortcut of the project", Required: true},
+		},
+		Handler: func(args []string) bool {
+			if len(args) == 0 {
+				fmt.Println(i18n.T("archived.usage"))
+				return false
+			}
+
+			projectID, err := GetStore().ResolveProjectID(args[0])
+			if err != nil {
+				fmt.Printf("Error: %v\n", err)
+				return false
+			}
+			project, err := GetStore().GetProject(projectID)
+			if err != nil {
+				fmt.Printf("Error: %v\n", err)
+				return false
+			}
+
+			tasks, err := GetStore().ListArchivedTasks(projectID)
+			if err != nil {
+				fmt.Printf("Error listing tasks: %v\n", err)
+				return false
+			}
+
+			fmt.Printf("Archived tasks in %s:\n", project.Name)
+			if len(tasks) == 0 {
+				fmt.Println("  No archived tasks. Archive done tasks with /archive --done <project-id>")
+				return false
+			}
+			for _, t := range tasks {
+				var extras []string
+				if t.Duration != 0 {
+					extras = append(extras, t.Duration.String())
+				}
+				extras = append(extras, "archived "+t.ArchivedAt.Format("2006-01-02"))
+				fmt.Println("  " + taskLine(t, "[✓]", fmt.Sprintf("%s (%s)%s", storage.DisplayName(t.Name), strings.Join(extras, ", "), formatTags(t))))
+			}
+			fmt.Println("\nReopen one with /undone <task-id> to bring it back.")
+			return false
+		},
+	})
+
+	Register(&Command{
+		Name:        "/archivefile",
+		Description: "Move a project and its tasks out of the store into an archive file",
+		Hidden:      true,
+		Params: []Param{
+			{Name: "project_id", Type: ParamTypeString, Description: "The ID or shortcut of the project to archive", Required: true},
+			{Name: "file", Type: ParamTypeString, Description: "Optional archive file (defaults to twooms-<shortcut>-<date>.json)", Required: false},
+		},
+		Handler: func(args []string) bool {
+			if len(args) == 0 {
+				fmt.Println(i18n.T("archivefile.usage"))
+				return false
+			}
+
+			projectID, err := GetStore().ResolveProjectID(args[0])
+			if err != nil {
+				fmt.Printf("Error: %v\n", err)
+				return false
+			}
+			project, err := GetStore().GetProject(projectID)
+			if err != nil {
+				fmt.Printf("Error: %v\n", err)
+				return false
+			}
+
+			filename := fmt.Sprintf("twooms-%s-%s.json", project.Shortcut, time.Now().Format("2006-01-02"))
+			if len(args) > 1 {
+				filename = args[1]
+			}
+
+			archive, err := storage.ArchiveProject(GetStore(), projectID, filename)
+			if err != nil {
+				fmt.Printf("Error archiving project: %v\n", err)
+				return false
+			}
+
+			fmt.Printf("Archived project %s (%d tasks) to %s\n", project.Name, len(archive.Tasks), filename)
+			fmt.Printf("Restore it with /restorefile %s\n", filename)
+			return false
+		},
+	})
+
+	Register(&Command{
+		Name:        "/restorefile",
+		Description: "Restore a project from an archive file",
+		Hidden:      true,
+		Params: []Param{
+			{Name: "file", Type: ParamTypeString, Description: "The archive file written by /archivefile", Required: true},
+		},
+		Handler: func(args []string) bool {
+			if len(args) == 0 {
+				fmt.Println(i18n.T("restorefile.usage"))
+				return false
+			}
+
+			filename := args[0]
+			archive, err := storage.ReadProjectArchive(filename)
+			if err != nil {
+				fmt.Printf("Error reading %s: %v\n", filename, err)
+				return false
+			}
+
+			result, err := storage.RestoreProject(GetStore(), archive)
+			if err != nil {
+				fmt.Printf("Error: %v\n", err)
+				return false
+			}
+
+			fmt.Printf("Restored project %s (%d tasks) archived %s\n", archive.Projects[0].Name, result.TasksCreated, archive.ArchivedAt.Format("2006-01-02"))
+			if len(result.Conflicts) > 0 {
+				fmt.Printf("  %d tasks not restored:\n", len(result.Conflicts))
+				for _, c := range result.Conflicts {
+					fmt.Printf("    - %s\n", c)
+				}
+			}
+			return false
+		},
+	})
+}
+
+// archiveDone archives every done task in a project in one undoable step
+func archiveDone(projectRef string) {
+	projectID, err := GetStore().ResolveProjectID(projectRef)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		return
+	}
+	project, err := GetStore().GetProject(projectID)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		return
+	}
+	tasks, err := GetStore().ListTasks(projectID)
+	if err != nil {
+		fmt.Printf("Error listing tasks: %v\n", err)
+		return
+	}
+
+	var ids []string
+	for _, t := range tasks {
+		if t.Done {
+			ids = append(ids, t.ID)
+		}
+	}
+	if len(ids) == 0 {
+		fmt.Printf("No done tasks to archive in %s\n", project.Name)
+		return
+	}
+
+	if err := GetStore().ArchiveTasks(ids); err != nil {
+		fmt.Printf("Error: %v\n", err)
+		return
+	}
+	fmt.Printf("Archived %d done tasks in %s\n", len(ids), project.Name)
+}
@@ -0,0 +1,91 @@
+package commands
+
+import (
+	"strings"
+	"unicode"
+)
+
+// SplitArgs splits a command line into words the way Execute does. Words
+// are separated by whitespace, and quotes group words with spaces into one:
+// "Fix bug #12: crash" or 'Fix bug #12: crash'. Inside double quotes, \" and
+// \\ stand for a quote and a backslash; single quotes take everything
+// literally. A quote only opens at the start of a word and only closes
+// before whitespace or the end of the line, so apostrophes in words like
+// don't are just text, as is a quote that is never closed.
+func SplitArgs(line string) []string {
+	runes := []rune(line)
+	var args []string
+	var word strings.Builder
+	inWord := false
+
+	for i := 0; i < len(runes); i++ {
+		r := runes[i]
+		if unicode.IsSpace(r) {
+			if inWord {
+				args = append(args, word.String())
+				word.Reset()
+				inWord = false
+			}
+			continue
+		}
+
+		if (r == '"' || r == '\'') && !inWord {
+			if end := closingQuote(runes, i); end > 0 {
+				word.WriteString(unquote(runes[i+1:end], r))
+				inWord = true
+				i = end
+				continue
+			}
+		}
+		word.WriteRune(r)
+		inWord = true
+	}
+	if inWord {
+		args = append(args, word.String())
+	}
+	return args
+}
+
+// closingQuote returns the index of the quote that closes the one at open,
+// or -1 if there is none
+func closingQuote(runes []rune, open int) int {
+	quote := runes[open]
+	for i := open + 1; i < len(runes); i++ {
+		if quote == '"' && runes[i] == '\\' && i+1 < len(runes) {
+			i++ // skip the escaped character
+			continue
+		}
+		if runes[i] == quote && (i+1 == len(runes) || unicode.IsSpace(runes[i+1])) {
+			return i
+		}
+	}
+	return -1
+}
+
+// unquote returns the text between a pair of quotes
+func unquote(text []rune, quote rune) string {
+	if quote == '\'' {
+		return string(text)
+	}
+	var b strings.Builder
+	for i := 0; i < len(text); i++ {
+		if text[i] == '\\' && i+1 < len(text) && (text[i+1] == '"' || text[i+1] == '\\') {
+			i++
+		}
+		b.WriteRune(text[i])
+	}
+	return b.String()
+}
+
+// JoinArgs builds a command line that SplitArgs splits back into args,
+// quoting the words that need it
+func JoinArgs(args []string) string {
+	quoted := make([]string, len(args))
+	for i, arg := range args {
+		quoted[i] = arg
+		if arg == "" || strings.ContainsFunc(arg, unicode.IsSpace) || strings.ContainsAny(arg[:1], `"'`) {
+			quoted[i] = `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(arg) + `"`
+		}
+	}
+	return strings.Join(quoted, " ")
+}
@@ -0,0 +1,391 @@
+package commands
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"twooms/config"
+	"twooms/i18n"
+	"twooms/storage"
+)
+
+// defaultBackupKeep is how many local backups of each kind (manual and
+// automatic) are kept when the config doesn't say
+const defaultBackupKeep = 10
+
+// backupCheckInterval is how often the schedule checks whether a backup is due
+const backupCheckInterval = time.Hour
+
+// backupDir holds the local backups, set by SetBackupDir
+var backupDir string
+
+// runBackupTool runs an external copy command like scp (replaced in tests)
+var runBackupTool = func(args []string) error {
+	output, err := exec.Command(args[0], args[1:]...).CombinedOutput()
+	if err != nil {
+		if msg := strings.TrimSpace(string(output)); msg != "" {
+			return fmt.Errorf("%s: %v (%s)", args[0], err, msg)
+		}
+		return fmt.Errorf("%s: %v", args[0], err)
+	}
+	return nil
+}
+
+// backupScheduled is set once the scheduled backup goroutine is running
+var backupScheduled bool
+
+func init() {
+	Register(&Command{
+		Name:        "/backup",
+		Description: "Back up all projects and tasks locally and to the configured remote targets, list backups, or restore one",
+		Examples:    []string{"/backup now", "/backup list", "/backup restore twooms-20250101-090000.json nas"},
+		Hidden:      true,
+		Handler: func(args []string) bool {
+			if backupDir == "" {
+				fmt.Println("Error: backups are not available")
+				return false
+			}
+			if len(args) == 0 {
+				args = []string{"list"}
+			}
+
+			switch strings.ToLower(args[0]) {
+			case "now":
+				path, copyErrs, err := runBackup()
+				if err != nil {
+					fmt.Printf("Error: %v\n", err)
+					return false
+				}
+				fmt.Printf("Backed up to %s\n", path)
+				for _, target := range backupConfig().Targets {
+					if err := copyErrs[target.Name]; err != nil {
+						fmt.Printf("  Error copying to %s: %v\n", target.Name, err)
+					} else {
+						fmt.Printf("  Copied to %s\n", target.Name)
+					}
+				}
+			case "list":
+				listBackups(false)
+			case "restore":
+				if len(args) < 2 || len(args) > 3 {
+					fmt.Println(i18n.T("backup.usage_restore"))
+					return false
+				}
+				restoreBackup(args[1], args[2:])
+			default:
+				fmt.Println(i18n.T("backup.usage"))
+			}
+			return false
+		},
+	})
+
+	Register(&Command{
+		Name:        "/backups",
+		Description: "List local backups, including the automatic ones taken before each change",
+		Access:      AccessRead,
+		Hidden:      true,
+		Handler: func(args []string) bool {
+			if backupDir == "" {
+				fmt.Println("Error: backups are not available")
+				return false
+			}
+			listBackups(true)
+			return false
+		},
+	})
+
+	Register(&Command{
+		Name:        "/restore",
+		Description: "Bring a deleted project or task back from the trash by its ID from /trash, or roll projects and tasks back to a local backup by its timestamp from /backups",
+		Examples:    []string{"/restore 1a2b3c4d", "/restore 20250101-090000"},
+		Access:      AccessWrite,
+		Hidden:      true,
+		Destructive: true,
+		Interactive: true,
+		Handler: func(args []string) bool {
+			args, yes := takeYes(args)
+			if len(args) != 1 {
+				fmt.Println(i18n.T("restore.usage"))
+				return false
+			}
+			if !storage.IsBackupRef(args[0]) {
+				restoreFromTrash(args[0])
+				return false
+			}
+			if backupDir == "" {
+				fmt.Println("Error: backups are not available")
+				return false
+			}
+			rollBack(args[0], yes)
+			return false
+		},
+	})
+}
+
+// SetBackupDir sets the directory local backups are written to
+func SetBackupDir(dir string) {
+	backupDir = dir
+}
+
+// backupConfig returns the backup settings, or empty ones if none are configured
+func backupConfig() *config.BackupConfig {
+	if cfg := GetConfig(); cfg != nil && cfg.Backup != nil {
+		return cfg.Backup
+	}
+	return &config.BackupConfig{}
+}
+
+// backupKeep returns how many manual local backups to keep
+func backupKeep() int {
+	if keep := backupConfig().Keep; keep > 0 {
+		return keep
+	}
+	return defaultBackupKeep
+}
+
+// autoBackupKeep returns how many automatic backups to keep, or a negative
+// number when they're off
+func autoBackupKeep() int {
+	if keep := backupConfig().Auto; keep != 0 {
+		return keep
+	}
+	return defaultBackupKeep
+}
+
+// autoBackupStore wraps a workspace's store to back it up before each
+// change, unless automatic backups are off
+func autoBackupStore(store storage.Store, dir string) storage.Store {
+	keep := autoBackupKeep()
+	if dir == "" || keep < 0 {
+		return store
+	}
+	backed := storage.NewAutoBackupStore(store, dir, keep)
+	backed.OnError = func(err error) {
+		fmt.Fprintf(os.Stderr, "Warning: automatic backup failed: %v\n", err)
+	}
+	return backed
+}
+
+// runBackup writes a JSON snapshot of the store to the backup directory,
+// prunes old local backups, and copies the new one to every target. It
+// returns the backup's path and any copy errors by target name; err is set
+// only if the local backup couldn't be written.
+func runBackup() (path string, copyErrs map[string]error, err error) {
+	b, err := storage.WriteBackup(GetStore(), backupDir, false, backupKeep(), time.Now())
+	if err != nil {
+		return "", nil, err
+	}
+	path = filepath.Join(backupDir, b.Name)
+
+	copyErrs = make(map[string]error)
+	for _, target := range backupConfig().Targets {
+		args, err := backupCopyArgs(target, path, remoteBackupPath(target, filepath.Base(path)))
+		if err == nil {
+			err = runBackupTool(args)
+		}
+		if err != nil {
+			copyErrs[target.Name] = err
+		}
+	}
+	return path, copyErrs, nil
+}
+
+// remoteBackupPath joins a target's destination and a backup name
+func remoteBackupPath(target config.BackupTarget, name string) string {
+	dest := strings.TrimSuffix(target.Dest, "/")
+	if strings.HasSuffix(dest, ":") {
+		return dest + name // a bare "host:" or "remote:"
+	}
+	return dest + "/" + name
+}
+
+// backupCopyArgs builds the command that copies src to dst for a target;
+// either side may be the remote one
+func backupCopyArgs(target config.BackupTarget, src, dst string) ([]string, error) {
+	switch target.Type {
+	case "s3":
+		args := []string{"aws", "s3", "cp", "--only-show-errors", src, dst}
+		if target.Endpoint != "" {
+			args = append(args, "--endpoint-url", target.Endpoint)
+		}
+		return args, nil
+	case "rclone":
+		return []string{"rclone", "copyto", src, dst}, nil
+	case "scp":
+		return []string{"scp", "-q", "-B", src, dst}, nil
+	}
+	return nil, fmt.Errorf("unknown backup target type %q (use s3, rclone, or scp)", target.Type)
+}
+
+// listBackups prints the local backups, newest first, leaving out the
+// automatic ones unless all is set
+func listBackups(all bool) {
+	cfg := backupConfig()
+	backups, err := storage.ListBackups(backupDir)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		return
+	}
+	auto := 0
+	var shown []*storage.Backup
+	for _, b := range backups {
+		if b.Auto {
+			auto++
+		}
+		if all || !b.Auto {
+			shown = append(shown, b)
+		}
+	}
+
+	if len(shown) == 0 {
+		fmt.Printf("No backups in %s yet. Use /backup now to make one.\n", backupDir)
+	} else {
+		fmt.Printf("Backups in %s (newest first):\n", backupDir)
+		for _, b := range shown {
+			kind := ""
+			if b.Auto {
+				kind = "  (automatic)"
+			}
+			fmt.Printf("  %s  %s%s\n", b.Stamp(), b.Time.Format("Mon 2006-01-02 15:04:05"), kind)
+		}
+	}
+	if !all && auto > 0 {
+		fmt.Printf("Plus %d automatic backups from before changes; /backups lists them\n", auto)
+	}
+
+	if cfg.Every != "" {
+		fmt.Printf("Scheduled every %s while twooms runs\n", cfg.Every)
+	}
+	for _, target := range cfg.Targets {
+		fmt.Printf("Target %s: %s %s\n", target.Name, target.Type, target.Dest)
+	}
+}
+
+// restoreBackup imports a backup, first downloading it from a target if one
+// is named. Importing never overwrites: entries that differ from the store
+// are reported as conflicts, as with /import. /restore rolls back instead.
+func restoreBackup(name string, target []string) {
+	if name != filepath.Base(name) || !strings.HasSuffix(name, ".json") {
+		fmt.Println("Error: give a backup name from /backup list, like twooms-20250101-090000.json")
+		return
+	}
+	path := filepath.Join(backupDir, name)
+
+	if len(target) > 0 {
+		t, ok := findBackupTarget(target[0])
+		if !ok {
+			fmt.Printf("Error: unknown backup target: %s\n", target[0])
+			return
+		}
+		if err := os.MkdirAll(backupDir, 0700); err != nil {
+			fmt.Printf("Error: %v\n", err)
+			return
+		}
+		args, err := backupCopyArgs(t, remoteBackupPath(t, name), path)
+		if err == nil {
+			err = runBackupTool(args)
+		}
+		if err != nil {
+			fmt.Printf("Error downloading from %s: %v\n", t.Name, err)
+			return
+		}
+		fmt.Printf("Downloaded %s from %s\n", name, t.Name)
+	} else if _, err := os.Stat(path); err != nil {
+		fmt.Printf("Error: no local backup named %s (see /backup list)\n", name)
+		return
+	}
+
+	importFile(path, false)
+}
+
+func findBackupTarget(name string) (config.BackupTarget, bool) {
+	for _, t := range backupConfig().Targets {
+		if t.Name == name {
+			return t, true
+		}
+	}
+	return config.BackupTarget{}, false
+}
+
+// StartBackupSchedule starts backing up in the background whenever the
+// newest local backup is older than the configured interval. It does nothing
+// without an interval or when already running. Errors go to stderr.
+func StartBackupSchedule() error {
+	every := backupConfig().Every
+	if every == "" || backupScheduled || backupDir == "" {
+		return nil
+	}
+	interval, err := time.ParseDuration(every)
+	if err != nil || interval <= 0 {
+		return fmt.Errorf("invalid backup interval %q (use e.g. 24h)", every)
+	}
+	backupScheduled = true
+
+	go func() {
+		for {
+			if backupDue(interval, time.Now()) {
+				_, copyErrs, err := runBackup()
+				if err != nil {
+					fmt.Fprintf(os.Stderr, "Scheduled backup failed: %v\n", err)
+				}
+				for name, err := range copyErrs {
+					fmt.Fprintf(os.Stderr, "Scheduled backup: copying to %s failed: %v\n", name, err)
+				}
+			}
+			time.Sleep(min(interval, backupCheckInterval))
+		}
+	}()
+	return nil
+}
+
+// backupDue reports whether the newest manual local backup is at least
+// interval old; automatic ones don't count, since they aren't copied to
+// targets
+func backupDue(interval time.Duration, now time.Time) bool {
+	backups, _ := storage.ListBackups(backupDir)
+	for _, b := range backups {
+		if !b.Auto {
+			return now.Sub(b.Time) >= interval
+		}
+	}
+	return true
+}
+
+// rollBack replaces projects and tasks with a local backup's, after backing
+// up the current ones so the roll back can be undone. That backup is a manual
+// one: an automatic one from the same second would be skipped, and it
+// shouldn't rotate away as changes are made.
+func rollBack(stamp string, yes bool) {
+	b, err := storage.FindBackup(backupDir, stamp)
+	if err != nil {
+		fmt.Printf("Error: %v (see /backups)\n", err)
+		return
+	}
+	snap, err := storage.ReadBackup(backupDir, b)
+	if err != nil {
+		fmt.Printf("Error reading %s: %v\n", b.Name, err)
+		return
+	}
+
+	question := fmt.Sprintf("Roll back to the backup from %s? Changes since then are undone.", b.Time.Format("Mon 2006-01-02 15:04:05"))
+	if !confirm("/restore", question, yes) {
+		return
+	}
+
+	saved, err := storage.WriteBackup(GetStore(), backupDir, false, backupKeep(), time.Now())
+	if err != nil {
+		fmt.Printf("Error: not rolling back, since the current data couldn't be backed up first: %v\n", err)
+		return
+	}
+	result, err := storage.RestoreSnapshot(GetStore(), snap, "roll back to "+b.Stamp())
+	if err != nil {
+		fmt.Printf("Error: %v (nothing was rolled back)\n", err)
+		return
+	}
+	fmt.Printf("Rolled back to %s: %d added, %d changed, %d deleted\n", b.Stamp(), result.Added, result.Replaced, result.Deleted)
+	fmt.Printf("The data from before is backed up; /undo or /restore %s puts it back\n", saved.Stamp())
+}
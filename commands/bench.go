@@ -0,0 +1,176 @@
+package commands
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime/pprof"
+	"strconv"
+	"strings"
+	"time"
+
+	"twooms/i18n"
+	"twooms/storage"
+)
+
+// benchBackends are the storage backends /bench can measure, in table order
+var benchBackends = []string{"json", "bolt"}
+
+// defaultBenchSizes are the dataset sizes /bench runs without arguments;
+// 100k takes minutes to create, so it runs only when asked for
+var defaultBenchSizes = []int{1000, 10000}
+
+// maxBenchSize keeps a typo from filling the disk
+const maxBenchSize = 1000000
+
+func init() {
+	Register(&Command{
+		Name:        "/bench",
+		Description: "Time the storage backends on synthetic datasets (your data isn't touched)",
+		Access:      AccessRead,
+		Hidden:      true, // slow, and only useful to a person choosing a backend
+		Handler: func(args []string) bool {
+			sizes, backends, profile, err := parseBenchArgs(args)
+			if err != nil {
+				fmt.Printf("Error: %v\n", err)
+				fmt.Println(i18n.T("bench.usage"))
+				return false
+			}
+			if err := runBench(sizes, backends, profile); err != nil {
+				fmt.Printf("Error: %v\n", err)
+			}
+			return false
+		},
+	})
+}
+
+// parseBenchArgs reads dataset sizes like 1k or 2500, --backend, and
+// --profile; with no sizes or backend it runs the defaults on every backend
+func parseBenchArgs(args []string) (sizes []int, backends []string, profile string, err error) {
+	backends = benchBackends
+	for i := 0; i < len(args); i++ {
+		switch arg := args[i]; {
+		case arg == "--backend" || arg == "--profile":
+			if i+1 >= len(args) {
+				return nil, nil, "", fmt.Errorf("%s needs a value", arg)
+			}
+			i++
+			if arg == "--profile" {
+				profile = args[i]
+				continue
+			}
+			name := strings.ToLower(args[i])
+			if name != "json" && name != "bolt" {
+				return nil, nil, "", fmt.Errorf("unknown backend %q (use json or bolt)", args[i])
+			}
+			backends = []string{name}
+		default:
+			n, err := parseBenchSize(arg)
+			if err != nil {
+				return nil, nil, "", err
+			}
+			sizes = append(sizes, n)
+		}
+	}
+	if len(sizes) == 0 {
+		sizes = defaultBenchSizes
+	}
+	return sizes, backends, profile, nil
+}
+
+// parseBenchSize reads a task count, with k for thousands
+func parseBenchSize(s string) (int, error) {
+	digits, thousands := strings.CutSuffix(strings.ToLower(s), "k")
+	n, err := strconv.Atoi(digits)
+	if thousands {
+		n *= 1000
+	}
+	if err != nil || n < 1 || n > maxBenchSize {
+		return 0, fmt.Errorf("invalid dataset size %q (a task count from 1 to 1000k, like 500 or 10k)", s)
+	}
+	return n, nil
+}
+
+// runBench measures each backend at each size in a temporary directory,
+// printing a row as each finishes, and writes a CPU profile of the whole run
+// when asked
+func runBench(sizes []int, backends []string, profile string) error {
+	dir, err := os.MkdirTemp("", "twooms-bench-")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(dir)
+
+	if profile != "" {
+		f, err := os.Create(profile)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		if err := pprof.StartCPUProfile(f); err != nil {
+			return err
+		}
+		defer pprof.StopCPUProfile()
+	}
+
+	current := currentBackend()
+	fmt.Println("Timing synthetic datasets in a temporary directory; your data isn't touched.")
+	fmt.Printf("\n  %-8s %9s %9s %9s %9s %9s %9s %9s %9s\n", "Backend", "Tasks", "Create", "Load", "Save", "Resolve", "List", "List all", "Size")
+	for _, size := range sizes {
+		for _, backend := range backends {
+			path := filepath.Join(dir, fmt.Sprintf("%s-%d", backend, size))
+			r, err := storage.Bench(func() (storage.Store, error) { return openBenchStore(backend, path) }, size)
+			if err != nil {
+				return fmt.Errorf("%s with %d tasks: %w", backend, size, err)
+			}
+			name := backend
+			if backend == current {
+				name += "*"
+			}
+			row := fmt.Sprintf("  %-8s %9d", name, r.Tasks)
+			for _, cell := range []string{formatLatency(r.Create), formatLatency(r.Load), formatLatency(r.Save),
+				formatLatency(r.Resolve), formatLatency(r.List), formatLatency(r.ListAll), formatMB(r.Size)} {
+				// %9s would count the two bytes of µ as two columns
+				row += strings.Repeat(" ", 10-storage.TextWidth(cell)) + cell
+			}
+			fmt.Println(row)
+		}
+	}
+	fmt.Printf("\n* the backend in use (set TWOOMS_STORAGE=bolt for bbolt). Save is one small edit; the JSON store rewrites the whole file for each.\n")
+	if profile != "" {
+		fmt.Printf("CPU profile written to %s (view it with go tool pprof)\n", profile)
+	}
+	return nil
+}
+
+// openBenchStore opens an empty store of the given backend at path
+func openBenchStore(backend, path string) (storage.Store, error) {
+	if backend == "bolt" {
+		return storage.NewBoltStore(path + ".db")
+	}
+	return storage.NewJSONStore(path + ".json")
+}
+
+// currentBackend names the backend of the open store
+func currentBackend() string {
+	store := GetStore()
+	if backed, ok := store.(*storage.AutoBackupStore); ok {
+		store = backed.Store
+	}
+	if _, ok := store.(*storage.BoltStore); ok {
+		return "bolt"
+	}
+	return "json"
+}
+
+// formatLatency shows a duration with the precision a table of timings needs
+func formatLatency(d time.Duration) string {
+	switch {
+	case d < time.Millisecond:
+		return fmt.Sprintf("%dµs", d.Microseconds())
+	case d < time.Second:
+		return fmt.Sprintf("%.1fms", float64(d)/float64(time.Millisecond))
+	default:
+		return fmt.Sprintf("%.2fs", d.Seconds())
+	}
+}
@@ -0,0 +1,56 @@
+package commands
+
+import (
+	"fmt"
+	"strings"
+
+	"twooms/tui"
+)
+
+func init() {
+	Register(&Command{
+		Name:        "/board",
+		Description: "Open the full-screen dashboard of projects, tasks, and today's schedule",
+		Examples: []string{
+			"/board",
+		},
+		Access:      AccessRead,
+		Hidden:      true,
+		Interactive: true,
+		Handler:     runBoard,
+	})
+
+	// Alias, matching `twooms tui`
+	Register(&Command{
+		Name:        "/tui",
+		Description: "Open the full-screen dashboard (same as /board)",
+		Access:      AccessRead,
+		Hidden:      true,
+		Interactive: true,
+		Handler:     runBoard,
+	})
+}
+
+// runBoard shows the board until it's closed. The board owns the terminal
+// meanwhile, so commands run from it can't prompt: interactive ones are
+// refused, and ones /confirm covers need --yes, as in single-shot mode.
+func runBoard(args []string) bool {
+	reader := lineReader
+	lineReader = nil
+	defer func() { lineReader = reader }()
+
+	quit := false
+	err := tui.Run(GetStore(), func(line string) (bool, string, error) {
+		if IsInteractive(line) {
+			return false, "", fmt.Errorf("%s is interactive and can't run from the board; press q and run it at the prompt", strings.Fields(line)[0])
+		}
+		q, output, err := ExecuteWithOutput(line)
+		quit = quit || q
+		return q, output, err
+	})
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		return false
+	}
+	return quit
+}
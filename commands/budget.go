@@ -0,0 +1,187 @@
+package commands
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"twooms/llm"
+)
+
+// defaultRequestWarn is the cost in dollars above which a single LLM request
+// prints a warning, unless TWOOMS_REQUEST_WARN says otherwise
+const defaultRequestWarn = 0.10
+
+// budgetWarnShare is how much of the monthly budget is used before a warning
+const budgetWarnShare = 0.8
+
+// usageMonth is one month's LLM usage in the ledger
+type usageMonth struct {
+	Prompts      int     `json:"prompts"`
+	InputTokens  int64   `json:"input_tokens"`
+	OutputTokens int64   `json:"output_tokens"`
+	Cost         float64 `json:"cost"`
+}
+
+var (
+	// usageLedger is LLM usage by month ("2006-01"), kept across sessions
+	// and workspaces in usageLedgerPath
+	usageLedger     = make(map[string]*usageMonth)
+	usageLedgerPath string
+
+	// budgetOverride lets LLM requests through for the rest of the session
+	// once the monthly budget is used up (/usage override)
+	budgetOverride bool
+)
+
+// LoadUsageLedger reads the monthly usage ledger from path, where usage is
+// saved from then on; a missing file means no usage yet
+func LoadUsageLedger(path string) error {
+	usageLedgerPath = path
+	usageLedger = make(map[string]*usageMonth)
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(data, &usageLedger); err != nil {
+		usageLedger = make(map[string]*usageMonth)
+		return fmt.Errorf("invalid usage file %s: %w", path, err)
+	}
+	return nil
+}
+
+// monthKey is the ledger key for the month of t
+func monthKey(t time.Time) string {
+	return t.Format("2006-01")
+}
+
+// monthUsage returns this month's usage so far
+func monthUsage() usageMonth {
+	if m := usageLedger[monthKey(time.Now())]; m != nil {
+		return *m
+	}
+	return usageMonth{}
+}
+
+// monthlyBudget is the dollar limit on LLM spending per calendar month from
+// TWOOMS_MONTHLY_BUDGET, or 0 when there is none
+func monthlyBudget() float64 {
+	budget, err := strconv.ParseFloat(strings.TrimPrefix(strings.TrimSpace(os.Getenv("TWOOMS_MONTHLY_BUDGET")), "$"), 64)
+	if err != nil || budget < 0 {
+		return 0
+	}
+	return budget
+}
+
+// requestWarn is the cost of a single request that prints a warning, from
+// TWOOMS_REQUEST_WARN (default $0.10; 0 turns it off)
+func requestWarn() float64 {
+	s := strings.TrimPrefix(strings.TrimSpace(os.Getenv("TWOOMS_REQUEST_WARN")), "$")
+	if s == "" {
+		return defaultRequestWarn
+	}
+	warn, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return defaultRequestWarn
+	}
+	return warn
+}
+
+// formatCost prints a dollar amount with enough digits for tiny requests
+func formatCost(cost float64) string {
+	if cost < 0.01 {
+		return fmt.Sprintf("$%.6f", cost)
+	}
+	return fmt.Sprintf("$%.4f", cost)
+}
+
+// checkBudget returns an error when the monthly budget is used up and the
+// session hasn't overridden it. Every command that calls the LLM checks it
+// first; the offline assistant costs nothing and isn't limited.
+func checkBudget() error {
+	budget := monthlyBudget()
+	if budget == 0 || budgetOverride {
+		return nil
+	}
+	if spent := monthUsage().Cost; spent >= budget {
+		return fmt.Errorf("the monthly LLM budget of $%.2f is used up (%s spent this month); /usage override allows requests for the rest of this session, or raise TWOOMS_MONTHLY_BUDGET", budget, formatCost(spent))
+	}
+	return nil
+}
+
+// recordMonthlyUsage adds an LLM response's usage to this month's ledger,
+// saves it, and warns about an expensive request or the budget running out.
+// The file is read again first, so sessions running side by side all count.
+func recordMonthlyUsage(response *llm.Response) {
+	if response.InputTokens == 0 && response.OutputTokens == 0 && response.Cost == 0 {
+		return
+	}
+	if usageLedgerPath != "" {
+		if err := LoadUsageLedger(usageLedgerPath); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: %v (starting a new usage ledger)\n", err)
+		}
+	}
+
+	key := monthKey(time.Now())
+	month := usageLedger[key]
+	if month == nil {
+		month = &usageMonth{}
+		usageLedger[key] = month
+	}
+	before := month.Cost
+	month.Prompts++
+	month.InputTokens += response.InputTokens
+	month.OutputTokens += response.OutputTokens
+	month.Cost += response.Cost
+
+	if usageLedgerPath != "" {
+		data, err := json.MarshalIndent(usageLedger, "", "  ")
+		if err == nil {
+			err = os.WriteFile(usageLedgerPath, data, 0600)
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: could not save usage: %v\n", err)
+		}
+	}
+
+	if warn := requestWarn(); warn > 0 && response.Cost >= warn {
+		fmt.Printf("Warning: that request cost %s, more than the %s warning threshold (TWOOMS_REQUEST_WARN)\n", formatCost(response.Cost), formatCost(warn))
+	}
+	budget := monthlyBudget()
+	switch {
+	case budget == 0:
+	case month.Cost >= budget && before < budget:
+		fmt.Printf("Warning: the monthly LLM budget of $%.2f is used up (%s spent); further requests are refused until next month unless you run /usage override\n", budget, formatCost(month.Cost))
+	case month.Cost >= budget*budgetWarnShare && before < budget*budgetWarnShare:
+		fmt.Printf("Warning: %s of the $%.2f monthly LLM budget used\n", formatCost(month.Cost), budget)
+	}
+}
+
+// printMonthUsage prints the month-to-date ledger and budget for /usage
+func printMonthUsage() {
+	month := monthUsage()
+	fmt.Printf("Month to date (%s):\n", time.Now().Format("January 2006"))
+	fmt.Printf("  Prompts:       %d\n", month.Prompts)
+	fmt.Printf("  Total tokens:  %d\n", month.InputTokens+month.OutputTokens)
+	fmt.Printf("  Total cost:    %s\n", formatCost(month.Cost))
+
+	budget := monthlyBudget()
+	switch {
+	case budget == 0:
+		fmt.Println("  Budget:        none (set TWOOMS_MONTHLY_BUDGET, e.g. 5.00)")
+	case month.Cost >= budget && budgetOverride:
+		fmt.Printf("  Budget:        $%.2f, used up (overridden for this session)\n", budget)
+	case month.Cost >= budget:
+		fmt.Printf("  Budget:        $%.2f, used up; /usage override allows requests for this session\n", budget)
+	default:
+		fmt.Printf("  Budget:        $%.2f (%.0f%% used, $%.2f left)\n", budget, 100*month.Cost/budget, budget-month.Cost)
+	}
+}
@@ -0,0 +1,160 @@
+package commands
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"twooms/config"
+	"twooms/storage"
+)
+
+// calendarTimeout bounds fetching busy times, so /plan doesn't hang on a
+// slow calendar server
+const calendarTimeout = 15 * time.Second
+
+// calendarQuery asks a CalDAV server for the events in a time range, with
+// recurring ones expanded by the server (RFC 4791 section 7.8)
+const calendarQuery = `<?xml version="1.0" encoding="utf-8"?>
+<C:calendar-query xmlns:D="DAV:" xmlns:C="urn:ietf:params:xml:ns:caldav">
+  <D:prop>
+    <C:calendar-data><C:expand start="%[1]s" end="%[2]s"/></C:calendar-data>
+  </D:prop>
+  <C:filter>
+    <C:comp-filter name="VCALENDAR">
+      <C:comp-filter name="VEVENT"><C:time-range start="%[1]s" end="%[2]s"/></C:comp-filter>
+    </C:comp-filter>
+  </C:filter>
+</C:calendar-query>`
+
+// workday returns the free-for-tasks part of now's day: from now (rounded
+// up to 5 minutes) or the configured start, whichever is later, to the end
+func workday(cal *config.CalendarConfig, now time.Time) (from, to time.Time, err error) {
+	clock := func(setting, fallback string) (time.Time, error) {
+		if setting == "" {
+			setting = fallback
+		}
+		t, err := time.Parse("15:04", setting)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("invalid calendar workday time %q (use HH:MM)", setting)
+		}
+		return time.Date(now.Year(), now.Month(), now.Day(), t.Hour(), t.Minute(), 0, 0, now.Location()), nil
+	}
+	start, err := clock(cal.DayStart, "09:00")
+	if err != nil {
+		return
+	}
+	if to, err = clock(cal.DayEnd, "17:00"); err != nil {
+		return
+	}
+
+	from = now.Truncate(5 * time.Minute)
+	if from.Before(now) {
+		from = from.Add(5 * time.Minute)
+	}
+	if from.Before(start) {
+		from = start
+	}
+	return from, to, nil
+}
+
+// busyTimes returns the configured calendar's events between from and to
+func busyTimes(cal *config.CalendarConfig, from, to time.Time) ([]storage.Busy, error) {
+	ctx, cancel := context.WithTimeout(commandContext(), calendarTimeout)
+	defer cancel()
+
+	if strings.EqualFold(cal.Type, "caldav") {
+		return caldavBusy(ctx, cal, from, to)
+	}
+	if cal.Type != "" && !strings.EqualFold(cal.Type, "ics") {
+		return nil, fmt.Errorf("unknown calendar type %q (use ics or caldav)", cal.Type)
+	}
+
+	url := cal.URL
+	if strings.HasPrefix(url, "webcal://") {
+		url = "https://" + strings.TrimPrefix(url, "webcal://")
+	}
+	if !strings.HasPrefix(url, "http://") && !strings.HasPrefix(url, "https://") {
+		f, err := os.Open(url)
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+		return storage.ParseBusyICS(f, from, to)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	body, err := calendarRequest(cal, req)
+	if err != nil {
+		return nil, err
+	}
+	return storage.ParseBusyICS(bytes.NewReader(body), from, to)
+}
+
+// caldavBusy runs a calendar-query REPORT and reads the events in each
+// calendar object it returns
+func caldavBusy(ctx context.Context, cal *config.CalendarConfig, from, to time.Time) ([]storage.Busy, error) {
+	query := fmt.Sprintf(calendarQuery, from.UTC().Format("20060102T150405Z"), to.UTC().Format("20060102T150405Z"))
+	req, err := http.NewRequestWithContext(ctx, "REPORT", cal.URL, strings.NewReader(query))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/xml; charset=utf-8")
+	req.Header.Set("Depth", "1")
+	body, err := calendarRequest(cal, req)
+	if err != nil {
+		return nil, err
+	}
+
+	var busy []storage.Busy
+	dec := xml.NewDecoder(bytes.NewReader(body))
+	for {
+		tok, err := dec.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid CalDAV response: %w", err)
+		}
+		start, ok := tok.(xml.StartElement)
+		if !ok || start.Name.Local != "calendar-data" {
+			continue
+		}
+		var data string
+		if err := dec.DecodeElement(&data, &start); err != nil {
+			return nil, fmt.Errorf("invalid CalDAV response: %w", err)
+		}
+		events, err := storage.ParseBusyICS(strings.NewReader(data), from, to)
+		if err != nil {
+			return nil, err
+		}
+		busy = append(busy, events...)
+	}
+	return busy, nil
+}
+
+// calendarRequest sends a request with the configured credentials and
+// returns the body of a successful response
+func calendarRequest(cal *config.CalendarConfig, req *http.Request) ([]byte, error) {
+	if cal.Username != "" {
+		req.SetBasicAuth(cal.Username, os.Getenv(cal.PasswordEnv))
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("fetching calendar: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		return nil, fmt.Errorf("fetching calendar: %s", resp.Status)
+	}
+	return io.ReadAll(resp.Body)
+}
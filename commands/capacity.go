@@ -0,0 +1,63 @@
+package commands
+
+import (
+	"fmt"
+	"time"
+
+	"twooms/storage"
+)
+
+func init() {
+	Register(&Command{
+		Name:        "/capacity",
+		Description: "Show how much work is due and how much time is free on each of the next seven days. Call this before picking a due date for vague requests like \"sometime this week\".",
+		Access:      AccessRead,
+		Params: []Param{
+			{Name: "hours", Type: ParamTypeString, Description: "Time available per day (e.g., 6, 90m); defaults to the configured daily capacity (4h unless set)", Required: false},
+		},
+		Handler: func(args []string) bool {
+			capacity := dailyCapacity()
+			if len(args) > 0 {
+				minutes, err := parseBudget(args[0])
+				if err != nil {
+					fmt.Printf("Error: %v\n", err)
+					return false
+				}
+				capacity = minutes
+			}
+
+			tasks, err := GetStore().ListAllTasks()
+			if err != nil {
+				fmt.Printf("Error listing tasks: %v\n", err)
+				return false
+			}
+			printCapacity(storage.NewWeekPlan(tasks, capacity, time.Now()))
+			return false
+		},
+	})
+}
+
+// printCapacity lists each day's load and free time with its date, so the
+// model can pass the least loaded day straight to /due
+func printCapacity(week *storage.WeekPlan) {
+	fmt.Printf("Capacity for the next 7 days (%s per day; unestimated tasks count as 30m):\n", storage.FormatMinutes(week.Capacity))
+
+	best := week.Days[0]
+	for i, day := range week.Days {
+		load := week.Load(day)
+		free := max(week.Capacity-load, 0)
+		label := ""
+		switch i {
+		case 0:
+			label = " (today)"
+		case 1:
+			label = " (tomorrow)"
+		}
+		fmt.Printf("  %s%s: %s due, %s free\n", day.Format("Mon 2006-01-02"), label, storage.FormatMinutes(load), storage.FormatMinutes(free))
+
+		if load < week.Load(best) {
+			best = day
+		}
+	}
+	fmt.Printf("Least loaded: %s\n", best.Format("Mon 2006-01-02"))
+}
@@ -0,0 +1,64 @@
+package commands
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Access is what a command does to the data, for channel policies. The zero
+// value is the most restricted, so commands that don't say need full access.
+type Access int
+
+const (
+	AccessWrite  Access = iota // changes or deletes existing data
+	AccessRead                 // only reads (or, like /chat, only runs tools the policy allows)
+	AccessCreate               // only adds projects and tasks
+)
+
+// Policy is what an integration channel (a Slack bot, a Telegram bot, an
+// API) may do, whatever its LLM decides to call
+type Policy string
+
+const (
+	PolicyReadOnly   Policy = "read-only"   // list and search, nothing else
+	PolicyCreateOnly Policy = "create-only" // also add projects and tasks
+	PolicyFull       Policy = "full"        // everything the REPL can do
+)
+
+// Allows reports whether the policy lets a command with this access run
+func (p Policy) Allows(a Access) bool {
+	switch p {
+	case PolicyFull:
+		return true
+	case PolicyCreateOnly:
+		return a == AccessRead || a == AccessCreate
+	default:
+		return a == AccessRead
+	}
+}
+
+// channelName and channelPolicy are the channel commands are running for;
+// the terminal is the "repl" channel, which may do everything
+var (
+	channelName   = "repl"
+	channelPolicy = PolicyFull
+)
+
+// SetChannel runs commands for the named channel from now on, with the policy
+// the config's channels gives it. Commands it doesn't allow refuse to run and
+// aren't offered to the LLM as tools. A channel missing from the config is an
+// error, so a typo can't quietly get more access than intended.
+func SetChannel(name string) error {
+	setting, ok := GetConfig().Channels[name]
+	if !ok {
+		return fmt.Errorf("unknown channel %q (add it to channels in ~/.twooms.config.json)", name)
+	}
+	policy := Policy(strings.ToLower(setting))
+	switch policy {
+	case PolicyReadOnly, PolicyCreateOnly, PolicyFull:
+	default:
+		return fmt.Errorf("invalid policy %q for channel %s (use read-only, create-only, or full)", setting, name)
+	}
+	channelName, channelPolicy = name, policy
+	return nil
+}
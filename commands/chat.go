@@ -3,22 +3,31 @@ package commands
 import (
 	"bytes"
 	"context"
+	"errors"
 	"fmt"
 	"io"
 	"os"
+	"regexp"
+	"strconv"
 	"strings"
 	"time"
 
+	"twooms/i18n"
 	"twooms/llm"
+	"twooms/storage"
 )
 
 // chatHistory stores the conversation history for the /chat command
 var chatHistory []*llm.Message
 
+// chatRunning is true while /chat is executing tool calls for the model
+var chatRunning bool
+
 // Session usage tracking
 var (
 	sessionInputTokens  int64
 	sessionOutputTokens int64
+	sessionCachedTokens int64 // input tokens read from the provider's prompt cache
 	sessionCost         float64
 	sessionPromptCount  int
 )
@@ -29,36 +38,99 @@ const maxCommandContextEntries = 10
 // commandContextPrefix identifies command context messages in history
 const commandContextPrefix = "[Command executed]"
 
-// getSystemPrompt returns the system prompt with today's date and tool-use instructions
-func getSystemPrompt() string {
-	today := time.Now().Format("2006-01-02") // YYYY-MM-DD format
-	weekday := time.Now().Weekday().String()
-
-	return fmt.Sprintf(`You are a helpful task management assistant for Twooms, a terminal-based task manager.
-
-TODAY'S DATE: %s (%s)
+// systemRules is the start of the system prompt, which never changes, so
+// providers with prompt caching can reuse it (and the tool definitions sent
+// before it) from one request to the next. Anything that changes between
+// turns goes after it, in getSystemPrompt.
+const systemRules = `You are a helpful task management assistant for Twooms, a terminal-based task manager.
 
 IMPORTANT RULES:
 1. When a user refers to a project by NAME (not ID), FIRST call "projects" to find its shortcut, then use that shortcut as the project_id.
 2. Projects have SHORTCUTS (shown in brackets in the projects list, e.g. [a], [work]). Always use the shortcut as the project_id parameter when calling tools like "task" or "tasks". Shortcuts are valid project IDs.
 3. When a user refers to a task by NAME, FIRST call the listing tool to find the task's ID.
 4. NEVER ask the user for an ID. Always look it up using available tools.
-5. When users refer to "that task" or "the project I just created", use context from [Command executed] messages.
-6. When setting due dates: "today" = %s, "tomorrow" = the next day, etc.
+5. When users refer to "that task" or "the project I just created", use context from [Command executed] messages and the [Conversation summary] of earlier turns.
+6. When setting due dates: "today" = TODAY'S DATE below, "tomorrow" = the next day, etc.
 7. Tool outputs are ALREADY shown to the user. After using tools, just say "Done." or give a one-sentence summary. Do NOT repeat or list the tool output.
 8. Be concise since this is a terminal application.
 9. When creating a task and setting its properties (duration, due date), call "task" FIRST and wait for the result to get the task ID, then call duration/due with that ID. Do NOT call them in parallel.
-10. ALWAYS attempt tool calls when asked to perform actions. Never refuse by saying a project or task doesn't exist without first trying the tool call.`, today, weekday, today)
+10. ALWAYS attempt tool calls when asked to perform actions. Never refuse by saying a project or task doesn't exist without first trying the tool call.
+11. When the user leaves the day open ("sometime this week", "when I have time"), call "capacity" first and set the due date to an underloaded day with enough free time for the task, instead of defaulting to tomorrow.
+12. When asked what to do now or next, call "now" for the current time, the running timer, and tasks due at a time today, and account for them (e.g. "your 15:00 task is due in 20 minutes").
+13. When the user wants to jot a task down without saying which project, or none fits, call "in" to capture it in the inbox. Use "move" to file inbox tasks into a project later.
+14. When asked what was finished or done (today, yesterday, this week, for a standup or timesheet), call "log" with that period, and the project if one is named.
+15. When a tool result says a change was planned, it has NOT been made yet and must not be called again. Plan every change the request needs, referring to what earlier steps create by their placeholders (new-1, new-project-1), then sum up the plan in a sentence; the user approves it before anything runs.
+16. When asked about one task (when it's due, its note, what blocks it, how long was spent on it), call "task_get" with its ID instead of listing tasks.`
+
+// getSystemPrompt returns the system prompt: the fixed rules and persona
+// (promptRules), then the current date and time, the project snapshot, and tasks due soon
+func getSystemPrompt() string {
+	now := time.Now()
+	today := now.Format("2006-01-02") // YYYY-MM-DD format
+	weekday := now.Weekday().String()
+
+	return promptRules() + fmt.Sprintf(`
+
+TODAY'S DATE: %s (%s)
+CURRENT TIME: %s%s%s`, today, weekday, now.Format("15:04"), getStoreSnapshot(), upcomingReminders(now))
+}
+
+// getStoreSnapshot returns a compact overview of projects for the system prompt,
+// so the model knows shortcuts and deadlines without an extra tool call, and
+// the task in focus
+func getStoreSnapshot() string {
+	if GetStore() == nil {
+		return ""
+	}
+	if focusProjectID != "" {
+		return focusSnapshot() + focusTaskSnapshot()
+	}
+	projects, err := GetStore().ListProjects()
+	if err != nil {
+		return ""
+	}
+	inbox, _ := GetStore().ListTasks("")
+	if len(projects) == 0 && len(inbox) == 0 {
+		return ""
+	}
+
+	var b strings.Builder
+	b.WriteString("\n\nCURRENT PROJECTS:")
+	for _, p := range projects {
+		tasks, _ := GetStore().ListTasks(p.ID)
+		fmt.Fprintf(&b, "\n- [%s] %s (%d open tasks)%s", p.Shortcut, p.Name, countOpen(tasks), stripColors(formatProjectDue(p, tasks)))
+	}
+	if open := countOpen(inbox); open > 0 {
+		fmt.Fprintf(&b, "\n- Inbox, tasks with no project yet (%d open tasks; list them with inbox, file them with move)", open)
+	}
+	return b.String() + focusTaskSnapshot()
+}
+
+// countOpen counts the tasks that aren't done
+func countOpen(tasks []*storage.Task) int {
+	open := 0
+	for _, t := range tasks {
+		if !t.Done {
+			open++
+		}
+	}
+	return open
 }
 
-// ensureSystemPrompt adds the system prompt if chat history is empty
+// ensureSystemPrompt adds the system prompt if chat history lacks one (it is
+// empty, or was loaded from a saved conversation), or refreshes it so the
+// date and project snapshot stay current
 func ensureSystemPrompt() {
-	if len(chatHistory) == 0 {
-		chatHistory = append(chatHistory, &llm.Message{
-			Role:    "system",
-			Content: getSystemPrompt(),
-		})
+	if len(chatHistory) == 0 || chatHistory[0].Role != "system" {
+		chatHistory = append([]*llm.Message{{
+			Role:        "system",
+			Content:     getSystemPrompt(),
+			CachePrefix: len(promptRules()),
+		}}, chatHistory...)
+		return
 	}
+	chatHistory[0].Content = getSystemPrompt()
+	chatHistory[0].CachePrefix = len(promptRules())
 }
 
 // AddCommandContext adds a direct command and its output to the chat history
@@ -66,7 +138,7 @@ func ensureSystemPrompt() {
 func AddCommandContext(command string, output string) {
 	ensureSystemPrompt()
 
-	contextMsg := fmt.Sprintf("%s %s\nResult: %s", commandContextPrefix, command, output)
+	contextMsg := fmt.Sprintf("%s %s\nResult: %s", commandContextPrefix, command, stripColors(output))
 	chatHistory = append(chatHistory, &llm.Message{
 		Role:    "user",
 		Content: contextMsg,
@@ -117,10 +189,14 @@ func init() {
 	Register(&Command{
 		Name:        "/clearchat",
 		Shorthand:   "/cc",
-		Description: "Clear the chat conversation history",
+		Description: "Start a new chat conversation (for the focused project, if any); the old one stays in /sessions",
 		Hidden:      true,
 		Handler: func(args []string) bool {
+			// The cleared conversation stays in /sessions; the next chat starts a new one
+			saveChatSession()
 			chatHistory = nil
+			currentSession = nil
+			pendingPlan = nil
 			fmt.Println("Chat history cleared.")
 			return false
 		},
@@ -129,28 +205,44 @@ func init() {
 	Register(&Command{
 		Name:        "/usage",
 		Shorthand:   "/u",
-		Description: "Show session token usage and cost statistics",
+		Description: "Show token usage and cost for this session and the month, or allow requests past the monthly budget",
+		Examples:    []string{"/usage", "/usage override"},
+		Access:      AccessRead,
 		Hidden:      true,
 		Handler: func(args []string) bool {
-			if sessionPromptCount == 0 {
-				fmt.Println("No chat usage in this session yet.")
+			if len(args) > 0 {
+				if !strings.EqualFold(args[0], "override") {
+					fmt.Println(i18n.T("usage.usage"))
+					return false
+				}
+				budgetOverride = true
+				fmt.Println("LLM requests are allowed past the monthly budget for the rest of this session.")
 				return false
 			}
 
-			fmt.Println("Session Usage Statistics:")
-			fmt.Printf("  Prompts:       %d\n", sessionPromptCount)
-			fmt.Printf("  Input tokens:  %d\n", sessionInputTokens)
-			fmt.Printf("  Output tokens: %d\n", sessionOutputTokens)
-			fmt.Printf("  Total tokens:  %d\n", sessionInputTokens+sessionOutputTokens)
-			if sessionCost > 0 {
-				if sessionCost < 0.01 {
-					fmt.Printf("  Total cost:    $%.6f\n", sessionCost)
+			if sessionPromptCount == 0 {
+				fmt.Println("No chat usage in this session yet.")
+			} else {
+				fmt.Println("Session Usage Statistics:")
+				fmt.Printf("  Prompts:       %d\n", sessionPromptCount)
+				fmt.Printf("  Input tokens:  %d\n", sessionInputTokens)
+				fmt.Printf("  Output tokens: %d\n", sessionOutputTokens)
+				fmt.Printf("  Total tokens:  %d\n", sessionInputTokens+sessionOutputTokens)
+				// Cached input is billed at a discount (a tenth of the price on
+				// Anthropic models), so this is how much of the prompt was cheap
+				if sessionCachedTokens > 0 {
+					fmt.Printf("  Cached input:  %d (%.0f%% of input tokens)\n", sessionCachedTokens, 100*float64(sessionCachedTokens)/float64(sessionInputTokens))
 				} else {
-					fmt.Printf("  Total cost:    $%.4f\n", sessionCost)
+					fmt.Println("  Cached input:  none")
+				}
+				if sessionCost > 0 {
+					fmt.Printf("  Total cost:    %s\n", formatCost(sessionCost))
+				} else {
+					fmt.Println("  Total cost:    no data")
 				}
-			} else {
-				fmt.Println("  Total cost:    no data")
 			}
+			fmt.Println()
+			printMonthUsage()
 			return false
 		},
 	})
@@ -159,31 +251,86 @@ func init() {
 		Name:        "/chat",
 		Shorthand:   "/c",
 		Description: "Chat with the AI assistant",
+		Examples:    []string{"/chat what's due this week?", "/chat --dry-run move everything tagged errands to Home", "/chat --plan set up my tax project with 8 tasks and staggered deadlines"},
+		Access:      AccessRead,
 		Hidden:      true, // Exclude from tool generation
+		RawArgs:     true, // Messages are prose, so quotes stay as typed
 		Params: []Param{
 			{Name: "message", Type: ParamTypeString, Description: "The message to send to the assistant", Required: true},
 		},
 		Handler: func(args []string) bool {
 			if len(args) == 0 {
-				fmt.Println("Usage: /chat <message>")
+				fmt.Println(i18n.T("chat.usage"))
 				return false
 			}
 
+			message := strings.Join(args, " ")
+
+			// A plan waiting for approval runs on a yes; anything else
+			// drops it and is sent as usual
+			if pendingPlan != nil {
+				plan := pendingPlan
+				pendingPlan = nil
+				if isApproval(message) {
+					runChatPlan(plan)
+					return false
+				}
+				declineChatPlan()
+			}
+
+			// A dry run describes the changes tools would make instead of making them
+			var dry *dryRun
+			if rest, ok := strings.CutPrefix(message, dryRunFlag); ok && (rest == "" || rest[0] == ' ') {
+				message = strings.TrimSpace(rest)
+				dry = newDryRun()
+				if message == "" {
+					fmt.Println(i18n.T("chat.usage_dry_run"))
+					return false
+				}
+			} else if dryRunMode {
+				dry = newDryRun()
+			}
+
+			// --plan, or a request that sounds like many changes, is planned
+			// first: its changes are collected, shown, and made once approved
+			forcePlan := false
+			if rest, ok := strings.CutPrefix(message, planFlag); ok && (rest == "" || rest[0] == ' ') {
+				message = strings.TrimSpace(rest)
+				forcePlan = true
+				if message == "" {
+					fmt.Println(i18n.T("chat.usage_plan"))
+					return false
+				}
+			}
+
 			client := GetLLMClient()
 			if client == nil {
-				fmt.Println("Error: LLM client not available. Set OPENROUTER_API_KEY environment variable.")
+				offlineChat(message, dry != nil)
+				return false
+			}
+			if err := checkBudget(); err != nil {
+				fmt.Printf("Error: %v\n", err)
 				return false
 			}
+			var plan *chatPlan
+			if dry == nil && (forcePlan || planWorthy(message)) {
+				plan = newChatPlan()
+			}
 
 			// Ensure system prompt is present
 			ensureSystemPrompt()
 
-			message := strings.Join(args, " ")
 			tools := GenerateToolDefinitions()
+			if focusProjectID != "" {
+				tools = focusTools(tools)
+			}
 
 			// Sync debug mode with the LLM client
 			client.SetDebug(IsDebugMode())
 
+			// Long conversations have their older turns summarized
+			compactChatHistory(client)
+
 			if IsDebugMode() {
 				fmt.Printf("[DEBUG] Chat history: %d messages\n", len(chatHistory))
 				fmt.Printf("[DEBUG] Available tools: %d\n", len(tools))
@@ -191,19 +338,42 @@ func init() {
 
 			// Create the tool executor that runs commands and captures output
 			executor := func(name string, fnArgs map[string]any) string {
-				// Convert function arguments to command args slice
-				cmdArgs := convertArgsToSlice(name, fnArgs)
+				// Keep focused chats inside their project
+				if focusProjectID != "" {
+					if msg := checkFocus(tools, name, fnArgs); msg != "" {
+						fmt.Println(msg)
+						return msg
+					}
+				}
+
+				// Catch bad arguments before they reach the command, with an error the model can act on
+				if msg := validateToolArgs(tools, name, fnArgs); msg != "" {
+					fmt.Println(msg)
+					return msg
+				}
+
+				if msg := safeModeRefusal(name); msg != "" {
+					fmt.Println(msg)
+					return msg
+				}
+				if dry != nil {
+					if msg := dry.intercept(name, fnArgs); msg != "" {
+						fmt.Println(msg)
+						return msg
+					}
+				}
+				if plan != nil {
+					if msg := plan.intercept(name, fnArgs); msg != "" {
+						return msg
+					}
+				}
 
-				// Build the full command string
-				cmdStr := "/" + name
-				if len(cmdArgs) > 0 {
-					cmdStr += " " + strings.Join(cmdArgs, " ")
+				if msg := confirmRefusal(name, fnArgs); msg != "" {
+					fmt.Println(msg)
+					return msg
 				}
 
-				// Capture stdout while executing the command
-				output := captureOutput(func() {
-					Execute(cmdStr)
-				})
+				output := runTool(name, fnArgs)
 
 				// Print output immediately so user sees progress
 				if output != "" {
@@ -213,88 +383,154 @@ func init() {
 				return output
 			}
 
-			ctx := context.Background()
+			ctx := commandContext()
+			chatRunning = true
 			response, newHistory, err := client.ChatWithTools(ctx, message, chatHistory, tools, executor)
+			chatRunning = false
 			if err != nil {
+				// Ctrl-C: the turn is dropped from history, but commands it
+				// already ran stay done
+				if errors.Is(err, context.Canceled) {
+					fmt.Println("Interrupted. This message was left out of the conversation; commands it already ran were kept.")
+					return false
+				}
 				fmt.Printf("Error: %v\n", err)
 				return false
 			}
 
-			// Update conversation history
-			chatHistory = newHistory
-
 			// Only print response text if non-empty (tool outputs already printed)
 			if strings.TrimSpace(response.Text) != "" {
-				fmt.Println(response.Text)
+				fmt.Println(formatAnswer(response.Text))
+			}
+			if plan != nil {
+				plan.print()
 			}
 
 			// Display usage statistics
 			printUsageStats(response)
+
+			// The model thinks the dry run's changes were made, so the
+			// exchange stays out of the conversation
+			if dry != nil {
+				fmt.Println("Dry run: nothing was changed, and this exchange was left out of the conversation.")
+				return false
+			}
+			chatHistory = newHistory
+			saveChatSession()
+			if plan != nil && len(plan.steps) > 0 {
+				approveChatPlan(plan)
+			}
 			return false
 		},
 	})
 }
 
-// printUsageStats displays token usage and cost information and updates session totals
+// printUsageStats displays token usage and cost information and updates the
+// session totals and the monthly ledger
 func printUsageStats(response *llm.Response) {
 	// Only count if we have actual token data
 	if response.InputTokens > 0 || response.OutputTokens > 0 {
 		sessionInputTokens += response.InputTokens
 		sessionOutputTokens += response.OutputTokens
+		sessionCachedTokens += response.CachedTokens
 		sessionCost += response.Cost
 		sessionPromptCount++
+		recordSessionUsage(response)
 	}
 
 	// Always show token info (helps debug silent failures)
-	fmt.Printf("\n[Tokens: %d in / %d out", response.InputTokens, response.OutputTokens)
+	fmt.Printf("\n[Tokens: %d in", response.InputTokens)
+	if response.CachedTokens > 0 {
+		fmt.Printf(" (%d cached)", response.CachedTokens)
+	}
+	fmt.Printf(" / %d out", response.OutputTokens)
 
 	// Display cost if available
 	if response.Cost > 0 {
-		// Format cost appropriately based on magnitude
-		if response.Cost < 0.01 {
-			fmt.Printf(" | Cost: $%.6f", response.Cost)
-		} else {
-			fmt.Printf(" | Cost: $%.4f", response.Cost)
-		}
+		fmt.Printf(" | Cost: %s", formatCost(response.Cost))
 	} else {
 		fmt.Printf(" | Cost: no data")
 	}
 
 	fmt.Println("]")
+
+	// Warnings about the cost follow the usage line
+	recordMonthlyUsage(response)
+}
+
+// runTool runs a tool call and returns its output: through the command's
+// ToolHandler if it has one, or else as a command line built from the
+// arguments
+func runTool(name string, args map[string]any) string {
+	if cmd, ok := toolCommand(name); ok && cmd.ToolHandler != nil {
+		output, err := cmd.ToolHandler(args)
+		if err != nil {
+			return fmt.Sprintf("Error: %v", err)
+		}
+		return stripColors(output)
+	}
+
+	cmd, ok := toolCommand(name)
+	if !ok {
+		return fmt.Sprintf("Error: there is no tool named %s", name)
+	}
+	cmdStr := cmd.Name
+	if cmdArgs := convertArgsToSlice(name, args); len(cmdArgs) > 0 {
+		cmdStr += " " + JoinArgs(cmdArgs)
+	}
+
+	// Capture stdout while executing the command; the model gets it
+	// without colors
+	return stripColors(captureOutput(func() {
+		Execute(cmdStr)
+	}))
 }
 
-// convertArgsToSlice converts Gemini function call arguments to a string slice
-// in the order expected by the command handler
+// convertArgsToSlice converts function call arguments to a string slice in
+// the order of the command's Params, which is the order its Handler reads
+// them in. Absent optional arguments are skipped, so a command whose
+// optional arguments aren't last needs a ToolHandler.
 func convertArgsToSlice(cmdName string, args map[string]any) []string {
-	// Define the argument order for each command
-	argOrder := map[string][]string{
-		"project":    {"name"},
-		"projects":   {},
-		"delproject": {"project_id"},
-		"task":       {"project_id", "task_name"},
-		"tasks":      {"project_id"},
-		"done":       {"task_id"},
-		"undone":     {"task_id"},
-		"deltask":    {"task_id"},
-		"due":        {"task_id", "date"},
-		"duration":   {"task_id", "duration"},
-	}
-
-	order, exists := argOrder[cmdName]
+	cmd, exists := toolCommand(cmdName)
 	if !exists {
 		return nil
 	}
 
 	var result []string
-	for _, key := range order {
-		if val, ok := args[key]; ok {
-			result = append(result, fmt.Sprintf("%v", val))
+	for _, p := range cmd.Params {
+		val, ok := args[p.Name]
+		if !ok {
+			continue
+		}
+		// Boolean parameters are flags, passed only when true
+		if b, isBool := val.(bool); isBool {
+			if b {
+				result = append(result, "--"+p.Name)
+			}
+			continue
+		}
+		// JSON numbers arrive as float64; integers shouldn't print as 2e+06
+		if f, isFloat := val.(float64); isFloat && f == float64(int64(f)) {
+			result = append(result, strconv.FormatInt(int64(f), 10))
+			continue
 		}
+		result = append(result, fmt.Sprintf("%v", val))
 	}
 
 	return result
 }
 
+// stringArg returns a tool call's string argument, which must be present
+// and not blank. It's for ToolHandlers, which get the arguments unchecked
+// when called outside /chat.
+func stringArg(args map[string]any, name string) (string, error) {
+	val, ok := args[name].(string)
+	if !ok || strings.TrimSpace(val) == "" {
+		return "", fmt.Errorf("missing argument %s", name)
+	}
+	return strings.TrimSpace(val), nil
+}
+
 // captureOutput captures stdout during execution of a function
 func captureOutput(fn func()) string {
 	// Save original stdout
@@ -328,3 +564,211 @@ func captureOutput(fn func()) string {
 
 	return strings.TrimSpace(buf.String())
 }
+
+// planFlag at the start of a /chat message plans its changes before any
+// are made, however simple the request sounds
+const planFlag = "--plan"
+
+// planWorthyRequest matches requests that sound like many changes at once:
+// "8 tasks", "several projects", "set up", "break it down", "staggered"
+var planWorthyRequest = regexp.MustCompile(`(?i)\b(?:(?:[3-9]|\d{2,}|three|four|five|six|seven|eight|nine|ten|eleven|twelve|several|multiple)\s+(?:\w+\s+)?(?:tasks|steps|subtasks|items|projects|deadlines|milestones)|set\s+(?:\w+\s+)?up|break\s+(?:\w+\s+)?down|staggered)\b`)
+
+// planPlaceholder matches the IDs a plan hands out for what its steps create
+var planPlaceholder = regexp.MustCompile(`^new-(?:project-)?\d+$`)
+
+// pendingPlan is a plan shown outside the REPL, which runs if the next
+// /chat message approves it
+var pendingPlan *chatPlan
+
+// planWorthy reports whether a chat message should be planned before its
+// changes are made
+func planWorthy(message string) bool {
+	return planWorthyRequest.MatchString(message)
+}
+
+// chatPlan collects the changes the model makes in one /chat message instead
+// of making them. Read-only tools still run, so the model can look things
+// up. Once the turn ends the steps are shown, and they run in order only if
+// the user approves.
+type chatPlan struct {
+	describer *dryRun // describes steps and hands out placeholder IDs
+	steps     []*planStep
+}
+
+// planStep is one tool call in a plan
+type planStep struct {
+	name        string
+	args        map[string]any
+	text        string // what it does, e.g. `create project "Taxes"`
+	placeholder string // the ID later steps use for what it creates, if anything
+}
+
+func newChatPlan() *chatPlan {
+	return &chatPlan{describer: newDryRun()}
+}
+
+// intercept records a tool call that changes data as the next step and
+// returns what the model is told, or "" for one that only reads and should
+// run as usual
+func (p *chatPlan) intercept(name string, args map[string]any) string {
+	cmd, ok := toolCommand(name)
+	if !ok || cmd.ReadOnly() {
+		return ""
+	}
+	tasks, projects := len(p.describer.created), len(p.describer.projects)
+	step := &planStep{name: name, args: args, text: p.describer.describe(name, args)}
+	switch {
+	case len(p.describer.created) > tasks:
+		step.placeholder = fmt.Sprintf("new-%d", len(p.describer.created))
+	case len(p.describer.projects) > projects:
+		step.placeholder = fmt.Sprintf("new-project-%d", len(p.describer.projects))
+	}
+	p.steps = append(p.steps, step)
+	return fmt.Sprintf("Planned as step %d: %s. Not done yet: it runs if the user approves the plan, so don't call it again.", len(p.steps), step.text)
+}
+
+// print lists the plan's steps
+func (p *chatPlan) print() {
+	if len(p.steps) == 0 {
+		return
+	}
+	fmt.Printf("Plan (%d steps):\n", len(p.steps))
+	for i, step := range p.steps {
+		fmt.Printf("  %d. %s\n", i+1, step.text)
+	}
+}
+
+// approveChatPlan asks whether to run a plan. Outside the REPL nobody can
+// answer now, so the plan waits for the next /chat message.
+func approveChatPlan(plan *chatPlan) {
+	if lineReader == nil {
+		pendingPlan = plan
+		fmt.Println("Nothing has changed yet. Reply yes to run this plan, or with anything else to drop it.")
+		return
+	}
+	answer, err := lineReader("Run this plan? [y/N] ")
+	if err != nil || !isApproval(answer) {
+		declineChatPlan()
+		return
+	}
+	runChatPlan(plan)
+}
+
+// isApproval reports whether a reply approves a plan
+func isApproval(reply string) bool {
+	switch strings.Trim(strings.ToLower(strings.TrimSpace(reply)), ".!") {
+	case "y", "yes", "yes please", "ok", "okay", "sure", "go", "go ahead", "do it", "run it":
+		return true
+	}
+	return false
+}
+
+// declineChatPlan drops a plan, and tells the model so it can offer another
+func declineChatPlan() {
+	fmt.Println("Plan dropped. Nothing was changed.")
+	AddCommandContext("(plan declined)", "The user declined the plan, so none of its steps were run.")
+	saveChatSession()
+}
+
+// runChatPlan runs a plan's steps in order, swapping placeholders for the
+// IDs of what earlier steps created. A step that needs something that
+// wasn't created is skipped. The outputs are added to the chat history.
+func runChatPlan(plan *chatPlan) {
+	chatRunning = true
+	defer func() { chatRunning = false }()
+
+	created := make(map[string]string) // placeholder -> real ID or shortcut
+	var results []string
+	for i, step := range plan.steps {
+		args, missing := step.resolve(created)
+		var output string
+		if missing != "" {
+			output = fmt.Sprintf("Skipped: it needs %s, which wasn't created", missing)
+		} else {
+			tasks, projects := storeIDs()
+			output = runPlannedTool(step.name, args)
+			if step.placeholder != "" {
+				if id := newID(tasks, projects, step.placeholder); id != "" {
+					created[step.placeholder] = id
+				}
+			}
+		}
+		if output != "" {
+			fmt.Println(output)
+		}
+		results = append(results, fmt.Sprintf("%d. %s: %s", i+1, step.text, output))
+	}
+	fmt.Printf("Ran the %d-step plan.\n", len(plan.steps))
+	AddCommandContext("(plan approved)", strings.Join(results, "\n"))
+	saveChatSession()
+}
+
+// resolve returns the step's arguments with placeholders replaced, or the
+// first placeholder that nothing was created for
+func (s *planStep) resolve(created map[string]string) (map[string]any, string) {
+	args := make(map[string]any, len(s.args))
+	for key, val := range s.args {
+		if str, ok := val.(string); ok && planPlaceholder.MatchString(strings.TrimSpace(str)) {
+			id, ok := created[strings.TrimSpace(str)]
+			if !ok {
+				return nil, str
+			}
+			val = id
+		}
+		args[key] = val
+	}
+	return args, ""
+}
+
+// runPlannedTool runs an approved step as /chat runs a tool call; safe mode
+// and the confirmation policy still apply
+func runPlannedTool(name string, args map[string]any) string {
+	if msg := safeModeRefusal(name); msg != "" {
+		return msg
+	}
+	if msg := confirmRefusal(name, args); msg != "" {
+		return msg
+	}
+	return runTool(name, args)
+}
+
+// storeIDs returns the IDs of every task and project, to spot what a step
+// creates
+func storeIDs() (tasks, projects map[string]bool) {
+	tasks, projects = make(map[string]bool), make(map[string]bool)
+	if all, err := GetStore().ListAllTasks(); err == nil {
+		for _, t := range all {
+			tasks[t.ID] = true
+		}
+	}
+	if all, err := GetStore().ListProjects(); err == nil {
+		for _, p := range all {
+			projects[p.ID] = true
+		}
+	}
+	return tasks, projects
+}
+
+// newID returns the ID of the task, or the shortcut of the project, that
+// appeared since storeIDs was called, or "" if none did
+func newID(tasks, projects map[string]bool, placeholder string) string {
+	if strings.HasPrefix(placeholder, "new-project-") {
+		all, _ := GetStore().ListProjects()
+		for _, p := range all {
+			if !projects[p.ID] {
+				if p.Shortcut != "" {
+					return p.Shortcut
+				}
+				return p.ID
+			}
+		}
+		return ""
+	}
+	all, _ := GetStore().ListAllTasks()
+	for _, t := range all {
+		if !tasks[t.ID] {
+			return t.ID
+		}
+	}
+	return ""
+}
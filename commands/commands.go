@@ -6,7 +6,11 @@ import (
 	"io"
 	"os"
 	"strings"
+	"sync"
+	"unicode"
 
+	"twooms/config"
+	"twooms/i18n"
 	"twooms/llm"
 	"twooms/storage"
 )
@@ -15,15 +19,30 @@ import (
 type ParamType string
 
 const (
-	ParamTypeString ParamType = "string"
+	ParamTypeString  ParamType = "string"
+	ParamTypeInteger ParamType = "integer"
+	ParamTypeBoolean ParamType = "boolean" // passed to the handler as a --name flag when true
 )
 
+// ParamFormatDate marks a string parameter that takes a YYYY-MM-DD date, or
+// "none" to clear it; tool calls are checked against it before they run
+const ParamFormatDate = "date"
+
+// ParamFormatTime marks a string parameter that takes a 24-hour HH:MM time
+const ParamFormatTime = "time"
+
+// ParamFormatDuration marks a string parameter that takes a duration in
+// minutes and/or hours, like 45m, 3h, or 1h30m
+const ParamFormatDuration = "duration"
+
 // Param defines a parameter for a command
 type Param struct {
 	Name        string
 	Type        ParamType
 	Description string
 	Required    bool
+	Enum        []string // the only values allowed, if the handler accepts a fixed set
+	Format      string   // extra check on a string's form, e.g. ParamFormatDate
 }
 
 // Command represents a CLI command
@@ -35,12 +54,30 @@ type Command struct {
 	Params      []Param                  // parameter definitions for tool generation
 	Hidden      bool                     // if true, exclude from tool generation
 	Destructive bool                     // if true, exclude from tool generation (destructive actions)
+	Interactive bool                     // prompts for input, so its output must not be captured
+	Bulk        bool                     // changes many items at once, so /confirm always asks first
+	RawArgs     bool                     // Handler gets the rest of the line as typed, as one argument (e.g. chat messages)
+	Access      Access                   // what the command does to the data, checked against the channel's policy
+	Examples    []string                 // sample command lines shown by /help <command>
+	ToolName    string                   // the LLM tool's name, if not the command's name without "/"
+
+	// ToolHandler, if set, runs the command for an LLM tool call with its
+	// named arguments and returns the tool result. Without one, the arguments
+	// are passed to Handler in Params order. An error means the call couldn't
+	// run at all; the command's own failures are part of the result, as in
+	// the terminal.
+	ToolHandler func(args map[string]any) (string, error)
 }
 
 var (
 	registry  = make(map[string]*Command)
 	store     storage.Store
+	storeMu   sync.RWMutex // background goroutines like the notify daemon read store
 	llmClient llm.Client
+	cfg       = &config.Config{}
+
+	// lineReader prompts for a line of input in interactive commands (REPL only)
+	lineReader func(prompt string) (string, error)
 )
 
 // Register adds a command to the registry
@@ -53,11 +90,15 @@ func Register(cmd *Command) {
 
 // SetStore sets the global store for commands to use
 func SetStore(s storage.Store) {
+	storeMu.Lock()
+	defer storeMu.Unlock()
 	store = s
 }
 
 // GetStore returns the global store
 func GetStore() storage.Store {
+	storeMu.RLock()
+	defer storeMu.RUnlock()
 	return store
 }
 
@@ -71,7 +112,24 @@ func GetLLMClient() llm.Client {
 	return llmClient
 }
 
-// Execute runs a command by name with arguments
+// SetConfig sets the persistent user config for commands to use
+func SetConfig(c *config.Config) {
+	cfg = c
+}
+
+// GetConfig returns the persistent user config
+func GetConfig() *config.Config {
+	return cfg
+}
+
+// SetLineReader sets how interactive commands like /planweek read input.
+// Without one (single-shot mode) they refuse to run.
+func SetLineReader(fn func(prompt string) (string, error)) {
+	lineReader = fn
+}
+
+// Execute runs a command by name with arguments. The arguments are split
+// with SplitArgs, so quoted text can contain spaces.
 func Execute(input string) (bool, error) {
 	parts := strings.Fields(input)
 	if len(parts) == 0 {
@@ -79,14 +137,28 @@ func Execute(input string) (bool, error) {
 	}
 
 	cmdName := strings.ToLower(parts[0])
-	args := parts[1:]
-
 	cmd, exists := registry[cmdName]
 	if !exists {
-		return false, fmt.Errorf("unknown command: %s", cmdName)
+		return false, unknownCommand(cmdName)
+	}
+	if !channelPolicy.Allows(cmd.Access) {
+		fmt.Printf("Error: %s is not allowed on the %s channel (%s)\n", cmd.Name, channelName, channelPolicy)
+		return false, nil
 	}
 
-	return cmd.Handler(args), nil
+	// With sync on, each change is committed to the sync repository
+	if syncConfig() != nil && cmd.Name != "/sync" {
+		defer commitChanges(cmd.Name)
+	}
+
+	rest := strings.TrimSpace(strings.TrimLeftFunc(input, unicode.IsSpace)[len(parts[0]):])
+	if cmd.RawArgs {
+		if rest == "" {
+			return cmd.Handler(nil), nil
+		}
+		return cmd.Handler([]string{rest}), nil
+	}
+	return cmd.Handler(SplitArgs(rest)), nil
 }
 
 // ExecuteWithOutput runs a command and returns its captured stdout output
@@ -124,6 +196,22 @@ func ExecuteWithOutput(input string) (quit bool, output string, err error) {
 	return quit, output, err
 }
 
+// OutputIndicatesError reports whether a command's output is an error or usage
+// message. Handlers print these instead of returning errors, so callers that
+// need a status (e.g., single-shot mode) inspect the output. The prefixes of
+// every locale count, since the output may be translated.
+func OutputIndicatesError(output string) bool {
+	// Only unindented lines count; list output indents task and project names
+	for _, line := range strings.Split(output, "\n") {
+		for _, prefix := range append(i18n.All("prefix.error"), i18n.All("prefix.usage")...) {
+			if strings.HasPrefix(line, prefix) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
 // List returns all registered commands (deduplicated)
 func List() []*Command {
 	seen := make(map[*Command]bool)
@@ -137,6 +225,50 @@ func List() []*Command {
 	return cmds
 }
 
+// ReadOnly reports whether the command only reads data (its Access is
+// AccessRead), so it may run in safe mode and dry runs
+func (c *Command) ReadOnly() bool {
+	return c.Access == AccessRead
+}
+
+// IsInteractive reports whether input runs a command that prompts for input
+func IsInteractive(input string) bool {
+	parts := strings.Fields(input)
+	if len(parts) == 0 {
+		return false
+	}
+	cmd, exists := Lookup(parts[0])
+	return exists && cmd.Interactive
+}
+
+// Lookup returns the command registered under a name or shorthand
+func Lookup(name string) (*Command, bool) {
+	cmd, exists := registry[strings.ToLower(name)]
+	return cmd, exists
+}
+
+// toolName returns the name of the command's LLM tool
+func (cmd *Command) toolName() string {
+	if cmd.ToolName != "" {
+		return cmd.ToolName
+	}
+	return strings.TrimPrefix(cmd.Name, "/")
+}
+
+// toolCommand finds the command behind an LLM tool
+func toolCommand(name string) (*Command, bool) {
+	for _, cmd := range registry {
+		if cmd.ToolName == name {
+			return cmd, true
+		}
+	}
+	cmd, ok := registry["/"+name]
+	if ok && cmd.ToolName != "" {
+		return nil, false
+	}
+	return cmd, ok
+}
+
 // GenerateToolDefinitions creates Tool definitions from registered commands
 func GenerateToolDefinitions() []*llm.Tool {
 	var tools []*llm.Tool
@@ -147,7 +279,7 @@ func GenerateToolDefinitions() []*llm.Tool {
 			continue
 		}
 		seen[cmd] = true
-		if cmd.Hidden || cmd.Destructive {
+		if cmd.Hidden || cmd.Destructive || !channelPolicy.Allows(cmd.Access) {
 			continue
 		}
 
@@ -156,9 +288,14 @@ func GenerateToolDefinitions() []*llm.Tool {
 		var required []string
 
 		for _, p := range cmd.Params {
+			paramType := p.Type
+			if paramType == "" {
+				paramType = ParamTypeString
+			}
 			properties[p.Name] = &llm.ToolProperty{
-				Type:        "string",
+				Type:        string(paramType),
 				Description: p.Description,
+				Enum:        p.Enum,
 			}
 			if p.Required {
 				required = append(required, p.Name)
@@ -167,7 +304,7 @@ func GenerateToolDefinitions() []*llm.Tool {
 
 		// Create Tool
 		tool := &llm.Tool{
-			Name:        strings.TrimPrefix(cmd.Name, "/"),
+			Name:        cmd.toolName(),
 			Description: cmd.Description,
 		}
 
@@ -1,7 +1,22 @@
 package commands
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
 	"testing"
+	"time"
+
+	"twooms/config"
+	"twooms/i18n"
+	"twooms/llm"
+	"twooms/storage"
 )
 
 func TestGenerateToolDefinitions(t *testing.T) {
@@ -9,18 +24,51 @@ func TestGenerateToolDefinitions(t *testing.T) {
 
 	// Expected tool names (commands that are NOT hidden or destructive)
 	expectedTools := map[string]bool{
-		"project":  true,
-		"projects": true,
-		"shortcut": true,
-		"task":     true,
-		"tasks":    true,
-		"done":     true,
-		"undone":   true,
-		"due":      true,
-		"duration": true,
-		"today":    true,
-		"tomorrow": true,
-		"week":     true,
+		"project":            true,
+		"projects":           true,
+		"shortcut":           true,
+		"task":               true,
+		"tasks":              true,
+		"tasks_create_batch": true,
+		"in":                 true,
+		"inbox":              true,
+		"done":               true,
+		"undone":             true,
+		"move":               true,
+		"reorder":            true,
+		"now":                true,
+		"status":             true,
+		"due":                true,
+		"duration":           true,
+		"today":              true,
+		"tomorrow":           true,
+		"week":               true,
+		"agenda":             true,
+		"task_get":           true,
+		"tag":                true,
+		"untag":              true,
+		"tagged":             true,
+		"projectdue":         true,
+		"projectset":         true,
+		"blocks":             true,
+		"deps":               true,
+		"ready":              true,
+		"defer":              true,
+		"defer-all-overdue":  true,
+		"log":                true,
+		"durations":          true,
+		"search":             true,
+		"priority":           true,
+		"note":               true,
+		"start":              true,
+		"stop":               true,
+		"timelog":            true,
+		"plan":               true,
+		"archive":            true,
+		"archived":           true,
+		"digest":             true,
+		"stats":              true,
+		"capacity":           true,
 	}
 
 	// Commands that should NOT be generated (hidden or destructive)
@@ -101,11 +149,13 @@ func TestToolParameterDefinitions(t *testing.T) {
 		{"project", []string{"name"}},
 		{"projects", nil}, // no params
 		{"task", []string{"project_id", "task_name"}},
-		{"tasks", []string{"project_id"}},
+		{"tasks", []string{"project_id", "status", "sort", "group"}},
 		{"done", []string{"task_id"}},
 		{"undone", []string{"task_id"}},
-		{"due", []string{"task_id", "date"}},
+		{"due", []string{"task_id", "date", "time"}},
 		{"duration", []string{"task_id", "duration"}},
+		{"tag", []string{"task_id", "tag"}},
+		{"tagged", []string{"tag"}},
 	}
 
 	for _, tc := range testCases {
@@ -139,3 +189,1816 @@ func TestToolParameterDefinitions(t *testing.T) {
 		})
 	}
 }
+
+func TestToolParameterSchemas(t *testing.T) {
+	properties := make(map[string]map[string]*llm.ToolProperty)
+	for _, tool := range GenerateToolDefinitions() {
+		if tool.Parameters != nil {
+			properties[tool.Name] = tool.Parameters.Properties
+		}
+	}
+
+	// Any duration in minutes or hours is allowed, so there's no enum
+	if got := properties["duration"]["duration"]; got.Type != "string" || len(got.Enum) != 0 {
+		t.Errorf("Expected a free-form duration, got %s %v", got.Type, got.Enum)
+	}
+	if got := properties["priority"]["priority"]; got.Type != "string" || strings.Join(got.Enum, ",") != "low,medium,high,urgent,none" {
+		t.Errorf("Expected priority enum, got %s %v", got.Type, got.Enum)
+	}
+	if got := properties["plan"]["why"]; got.Type != "boolean" {
+		t.Errorf("Expected boolean why, got %s", got.Type)
+	}
+	if got := properties["task"]["task_name"]; got.Type != "string" || got.Enum != nil {
+		t.Errorf("Expected plain string task_name, got %s %v", got.Type, got.Enum)
+	}
+
+	// Booleans become flags, and integral numbers print without a decimal point
+	args := convertArgsToSlice("plan", map[string]any{"hours": float64(4), "project_id": "work", "why": true})
+	if strings.Join(args, " ") != "4 work --why" {
+		t.Errorf("Expected 4 work --why, got %v", args)
+	}
+	args = convertArgsToSlice("plan", map[string]any{"hours": 2.5, "why": false})
+	if strings.Join(args, " ") != "2.5" {
+		t.Errorf("Expected 2.5, got %v", args)
+	}
+
+	// Arguments follow Params order for every tool
+	args = convertArgsToSlice("shortcut", map[string]any{"new_shortcut": "wk", "project_id": "work"})
+	if strings.Join(args, " ") != "work wk" {
+		t.Errorf("Expected work wk, got %v", args)
+	}
+}
+
+func TestToolHandler(t *testing.T) {
+	store, err := storage.NewJSONStore(filepath.Join(t.TempDir(), "test.json"))
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+	defer store.Close()
+	SetStore(store)
+
+	project, _ := store.CreateProject("Work")
+
+	// Text reaches the command as sent, without going through a command line
+	output := runTool("task", map[string]any{"project_id": project.Shortcut, "task_name": `Read "Dune" again`})
+	if !strings.Contains(output, `Created task: Read "Dune" again`) {
+		t.Fatalf("Expected task created with its exact name, got: %s", output)
+	}
+	tasks, _ := store.ListTasks(project.ID)
+	if len(tasks) != 1 || tasks[0].Name != `Read "Dune" again` {
+		t.Fatalf("Expected one task named as sent, got %v", tasks)
+	}
+
+	note := "Steps:\n1. outline\n2. numbers"
+	runTool("note", map[string]any{"task_id": tasks[0].ID, "note": note})
+	if got, _ := store.GetTask(tasks[0].ID); got.Note != note {
+		t.Errorf("Expected note %q kept line by line, got %q", note, got.Note)
+	}
+
+	output = runTool("tasks_create_batch", map[string]any{"project_id": project.Shortcut, "tasks": "Call Ann; Book room"})
+	if !strings.Contains(output, "Created 2 tasks") || !strings.Contains(output, "Book room") {
+		t.Errorf("Expected batch created, got: %s", output)
+	}
+
+	// Unusable arguments are an error rather than a usage message
+	if output := runTool("project", map[string]any{"name": " "}); output != "Error: missing argument name" {
+		t.Errorf("Expected missing argument error, got: %s", output)
+	}
+
+	// Commands without a ToolHandler still run from their Params order
+	output = runTool("due", map[string]any{"task_id": tasks[0].ID, "date": "2030-01-02"})
+	if !strings.Contains(output, "2030-01-02") {
+		t.Errorf("Expected due date set, got: %s", output)
+	}
+}
+
+func TestChannelPolicy(t *testing.T) {
+	store, err := storage.NewJSONStore(filepath.Join(t.TempDir(), "test.json"))
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+	defer store.Close()
+	SetStore(store)
+	SetConfig(&config.Config{Channels: map[string]string{"slack": "create-only", "feed": "Read-Only", "bad": "admin"}})
+	defer SetConfig(&config.Config{})
+	defer func() { channelName, channelPolicy = "repl", PolicyFull }()
+
+	project, _ := store.CreateProject("Work")
+
+	if err := SetChannel("slak"); err == nil {
+		t.Error("Expected an error for a channel missing from the config")
+	}
+	if err := SetChannel("bad"); err == nil {
+		t.Error("Expected an error for an unknown policy")
+	}
+	if channelPolicy != PolicyFull {
+		t.Fatalf("Expected a failed SetChannel to keep the policy, got %s", channelPolicy)
+	}
+
+	if err := SetChannel("slack"); err != nil {
+		t.Fatalf("SetChannel failed: %v", err)
+	}
+	tools := make(map[string]bool)
+	for _, tool := range GenerateToolDefinitions() {
+		tools[tool.Name] = true
+	}
+	for _, name := range []string{"task", "tasks_create_batch", "tasks", "projects"} {
+		if !tools[name] {
+			t.Errorf("Expected %s offered on a create-only channel", name)
+		}
+	}
+	for _, name := range []string{"done", "due", "note", "archive"} {
+		if tools[name] {
+			t.Errorf("Expected %s withheld on a create-only channel", name)
+		}
+	}
+
+	// A tool the model wasn't offered is rejected before it runs
+	if msg := validateToolArgs(GenerateToolDefinitions(), "done", map[string]any{"task_id": "x"}); msg == "" {
+		t.Error("Expected done rejected on a create-only channel")
+	}
+
+	output := captureCommandOutput(t, "/task "+project.Shortcut+" Call Ann")
+	if !strings.Contains(output, "Created task") {
+		t.Fatalf("Expected task created, got: %s", output)
+	}
+
+	// Typed commands are held to the same policy
+	output = captureCommandOutput(t, "/delproject "+project.Shortcut)
+	if !strings.Contains(output, "Error: /delproject is not allowed on the slack channel (create-only)") {
+		t.Errorf("Expected /delproject refused, got: %s", output)
+	}
+	if _, err := store.GetProject(project.ID); err != nil {
+		t.Errorf("Expected project kept, got: %v", err)
+	}
+
+	if err := SetChannel("feed"); err != nil {
+		t.Fatalf("SetChannel failed: %v", err)
+	}
+	if output := captureCommandOutput(t, "/task "+project.Shortcut+" Book room"); !strings.Contains(output, "not allowed") {
+		t.Errorf("Expected /task refused on a read-only channel, got: %s", output)
+	}
+	if output := captureCommandOutput(t, "/tasks "+project.Shortcut); !strings.Contains(output, "Call Ann") {
+		t.Errorf("Expected /tasks allowed on a read-only channel, got: %s", output)
+	}
+}
+
+func TestUnknownCommandSuggestions(t *testing.T) {
+	tests := []struct {
+		input       string
+		suggestions string
+		likely      string
+	}{
+		{"/tsks", "/tasks, /task", "/tasks"},
+		{"/tsak work", "/task, /tag, /tasks", "/task"}, // swapped letters are one edit
+		{"/TAGGD", "/tagged, /tag", "/tagged"},
+		{"/dne", "/done, /due", ""}, // two commands one edit away
+		{"/zzzz", "", ""},
+		{"/x", "", ""}, // too short to guess
+	}
+
+	for _, tt := range tests {
+		_, err := Execute(tt.input)
+		var unknown *UnknownCommandError
+		if !errors.As(err, &unknown) {
+			t.Fatalf("%s: expected UnknownCommandError, got %v", tt.input, err)
+		}
+		if got := strings.Join(unknown.Suggestions, ", "); got != tt.suggestions {
+			t.Errorf("%s: expected suggestions %q, got %q", tt.input, tt.suggestions, got)
+		}
+		if unknown.Likely != tt.likely {
+			t.Errorf("%s: expected likely %q, got %q", tt.input, tt.likely, unknown.Likely)
+		}
+	}
+
+	_, err := Execute("/tsks")
+	if err.Error() != "unknown command: /tsks (did you mean /tasks, /task?)" {
+		t.Errorf("Unexpected error message: %v", err)
+	}
+}
+
+func TestHelpCommand(t *testing.T) {
+	for _, input := range []string{"/help due", "/help /due", "/help du"} {
+		output := captureCommandOutput(t, input)
+		for _, want := range []string{
+			"/due (/du) - Set a task's due date",
+			"Usage: /due <task-id> <YYYY-MM-DD|none> [HH:MM]",
+			"date (required, YYYY-MM-DD, or none to clear)",
+			"time (optional, HH:MM, 24-hour)",
+			"Examples:\n  /due 1a2b3c4d 2025-03-01\n",
+		} {
+			if !strings.Contains(output, want) {
+				t.Errorf("%s: expected %q in output:\n%s", input, want, output)
+			}
+		}
+	}
+
+	// Bare /help keeps the summary
+	output := captureCommandOutput(t, "/help")
+	if !strings.Contains(output, "/tasks") || strings.Contains(output, "Examples:") || !strings.Contains(output, "/help <command>") {
+		t.Errorf("Expected the command summary, got:\n%s", output)
+	}
+
+	if output := captureCommandOutput(t, "/help dye"); !strings.Contains(output, "unknown command: /dye (did you mean /due?)") {
+		t.Errorf("Expected a suggestion for an unknown command, got %q", output)
+	}
+}
+
+func TestOutputIndicatesError(t *testing.T) {
+	testCases := []struct {
+		output string
+		want   bool
+	}{
+		{"Created task: Pay rent (ID: abcd1234)", false},
+		{"Error: project not found: work", true},
+		{"Usage: /task <project-id> <task name>", true},
+		{"Tasks in Work:\n  Error handling review", false},
+		{"Fehler: Ungültige Dauer", true},
+		{"Uso: /tasks <id-proyecto>", true},
+		{"", false},
+	}
+
+	for _, tc := range testCases {
+		if got := OutputIndicatesError(tc.output); got != tc.want {
+			t.Errorf("OutputIndicatesError(%q) = %v, want %v", tc.output, got, tc.want)
+		}
+	}
+}
+
+// fakeModelClient is an llm.Client that supports listing and switching models
+type fakeModelClient struct {
+	llm.Client
+	model string
+}
+
+func (f *fakeModelClient) Model() string         { return f.model }
+func (f *fakeModelClient) SetModel(model string) { f.model = model }
+func (f *fakeModelClient) ListModels(ctx context.Context) ([]*llm.ModelInfo, error) {
+	return []*llm.ModelInfo{
+		{ID: "acme/small", Name: "Acme Small", ContextLength: 32000, PromptPrice: 0.0000001, CompletionPrice: 0.0000004},
+		{ID: "acme/large", Name: "Acme Large", ContextLength: 200000, PromptPrice: 0.000003, CompletionPrice: 0.000015},
+	}, nil
+}
+
+func TestModelCommand(t *testing.T) {
+	client := &fakeModelClient{model: "acme/small"}
+	SetLLMClient(client)
+	defer SetLLMClient(nil)
+
+	configPath := filepath.Join(t.TempDir(), "config.json")
+	c, _ := config.Load(configPath)
+	SetConfig(c)
+	defer SetConfig(&config.Config{})
+
+	output := captureOutput(func() { Execute("/model") })
+	if !strings.Contains(output, "Current model: acme/small") {
+		t.Errorf("Expected current model, got: %s", output)
+	}
+
+	output = captureOutput(func() { Execute("/model list large") })
+	if !strings.Contains(output, "acme/large") || strings.Contains(output, "acme/small") {
+		t.Errorf("Expected filtered list, got: %s", output)
+	}
+	if !strings.Contains(output, "$3.00 / $15.00  (200k context)") {
+		t.Errorf("Expected pricing per 1M tokens, got: %s", output)
+	}
+
+	output = captureOutput(func() { Execute("/model acme/nope") })
+	if !strings.Contains(output, "unknown model") {
+		t.Errorf("Expected unknown model error, got: %s", output)
+	}
+
+	output = captureOutput(func() { Execute("/model acme/large") })
+	if !strings.Contains(output, "Switched model to acme/large (saved)") || client.model != "acme/large" {
+		t.Errorf("Expected switch, got: %s", output)
+	}
+
+	// The choice persists to the config file
+	data, _ := os.ReadFile(configPath)
+	if !strings.Contains(string(data), `"model": "acme/large"`) {
+		t.Errorf("Expected model saved to config, got: %s", data)
+	}
+}
+
+func TestPersonaCommand(t *testing.T) {
+	dir := t.TempDir()
+	SetPromptDir(dir)
+	defer SetPromptDir("")
+
+	configPath := filepath.Join(dir, "config.json")
+	c, _ := config.Load(configPath)
+	SetConfig(c)
+	defer SetConfig(&config.Config{})
+
+	os.MkdirAll(filepath.Join(dir, "personas"), 0700)
+	os.WriteFile(filepath.Join(dir, "personas", "pirate.md"), []byte("Talk like a pirate.\n"), 0600)
+
+	output := captureOutput(func() { Execute("/persona") })
+	for _, want := range []string{"* default", "  gtd-coach", "  pirate", "  terse"} {
+		if !strings.Contains(output, want) {
+			t.Errorf("Expected %q in the list, got: %s", want, output)
+		}
+	}
+
+	output = captureOutput(func() { Execute("/persona Pirate") })
+	if !strings.Contains(output, "Switched to the pirate persona (saved)") {
+		t.Errorf("Expected switch, got: %s", output)
+	}
+	data, _ := os.ReadFile(configPath)
+	if !strings.Contains(string(data), `"persona": "pirate"`) {
+		t.Errorf("Expected persona saved to config, got: %s", data)
+	}
+	prompt := getSystemPrompt()
+	if !strings.HasPrefix(prompt, systemRules+"\n\nSTYLE:\nTalk like a pirate.\n\nTODAY'S DATE: ") {
+		t.Errorf("Expected the persona between the rules and the date, got: %s", prompt)
+	}
+
+	if output := captureOutput(func() { Execute("/persona grumpy") }); !strings.Contains(output, `unknown persona "grumpy"`) || c.Persona != "pirate" {
+		t.Errorf("Expected an unknown persona rejected, got: %s", output)
+	}
+
+	// system_prompt.md replaces the built-in rules, and the date still follows
+	os.WriteFile(filepath.Join(dir, "system_prompt.md"), []byte("You manage my tasks.\n"), 0600)
+	captureOutput(func() { Execute("/persona default") })
+	prompt = getSystemPrompt()
+	if c.Persona != "" || !strings.HasPrefix(prompt, "You manage my tasks.\n\nTODAY'S DATE: ") {
+		t.Errorf("Expected the override without a persona, got: %s", prompt)
+	}
+}
+
+func TestRenderMarkdown(t *testing.T) {
+	answer := "## Today\n\nYou have **two** tasks due, see `/today`:\n\n- Pay rent, which is overdue by three days now\n  - [x] ask about the deposit\n\n| Task | Due |\n|---|---|\n| Rent | Jan 1 |\n\n```\n/done 1a2b\n```"
+	got := mdAnsi.ReplaceAllString(renderMarkdown(answer, 30), "")
+	want := strings.Join([]string{
+		"Today",
+		"",
+		"You have two tasks due, see",
+		"/today:",
+		"",
+		"• Pay rent, which is overdue",
+		"  by three days now",
+		"  [✓] ask about the deposit",
+		"",
+		"Task  Due",
+		"───────────",
+		"Rent  Jan 1",
+		"",
+		"  /done 1a2b",
+	}, "\n")
+	if got != want {
+		t.Errorf("renderMarkdown() =\n%s\nwant:\n%s", got, want)
+	}
+
+	// Styles don't leak past a line break, and names with underscores stay
+	got = renderMarkdown("**a bold phrase that wraps** in snake_case_name", 20)
+	if lines := strings.Split(got, "\n"); !strings.HasSuffix(lines[0], styleReset) || !strings.HasPrefix(lines[1], styleBold) {
+		t.Errorf("Expected bold closed and reopened across the wrap, got: %q", got)
+	}
+	if !strings.Contains(got, "snake_case_name") {
+		t.Errorf("Expected the underscores kept, got: %q", got)
+	}
+
+	dir := t.TempDir()
+	configPath := filepath.Join(dir, "config.json")
+	c, _ := config.Load(configPath)
+	SetConfig(c)
+	defer SetConfig(&config.Config{})
+	SetColorTerminal(true)
+	defer SetColorTerminal(false)
+
+	if formatAnswer("**hi**") != styleBold+"hi"+styleReset {
+		t.Errorf("Expected answers rendered by default, got: %q", formatAnswer("**hi**"))
+	}
+	output := captureOutput(func() { Execute("/render plain") })
+	if !strings.Contains(output, "rendered plain (saved)") || formatAnswer("**hi**") != "**hi**" {
+		t.Errorf("Expected plain rendering, got: %s", output)
+	}
+	data, _ := os.ReadFile(configPath)
+	if !strings.Contains(string(data), `"render": "plain"`) {
+		t.Errorf("Expected the mode saved to config, got: %s", data)
+	}
+	output = captureOutput(func() { Execute("/render fancy") })
+	if !strings.Contains(output, "Usage: /render [pretty|plain]") {
+		t.Errorf("Expected usage, got: %s", output)
+	}
+	captureOutput(func() { Execute("/render pretty") })
+	if GetConfig().Render != "" {
+		t.Errorf("Expected pretty saved as the default, got %q", GetConfig().Render)
+	}
+}
+
+func TestThemeCommand(t *testing.T) {
+	cleanup := setupTestStore(t)
+	defer cleanup()
+
+	dir := t.TempDir()
+	configPath := filepath.Join(dir, "config.json")
+	c, _ := config.Load(configPath)
+	SetConfig(c)
+	defer SetConfig(&config.Config{})
+
+	shortcut := extractShortcut(captureCommandOutput(t, "/project Work"))
+	overdue := extractTaskID(captureCommandOutput(t, "/task "+shortcut+" File taxes due:2020-01-01"))
+	done := extractTaskID(captureCommandOutput(t, "/task "+shortcut+" Buy milk"))
+	captureCommandOutput(t, "/done "+done)
+
+	// Output that isn't a terminal is never colored
+	if output := captureCommandOutput(t, "/tasks "+shortcut); strings.Contains(output, "\033[") {
+		t.Errorf("Expected no colors off a terminal, got: %q", output)
+	}
+
+	SetColorTerminal(true)
+	defer SetColorTerminal(false)
+	output := captureCommandOutput(t, "/tasks "+shortcut)
+	dark := themes["dark"]
+	if !strings.Contains(output, dark.overdue+"File taxes") || !strings.Contains(output, dark.done+"[✓]"+colorReset) || !strings.Contains(output, "["+dark.id+overdue+colorReset+"]") {
+		t.Errorf("Expected overdue, done, and ID colors, got: %q", output)
+	}
+
+	// NO_COLOR turns every color off
+	t.Setenv("NO_COLOR", "1")
+	if output := captureCommandOutput(t, "/tasks "+shortcut); strings.Contains(output, "\033[") {
+		t.Errorf("Expected no colors with NO_COLOR, got: %q", output)
+	}
+	if output := captureCommandOutput(t, "/theme"); !strings.Contains(output, "NO_COLOR is set") {
+		t.Errorf("Expected /theme to mention NO_COLOR, got: %s", output)
+	}
+	t.Setenv("NO_COLOR", "")
+
+	output = captureCommandOutput(t, "/theme light")
+	if !strings.Contains(output, "Theme set to light (saved)") || !strings.Contains(captureCommandOutput(t, "/tasks "+shortcut), themes["light"].id) {
+		t.Errorf("Expected the light theme, got: %s", output)
+	}
+	data, _ := os.ReadFile(configPath)
+	if !strings.Contains(string(data), `"theme": "light"`) {
+		t.Errorf("Expected the theme saved to config, got: %s", data)
+	}
+	captureCommandOutput(t, "/theme none")
+	if output := captureCommandOutput(t, "/tasks "+shortcut); strings.Contains(output, "\033[") {
+		t.Errorf("Expected no colors with the none theme, got: %q", output)
+	}
+	if output := captureCommandOutput(t, "/theme sepia"); !strings.Contains(output, "Usage: /theme [dark|light|none]") {
+		t.Errorf("Expected usage, got: %s", output)
+	}
+	captureCommandOutput(t, "/theme dark")
+	if GetConfig().Theme != "" {
+		t.Errorf("Expected dark saved as the default, got %q", GetConfig().Theme)
+	}
+}
+
+func TestLocaleCommand(t *testing.T) {
+	cleanup := setupTestStore(t)
+	defer cleanup()
+	defer i18n.SetLocale(i18n.DefaultLocale)
+
+	configPath := filepath.Join(t.TempDir(), "config.json")
+	c, _ := config.Load(configPath)
+	SetConfig(c)
+	defer SetConfig(&config.Config{})
+
+	output := captureOutput(func() { Execute("/locale") })
+	if !strings.Contains(output, "Current language: en (available: de, en, es)") {
+		t.Errorf("Expected current language, got: %s", output)
+	}
+
+	output = captureOutput(func() { Execute("/locale fr") })
+	if !strings.Contains(output, "unknown language") || i18n.Locale() != "en" {
+		t.Errorf("Expected unknown language rejected, got: %s", output)
+	}
+
+	output = captureOutput(func() { Execute("/locale es_ES.UTF-8") })
+	if !strings.Contains(output, "Idioma cambiado a es (guardado)") {
+		t.Errorf("Expected switch to Spanish, got: %s", output)
+	}
+	data, _ := os.ReadFile(configPath)
+	if !strings.Contains(string(data), `"locale": "es"`) {
+		t.Errorf("Expected locale saved to config, got: %s", data)
+	}
+
+	// Command output follows the locale, and still reads as an error
+	output = captureOutput(func() { Execute("/project Trabajo") })
+	if !strings.Contains(output, "Proyecto creado: Trabajo") {
+		t.Errorf("Expected Spanish confirmation, got: %s", output)
+	}
+	_, output, _ = ExecuteWithOutput("/tasks")
+	if !strings.HasPrefix(output, "Uso: /tasks <id-proyecto>") || !OutputIndicatesError(output) {
+		t.Errorf("Expected Spanish usage recognized as an error, got: %s", output)
+	}
+
+	// Every command's usage message is translated, in /help too
+	for input, want := range map[string]string{
+		"/move":           "Uso: /move <id-tarea> <id-proyecto|inbox>",
+		"/workspace zzz":  "Uso: /workspace [list|create <nombre>|switch <nombre>]",
+		"/undo x":         "Uso: /undo [cantidad]",
+		"/theme sepia":    "Uso: /theme [dark|light|none]",
+		"/help share":     "Uso: /share <id-proyecto> [días]",
+		"/safemode maybe": "Uso: /safemode on|off",
+	} {
+		if output := captureOutput(func() { Execute(input) }); !strings.Contains(output, want) {
+			t.Errorf("%s: expected %q, got: %s", input, want, output)
+		}
+	}
+}
+
+// TestMessageKeys checks every message key the commands use is in the
+// catalogs, and that each <command>.usage key names a command, since /help
+// shows it as that command's usage line
+func TestMessageKeys(t *testing.T) {
+	files, _ := filepath.Glob("*.go")
+	keyRegex := regexp.MustCompile(`i18n\.T\("([^"]+)"[,)]`) // whole keys, not prefixes
+	found := 0
+	for _, file := range files {
+		if strings.HasSuffix(file, "_test.go") {
+			continue
+		}
+		data, err := os.ReadFile(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, m := range keyRegex.FindAllStringSubmatch(string(data), -1) {
+			found++
+			key := m[1]
+			if !i18n.Has(key) {
+				t.Errorf("%s: message %q is not in the English catalog", file, key)
+			}
+			if name, ok := strings.CutSuffix(key, ".usage"); ok {
+				if _, exists := Lookup("/" + name); !exists {
+					t.Errorf("%s: usage key %q names no command", file, key)
+				}
+			}
+		}
+	}
+	if found < 100 {
+		t.Errorf("Expected to find the commands' message keys, found %d", found)
+	}
+}
+
+func TestPromptTemplate(t *testing.T) {
+	cleanup := setupTestStore(t)
+	defer cleanup()
+
+	configPath := filepath.Join(t.TempDir(), "config.json")
+	c, _ := config.Load(configPath)
+	SetConfig(c)
+	defer SetConfig(&config.Config{})
+
+	// The default prompt shows the focused project
+	if got := Prompt(); got != "> " {
+		t.Errorf("Expected default prompt, got %q", got)
+	}
+
+	shortcut := extractShortcut(captureCommandOutput(t, "/project Work"))
+	taskID := extractTaskID(captureCommandOutput(t, "/task "+shortcut+" Write report"))
+	captureCommandOutput(t, "/duration "+taskID+" 2h")
+	captureCommandOutput(t, "/due "+taskID+" "+time.Now().Format("2006-01-02"))
+	captureCommandOutput(t, "/focus "+shortcut)
+	defer setFocus("")
+
+	if got := Prompt(); got != "Work> " {
+		t.Errorf("Expected focused project in default prompt, got %q", got)
+	}
+
+	output := captureCommandOutput(t, "/prompt {project} {due_today} due, {load} [{task}] >")
+	if !strings.Contains(output, "(saved)") {
+		t.Fatalf("Expected template saved, got: %s", output)
+	}
+	captureCommandOutput(t, "/start "+taskID)
+	if got := Prompt(); got != "Work 1 due, 2h [Write report] > " {
+		t.Errorf("Expected live values, got %q", got)
+	}
+
+	// Values follow the store
+	captureCommandOutput(t, "/done "+taskID)
+	if got := Prompt(); got != "Work 0 due, 0m [Write report] > " {
+		t.Errorf("Expected updated values, got %q", got)
+	}
+
+	output = captureCommandOutput(t, "/prompt {profile} >")
+	if !strings.Contains(output, "Error: unknown placeholder: {profile}") {
+		t.Errorf("Expected unknown placeholder rejected, got: %s", output)
+	}
+
+	data, _ := os.ReadFile(configPath)
+	if !strings.Contains(string(data), `"prompt": "{project} {due_today} due, {load} [{task}] > "`) {
+		t.Errorf("Expected prompt saved to config, got: %s", data)
+	}
+
+	captureCommandOutput(t, "/prompt reset")
+	if got := Prompt(); got != "Work> " {
+		t.Errorf("Expected reset prompt, got %q", got)
+	}
+}
+
+// fakeChatClient records what /chat sends and makes one scripted tool call
+type fakeChatClient struct {
+	llm.Client
+	call   string
+	args   map[string]any
+	tools  []*llm.Tool
+	system string
+	result string
+	tokens int64
+	cached int64 // input tokens reported as read from the prompt cache
+	prefix int   // the system prompt's CachePrefix
+	cost   float64
+}
+
+func (f *fakeChatClient) SetDebug(enabled bool) {}
+func (f *fakeChatClient) ChatWithTools(ctx context.Context, message string, history []*llm.Message, tools []*llm.Tool, executor llm.ToolExecutor) (*llm.Response, []*llm.Message, error) {
+	f.tools = tools
+	f.system = history[0].Content
+	f.prefix = history[0].CachePrefix
+	if err := ctx.Err(); err != nil {
+		return nil, history, err
+	}
+	f.result = executor(f.call, f.args)
+	history = append(history, &llm.Message{Role: "user", Content: message}, &llm.Message{Role: "assistant", Content: "Done."})
+	return &llm.Response{Text: "Done.", InputTokens: f.tokens, OutputTokens: f.tokens, CachedTokens: f.cached, Cost: f.cost}, history, nil
+}
+
+// fakeEstimateClient looks up past durations, as /estimate asks, then
+// answers with its estimates
+type fakeEstimateClient struct {
+	llm.Client
+	answer  string
+	tools   []*llm.Tool
+	history string
+	refused string
+}
+
+func (f *fakeEstimateClient) SetDebug(enabled bool) {}
+func (f *fakeEstimateClient) ChatWithTools(ctx context.Context, message string, history []*llm.Message, tools []*llm.Tool, executor llm.ToolExecutor) (*llm.Response, []*llm.Message, error) {
+	f.tools = tools
+	f.history = executor("durations", map[string]any{})
+	f.refused = executor("deltask", map[string]any{"task_id": "x"})
+	return &llm.Response{Text: f.answer}, history, nil
+}
+
+func TestEstimateCommand(t *testing.T) {
+	cleanup := setupTestStore(t)
+	defer cleanup()
+
+	work := extractShortcut(captureCommandOutput(t, "/project Work"))
+	pastID := extractTaskID(captureCommandOutput(t, "/task "+work+" Write old report"))
+	captureCommandOutput(t, "/duration "+pastID+" 2h")
+	captureCommandOutput(t, "/done "+pastID)
+	reportID := extractTaskID(captureCommandOutput(t, "/task "+work+" Write report"))
+	slidesID := extractTaskID(captureCommandOutput(t, "/task "+work+" Make slides"))
+	setID := extractTaskID(captureCommandOutput(t, "/task "+work+" Already sized"))
+	captureCommandOutput(t, "/duration "+setID+" 15m")
+	get := func(id string) *storage.Task {
+		resolved, _ := GetStore().ResolveTaskID(id)
+		task, _ := GetStore().GetTask(resolved)
+		return task
+	}
+
+	if output := captureCommandOutput(t, "/durations "+work); !strings.Contains(output, "Write old report - 2h est") || strings.Contains(output, "Make slides") {
+		t.Errorf("Expected the completed task's estimate, got: %s", output)
+	}
+
+	// Without a provider nothing is asked
+	if output := captureCommandOutput(t, "/estimate "+work+" --yes"); !strings.Contains(output, "no LLM provider") {
+		t.Errorf("Expected a missing provider error, got: %s", output)
+	}
+
+	client := &fakeEstimateClient{answer: "Here you go:\n" + reportID + " 1h30m - like the old report\n[" + slidesID + "] 45m\nbogus 3h"}
+	SetLLMClient(client)
+	defer SetLLMClient(nil)
+
+	// Outside the REPL it only shows the estimates
+	output := captureCommandOutput(t, "/estimate "+work)
+	if !strings.Contains(output, "Write report: 1h30m - like the old report") || !strings.Contains(output, "Make slides: 45m") || strings.Contains(output, "Already sized") || !strings.Contains(output, "Run again with --yes") {
+		t.Errorf("Expected estimates for the unsized open tasks, got: %s", output)
+	}
+	if len(client.tools) != 1 || client.tools[0].Name != "durations" || !strings.Contains(client.history, "Write old report") || !strings.Contains(client.refused, "isn't available") {
+		t.Errorf("Expected only the durations tool, got %v, %q, %q", client.tools, client.history, client.refused)
+	}
+	if task := get(reportID); task.Duration != 0 {
+		t.Errorf("Expected nothing set before confirming, got %s", task.Duration)
+	}
+
+	output = captureCommandOutput(t, "/estimate "+work+" --yes")
+	report, slides := get(reportID), get(slidesID)
+	if !strings.Contains(output, "Set 2 durations") || report.Duration != 90 || slides.Duration != 45 {
+		t.Errorf("Expected both durations set, got %s and %s: %s", report.Duration, slides.Duration, output)
+	}
+	captureCommandOutput(t, "/undo")
+	if report := get(reportID); report.Duration != 0 {
+		t.Errorf("Expected one undo to clear the estimates, got %s", report.Duration)
+	}
+
+	// A single task is estimated even if it has a duration
+	client.answer = setID + " 30m - a bit more"
+	output = captureCommandOutput(t, "/estimate "+setID+" --yes")
+	if !strings.Contains(output, "Already sized: 30m (was 15m) - a bit more") {
+		t.Errorf("Expected a re-estimate, got: %s", output)
+	}
+}
+
+// fakeSequentialClient makes its calls one after another
+type fakeSequentialClient struct {
+	llm.Client
+	calls   []llm.ToolCall
+	results []string
+}
+
+func (f *fakeSequentialClient) SetDebug(enabled bool) {}
+func (f *fakeSequentialClient) ChatWithTools(ctx context.Context, message string, history []*llm.Message, tools []*llm.Tool, executor llm.ToolExecutor) (*llm.Response, []*llm.Message, error) {
+	f.results = nil
+	for _, call := range f.calls {
+		f.results = append(f.results, executor(call.Name, call.Arguments))
+	}
+	history = append(history, &llm.Message{Role: "user", Content: message}, &llm.Message{Role: "assistant", Content: "Here's the plan."})
+	return &llm.Response{Text: "Here's the plan."}, history, nil
+}
+
+func TestChatPlan(t *testing.T) {
+	cleanup := setupTestStore(t)
+	defer cleanup()
+	defer func() { chatHistory, pendingPlan = nil, nil }()
+
+	client := &fakeSequentialClient{calls: []llm.ToolCall{
+		{Name: "projects", Arguments: map[string]any{}},
+		{Name: "project", Arguments: map[string]any{"name": "Taxes"}},
+		{Name: "task", Arguments: map[string]any{"project_id": "new-project-1", "task_name": "Gather W-2s"}},
+		{Name: "due", Arguments: map[string]any{"task_id": "new-1", "date": "2030-04-01"}},
+	}}
+	SetLLMClient(client)
+	defer SetLLMClient(nil)
+
+	// Simple requests run their changes directly
+	if planWorthy("add Buy milk to home") || !planWorthy("set up my tax project with 8 tasks and staggered deadlines") || !planWorthy("break the launch down into five steps") {
+		t.Error("Expected only requests for many changes to be planned")
+	}
+
+	// In the REPL the plan is approved at a prompt, then runs with real IDs
+	var asked string
+	SetLineReader(func(prompt string) (string, error) {
+		asked = prompt
+		return "y", nil
+	})
+	output := captureOutput(func() { Execute("/chat set up my tax project with 3 tasks") })
+	SetLineReader(nil)
+	if !strings.Contains(client.results[0], "No projects") && !strings.Contains(client.results[0], "Projects") {
+		t.Errorf("Expected the read-only tool to run, got %q", client.results[0])
+	}
+	if !strings.Contains(client.results[2], "Planned as step 2") || !strings.Contains(output, "3. set the due date of the new task \"Gather W-2s\" to 2030-04-01") || asked != "Run this plan? [y/N] " {
+		t.Errorf("Expected a three-step plan to approve, got %v: %s", client.results, output)
+	}
+	tasks, _ := GetStore().ListAllTasks()
+	if len(tasks) != 1 || tasks[0].DueDate == nil || tasks[0].DueDate.Format("2006-01-02") != "2030-04-01" || projectName(tasks[0].ProjectID) != "Taxes" {
+		t.Fatalf("Expected the plan run in order, got %v: %s", tasks, output)
+	}
+
+	// Outside the REPL it waits for a yes in the next message
+	client.calls = []llm.ToolCall{{Name: "project", Arguments: map[string]any{"name": "Garden"}}}
+	output = captureOutput(func() { Execute("/chat --plan start a garden project") })
+	if !strings.Contains(output, "Reply yes to run this plan") {
+		t.Errorf("Expected the plan left waiting, got: %s", output)
+	}
+	if projects, _ := GetStore().ListProjects(); len(projects) != 1 {
+		t.Errorf("Expected nothing created before approval, got %d projects", len(projects))
+	}
+	captureOutput(func() { Execute("/chat yes") })
+	projects, _ := GetStore().ListProjects()
+	if len(projects) != 2 {
+		t.Errorf("Expected the approved plan to create Garden, got %d projects", len(projects))
+	}
+
+	// Anything else drops it, and is sent as a message
+	client.calls = []llm.ToolCall{{Name: "project", Arguments: map[string]any{"name": "Chores"}}}
+	captureOutput(func() { Execute("/chat --plan add a chores project") })
+	client.calls = nil
+	output = captureOutput(func() { Execute("/chat never mind") })
+	if !strings.Contains(output, "Plan dropped") || pendingPlan != nil {
+		t.Errorf("Expected the plan dropped, got: %s", output)
+	}
+	if projects, _ := GetStore().ListProjects(); len(projects) != 2 {
+		t.Errorf("Expected no Chores project, got %d projects", len(projects))
+	}
+}
+
+func TestChatInterrupt(t *testing.T) {
+	cleanup := setupTestStore(t)
+	defer cleanup()
+	defer func() { chatHistory = nil }()
+
+	client := &fakeChatClient{call: "projects", args: map[string]any{}}
+	SetLLMClient(client)
+	defer SetLLMClient(nil)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	SetCommandContext(ctx)
+	defer SetCommandContext(nil)
+
+	output := captureOutput(func() { Execute("/chat plan the garden") })
+	if !strings.Contains(output, "Interrupted.") || strings.Contains(output, "Error:") {
+		t.Errorf("Expected interrupted chat, got: %s", output)
+	}
+	if client.result != "" {
+		t.Errorf("Expected no tool run after the interrupt, got: %s", client.result)
+	}
+	for _, msg := range chatHistory {
+		if msg.Content == "plan the garden" {
+			t.Error("Interrupted message should be left out of the history")
+		}
+	}
+}
+
+// fakeSummaryClient answers /chat like fakeChatClient, and summarizes
+// history when asked
+type fakeSummaryClient struct {
+	fakeChatClient
+	prompt  string
+	history []*llm.Message
+}
+
+func (f *fakeSummaryClient) Chat(ctx context.Context, prompt string) (*llm.Response, error) {
+	f.prompt = prompt
+	return &llm.Response{Text: "The user planned the garden."}, nil
+}
+
+func (f *fakeSummaryClient) ChatWithTools(ctx context.Context, message string, history []*llm.Message, tools []*llm.Tool, executor llm.ToolExecutor) (*llm.Response, []*llm.Message, error) {
+	f.history = history
+	return f.fakeChatClient.ChatWithTools(ctx, message, history, tools, executor)
+}
+
+func TestChatSummarizesLongHistory(t *testing.T) {
+	cleanup := setupTestStore(t)
+	defer cleanup()
+	defer func() { chatHistory, debugMode = nil, false }()
+	SetConfig(&config.Config{ContextTokens: 200})
+	defer SetConfig(&config.Config{})
+
+	client := &fakeSummaryClient{fakeChatClient: fakeChatClient{call: "projects", args: map[string]any{}}}
+	SetLLMClient(client)
+	defer SetLLMClient(nil)
+
+	// Short histories are sent as they are
+	captureOutput(func() { Execute("/chat plan the garden") })
+	if client.prompt != "" {
+		t.Fatalf("Expected no summary for a short history, got: %s", client.prompt)
+	}
+
+	for i := 0; i < 6; i++ {
+		chatHistory = append(chatHistory,
+			&llm.Message{Role: "user", Content: "message " + strconv.Itoa(i) + strings.Repeat(" words", 40)},
+			&llm.Message{Role: "assistant", Content: "Done."})
+	}
+	debugMode = true
+	output := captureOutput(func() { Execute("/chat what's next?") })
+
+	if !strings.Contains(client.prompt, "User: plan the garden") || strings.Contains(client.prompt, "message 5") {
+		t.Errorf("Expected only older turns summarized, got: %s", client.prompt)
+	}
+	if !strings.Contains(output, "[DEBUG] Summarized") {
+		t.Errorf("Expected debug output about the summary, got: %s", output)
+	}
+	sent := client.history
+	if sent[0].Role != "system" || !strings.HasPrefix(sent[1].Content, summaryPrefix) || !strings.Contains(sent[1].Content, "planned the garden") {
+		t.Fatalf("Expected the system prompt then the summary, got %q and %q", sent[0].Content, sent[1].Content)
+	}
+	if !strings.HasPrefix(sent[3].Content, "message 2") || len(sent) != 3+2*keepRecentTurns {
+		t.Errorf("Expected the latest turns kept, got %d messages starting %q", len(sent), sent[3].Content)
+	}
+}
+
+func TestDebugLogFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "llm.jsonl")
+	SetDebugLogPath(path)
+	defer SetDebugLogPath("")
+	defer llm.SetLogFile("")
+
+	output := captureOutput(func() { Execute("/debug file") })
+	if !strings.Contains(output, "Logging LLM requests, responses, and tool calls to "+path) || llm.LogFile() != path {
+		t.Errorf("Expected logging to the default file, got: %s", output)
+	}
+	if _, err := os.Stat(path); err != nil {
+		t.Errorf("Expected the log file created: %v", err)
+	}
+	output = captureOutput(func() { Execute("/debug") })
+	captureOutput(func() { Execute("/debug") })
+	if !strings.Contains(output, "Debug mode: ON") || !strings.Contains(output, "being logged to "+path) {
+		t.Errorf("Expected the toggle to mention the log, got: %s", output)
+	}
+
+	other := filepath.Join(t.TempDir(), "other.jsonl")
+	captureOutput(func() { Execute("/debug log " + other) })
+	if llm.LogFile() != other {
+		t.Errorf("Expected logging moved to %s, got %q", other, llm.LogFile())
+	}
+	output = captureOutput(func() { Execute("/debug file off") })
+	if !strings.Contains(output, "Stopped logging LLM traffic to "+other) || llm.LogFile() != "" {
+		t.Errorf("Expected logging stopped, got: %s", output)
+	}
+	if output := captureOutput(func() { Execute("/debug verbose") }); !strings.Contains(output, "Usage: /debug") {
+		t.Errorf("Expected usage, got: %s", output)
+	}
+}
+
+func TestOfflineChat(t *testing.T) {
+	cleanup := setupTestStore(t)
+	defer cleanup()
+	SetLLMClient(nil)
+
+	home := extractShortcut(captureOutput(func() { Execute("/project Home") }))
+
+	output := captureOutput(func() { Execute("/chat Add Buy milk to home due tomorrow.") })
+	if !strings.Contains(output, "(offline) /task "+home+" Buy milk due:tomorrow") || !strings.Contains(output, "Created task: Buy milk") {
+		t.Fatalf("Expected task created offline, got: %s", output)
+	}
+	captureOutput(func() { Execute("/chat add Buy bread to home") })
+
+	output = captureOutput(func() { Execute("/chat what's due tomorrow?") })
+	if !strings.Contains(output, "(offline) /tomorrow") || !strings.Contains(output, "Buy milk") {
+		t.Errorf("Expected tomorrow's tasks, got: %s", output)
+	}
+
+	// A name has to pick out one task
+	output = captureOutput(func() { Execute("/chat mark buy done") })
+	if !strings.Contains(output, "matches several tasks") {
+		t.Errorf("Expected ambiguous name refused, got: %s", output)
+	}
+	output = captureOutput(func() { Execute("/chat buy milk done") })
+	if !strings.Contains(output, "Marked task Buy milk as done") {
+		t.Errorf("Expected task done, got: %s", output)
+	}
+	output = captureOutput(func() { Execute("/chat what did I finish this week?") })
+	if !strings.Contains(output, "(offline) /log week") || !strings.Contains(output, "Buy milk") {
+		t.Errorf("Expected this week's log, got: %s", output)
+	}
+	output = captureOutput(func() { Execute("/chat buy bread due 2030-01-02") })
+	if !strings.Contains(output, "Set due date for task Buy bread to 2030-01-02") {
+		t.Errorf("Expected due date set, got: %s", output)
+	}
+
+	output = captureOutput(func() { Execute("/chat make me a sandwich") })
+	if !strings.Contains(output, "Offline assistant") || !strings.Contains(output, "/providers") {
+		t.Errorf("Expected offline help, got: %s", output)
+	}
+}
+
+func TestProvidersCommand(t *testing.T) {
+	t.Setenv("OPENROUTER_API_KEY", "")
+	t.Setenv("GEMINI_API_KEY", "key")
+	t.Setenv("OPENAI_BASE_URL", "")
+	t.Setenv("TWOOMS_LLM_PROVIDER", "")
+	t.Setenv("LLM_PROVIDER", "")
+	SetLLMClient(nil)
+
+	output := captureOutput(func() { Execute("/providers") })
+	for _, want := range []string{"Chat: offline assistant", "openrouter inactive  OPENROUTER_API_KEY not set", "gemini     active    GEMINI_API_KEY set", "ollama     inactive  not selected"} {
+		if !strings.Contains(output, want) {
+			t.Errorf("Expected %q, got: %s", want, output)
+		}
+	}
+
+	t.Setenv("TWOOMS_LLM_PROVIDER", "ollama")
+	output = captureOutput(func() { Execute("/providers") })
+	if !strings.Contains(output, "ollama     active") || !strings.Contains(output, "gemini     inactive  skipped") {
+		t.Errorf("Expected only ollama active, got: %s", output)
+	}
+}
+
+func TestChatDryRun(t *testing.T) {
+	cleanup := setupTestStore(t)
+	defer cleanup()
+	defer func() { chatHistory, dryRunMode = nil, false }()
+
+	client := &fakeChatClient{}
+	SetLLMClient(client)
+	defer SetLLMClient(nil)
+
+	work := extractShortcut(captureOutput(func() { Execute("/project Work") }))
+	taskID, _ := GetStore().ResolveTaskID(extractTaskID(captureOutput(func() { Execute("/task " + work + " Mow lawn") })))
+
+	client.call, client.args = "task", map[string]any{"project_id": work, "task_name": "Write report"}
+	output := captureOutput(func() { Execute("/chat --dry-run add a report task") })
+	if !strings.Contains(client.result, `Dry run: would create task "Write report" in project Work`) {
+		t.Errorf("Expected the task creation described, got: %s", client.result)
+	}
+	if tasks, _ := GetStore().ListAllTasks(); len(tasks) != 1 {
+		t.Errorf("Expected no task created, got %d tasks", len(tasks))
+	}
+	if len(chatHistory) > 1 || !strings.Contains(output, "left out of the conversation") {
+		t.Errorf("Expected only the system prompt in the history, got %d messages: %s", len(chatHistory), output)
+	}
+
+	captureOutput(func() { Execute("/dryrun on") })
+	client.call, client.args = "done", map[string]any{"task_id": taskID}
+	captureOutput(func() { Execute("/chat finish mowing") })
+	if task, _ := GetStore().GetTask(taskID); task.Done || !strings.Contains(client.result, `would mark task "Mow lawn" done`) {
+		t.Errorf("Expected the task left open and described, got: %s", client.result)
+	}
+
+	// Read-only tools still run
+	client.call, client.args = "projects", map[string]any{}
+	captureOutput(func() { Execute("/chat what projects do I have") })
+	if !strings.Contains(client.result, "Work") || strings.Contains(client.result, "Dry run") {
+		t.Errorf("Expected projects listed, got: %s", client.result)
+	}
+
+	captureOutput(func() { Execute("/dryrun off") })
+	client.call, client.args = "done", map[string]any{"task_id": taskID}
+	captureOutput(func() { Execute("/chat finish mowing") })
+	if task, _ := GetStore().GetTask(taskID); !task.Done {
+		t.Errorf("Expected the task done with dry run off, got: %s", client.result)
+	}
+}
+
+func TestSafeMode(t *testing.T) {
+	cleanup := setupTestStore(t)
+	defer cleanup()
+	defer func() { chatHistory, safeMode = nil, false }()
+
+	client := &fakeChatClient{}
+	SetLLMClient(client)
+	defer SetLLMClient(nil)
+
+	work := extractShortcut(captureOutput(func() { Execute("/project Work") }))
+	captureOutput(func() { Execute("/safemode on") })
+
+	client.call, client.args = "task", map[string]any{"project_id": work, "task_name": "Write report"}
+	captureOutput(func() { Execute("/chat add a report task") })
+	if !strings.Contains(client.result, "safe mode is on, so task was not run") {
+		t.Errorf("Expected the call refused, got: %s", client.result)
+	}
+	if tasks, _ := GetStore().ListAllTasks(); len(tasks) != 0 {
+		t.Errorf("Expected no task created, got %d tasks", len(tasks))
+	}
+
+	client.call, client.args = "projects", map[string]any{}
+	captureOutput(func() { Execute("/chat what projects do I have") })
+	if !strings.Contains(client.result, "Work") {
+		t.Errorf("Expected read-only tools to run, got: %s", client.result)
+	}
+
+	// Typed commands aren't affected
+	if output := captureOutput(func() { Execute("/task " + work + " Mow lawn") }); !strings.Contains(output, "Created task") {
+		t.Errorf("Expected typed command to run, got: %s", output)
+	}
+
+	if output := captureOutput(func() { Execute("/tools") }); !strings.Contains(output, "projects             read-only") || !strings.Contains(output, "task                 changes") {
+		t.Errorf("Expected tools classified, got: %s", output)
+	}
+
+	captureOutput(func() { Execute("/safemode off") })
+	client.call, client.args = "task", map[string]any{"project_id": work, "task_name": "Write report"}
+	captureOutput(func() { Execute("/chat add a report task") })
+	if !strings.Contains(client.result, "Created task: Write report") {
+		t.Errorf("Expected the call to run with safe mode off, got: %s", client.result)
+	}
+}
+
+func TestConfirmPolicy(t *testing.T) {
+	cleanup := setupTestStore(t)
+	defer cleanup()
+	defer func() { chatHistory = nil }()
+
+	configPath := filepath.Join(t.TempDir(), "config.json")
+	c, _ := config.Load(configPath)
+	SetConfig(c)
+	defer SetConfig(&config.Config{})
+
+	client := &fakeChatClient{}
+	SetLLMClient(client)
+	defer SetLLMClient(nil)
+
+	work := extractShortcut(captureOutput(func() { Execute("/project Work") }))
+	home := extractShortcut(captureOutput(func() { Execute("/project Home") }))
+
+	// The default asks nothing
+	if output := captureOutput(func() { Execute("/confirm") }); !strings.Contains(output, "Confirmation is never") {
+		t.Errorf("Expected never by default, got: %s", output)
+	}
+
+	captureOutput(func() { Execute("/confirm destructive") })
+	data, _ := os.ReadFile(configPath)
+	if !strings.Contains(string(data), `"confirm": "destructive"`) {
+		t.Errorf("Expected policy saved to config, got: %s", data)
+	}
+
+	// Outside the REPL, deleting needs --yes
+	output := captureOutput(func() { Execute("/delproject " + home) })
+	if !strings.Contains(output, "Run again with --yes to apply.") {
+		t.Errorf("Expected --yes required, got: %s", output)
+	}
+	if _, err := GetStore().ResolveProjectID(home); err != nil {
+		t.Errorf("Expected project kept, got: %v", err)
+	}
+	if output := captureOutput(func() { Execute("/delproject " + home + " --yes") }); !strings.Contains(output, "Moved project Home and its tasks to the trash") {
+		t.Errorf("Expected deletion with --yes, got: %s", output)
+	}
+
+	// Bulk commands only ask under always
+	if output := captureOutput(func() { Execute("/taskbatch " + work + " A; B") }); !strings.Contains(output, "Created 2 tasks") {
+		t.Errorf("Expected batch to run under destructive, got: %s", output)
+	}
+	captureOutput(func() { Execute("/confirm always") })
+	if output := captureOutput(func() { Execute("/taskbatch " + work + " C; D") }); !strings.Contains(output, "Run again with --yes") {
+		t.Errorf("Expected batch to need --yes, got: %s", output)
+	}
+
+	// In the REPL the user is asked
+	var asked []string
+	answer := "n"
+	SetLineReader(func(prompt string) (string, error) {
+		asked = append(asked, prompt)
+		return answer, nil
+	})
+	defer SetLineReader(nil)
+
+	taskID := extractTaskID(captureOutput(func() { Execute("/task " + work + " Old task") }))
+	output = captureOutput(func() { Execute("/deltask " + taskID) })
+	if len(asked) != 1 || asked[0] != "Delete task Old task? [y/N] " || !strings.Contains(output, "Cancelled. Nothing changed.") {
+		t.Errorf("Expected the user asked and the deletion cancelled, got %q: %s", asked, output)
+	}
+
+	// Chat's bulk tool calls are asked about too
+	client.call, client.args = "tasks_create_batch", map[string]any{"project_id": work, "tasks": "E; F"}
+	captureOutput(func() { Execute("/chat add E and F") })
+	if !strings.Contains(client.result, "the user declined") || !strings.Contains(asked[len(asked)-1], `Chat wants to create 2 tasks in project Work: "E", "F"`) {
+		t.Errorf("Expected the call declined, got %q: %s", asked, client.result)
+	}
+	answer = "y"
+	captureOutput(func() { Execute("/chat add E and F") })
+	if !strings.Contains(client.result, "Created 2 tasks") {
+		t.Errorf("Expected the call approved, got: %s", client.result)
+	}
+
+	captureOutput(func() { Execute("/confirm never") })
+	asked = nil
+	if output := captureOutput(func() { Execute("/deltask " + taskID) }); !strings.Contains(output, "Moved task to the trash") || len(asked) != 0 {
+		t.Errorf("Expected deletion without asking, got %q: %s", asked, output)
+	}
+}
+
+func TestFocusCommand(t *testing.T) {
+	cleanup := setupTestStore(t)
+	defer cleanup()
+	defer setFocus("")
+
+	client := &fakeChatClient{}
+	SetLLMClient(client)
+	defer SetLLMClient(nil)
+
+	work := extractShortcut(captureOutput(func() { Execute("/project Work") }))
+	home := extractShortcut(captureOutput(func() { Execute("/project Home") }))
+	homeTask := extractTaskID(captureOutput(func() { Execute("/task " + home + " Mow lawn") }))
+
+	output := captureOutput(func() { Execute("/focus " + work) })
+	if !strings.Contains(output, "Chat focused on Work") || FocusedProject() != "Work" {
+		t.Fatalf("Expected focus on Work, got: %s", output)
+	}
+
+	// project_id is filled in from the focus, even if the model names another project
+	client.call, client.args = "task", map[string]any{"project_id": home, "task_name": "Write report"}
+	captureOutput(func() { Execute("/chat add a report task") })
+	if !strings.Contains(client.result, "Created task: Write report") {
+		t.Errorf("Expected task created, got: %s", client.result)
+	}
+	tasks, _ := GetStore().ListTasks(focusProjectID)
+	if len(tasks) != 1 {
+		t.Errorf("Expected task in focused project, got %d tasks", len(tasks))
+	}
+
+	// Only project- and task-scoped tools are offered, without project_id
+	for _, tool := range client.tools {
+		if tool.Name == "projects" || tool.Name == "search" {
+			t.Errorf("Expected %s to be unavailable while focused", tool.Name)
+		}
+		if _, ok := tool.Parameters.Properties["project_id"]; ok {
+			t.Errorf("Expected project_id to be dropped from %s", tool.Name)
+		}
+	}
+	if !strings.Contains(client.system, "FOCUSED PROJECT: ["+work+"] Work") || strings.Contains(client.system, "Home") {
+		t.Errorf("Expected snapshot of Work only, got: %s", client.system)
+	}
+
+	// Tasks from other projects are rejected
+	client.call, client.args = "done", map[string]any{"task_id": homeTask}
+	captureOutput(func() { Execute("/chat finish mowing") })
+	if !strings.Contains(client.result, "outside the focused project") {
+		t.Errorf("Expected cross-project task rejected, got: %s", client.result)
+	}
+
+	// Each scope keeps its own history
+	focused := len(chatHistory)
+	captureOutput(func() { Execute("/focus off") })
+	if len(chatHistory) != 0 {
+		t.Errorf("Expected empty unscoped history, got %d messages", len(chatHistory))
+	}
+	captureOutput(func() { Execute("/focus " + work) })
+	if len(chatHistory) != focused {
+		t.Errorf("Expected focused history restored (%d messages), got %d", focused, len(chatHistory))
+	}
+}
+
+// failingUpdateStore is a store that can't mark tasks done
+type failingUpdateStore struct {
+	storage.Store
+}
+
+func (failingUpdateStore) UpdateTask(id string, done bool) error {
+	return errors.New("disk full")
+}
+
+func TestFocusTask(t *testing.T) {
+	cleanup := setupTestStore(t)
+	defer cleanup()
+	defer clearTaskFocus()
+	SetConfig(&config.Config{FocusTimer: true})
+	defer SetConfig(&config.Config{})
+
+	client := &fakeChatClient{}
+	SetLLMClient(client)
+	defer SetLLMClient(nil)
+
+	work := extractShortcut(captureOutput(func() { Execute("/project Work") }))
+	taskID := extractTaskID(captureOutput(func() { Execute("/task " + work + " Write report") }))
+
+	output := captureOutput(func() { Execute("/focus " + taskID) })
+	if !strings.Contains(output, "Working on Write report") || !strings.Contains(output, "Started timer for Write report") {
+		t.Fatalf("Expected the task in focus with its timer started, got: %s", output)
+	}
+	if got := Prompt(); got != "[Write report]> " {
+		t.Errorf("Expected the focused task in the default prompt, got %q", got)
+	}
+	captureOutput(func() { Execute("/focus " + work) })
+	defer setFocus("")
+	if got := Prompt(); got != "Work [Write report]> " {
+		t.Errorf("Expected the project then the task, got %q", got)
+	}
+
+	// Chat knows what "this" is
+	client.call, client.args = "note", map[string]any{"task_id": taskID, "text": "Use the Q3 numbers"}
+	captureOutput(func() { Execute("/chat add a note to this") })
+	if !strings.Contains(client.system, "FOCUSED TASK") || !strings.Contains(client.system, "["+taskID+"] Write report (Work)") {
+		t.Errorf("Expected the focused task in the system prompt, got: %s", client.system)
+	}
+
+	// A task that can't be marked done stays in focus, timer running
+	store := GetStore()
+	SetStore(failingUpdateStore{store})
+	output = captureOutput(func() { Execute("/focus done") })
+	SetStore(store)
+	if !strings.Contains(output, "Error: disk full") || strings.Contains(output, "Stopped timer") || FocusedTask() != "Write report" {
+		t.Errorf("Expected focus and the timer kept after /done failed, got: %s", output)
+	}
+	if active, _ := GetStore().ActiveTimer(); active == nil {
+		t.Errorf("Expected the timer still running after /done failed")
+	}
+
+	output = captureOutput(func() { Execute("/focus done") })
+	if !strings.Contains(output, "Stopped timer for Write report") || !strings.Contains(output, "Write report") || FocusedTask() != "" {
+		t.Errorf("Expected the timer stopped and focus cleared, got: %s", output)
+	}
+	fullID, _ := GetStore().ResolveTaskID(taskID)
+	if task, _ := GetStore().GetTask(fullID); task == nil || !task.Done {
+		t.Errorf("Expected /focus done to complete the task")
+	}
+	if output := captureOutput(func() { Execute("/focus done") }); !strings.Contains(output, "Error: no task in focus") {
+		t.Errorf("Expected an error with nothing in focus, got: %s", output)
+	}
+	if output := captureOutput(func() { Execute("/focus " + taskID) }); !strings.Contains(output, "already done") {
+		t.Errorf("Expected a done task refused, got: %s", output)
+	}
+	if output := captureOutput(func() { Execute("/focus nothing-here") }); !strings.Contains(output, "no project or task matches") {
+		t.Errorf("Expected an error for an unknown ID, got: %s", output)
+	}
+}
+
+func TestFocusByName(t *testing.T) {
+	cleanup := setupTestStore(t)
+	defer cleanup()
+	defer clearTaskFocus()
+	defer setFocus("")
+
+	reports := extractShortcut(captureCommandOutput(t, "/project Weekly Reports"))
+	captureCommandOutput(t, "/task "+reports+" Reports")
+	captureCommandOutput(t, "/task "+reports+" Plan budget")
+	captureCommandOutput(t, "/project Planning")
+	captureCommandOutput(t, "/project Groceries")
+	captureCommandOutput(t, "/project Growth")
+
+	// A task's whole name beats part of a project's
+	if output := captureCommandOutput(t, "/focus reports"); !strings.Contains(output, "Working on Reports") {
+		t.Errorf("Expected the exact task name to win, got: %s", output)
+	}
+	if output := captureCommandOutput(t, "/focus planning"); !strings.Contains(output, "Chat focused on Planning") {
+		t.Errorf("Expected the exact project name to win, got: %s", output)
+	}
+	if output := captureCommandOutput(t, "/focus gro"); !strings.Contains(output, "ambiguous project name") {
+		t.Errorf("Expected the ambiguity reported, got: %s", output)
+	}
+	if output := captureCommandOutput(t, "/focus plan"); !strings.Contains(output, "matches project Planning and task Plan budget") {
+		t.Errorf("Expected parts of both names reported, got: %s", output)
+	}
+}
+
+func TestChatSessions(t *testing.T) {
+	cleanup := setupTestStore(t)
+	defer cleanup()
+
+	path := filepath.Join(t.TempDir(), "chat.json")
+	if err := LoadChatSessions(path); err != nil {
+		t.Fatalf("LoadChatSessions failed: %v", err)
+	}
+	defer func() {
+		LoadChatSessions("")
+		chatHistory = nil
+	}()
+
+	client := &fakeChatClient{call: "projects", args: map[string]any{}, tokens: 10}
+	SetLLMClient(client)
+	defer SetLLMClient(nil)
+
+	// Commands alone aren't saved as a conversation
+	AddCommandContext("/projects", "No projects")
+	captureOutput(func() { Execute("/clearchat") })
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("Expected no chat file before any chat, got err %v", err)
+	}
+
+	captureOutput(func() { Execute("/chat plan the garden") })
+	captureOutput(func() { Execute("/clearchat") })
+	captureOutput(func() { Execute("/chat review the budget") })
+
+	output := captureCommandOutput(t, "/sessions")
+	if !strings.Contains(output, `"review the budget"`) || !strings.Contains(output, `"plan the garden"`) ||
+		strings.Index(output, "budget") > strings.Index(output, "garden") {
+		t.Fatalf("Expected both conversations, most recent first, got: %s", output)
+	}
+	if !strings.Contains(output, "* 1.") || !strings.Contains(output, "1 prompts, 20 tokens") {
+		t.Errorf("Expected current conversation marked with its usage, got: %s", output)
+	}
+
+	// A restart continues the most recent conversation
+	chatHistory = nil
+	if err := LoadChatSessions(path); err != nil {
+		t.Fatalf("LoadChatSessions failed: %v", err)
+	}
+	if len(chatHistory) == 0 || chatHistory[0].Role == "system" || !strings.Contains(chatHistory[0].Content, "review the budget") {
+		t.Fatalf("Expected saved conversation without system prompt, got %d messages", len(chatHistory))
+	}
+	captureOutput(func() { Execute("/chat and the rent") })
+	if !strings.HasPrefix(client.system, "You are a helpful task management assistant") {
+		t.Errorf("Expected system prompt restored, got: %s", client.system)
+	}
+
+	output = captureCommandOutput(t, "/resume 2")
+	if !strings.Contains(output, `"plan the garden"`) || !strings.Contains(chatHistory[0].Content, "plan the garden") {
+		t.Errorf("Expected garden conversation resumed, got: %s", output)
+	}
+	if output := captureCommandOutput(t, "/resume 9"); !strings.Contains(output, "Error: no conversation 9") {
+		t.Errorf("Expected error for missing conversation, got: %s", output)
+	}
+}
+
+func TestPromptCacheUsage(t *testing.T) {
+	cleanup := setupTestStore(t)
+	defer cleanup()
+	defer func() { chatHistory = nil }()
+	sessionPromptCount, sessionInputTokens, sessionOutputTokens, sessionCachedTokens = 0, 0, 0, 0
+
+	client := &fakeChatClient{call: "projects", args: map[string]any{}, tokens: 10, cached: 5}
+	SetLLMClient(client)
+	defer SetLLMClient(nil)
+
+	output := captureOutput(func() { Execute("/chat plan the garden") })
+	if !strings.Contains(output, "[Tokens: 10 in (5 cached) / 10 out") {
+		t.Errorf("Expected cached tokens in the usage line, got: %s", output)
+	}
+
+	// Only the fixed rules are marked cacheable; the date and snapshot follow them
+	if client.prefix != len(systemRules) || !strings.HasPrefix(client.system, systemRules) || !strings.Contains(client.system[client.prefix:], "CURRENT TIME: ") {
+		t.Errorf("Expected the rules as the cacheable prefix, got %d bytes of: %s", client.prefix, client.system)
+	}
+
+	if output := captureCommandOutput(t, "/usage"); !strings.Contains(output, "Cached input:  5 (50% of input tokens)") {
+		t.Errorf("Expected cache savings in /usage, got: %s", output)
+	}
+}
+
+func TestUsageBudget(t *testing.T) {
+	cleanup := setupTestStore(t)
+	defer cleanup()
+	defer func() { chatHistory = nil; budgetOverride = false }()
+	t.Setenv("TWOOMS_MONTHLY_BUDGET", "1.00")
+	t.Setenv("TWOOMS_REQUEST_WARN", "0.50")
+
+	ledgerPath := filepath.Join(t.TempDir(), "usage.json")
+	if err := LoadUsageLedger(ledgerPath); err != nil {
+		t.Fatalf("Failed to load ledger: %v", err)
+	}
+	defer LoadUsageLedger("")
+
+	client := &fakeChatClient{call: "projects", args: map[string]any{}, tokens: 10, cost: 0.30}
+	SetLLMClient(client)
+	defer SetLLMClient(nil)
+
+	output := captureOutput(func() { Execute("/chat what's next?") })
+	if strings.Contains(output, "Warning:") {
+		t.Errorf("Expected no warning for a cheap request, got: %s", output)
+	}
+
+	// Crossing 80% of the budget warns, as does an expensive request
+	client.cost = 0.55
+	output = captureOutput(func() { Execute("/chat what's next?") })
+	if !strings.Contains(output, "Warning: that request cost $0.5500, more than the $0.5000 warning threshold") ||
+		!strings.Contains(output, "Warning: $0.8500 of the $1.00 monthly LLM budget used") {
+		t.Errorf("Expected request and budget warnings, got: %s", output)
+	}
+
+	// The ledger is saved, and a new session picks it up
+	client.cost = 0.20
+	output = captureOutput(func() { Execute("/chat what's next?") })
+	if !strings.Contains(output, "budget of $1.00 is used up ($1.0500 spent)") {
+		t.Errorf("Expected the budget used up, got: %s", output)
+	}
+	if err := LoadUsageLedger(ledgerPath); err != nil || monthUsage().Prompts != 3 {
+		t.Fatalf("Expected 3 prompts in the saved ledger, got %+v, %v", monthUsage(), err)
+	}
+
+	output = captureOutput(func() { Execute("/chat what's next?") })
+	if !strings.Contains(output, "Error: the monthly LLM budget of $1.00 is used up") || strings.Contains(output, "[Tokens:") {
+		t.Errorf("Expected the request refused, got: %s", output)
+	}
+	output = captureCommandOutput(t, "/usage")
+	if !strings.Contains(output, "Total cost:    $1.0500") || !strings.Contains(output, "Budget:        $1.00, used up; /usage override") {
+		t.Errorf("Expected month-to-date spend in /usage, got: %s", output)
+	}
+
+	captureCommandOutput(t, "/usage override")
+	if output := captureOutput(func() { Execute("/chat what's next?") }); !strings.Contains(output, "[Tokens:") {
+		t.Errorf("Expected the override to allow requests, got: %s", output)
+	}
+}
+
+func TestTrimSessionMessages(t *testing.T) {
+	var messages []*llm.Message
+	for i := 0; i < maxSessionMessages; i++ {
+		messages = append(messages, &llm.Message{Role: "user"}, &llm.Message{Role: "tool"})
+	}
+	messages = append(messages, &llm.Message{Role: "tool"})
+
+	trimmed := trimSessionMessages(messages)
+	if len(trimmed) > maxSessionMessages || len(trimmed) == 0 || trimmed[0].Role != "user" {
+		t.Errorf("Expected at most %d messages starting with a user message, got %d", maxSessionMessages, len(trimmed))
+	}
+}
+
+func TestWorkspace(t *testing.T) {
+	cleanup := setupTestStore(t)
+	defer cleanup()
+
+	dir := t.TempDir()
+	c, _ := config.Load(filepath.Join(dir, "config.json"))
+	SetConfig(c)
+	defer SetConfig(&config.Config{})
+
+	SetWorkspaceOpener(func(name string) (*Workspace, error) {
+		if name == "" {
+			name = "default"
+		}
+		store, err := storage.NewJSONStore(filepath.Join(dir, name+".json"))
+		if err != nil {
+			return nil, err
+		}
+		return &Workspace{Store: store, ChatPath: filepath.Join(dir, name+".chat.json"), BackupDir: filepath.Join(dir, name+".backups")}, nil
+	})
+	defer SetWorkspaceOpener(nil)
+	defer func() { activeWorkspace = "" }()
+	if err := OpenWorkspace(""); err != nil {
+		t.Fatal(err)
+	}
+
+	captureCommandOutput(t, "/project Home")
+	if output := captureCommandOutput(t, "/workspace create Bad_Name"); !strings.Contains(output, "Error:") {
+		t.Errorf("Expected invalid name error, got: %s", output)
+	}
+	if output := captureCommandOutput(t, "/workspace create work"); !strings.Contains(output, "Created workspace work") {
+		t.Errorf("Expected workspace created, got: %s", output)
+	}
+	if output := captureCommandOutput(t, "/workspace create Work"); !strings.Contains(output, "already exists") {
+		t.Errorf("Expected duplicate error, got: %s", output)
+	}
+
+	output := captureCommandOutput(t, "/workspace switch work")
+	if !strings.Contains(output, "Switched to workspace work, 0 projects (saved)") {
+		t.Errorf("Expected switch, got: %s", output)
+	}
+	if Prompt() != "[work] > " {
+		t.Errorf("Expected workspace in the prompt, got %q", Prompt())
+	}
+	captureCommandOutput(t, "/project Job")
+
+	// Commands only see the active workspace
+	output = captureCommandOutput(t, "/projects")
+	if !strings.Contains(output, "Job") || strings.Contains(output, "Home") {
+		t.Errorf("Expected only work projects, got: %s", output)
+	}
+
+	data, _ := os.ReadFile(filepath.Join(dir, "config.json"))
+	if !strings.Contains(string(data), `"workspace": "work"`) {
+		t.Errorf("Expected active workspace saved, got: %s", data)
+	}
+
+	output = captureCommandOutput(t, "/workspace")
+	if !strings.Contains(output, "  default") || !strings.Contains(output, "* work") {
+		t.Errorf("Expected work marked active, got: %s", output)
+	}
+
+	captureCommandOutput(t, "/workspace switch default")
+	output = captureCommandOutput(t, "/projects")
+	if !strings.Contains(output, "Home") || strings.Contains(output, "Job") {
+		t.Errorf("Expected only default projects, got: %s", output)
+	}
+	if Prompt() != "> " {
+		t.Errorf("Expected plain prompt in the default workspace, got %q", Prompt())
+	}
+	if output := captureCommandOutput(t, "/workspace switch personal"); !strings.Contains(output, "no workspace named personal") {
+		t.Errorf("Expected unknown workspace error, got: %s", output)
+	}
+}
+
+func TestSync(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+	for _, key := range []string{"GIT_AUTHOR_NAME", "GIT_COMMITTER_NAME"} {
+		t.Setenv(key, "twooms")
+	}
+	for _, key := range []string{"GIT_AUTHOR_EMAIL", "GIT_COMMITTER_EMAIL"} {
+		t.Setenv(key, "twooms@example.com")
+	}
+	t.Setenv("GIT_CONFIG_GLOBAL", os.DevNull)
+
+	dir := t.TempDir()
+	run := func(args ...string) string {
+		out, err := exec.Command("git", args...).CombinedOutput()
+		if err != nil {
+			t.Fatalf("git %v: %v\n%s", args, err, out)
+		}
+		return strings.TrimSpace(string(out))
+	}
+	remote := filepath.Join(dir, "remote.git")
+	run("init", "--quiet", "--bare", "--initial-branch=main", remote)
+	laptop, desktop := filepath.Join(dir, "laptop"), filepath.Join(dir, "desktop")
+	run("clone", "--quiet", remote, laptop)
+	run("clone", "--quiet", remote, desktop)
+	defer SetConfig(&config.Config{})
+
+	// The laptop's existing tasks go up with its first sync
+	cleanup := setupTestStore(t)
+	defer cleanup()
+	laptopStore := GetStore()
+	SetConfig(&config.Config{Sync: &config.SyncConfig{Repo: laptop}})
+	if output := captureCommandOutput(t, "/sync"); !strings.Contains(output, "Not synced yet") {
+		t.Errorf("Expected status before the first sync, got: %s", output)
+	}
+	work := extractShortcut(captureCommandOutput(t, "/project Work"))
+	report := extractTaskID(captureCommandOutput(t, "/task "+work+" Write report"))
+	if output := captureCommandOutput(t, "/sync now"); !strings.Contains(output, "Synced.") {
+		t.Fatalf("Expected the first sync, got: %s", output)
+	}
+
+	// Changes are committed as they're made
+	captureCommandOutput(t, "/priority "+report+" high")
+	if output := captureCommandOutput(t, "/sync status"); !strings.Contains(output, "1 commits to push, 0 to pull") || !strings.Contains(output, "/priority on") {
+		t.Errorf("Expected the change committed, got: %s", output)
+	}
+	PushSync()
+
+	// The desktop merges its own project in and gets the laptop's
+	cleanup2 := setupTestStore(t)
+	defer cleanup2()
+	SetConfig(&config.Config{Sync: &config.SyncConfig{Repo: desktop}})
+	captureCommandOutput(t, "/project Home")
+	if output := captureCommandOutput(t, "/sync now"); !strings.Contains(output, "Synced from origin: 2 added") {
+		t.Fatalf("Expected the laptop's project and task, got: %s", output)
+	}
+	captureCommandOutput(t, "/done "+report)
+	PushSync()
+
+	// Meanwhile the laptop tags the same task; syncing merges both by ID
+	SetStore(laptopStore)
+	SetConfig(&config.Config{Sync: &config.SyncConfig{Repo: laptop}})
+	captureCommandOutput(t, "/tag "+report+" q1")
+
+	// Anything else in the repository stops the sync instead of being reset away
+	if err := os.WriteFile(filepath.Join(laptop, "notes.txt"), []byte("mine"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	run("-C", laptop, "add", "notes.txt")
+	if output := captureCommandOutput(t, "/sync now"); !strings.Contains(output, "uncommitted changes") {
+		t.Errorf("Expected uncommitted changes to stop the sync, got: %s", output)
+	}
+	run("-C", laptop, "commit", "--quiet", "-m", "Notes")
+	if output := captureCommandOutput(t, "/sync now"); !strings.Contains(output, "local commits to notes.txt") {
+		t.Errorf("Expected an unpushed commit to stop the sync, got: %s", output)
+	}
+	run("-C", laptop, "reset", "--quiet", "--hard", "HEAD~1")
+
+	if output := captureCommandOutput(t, "/sync now"); !strings.Contains(output, "Synced from origin: 1 added, 1 changed") {
+		t.Errorf("Expected Home added and the task merged, got: %s", output)
+	}
+	id, _ := GetStore().ResolveTaskID(report)
+	task, _ := GetStore().GetTask(id)
+	if !task.Done || !task.HasTag("q1") || task.Priority != storage.PriorityHigh {
+		t.Errorf("Expected the changes from both machines, got %+v", task)
+	}
+	if merges := run("-C", remote, "rev-list", "--merges", "--count", "main"); merges != "0" {
+		t.Errorf("Expected linear history, got %s merge commits", merges)
+	}
+}
+
+func TestNewDaySummary(t *testing.T) {
+	cleanup := setupTestStore(t)
+	defer cleanup()
+
+	output := captureCommandOutput(t, "/project Home")
+	shortcut := extractShortcut(output)
+	now := time.Now()
+	for name, days := range map[string]int{"Pay rent": -1, "Old chore": -3, "Call mom": 0, "Next week": 7} {
+		due := now.AddDate(0, 0, days).Format("2006-01-02")
+		captureCommandOutput(t, "/task "+shortcut+" "+name+" due:"+due)
+	}
+
+	summary := newDaySummary(now.AddDate(0, 0, -1), now)
+	if !strings.Contains(summary, "New day: "+now.Format("Monday 2006-01-02")) {
+		t.Errorf("Expected the new date, got: %s", summary)
+	}
+	if !strings.Contains(summary, "1 tasks became overdue") || !strings.Contains(summary, "Pay rent (Home, due") {
+		t.Errorf("Expected only the newly overdue task, got: %s", summary)
+	}
+	if strings.Contains(summary, "Old chore") || !strings.Contains(summary, "1 tasks due today.") {
+		t.Errorf("Expected older overdue tasks left out and one due today, got: %s", summary)
+	}
+
+	summary = newDaySummary(now.AddDate(0, 0, -4), now)
+	if !strings.Contains(summary, "(4 days since the last summary)") || !strings.Contains(summary, "2 tasks became overdue") {
+		t.Errorf("Expected tasks overdue since the last summary, got: %s", summary)
+	}
+
+	defer SetConfig(&config.Config{})
+	for setting, want := range map[string]time.Duration{"": time.Hour, "off": 0, "30m": 30 * time.Minute} {
+		SetConfig(&config.Config{NewDayIdle: setting})
+		if idle, err := NewDayIdle(); err != nil || idle != want {
+			t.Errorf("NewDayIdle with %q = %v, %v; want %v", setting, idle, err, want)
+		}
+	}
+	SetConfig(&config.Config{NewDayIdle: "soon"})
+	if _, err := NewDayIdle(); err == nil {
+		t.Error("Expected an error for an invalid new_day_idle")
+	}
+}
+
+func TestToolsExport(t *testing.T) {
+	var doc struct {
+		OpenAPI string `json:"openapi"`
+		Paths   map[string]struct {
+			Post struct {
+				OperationID string `json:"operationId"`
+				RequestBody struct {
+					Content map[string]struct {
+						Schema struct {
+							Type       string                    `json:"type"`
+							Properties map[string]map[string]any `json:"properties"`
+							Required   []string                  `json:"required"`
+						} `json:"schema"`
+					} `json:"content"`
+				} `json:"requestBody"`
+			} `json:"post"`
+		} `json:"paths"`
+	}
+	output := captureOutput(func() { Execute("/tools export") })
+	if err := json.Unmarshal([]byte(output), &doc); err != nil {
+		t.Fatalf("Expected JSON, got %v: %s", err, output)
+	}
+	if doc.OpenAPI != "3.1.0" || len(doc.Paths) != len(GenerateToolDefinitions()) {
+		t.Errorf("Expected an OpenAPI path per tool, got %s with %d paths", doc.OpenAPI, len(doc.Paths))
+	}
+	task := doc.Paths["/tools/task"].Post
+	schema := task.RequestBody.Content["application/json"].Schema
+	if task.OperationID != "task" || schema.Type != "object" || strings.Join(schema.Required, ",") != "project_id,task_name" {
+		t.Errorf("Unexpected task operation: %+v", task)
+	}
+	if schema.Properties["project_id"]["type"] != "string" {
+		t.Errorf("Expected project_id property, got %v", schema.Properties)
+	}
+
+	path := filepath.Join(t.TempDir(), "tools.json")
+	if output := captureOutput(func() { Execute("/tools export schema " + path) }); !strings.Contains(output, "Exported") {
+		t.Errorf("Expected export to file, got: %s", output)
+	}
+	data, _ := os.ReadFile(path)
+	var schemaDoc map[string]any
+	if err := json.Unmarshal(data, &schemaDoc); err != nil {
+		t.Fatalf("Expected JSON Schema file, got %v", err)
+	}
+	defs, _ := schemaDoc["$defs"].(map[string]any)
+	if schemaDoc["$schema"] == nil || defs["done"] == nil || defs["workspace"] != nil {
+		t.Errorf("Expected $defs for each tool, got %v", schemaDoc)
+	}
+
+	if output := captureOutput(func() { Execute("/tools export yaml") }); !strings.Contains(output, "Error: unknown format") {
+		t.Errorf("Expected unknown format error, got: %s", output)
+	}
+}
+
+func TestValidateToolArgs(t *testing.T) {
+	tools := GenerateToolDefinitions()
+	tomorrow := time.Now().AddDate(0, 0, 1).Format("2006-01-02")
+
+	tests := []struct {
+		name string
+		tool string
+		args map[string]any
+		want []string // substrings of the error; none means valid
+	}{
+		{"valid", "due", map[string]any{"task_id": "abc", "date": "2026-01-05"}, nil},
+		{"clear date", "due", map[string]any{"task_id": "abc", "date": "none"}, nil},
+		{"due time", "due", map[string]any{"task_id": "abc", "date": "2026-01-05", "time": "14:30"}, nil},
+		{"bad time", "due", map[string]any{"task_id": "abc", "date": "2026-01-05", "time": "2pm"}, []string{"time: must be a 24-hour HH:MM time"}},
+		{"date word", "due", map[string]any{"task_id": "abc", "date": "tomorrow"}, []string{"date: must be a YYYY-MM-DD date", "use " + tomorrow}},
+		{"missing required", "task", map[string]any{"task_name": "Buy milk"}, []string{"project_id: required"}},
+		{"enum", "priority", map[string]any{"task_id": "abc", "priority": "critical"}, []string{"priority: must be one of"}},
+		{"enum case", "priority", map[string]any{"task_id": "abc", "priority": "HIGH"}, nil},
+		{"boolean", "plan", map[string]any{"hours": "4", "why": "yes"}, []string{"why: must be true or false"}},
+		{"number as string param", "plan", map[string]any{"hours": 4.0}, nil},
+		{"unknown argument", "done", map[string]any{"task_id": "abc", "when": "now"}, []string{"when: unknown argument (expected: task_id)"}},
+		{"several problems", "due", map[string]any{"date": "friday"}, []string{"task_id: required", "date: must be"}},
+		{"tool not offered", "deltask", map[string]any{"task_id": "abc"}, []string{"no tool named deltask"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			msg := validateToolArgs(tools, tt.tool, tt.args)
+			if len(tt.want) == 0 && msg != "" {
+				t.Errorf("Expected valid call, got: %s", msg)
+			}
+			for _, want := range tt.want {
+				if !strings.Contains(msg, want) {
+					t.Errorf("Expected %q in: %s", want, msg)
+				}
+			}
+		})
+	}
+}
+
+func TestSplitArgs(t *testing.T) {
+	tests := []struct {
+		line string
+		want []string
+	}{
+		{`work Buy milk`, []string{"work", "Buy", "milk"}},
+		{`  work   "Fix bug #12: crash"  `, []string{"work", "Fix bug #12: crash"}},
+		{`'Home Renovation' 'He said "hi"'`, []string{"Home Renovation", `He said "hi"`}},
+		{`"a \"quoted\" word" "back\\slash"`, []string{`a "quoted" word`, `back\slash`}},
+		{`Don't forget Bob's keys`, []string{"Don't", "forget", "Bob's", "keys"}},
+		{`'Fix Bob's bike' today`, []string{"Fix Bob's bike", "today"}},
+		{`"never closed`, []string{`"never`, "closed"}},
+		{`C:\temp\file.json ""`, []string{`C:\temp\file.json`, ""}},
+	}
+	for _, tt := range tests {
+		got := SplitArgs(tt.line)
+		if strings.Join(got, "|") != strings.Join(tt.want, "|") || len(got) != len(tt.want) {
+			t.Errorf("SplitArgs(%q) = %q, want %q", tt.line, got, tt.want)
+		}
+		// Joining quotes whatever needs it, so the words survive another split
+		if again := SplitArgs(JoinArgs(got)); strings.Join(again, "|") != strings.Join(got, "|") || len(again) != len(got) {
+			t.Errorf("SplitArgs(JoinArgs(%q)) = %q", got, again)
+		}
+	}
+}
+
+func TestBenchCommand(t *testing.T) {
+	cleanup := setupTestStore(t)
+	defer cleanup()
+
+	output := captureCommandOutput(t, "/bench 150 --backend json")
+	if !strings.Contains(output, "Backend      Tasks    Create") || !strings.Contains(output, "json*          150") {
+		t.Errorf("Expected a row for the JSON store in use, got: %s", output)
+	}
+	if strings.Contains(output, "  bolt ") {
+		t.Errorf("Expected only the chosen backend, got: %s", output)
+	}
+	if projects, _ := GetStore().ListProjects(); len(projects) != 0 {
+		t.Error("Expected the benchmark to leave the real store alone")
+	}
+
+	for input, want := range map[string]string{
+		"/bench 10x":              `invalid dataset size "10x"`,
+		"/bench 2000k":            `invalid dataset size "2000k"`,
+		"/bench --backend sqlite": `unknown backend "sqlite" (use json or bolt)`,
+		"/bench --profile":        "--profile needs a value",
+	} {
+		if output := captureCommandOutput(t, input); !strings.Contains(output, want) || !strings.Contains(output, "Usage: /bench") {
+			t.Errorf("%s: expected %q, got: %s", input, want, output)
+		}
+	}
+	if sizes, _, _, _ := parseBenchArgs([]string{"1k", "10K", "2500"}); len(sizes) != 3 || sizes[0] != 1000 || sizes[1] != 10000 || sizes[2] != 2500 {
+		t.Errorf("Expected sizes 1000, 10000, 2500, got %v", sizes)
+	}
+}
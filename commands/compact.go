@@ -0,0 +1,174 @@
+package commands
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"twooms/i18n"
+	"twooms/storage"
+)
+
+// defaultStoreWarnMB is the data file size that triggers the startup
+// warning, when the config doesn't say
+const defaultStoreWarnMB = 5
+
+// defaultArchiveDays is how long ago tasks must have been completed for the
+// startup warning to suggest archiving them
+const defaultArchiveDays = 90
+
+func init() {
+	Register(&Command{
+		Name:        "/compact",
+		Description: "Shrink the data file: move tasks completed more than N days ago to an archive file, and drop the undo history",
+		Hidden:      true,
+		Destructive: true,
+		Interactive: true,
+		Params: []Param{
+			{Name: "days", Type: ParamTypeInteger, Description: "Archive tasks completed more than this many days ago (omit to only drop the undo history)", Required: false},
+			{Name: "file", Type: ParamTypeString, Description: "Optional archive file (defaults to twooms-completed-<date>.json)", Required: false},
+		},
+		Handler: func(args []string) bool {
+			yes := false
+			var rest []string
+			for _, arg := range args {
+				if arg == "--yes" {
+					yes = true
+				} else {
+					rest = append(rest, arg)
+				}
+			}
+			if len(rest) > 2 {
+				fmt.Println(i18n.T("compact.usage"))
+				return false
+			}
+
+			days := 0
+			if len(rest) > 0 {
+				n, err := strconv.Atoi(rest[0])
+				if err != nil || n < 1 {
+					fmt.Println(i18n.T("compact.usage"))
+					return false
+				}
+				days = n
+			}
+			filename := completedArchiveName()
+			if len(rest) > 1 {
+				filename = rest[1]
+			}
+
+			size, err := GetStore().Size()
+			if err != nil {
+				fmt.Printf("Error: %v\n", err)
+				return false
+			}
+			fmt.Printf("The data file is %s.\n", formatMB(size))
+			if days > 0 {
+				old, err := storage.CompletedBefore(GetStore(), archiveCutoff(days))
+				if err != nil {
+					fmt.Printf("Error: %v\n", err)
+					return false
+				}
+				fmt.Printf("  Move %d tasks completed more than %d days ago to %s\n", len(old), days, filename)
+			}
+			fmt.Println("  Drop the undo history (this can't be undone)")
+
+			if !yes {
+				if lineReader == nil {
+					fmt.Println("Run again with --yes to apply.")
+					return false
+				}
+				answer, err := lineReader("Apply? [y/N] ")
+				if err != nil || !strings.EqualFold(strings.TrimSpace(answer), "y") {
+					fmt.Println("Cancelled. Nothing changed.")
+					return false
+				}
+			}
+
+			runCompaction(days, filename)
+			return false
+		},
+	})
+}
+
+// StoreWarnSize returns the data file size in bytes above which startup
+// warns (store_warn_mb in the config), or 0 if the warning is turned off
+func StoreWarnSize() int64 {
+	mb := GetConfig().StoreWarnMB
+	switch {
+	case mb < 0:
+		return 0
+	case mb == 0:
+		mb = defaultStoreWarnMB
+	}
+	return int64(mb) << 20
+}
+
+// WarnStoreSize prints a warning when the data file has grown past
+// store_warn_mb, saying what /compact would do about it, and offers to do
+// it with Enter at the next prompt (REPL only)
+func WarnStoreSize() {
+	limit := StoreWarnSize()
+	if limit == 0 {
+		return
+	}
+	size, err := GetStore().Size()
+	if err != nil || size <= limit {
+		return
+	}
+
+	old, err := storage.CompletedBefore(GetStore(), archiveCutoff(defaultArchiveDays))
+	if err != nil {
+		return
+	}
+
+	fmt.Printf("Your data file is %s, over the %s warning size, which slows down every change.\n", formatMB(size), formatMB(limit))
+	days, filename := 0, completedArchiveName()
+	if len(old) > 0 {
+		days = defaultArchiveDays
+		fmt.Printf("  /compact %d moves the %d tasks completed more than %d days ago to %s\n", days, len(old), days, filename)
+	}
+	fmt.Println("  /compact drops the undo history")
+	if days > 0 {
+		fmt.Println("Press Enter to do both now.")
+	} else {
+		fmt.Println("Press Enter to compact now.")
+	}
+	offerSuggestion(func() { runCompaction(days, filename) })
+}
+
+// runCompaction archives tasks completed more than days ago to filename
+// (none if days is 0), then compacts the store
+func runCompaction(days int, filename string) {
+	if days > 0 {
+		archived, err := storage.ArchiveCompletedTasks(GetStore(), archiveCutoff(days), filename)
+		if err != nil {
+			fmt.Printf("Error archiving tasks: %v\n", err)
+			return
+		}
+		if len(archived) > 0 {
+			fmt.Printf("Moved %d completed tasks to %s (bring them back with /import %s)\n", len(archived), filename, filename)
+		}
+	}
+
+	c, err := GetStore().Compact()
+	if err != nil {
+		fmt.Printf("Error compacting: %v\n", err)
+		return
+	}
+	fmt.Printf("Dropped %d undo entries. The data file went from %s to %s.\n", c.UndoEntries, formatMB(c.SizeBefore), formatMB(c.SizeAfter))
+}
+
+// archiveCutoff is the completion time before which tasks are archived
+func archiveCutoff(days int) time.Time {
+	return time.Now().AddDate(0, 0, -days)
+}
+
+func completedArchiveName() string {
+	return fmt.Sprintf("twooms-completed-%s.json", time.Now().Format("2006-01-02"))
+}
+
+func formatMB(bytes int64) string {
+	return fmt.Sprintf("%.1f MB", float64(bytes)/(1<<20))
+}
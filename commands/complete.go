@@ -0,0 +1,119 @@
+package commands
+
+import (
+	"sort"
+	"strings"
+
+	"twooms/storage"
+)
+
+// Completion is one Tab-completion candidate
+type Completion struct {
+	Text        string // the full word, which starts with what was typed
+	Description string // shown alongside the candidate (e.g. a task name), may be empty
+}
+
+// Complete returns candidates for the word being typed at the end of line,
+// where line is the input up to the cursor. Command names are completed after
+// "/", and arguments are completed from the command's Params: project
+// shortcuts for project_id, task ID prefixes for task_id, and the allowed
+// values of enum params. Matching is case-sensitive: readline can only add
+// to what was typed, so every candidate must start with the word exactly.
+func Complete(line string) []Completion {
+	word := line[strings.LastIndex(line, " ")+1:]
+	// The words before it, split as Execute splits them, so a quoted
+	// argument like "buy milk" is one
+	before := SplitArgs(line[:len(line)-len(word)])
+
+	// First word: command names
+	if len(before) == 0 {
+		if !strings.HasPrefix(word, "/") {
+			return nil
+		}
+		return completeCommands(word)
+	}
+
+	cmd, exists := Lookup(before[0])
+	if !exists || strings.HasPrefix(word, "--") {
+		return nil
+	}
+
+	// Count the positional arguments before the word (flags don't take a slot)
+	index := 0
+	for _, arg := range before[1:] {
+		if !strings.HasPrefix(arg, "--") {
+			index++
+		}
+	}
+	if index >= len(cmd.Params) {
+		return nil
+	}
+
+	if enum := cmd.Params[index].Enum; len(enum) > 0 {
+		return completeValues(word, enum)
+	}
+	if cmd.Params[index].Format == ParamFormatDuration {
+		return completeValues(word, durationValues())
+	}
+	switch cmd.Params[index].Name {
+	case "project_id":
+		return completeProjects(word)
+	case "task_id", "blocking_task_id":
+		return completeTasks(word)
+	}
+	return nil
+}
+
+func completeCommands(prefix string) []Completion {
+	var completions []Completion
+	for _, cmd := range List() {
+		if strings.HasPrefix(cmd.Name, prefix) {
+			completions = append(completions, Completion{Text: cmd.Name})
+		}
+	}
+	sort.Slice(completions, func(i, j int) bool { return completions[i].Text < completions[j].Text })
+	return completions
+}
+
+func completeValues(prefix string, values []string) []Completion {
+	var completions []Completion
+	for _, v := range values {
+		if strings.HasPrefix(v, prefix) {
+			completions = append(completions, Completion{Text: v})
+		}
+	}
+	return completions
+}
+
+func completeProjects(prefix string) []Completion {
+	projects, err := GetStore().ListProjects()
+	if err != nil {
+		return nil
+	}
+	var completions []Completion
+	for _, p := range projects {
+		if strings.HasPrefix(p.Shortcut, prefix) {
+			completions = append(completions, Completion{Text: p.Shortcut, Description: storage.DisplayName(p.Name)})
+		}
+	}
+	return completions
+}
+
+func completeTasks(prefix string) []Completion {
+	tasks, err := GetStore().ListAllTasks()
+	if err != nil {
+		return nil
+	}
+	var completions []Completion
+	for _, t := range tasks {
+		if !strings.HasPrefix(t.ID, prefix) {
+			continue
+		}
+		text := shortenID(t.ID)
+		if len(prefix) > len(text) {
+			text = t.ID
+		}
+		completions = append(completions, Completion{Text: text, Description: storage.DisplayName(t.Name)})
+	}
+	return completions
+}
@@ -0,0 +1,139 @@
+package commands
+
+import (
+	"fmt"
+	"strings"
+
+	"twooms/i18n"
+)
+
+// Confirmation policies set with /confirm
+const (
+	confirmAlways      = "always"      // destructive and bulk commands, typed or run by chat
+	confirmDestructive = "destructive" // destructive commands typed at the prompt
+	confirmNever       = "never"       // nothing asks (the default)
+)
+
+// yesFlag skips the confirmation question, for scripts
+const yesFlag = "--yes"
+
+func init() {
+	Register(&Command{
+		Name:        "/confirm",
+		Description: "Show or set which commands ask before changing data: always, destructive, or never (the choice is saved)",
+		Access:      AccessRead,
+		Hidden:      true, // a safety setting for the user, not something the model should flip
+		Handler: func(args []string) bool {
+			if len(args) == 0 {
+				fmt.Println(i18n.T("confirm.current", confirmPolicy()))
+				return false
+			}
+
+			policy := strings.ToLower(args[0])
+			switch policy {
+			case confirmAlways, confirmDestructive, confirmNever:
+			default:
+				fmt.Println(i18n.T("confirm.usage"))
+				return false
+			}
+
+			GetConfig().Confirm = policy
+			if policy == confirmNever {
+				GetConfig().Confirm = ""
+			}
+			if err := GetConfig().Save(); err != nil {
+				fmt.Printf("Confirmation is %s for this session, but could not save it: %v\n", policy, err)
+				return false
+			}
+
+			switch policy {
+			case confirmAlways:
+				fmt.Println("Confirmation: always. Deleting, and adding or importing many tasks at once, ask first, including when chat does it. Add --yes to skip the question.")
+			case confirmDestructive:
+				fmt.Println("Confirmation: destructive. /delproject and /deltask ask first. Add --yes to skip the question.")
+			default:
+				fmt.Println("Confirmation: never. Commands run without asking.")
+			}
+			return false
+		},
+	})
+}
+
+// confirmPolicy returns the saved /confirm policy; a missing or unknown one
+// is never
+func confirmPolicy() string {
+	switch policy := GetConfig().Confirm; policy {
+	case confirmAlways, confirmDestructive:
+		return policy
+	}
+	return confirmNever
+}
+
+// needsConfirm reports whether the policy makes cmd ask before it runs
+func needsConfirm(cmd *Command) bool {
+	switch confirmPolicy() {
+	case confirmAlways:
+		return cmd.Destructive || cmd.Bulk
+	case confirmDestructive:
+		return cmd.Destructive
+	}
+	return false
+}
+
+// takeYes removes --yes from args and reports whether it was there
+func takeYes(args []string) ([]string, bool) {
+	yes := false
+	var rest []string
+	for _, arg := range args {
+		if arg == yesFlag {
+			yes = true
+		} else {
+			rest = append(rest, arg)
+		}
+	}
+	return rest, yes
+}
+
+// confirm asks the question before the command name changes data, if the
+// policy says it should. Outside the REPL nobody can answer, so the command
+// is refused until it's run again with --yes.
+func confirm(name, question string, yes bool) bool {
+	cmd, ok := Lookup(name)
+	if yes || !ok || !needsConfirm(cmd) {
+		return true
+	}
+	return askYes(question)
+}
+
+// askYes asks a yes/no question at the prompt; outside the REPL the answer
+// is no, with a hint to run the command again with --yes
+func askYes(question string) bool {
+	if lineReader == nil {
+		fmt.Println(i18n.T("confirm.rerun"))
+		return false
+	}
+	answer, err := lineReader(question + " [y/N] ")
+	if err != nil || !strings.EqualFold(strings.TrimSpace(answer), "y") {
+		fmt.Println(i18n.T("confirm.cancelled"))
+		return false
+	}
+	return true
+}
+
+// confirmRefusal asks the user before a chat tool call the policy covers,
+// and returns the tool result if they decline or can't be asked, or "" if
+// the tool may run
+func confirmRefusal(name string, args map[string]any) string {
+	cmd, ok := toolCommand(name)
+	if !ok || !needsConfirm(cmd) {
+		return ""
+	}
+	if lineReader == nil {
+		return fmt.Sprintf("Error: %s was not run because /confirm needs the user to approve it, and they can't be asked here. They can run it themselves with --yes.", name)
+	}
+	answer, err := lineReader("Chat wants to " + newDryRun().describe(name, args) + ". Allow? [y/N] ")
+	if err != nil || !strings.EqualFold(strings.TrimSpace(answer), "y") {
+		return fmt.Sprintf("Error: the user declined, so %s was not run. Ask what they'd like instead.", name)
+	}
+	return ""
+}
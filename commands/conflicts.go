@@ -0,0 +1,188 @@
+package commands
+
+import (
+	"fmt"
+	"strings"
+
+	"twooms/storage"
+)
+
+func init() {
+	Register(&Command{
+		Name:        "/conflicts",
+		Shorthand:   "/cf",
+		Description: "Review queued conflicts one at a time (mine, theirs, or merge)",
+		Hidden:      true,
+		Params: []Param{
+			{Name: "resolution", Type: ParamTypeString, Description: "How to resolve the current conflict: mine, theirs, or merge", Required: false, Enum: []string{"mine", "theirs", "merge"}},
+		},
+		Handler: func(args []string) bool {
+			conflicts, err := GetStore().ListConflicts()
+			if err != nil {
+				fmt.Printf("Error: %v\n", err)
+				return false
+			}
+
+			if len(conflicts) == 0 {
+				fmt.Println("No conflicts to resolve.")
+				return false
+			}
+
+			if len(args) > 0 {
+				if err := resolveConflict(conflicts[0], strings.ToLower(args[0])); err != nil {
+					fmt.Printf("Error: %v\n", err)
+					return false
+				}
+				conflicts = conflicts[1:]
+				if len(conflicts) == 0 {
+					fmt.Println("\nAll conflicts resolved.")
+					return false
+				}
+				fmt.Println()
+			}
+
+			showConflict(conflicts[0], len(conflicts))
+			return false
+		},
+	})
+}
+
+// showConflict prints the current conflict with both versions side by side
+func showConflict(c *storage.Conflict, remaining int) {
+	fmt.Printf("Conflict 1 of %d: %s %s", remaining, c.Kind, shortenID(c.EntityID))
+	if c.Source != "" {
+		fmt.Printf(" (from %s)", c.Source)
+	}
+	fmt.Println()
+
+	var mine, theirs []string
+	switch c.Kind {
+	case "project":
+		if p, err := GetStore().GetProject(c.EntityID); err == nil {
+			mine = describeProject(p)
+		}
+		theirs = describeProject(c.Project)
+	case "task":
+		if t, err := GetStore().GetTask(c.EntityID); err == nil {
+			mine = describeTask(t)
+		}
+		theirs = describeTask(c.Task)
+	}
+	if mine == nil {
+		mine = []string{"(deleted)"}
+	}
+
+	fmt.Printf("  %-36s %s\n", "MINE", "THEIRS")
+	for i := 0; i < len(mine) || i < len(theirs); i++ {
+		var left, right string
+		if i < len(mine) {
+			left = mine[i]
+		}
+		if i < len(theirs) {
+			right = theirs[i]
+		}
+		marker := " "
+		if left != right {
+			marker = "*"
+		}
+		// Pad by display width, so wide characters in names don't push THEIRS out of line
+		fmt.Printf("%s %s %s\n", marker, storage.PadText(storage.TruncateText(left, 36), 36), right)
+	}
+
+	options := "mine|theirs"
+	if c.Kind == "task" {
+		options += "|merge"
+	}
+	fmt.Printf("\nResolve with /conflicts <%s>\n", options)
+}
+
+// resolveConflict applies the chosen resolution and removes the conflict
+// from the queue, in one transaction so a conflict is never left queued
+// after its resolution was applied
+func resolveConflict(c *storage.Conflict, resolution string) error {
+	var msg string
+	op := fmt.Sprintf("resolve conflict on %s %s", c.Kind, shortenID(c.EntityID))
+	err := GetStore().Transact(op, func(tx storage.Store) error {
+		switch resolution {
+		case "mine":
+			msg = fmt.Sprintf("Kept your version of %s %s", c.Kind, shortenID(c.EntityID))
+		case "theirs":
+			if err := applyTheirs(tx, c); err != nil {
+				return err
+			}
+			msg = fmt.Sprintf("Applied incoming version of %s %s", c.Kind, shortenID(c.EntityID))
+		case "merge":
+			if c.Kind != "task" {
+				return fmt.Errorf("merge is only supported for tasks; choose mine or theirs")
+			}
+			mine, err := tx.GetTask(c.EntityID)
+			if err != nil {
+				if err := applyTheirs(tx, c); err != nil {
+					return err
+				}
+				msg = fmt.Sprintf("Applied incoming version of %s %s", c.Kind, shortenID(c.EntityID))
+				break
+			}
+			if err := tx.ReplaceTask(storage.MergeTasks(mine, c.Task)); err != nil {
+				return err
+			}
+			msg = fmt.Sprintf("Merged task %s", mine.Name)
+		default:
+			return fmt.Errorf("unknown resolution: %s (use mine, theirs, or merge)", resolution)
+		}
+		return tx.DeleteConflict(c.ID)
+	})
+	if err != nil {
+		return err
+	}
+	fmt.Println(msg)
+	return nil
+}
+
+// applyTheirs writes the incoming version, recreating it if it was deleted locally
+func applyTheirs(s storage.Store, c *storage.Conflict) error {
+	if c.Kind == "project" {
+		if _, err := s.GetProject(c.EntityID); err != nil {
+			return s.ImportProject(c.Project)
+		}
+		return s.ReplaceProject(c.Project)
+	}
+	if _, err := s.GetTask(c.EntityID); err != nil {
+		return s.ImportTask(c.Task)
+	}
+	return s.ReplaceTask(c.Task)
+}
+
+func describeProject(p *storage.Project) []string {
+	return []string{
+		"name: " + storage.CleanName(p.Name),
+		"shortcut: " + p.Shortcut,
+	}
+}
+
+func describeTask(t *storage.Task) []string {
+	due := "none"
+	if t.DueDate != nil {
+		due = storage.FormatDue(*t.DueDate)
+	}
+	duration := t.Duration.String()
+	if duration == "" {
+		duration = "none"
+	}
+	return []string{
+		"name: " + storage.CleanName(t.Name),
+		fmt.Sprintf("done: %v", t.Done),
+		"due: " + due,
+		"duration: " + duration,
+		"tags: " + strings.Join(t.Tags, ", "),
+		"project: " + shortenID(t.ProjectID),
+	}
+}
+
+// shortenID returns the first 8 characters of an ID for display
+func shortenID(id string) string {
+	if len(id) > 8 {
+		return id[:8]
+	}
+	return id
+}
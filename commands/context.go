@@ -0,0 +1,21 @@
+package commands
+
+import "context"
+
+// commandCtx is cancelled when the user interrupts the running command (the
+// REPL sets one per line), so LLM requests can stop mid-flight
+var commandCtx = context.Background()
+
+// SetCommandContext sets the context LLM requests run under until the next
+// call; nil restores one that is never cancelled
+func SetCommandContext(ctx context.Context) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	commandCtx = ctx
+}
+
+// commandContext returns the context for the running command's requests
+func commandContext() context.Context {
+	return commandCtx
+}
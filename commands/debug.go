@@ -1,27 +1,87 @@
 package commands
 
-import "fmt"
+import (
+	"fmt"
+	"strings"
+
+	"twooms/i18n"
+	"twooms/llm"
+)
 
 var debugMode bool
 
+// debugLogPath is where /debug file logs LLM traffic without a path;
+// main.go sets it
+var debugLogPath string
+
+// SetDebugLogPath sets the default file for /debug file
+func SetDebugLogPath(path string) {
+	debugLogPath = path
+}
+
 func init() {
 	Register(&Command{
 		Name:        "/debug",
 		Shorthand:   "/db",
-		Description: "Toggle debug mode for LLM interactions",
+		Description: "Toggle debug mode for LLM interactions, or log raw API requests, responses, and tool calls to a JSONL file",
+		Examples:    []string{"/debug", "/debug file", "/debug file /tmp/llm.jsonl", "/debug file off"},
 		Hidden:      true,
+		Params: []Param{
+			{Name: "file", Type: ParamTypeString, Description: "file (or log) to start logging, optionally followed by a path or off", Required: false},
+		},
 		Handler: func(args []string) bool {
+			if len(args) > 0 {
+				if sub := strings.ToLower(args[0]); (sub != "file" && sub != "log") || len(args) > 2 {
+					fmt.Println(i18n.T("debug.usage"))
+					return false
+				}
+				setDebugLog(args[1:])
+				return false
+			}
+
 			debugMode = !debugMode
 			if debugMode {
 				fmt.Println("Debug mode: ON")
 			} else {
 				fmt.Println("Debug mode: OFF")
 			}
+			if path := llm.LogFile(); path != "" {
+				fmt.Printf("LLM traffic is being logged to %s (/debug file off stops it)\n", path)
+			}
 			return false
 		},
 	})
 }
 
+// setDebugLog starts logging LLM traffic to the file in args (the default
+// without one), or stops with off
+func setDebugLog(args []string) {
+	if len(args) > 0 && strings.EqualFold(args[0], "off") {
+		path := llm.LogFile()
+		if path == "" {
+			fmt.Println("LLM traffic isn't being logged.")
+			return
+		}
+		llm.SetLogFile("")
+		fmt.Printf("Stopped logging LLM traffic to %s\n", path)
+		return
+	}
+
+	path := debugLogPath
+	if len(args) > 0 {
+		path = args[0]
+	}
+	if path == "" {
+		fmt.Println(i18n.T("debug.usage_file"))
+		return
+	}
+	if err := llm.SetLogFile(path); err != nil {
+		fmt.Printf("Error: %v\n", err)
+		return
+	}
+	fmt.Printf("Logging LLM requests, responses, and tool calls to %s (API keys redacted; /debug file off stops it)\n", path)
+}
+
 // IsDebugMode returns whether debug mode is enabled
 func IsDebugMode() bool {
 	return debugMode
@@ -0,0 +1,165 @@
+package commands
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"twooms/i18n"
+	"twooms/storage"
+)
+
+func init() {
+	Register(&Command{
+		Name:        "/defer",
+		Description: "Push a task's due date later: by +1d, +2w, or +1m, to the next weekday after it (next-monday), or to a date like tomorrow. Counts from today if the task has no due date or is overdue.",
+		Examples: []string{
+			"/defer 1a2b3c4d +1d",
+			"/defer 1a2b3c4d +1w",
+			"/defer 1a2b3c4d next-monday",
+		},
+		Params: []Param{
+			{Name: "task_id", Type: ParamTypeString, Description: "The ID of the task", Required: true},
+			{Name: "shift", Type: ParamTypeString, Description: "How far to push it: +Nd, +Nw, or +Nm (days, weeks, months), next-<weekday>, or a date (tomorrow, a weekday, YYYY-MM-DD)", Required: true},
+		},
+		Handler: func(args []string) bool {
+			if len(args) < 2 {
+				fmt.Println(i18n.T("defer.usage"))
+				return false
+			}
+			taskID, err := GetStore().ResolveTaskID(args[0])
+			if err != nil {
+				fmt.Printf("Error: %v\n", err)
+				return false
+			}
+			task, err := GetStore().GetTask(taskID)
+			if err != nil {
+				fmt.Printf("Error: %v\n", err)
+				return false
+			}
+			if task.Done {
+				fmt.Printf("Error: %q is already done\n", task.Name)
+				return false
+			}
+
+			// The store may hand back the task it holds, so keep the old date
+			old := task.DueDate
+			if old != nil {
+				date := *old
+				old = &date
+			}
+			due, err := storage.DeferDue(old, strings.Join(args[1:], " "), time.Now())
+			if err != nil {
+				fmt.Printf("Error: %v\n", err)
+				return false
+			}
+			if err := GetStore().SetTaskDueDate(taskID, &due); err != nil {
+				fmt.Printf("Error: %v\n", err)
+				return false
+			}
+			fmt.Printf("Deferred %q: %s\n", task.Name, dueChange(old, due))
+			return false
+		},
+	})
+
+	Register(&Command{
+		Name:        "/defer-all-overdue",
+		Description: "Push every overdue open task later in one change, e.g. +1d, or next-monday to move them all to Monday; optionally only in one project",
+		Examples: []string{
+			"/defer-all-overdue +1d",
+			"/defer-all-overdue next-monday work",
+		},
+		Bulk: true,
+		Params: []Param{
+			{Name: "shift", Type: ParamTypeString, Description: "How far to push them, counted from today: +Nd, +Nw, or +Nm, next-<weekday>, or a date (tomorrow, a weekday, YYYY-MM-DD)", Required: true},
+			{Name: "project_id", Type: ParamTypeString, Description: "Optional project ID or shortcut to limit it to", Required: false},
+		},
+		Handler: func(args []string) bool {
+			args, yes := takeYes(args)
+			if len(args) < 1 {
+				fmt.Println(i18n.T("defer-all-overdue.usage"))
+				return false
+			}
+			projectRef := ""
+			if len(args) > 1 {
+				projectRef = args[1]
+			}
+			deferOverdue(args[0], projectRef, yes)
+			return false
+		},
+		// Chat asks through confirmRefusal before a tool runs, so it isn't
+		// asked again here
+		ToolHandler: func(args map[string]any) (string, error) {
+			shift, err := stringArg(args, "shift")
+			if err != nil {
+				return "", err
+			}
+			projectRef, _ := args["project_id"].(string)
+			return captureOutput(func() { deferOverdue(shift, projectRef, true) }), nil
+		},
+	})
+}
+
+// dueChange describes a due date moving from old (nil for none) to due
+func dueChange(old *time.Time, due time.Time) string {
+	if old == nil {
+		return fmt.Sprintf("no due date -> %s", storage.FormatDue(due))
+	}
+	return fmt.Sprintf("%s -> %s", storage.FormatDue(*old), storage.FormatDue(due))
+}
+
+// deferOverdue pushes the open overdue tasks, in one project or all of them,
+// by shift as one change, so a single /undo puts them back
+func deferOverdue(shift, projectRef string, yes bool) {
+	var tasks []*storage.Task
+	var err error
+	if projectRef != "" {
+		projectID, err := GetStore().ResolveProjectID(projectRef)
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			return
+		}
+		tasks, err = GetStore().ListTasks(projectID)
+		if err != nil {
+			fmt.Printf("Error listing tasks: %v\n", err)
+			return
+		}
+	} else if tasks, err = GetStore().ListAllTasks(); err != nil {
+		fmt.Printf("Error listing tasks: %v\n", err)
+		return
+	}
+
+	now := time.Now()
+	var overdue []*storage.Task
+	dates := make(map[string]time.Time)
+	before := make(map[string]time.Time)
+	for _, t := range tasks {
+		if t.Done || t.DueDate == nil || storage.DueAt(*t.DueDate, time.Local).After(now) {
+			continue
+		}
+		due, err := storage.DeferDue(t.DueDate, shift, now)
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			return
+		}
+		overdue = append(overdue, t)
+		dates[t.ID], before[t.ID] = due, *t.DueDate
+	}
+	if len(overdue) == 0 {
+		fmt.Println("No overdue tasks.")
+		return
+	}
+	if !confirm("/defer-all-overdue", fmt.Sprintf("Defer %d overdue tasks?", len(overdue)), yes) {
+		return
+	}
+
+	if err := GetStore().SetTaskDueDates(dates); err != nil {
+		fmt.Printf("Error: %v\n", err)
+		return
+	}
+	fmt.Printf("Deferred %d overdue tasks (one /undo puts them back):\n", len(overdue))
+	for _, t := range overdue {
+		old := before[t.ID]
+		fmt.Printf("  [%s] %s: %s\n", shortenID(t.ID), storage.DisplayName(t.Name), dueChange(&old, dates[t.ID]))
+	}
+}
@@ -0,0 +1,409 @@
+package commands
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"twooms/i18n"
+	"twooms/storage"
+)
+
+func init() {
+	Register(&Command{
+		Name:        "/blocks",
+		Shorthand:   "/bl",
+		Description: "Declare that a task is blocked by another task",
+		Params: []Param{
+			{Name: "task_id", Type: ParamTypeString, Description: "The ID of the task that is blocked", Required: true},
+			{Name: "blocking_task_id", Type: ParamTypeString, Description: "The ID of the task that must be done first", Required: true},
+		},
+		Handler: func(args []string) bool {
+			if len(args) < 2 {
+				fmt.Println(i18n.T("blocks.usage"))
+				return false
+			}
+
+			// Resolve both task IDs
+			taskID, err := GetStore().ResolveTaskID(args[0])
+			if err != nil {
+				fmt.Printf("Error: %v\n", err)
+				return false
+			}
+			blockerID, err := GetStore().ResolveTaskID(args[1])
+			if err != nil {
+				fmt.Printf("Error: %v\n", err)
+				return false
+			}
+
+			// Get tasks for display
+			task, err := GetStore().GetTask(taskID)
+			if err != nil {
+				fmt.Printf("Error: %v\n", err)
+				return false
+			}
+			blocker, err := GetStore().GetTask(blockerID)
+			if err != nil {
+				fmt.Printf("Error: %v\n", err)
+				return false
+			}
+
+			if err := GetStore().AddTaskDependency(taskID, blockerID); err != nil {
+				fmt.Printf("Error: %v\n", err)
+				return false
+			}
+
+			fmt.Printf("Task %s is now blocked by %s\n", task.Name, blocker.Name)
+			return false
+		},
+	})
+
+	Register(&Command{
+		Name:        "/ready",
+		Description: "List open tasks that aren't waiting on another task, in one project or all",
+		Examples:    []string{"/ready", "/ready web"},
+		Access:      AccessRead,
+		Params: []Param{
+			{Name: "project_id", Type: ParamTypeString, Description: "The ID or shortcut of the project; omit for all projects and the inbox", Required: false},
+		},
+		Handler: func(args []string) bool {
+			projectRef := ""
+			if len(args) > 0 {
+				projectRef = args[0]
+			}
+			listReady(projectRef)
+			return false
+		},
+	})
+
+	Register(&Command{
+		Name:        "/deps",
+		Description: "Show a project's dependency graph and critical path",
+		Params: []Param{
+			{Name: "project_id", Type: ParamTypeString, Description: "The ID or shortcut of the project", Required: true},
+		},
+		Handler: func(args []string) bool {
+			dot := false
+			var rest []string
+			for _, arg := range args {
+				if arg == "--dot" {
+					dot = true
+				} else {
+					rest = append(rest, arg)
+				}
+			}
+
+			if len(rest) == 0 {
+				fmt.Println(i18n.T("deps.usage"))
+				return false
+			}
+
+			// Resolve project ID
+			projectID, err := GetStore().ResolveProjectID(rest[0])
+			if err != nil {
+				fmt.Printf("Error: %v\n", err)
+				return false
+			}
+
+			project, err := GetStore().GetProject(projectID)
+			if err != nil {
+				fmt.Printf("Error: %v\n", err)
+				return false
+			}
+
+			graph, err := buildDepGraph(projectID)
+			if err != nil {
+				fmt.Printf("Error listing tasks: %v\n", err)
+				return false
+			}
+
+			if dot {
+				printDepsDOT(project, graph)
+			} else {
+				printDepsTree(project, graph)
+			}
+			return false
+		},
+	})
+}
+
+// openBlockers returns the tasks that t still waits on: its blockers that
+// aren't done. Blockers that were deleted don't count.
+func openBlockers(t *storage.Task) []*storage.Task {
+	var open []*storage.Task
+	for _, id := range t.BlockedBy {
+		if blocker, err := GetStore().GetTask(id); err == nil && !blocker.Done {
+			open = append(open, blocker)
+		}
+	}
+	return open
+}
+
+// blockedMark renders an open task's open blockers as " [blocked by 1a2b3c4d]"
+// for listings, or "" if it isn't waiting on anything
+func blockedMark(t *storage.Task) string {
+	if t.Done {
+		return ""
+	}
+	var ids []string
+	for _, blocker := range openBlockers(t) {
+		ids = append(ids, shortenID(blocker.ID))
+	}
+	if len(ids) == 0 {
+		return ""
+	}
+	return " " + i18n.T("list.blocked", strings.Join(ids, " "))
+}
+
+// listReady prints the open tasks that can be started now, grouped by
+// project: those without open blockers and not set to the blocked status
+func listReady(projectRef string) {
+	var projects []*storage.Project
+	if projectRef != "" {
+		projectID, err := GetStore().ResolveProjectID(projectRef)
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			return
+		}
+		project, err := GetStore().GetProject(projectID)
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			return
+		}
+		projects = []*storage.Project{project}
+	} else {
+		var err error
+		if projects, err = GetStore().ListProjects(); err != nil {
+			fmt.Printf("Error listing projects: %v\n", err)
+			return
+		}
+		projects = append(projects, &storage.Project{Name: storage.InboxName})
+	}
+
+	fmt.Println("Ready to start:")
+	found := false
+	for _, p := range projects {
+		tasks, err := GetStore().ListTasks(p.ID)
+		if err != nil {
+			fmt.Printf("Error listing tasks: %v\n", err)
+			return
+		}
+		var ready []*storage.Task
+		for _, t := range tasks {
+			if st := t.CurrentStatus(); st != storage.StatusDone && st != storage.StatusBlocked && len(openBlockers(t)) == 0 {
+				ready = append(ready, t)
+			}
+		}
+		if len(ready) == 0 {
+			continue
+		}
+		found = true
+		fmt.Printf("  %s (%d):\n", p.Name, len(ready))
+		printTasks(ready, "    ")
+	}
+	if !found {
+		fmt.Println("  Nothing is ready; every open task is waiting on another")
+	}
+}
+
+// depGraph holds a project's tasks plus any blockers from other projects
+type depGraph struct {
+	tasks    []*storage.Task          // project tasks in store order
+	byID     map[string]*storage.Task // project tasks and their external blockers
+	blocks   map[string][]string      // blocker ID -> IDs of tasks it blocks
+	path     []*storage.Task          // critical path, first task to do first
+	critical map[string]bool          // IDs on the critical path
+}
+
+func buildDepGraph(projectID string) (*depGraph, error) {
+	tasks, err := GetStore().ListTasks(projectID)
+	if err != nil {
+		return nil, err
+	}
+
+	g := &depGraph{
+		tasks:    tasks,
+		byID:     make(map[string]*storage.Task),
+		blocks:   make(map[string][]string),
+		critical: make(map[string]bool),
+	}
+	for _, t := range tasks {
+		g.byID[t.ID] = t
+	}
+
+	// Pull in blockers that live in other projects so chains aren't cut short
+	all := append([]*storage.Task{}, tasks...)
+	for i := 0; i < len(all); i++ {
+		for _, id := range all[i].BlockedBy {
+			if _, ok := g.byID[id]; ok {
+				continue
+			}
+			if blocker, err := GetStore().GetTask(id); err == nil {
+				g.byID[id] = blocker
+				all = append(all, blocker)
+			}
+		}
+	}
+
+	for _, t := range all {
+		for _, id := range t.BlockedBy {
+			if _, ok := g.byID[id]; ok {
+				g.blocks[id] = append(g.blocks[id], t.ID)
+			}
+		}
+	}
+
+	g.path = storage.CriticalPath(all)
+	for _, t := range g.path {
+		g.critical[t.ID] = true
+	}
+	return g, nil
+}
+
+// printDepsTree renders each end goal (a task that blocks nothing) with its
+// blockers indented beneath it, so reading down a branch shows what has to be
+// finished first. Critical path tasks are marked with '*'.
+func printDepsTree(project *storage.Project, g *depGraph) {
+	fmt.Printf("Dependencies in %s:\n", project.Name)
+
+	var roots, independent []*storage.Task
+	for _, t := range g.tasks {
+		hasBlockers := len(g.blocks[t.ID]) > 0
+		for _, id := range t.BlockedBy {
+			if _, ok := g.byID[id]; ok {
+				hasBlockers = true
+			}
+		}
+		switch {
+		case !hasBlockers:
+			independent = append(independent, t)
+		case len(g.blocks[t.ID]) == 0:
+			roots = append(roots, t)
+		}
+	}
+
+	if len(roots) == 0 {
+		fmt.Println("  No dependencies. Declare one with /blocks <task-id> <blocking-task-id>")
+		return
+	}
+
+	// Show critical goals first
+	sort.SliceStable(roots, func(i, j int) bool {
+		return g.critical[roots[i].ID] && !g.critical[roots[j].ID]
+	})
+
+	for _, root := range roots {
+		printDepNode(g, root, "  ", "", project.ID, map[string]bool{})
+	}
+
+	if len(independent) > 0 {
+		fmt.Printf("\n%d tasks without dependencies\n", len(independent))
+	}
+
+	var path []string
+	for _, t := range g.path {
+		path = append(path, t.Name)
+	}
+	if len(path) > 1 {
+		fmt.Printf("\nCritical path (*): %s\n", strings.Join(path, " → "))
+	}
+}
+
+func printDepNode(g *depGraph, t *storage.Task, indent, branch, projectID string, seen map[string]bool) {
+	status := "[ ]"
+	if t.Done {
+		status = "[✓]"
+	}
+	marker := " "
+	if g.critical[t.ID] {
+		marker = "*"
+	}
+
+	var extras []string
+	if t.Duration != 0 {
+		extras = append(extras, t.Duration.String())
+	}
+	if t.ProjectID != projectID {
+		if p, err := GetStore().GetProject(t.ProjectID); err == nil {
+			extras = append(extras, "in "+p.Name)
+		}
+	}
+	extraStr := ""
+	if len(extras) > 0 {
+		extraStr = " (" + strings.Join(extras, ", ") + ")"
+	}
+
+	line := fmt.Sprintf("%s%s%s %s [%s] %s%s", indent, branch, marker, status, shortenID(t.ID), storage.DisplayName(t.Name), extraStr)
+	if g.critical[t.ID] {
+		line = colorOverdue(line)
+	}
+	fmt.Println(line)
+
+	// A task reachable through two branches is printed in full only once
+	if seen[t.ID] {
+		return
+	}
+	seen[t.ID] = true
+
+	childIndent := indent
+	if branch != "" {
+		childIndent += "   "
+	}
+	for _, id := range t.BlockedBy {
+		if blocker, ok := g.byID[id]; ok {
+			printDepNode(g, blocker, childIndent, "└─", projectID, seen)
+		}
+	}
+}
+
+// printDepsDOT writes the graph in Graphviz DOT format (edges point from
+// blocker to blocked task); critical path tasks are red, done tasks gray
+func printDepsDOT(project *storage.Project, g *depGraph) {
+	fmt.Printf("digraph %q {\n", project.Name)
+	fmt.Println("  rankdir=LR;")
+	fmt.Println("  node [shape=box];")
+
+	for _, t := range mapValues(g.byID) {
+		label := t.Name
+		if t.Duration != 0 {
+			label += "\\n" + t.Duration.String()
+		}
+		attrs := fmt.Sprintf("label=%q", label)
+		switch {
+		case t.Done:
+			attrs += ", style=filled, fillcolor=lightgray"
+		case g.critical[t.ID]:
+			attrs += ", color=red, penwidth=2"
+		}
+		fmt.Printf("  %q [%s];\n", shortenID(t.ID), attrs)
+	}
+
+	for _, t := range mapValues(g.byID) {
+		for _, id := range t.BlockedBy {
+			if _, ok := g.byID[id]; !ok {
+				continue
+			}
+			attrs := ""
+			if g.critical[id] && g.critical[t.ID] {
+				attrs = " [color=red, penwidth=2]"
+			}
+			fmt.Printf("  %q -> %q%s;\n", shortenID(id), shortenID(t.ID), attrs)
+		}
+	}
+	fmt.Println("}")
+}
+
+// mapValues returns the tasks in a map sorted by creation time for stable output
+func mapValues(m map[string]*storage.Task) []*storage.Task {
+	tasks := make([]*storage.Task, 0, len(m))
+	for _, t := range m {
+		tasks = append(tasks, t)
+	}
+	sort.Slice(tasks, func(i, j int) bool {
+		if tasks[i].CreatedAt.Equal(tasks[j].CreatedAt) {
+			return tasks[i].ID < tasks[j].ID
+		}
+		return tasks[i].CreatedAt.Before(tasks[j].CreatedAt)
+	})
+	return tasks
+}
@@ -0,0 +1,165 @@
+package commands
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"twooms/storage"
+)
+
+// digestGroups are the digest's sections, in the order they're printed
+var digestGroups = []string{"Overdue", "Today", "This week", "Later", "No date"}
+
+func init() {
+	Register(&Command{
+		Name:        "/digest",
+		Description: "Summarize open tasks grouped by when they're due (overdue, today, this week, later, no date), highest priority first",
+		Access:      AccessRead,
+		Params: []Param{
+			{Name: "project_id", Type: ParamTypeString, Description: "Optional project ID or shortcut, or 'all' (the default)", Required: false},
+		},
+		Handler: func(args []string) bool {
+			var project *storage.Project
+			var tasks []*storage.Task
+			var err error
+			if len(args) > 0 && strings.ToLower(args[0]) != "all" {
+				projectID, err := GetStore().ResolveProjectID(args[0])
+				if err != nil {
+					fmt.Printf("Error: %v\n", err)
+					return false
+				}
+				if project, err = GetStore().GetProject(projectID); err != nil {
+					fmt.Printf("Error: %v\n", err)
+					return false
+				}
+				tasks, err = GetStore().ListTasks(projectID)
+			} else {
+				tasks, err = GetStore().ListAllTasks()
+			}
+			if err != nil {
+				fmt.Printf("Error listing tasks: %v\n", err)
+				return false
+			}
+
+			printDigest(project, tasks, time.Now())
+			return false
+		},
+	})
+}
+
+// printDigest prints the open tasks grouped by due date, without colors or
+// IDs so it can be pasted into an email or chat message. With a nil project
+// each task is labelled with its project's name.
+func printDigest(project *storage.Project, tasks []*storage.Task, now time.Time) {
+	today := dateOnly(now)
+	weekEnd := startOfWeek(today).AddDate(0, 0, 7)
+
+	groups := make(map[string][]*storage.Task)
+	var open []*storage.Task
+	for _, t := range tasks {
+		if t.Done {
+			continue
+		}
+		open = append(open, t)
+		group := digestGroup(t, today, weekEnd)
+		groups[group] = append(groups[group], t)
+	}
+
+	scope := "all projects"
+	if project != nil {
+		scope = project.Name
+	}
+	header := fmt.Sprintf("Digest for %s (%s): %d open", scope, today.Format("2006-01-02"), len(open))
+	if minutes := storage.TotalDuration(open); minutes > 0 {
+		header += ", " + storage.FormatMinutes(minutes)
+	}
+	fmt.Println(header)
+	if len(open) == 0 {
+		fmt.Println("  Nothing open.")
+		return
+	}
+
+	projectNames := map[string]string{"": storage.InboxName}
+	if project == nil {
+		projects, _ := GetStore().ListProjects()
+		for _, p := range projects {
+			projectNames[p.ID] = p.Name
+		}
+	}
+
+	for _, name := range digestGroups {
+		group := groups[name]
+		if len(group) == 0 {
+			continue
+		}
+		sortByPriority(group)
+
+		title := fmt.Sprintf("%s (%d", name, len(group))
+		if minutes := storage.TotalDuration(group); minutes > 0 {
+			title += ", " + storage.FormatMinutes(minutes)
+		}
+		fmt.Println(title + ")")
+
+		for _, t := range group {
+			line := "  - "
+			if t.Priority != "" {
+				line += "[" + string(t.Priority) + "] "
+			}
+			line += storage.DisplayName(t.Name)
+
+			var extras []string
+			if t.Duration != 0 {
+				extras = append(extras, t.Duration.String())
+			}
+			// Today's tasks don't need their date repeated
+			if t.DueDate != nil && name != "Today" {
+				extras = append(extras, "due "+t.DueDate.Format("Mon Jan 2"))
+			}
+			if projectName, ok := projectNames[t.ProjectID]; ok {
+				extras = append(extras, projectName)
+			}
+			if len(extras) > 0 {
+				line += " (" + strings.Join(extras, ", ") + ")"
+			}
+			fmt.Println(line)
+		}
+	}
+}
+
+// digestGroup returns the digest section an open task belongs in
+func digestGroup(t *storage.Task, today, weekEnd time.Time) string {
+	if t.DueDate == nil {
+		return "No date"
+	}
+	due := dateOnly(*t.DueDate)
+	switch {
+	case due.Before(today):
+		return "Overdue"
+	case due.Equal(today):
+		return "Today"
+	case due.Before(weekEnd):
+		return "This week"
+	default:
+		return "Later"
+	}
+}
+
+// sortByPriority orders tasks highest priority first, then by due date
+// (undated last), then by creation
+func sortByPriority(tasks []*storage.Task) {
+	sort.SliceStable(tasks, func(i, j int) bool {
+		a, b := tasks[i], tasks[j]
+		if a.Priority.Rank() != b.Priority.Rank() {
+			return a.Priority.Rank() > b.Priority.Rank()
+		}
+		if (a.DueDate == nil) != (b.DueDate == nil) {
+			return b.DueDate == nil
+		}
+		if a.DueDate != nil && !a.DueDate.Equal(*b.DueDate) {
+			return a.DueDate.Before(*b.DueDate)
+		}
+		return a.CreatedAt.Before(b.CreatedAt)
+	})
+}
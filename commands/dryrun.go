@@ -0,0 +1,171 @@
+package commands
+
+import (
+	"fmt"
+	"strings"
+
+	"twooms/i18n"
+)
+
+// dryRunFlag at the start of a /chat message makes that one message a dry run
+const dryRunFlag = "--dry-run"
+
+// dryRunMode makes every /chat message a dry run, until /dryrun off
+var dryRunMode bool
+
+func init() {
+	Register(&Command{
+		Name:        "/dryrun",
+		Description: "Turn dry-run chat on or off: the assistant's changes are described instead of made",
+		Access:      AccessRead,
+		Hidden:      true, // a setting for the user, not something the model should flip
+		Handler: func(args []string) bool {
+			if len(args) == 0 {
+				state := "off"
+				if dryRunMode {
+					state = "on"
+				}
+				fmt.Println(i18n.T("dryrun.current", state))
+				return false
+			}
+			switch strings.ToLower(args[0]) {
+			case "on":
+				dryRunMode = true
+				fmt.Println("Dry run on: /chat describes changes instead of making them; read-only tools still run.")
+			case "off":
+				dryRunMode = false
+				fmt.Println("Dry run off: /chat makes changes again.")
+			default:
+				fmt.Println(i18n.T("dryrun.usage"))
+			}
+			return false
+		},
+	})
+}
+
+// dryRun intercepts the tool calls of one dry-run /chat message. Tasks and
+// projects it pretends to create get placeholder IDs, so later calls in the
+// same message can refer to them and still be described by name.
+type dryRun struct {
+	created  map[string]string // placeholder ID -> task name
+	projects map[string]string // placeholder shortcut -> project name
+}
+
+func newDryRun() *dryRun {
+	return &dryRun{created: make(map[string]string), projects: make(map[string]string)}
+}
+
+// intercept returns what a tool call that changes data would do, or "" for
+// one that only reads and should run as usual
+func (d *dryRun) intercept(name string, args map[string]any) string {
+	cmd, ok := toolCommand(name)
+	if !ok || cmd.ReadOnly() {
+		return ""
+	}
+	return "Dry run: would " + d.describe(name, args) + ". Nothing was changed."
+}
+
+// describe says what a mutating tool call would do, e.g. `create task "Buy
+// milk" in project Home`; tools without a description are shown as the
+// command line they'd run
+func (d *dryRun) describe(name string, args map[string]any) string {
+	arg := func(key string) string {
+		s, _ := args[key].(string)
+		return strings.TrimSpace(s)
+	}
+	task := func() string { return d.taskName(arg("task_id")) }
+	project := func() string { return d.projectName(arg("project_id")) }
+
+	switch name {
+	case "task":
+		id := fmt.Sprintf("new-%d", len(d.created)+1)
+		d.created[id] = arg("task_name")
+		return fmt.Sprintf("create task %q in project %s (%s stands in for its ID)", arg("task_name"), project(), id)
+	case "tasks_create_batch":
+		var names []string
+		for _, n := range strings.Split(arg("tasks"), ";") {
+			if n = strings.TrimSpace(n); n != "" {
+				names = append(names, fmt.Sprintf("%q", n))
+			}
+		}
+		return fmt.Sprintf("create %d tasks in project %s: %s", len(names), project(), strings.Join(names, ", "))
+	case "in":
+		id := fmt.Sprintf("new-%d", len(d.created)+1)
+		d.created[id] = arg("task_name")
+		return fmt.Sprintf("capture task %q in the inbox (%s stands in for its ID)", arg("task_name"), id)
+	case "project":
+		id := fmt.Sprintf("new-project-%d", len(d.projects)+1)
+		d.projects[id] = arg("name")
+		return fmt.Sprintf("create project %q (%s stands in for its shortcut)", arg("name"), id)
+	case "projectset":
+		if strings.EqualFold(arg("value"), "none") {
+			return fmt.Sprintf("clear the default %s of project %s", arg("key"), project())
+		}
+		return fmt.Sprintf("set the default %s of project %s to %s", arg("key"), project(), arg("value"))
+	case "done":
+		return "mark " + task() + " done"
+	case "undone":
+		return "mark " + task() + " not done"
+	case "due":
+		if strings.EqualFold(arg("date"), "none") {
+			return "clear the due date of " + task()
+		}
+		return fmt.Sprintf("set the due date of %s to %s", task(), strings.TrimSpace(arg("date")+" "+arg("time")))
+	case "defer":
+		return fmt.Sprintf("defer %s (%s)", task(), arg("shift"))
+	case "defer-all-overdue":
+		if arg("project_id") != "" {
+			return fmt.Sprintf("defer every overdue task in project %s (%s)", project(), arg("shift"))
+		}
+		return fmt.Sprintf("defer every overdue task (%s)", arg("shift"))
+	case "duration", "priority", "status":
+		return fmt.Sprintf("set the %s of %s to %s", name, task(), arg(name))
+	case "note":
+		if strings.EqualFold(arg("note"), "none") {
+			return "clear the note on " + task()
+		}
+		return fmt.Sprintf("set the note on %s to %q", task(), arg("note"))
+	case "tag":
+		return fmt.Sprintf("tag %s with #%s", task(), strings.TrimPrefix(arg("tag"), "#"))
+	case "untag":
+		return fmt.Sprintf("remove #%s from %s", strings.TrimPrefix(arg("tag"), "#"), task())
+	case "move":
+		return fmt.Sprintf("move %s to project %s", task(), project())
+	case "start":
+		return "start a timer on " + task()
+	case "stop":
+		return "stop the running timer"
+	}
+
+	line := "/" + name
+	if cmdArgs := convertArgsToSlice(name, args); len(cmdArgs) > 0 {
+		line += " " + JoinArgs(cmdArgs)
+	}
+	return "run " + line
+}
+
+// taskName quotes the name of a task given by ID, prefix, or placeholder,
+// or returns the ID itself if there's no such task
+func (d *dryRun) taskName(id string) string {
+	if name, ok := d.created[id]; ok {
+		return fmt.Sprintf("the new task %q", name)
+	}
+	if resolved, err := GetStore().ResolveTaskID(id); err == nil {
+		if t, err := GetStore().GetTask(resolved); err == nil {
+			return fmt.Sprintf("task %q", t.Name)
+		}
+	}
+	return "task " + id
+}
+
+// projectName returns the name of a project given by ID, shortcut, or
+// placeholder, or the reference itself if there's no such project
+func (d *dryRun) projectName(ref string) string {
+	if name, ok := d.projects[ref]; ok {
+		return name
+	}
+	if id, err := GetStore().ResolveProjectID(ref); err == nil {
+		return projectName(id)
+	}
+	return ref
+}
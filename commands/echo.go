@@ -10,6 +10,7 @@ func init() {
 		Name:        "/echo",
 		Shorthand:   "/e",
 		Description: "Echo your message",
+		Access:      AccessRead,
 		Hidden:      true,
 		Handler: func(args []string) bool {
 			fmt.Println(strings.Join(args, " "))
@@ -0,0 +1,310 @@
+package commands
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"twooms/i18n"
+	"twooms/llm"
+	"twooms/storage"
+)
+
+// durationHistoryLimit is how many completed tasks /durations lists
+const durationHistoryLimit = 40
+
+// estimateSystem asks the model for estimates in a form /estimate can read
+const estimateSystem = `You estimate how long tasks will take. Before answering, call the durations tool to see how long similar completed tasks were estimated at and actually took, and lean on the time tracked where there is some.
+Answer with one line per task and nothing else, in exactly this form:
+<task-id> <duration> - <a short reason>
+Durations are minutes and/or hours, like 15m, 45m, 2h, or 1h30m.`
+
+// estimateLine is one line of the model's answer: a task ID, a duration,
+// and an optional reason
+var estimateLine = regexp.MustCompile(`^\W*\[?([0-9a-fA-F]{4,})\]?\W*\s+(\d+[hHmM]\S*)\s*(?:[-–—:]\s*(.*))?$`)
+
+func init() {
+	Register(&Command{
+		Name:        "/durations",
+		Description: "Show how long recently completed tasks were estimated at and how much time was tracked on them, most recent first; use it to estimate similar work",
+		Examples: []string{
+			"/durations",
+			"/durations work",
+		},
+		Access: AccessRead,
+		Params: []Param{
+			{Name: "project_id", Type: ParamTypeString, Description: "Optional project ID or shortcut to filter by", Required: false},
+		},
+		Handler: func(args []string) bool {
+			projectID := ""
+			if len(args) > 0 {
+				resolved, err := GetStore().ResolveProjectID(args[0])
+				if err != nil {
+					fmt.Printf("Error: %v\n", err)
+					return false
+				}
+				projectID = resolved
+			}
+			printDurationHistory(projectID, time.Now())
+			return false
+		},
+	})
+
+	Register(&Command{
+		Name:        "/estimate",
+		Description: "Ask the LLM to estimate a task's duration, or those of a project's open tasks without one, from their names, notes, and how long past tasks took, then set them after confirmation",
+		Examples: []string{
+			"/estimate 1a2b3c4d",
+			"/estimate work",
+		},
+		Hidden:      true, // it asks the model itself
+		Interactive: true,
+		Params: []Param{
+			{Name: "id", Type: ParamTypeString, Description: "A task ID, or a project ID or shortcut to estimate its open tasks without a duration", Required: true},
+		},
+		Handler: func(args []string) bool {
+			args, yes := takeYes(args)
+			if len(args) != 1 {
+				fmt.Println(i18n.T("estimate.usage"))
+				return false
+			}
+			tasks, err := estimateTargets(args[0])
+			if err != nil {
+				fmt.Printf("Error: %v\n", err)
+				return false
+			}
+			if len(tasks) == 0 {
+				fmt.Println("Every open task there already has a duration.")
+				return false
+			}
+
+			client := GetLLMClient()
+			if client == nil {
+				fmt.Println("Error: no LLM provider is set up (/providers shows how to add one)")
+				return false
+			}
+			if err := checkBudget(); err != nil {
+				fmt.Printf("Error: %v\n", err)
+				return false
+			}
+
+			estimates, reasons, err := askEstimates(client, tasks)
+			if err != nil {
+				fmt.Printf("Error: %v\n", err)
+				return false
+			}
+			if len(estimates) == 0 {
+				fmt.Println("The model gave no estimates. Nothing changed.")
+				return false
+			}
+
+			fmt.Println("Estimates:")
+			for _, t := range tasks {
+				d, ok := estimates[t.ID]
+				if !ok {
+					fmt.Printf("  [%s] %s: no estimate given\n", shortenID(t.ID), storage.DisplayName(t.Name))
+					continue
+				}
+				line := fmt.Sprintf("  [%s] %s: %s", shortenID(t.ID), storage.DisplayName(t.Name), d)
+				if t.Duration != 0 {
+					line += fmt.Sprintf(" (was %s)", t.Duration)
+				}
+				if reasons[t.ID] != "" {
+					line += " - " + reasons[t.ID]
+				}
+				fmt.Println(line)
+			}
+
+			if !yes {
+				if lineReader == nil {
+					fmt.Println("Run again with --yes to apply.")
+					return false
+				}
+				answer, err := lineReader("Apply? [y/N] ")
+				if err != nil || !strings.EqualFold(strings.TrimSpace(answer), "y") {
+					fmt.Println("Cancelled. Nothing changed.")
+					return false
+				}
+			}
+
+			if err := GetStore().SetTaskDurations(estimates); err != nil {
+				fmt.Printf("Error: %v\n", err)
+				return false
+			}
+			fmt.Printf("Set %d durations (one /undo puts them back).\n", len(estimates))
+			return false
+		},
+	})
+}
+
+// estimateTargets resolves /estimate's argument: a task, even one with a
+// duration, or else a project's open tasks that have none
+func estimateTargets(ref string) ([]*storage.Task, error) {
+	if taskID, err := GetStore().ResolveTaskID(ref); err == nil {
+		task, err := GetStore().GetTask(taskID)
+		if err != nil {
+			return nil, err
+		}
+		return []*storage.Task{task}, nil
+	}
+	projectID, err := GetStore().ResolveProjectID(ref)
+	if err != nil {
+		return nil, fmt.Errorf("no task or project matches %q", ref)
+	}
+	tasks, err := GetStore().ListTasks(projectID)
+	if err != nil {
+		return nil, err
+	}
+	var open []*storage.Task
+	for _, t := range tasks {
+		if !t.Done && t.Duration == 0 {
+			open = append(open, t)
+		}
+	}
+	return open, nil
+}
+
+// askEstimates has the model estimate the tasks, letting it look up past
+// durations with the durations tool, and returns the estimates it gave by
+// task ID along with their reasons
+func askEstimates(client llm.Client, tasks []*storage.Task) (map[string]storage.Duration, map[string]string, error) {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Today is %s. Estimate how long each of these tasks will take:\n", time.Now().Format("Monday, 2006-01-02"))
+	for _, t := range tasks {
+		fmt.Fprintf(&b, "%s %s (project: %s", shortenID(t.ID), storage.DisplayName(t.Name), projectName(t.ProjectID))
+		if len(t.Tags) > 0 {
+			fmt.Fprintf(&b, "; tags: %s", strings.Join(t.Tags, ", "))
+		}
+		if t.Note != "" {
+			fmt.Fprintf(&b, "; note: %s", strings.ReplaceAll(t.Note, "\n", " "))
+		}
+		b.WriteString(")\n")
+	}
+
+	// The model may only read the history, whatever else chat could do
+	var tools []*llm.Tool
+	for _, tool := range GenerateToolDefinitions() {
+		if tool.Name == "durations" {
+			tools = append(tools, tool)
+		}
+	}
+	executor := func(name string, args map[string]any) string {
+		if name != "durations" {
+			return fmt.Sprintf("Error: %s isn't available here; only durations is", name)
+		}
+		return runTool(name, args)
+	}
+
+	client.SetDebug(IsDebugMode())
+	history := []*llm.Message{{Role: "system", Content: estimateSystem}}
+	resp, _, err := client.ChatWithTools(commandContext(), b.String(), history, tools, executor)
+	if err != nil {
+		return nil, nil, err
+	}
+	recordMonthlyUsage(resp)
+
+	estimates, reasons := parseEstimates(resp.Text, tasks)
+	return estimates, reasons, nil
+}
+
+// parseEstimates reads the model's "<task-id> <duration> - <reason>" lines,
+// keeping those that name one of the tasks and a valid duration
+func parseEstimates(text string, tasks []*storage.Task) (map[string]storage.Duration, map[string]string) {
+	estimates := make(map[string]storage.Duration)
+	reasons := make(map[string]string)
+	for _, line := range strings.Split(text, "\n") {
+		m := estimateLine.FindStringSubmatch(strings.TrimSpace(line))
+		if m == nil {
+			continue
+		}
+		d, err := storage.ParseDuration(strings.TrimRight(m[2], ".,;"))
+		if err != nil {
+			continue
+		}
+		for _, t := range tasks {
+			if strings.HasPrefix(t.ID, strings.ToLower(m[1])) {
+				estimates[t.ID] = d
+				reasons[t.ID] = strings.TrimSpace(m[3])
+				break
+			}
+		}
+	}
+	return estimates, reasons
+}
+
+// printDurationHistory lists recently completed tasks, including archived
+// ones, with their estimates and tracked time, and how the two compared
+func printDurationHistory(projectID string, now time.Time) {
+	tasks, err := GetStore().ListAllTasks()
+	if err != nil {
+		fmt.Printf("Error listing tasks: %v\n", err)
+		return
+	}
+	archived, err := GetStore().ListArchivedTasks(projectID)
+	if err != nil {
+		fmt.Printf("Error listing archived tasks: %v\n", err)
+		return
+	}
+
+	tracked := make(map[string]int)
+	if entries, err := GetStore().ListTimeEntries(); err == nil {
+		for _, e := range entries {
+			tracked[e.TaskID] += e.Minutes(now)
+		}
+	}
+
+	// Only tasks with an estimate or tracked time say anything about durations
+	var done []*storage.Task
+	for _, t := range append(tasks, archived...) {
+		if !t.Done || (projectID != "" && t.ProjectID != projectID) {
+			continue
+		}
+		if t.Duration != 0 || tracked[t.ID] > 0 {
+			done = append(done, t)
+		}
+	}
+	if len(done) == 0 {
+		fmt.Println("No completed tasks with a duration or tracked time yet.")
+		return
+	}
+	finished := func(t *storage.Task) time.Time {
+		if t.CompletedAt != nil {
+			return *t.CompletedAt
+		}
+		return t.CreatedAt
+	}
+	sort.SliceStable(done, func(i, j int) bool { return finished(done[i]).After(finished(done[j])) })
+	if len(done) > durationHistoryLimit {
+		done = done[:durationHistoryLimit]
+	}
+
+	fmt.Println("Completed tasks, most recent first:")
+	estimated, spent, compared := 0, 0, 0
+	for _, t := range done {
+		line := fmt.Sprintf("  [%s] %s", shortenID(t.ID), storage.DisplayName(t.Name))
+		if projectID == "" {
+			line += " (" + projectName(t.ProjectID) + ")"
+		}
+		var extras []string
+		if t.Duration != 0 {
+			extras = append(extras, t.Duration.String()+" est")
+		}
+		if minutes := tracked[t.ID]; minutes > 0 {
+			extras = append(extras, storage.FormatMinutes(minutes)+" tracked")
+			if t.Duration != 0 {
+				estimated += t.Duration.ToMinutes()
+				spent += minutes
+				compared++
+			}
+		}
+		line += " - " + strings.Join(extras, ", ")
+		fmt.Println(line)
+	}
+
+	if compared > 0 {
+		fmt.Printf("Tasks with both took %d%% of their estimates (%s tracked against %s estimated, over %d tasks).\n",
+			spent*100/estimated, storage.FormatMinutes(spent), storage.FormatMinutes(estimated), compared)
+	}
+}
@@ -0,0 +1,34 @@
+package commands
+
+import "twooms/storage"
+
+// Event names published on the event bus
+const (
+	EventTaskCreated = "task_created"
+	EventTaskDone    = "task_done"
+	EventDuePassed   = "due_passed"
+)
+
+// Event describes something that happened to a task
+type Event struct {
+	Name string
+	Task *storage.Task
+}
+
+// EventHandler reacts to a published event
+type EventHandler func(e Event)
+
+var subscribers []EventHandler
+
+// Subscribe registers a handler that receives every published event
+func Subscribe(h EventHandler) {
+	subscribers = append(subscribers, h)
+}
+
+// Publish delivers an event to all subscribers in registration order
+func Publish(name string, task *storage.Task) {
+	e := Event{Name: name, Task: task}
+	for _, h := range subscribers {
+		h(e)
+	}
+}
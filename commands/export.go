@@ -0,0 +1,236 @@
+package commands
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"twooms/i18n"
+	"twooms/storage"
+)
+
+func init() {
+	Register(&Command{
+		Name:        "/export",
+		Description: "Export all projects and tasks (json, csv, or md)",
+		Examples:    []string{"/export md", "/export json tasks.json"},
+		Hidden:      true,
+		Params: []Param{
+			{Name: "format", Type: ParamTypeString, Description: "Export format: json, csv, or md", Required: true, Enum: []string{"json", "csv", "md"}},
+			{Name: "file", Type: ParamTypeString, Description: "Optional output file (prints to the terminal if omitted)", Required: false},
+		},
+		Handler: func(args []string) bool {
+			if len(args) == 0 {
+				fmt.Println(i18n.T("export.usage"))
+				return false
+			}
+
+			format, err := storage.ParseFormat(args[0])
+			if err != nil {
+				fmt.Printf("Error: %v\n", err)
+				return false
+			}
+
+			snap, err := storage.ExportSnapshot(GetStore())
+			if err != nil {
+				fmt.Printf("Error: %v\n", err)
+				return false
+			}
+
+			if len(args) < 2 {
+				if err := storage.WriteSnapshot(os.Stdout, snap, format); err != nil {
+					fmt.Printf("Error: %v\n", err)
+				}
+				return false
+			}
+
+			filename := args[1]
+			f, err := os.Create(filename)
+			if err != nil {
+				fmt.Printf("Error: %v\n", err)
+				return false
+			}
+			defer f.Close()
+
+			if err := storage.WriteSnapshot(f, snap, format); err != nil {
+				fmt.Printf("Error writing export: %v\n", err)
+				return false
+			}
+
+			fmt.Printf("Exported %d projects and %d tasks to %s\n", len(snap.Projects), len(snap.Tasks), filename)
+			return false
+		},
+	})
+
+	Register(&Command{
+		Name:        "/import",
+		Description: "Import projects and tasks from a json, csv, or md file, or from a Todoist or Asana CSV export",
+		Examples:    []string{"/import --dry-run tasks.json", "/import tasks.csv", "/import todoist --dry-run Errands.csv", "/import asana launch.csv"},
+		Hidden:      true,
+		Interactive: true,
+		Bulk:        true,
+		Params: []Param{
+			{Name: "source", Type: ParamTypeString, Description: "todoist or asana to read that app's CSV export", Required: false, Enum: storage.ExternalSources},
+			{Name: "file", Type: ParamTypeString, Description: "The file to import (format is taken from the extension)", Required: true},
+		},
+		Handler: func(args []string) bool {
+			dryRun := false
+			var rest []string
+			args, yes := takeYes(args)
+			for _, arg := range args {
+				if arg == "--dry-run" {
+					dryRun = true
+				} else {
+					rest = append(rest, arg)
+				}
+			}
+
+			source := ""
+			if len(rest) > 1 && storage.IsExternalSource(rest[0]) {
+				source, rest = strings.ToLower(rest[0]), rest[1:]
+			}
+			if len(rest) != 1 {
+				fmt.Println(i18n.T("import.usage"))
+				return false
+			}
+
+			if !dryRun && !confirm("/import", fmt.Sprintf("Import the projects and tasks in %s?", rest[0]), yes) {
+				return false
+			}
+			if source != "" {
+				importExternal(source, rest[0], dryRun)
+				return false
+			}
+			importFile(rest[0], dryRun)
+			return false
+		},
+	})
+}
+
+// importFile imports a json, csv, or md file, or with dryRun only reports
+// what importing it would do
+func importFile(filename string, dryRun bool) {
+	format, err := storage.FormatFromFilename(filename)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		return
+	}
+
+	f, err := os.Open(filename)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		return
+	}
+	defer f.Close()
+
+	snap, err := storage.ReadSnapshot(f, format)
+	if err != nil {
+		fmt.Printf("Error reading %s: %v\n", filename, err)
+		return
+	}
+
+	applyImport(snap, filename, dryRun)
+}
+
+// importExternal imports a Todoist or Asana CSV export, printing how its
+// columns were mapped before the dry-run plan or the import's results
+func importExternal(source, filename string, dryRun bool) {
+	f, err := os.Open(filename)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		return
+	}
+	defer f.Close()
+
+	snap, report, err := storage.ReadExternal(f, source, filename)
+	if err != nil {
+		fmt.Printf("Error reading %s: %v\n", filename, err)
+		return
+	}
+	printMappingReport(report)
+	applyImport(snap, filename, dryRun)
+}
+
+// applyImport merges a parsed snapshot into the store, or with dryRun
+// only reports what that would do
+func applyImport(snap *storage.Snapshot, filename string, dryRun bool) {
+	plan, err := storage.PlanImport(GetStore(), snap)
+	if err != nil {
+		fmt.Printf("Error planning import: %v\n", err)
+		return
+	}
+	plan.Source = filename
+
+	if dryRun {
+		printImportPlan(filename, plan)
+		return
+	}
+
+	result, err := storage.ApplyImport(GetStore(), plan)
+	if err != nil {
+		fmt.Printf("Error importing: %v\n", err)
+		return
+	}
+
+	fmt.Printf("Imported %d projects and %d tasks from %s\n", result.ProjectsCreated, result.TasksCreated, filename)
+	if result.Unchanged > 0 {
+		fmt.Printf("  %d entries already present and unchanged\n", result.Unchanged)
+	}
+	if len(result.Skipped) > 0 {
+		fmt.Printf("  %d skipped:\n", len(result.Skipped))
+		for _, s := range result.Skipped {
+			fmt.Printf("    - %s\n", s)
+		}
+	}
+	if len(result.Conflicts) > 0 {
+		fmt.Printf("  %d conflicts (skipped, existing data kept):\n", len(result.Conflicts))
+		for _, c := range result.Conflicts {
+			fmt.Printf("    - %s\n", c)
+		}
+	}
+	if result.Queued > 0 {
+		fmt.Printf("  %d conflicts queued. Review them with /conflicts\n", result.Queued)
+	}
+}
+
+// printImportPlan prints a dry-run report of what an import would do
+func printImportPlan(filename string, plan *storage.ImportPlan) {
+	fmt.Printf("Dry run: importing %s would\n", filename)
+	fmt.Printf("  create %d, skip %d, leave %d unchanged, conflict on %d\n",
+		plan.Count(storage.ImportCreate), plan.Count(storage.ImportSkip),
+		plan.Count(storage.ImportUnchanged), plan.Count(storage.ImportConflict))
+
+	for _, action := range []storage.ImportAction{storage.ImportCreate, storage.ImportSkip, storage.ImportConflict} {
+		if plan.Count(action) == 0 {
+			continue
+		}
+		fmt.Printf("\n%s:\n", strings.ToUpper(string(action)))
+		for _, e := range plan.Entries {
+			if e.Action != action {
+				continue
+			}
+			line := fmt.Sprintf("  %-7s %s [%s]", e.Kind, e.Name, e.Key)
+			if e.Reason != "" {
+				line += " - " + e.Reason
+			}
+			fmt.Println(line)
+		}
+	}
+	fmt.Println("\nNo changes made. Run without --dry-run to import.")
+}
+
+// printMappingReport prints how an external export's columns became
+// twooms fields, and the rows that needed a judgment call
+func printMappingReport(report *storage.MappingReport) {
+	fmt.Printf("Read %d projects and %d tasks from the %s export:\n", report.Projects, report.Tasks, report.Source)
+	for _, field := range report.Fields {
+		fmt.Printf("  %s\n", field)
+	}
+	if len(report.Notes) > 0 {
+		fmt.Println("Notes:")
+		for _, note := range report.Notes {
+			fmt.Printf("  - %s\n", note)
+		}
+	}
+	fmt.Println()
+}
@@ -0,0 +1,355 @@
+package commands
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"twooms/llm"
+	"twooms/storage"
+)
+
+// focusProjectID is the project /chat is scoped to, or "" for all projects
+var focusProjectID string
+
+// focusTaskID is the task being worked on, set with /focus <task-id>, or ""
+var focusTaskID string
+
+// chatHistories holds each scope's conversation while another scope is active,
+// keyed by project ID ("" for the unscoped conversation)
+var chatHistories = make(map[string][]*llm.Message)
+
+func init() {
+	Register(&Command{
+		Name:        "/focus",
+		Shorthand:   "/f",
+		Description: "Scope /chat to one project (tools, context, and history), or mark the task you're working on; 'done' completes that task, 'off' clears both",
+		Examples:    []string{"/focus work", "/focus 1a2b3c4d", "/focus done", "/focus off"},
+		Hidden:      true,
+		Params: []Param{
+			{Name: "id", Type: ParamTypeString, Description: "A project ID or shortcut, a task ID, 'done', or 'off'", Required: false},
+		},
+		Handler: func(args []string) bool {
+			if len(args) == 0 {
+				if name := FocusedProject(); name != "" {
+					fmt.Printf("Chat is focused on %s. Use /focus off to chat across all projects.\n", name)
+				} else {
+					fmt.Println("Chat is not focused. Use /focus <project-id> to scope it to one project.")
+				}
+				if task := focusedTask(); task != nil {
+					fmt.Printf("Working on %s [%s]. Use /focus done when it's finished.\n", task.Name, shortenID(task.ID))
+				}
+				return false
+			}
+
+			switch strings.ToLower(args[0]) {
+			case "off":
+				if focusTaskID != "" {
+					clearTaskFocus()
+					fmt.Println("No task in focus.")
+				}
+				setFocus("")
+				fmt.Println("Chat is no longer focused.")
+				return false
+			case "done":
+				task := focusedTask()
+				if task == nil {
+					fmt.Println("Error: no task in focus. Use /focus <task-id> first.")
+					return false
+				}
+				// Focus and its timer stay if the task couldn't be marked done
+				registry["/done"].Handler([]string{task.ID})
+				if task, err := GetStore().GetTask(task.ID); err == nil && task.Done {
+					clearTaskFocus()
+				}
+				return false
+			}
+
+			// A project scopes chat; a task is the one to work on
+			projectID, taskID, err := focusTarget(args[0])
+			switch {
+			case err != nil:
+				fmt.Printf("Error: %v\n", err)
+			case projectID != "":
+				setFocus(projectID)
+				fmt.Printf("Chat focused on %s. Tools and history are limited to this project.\n", FocusedProject())
+			default:
+				focusTask(taskID)
+			}
+			return false
+		},
+	})
+}
+
+// focusTarget resolves /focus's argument to a project or a task. A match by
+// ID, shortcut, or whole name beats one by part of a name, a project before
+// a task; when only parts of names match, the reference must pick out one
+// project or task.
+func focusTarget(ref string) (projectID, taskID string, err error) {
+	projectID, projectErr := GetStore().ResolveProjectID(ref)
+	taskID, taskErr := GetStore().ResolveTaskID(ref)
+	projectPartial, taskPartial, taskName := true, true, ""
+	if projectErr == nil {
+		if p, err := GetStore().GetProject(projectID); err == nil {
+			projectPartial = partialMatch(ref, p.ID, p.Shortcut, p.Name)
+		}
+	}
+	if taskErr == nil {
+		if t, err := GetStore().GetTask(taskID); err == nil {
+			taskPartial, taskName = partialMatch(ref, t.ID, "", t.Name), t.Name
+		}
+	}
+
+	switch {
+	case projectErr == nil && !projectPartial:
+		return projectID, "", nil
+	case taskErr == nil && !taskPartial:
+		return "", taskID, nil
+	case errors.Is(projectErr, storage.ErrAmbiguous):
+		return "", "", projectErr
+	case errors.Is(taskErr, storage.ErrAmbiguous):
+		return "", "", taskErr
+	case projectErr == nil && taskErr == nil:
+		return "", "", fmt.Errorf("%q matches project %s and task %s [%s]; use a shortcut or task ID", ref, projectName(projectID), taskName, shortenID(taskID))
+	case projectErr == nil:
+		return projectID, "", nil
+	case taskErr == nil:
+		return "", taskID, nil
+	}
+	return "", "", fmt.Errorf("no project or task matches %q", ref)
+}
+
+// partialMatch reports whether ref picked out a project or task only by
+// part of its name, not by its ID, shortcut, or whole name
+func partialMatch(ref, id, shortcut, name string) bool {
+	ref = strings.TrimSpace(ref)
+	return !strings.EqualFold(ref, name) && (shortcut == "" || !strings.EqualFold(ref, shortcut)) && !strings.HasPrefix(id, strings.ToLower(ref))
+}
+
+// setFocus switches the active chat scope, parking the current history so it
+// picks up where it left off when the scope is focused again
+func setFocus(projectID string) {
+	saveChatSession()
+	chatHistories[focusProjectID] = chatHistory
+	parkedSessions[focusProjectID] = currentSession
+	focusProjectID = projectID
+	chatHistory = chatHistories[projectID]
+	currentSession = parkedSessions[projectID]
+}
+
+// FocusedProject returns the name of the project chat is focused on, or ""
+func FocusedProject() string {
+	if focusProjectID == "" {
+		return ""
+	}
+	project, err := GetStore().GetProject(focusProjectID)
+	if err != nil {
+		return ""
+	}
+	return project.Name
+}
+
+// FocusedShortcut returns the shortcut of the project chat is focused on, or ""
+func FocusedShortcut() string {
+	if focusProjectID == "" {
+		return ""
+	}
+	project, err := GetStore().GetProject(focusProjectID)
+	if err != nil {
+		return ""
+	}
+	if project.Shortcut == "" {
+		return project.ID
+	}
+	return project.Shortcut
+}
+
+// focusSnapshot describes only the focused project for the system prompt,
+// listing its open tasks so the model rarely needs a listing call
+func focusSnapshot() string {
+	project, err := GetStore().GetProject(focusProjectID)
+	if err != nil {
+		return ""
+	}
+	tasks, _ := GetStore().ListTasks(project.ID)
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "\n\nFOCUSED PROJECT: [%s] %s%s", project.Shortcut, project.Name, stripColors(formatProjectDue(project, tasks)))
+	b.WriteString("\nYou can only see and change this project. The project_id is filled in automatically; tasks in other projects are out of scope.")
+	b.WriteString("\nOPEN TASKS:")
+	open := 0
+	for _, t := range tasks {
+		if t.Done {
+			continue
+		}
+		open++
+		fmt.Fprintf(&b, "\n- [%s] %s", shortenID(t.ID), t.Name)
+	}
+	if open == 0 {
+		b.WriteString(" none")
+	}
+	return b.String()
+}
+
+// focusTools narrows the tool list to commands that act on a project or task,
+// dropping the project_id parameter since the executor supplies it
+func focusTools(tools []*llm.Tool) []*llm.Tool {
+	var scoped []*llm.Tool
+	for _, tool := range tools {
+		if tool.Parameters == nil {
+			continue
+		}
+		_, hasProject := tool.Parameters.Properties["project_id"]
+		_, hasTask := tool.Parameters.Properties["task_id"]
+		if !hasProject && !hasTask {
+			continue
+		}
+
+		if hasProject {
+			properties := make(map[string]*llm.ToolProperty)
+			for name, prop := range tool.Parameters.Properties {
+				if name != "project_id" {
+					properties[name] = prop
+				}
+			}
+			var required []string
+			for _, name := range tool.Parameters.Required {
+				if name != "project_id" {
+					required = append(required, name)
+				}
+			}
+			tool = &llm.Tool{
+				Name:        tool.Name,
+				Description: tool.Description,
+				Parameters:  &llm.ToolParameters{Type: tool.Parameters.Type, Properties: properties, Required: required},
+			}
+		}
+		scoped = append(scoped, tool)
+	}
+	return scoped
+}
+
+// checkFocus pins a tool call to the focused project. It fills in project_id
+// and rejects task IDs from other projects, returning an error message for
+// the model, or "" if the call may run.
+func checkFocus(tools []*llm.Tool, name string, args map[string]any) string {
+	var tool *llm.Tool
+	for _, t := range tools {
+		if t.Name == name {
+			tool = t
+			break
+		}
+	}
+	if tool == nil {
+		return fmt.Sprintf("Error: %s is not available while chat is focused on %s", name, FocusedProject())
+	}
+
+	if cmd, ok := toolCommand(name); ok {
+		for _, p := range cmd.Params {
+			if p.Name == "project_id" {
+				args["project_id"] = focusProjectID
+			}
+		}
+	}
+
+	for _, key := range []string{"task_id", "blocking_task_id"} {
+		ref, ok := args[key]
+		if !ok || strings.HasPrefix(fmt.Sprintf("%v", ref), "--") {
+			continue // flags like /archive --done name no task
+		}
+		taskID, err := GetStore().ResolveTaskID(fmt.Sprintf("%v", ref))
+		if err != nil {
+			return fmt.Sprintf("Error: %v", err)
+		}
+		task, err := GetStore().GetTask(taskID)
+		if err != nil {
+			return fmt.Sprintf("Error: %v", err)
+		}
+		if task.ProjectID != focusProjectID {
+			return fmt.Sprintf("Error: task %s is outside the focused project %s", shortenID(taskID), FocusedProject())
+		}
+	}
+	return ""
+}
+
+// focusedTask returns the task in focus, or nil. A task that was deleted
+// or finished some other way (like /done) drops out of focus.
+func focusedTask() *storage.Task {
+	if focusTaskID == "" || GetStore() == nil {
+		return nil
+	}
+	task, err := GetStore().GetTask(focusTaskID)
+	if err != nil || task.Done {
+		clearTaskFocus()
+		return nil
+	}
+	return task
+}
+
+// FocusedTask returns the name of the task in focus, or ""
+func FocusedTask() string {
+	if task := focusedTask(); task != nil {
+		return task.Name
+	}
+	return ""
+}
+
+// focusTimer reports whether focusing a task also times it (focus_timer in
+// the config)
+func focusTimer() bool {
+	return GetConfig() != nil && GetConfig().FocusTimer
+}
+
+// focusTask marks the task being worked on, starting its timer when
+// focus_timer is on
+func focusTask(taskID string) {
+	task, err := GetStore().GetTask(taskID)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		return
+	}
+	if task.Done {
+		fmt.Printf("Error: %s is already done\n", task.Name)
+		return
+	}
+	if focusTaskID != "" && focusTaskID != taskID {
+		clearTaskFocus()
+	}
+	focusTaskID = taskID
+	fmt.Printf("Working on %s [%s]\n", task.Name, shortenID(task.ID))
+
+	if focusTimer() {
+		if active, _ := GetStore().ActiveTimer(); active == nil || active.TaskID != taskID {
+			registry["/start"].Handler([]string{taskID})
+		}
+	}
+}
+
+// clearTaskFocus drops the task in focus, stopping its timer when
+// focus_timer started it
+func clearTaskFocus() {
+	if focusTimer() {
+		if active, _ := GetStore().ActiveTimer(); active != nil && active.TaskID == focusTaskID {
+			stopTimer()
+		}
+	}
+	focusTaskID = ""
+}
+
+// focusTaskSnapshot describes the task in focus for the system prompt, so
+// "this" and "it" in chat can refer to it
+func focusTaskSnapshot() string {
+	task := focusedTask()
+	if task == nil {
+		return ""
+	}
+	details := []string{projectName(task.ProjectID)}
+	if task.DueDate != nil {
+		details = append(details, "due "+storage.FormatDue(*task.DueDate))
+	}
+	if task.Note != "" {
+		details = append(details, "has a note")
+	}
+	return fmt.Sprintf("\n\nFOCUSED TASK (what the user is working on; \"this\", \"it\", and \"the current task\" mean this task unless they say otherwise): [%s] %s (%s)",
+		shortenID(task.ID), task.Name, strings.Join(details, ", "))
+}
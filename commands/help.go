@@ -3,16 +3,26 @@ package commands
 import (
 	"fmt"
 	"sort"
+	"strings"
+
+	"twooms/i18n"
 )
 
 func init() {
 	Register(&Command{
 		Name:        "/help",
 		Shorthand:   "/h",
-		Description: "Show available commands",
+		Description: "Show available commands, or the details of one",
+		Access:      AccessRead,
 		Hidden:      true,
+		Examples:    []string{"/help", "/help due", "/help /t"},
 		Handler: func(args []string) bool {
-			fmt.Println("Available commands:")
+			if len(args) > 0 {
+				showCommandHelp(args[0])
+				return false
+			}
+
+			fmt.Println(i18n.T("help.header"))
 
 			// Get all commands and sort by name
 			cmds := List()
@@ -27,8 +37,102 @@ func init() {
 				}
 				fmt.Printf("  %-22s - %s\n", nameCol, cmd.Description)
 			}
+			fmt.Println(i18n.T("help.more"))
 
 			return false
 		},
 	})
 }
+
+// showCommandHelp prints a command's usage, its parameters with the values
+// they accept, and examples. The name may leave out the slash or be a
+// shorthand.
+func showCommandHelp(name string) {
+	name = "/" + strings.TrimPrefix(strings.ToLower(name), "/")
+	cmd, ok := Lookup(name)
+	if !ok {
+		fmt.Println(i18n.T("error", unknownCommand(name)))
+		return
+	}
+
+	title := cmd.Name
+	if cmd.Shorthand != "" {
+		title = fmt.Sprintf("%s (%s)", cmd.Name, cmd.Shorthand)
+	}
+	fmt.Printf("%s - %s\n", title, cmd.Description)
+	if usage := commandUsage(cmd); usage != "" {
+		fmt.Println(usage)
+	}
+
+	if len(cmd.Params) > 0 {
+		fmt.Println(i18n.T("help.params"))
+		for _, p := range cmd.Params {
+			fmt.Printf("  %s (%s): %s\n", p.Name, paramHint(p), p.Description)
+		}
+	}
+	if len(cmd.Examples) > 0 {
+		fmt.Println(i18n.T("help.examples"))
+		for _, ex := range cmd.Examples {
+			fmt.Printf("  %s\n", ex)
+		}
+	}
+}
+
+// commandUsage returns a command's usage line: its own usage message when it
+// has one (like "due.usage"), or else one built from its Params. A command
+// with neither, which may still take arguments, has none.
+func commandUsage(cmd *Command) string {
+	if key := strings.TrimPrefix(cmd.Name, "/") + ".usage"; i18n.Has(key) {
+		return i18n.T(key)
+	}
+	if len(cmd.Params) == 0 {
+		return ""
+	}
+
+	line := []string{cmd.Name}
+	for _, p := range cmd.Params {
+		value := p.Name
+		switch {
+		case p.Type == ParamTypeBoolean:
+			line = append(line, "[--"+p.Name+"]")
+			continue
+		case len(p.Enum) > 0:
+			value = strings.Join(p.Enum, "|")
+		case p.Format == ParamFormatDate:
+			value = "YYYY-MM-DD|none"
+		case p.Format == ParamFormatTime:
+			value = "HH:MM"
+		case p.Format == ParamFormatDuration:
+			value = "duration"
+		}
+		if p.Required {
+			line = append(line, "<"+value+">")
+		} else {
+			line = append(line, "["+value+"]")
+		}
+	}
+	return i18n.T("help.usage", strings.Join(line, " "))
+}
+
+// paramHint says whether a parameter is required and what values it takes
+func paramHint(p Param) string {
+	hint := []string{i18n.T("help.optional")}
+	if p.Required {
+		hint = []string{i18n.T("help.required")}
+	}
+	switch {
+	case p.Type == ParamTypeBoolean:
+		hint = append(hint, i18n.T("help.flag", p.Name))
+	case p.Type == ParamTypeInteger:
+		hint = append(hint, i18n.T("help.integer"))
+	case len(p.Enum) > 0:
+		hint = append(hint, i18n.T("help.enum", strings.Join(p.Enum, ", ")))
+	case p.Format == ParamFormatDate:
+		hint = append(hint, i18n.T("help.format.date"))
+	case p.Format == ParamFormatTime:
+		hint = append(hint, i18n.T("help.format.time"))
+	case p.Format == ParamFormatDuration:
+		hint = append(hint, i18n.T("help.format.duration"))
+	}
+	return strings.Join(hint, ", ")
+}
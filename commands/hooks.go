@@ -0,0 +1,293 @@
+package commands
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"os/exec"
+	"strings"
+	"text/template"
+	"time"
+
+	"twooms/config"
+	"twooms/i18n"
+	"twooms/storage"
+)
+
+// hookTimeout is how long a hook's command or webhook may take
+const hookTimeout = 10 * time.Second
+
+// hookData is what a hook's payload template sees, e.g. {{.Task.Name}}
+// or {{.Project}}
+type hookData struct {
+	Event    string        `json:"event"`
+	Task     *storage.Task `json:"task"`
+	Project  string        `json:"project"`
+	Shortcut string        `json:"project_shortcut,omitempty"`
+	Due      string        `json:"due,omitempty"` // as /tasks shows it, e.g. "2030-03-14 15:00"
+	Time     string        `json:"time"`          // when the event fired, RFC 3339
+}
+
+// hookFuncs are the functions payload templates can call: json quotes a
+// value so task names with quotes don't break a JSON payload
+var hookFuncs = template.FuncMap{
+	"json": func(v any) (string, error) {
+		data, err := json.Marshal(v)
+		return string(data), err
+	},
+}
+
+func init() {
+	Subscribe(runHooks)
+
+	Register(&Command{
+		Name:        "/hooks",
+		Description: "List the hooks in ~/.twooms.config.json, or fire one for a task to try it",
+		Examples:    []string{"/hooks", "/hooks test slack 1a2b3c4d"},
+		Hidden:      true,
+		Params: []Param{
+			{Name: "action", Type: ParamTypeString, Description: "test to fire a hook", Required: false},
+			{Name: "hook", Type: ParamTypeString, Description: "The hook's name", Required: false},
+			{Name: "task_id", Type: ParamTypeString, Description: "The task to fire it for", Required: false},
+		},
+		Handler: func(args []string) bool {
+			if len(args) == 0 {
+				listHooks()
+				return false
+			}
+			if len(args) != 3 || args[0] != "test" {
+				fmt.Println(i18n.T("hooks.usage"))
+				return false
+			}
+
+			hook, ok := findHook(args[1])
+			if !ok {
+				fmt.Printf("Error: no hook named %q\n", args[1])
+				return false
+			}
+			id, err := GetStore().ResolveTaskID(args[2])
+			if err != nil {
+				fmt.Printf("Error: %v\n", err)
+				return false
+			}
+			task, err := GetStore().GetTask(id)
+			if err != nil {
+				fmt.Printf("Error: %v\n", err)
+				return false
+			}
+			if err := fireHook(hook, Event{Name: hook.On, Task: task}); err != nil {
+				fmt.Printf("Error: hook %q: %v\n", hook.Name, err)
+				return false
+			}
+			fmt.Printf("Hook %s fired for task %s\n", hook.Name, task.Name)
+			return false
+		},
+	})
+}
+
+// configuredHooks returns the hooks in the config, named "hook 1", "hook 2",
+// and so on when they have no name
+func configuredHooks() []config.HookConfig {
+	if GetConfig() == nil {
+		return nil
+	}
+	hooks := make([]config.HookConfig, len(GetConfig().Hooks))
+	for i, h := range GetConfig().Hooks {
+		if h.Name == "" {
+			h.Name = fmt.Sprintf("hook %d", i+1)
+		}
+		hooks[i] = h
+	}
+	return hooks
+}
+
+// findHook looks a hook up by name, ignoring case
+func findHook(name string) (config.HookConfig, bool) {
+	for _, h := range configuredHooks() {
+		if strings.EqualFold(h.Name, name) {
+			return h, true
+		}
+	}
+	return config.HookConfig{}, false
+}
+
+// hasHooksFor reports whether any hook fires on an event
+func hasHooksFor(event string) bool {
+	for _, h := range configuredHooks() {
+		if h.On == event {
+			return true
+		}
+	}
+	return false
+}
+
+// checkHook reports what's wrong with a hook's settings, if anything
+func checkHook(h config.HookConfig) error {
+	switch h.On {
+	case EventTaskCreated, EventTaskDone, EventDuePassed:
+	default:
+		return fmt.Errorf("unknown event %q (use task_created, task_done, or due_passed)", h.On)
+	}
+	if h.Command == "" && h.URL == "" {
+		return fmt.Errorf("needs a command or a url")
+	}
+	if _, err := template.New(h.Name).Funcs(hookFuncs).Parse(h.Payload); err != nil {
+		return fmt.Errorf("invalid payload: %w", err)
+	}
+	return nil
+}
+
+// CheckHooks returns a problem for each hook in the config that can't
+// fire; main.go prints them as warnings at startup
+func CheckHooks() []error {
+	var errs []error
+	for _, h := range configuredHooks() {
+		if err := checkHook(h); err != nil {
+			errs = append(errs, fmt.Errorf("hook %q: %w", h.Name, err))
+		}
+	}
+	return errs
+}
+
+// runHooks fires every hook for an event. Hooks run one at a time and
+// print only when they fail, so a slow webhook holds up the command that
+// published the event for at most hookTimeout.
+func runHooks(e Event) {
+	for _, h := range configuredHooks() {
+		if h.On != e.Name {
+			continue
+		}
+		if err := fireHook(h, e); err != nil {
+			fmt.Printf("Error: hook %q: %v\n", h.Name, err)
+		}
+	}
+}
+
+// fireHook renders a hook's payload for an event, then runs its command
+// and posts to its URL
+func fireHook(h config.HookConfig, e Event) error {
+	if err := checkHook(h); err != nil {
+		return err
+	}
+	payload, err := hookPayload(h, e)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), hookTimeout)
+	defer cancel()
+	if h.Command != "" {
+		if err := runHookCommand(ctx, h, e, payload); err != nil {
+			return err
+		}
+	}
+	if h.URL != "" {
+		if err := postHook(ctx, h, payload); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// hookPayload renders a hook's payload template, or without one the
+// event's hookData as JSON
+func hookPayload(h config.HookConfig, e Event) ([]byte, error) {
+	data := hookData{
+		Event:   e.Name,
+		Task:    e.Task,
+		Project: projectName(e.Task.ProjectID),
+		Time:    time.Now().Format(time.RFC3339),
+	}
+	if p, err := GetStore().GetProject(e.Task.ProjectID); err == nil {
+		data.Shortcut = p.Shortcut
+	}
+	if e.Task.DueDate != nil {
+		data.Due = storage.FormatDue(*e.Task.DueDate)
+	}
+
+	if h.Payload == "" {
+		return json.Marshal(data)
+	}
+	tmpl, err := template.New(h.Name).Funcs(hookFuncs).Parse(h.Payload)
+	if err != nil {
+		return nil, fmt.Errorf("invalid payload: %w", err)
+	}
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return nil, fmt.Errorf("payload: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// runHookCommand runs a hook's command with sh -c. The payload is its
+// standard input and the event is in TWOOMS_* variables; the command line
+// itself isn't templated, so task names never reach the shell as code.
+func runHookCommand(ctx context.Context, h config.HookConfig, e Event, payload []byte) error {
+	cmd := exec.CommandContext(ctx, "sh", "-c", h.Command)
+	cmd.Stdin = bytes.NewReader(payload)
+	cmd.Env = append(os.Environ(),
+		"TWOOMS_EVENT="+e.Name,
+		"TWOOMS_TASK_ID="+e.Task.ID,
+		"TWOOMS_TASK_NAME="+e.Task.Name,
+		"TWOOMS_PROJECT="+projectName(e.Task.ProjectID),
+	)
+	if out, err := cmd.CombinedOutput(); err != nil {
+		if msg := strings.TrimSpace(string(out)); msg != "" {
+			return fmt.Errorf("command failed: %w: %s", err, msg)
+		}
+		return fmt.Errorf("command failed: %w", err)
+	}
+	return nil
+}
+
+// postHook posts a payload to a hook's URL, as JSON unless its headers
+// say otherwise
+func postHook(ctx context.Context, h config.HookConfig, payload []byte) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, h.URL, bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for name, value := range h.Headers {
+		req.Header.Set(name, os.ExpandEnv(value))
+	}
+
+	resp, err := webhookClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("webhook failed: %w", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook returned status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// listHooks prints the configured hooks for /hooks
+func listHooks() {
+	hooks := configuredHooks()
+	if len(hooks) == 0 {
+		fmt.Println(`No hooks. Add them to ~/.twooms.config.json as "hooks", e.g.`)
+		fmt.Println(`  {"hooks": [{"name": "slack", "on": "task_done", "url": "https://hooks.slack.com/...", "payload": "{\"text\": {{json .Task.Name}}}"}]}`)
+		return
+	}
+
+	fmt.Println("Hooks:")
+	for _, h := range hooks {
+		var targets []string
+		if h.Command != "" {
+			targets = append(targets, "run "+h.Command)
+		}
+		if h.URL != "" {
+			targets = append(targets, "post to "+h.URL)
+		}
+		line := fmt.Sprintf("  %s: on %s -> %s", h.Name, h.On, strings.Join(targets, ", "))
+		if err := checkHook(h); err != nil {
+			line += " " + colorOverdue("("+err.Error()+")")
+		}
+		fmt.Println(line)
+	}
+}
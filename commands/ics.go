@@ -0,0 +1,108 @@
+package commands
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"twooms/i18n"
+	"twooms/storage"
+)
+
+func init() {
+	Register(&Command{
+		Name:        "/ics",
+		Description: "Export tasks with due dates as an iCalendar file (to-dos, or all-day events with --events)",
+		Hidden:      true,
+		Params: []Param{
+			{Name: "project_id", Type: ParamTypeString, Description: "Optional project ID or shortcut (all projects if omitted)", Required: false},
+			{Name: "file", Type: ParamTypeString, Description: "Optional output .ics file (prints to the terminal if omitted)", Required: false},
+			{Name: "events", Type: ParamTypeBoolean, Description: "Write all-day events instead of to-dos", Required: false},
+		},
+		Handler: func(args []string) bool {
+			events := false
+			var rest []string
+			for _, arg := range args {
+				if arg == "--events" {
+					events = true
+				} else {
+					rest = append(rest, arg)
+				}
+			}
+
+			// A lone argument ending in .ics is the file, not a project
+			var projectID, filename string
+			if len(rest) > 0 && !strings.HasSuffix(strings.ToLower(rest[0]), ".ics") {
+				projectID, rest = rest[0], rest[1:]
+			}
+			if len(rest) > 0 {
+				filename, rest = rest[0], rest[1:]
+			}
+			if len(rest) > 0 {
+				fmt.Println(i18n.T("ics.usage"))
+				return false
+			}
+
+			snap, err := storage.ExportSnapshot(GetStore())
+			if err != nil {
+				fmt.Printf("Error: %v\n", err)
+				return false
+			}
+			if projectID != "" {
+				if snap, err = projectSnapshot(snap, projectID); err != nil {
+					fmt.Printf("Error: %v\n", err)
+					return false
+				}
+			}
+
+			if filename == "" {
+				if err := storage.WriteICS(os.Stdout, snap, events); err != nil {
+					fmt.Printf("Error: %v\n", err)
+				}
+				return false
+			}
+
+			f, err := os.Create(filename)
+			if err != nil {
+				fmt.Printf("Error: %v\n", err)
+				return false
+			}
+			defer f.Close()
+
+			if err := storage.WriteICS(f, snap, events); err != nil {
+				fmt.Printf("Error writing calendar: %v\n", err)
+				return false
+			}
+
+			count := 0
+			for _, task := range snap.Tasks {
+				if task.DueDate != nil {
+					count++
+				}
+			}
+			fmt.Printf("Exported %d tasks with due dates to %s\n", count, filename)
+			return false
+		},
+	})
+}
+
+// projectSnapshot narrows a snapshot to one project and its tasks
+func projectSnapshot(snap *storage.Snapshot, projectID string) (*storage.Snapshot, error) {
+	id, err := GetStore().ResolveProjectID(projectID)
+	if err != nil {
+		return nil, err
+	}
+
+	narrowed := &storage.Snapshot{}
+	for _, p := range snap.Projects {
+		if p.ID == id {
+			narrowed.Projects = append(narrowed.Projects, p)
+		}
+	}
+	for _, t := range snap.Tasks {
+		if t.ProjectID == id {
+			narrowed.Tasks = append(narrowed.Tasks, t)
+		}
+	}
+	return narrowed, nil
+}
@@ -0,0 +1,63 @@
+package commands
+
+import (
+	"fmt"
+	"strings"
+
+	"twooms/i18n"
+	"twooms/storage"
+)
+
+func init() {
+	Register(&Command{
+		Name:        "/in",
+		Description: "Capture a task in the inbox, without deciding its project yet",
+		Examples:    []string{"/in Renew passport due:2025-06-01"},
+		Access:      AccessCreate,
+		Params: []Param{
+			{Name: "task_name", Type: ParamTypeString, Description: "The name of the task; may include inline metadata like !p1 @context due:fri ~2h #tag", Required: true},
+		},
+		Handler: func(args []string) bool {
+			if len(args) == 0 {
+				fmt.Println(i18n.T("in.usage"))
+				return false
+			}
+			addTask("", args)
+			return false
+		},
+		ToolHandler: func(args map[string]any) (string, error) {
+			taskName, err := stringArg(args, "task_name")
+			if err != nil {
+				return "", err
+			}
+			return captureOutput(func() { addTask("", strings.Fields(taskName)) }), nil
+		},
+	})
+
+	Register(&Command{
+		Name:        "/inbox",
+		Description: "List tasks in the inbox, which have no project yet",
+		Access:      AccessRead,
+		Handler: func(args []string) bool {
+			listInbox()
+			return false
+		},
+	})
+}
+
+// listInbox prints the inbox's tasks, with a reminder of how to file them
+func listInbox() {
+	tasks, err := GetStore().ListTasks("")
+	if err != nil {
+		fmt.Printf("Error listing tasks: %v\n", err)
+		return
+	}
+	if len(tasks) == 0 {
+		fmt.Println("The inbox is empty. Capture a task with /in <task name>.")
+		return
+	}
+
+	fmt.Printf("%s (%d):\n", storage.InboxName, len(tasks))
+	printTasks(tasks, "  ")
+	fmt.Println("File them with /move <task-id> <project-id>.")
+}
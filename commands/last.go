@@ -0,0 +1,124 @@
+package commands
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"twooms/storage"
+)
+
+// lastTaskID is the task most recently created this session, for /last
+var lastTaskID string
+
+// lastTaskFields maps each quick-edit key to the command that sets the field
+var lastTaskFields = map[string]struct {
+	name    string
+	command string
+}{
+	"d": {"due", "/due"},
+	"t": {"duration", "/duration"},
+	"p": {"priority", "/priority"},
+	"n": {"note", "/note"},
+}
+
+func init() {
+	// Tasks made by /task, /chat, rules, and scripts all count
+	Subscribe(func(e Event) {
+		if e.Name == EventTaskCreated {
+			lastTaskID = e.Task.ID
+		}
+	})
+
+	Register(&Command{
+		Name:        "/last",
+		Shorthand:   "/l",
+		Description: "Show the last task created this session and quick-edit it (d=due, t=duration, p=priority, n=note)",
+		Hidden:      true,
+		Interactive: true,
+		Handler: func(args []string) bool {
+			if lastTaskID == "" {
+				fmt.Println("No task created this session.")
+				return false
+			}
+			task, err := GetStore().GetTask(lastTaskID)
+			if err != nil {
+				fmt.Printf("Error: %v\n", err)
+				return false
+			}
+			printLastTask(task)
+
+			// Outside the REPL there's nothing to read edits from
+			if lineReader == nil {
+				return false
+			}
+			fmt.Println("Edit with d <due>, t <duration>, p <priority>, or n <note> ('none' clears); Enter to finish.")
+			for {
+				line, err := lineReader("edit> ")
+				if err != nil || strings.TrimSpace(line) == "" {
+					return false
+				}
+				editLastTask(task.ID, line)
+			}
+		},
+	})
+}
+
+// editLastTask applies one quick edit like "d fri" or "p high" by running the
+// field's command, asking for the value if only the key was given
+func editLastTask(taskID, line string) {
+	key, value, _ := strings.Cut(strings.TrimSpace(line), " ")
+	field, ok := lastTaskFields[strings.ToLower(key)]
+	if !ok {
+		fmt.Printf("  Unknown field %q: use d (due), t (duration), p (priority), or n (note)\n", key)
+		return
+	}
+
+	value = strings.TrimSpace(value)
+	if value == "" {
+		answer, err := lineReader(field.name + "> ")
+		if err != nil {
+			return
+		}
+		value = strings.TrimSpace(answer)
+		if value == "" {
+			return
+		}
+	}
+
+	// Due dates can be words like "fri" here, as in inline metadata
+	if field.name == "due" && value != "none" {
+		due, err := storage.ParseDueWord(value, time.Now())
+		if err != nil {
+			fmt.Printf("  %v\n", err)
+			return
+		}
+		value = due.Format("2006-01-02")
+	}
+
+	if _, err := Execute(field.command + " " + taskID + " " + value); err != nil {
+		fmt.Printf("Error: %v\n", err)
+	}
+}
+
+// printLastTask shows a task with the fields /last can edit
+func printLastTask(task *storage.Task) {
+	fmt.Printf("Last task: %s (ID: %s) in %s\n", storage.DisplayName(task.Name), shortenID(task.ID), projectName(task.ProjectID))
+
+	due := "none"
+	if task.DueDate != nil {
+		due = storage.FormatDue(*task.DueDate)
+	}
+	duration := "none"
+	if task.Duration != 0 {
+		duration = task.Duration.String()
+	}
+	priority := "none"
+	if task.Priority != "" {
+		priority = string(task.Priority)
+	}
+	fmt.Printf("  Due: %s | Duration: %s | Priority: %s\n", due, duration, priority)
+	if task.Note != "" {
+		fmt.Printf("  Note: %s\n", task.Note)
+	}
+}
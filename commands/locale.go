@@ -0,0 +1,41 @@
+package commands
+
+import (
+	"fmt"
+	"strings"
+
+	"twooms/i18n"
+)
+
+func init() {
+	Register(&Command{
+		Name:        "/locale",
+		Description: "Show or switch the language of command output (the choice is saved)",
+		Hidden:      true,
+		Params: []Param{
+			{Name: "locale", Type: ParamTypeString, Description: "Language to switch to", Required: false, Enum: i18n.Locales()},
+		},
+		Handler: func(args []string) bool {
+			available := strings.Join(i18n.Locales(), ", ")
+			if len(args) == 0 {
+				fmt.Println(i18n.T("locale.current", i18n.Locale(), available))
+				fmt.Println(i18n.T("locale.hint"))
+				return false
+			}
+
+			if err := i18n.SetLocale(args[0]); err != nil {
+				fmt.Println(i18n.T("locale.unknown", args[0], available))
+				return false
+			}
+
+			GetConfig().Locale = i18n.Locale()
+			if err := GetConfig().Save(); err != nil {
+				fmt.Println(i18n.T("locale.unsaved", i18n.Locale(), err))
+				return false
+			}
+
+			fmt.Println(i18n.T("locale.switched", i18n.Locale()))
+			return false
+		},
+	})
+}
@@ -0,0 +1,161 @@
+package commands
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"twooms/storage"
+)
+
+// logPeriods are the spans /log covers, by name
+var logPeriods = []string{"today", "yesterday", "week"}
+
+func init() {
+	Register(&Command{
+		Name:        "/log",
+		Description: "Show what was completed today, yesterday, or this week, and when, with the total estimated and tracked time; for standups, timesheets, and questions like what did I finish this week",
+		Examples: []string{
+			"/log",
+			"/log week",
+			"/log week work",
+			"/log yesterday",
+		},
+		Access: AccessRead,
+		Params: []Param{
+			{Name: "period", Type: ParamTypeString, Description: "today (the default), yesterday, or week (since Monday)", Required: false, Enum: logPeriods},
+			{Name: "project_id", Type: ParamTypeString, Description: "Optional project ID or shortcut to filter by", Required: false},
+		},
+		Handler: func(args []string) bool {
+			// Either argument may come alone, in either order
+			period, projectID := "today", ""
+			for _, arg := range args {
+				if isLogPeriod(arg) {
+					period = strings.ToLower(arg)
+					continue
+				}
+				resolved, err := GetStore().ResolveProjectID(arg)
+				if err != nil {
+					fmt.Printf("Error: %v\n", err)
+					return false
+				}
+				projectID = resolved
+			}
+			printCompletionLog(period, projectID, time.Now())
+			return false
+		},
+	})
+}
+
+// isLogPeriod reports whether arg names a /log period
+func isLogPeriod(arg string) bool {
+	for _, p := range logPeriods {
+		if strings.EqualFold(arg, p) {
+			return true
+		}
+	}
+	return false
+}
+
+// logRange is the span of local time a /log period covers, and how to say it
+func logRange(period string, now time.Time) (start, end time.Time, label string) {
+	today := dateOnly(now)
+	switch period {
+	case "yesterday":
+		return today.AddDate(0, 0, -1), today, "yesterday"
+	case "week":
+		return startOfWeek(today), today.AddDate(0, 0, 1), "this week"
+	}
+	return today, today.AddDate(0, 0, 1), "today"
+}
+
+// printCompletionLog lists the tasks completed in a period, oldest first and
+// grouped by day for a week, including ones archived since, with totals of
+// their estimates and the time tracked on them
+func printCompletionLog(period, projectID string, now time.Time) {
+	start, end, label := logRange(period, now)
+
+	tasks, err := GetStore().ListAllTasks()
+	if err != nil {
+		fmt.Printf("Error listing tasks: %v\n", err)
+		return
+	}
+	archived, err := GetStore().ListArchivedTasks(projectID)
+	if err != nil {
+		fmt.Printf("Error listing archived tasks: %v\n", err)
+		return
+	}
+
+	var done []*storage.Task
+	for _, t := range append(tasks, archived...) {
+		if t.CompletedAt == nil || (projectID != "" && t.ProjectID != projectID) {
+			continue
+		}
+		if at := t.CompletedAt.In(time.Local); !at.Before(start) && at.Before(end) {
+			done = append(done, t)
+		}
+	}
+	sort.SliceStable(done, func(i, j int) bool { return done[i].CompletedAt.Before(*done[j].CompletedAt) })
+
+	scope := ""
+	if projectID != "" {
+		scope = " in " + projectName(projectID)
+	}
+	if len(done) == 0 {
+		fmt.Printf("Nothing completed%s %s.\n", scope, label)
+		return
+	}
+
+	tracked := make(map[string]int)
+	if entries, err := GetStore().ListTimeEntries(); err == nil {
+		for _, e := range entries {
+			tracked[e.TaskID] += e.Minutes(now)
+		}
+	}
+
+	if period == "week" {
+		fmt.Printf("Completed%s this week (%s to %s):\n", scope, start.Format("2006-01-02"), now.Format("2006-01-02"))
+	} else {
+		fmt.Printf("Completed%s %s (%s):\n", scope, label, start.Format("Monday 2006-01-02"))
+	}
+
+	estimated, trackedTotal, unestimated := 0, 0, 0
+	day := ""
+	for _, t := range done {
+		at := t.CompletedAt.In(time.Local)
+		if period == "week" && at.Format("2006-01-02") != day {
+			day = at.Format("2006-01-02")
+			fmt.Printf("%s\n", at.Format("Monday 2006-01-02"))
+		}
+
+		line := fmt.Sprintf("  %s [%s] %s", at.Format("15:04"), shortenID(t.ID), storage.DisplayName(t.Name))
+		if projectID == "" {
+			line += " (" + projectName(t.ProjectID) + ")"
+		}
+		var extras []string
+		if t.Duration != 0 {
+			extras = append(extras, t.Duration.String()+" est")
+			estimated += t.Duration.ToMinutes()
+		} else {
+			unestimated++
+		}
+		if minutes := tracked[t.ID]; minutes > 0 {
+			extras = append(extras, storage.FormatMinutes(minutes)+" tracked")
+			trackedTotal += minutes
+		}
+		if len(extras) > 0 {
+			line += " - " + strings.Join(extras, ", ")
+		}
+		fmt.Println(line)
+	}
+
+	summary := fmt.Sprintf("Total: %d tasks, %s estimated", len(done), storage.FormatMinutes(estimated))
+	if unestimated > 0 {
+		summary += fmt.Sprintf(" (%d without a duration)", unestimated)
+	}
+	if trackedTotal > 0 {
+		summary += fmt.Sprintf(", %s tracked", storage.FormatMinutes(trackedTotal))
+	}
+	fmt.Println(summary)
+}
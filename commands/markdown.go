@@ -0,0 +1,247 @@
+package commands
+
+import (
+	"regexp"
+	"strings"
+
+	"twooms/storage"
+)
+
+// ANSI styles for rendered markdown
+const (
+	styleBold      = "\033[1m"
+	styleDim       = "\033[2m"
+	styleItalic    = "\033[3m"
+	styleUnderline = "\033[4m"
+	styleStrike    = "\033[9m"
+	styleCode      = "\033[36m"
+	styleReset     = "\033[0m"
+)
+
+var (
+	mdHeading   = regexp.MustCompile(`^(#{1,6})\s+(.*?)\s*#*\s*$`)
+	mdRule      = regexp.MustCompile(`^\s*([-*_])(\s*[-*_]){2,}\s*$`)
+	mdBullet    = regexp.MustCompile(`^(\s*)[-*+]\s+(.*)$`)
+	mdNumbered  = regexp.MustCompile(`^(\s*)(\d+[.)])\s+(.*)$`)
+	mdCheckbox  = regexp.MustCompile(`^\[([ xX])\]\s+`)
+	mdTableSep  = regexp.MustCompile(`^\s*\|?\s*:?-+:?\s*(\|\s*:?-+:?\s*)*\|?\s*$`)
+	mdAnsi      = regexp.MustCompile(`\033\[[0-9;]*m`)
+	mdInline    = regexp.MustCompile("`[^`]+`|\\*\\*[^*]+\\*\\*|__[^_]+__|~~[^~]+~~|\\*[^*\\s][^*]*\\*|\\b_[^_\\s][^_]*_\\b|\\[[^\\]]+\\]\\([^)\\s]+\\)")
+	mdLinkParts = regexp.MustCompile(`^\[([^\]]+)\]\(([^)\s]+)\)$`)
+)
+
+// renderMarkdown formats the markdown an LLM answers with for the terminal:
+// headings, emphasis, and code are styled, lists get bullets and hanging
+// indents, tables are aligned, and other lines wrap at width columns. Code
+// blocks and tables are never wrapped.
+func renderMarkdown(text string, width int) string {
+	if width < 20 {
+		width = 20
+	}
+	lines := strings.Split(strings.ReplaceAll(text, "\r\n", "\n"), "\n")
+	var out []string
+
+	for i := 0; i < len(lines); i++ {
+		line := lines[i]
+		trimmed := strings.TrimSpace(line)
+
+		switch {
+		case strings.HasPrefix(trimmed, "```") || strings.HasPrefix(trimmed, "~~~"):
+			// Code blocks keep their lines as written, indented and colored
+			fence := trimmed[:3]
+			for i++; i < len(lines) && !strings.HasPrefix(strings.TrimSpace(lines[i]), fence); i++ {
+				out = append(out, "  "+styleCode+strings.TrimRight(lines[i], " \t")+styleReset)
+			}
+		case trimmed == "":
+			out = append(out, "")
+		case mdHeading.MatchString(trimmed):
+			m := mdHeading.FindStringSubmatch(trimmed)
+			style := styleBold
+			if len(m[1]) == 1 {
+				style += styleUnderline
+			}
+			out = append(out, wrapStyled(style+stripInline(m[2])+styleReset, width, "", "")...)
+		case mdRule.MatchString(trimmed):
+			out = append(out, styleDim+strings.Repeat("─", min(width, 40))+styleReset)
+		case strings.HasPrefix(trimmed, ">"):
+			quote := strings.TrimSpace(strings.TrimPrefix(trimmed, ">"))
+			prefix := styleDim + "│ " + styleReset
+			out = append(out, wrapStyled(styleItalic+renderInline(quote)+styleReset, width, prefix, prefix)...)
+		case strings.HasPrefix(trimmed, "|"):
+			var rows []string
+			for ; i < len(lines) && strings.HasPrefix(strings.TrimSpace(lines[i]), "|"); i++ {
+				rows = append(rows, strings.TrimSpace(lines[i]))
+			}
+			i--
+			out = append(out, renderTable(rows)...)
+		case mdBullet.MatchString(line) && !mdRule.MatchString(line):
+			m := mdBullet.FindStringSubmatch(line)
+			indent := strings.Repeat("  ", len(expandTabs(m[1]))/2)
+			item, marker := m[2], "• "
+			if c := mdCheckbox.FindStringSubmatch(item); c != nil {
+				marker = "[ ] "
+				if c[1] != " " {
+					marker = "[✓] "
+				}
+				item = item[len(c[0]):]
+			}
+			out = append(out, wrapStyled(renderInline(item), width, indent+marker, indent+strings.Repeat(" ", storage.TextWidth(marker)))...)
+		case mdNumbered.MatchString(line):
+			m := mdNumbered.FindStringSubmatch(line)
+			indent := strings.Repeat("  ", len(expandTabs(m[1]))/2)
+			marker := m[2] + " "
+			out = append(out, wrapStyled(renderInline(m[3]), width, indent+marker, indent+strings.Repeat(" ", len(marker)))...)
+		default:
+			// Unlike markdown, lines of a paragraph stay apart: models
+			// rarely hard-wrap, and a line break usually means one
+			out = append(out, wrapStyled(renderInline(trimmed), width, "", "")...)
+		}
+	}
+
+	// Runs of blank lines collapse to one, and none lead or trail
+	var result []string
+	for _, line := range out {
+		if line == "" && (len(result) == 0 || result[len(result)-1] == "") {
+			continue
+		}
+		result = append(result, line)
+	}
+	for len(result) > 0 && result[len(result)-1] == "" {
+		result = result[:len(result)-1]
+	}
+	return strings.Join(result, "\n")
+}
+
+// expandTabs counts a tab as four spaces of list indentation
+func expandTabs(s string) string {
+	return strings.ReplaceAll(s, "\t", "    ")
+}
+
+// renderInline styles code spans, bold, italic, strikethrough, and links
+func renderInline(s string) string {
+	return mdInline.ReplaceAllStringFunc(s, func(m string) string {
+		switch {
+		case strings.HasPrefix(m, "`"):
+			return styleCode + m[1:len(m)-1] + styleReset
+		case strings.HasPrefix(m, "**"), strings.HasPrefix(m, "__"):
+			return styleBold + m[2:len(m)-2] + styleReset
+		case strings.HasPrefix(m, "~~"):
+			return styleStrike + m[2:len(m)-2] + styleReset
+		case strings.HasPrefix(m, "["):
+			link := mdLinkParts.FindStringSubmatch(m)
+			if link[1] == link[2] {
+				return styleUnderline + link[2] + styleReset
+			}
+			return link[1] + " " + styleDim + "(" + link[2] + ")" + styleReset
+		}
+		return styleItalic + m[1:len(m)-1] + styleReset
+	})
+}
+
+// stripInline removes inline markers, for text that is styled as a whole
+func stripInline(s string) string {
+	return mdAnsi.ReplaceAllString(renderInline(s), "")
+}
+
+// visibleWidth is the terminal width of s, not counting ANSI styles
+func visibleWidth(s string) int {
+	return storage.TextWidth(mdAnsi.ReplaceAllString(s, ""))
+}
+
+// wrapStyled word-wraps s to width columns. The first line starts with
+// first and the rest with rest. A style still open at a line break is
+// carried onto the next line, so prefixes stay unstyled.
+func wrapStyled(s string, width int, first, rest string) []string {
+	var lines []string
+	prefix := first
+	line, lineWidth := "", 0
+	active := ""
+
+	emit := func() {
+		if active != "" {
+			line += styleReset
+		}
+		lines = append(lines, prefix+line)
+		prefix = rest
+		line, lineWidth = active, 0
+	}
+
+	for _, word := range strings.Fields(s) {
+		w := visibleWidth(word)
+		room := width - visibleWidth(prefix)
+		if lineWidth > 0 && lineWidth+1+w > room {
+			emit()
+		}
+		if lineWidth > 0 {
+			line += " "
+			lineWidth++
+		}
+		line += word
+		lineWidth += w
+
+		// Track the styles open at the end of the word
+		for _, code := range mdAnsi.FindAllString(word, -1) {
+			if code == styleReset {
+				active = ""
+			} else {
+				active += code
+			}
+		}
+	}
+	if lineWidth > 0 || len(lines) == 0 {
+		emit()
+	}
+	return lines
+}
+
+// renderTable aligns a markdown table's columns and bolds its header; the
+// |---| separator row becomes a rule
+func renderTable(rows []string) []string {
+	var cells [][]string
+	header := -1
+	for _, row := range rows {
+		if mdTableSep.MatchString(row) {
+			if header < 0 && len(cells) == 1 {
+				header = 0
+			}
+			continue
+		}
+		row = strings.TrimSuffix(strings.TrimPrefix(row, "|"), "|")
+		parts := strings.Split(row, "|")
+		for i := range parts {
+			parts[i] = renderInline(strings.TrimSpace(parts[i]))
+		}
+		cells = append(cells, parts)
+	}
+
+	var widths []int
+	for _, row := range cells {
+		for i, cell := range row {
+			if i >= len(widths) {
+				widths = append(widths, 0)
+			}
+			widths[i] = max(widths[i], visibleWidth(cell))
+		}
+	}
+
+	var out []string
+	for r, row := range cells {
+		var parts []string
+		for i, cell := range row {
+			pad := strings.Repeat(" ", widths[i]-visibleWidth(cell))
+			if r == header {
+				cell = styleBold + cell + styleReset
+			}
+			parts = append(parts, cell+pad)
+		}
+		out = append(out, strings.TrimRight(strings.Join(parts, "  "), " "))
+		if r == header {
+			total := 0
+			for _, w := range widths {
+				total += w
+			}
+			out = append(out, styleDim+strings.Repeat("─", total+2*(len(widths)-1))+styleReset)
+		}
+	}
+	return out
+}
@@ -0,0 +1,116 @@
+package commands
+
+import (
+	"fmt"
+	"strings"
+
+	"twooms/llm"
+)
+
+func init() {
+	Register(&Command{
+		Name:        "/model",
+		Shorthand:   "/m",
+		Description: "Show, list, or switch the LLM model (the choice is saved)",
+		Hidden:      true,
+		Handler: func(args []string) bool {
+			client := GetLLMClient()
+			if client == nil {
+				fmt.Println("Error: no LLM provider is set up (/providers shows how to add one)")
+				return false
+			}
+
+			selector, ok := client.(llm.ModelSelector)
+			if !ok {
+				fmt.Println("Error: this LLM provider does not support switching models")
+				return false
+			}
+
+			if len(args) == 0 {
+				fmt.Printf("Current model: %s\n", selector.Model())
+				fmt.Println("Use /model list [filter] to see available models, /model <name> to switch")
+				return false
+			}
+
+			if strings.ToLower(args[0]) == "list" {
+				listModels(client, strings.ToLower(strings.Join(args[1:], " ")))
+				return false
+			}
+
+			model := args[0]
+
+			// Validate against the provider's list when we can fetch it
+			if lister, ok := client.(llm.ModelLister); ok {
+				if models, err := lister.ListModels(commandContext()); err == nil && findModel(models, model) == nil {
+					fmt.Printf("Error: unknown model: %s (see /model list)\n", model)
+					return false
+				}
+			}
+
+			selector.SetModel(model)
+
+			GetConfig().Model = model
+			if err := GetConfig().Save(); err != nil {
+				fmt.Printf("Switched to %s for this session, but could not save it: %v\n", model, err)
+			} else {
+				fmt.Printf("Switched model to %s (saved)\n", model)
+			}
+
+			// Model names belong to one provider, so the fallbacks keep theirs
+			if f, ok := client.(*llm.FallbackClient); ok && len(f.Providers()) > 1 {
+				names := f.Providers()
+				fmt.Printf("This is %s's model; %s keep their own if it fails.\n", names[0], strings.Join(names[1:], " and "))
+			}
+			return false
+		},
+	})
+}
+
+// listModels prints the provider's models whose ID or name contains filter
+func listModels(client llm.Client, filter string) {
+	lister, ok := client.(llm.ModelLister)
+	if !ok {
+		fmt.Println("Error: this LLM provider does not support listing models")
+		return
+	}
+
+	models, err := lister.ListModels(commandContext())
+	if err != nil {
+		fmt.Printf("Error listing models: %v\n", err)
+		return
+	}
+
+	current := ""
+	if selector, ok := client.(llm.ModelSelector); ok {
+		current = selector.Model()
+	}
+
+	count := 0
+	fmt.Println("Models (price per 1M tokens in / out):")
+	for _, m := range models {
+		if filter != "" && !strings.Contains(strings.ToLower(m.ID), filter) && !strings.Contains(strings.ToLower(m.Name), filter) {
+			continue
+		}
+		count++
+
+		marker := " "
+		if m.ID == current {
+			marker = "*"
+		}
+		fmt.Printf("%s %-45s $%.2f / $%.2f  (%dk context)\n",
+			marker, m.ID, m.PromptPrice*1e6, m.CompletionPrice*1e6, m.ContextLength/1000)
+	}
+
+	if count == 0 {
+		fmt.Println("  No models match")
+	}
+}
+
+func findModel(models []*llm.ModelInfo, id string) *llm.ModelInfo {
+	for _, m := range models {
+		if m.ID == id {
+			return m
+		}
+	}
+	return nil
+}
@@ -0,0 +1,56 @@
+package commands
+
+import (
+	"fmt"
+	"strings"
+
+	"twooms/i18n"
+	"twooms/storage"
+)
+
+func init() {
+	Register(&Command{
+		Name:        "/move",
+		Shorthand:   "/mv",
+		Description: "Move a task to another project, keeping its due date, duration, priority, tags, and done status",
+		Examples:    []string{"/move 1a2b3c4d web", "/move 1a2b3c4d inbox"},
+		Params: []Param{
+			{Name: "task_id", Type: ParamTypeString, Description: "The ID of the task to move", Required: true},
+			{Name: "project_id", Type: ParamTypeString, Description: "The ID or shortcut of the project to move it to, or inbox", Required: true},
+		},
+		Handler: func(args []string) bool {
+			if len(args) != 2 {
+				fmt.Println(i18n.T("move.usage"))
+				return false
+			}
+
+			taskID, err := GetStore().ResolveTaskID(args[0])
+			if err != nil {
+				fmt.Printf("Error: %v\n", err)
+				return false
+			}
+			// "inbox" is a reserved shortcut, so it always means the inbox
+			projectID := ""
+			if !strings.EqualFold(args[1], storage.InboxName) {
+				projectID, err = GetStore().ResolveProjectID(args[1])
+				if err != nil {
+					fmt.Printf("Error: %v\n", err)
+					return false
+				}
+			}
+
+			if err := GetStore().MoveTask(taskID, projectID); err != nil {
+				fmt.Printf("Error: %v\n", err)
+				return false
+			}
+
+			task, err := GetStore().GetTask(taskID)
+			if err != nil {
+				fmt.Printf("Error: %v\n", err)
+				return false
+			}
+			fmt.Printf("Moved %s to %s\n", task.Name, projectName(projectID))
+			return false
+		},
+	})
+}
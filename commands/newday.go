@@ -0,0 +1,87 @@
+package commands
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"twooms/storage"
+)
+
+// defaultNewDayIdle is how long the REPL sits idle before a new day's
+// summary is printed, when the config doesn't say
+const defaultNewDayIdle = time.Hour
+
+// NewDayIdle returns how long the REPL must be idle before a new day's
+// summary is printed (new_day_idle in the config), or 0 if it's turned off
+func NewDayIdle() (time.Duration, error) {
+	setting := strings.TrimSpace(GetConfig().NewDayIdle)
+	switch setting {
+	case "":
+		return defaultNewDayIdle, nil
+	case "off":
+		return 0, nil
+	}
+	idle, err := time.ParseDuration(setting)
+	if err != nil || idle <= 0 {
+		return 0, fmt.Errorf("invalid new_day_idle %q (use a duration like 30m, or off)", setting)
+	}
+	return idle, nil
+}
+
+// NewDay catches up after the date changes under a long-running session:
+// due-date rules fire for tasks that just became overdue, and the returned
+// summary lists them along with what's due today. since is when the
+// session last caught up.
+func NewDay(since, now time.Time) string {
+	CheckDueRules()
+	return newDaySummary(since, now)
+}
+
+// newDaySummary describes a new day: the date, tasks whose due date passed
+// since the last summary, and how many are due today
+func newDaySummary(since, now time.Time) string {
+	today := dateOnly(now)
+	var b strings.Builder
+	fmt.Fprintf(&b, "New day: %s", now.Format("Monday 2006-01-02"))
+	if days := int(today.Sub(dateOnly(since)).Hours()/24 + 0.5); days > 1 {
+		fmt.Fprintf(&b, " (%d days since the last summary)", days)
+	}
+	b.WriteString("\n")
+
+	tasks, err := GetStore().ListAllTasks()
+	if err != nil {
+		fmt.Fprintf(&b, "Error listing tasks: %v", err)
+		return b.String()
+	}
+
+	var overdue []*storage.Task
+	dueToday := 0
+	for _, t := range tasks {
+		if t.Done || t.DueDate == nil {
+			continue
+		}
+		due := dateOnly(*t.DueDate)
+		switch {
+		case due.Equal(today):
+			dueToday++
+		case due.Before(today) && !due.Before(dateOnly(since)):
+			overdue = append(overdue, t)
+		}
+	}
+	sort.SliceStable(overdue, func(i, j int) bool {
+		return overdue[i].DueDate.Before(*overdue[j].DueDate)
+	})
+
+	if len(overdue) == 0 {
+		b.WriteString("Nothing became overdue.\n")
+	} else {
+		fmt.Fprintf(&b, "%d tasks became overdue:\n", len(overdue))
+		for _, t := range overdue {
+			fmt.Fprintf(&b, "  [%s] %s (%s, due %s)\n", shortenID(t.ID), storage.DisplayName(t.Name), projectName(t.ProjectID), storage.FormatDue(*t.DueDate))
+		}
+	}
+	fmt.Fprintf(&b, "%d tasks due today.", dueToday)
+	return b.String()
+}
@@ -0,0 +1,235 @@
+package commands
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"sort"
+	"strings"
+	"time"
+
+	"twooms/i18n"
+)
+
+// defaultNotifyTimes is when reminders go out if none are configured
+var defaultNotifyTimes = []string{"09:00"}
+
+// maxReminderTasks caps how many task names one notification lists
+const maxReminderTasks = 5
+
+// notifyStop stops the reminder goroutine; nil while reminders are off
+var notifyStop chan struct{}
+
+// notifyDone is closed when the reminder goroutine has returned
+var notifyDone chan struct{}
+
+// notifyTimes are the times the running reminder goroutine uses
+var notifyTimes []string
+
+// sendNotification shows a desktop notification (replaced in tests)
+var sendNotification = desktopNotify
+
+// untilReminder is how long to wait for the next of the reminder times
+// (replaced in tests)
+var untilReminder = func(times []string) time.Duration {
+	return time.Until(nextReminder(time.Now(), times))
+}
+
+func init() {
+	Register(&Command{
+		Name:        "/notify",
+		Description: "Turn desktop reminders for tasks due today or overdue on or off, or send one now",
+		Hidden:      true,
+		Params: []Param{
+			{Name: "action", Type: ParamTypeString, Description: "on, off, status, or test", Required: false, Enum: []string{"on", "off", "status", "test"}},
+			{Name: "times", Type: ParamTypeString, Description: "With on: reminder times as HH:MM (saved)", Required: false},
+		},
+		Handler: func(args []string) bool {
+			action := "status"
+			if len(args) > 0 {
+				action = strings.ToLower(args[0])
+			}
+
+			switch action {
+			case "status":
+				if notifyStop == nil {
+					fmt.Println("Reminders are off. Use /notify on [HH:MM ...] to start them.")
+				} else {
+					fmt.Printf("Reminders are on at %s\n", strings.Join(notifyTimes, ", "))
+				}
+			case "on":
+				times, err := parseNotifyTimes(args[1:])
+				if err != nil {
+					fmt.Printf("Error: %v\n", err)
+					return false
+				}
+				if len(times) > 0 {
+					GetConfig().NotifyTimes = times
+					if err := GetConfig().Save(); err != nil {
+						fmt.Printf("Warning: could not save reminder times: %v\n", err)
+					}
+				} else if times = GetConfig().NotifyTimes; len(times) == 0 {
+					times = defaultNotifyTimes
+				}
+				startReminders(times)
+				fmt.Printf("Reminders on: tasks due today or overdue at %s\n", strings.Join(times, ", "))
+			case "off":
+				if notifyStop == nil {
+					fmt.Println("Reminders are already off.")
+					return false
+				}
+				stopReminders()
+				fmt.Println("Reminders off.")
+			case "test":
+				sent, err := sendReminder()
+				if err != nil {
+					fmt.Printf("Error: %v\n", err)
+				} else if sent == 0 {
+					fmt.Println("Nothing due today, so no reminder was sent.")
+				} else {
+					fmt.Printf("Sent a reminder for %d tasks\n", sent)
+				}
+			default:
+				fmt.Println(i18n.T("notify.usage"))
+			}
+			return false
+		},
+	})
+}
+
+// Notifying reports whether the reminder goroutine is running
+func Notifying() bool {
+	return notifyStop != nil
+}
+
+// parseNotifyTimes validates HH:MM times, returning them sorted and normalized
+func parseNotifyTimes(args []string) ([]string, error) {
+	var times []string
+	for _, arg := range args {
+		t, err := time.Parse("15:04", arg)
+		if err != nil {
+			return nil, fmt.Errorf("invalid time %q (use HH:MM, e.g., 09:00)", arg)
+		}
+		times = append(times, t.Format("15:04"))
+	}
+	sort.Strings(times)
+	return times, nil
+}
+
+// startReminders (re)starts the goroutine that sends a reminder at each of
+// times every day. It reads the store while the REPL writes it, which is safe
+// because the store hands out copies.
+func startReminders(times []string) {
+	stopReminders()
+	stop, done := make(chan struct{}), make(chan struct{})
+	notifyStop, notifyDone, notifyTimes = stop, done, times
+
+	go func() {
+		defer close(done)
+		for {
+			timer := time.NewTimer(untilReminder(times))
+			select {
+			case <-stop:
+				timer.Stop()
+				return
+			case <-timer.C:
+				// Printed to stderr so it doesn't land in captured command output
+				if _, err := sendReminder(); err != nil {
+					fmt.Fprintf(os.Stderr, "Reminder failed: %v\n", err)
+				}
+			}
+		}
+	}()
+}
+
+// stopReminders stops the reminder goroutine and waits for it, so no
+// reminder goes out once it returns
+func stopReminders() {
+	if notifyStop != nil {
+		close(notifyStop)
+		<-notifyDone
+		notifyStop, notifyDone, notifyTimes = nil, nil, nil
+	}
+}
+
+// nextReminder returns the first of the daily HH:MM times after now
+func nextReminder(now time.Time, times []string) time.Time {
+	var next time.Time
+	for _, s := range times {
+		t, err := time.Parse("15:04", s)
+		if err != nil {
+			continue
+		}
+		at := time.Date(now.Year(), now.Month(), now.Day(), t.Hour(), t.Minute(), 0, 0, now.Location())
+		if !at.After(now) {
+			at = at.AddDate(0, 0, 1)
+		}
+		if next.IsZero() || at.Before(next) {
+			next = at
+		}
+	}
+	return next
+}
+
+// sendReminder notifies about the open tasks due today or overdue, returning
+// how many there were. Nothing is sent when nothing is due.
+func sendReminder() (int, error) {
+	due := tasksDueToday()
+	if len(due) == 0 {
+		return 0, nil
+	}
+
+	today := dateOnly(time.Now())
+	var lines []string
+	for i, t := range due {
+		if i == maxReminderTasks {
+			lines = append(lines, fmt.Sprintf("and %d more", len(due)-maxReminderTasks))
+			break
+		}
+		line := t.Name
+		if dateOnly(*t.DueDate).Before(today) {
+			line += " (overdue)"
+		}
+		lines = append(lines, line)
+	}
+
+	title := fmt.Sprintf("Twooms: %d tasks due", len(due))
+	if len(due) == 1 {
+		title = "Twooms: 1 task due"
+	}
+	return len(due), sendNotification(title, strings.Join(lines, "\n"))
+}
+
+// desktopNotify shows a notification with the platform's own tool:
+// notify-send on Linux and BSD, osascript on macOS, and a PowerShell toast on
+// Windows. The text is passed in environment variables so it needs no quoting.
+func desktopNotify(title, body string) error {
+	var cmd *exec.Cmd
+	switch runtime.GOOS {
+	case "darwin":
+		cmd = exec.Command("osascript", "-e",
+			`display notification (system attribute "TWOOMS_NOTIFY_BODY") with title (system attribute "TWOOMS_NOTIFY_TITLE")`)
+	case "windows":
+		cmd = exec.Command("powershell", "-NoProfile", "-Command", windowsToastScript)
+	default:
+		cmd = exec.Command("notify-send", "--app-name=twooms", title, body)
+	}
+	cmd.Env = append(os.Environ(), "TWOOMS_NOTIFY_TITLE="+title, "TWOOMS_NOTIFY_BODY="+body)
+
+	if output, err := cmd.CombinedOutput(); err != nil {
+		if msg := strings.TrimSpace(string(output)); msg != "" {
+			return fmt.Errorf("%s: %v (%s)", cmd.Args[0], err, msg)
+		}
+		return fmt.Errorf("%s: %v", cmd.Args[0], err)
+	}
+	return nil
+}
+
+// windowsToastScript shows TWOOMS_NOTIFY_TITLE and TWOOMS_NOTIFY_BODY as a toast
+const windowsToastScript = `$m = [Windows.UI.Notifications.ToastNotificationManager, Windows.UI.Notifications, ContentType = WindowsRuntime]
+$x = $m::GetTemplateContent([Windows.UI.Notifications.ToastTemplateType]::ToastText02)
+$t = $x.GetElementsByTagName('text')
+$t.Item(0).AppendChild($x.CreateTextNode($env:TWOOMS_NOTIFY_TITLE)) > $null
+$t.Item(1).AppendChild($x.CreateTextNode($env:TWOOMS_NOTIFY_BODY)) > $null
+$m::CreateToastNotifier('twooms').Show([Windows.UI.Notifications.ToastNotification]::new($x))`
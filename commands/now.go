@@ -0,0 +1,98 @@
+package commands
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"twooms/storage"
+)
+
+func init() {
+	Register(&Command{
+		Name:        "/now",
+		Description: "Show the current time, the running timer, and today's tasks with a due time. Call this when asked what to do now or next.",
+		Access:      AccessRead,
+		Handler: func(args []string) bool {
+			fmt.Print(nowSummary(time.Now()))
+			return false
+		},
+	})
+}
+
+// nowSummary describes the moment for /now: the time, the running timer,
+// and each open task due at a time today, with how long until (or since) it
+func nowSummary(now time.Time) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Now: %s\n", now.Format("Monday 2006-01-02 15:04"))
+	if name := timedTaskName(); name != "" {
+		entry, _ := GetStore().ActiveTimer()
+		fmt.Fprintf(&b, "Timer running: %s (%s so far)\n", storage.DisplayName(name), storage.FormatMinutes(entry.Minutes(now)))
+	}
+
+	tasks := timedTasksToday(now)
+	if len(tasks) == 0 {
+		b.WriteString("No tasks due at a set time today\n")
+		return b.String()
+	}
+	b.WriteString("Due today at a set time:\n")
+	for _, t := range tasks {
+		fmt.Fprintf(&b, "  %s\n", formatReminder(t, now))
+	}
+	return b.String()
+}
+
+// timedTasksToday returns open tasks due at a time of day today, soonest first
+func timedTasksToday(now time.Time) []*storage.Task {
+	tasks, err := GetStore().ListAllTasks()
+	if err != nil {
+		return nil
+	}
+	today := dateOnly(now)
+
+	var timed []*storage.Task
+	for _, t := range tasks {
+		if !t.Done && t.DueDate != nil && storage.HasDueTime(*t.DueDate) && dateOnly(*t.DueDate).Equal(today) {
+			timed = append(timed, t)
+		}
+	}
+	sort.SliceStable(timed, func(i, j int) bool { return timed[i].DueDate.Before(*timed[j].DueDate) })
+	return timed
+}
+
+// formatReminder describes a task due at a time, e.g.
+// "15:00 Submit grant [1a2b3c4d] (Work), in 20m"
+func formatReminder(t *storage.Task, now time.Time) string {
+	left := storage.DueAt(*t.DueDate, time.Local).Sub(now).Round(time.Minute)
+	when := "in " + storage.FormatMinutes(int(left.Minutes()))
+	if left < 0 {
+		when = storage.FormatMinutes(int(-left.Minutes())) + " ago, overdue"
+	}
+	shortID := t.ID
+	if len(shortID) > 8 {
+		shortID = shortID[:8]
+	}
+	return fmt.Sprintf("%s %s [%s] (%s), %s", t.DueDate.Format("15:04"), storage.DisplayName(t.Name), shortID, storage.DisplayName(projectName(t.ProjectID)), when)
+}
+
+// upcomingReminders lists the open tasks due within the due-soon window
+// (due_soon_hours) for the chat system prompt, so answers about what to do
+// now can account for them without a tool call
+func upcomingReminders(now time.Time) string {
+	window := dueSoonWindow()
+	if GetStore() == nil || window == 0 {
+		return ""
+	}
+	var lines []string
+	for _, t := range timedTasksToday(now) {
+		left := storage.DueAt(*t.DueDate, time.Local).Sub(now)
+		if left > 0 && left <= window {
+			lines = append(lines, "\n- "+formatReminder(t, now))
+		}
+	}
+	if len(lines) == 0 {
+		return ""
+	}
+	return "\n\nDUE SOON:" + strings.Join(lines, "")
+}
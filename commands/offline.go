@@ -0,0 +1,271 @@
+package commands
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"twooms/storage"
+)
+
+// offlineHelp lists what /chat understands without an LLM provider
+const offlineHelp = `Offline assistant: no LLM provider is set up (see /providers), so /chat understands simple requests like:
+  what's due today / tomorrow / this week
+  what now
+  what did I finish today / yesterday / this week
+  show projects / show tasks in <project>
+  add <task> [to <project>] [due <day>] (without a project it goes in the inbox)
+  create project <name>
+  mark <task> done / <task> done
+  <task> due <day>
+  start <task> / stop
+  find <words>
+For anything else, use a /command (/help lists them).`
+
+// offlineIntent turns a request matching pattern into a command line
+type offlineIntent struct {
+	pattern *regexp.Regexp
+	command func(m []string) (string, error)
+}
+
+// offlineActions change tasks and projects. They're tried in order, so the
+// specific ones (creating a project) come before the general ones (adding a
+// task) that would also match.
+var offlineActions = []offlineIntent{
+	{regexp.MustCompile(`^(?:add|create|new|start) (?:a )?(?:new )?project (?:called |named )?(.+)$`), func(m []string) (string, error) {
+		return "/project " + JoinArgs([]string{m[1]}), nil
+	}},
+	{regexp.MustCompile(`^(?:add|create|new) (?:a )?(?:new )?(?:task )?(.+?)(?: (?:to|in|for|under) (?:the )?(?:project )?(.+?))?(?: due (?:on )?(\S+))?$`), func(m []string) (string, error) {
+		words := strings.Fields(m[1])
+		if m[3] != "" {
+			words = append(words, "due:"+m[3])
+		}
+		// With no project named or focused, the task waits in the inbox
+		if m[2] == "" && focusProjectID == "" {
+			return "/in " + JoinArgs(words), nil
+		}
+		project, err := offlineProject(m[2])
+		if err != nil {
+			return "", err
+		}
+		return "/task " + JoinArgs(append([]string{project}, words...)), nil
+	}},
+	{regexp.MustCompile(`^(?:mark |set )?(.+?) (?:as )?(?:done|complete|completed|finished)$`), func(m []string) (string, error) {
+		return offlineTaskCommand("/done", m[1])
+	}},
+	{regexp.MustCompile(`^(?:done|finish|finished|complete|completed|i (?:finished|did|completed)) (.+)$`), func(m []string) (string, error) {
+		return offlineTaskCommand("/done", m[1])
+	}},
+	{regexp.MustCompile(`^(?:set |make )?(.+?) (?:is )?due (?:on )?(\S+)$`), func(m []string) (string, error) {
+		due, err := storage.ParseDueWord(m[2], time.Now())
+		if err != nil {
+			return "", err
+		}
+		return offlineTaskCommand("/due", m[1], due.Format("2006-01-02"))
+	}},
+	{regexp.MustCompile(`^stop(?: the)?(?: timer| working)?$`), func([]string) (string, error) {
+		return "/stop", nil
+	}},
+	{regexp.MustCompile(`^(?:start working on|work on|start|begin) (.+)$`), func(m []string) (string, error) {
+		return offlineTaskCommand("/start", m[1])
+	}},
+	{regexp.MustCompile(`^(?:search|find|look) (?:for )?(.+)$`), func(m []string) (string, error) {
+		return "/search " + JoinArgs([]string{m[1]}), nil
+	}},
+}
+
+// offlineViews show tasks, recognized by a keyword anywhere in the request
+var offlineViews = []offlineIntent{
+	{regexp.MustCompile(`^(?:help|what can you do)$`), func([]string) (string, error) {
+		return "", nil
+	}},
+	{regexp.MustCompile(`\b(?:did i|have i|i've|i have) (?:finish|finished|complete|completed|do|done|get done|got done)\b(?:.*\b(yesterday|week)\b)?`), func(m []string) (string, error) {
+		if m[1] != "" {
+			return "/log " + m[1], nil
+		}
+		return "/log", nil
+	}},
+	{regexp.MustCompile(`\btasks (?:in|for|of) (?:the |my )?(?:project )?(.+?)(?: project)?$`), func(m []string) (string, error) {
+		project, err := offlineProject(m[1])
+		if err != nil {
+			return "", err
+		}
+		return "/tasks " + JoinArgs([]string{project}), nil
+	}},
+	{regexp.MustCompile(`\bprojects\b`), func([]string) (string, error) { return "/projects", nil }},
+	{regexp.MustCompile(`\b(?:now|next)\b`), func([]string) (string, error) { return "/now", nil }},
+	{regexp.MustCompile(`\btomorrow\b`), func([]string) (string, error) { return "/tomorrow", nil }},
+	{regexp.MustCompile(`\bweek\b`), func([]string) (string, error) { return "/week", nil }},
+	{regexp.MustCompile(`\b(?:today|overdue|due)\b`), func([]string) (string, error) { return "/today", nil }},
+}
+
+// offlineQuestion recognizes requests to see tasks rather than change them
+var offlineQuestion = regexp.MustCompile(`^(?:what|which|show|list|anything|do i have|is there)\b`)
+
+// offlineChat answers /chat without an LLM provider: a request it
+// recognizes runs as the matching command, and anything else gets a list
+// of what it understands. In a dry run, commands that change data are
+// only shown.
+func offlineChat(message string, dry bool) {
+	line, err := parseOffline(message)
+	switch {
+	case err != nil:
+		fmt.Printf("Error: %v\n", err)
+	case line == "":
+		fmt.Println(offlineHelp)
+	case dry && !readOnlyLine(line):
+		fmt.Printf("Dry run: would run %s. Nothing was changed.\n", line)
+	default:
+		fmt.Printf("(offline) %s\n", line)
+		if _, err := Execute(line); err != nil {
+			fmt.Printf("Error: %v\n", err)
+		}
+	}
+}
+
+// readOnlyLine reports whether a command line only reads data
+func readOnlyLine(line string) bool {
+	cmd, ok := Lookup(strings.Fields(line)[0])
+	return ok && cmd.ReadOnly()
+}
+
+// parseOffline turns a plain-English request into a command line, or ""
+// when it isn't one the offline assistant understands. Matching ignores
+// case and trailing punctuation; task and project names keep theirs.
+func parseOffline(message string) (string, error) {
+	// /chat keeps quotes as typed, and `twooms chat "..."` adds them
+	message = strings.TrimSpace(message)
+	if len(message) >= 2 && message[0] == message[len(message)-1] && strings.ContainsAny(message[:1], `"'`) {
+		message = message[1 : len(message)-1]
+	}
+	message = strings.Join(strings.Fields(strings.TrimRight(message, "?!. ")), " ")
+	message = strings.TrimPrefix(strings.TrimPrefix(message, "please "), "Please ")
+	lower := strings.ToLower(message)
+
+	// "what's due today" would otherwise read as setting the due date of a
+	// task called "what's"
+	intents := append(append([]offlineIntent{}, offlineActions...), offlineViews...)
+	if offlineQuestion.MatchString(lower) {
+		intents = append(append([]offlineIntent{}, offlineViews...), offlineActions...)
+	}
+	for _, intent := range intents {
+		loc := intent.pattern.FindStringSubmatchIndex(lower)
+		if loc == nil {
+			continue
+		}
+		// Lowercasing can change byte lengths outside ASCII, so only reuse
+		// the match positions on the original text when it didn't
+		m := make([]string, len(loc)/2)
+		for i := range m {
+			if loc[2*i] < 0 {
+				continue
+			}
+			m[i] = lower[loc[2*i]:loc[2*i+1]]
+			if len(lower) == len(message) {
+				m[i] = message[loc[2*i]:loc[2*i+1]]
+			}
+		}
+		return intent.command(m)
+	}
+	return "", nil
+}
+
+// offlineProject finds a project by shortcut, ID, or name, and returns its
+// shortcut; with no reference it's the focused project
+func offlineProject(ref string) (string, error) {
+	id, err := offlineProjectID(ref)
+	if err != nil {
+		return "", err
+	}
+	if p, err := GetStore().GetProject(id); err == nil && p.Shortcut != "" {
+		return p.Shortcut, nil
+	}
+	return id, nil
+}
+
+func offlineProjectID(ref string) (string, error) {
+	if ref == "" {
+		if focusProjectID == "" {
+			return "", fmt.Errorf("which project? Say \"add <task> to <project>\"")
+		}
+		return focusProjectID, nil
+	}
+	if id, err := GetStore().ResolveProjectID(ref); err == nil {
+		return id, nil
+	}
+
+	projects, err := GetStore().ListProjects()
+	if err != nil {
+		return "", err
+	}
+	best, bestScore, tied := "", 0, false
+	for _, p := range projects {
+		score := fuzzyScore(ref, p.Name)
+		switch {
+		case score > bestScore:
+			best, bestScore, tied = p.ID, score, false
+		case score == bestScore && score > 0:
+			tied = true
+		}
+	}
+	if bestScore == 0 {
+		return "", fmt.Errorf("no project matches %q (/projects lists them)", ref)
+	}
+	if tied {
+		return "", fmt.Errorf("%q matches several projects; use its shortcut", ref)
+	}
+	return best, nil
+}
+
+// offlineTaskCommand builds a command line for the open task that best
+// matches name, e.g. "/done 1a2b3c4d"
+func offlineTaskCommand(command, name string, args ...string) (string, error) {
+	id, err := offlineTask(name)
+	if err != nil {
+		return "", err
+	}
+	// The command line is echoed, so use the short ID unless it's ambiguous
+	if resolved, err := GetStore().ResolveTaskID(shortenID(id)); err == nil && resolved == id {
+		id = shortenID(id)
+	}
+	return command + " " + JoinArgs(append([]string{id}, args...)), nil
+}
+
+// offlineTask finds an open task by name or ID. A name has to pick out one
+// task, since the command runs without asking. Names are tried first, since
+// a short word like "face" can also be an ID prefix.
+func offlineTask(name string) (string, error) {
+	name = strings.TrimPrefix(strings.TrimPrefix(name, "the "), "task ")
+
+	tasks, err := GetStore().ListAllTasks()
+	if err != nil {
+		return "", err
+	}
+	var hits []*storage.Task
+	scores := make(map[string]int)
+	for _, t := range tasks {
+		if score := fuzzyScore(name, t.Name); !t.Done && score > 0 {
+			hits = append(hits, t)
+			scores[t.ID] = score
+		}
+	}
+	if len(hits) == 0 {
+		if id, err := GetStore().ResolveTaskID(name); err == nil {
+			return id, nil
+		}
+		return "", fmt.Errorf("no open task matches %q (/search looks through all of them)", name)
+	}
+	sort.SliceStable(hits, func(i, j int) bool { return scores[hits[i].ID] > scores[hits[j].ID] })
+	if len(hits) > 1 && scores[hits[1].ID] == scores[hits[0].ID] {
+		var names []string
+		for _, t := range hits {
+			if scores[t.ID] == scores[hits[0].ID] {
+				names = append(names, fmt.Sprintf("%s [%s]", storage.DisplayName(t.Name), shortenID(t.ID)))
+			}
+		}
+		return "", fmt.Errorf("%q matches several tasks: %s; use its ID", name, strings.Join(names, ", "))
+	}
+	return hits[0].ID, nil
+}
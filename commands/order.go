@@ -0,0 +1,124 @@
+package commands
+
+import (
+	"fmt"
+	"strconv"
+
+	"twooms/i18n"
+	"twooms/storage"
+)
+
+func init() {
+	Register(&Command{
+		Name:        "/reorder",
+		Description: "Move a task to a position in its project's list, counting from 1 at the top",
+		Examples:    []string{"/reorder 1a2b3c4d 1", "/reorder 1a2b3c4d 3"},
+		Params: []Param{
+			{Name: "task_id", Type: ParamTypeString, Description: "The ID of the task to move", Required: true},
+			{Name: "position", Type: ParamTypeInteger, Description: "Its new position in the list, 1 for the top", Required: true},
+		},
+		Handler: func(args []string) bool {
+			if len(args) != 2 {
+				fmt.Println(i18n.T("reorder.usage"))
+				return false
+			}
+			position, err := strconv.Atoi(args[1])
+			if err != nil || position < 1 {
+				fmt.Printf("Error: position must be a number from 1, got %q\n", args[1])
+				return false
+			}
+			reorderTask(args[0], func(int) int { return position })
+			return false
+		},
+	})
+
+	Register(&Command{
+		Name:        "/moveup",
+		Description: "Move a task one place up in its project's list",
+		Examples:    []string{"/moveup 1a2b3c4d"},
+		Hidden:      true,
+		Params: []Param{
+			{Name: "task_id", Type: ParamTypeString, Description: "The ID of the task to move", Required: true},
+		},
+		Handler: func(args []string) bool {
+			if len(args) != 1 {
+				fmt.Println(i18n.T("moveup.usage"))
+				return false
+			}
+			reorderTask(args[0], func(current int) int { return current - 1 })
+			return false
+		},
+	})
+
+	Register(&Command{
+		Name:        "/movedown",
+		Description: "Move a task one place down in its project's list",
+		Examples:    []string{"/movedown 1a2b3c4d"},
+		Hidden:      true,
+		Params: []Param{
+			{Name: "task_id", Type: ParamTypeString, Description: "The ID of the task to move", Required: true},
+		},
+		Handler: func(args []string) bool {
+			if len(args) != 1 {
+				fmt.Println(i18n.T("movedown.usage"))
+				return false
+			}
+			reorderTask(args[0], func(current int) int { return current + 1 })
+			return false
+		},
+	})
+}
+
+// reorderTask moves a task to the position that to picks from its current
+// one; positions count from 1 in the order /tasks lists by default
+func reorderTask(ref string, to func(current int) int) {
+	taskID, err := GetStore().ResolveTaskID(ref)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		return
+	}
+	task, err := GetStore().GetTask(taskID)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		return
+	}
+	if task.IsArchived() {
+		fmt.Printf("Error: %s is archived\n", task.Name)
+		return
+	}
+	tasks, err := GetStore().ListTasks(task.ProjectID)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		return
+	}
+
+	current := taskPosition(tasks, taskID)
+	position := max(1, min(to(current), len(tasks)))
+	if position == current {
+		switch position {
+		case 1:
+			fmt.Printf("%s is already at the top of %s\n", task.Name, projectName(task.ProjectID))
+		case len(tasks):
+			fmt.Printf("%s is already at the bottom of %s\n", task.Name, projectName(task.ProjectID))
+		default:
+			fmt.Printf("%s is already number %d in %s\n", task.Name, position, projectName(task.ProjectID))
+		}
+		return
+	}
+
+	if err := GetStore().ReorderTask(taskID, position); err != nil {
+		fmt.Printf("Error: %v\n", err)
+		return
+	}
+	fmt.Printf("Moved %s to number %d of %d in %s\n", task.Name, position, len(tasks), projectName(task.ProjectID))
+}
+
+// taskPosition returns where a task is in a list, counting from 1, or 0
+func taskPosition(tasks []*storage.Task, id string) int {
+	for i, t := range tasks {
+		if t.ID == id {
+			return i + 1
+		}
+	}
+	return 0
+}
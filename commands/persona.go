@@ -0,0 +1,166 @@
+package commands
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"twooms/i18n"
+)
+
+// builtinPersonas are the prompt profiles /persona offers out of the box; a
+// file in the personas directory with the same name replaces one
+var builtinPersonas = map[string]string{
+	"terse":     "Answer in as few words as possible. No greetings, and no explanations unless asked.",
+	"verbose":   "Explain what you did and why, and point out related tasks or deadlines the user may want to know about.",
+	"gtd-coach": "Act as a Getting Things Done coach. Help the user capture everything into the inbox, turn vague tasks into a concrete next action, file inbox tasks into projects, and suggest a weekly review when the inbox grows. Keep suggestions short.",
+}
+
+// personaName is what a persona may be called, so names map to files safely
+var personaName = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]*$`)
+
+// promptDir holds system_prompt.md and the personas directory; main sets it
+// to ~/.twooms
+var promptDir string
+
+// SetPromptDir sets where the system prompt override and personas live
+func SetPromptDir(dir string) {
+	promptDir = dir
+}
+
+func init() {
+	Register(&Command{
+		Name:        "/persona",
+		Description: "List prompt profiles for chat, switch to one (the choice is saved), or show the system prompt",
+		Examples:    []string{"/persona", "/persona terse", "/persona default", "/persona show"},
+		Hidden:      true, // how the assistant talks is the user's choice
+		Handler: func(args []string) bool {
+			if len(args) == 0 {
+				listPersonas()
+				return false
+			}
+
+			name := strings.ToLower(args[0])
+			switch name {
+			case "show":
+				fmt.Println(getSystemPrompt())
+				return false
+			case "default", "none":
+				name = ""
+			default:
+				if _, err := personaText(name); err != nil {
+					fmt.Printf("Error: %v\n", err)
+					return false
+				}
+			}
+
+			GetConfig().Persona = name
+			label := name
+			if name == "" {
+				label = "default"
+			}
+			if err := GetConfig().Save(); err != nil {
+				fmt.Printf("Switched to the %s persona for this session, but could not save it: %v\n", label, err)
+				return false
+			}
+			fmt.Printf("Switched to the %s persona (saved)\n", label)
+			return false
+		},
+	})
+}
+
+// personaText returns a persona's instructions: its file in the personas
+// directory, or else the built-in one
+func personaText(name string) (string, error) {
+	if !personaName.MatchString(name) {
+		return "", fmt.Errorf("invalid persona name %q", name)
+	}
+	if promptDir != "" {
+		data, err := os.ReadFile(filepath.Join(promptDir, "personas", name+".md"))
+		if err == nil {
+			return strings.TrimSpace(string(data)), nil
+		}
+		if !errors.Is(err, os.ErrNotExist) {
+			return "", err
+		}
+	}
+	if text, ok := builtinPersonas[name]; ok {
+		return text, nil
+	}
+	return "", fmt.Errorf("unknown persona %q (/persona lists them)", name)
+}
+
+// personaNames lists the built-in personas and those in the personas directory
+func personaNames() []string {
+	seen := make(map[string]bool)
+	for name := range builtinPersonas {
+		seen[name] = true
+	}
+	if promptDir != "" {
+		files, _ := filepath.Glob(filepath.Join(promptDir, "personas", "*.md"))
+		for _, f := range files {
+			if name := strings.TrimSuffix(filepath.Base(f), ".md"); personaName.MatchString(name) {
+				seen[name] = true
+			}
+		}
+	}
+	names := make([]string, 0, len(seen))
+	for name := range seen {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func listPersonas() {
+	current := ""
+	if cfg := GetConfig(); cfg != nil {
+		current = cfg.Persona
+	}
+	mark := func(name string) string {
+		if name == current {
+			return "* "
+		}
+		return "  "
+	}
+
+	fmt.Println("Personas:")
+	fmt.Printf("  %sdefault\n", mark(""))
+	for _, name := range personaNames() {
+		fmt.Printf("  %s%s\n", mark(name), name)
+	}
+	if promptDir != "" {
+		fmt.Printf("Add your own as %s, or replace the built-in rules with %s.\n",
+			filepath.Join(promptDir, "personas", "<name>.md"), filepath.Join(promptDir, "system_prompt.md"))
+	}
+	fmt.Println(i18n.T("persona.usage"))
+}
+
+// promptRules returns the start of the system prompt: the system_prompt.md
+// override if there is one, else the built-in rules, followed by the active
+// persona's instructions. Both only change when the user edits them, so the
+// prompt cache still holds between turns.
+func promptRules() string {
+	rules := systemRules
+	if promptDir != "" {
+		data, err := os.ReadFile(filepath.Join(promptDir, "system_prompt.md"))
+		if err == nil && strings.TrimSpace(string(data)) != "" {
+			rules = strings.TrimSpace(string(data))
+		}
+	}
+
+	cfg := GetConfig()
+	if cfg == nil || cfg.Persona == "" {
+		return rules
+	}
+	text, err := personaText(cfg.Persona)
+	if err != nil {
+		// A persona file that was removed leaves the default behavior
+		return rules
+	}
+	return rules + "\n\nSTYLE:\n" + text
+}
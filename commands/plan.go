@@ -0,0 +1,304 @@
+package commands
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"twooms/i18n"
+	"twooms/storage"
+)
+
+func init() {
+	Register(&Command{
+		Name:        "/plan",
+		Shorthand:   "/pl",
+		Description: "Propose a day plan that fits a time budget (and the free time between meetings, if a calendar is set up), using due dates, priorities, and durations",
+		Examples:    []string{"/plan 4", "/plan 90m web --why"},
+		Access:      AccessRead,
+		Params: []Param{
+			{Name: "hours", Type: ParamTypeString, Description: "Time available (e.g., 4, 2.5, 90m, 1h30m); optional when a calendar is set up, which limits the plan to free time between meetings", Required: false},
+			{Name: "project_id", Type: ParamTypeString, Description: "Optional project ID to plan within", Required: false},
+			{Name: "why", Type: ParamTypeBoolean, Description: "Ask for a short explanation of the order", Required: false},
+		},
+		Handler: func(args []string) bool {
+			// --why asks the LLM to explain the order; strip it before parsing
+			why := false
+			var rest []string
+			for _, arg := range args {
+				if arg == "--why" {
+					why = true
+					continue
+				}
+				rest = append(rest, arg)
+			}
+
+			// With a calendar the budget is optional, and defaults to all the free time
+			cal := GetConfig().Calendar
+			if cal != nil && cal.URL == "" {
+				cal = nil
+			}
+			budget := 0
+			if len(rest) > 0 {
+				b, err := parseBudget(rest[0])
+				if err == nil {
+					budget, rest = b, rest[1:]
+				} else if cal == nil {
+					fmt.Printf("Error: %v\n", err)
+					return false
+				}
+			}
+			if (budget == 0 && cal == nil) || len(rest) > 1 {
+				fmt.Println(i18n.T("plan.usage"))
+				return false
+			}
+
+			var tasks []*storage.Task
+			var err error
+			if len(rest) > 0 {
+				// Resolve project ID
+				projectID, err := GetStore().ResolveProjectID(rest[0])
+				if err != nil {
+					fmt.Printf("Error: %v\n", err)
+					return false
+				}
+				tasks, err = GetStore().ListTasks(projectID)
+				if err != nil {
+					fmt.Printf("Error listing tasks: %v\n", err)
+					return false
+				}
+			} else {
+				tasks, err = GetStore().ListAllTasks()
+				if err != nil {
+					fmt.Printf("Error listing tasks: %v\n", err)
+					return false
+				}
+			}
+
+			var plan *storage.DayPlan
+			if cal == nil {
+				plan = storage.PlanDay(tasks, budget, time.Now())
+				printPlan(plan)
+			} else {
+				now := time.Now()
+				from, to, err := workday(cal, now)
+				if err != nil {
+					fmt.Printf("Error: %v\n", err)
+					return false
+				}
+				// After the workday there's no free time, and nothing to fetch
+				var busy []storage.Busy
+				if from.Before(to) {
+					if busy, err = busyTimes(cal, from, to); err != nil {
+						fmt.Printf("Error reading the calendar: %v\n", err)
+						return false
+					}
+				}
+				plan = storage.PlanDayAround(tasks, budget, storage.FreeTime(busy, from, to), now)
+				printCalendarPlan(plan, busy, to)
+			}
+
+			if why && len(plan.Tasks) > 0 {
+				explainPlan(plan)
+			}
+			return false
+		},
+	})
+}
+
+// parseBudget reads a time budget as plain hours ("4", "2.5") or a Go
+// duration ("90m", "1h30m") and returns it in minutes
+func parseBudget(s string) (int, error) {
+	if hours, err := strconv.ParseFloat(s, 64); err == nil {
+		if hours <= 0 {
+			return 0, fmt.Errorf("time budget must be positive: %s", s)
+		}
+		return int(hours * 60), nil
+	}
+
+	d, err := time.ParseDuration(s)
+	if err != nil {
+		return 0, fmt.Errorf("invalid time budget: %s (use hours like 4 or 2.5, or 90m)", s)
+	}
+	if d <= 0 {
+		return 0, fmt.Errorf("time budget must be positive: %s", s)
+	}
+	return int(d.Minutes()), nil
+}
+
+func printPlan(plan *storage.DayPlan) {
+	format, _ := planFormatter()
+
+	if len(plan.Tasks) == 0 {
+		fmt.Printf("Nothing fits in %s.\n", storage.FormatMinutes(plan.Budget))
+	} else {
+		what := fmt.Sprintf("these %d tasks", len(plan.Tasks))
+		if len(plan.Tasks) == 1 {
+			what = "this task"
+		}
+		fmt.Printf("You have %s: do %s (%s)\n", storage.FormatMinutes(plan.Budget), what, storage.FormatMinutes(plan.Used))
+		for i, t := range plan.Tasks {
+			fmt.Printf("  %d. %s\n", i+1, format(t))
+		}
+		if free := plan.Budget - plan.Used; free > 0 {
+			fmt.Printf("\n%s unplanned\n", storage.FormatMinutes(free))
+		}
+	}
+
+	printPlanLeftovers(plan, format)
+}
+
+// printCalendarPlan prints a plan made around meetings, with the meetings
+// and tasks in time order
+func printCalendarPlan(plan *storage.DayPlan, busy []storage.Busy, dayEnd time.Time) {
+	format, projectNames := planFormatter()
+
+	free := fmt.Sprintf("%s free until %s", storage.FormatMinutes(plan.Budget), dayEnd.Format("15:04"))
+	if len(busy) > 0 {
+		free += fmt.Sprintf(" around %d meetings", len(busy))
+	}
+	if len(plan.Tasks) == 0 {
+		fmt.Printf("Nothing fits: you have %s.\n", free)
+	} else {
+		fmt.Printf("You have %s: do %d tasks (%s)\n", free, len(plan.Tasks), storage.FormatMinutes(plan.Used))
+	}
+
+	i := 0
+	for _, slot := range plan.Slots {
+		for ; i < len(busy) && busy[i].Start.Before(slot.Start); i++ {
+			printBusy(busy[i])
+		}
+		fmt.Printf("  %s-%s  %s\n", slot.Start.Format("15:04"), slot.End.Format("15:04"), format(slot.Task))
+	}
+	for ; i < len(busy); i++ {
+		printBusy(busy[i])
+	}
+	if unplanned := plan.Budget - plan.Used; unplanned > 0 && len(plan.Tasks) > 0 {
+		fmt.Printf("\n%s unplanned\n", storage.FormatMinutes(unplanned))
+	}
+
+	if len(plan.Conflicts) > 0 {
+		fmt.Println("\nConflicts: due, but no free time between meetings holds them:")
+		for _, t := range plan.Conflicts {
+			fmt.Printf("  %s\n", colorOverdue(formatPlanTask(t, projectNames)))
+		}
+	}
+	printPlanLeftovers(plan, format)
+}
+
+func printBusy(b storage.Busy) {
+	summary := b.Summary
+	if summary == "" {
+		summary = "Busy"
+	}
+	fmt.Printf("  %s-%s  (%s)\n", b.Start.Format("15:04"), b.End.Format("15:04"), summary)
+}
+
+// planFormatter returns how plan output shows a task, with overdue tasks
+// in red, and the project names it uses
+func planFormatter() (func(*storage.Task) string, map[string]string) {
+	projectNames := map[string]string{"": storage.InboxName}
+	projects, _ := GetStore().ListProjects()
+	for _, p := range projects {
+		projectNames[p.ID] = p.Name
+	}
+
+	// Highlight overdue tasks in red
+	format := func(t *storage.Task) string {
+		if isOverdue(t) {
+			return colorOverdue(formatPlanTask(t, projectNames))
+		}
+		return formatPlanTask(t, projectNames)
+	}
+	return format, projectNames
+}
+
+// printPlanLeftovers prints the tasks a plan couldn't include
+func printPlanLeftovers(plan *storage.DayPlan, format func(*storage.Task) string) {
+	var deferred []*storage.Task
+	for _, t := range plan.Deferred {
+		if !slices.Contains(plan.Conflicts, t) {
+			deferred = append(deferred, t)
+		}
+	}
+	if len(deferred) > 0 {
+		fmt.Println("\nDoesn't fit today:")
+		for _, t := range deferred {
+			fmt.Printf("  %s\n", format(t))
+		}
+	}
+
+	if len(plan.Unestimated) > 0 {
+		fmt.Println("\nNo duration set (use /duration to include these):")
+		for _, t := range plan.Unestimated {
+			fmt.Printf("  %s\n", format(t))
+		}
+	}
+
+	if plan.Blocked > 0 {
+		fmt.Printf("\n%d blocked tasks left out\n", plan.Blocked)
+	}
+}
+
+func formatPlanTask(t *storage.Task, projectNames map[string]string) string {
+	var extras []string
+	if t.Duration != 0 {
+		extras = append(extras, t.Duration.String())
+	}
+	if t.Priority != "" {
+		extras = append(extras, string(t.Priority))
+	}
+	if t.Context != "" {
+		extras = append(extras, "@"+t.Context)
+	}
+	if t.DueDate != nil {
+		extras = append(extras, "due "+storage.FormatDue(*t.DueDate))
+	}
+	if name, ok := projectNames[t.ProjectID]; ok {
+		extras = append(extras, name)
+	}
+
+	line := fmt.Sprintf("[%s] %s", shortenID(t.ID), storage.DisplayName(t.Name))
+	if len(extras) > 0 {
+		line += " (" + strings.Join(extras, ", ") + ")"
+	}
+	return line
+}
+
+// explainPlan asks the LLM for a short rationale for the plan's order. The
+// plan itself is already decided; this only adds commentary.
+func explainPlan(plan *storage.DayPlan) {
+	client := GetLLMClient()
+	if client == nil {
+		fmt.Println("\nError: no LLM provider is set up (/providers shows how to add one)")
+		return
+	}
+	if err := checkBudget(); err != nil {
+		fmt.Printf("\nError: %v\n", err)
+		return
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "Today is %s. I have %s available and plan to work on these tasks in this order:\n",
+		time.Now().Format("Monday, 2006-01-02"), storage.FormatMinutes(plan.Budget))
+	for i, t := range plan.Tasks {
+		fmt.Fprintf(&b, "%d. %s\n", i+1, formatPlanTask(t, nil))
+	}
+	if len(plan.Deferred) > 0 {
+		b.WriteString("Deferred because they don't fit:\n")
+		for _, t := range plan.Deferred {
+			fmt.Fprintf(&b, "- %s\n", formatPlanTask(t, nil))
+		}
+	}
+	b.WriteString("In two or three sentences, explain why this order makes sense. Don't suggest a different plan.")
+
+	resp, err := client.Chat(commandContext(), b.String())
+	if err != nil {
+		fmt.Printf("\nError: %v\n", err)
+		return
+	}
+	fmt.Printf("\nWhy: %s\n", strings.TrimSpace(resp.Text))
+	recordMonthlyUsage(resp)
+}
@@ -0,0 +1,164 @@
+package commands
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"twooms/storage"
+)
+
+func init() {
+	Register(&Command{
+		Name:        "/planweek",
+		Shorthand:   "/pw",
+		Description: "Walk through overdue and undated tasks, assigning each to a day this week",
+		Hidden:      true,
+		Interactive: true,
+		Params: []Param{
+			{Name: "hours", Type: ParamTypeString, Description: "Time available per day (e.g., 6, 90m); defaults to 4h", Required: false},
+			{Name: "project_id", Type: ParamTypeString, Description: "Optional project ID to plan within", Required: false},
+		},
+		Handler: func(args []string) bool {
+			if lineReader == nil {
+				fmt.Println("Error: /planweek is interactive and only runs in the REPL")
+				return false
+			}
+
+			// The hours argument is optional, so a first argument that isn't a
+			// time budget is taken as the project
+			capacity := dailyCapacity()
+			if len(args) > 0 {
+				if minutes, err := parseBudget(args[0]); err == nil {
+					capacity = minutes
+					args = args[1:]
+				}
+			}
+
+			var tasks []*storage.Task
+			var err error
+			if len(args) > 0 {
+				projectID, err := GetStore().ResolveProjectID(args[0])
+				if err != nil {
+					fmt.Printf("Error: %v\n", err)
+					return false
+				}
+				tasks, err = GetStore().ListTasks(projectID)
+				if err != nil {
+					fmt.Printf("Error listing tasks: %v\n", err)
+					return false
+				}
+			} else {
+				tasks, err = GetStore().ListAllTasks()
+				if err != nil {
+					fmt.Printf("Error listing tasks: %v\n", err)
+					return false
+				}
+			}
+
+			// Existing due dates count against each day's capacity, even in other projects
+			all, err := GetStore().ListAllTasks()
+			if err != nil {
+				fmt.Printf("Error listing tasks: %v\n", err)
+				return false
+			}
+			week := storage.NewWeekPlan(all, capacity, time.Now())
+			week.Tasks = filterWeekTasks(week.Tasks, tasks)
+
+			runWeekWizard(week)
+			return false
+		},
+	})
+}
+
+// filterWeekTasks keeps the tasks to plan that are also in scope
+func filterWeekTasks(toPlan, scope []*storage.Task) []*storage.Task {
+	inScope := make(map[string]bool)
+	for _, t := range scope {
+		inScope[t.ID] = true
+	}
+	var kept []*storage.Task
+	for _, t := range toPlan {
+		if inScope[t.ID] {
+			kept = append(kept, t)
+		}
+	}
+	return kept
+}
+
+// runWeekWizard prompts for a day for each task, then saves every
+// assignment at once
+func runWeekWizard(week *storage.WeekPlan) {
+	if len(week.Tasks) == 0 {
+		fmt.Println("Nothing to plan: no overdue or undated open tasks.")
+		return
+	}
+
+	projectNames := make(map[string]string)
+	projects, _ := GetStore().ListProjects()
+	for _, p := range projects {
+		projectNames[p.ID] = p.Name
+	}
+
+	fmt.Printf("Planning %d tasks over %s to %s, %s per day.\n", len(week.Tasks),
+		week.Days[0].Format("Mon 01-02"), week.Days[len(week.Days)-1].Format("Mon 01-02"), storage.FormatMinutes(week.Capacity))
+	fmt.Println("Enter a day (mon-sun, today, tomorrow, or YYYY-MM-DD), Enter to skip, 'done' to save, or 'cancel'.")
+	printWeekLoad(week)
+
+	for i := 0; i < len(week.Tasks); i++ {
+		t := week.Tasks[i]
+		fmt.Printf("\n[%d/%d] %s\n", i+1, len(week.Tasks), formatPlanTask(t, projectNames))
+
+		line, err := lineReader("day> ")
+		if err != nil {
+			fmt.Println("Cancelled. No due dates changed.")
+			return
+		}
+
+		answer := strings.ToLower(strings.TrimSpace(line))
+		switch answer {
+		case "":
+			continue
+		case "done":
+			i = len(week.Tasks)
+			continue
+		case "cancel":
+			fmt.Println("Cancelled. No due dates changed.")
+			return
+		}
+
+		day, err := storage.ParseDueWord(answer, time.Now())
+		if err == nil {
+			err = week.Assign(t, day)
+		}
+		if err != nil {
+			fmt.Printf("  %v\n", err)
+			i-- // ask again for the same task
+			continue
+		}
+		printWeekLoad(week)
+	}
+
+	if len(week.Assigned) == 0 {
+		fmt.Println("\nNo due dates changed.")
+		return
+	}
+	if err := GetStore().SetTaskDueDates(week.Assigned); err != nil {
+		fmt.Printf("Error saving due dates: %v\n", err)
+		return
+	}
+	fmt.Printf("\nSet due dates for %d tasks. Use /undo to revert them all.\n", len(week.Assigned))
+}
+
+// printWeekLoad prints each day's load against capacity, marking full days
+func printWeekLoad(week *storage.WeekPlan) {
+	var days []string
+	for _, day := range week.Days {
+		entry := fmt.Sprintf("%s %s/%s", day.Format("Mon"), storage.FormatMinutes(week.Load(day)), storage.FormatMinutes(week.Capacity))
+		if week.Load(day) >= week.Capacity {
+			entry = colorOverdue(entry)
+		}
+		days = append(days, entry)
+	}
+	fmt.Println("  " + strings.Join(days, "  "))
+}
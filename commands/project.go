@@ -3,31 +3,41 @@ package commands
 import (
 	"fmt"
 	"strings"
+	"time"
+
+	"twooms/i18n"
+	"twooms/storage"
 )
 
+// atRiskHoursPerDay is the focused work time assumed available per day when
+// judging whether a project's open tasks fit before its due date
+const atRiskHoursPerDay = 4
+
 func init() {
 	Register(&Command{
 		Name:        "/project",
 		Shorthand:   "/p",
 		Description: "Create a new project",
+		Examples:    []string{"/project Website redesign"},
+		Access:      AccessCreate,
 		Params: []Param{
 			{Name: "name", Type: ParamTypeString, Description: "The name of the project to create", Required: true},
 		},
 		Handler: func(args []string) bool {
 			if len(args) == 0 {
-				fmt.Println("Usage: /project <name>")
+				fmt.Println(i18n.T("project.usage"))
 				return false
 			}
 
-			name := strings.Join(args, " ")
-			project, err := GetStore().CreateProject(name)
+			createProject(strings.Join(args, " "))
+			return false
+		},
+		ToolHandler: func(args map[string]any) (string, error) {
+			name, err := stringArg(args, "name")
 			if err != nil {
-				fmt.Printf("Error creating project: %v\n", err)
-				return false
+				return "", err
 			}
-
-			fmt.Printf("Created project: %s (shortcut: %s)\n", project.Name, project.Shortcut)
-			return false
+			return captureOutput(func() { createProject(name) }), nil
 		},
 	})
 
@@ -35,19 +45,20 @@ func init() {
 		Name:        "/projects",
 		Shorthand:   "/ps",
 		Description: "List all projects with their IDs. Use this to find a project's ID when you have the name.",
+		Access:      AccessRead,
 		Handler: func(args []string) bool {
 			projects, err := GetStore().ListProjects()
 			if err != nil {
-				fmt.Printf("Error listing projects: %v\n", err)
+				fmt.Println(i18n.T("projects.list_failed", err))
 				return false
 			}
 
 			if len(projects) == 0 {
-				fmt.Println("No projects yet. Create one with /project <name>")
+				fmt.Println(i18n.T("projects.none"))
 				return false
 			}
 
-			fmt.Println("Projects:")
+			fmt.Println(i18n.T("projects.header"))
 			for _, p := range projects {
 				// Count tasks for this project
 				tasks, _ := GetStore().ListTasks(p.ID)
@@ -58,8 +69,8 @@ func init() {
 					}
 				}
 
-				fmt.Printf("  [%s] %s (%d/%d tasks complete)\n",
-					p.Shortcut, p.Name, done, len(tasks))
+				fmt.Println(i18n.T("projects.item",
+					p.Shortcut, storage.DisplayName(p.Name), done, len(tasks), formatProjectDue(p, tasks)+formatProjectDefaults(p)))
 			}
 
 			return false
@@ -71,12 +82,14 @@ func init() {
 		Shorthand:   "/dp",
 		Description: "Delete a project and its tasks",
 		Destructive: true,
+		Interactive: true,
 		Params: []Param{
 			{Name: "project_id", Type: ParamTypeString, Description: "The ID or shortcut of the project to delete", Required: true},
 		},
 		Handler: func(args []string) bool {
+			args, yes := takeYes(args)
 			if len(args) == 0 {
-				fmt.Println("Usage: /delproject <project-id>")
+				fmt.Println(i18n.T("delproject.usage"))
 				return false
 			}
 
@@ -85,24 +98,279 @@ func init() {
 			// Resolve project ID
 			projectID, err := GetStore().ResolveProjectID(projectRef)
 			if err != nil {
-				fmt.Printf("Error: %v\n", err)
+				fmt.Println(i18n.T("error", err))
 				return false
 			}
 
 			// Get project for display
 			project, err := GetStore().GetProject(projectID)
 			if err != nil {
-				fmt.Printf("Error: %v\n", err)
+				fmt.Println(i18n.T("error", err))
+				return false
+			}
+
+			tasks, err := GetStore().ListTasks(projectID)
+			if err != nil {
+				fmt.Println(i18n.T("error", err))
+				return false
+			}
+			if !confirm("/delproject", i18n.T("delproject.confirm", project.Name, len(tasks)), yes) {
+				return false
+			}
+
+			if err := GetStore().TrashProject(projectID); err != nil {
+				fmt.Println(i18n.T("delproject.failed", err))
+				return false
+			}
+
+			fmt.Println(i18n.T("delproject.deleted", project.Name))
+			return false
+		},
+	})
+
+	Register(&Command{
+		Name:        "/projectset",
+		Description: "Set a project's defaults for new tasks in it: duration (e.g. 30m) or due (a shift from the day a task is created, e.g. +7d or next-monday); none clears one. Tasks that give their own keep it.",
+		Examples: []string{
+			"/projectset work",
+			"/projectset work duration 30m",
+			"/projectset work due +7d",
+			"/projectset work due none",
+		},
+		Params: []Param{
+			{Name: "project_id", Type: ParamTypeString, Description: "The ID or shortcut of the project", Required: true},
+			{Name: "key", Type: ParamTypeString, Description: "Which default to set", Required: false, Enum: projectDefaultKeys},
+			{Name: "value", Type: ParamTypeString, Description: "A duration like 30m, a due shift like +7d, or none to clear it", Required: false},
+		},
+		Handler: func(args []string) bool {
+			switch len(args) {
+			case 1:
+				printProjectDefaults(args[0])
+			case 3:
+				setProjectDefault(args[0], args[1], args[2])
+			default:
+				fmt.Println(i18n.T("projectset.usage"))
+			}
+			return false
+		},
+	})
+
+	Register(&Command{
+		Name:        "/projectdue",
+		Shorthand:   "/pdu",
+		Description: "Set a project's due date",
+		Params: []Param{
+			{Name: "project_id", Type: ParamTypeString, Description: "The ID or shortcut of the project", Required: true},
+			{Name: "date", Type: ParamTypeString, Description: "Due date in YYYY-MM-DD format, or 'none' to clear", Required: true, Format: ParamFormatDate},
+		},
+		Handler: func(args []string) bool {
+			if len(args) < 2 {
+				fmt.Println(i18n.T("projectdue.usage"))
+				return false
+			}
+
+			projectRef := args[0]
+			dateStr := args[1]
+
+			// Resolve project ID
+			projectID, err := GetStore().ResolveProjectID(projectRef)
+			if err != nil {
+				fmt.Println(i18n.T("error", err))
 				return false
 			}
 
-			if err := GetStore().DeleteProject(projectID); err != nil {
-				fmt.Printf("Error deleting project: %v\n", err)
+			// Get project for display
+			project, err := GetStore().GetProject(projectID)
+			if err != nil {
+				fmt.Println(i18n.T("error", err))
 				return false
 			}
 
-			fmt.Printf("Deleted project: %s\n", project.Name)
+			if dateStr == "none" {
+				if err := GetStore().SetProjectDueDate(projectID, nil); err != nil {
+					fmt.Println(i18n.T("error", err))
+					return false
+				}
+				fmt.Println(i18n.T("projectdue.cleared", project.Name))
+				return false
+			}
+
+			dueDate, err := time.Parse("2006-01-02", dateStr)
+			if err != nil {
+				fmt.Println(i18n.T("error.invalid_date"))
+				return false
+			}
+
+			if err := GetStore().SetProjectDueDate(projectID, &dueDate); err != nil {
+				fmt.Println(i18n.T("error", err))
+				return false
+			}
+
+			fmt.Println(i18n.T("projectdue.set", project.Name, dateStr))
 			return false
 		},
 	})
 }
+
+// projectDefaultKeys are the settings /projectset changes
+var projectDefaultKeys = []string{"duration", "due"}
+
+// setProjectDefault sets or, with "none", clears one of a project's
+// defaults for new tasks
+func setProjectDefault(projectRef, key, value string) {
+	projectID, err := GetStore().ResolveProjectID(projectRef)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		return
+	}
+	project, err := GetStore().GetProject(projectID)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		return
+	}
+
+	duration, due := project.DefaultDuration, project.DefaultDue
+	clear := strings.EqualFold(value, "none")
+	key = strings.ToLower(key)
+	switch key {
+	case "duration":
+		duration = 0
+		if !clear {
+			if duration, err = storage.ParseDuration(value); err != nil {
+				fmt.Printf("Error: %v\n", err)
+				return
+			}
+		}
+	case "due":
+		due = ""
+		if !clear {
+			// Checked now, so task creation can't fail on it later
+			if _, err := storage.DeferDue(nil, value, time.Now()); err != nil {
+				fmt.Printf("Error: %v\n", err)
+				return
+			}
+			due = strings.ToLower(strings.TrimSpace(value))
+		}
+	default:
+		fmt.Println(i18n.T("projectset.usage_field"))
+		return
+	}
+
+	if err := GetStore().SetProjectDefaults(projectID, duration, due); err != nil {
+		fmt.Printf("Error: %v\n", err)
+		return
+	}
+	if clear {
+		fmt.Printf("Cleared the default %s for new tasks in %s\n", key, project.Name)
+		return
+	}
+	if key == "duration" {
+		fmt.Printf("New tasks in %s now take %s unless they say otherwise\n", project.Name, duration)
+		return
+	}
+	example, _ := storage.DeferDue(nil, due, time.Now())
+	fmt.Printf("New tasks in %s are now due %s unless they say otherwise (one created now: %s)\n", project.Name, due, storage.FormatDue(example))
+}
+
+// printProjectDefaults shows a project's defaults for new tasks
+func printProjectDefaults(projectRef string) {
+	projectID, err := GetStore().ResolveProjectID(projectRef)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		return
+	}
+	project, err := GetStore().GetProject(projectID)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		return
+	}
+	duration, due := "none", "none"
+	if project.DefaultDuration != 0 {
+		duration = project.DefaultDuration.String()
+	}
+	if project.DefaultDue != "" {
+		due = project.DefaultDue
+	}
+	fmt.Printf("Defaults for new tasks in %s:\n  duration: %s\n  due: %s\n", project.Name, duration, due)
+}
+
+// applyProjectDefaults gives a task about to be created its project's
+// defaults, where it doesn't set its own; inbox tasks have none
+func applyProjectDefaults(t *storage.Task, now time.Time) error {
+	if t.ProjectID == "" {
+		return nil
+	}
+	project, err := GetStore().GetProject(t.ProjectID)
+	if err != nil {
+		return err
+	}
+	return project.ApplyDefaults(t, now)
+}
+
+// formatProjectDefaults lists a project's defaults for /projects, or ""
+// if it has none
+func formatProjectDefaults(p *storage.Project) string {
+	var defaults []string
+	if p.DefaultDuration != 0 {
+		defaults = append(defaults, p.DefaultDuration.String())
+	}
+	if p.DefaultDue != "" {
+		defaults = append(defaults, i18n.T("list.due", p.DefaultDue))
+	}
+	if len(defaults) == 0 {
+		return ""
+	}
+	return " - " + i18n.T("projects.defaults", strings.Join(defaults, ", "))
+}
+
+// formatProjectDue renders a project's due date as a countdown, flagging it
+// as at risk when the open estimated time exceeds the work time left
+// (atRiskHoursPerDay per remaining day, counting today). Returns "" if the
+// project has no due date.
+func formatProjectDue(p *storage.Project, tasks []*storage.Task) string {
+	if p.DueDate == nil {
+		return ""
+	}
+
+	var open []*storage.Task
+	for _, t := range tasks {
+		if !t.Done {
+			open = append(open, t)
+		}
+	}
+
+	today := dateOnly(time.Now())
+	daysLeft := int(dateOnly(*p.DueDate).Sub(today).Hours() / 24)
+	due := i18n.T("list.due", p.DueDate.Format("2006-01-02"))
+
+	var countdown string
+	switch {
+	case daysLeft < 0 && len(open) > 0:
+		return fmt.Sprintf(" - %s, %s", due, colorOverdue(i18n.T("projectdue.overdue", -daysLeft)))
+	case daysLeft < 0:
+		countdown = i18n.T("projectdue.days_ago", -daysLeft)
+	case daysLeft == 0:
+		countdown = i18n.T("projectdue.today")
+	case daysLeft == 1:
+		countdown = i18n.T("projectdue.one_left")
+	default:
+		countdown = i18n.T("projectdue.days_left", daysLeft)
+	}
+
+	openMinutes := storage.TotalDuration(open)
+	availableMinutes := (daysLeft + 1) * atRiskHoursPerDay * 60
+	if daysLeft >= 0 && openMinutes > availableMinutes {
+		return fmt.Sprintf(" - %s, %s (%s)", due, countdown, colorOverdue(i18n.T("projectdue.at_risk", storage.FormatMinutes(openMinutes))))
+	}
+	return fmt.Sprintf(" - %s, %s", due, countdown)
+}
+
+// createProject creates a project and prints its shortcut
+func createProject(name string) {
+	project, err := GetStore().CreateProject(name)
+	if err != nil {
+		fmt.Println(i18n.T("project.create_failed", err))
+		return
+	}
+	fmt.Println(i18n.T("project.created", project.Name, project.Shortcut))
+}
@@ -0,0 +1,164 @@
+package commands
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"twooms/storage"
+)
+
+// defaultPrompt shows the focused project, if any, before "> ". The task
+// in focus is added in brackets after it (see Prompt).
+const defaultPrompt = "{project}> "
+
+// defaultWorkspacePrompt is the default prompt outside the default workspace
+const defaultWorkspacePrompt = "[{workspace}] {project}> "
+
+// promptPlaceholderRegex matches {name} placeholders in a prompt template
+var promptPlaceholderRegex = regexp.MustCompile(`\{([a-z_]+)\}`)
+
+// promptPlaceholders are the values a prompt template can show. Each is
+// looked up fresh for every prompt, so the prompt tracks the store.
+var promptPlaceholders = []struct {
+	name        string
+	description string
+	value       func(today []*storage.Task) string
+}{
+	{"workspace", "active workspace, empty in the default one (see /workspace)", func([]*storage.Task) string { return ActiveWorkspace() }},
+	{"project", "focused project name (see /focus)", func([]*storage.Task) string { return FocusedProject() }},
+	{"task", "task with a running timer (see /start)", func([]*storage.Task) string { return timedTaskName() }},
+	{"focus", "task being worked on (see /focus)", func([]*storage.Task) string { return FocusedTask() }},
+	{"due_today", "open tasks due today, including overdue", func(today []*storage.Task) string { return strconv.Itoa(len(today)) }},
+	{"load", "estimated time of the tasks due today", func(today []*storage.Task) string {
+		return storage.FormatMinutes(storage.TotalDuration(today))
+	}},
+}
+
+func init() {
+	Register(&Command{
+		Name:        "/prompt",
+		Description: "Show or set the REPL prompt template (the choice is saved)",
+		Hidden:      true,
+		Params: []Param{
+			{Name: "template", Type: ParamTypeString, Description: "Prompt template with {placeholders}, or 'reset'", Required: false},
+		},
+		Handler: func(args []string) bool {
+			if len(args) == 0 {
+				template := GetConfig().Prompt
+				if template == "" {
+					template = defaultPrompt
+				}
+				fmt.Printf("Current prompt: %q\n", template)
+				fmt.Println("Placeholders:")
+				for _, p := range promptPlaceholders {
+					fmt.Printf("  {%s} - %s\n", p.name, p.description)
+				}
+				fmt.Println(`Use /prompt <template> to change it (e.g., /prompt {project} {due_today} due > ), or /prompt reset`)
+				return false
+			}
+
+			// Keep the template's spacing, including a trailing space before input
+			template := strings.Join(args, " ") + " "
+			if len(args) == 1 && args[0] == "reset" {
+				template = ""
+			}
+			for _, match := range promptPlaceholderRegex.FindAllStringSubmatch(template, -1) {
+				if !isPromptPlaceholder(match[1]) {
+					fmt.Printf("Error: unknown placeholder: %s (see /prompt)\n", match[0])
+					return false
+				}
+			}
+
+			GetConfig().Prompt = template
+			if err := GetConfig().Save(); err != nil {
+				fmt.Printf("Changed the prompt for this session, but could not save it: %v\n", err)
+				return false
+			}
+			if template == "" {
+				fmt.Println("Reset the prompt (saved)")
+			} else {
+				fmt.Printf("Set the prompt to %q (saved)\n", template)
+			}
+			return false
+		},
+	})
+}
+
+// Prompt renders the configured prompt template with live values
+func Prompt() string {
+	template := GetConfig().Prompt
+	focused := false
+	if template == "" {
+		template = defaultPrompt
+		if ActiveWorkspace() != "" {
+			template = defaultWorkspacePrompt
+		}
+		if FocusedTask() != "" {
+			template = strings.TrimSuffix(template, "> ") + " [{focus}]> "
+			focused = true
+		}
+	}
+
+	// Only look up today's tasks if the template shows them
+	var today []*storage.Task
+	if strings.Contains(template, "{due_today}") || strings.Contains(template, "{load}") {
+		today = tasksDueToday()
+	}
+
+	prompt := promptPlaceholderRegex.ReplaceAllStringFunc(template, func(match string) string {
+		name := match[1 : len(match)-1]
+		for _, p := range promptPlaceholders {
+			if p.name == name {
+				return p.value(today)
+			}
+		}
+		return match // unknown placeholders are shown as typed
+	})
+	// Empty values before the task would leave stray spaces
+	if focused {
+		prompt = strings.Join(strings.Fields(strings.TrimSuffix(prompt, "> ")), " ") + "> "
+	}
+	return prompt
+}
+
+func isPromptPlaceholder(name string) bool {
+	for _, p := range promptPlaceholders {
+		if p.name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// tasksDueToday returns open tasks due today or earlier
+func tasksDueToday() []*storage.Task {
+	tasks, err := GetStore().ListAllTasks()
+	if err != nil {
+		return nil
+	}
+	tomorrow := dateOnly(time.Now()).AddDate(0, 0, 1)
+
+	var due []*storage.Task
+	for _, t := range tasks {
+		if !t.Done && t.DueDate != nil && dateOnly(*t.DueDate).Before(tomorrow) {
+			due = append(due, t)
+		}
+	}
+	return due
+}
+
+// timedTaskName returns the name of the task with a running timer, or ""
+func timedTaskName() string {
+	entry, err := GetStore().ActiveTimer()
+	if err != nil || entry == nil {
+		return ""
+	}
+	task, err := GetStore().GetTask(entry.TaskID)
+	if err != nil {
+		return ""
+	}
+	return task.Name
+}
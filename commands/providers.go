@@ -0,0 +1,55 @@
+package commands
+
+import (
+	"fmt"
+	"strings"
+
+	"twooms/llm"
+)
+
+func init() {
+	Register(&Command{
+		Name:        "/providers",
+		Description: "Show which LLM providers chat uses, and why the others are inactive",
+		Access:      AccessRead,
+		Hidden:      true, // about the chat itself, not the user's tasks
+		Handler: func(args []string) bool {
+			fmt.Println(ChatMode())
+			for _, p := range llm.ProviderStatuses() {
+				state := "inactive"
+				if p.Active {
+					state = "active"
+				}
+				fmt.Printf("  %-10s %-8s  %s\n", p.Name, state, p.Reason)
+			}
+			if GetLLMClient() == nil {
+				fmt.Println("API keys can go in .env or ~/.twooms.env; restart twooms after setting one.")
+			}
+			return false
+		},
+	})
+}
+
+// ChatMode describes how /chat answers, for the startup banner and
+// /providers: through which provider and model, or offline
+func ChatMode() string {
+	client := GetLLMClient()
+	if client == nil {
+		return "Chat: offline assistant. No LLM provider is set up, so /chat understands simple requests only (/providers shows how to add one)."
+	}
+
+	mode := "Chat: online"
+	var fallbacks []string
+	if f, ok := client.(*llm.FallbackClient); ok {
+		names := f.Providers()
+		mode += " via " + names[0]
+		fallbacks = names[1:]
+	}
+	if s, ok := client.(llm.ModelSelector); ok && s.Model() != "" {
+		mode += " (" + s.Model() + ")"
+	}
+	if len(fallbacks) > 0 {
+		mode += ", falling back to " + strings.Join(fallbacks, ", ")
+	}
+	return mode
+}
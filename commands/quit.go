@@ -7,6 +7,7 @@ func init() {
 		Name:        "/quit",
 		Shorthand:   "/q",
 		Description: "Exit Twooms",
+		Access:      AccessRead,
 		Hidden:      true,
 		Handler: func(args []string) bool {
 			fmt.Println("Goodbye!")
@@ -18,6 +19,7 @@ func init() {
 	Register(&Command{
 		Name:        "/exit",
 		Description: "Exit Twooms",
+		Access:      AccessRead,
 		Hidden:      true,
 		Handler: func(args []string) bool {
 			fmt.Println("Goodbye!")
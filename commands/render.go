@@ -0,0 +1,95 @@
+package commands
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+
+	"twooms/i18n"
+)
+
+// defaultScreenWidth is the wrap width when the terminal's is unknown
+const defaultScreenWidth = 80
+
+// screenWidth reports the terminal width in columns, or a value below 1 when
+// it's unknown; main.go sets it from readline
+var screenWidth func() int
+
+// SetScreenWidth sets how rendered chat answers find the terminal width
+func SetScreenWidth(fn func() int) {
+	screenWidth = fn
+}
+
+// wrapWidth is the width chat answers wrap at: the terminal's, then
+// $COLUMNS, then 80
+func wrapWidth() int {
+	if screenWidth != nil {
+		if w := screenWidth(); w > 0 {
+			return w
+		}
+	}
+	if w, err := strconv.Atoi(os.Getenv("COLUMNS")); err == nil && w > 0 {
+		return w
+	}
+	return defaultScreenWidth
+}
+
+// renderPretty reports whether chat answers are rendered from markdown
+// (the default) rather than printed as written
+func renderPretty() bool {
+	return GetConfig() == nil || GetConfig().Render != "plain"
+}
+
+// formatAnswer is an LLM answer as /chat prints it
+func formatAnswer(text string) string {
+	if !renderPretty() {
+		return text
+	}
+	// Without colors the layout (bullets, wrapping, tables) still helps
+	if !colorsOn() {
+		return stripColors(renderMarkdown(text, wrapWidth()))
+	}
+	return renderMarkdown(text, wrapWidth())
+}
+
+func init() {
+	Register(&Command{
+		Name:        "/render",
+		Description: "Show or set how chat answers print: pretty renders their markdown, plain prints it as written (the choice is saved)",
+		Examples: []string{
+			"/render",
+			"/render plain",
+		},
+		Hidden: true,
+		Params: []Param{
+			{Name: "mode", Type: ParamTypeString, Description: "pretty or plain", Required: false},
+		},
+		Handler: func(args []string) bool {
+			if len(args) == 0 {
+				mode := "pretty"
+				if !renderPretty() {
+					mode = "plain"
+				}
+				fmt.Printf("Chat answers are rendered %s (wrapping at %d columns). Use /render pretty or /render plain to change.\n", mode, wrapWidth())
+				return false
+			}
+			mode := args[0]
+			if mode != "pretty" && mode != "plain" {
+				fmt.Println(i18n.T("render.usage"))
+				return false
+			}
+
+			// Pretty is the default, so it's saved as no setting
+			GetConfig().Render = mode
+			if mode == "pretty" {
+				GetConfig().Render = ""
+			}
+			if err := GetConfig().Save(); err != nil {
+				fmt.Printf("Chat answers are rendered %s for this session, but could not save it: %v\n", mode, err)
+				return false
+			}
+			fmt.Printf("Chat answers are rendered %s (saved)\n", mode)
+			return false
+		},
+	})
+}
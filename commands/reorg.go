@@ -0,0 +1,271 @@
+package commands
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"twooms/i18n"
+	"twooms/storage"
+)
+
+// reorgHelp describes the instructions /reorg accepts, typed or in a file
+const reorgHelp = `  move <project> <task-id|#tag>...   move tasks (or every task with a tag) to a project
+  merge <project> <into-project>      move all of a project's tasks into another and delete it
+  shortcut <project> <new-shortcut>   change a project's shortcut`
+
+func init() {
+	Register(&Command{
+		Name:        "/reorg",
+		Description: "Move tasks between projects, merge projects, and rename shortcuts in one undoable change, typed in or read from a file, with a preview",
+		Hidden:      true,
+		Interactive: true,
+		Params: []Param{
+			{Name: "file", Type: ParamTypeString, Description: "File of move/merge/shortcut lines (omit to type them)", Required: false},
+		},
+		Handler: func(args []string) bool {
+			yes := false
+			var files []string
+			for _, arg := range args {
+				if arg == "--yes" {
+					yes = true
+				} else {
+					files = append(files, arg)
+				}
+			}
+			if len(files) > 1 {
+				fmt.Println(i18n.T("reorg.usage"))
+				return false
+			}
+
+			reorg := storage.NewReorg()
+			if len(files) == 1 {
+				if err := readReorgFile(reorg, files[0]); err != nil {
+					fmt.Printf("Error: %v\n", err)
+					return false
+				}
+			} else if !readReorgLines(reorg) {
+				return false
+			}
+
+			if reorg.Empty() {
+				fmt.Println("Nothing to reorganize.")
+				return false
+			}
+			printReorg(reorg)
+
+			if !yes {
+				if lineReader == nil {
+					fmt.Println("Run again with --yes to apply.")
+					return false
+				}
+				answer, err := lineReader("Apply? [y/N] ")
+				if err != nil || !strings.EqualFold(strings.TrimSpace(answer), "y") {
+					fmt.Println("Cancelled. Nothing changed.")
+					return false
+				}
+			}
+
+			if err := GetStore().Reorganize(reorg); err != nil {
+				fmt.Printf("Error: %v. Nothing changed.\n", err)
+				return false
+			}
+			fmt.Println("Reorganized. Use /undo to revert all of it.")
+			return false
+		},
+	})
+}
+
+// readReorgLines collects instructions typed at the prompt until an empty
+// line or "done"; false means the user cancelled
+func readReorgLines(reorg *storage.Reorg) bool {
+	if lineReader == nil {
+		fmt.Println("Error: give a file of instructions outside the REPL")
+		return false
+	}
+	fmt.Println("Enter instructions, one per line:")
+	fmt.Println(reorgHelp)
+	fmt.Println("'preview' shows the changes so far, an empty line or 'done' previews and asks to apply, 'cancel' stops.")
+
+	for {
+		line, err := lineReader("reorg> ")
+		if err != nil {
+			fmt.Println("Cancelled. Nothing changed.")
+			return false
+		}
+		switch strings.ToLower(strings.TrimSpace(line)) {
+		case "", "done":
+			return true
+		case "cancel":
+			fmt.Println("Cancelled. Nothing changed.")
+			return false
+		case "preview":
+			printReorg(reorg)
+			continue
+		}
+		summary, err := parseReorgLine(reorg, line)
+		if err != nil {
+			fmt.Printf("  %v\n", err)
+			continue
+		}
+		fmt.Printf("  %s\n", summary)
+	}
+}
+
+// readReorgFile adds every instruction in a file; blank lines and lines
+// starting with "#" are skipped
+func readReorgFile(reorg *storage.Reorg, filename string) error {
+	f, err := os.Open(filename)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if _, err := parseReorgLine(reorg, line); err != nil {
+			return fmt.Errorf("%s line %d: %v", filename, n, err)
+		}
+	}
+	return scanner.Err()
+}
+
+// parseReorgLine adds one instruction to the reorg, returning a short
+// description of it
+func parseReorgLine(reorg *storage.Reorg, line string) (string, error) {
+	fields := strings.Fields(line)
+	if len(fields) < 3 {
+		return "", fmt.Errorf("expected an instruction:\n%s", reorgHelp)
+	}
+	store := GetStore()
+
+	switch strings.ToLower(fields[0]) {
+	case "move":
+		projectID, err := store.ResolveProjectID(fields[1])
+		if err != nil {
+			return "", err
+		}
+		moved := 0
+		for _, ref := range fields[2:] {
+			if tag, ok := strings.CutPrefix(ref, "#"); ok {
+				tasks, err := store.ListTasksByTag(tag)
+				if err != nil {
+					return "", err
+				}
+				if len(tasks) == 0 {
+					return "", fmt.Errorf("no tasks tagged #%s", tag)
+				}
+				for _, t := range tasks {
+					reorg.Moves[t.ID] = projectID
+				}
+				moved += len(tasks)
+				continue
+			}
+			taskID, err := store.ResolveTaskID(ref)
+			if err != nil {
+				return "", err
+			}
+			reorg.Moves[taskID] = projectID
+			moved++
+		}
+		return fmt.Sprintf("Move %d tasks to %s", moved, projectName(projectID)), nil
+
+	case "merge":
+		if len(fields) != 3 {
+			return "", fmt.Errorf("usage: merge <project> <into-project>")
+		}
+		from, err := store.ResolveProjectID(fields[1])
+		if err != nil {
+			return "", err
+		}
+		into, err := store.ResolveProjectID(fields[2])
+		if err != nil {
+			return "", err
+		}
+		if from == into {
+			return "", fmt.Errorf("can't merge a project into itself")
+		}
+		reorg.Merges[from] = into
+		return fmt.Sprintf("Merge %s into %s", projectName(from), projectName(into)), nil
+
+	case "shortcut":
+		if len(fields) != 3 {
+			return "", fmt.Errorf("usage: shortcut <project> <new-shortcut>")
+		}
+		projectID, err := store.ResolveProjectID(fields[1])
+		if err != nil {
+			return "", err
+		}
+		reorg.Shortcuts[projectID] = fields[2]
+		return fmt.Sprintf("Set shortcut of %s to %s", projectName(projectID), fields[2]), nil
+	}
+	return "", fmt.Errorf("unknown instruction %q (use move, merge, or shortcut)", fields[0])
+}
+
+// printReorg previews a reorg against the current store
+func printReorg(reorg *storage.Reorg) {
+	if reorg.Empty() {
+		fmt.Println("No changes yet.")
+		return
+	}
+	store := GetStore()
+	fmt.Println("Changes:")
+
+	var lines []string
+	for from, into := range reorg.Merges {
+		tasks, _ := store.ListTasks(from)
+		lines = append(lines, fmt.Sprintf("  Merge %s (%d tasks) into %s, deleting %s",
+			projectName(from), len(tasks), projectName(into), projectName(from)))
+	}
+	sort.Strings(lines)
+	for _, line := range lines {
+		fmt.Println(line)
+	}
+
+	lines = nil
+	for taskID, projectID := range reorg.Moves {
+		task, err := store.GetTask(taskID)
+		if err != nil {
+			continue
+		}
+		if task.ProjectID == projectID {
+			continue
+		}
+		lines = append(lines, fmt.Sprintf("  Move %q from %s to %s", task.Name, projectName(task.ProjectID), projectName(projectID)))
+	}
+	sort.Strings(lines)
+	for _, line := range lines {
+		fmt.Println(line)
+	}
+
+	lines = nil
+	for projectID, shortcut := range reorg.Shortcuts {
+		old := ""
+		if p, err := store.GetProject(projectID); err == nil {
+			old = p.Shortcut
+		}
+		lines = append(lines, fmt.Sprintf("  Shortcut of %s: %s -> %s", projectName(projectID), old, shortcut))
+	}
+	sort.Strings(lines)
+	for _, line := range lines {
+		fmt.Println(line)
+	}
+}
+
+// projectName returns a project's name, Inbox for no project, or the ID if
+// it can't be found
+func projectName(id string) string {
+	if id == "" {
+		return storage.InboxName
+	}
+	if p, err := GetStore().GetProject(id); err == nil {
+		return p.Name
+	}
+	return id
+}
@@ -0,0 +1,196 @@
+package commands
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"twooms/storage"
+)
+
+// defaultStaleDays is how long a task can go without edits before /review
+// calls it stale
+const defaultStaleDays = 14
+
+// reviewCounts tallies what was done with each reviewed task
+type reviewCounts struct {
+	done, deferred, deleted, skipped int
+}
+
+func init() {
+	Register(&Command{
+		Name:        "/review",
+		Shorthand:   "/rv",
+		Description: "Weekly review: walk each project's overdue, stale, and undated tasks and mark done, defer, delete, or skip each",
+		Hidden:      true,
+		Interactive: true,
+		Params: []Param{
+			{Name: "days", Type: ParamTypeInteger, Description: "Days without edits before a task counts as stale (default 14)", Required: false},
+			{Name: "project_id", Type: ParamTypeString, Description: "Optional project ID to review instead of all projects", Required: false},
+		},
+		Handler: func(args []string) bool {
+			if lineReader == nil {
+				fmt.Println("Error: /review is interactive and only runs in the REPL")
+				return false
+			}
+
+			// The days argument is optional, so a first argument that isn't a
+			// number is taken as the project
+			staleDays := defaultStaleDays
+			if len(args) > 0 {
+				if n, err := strconv.Atoi(args[0]); err == nil {
+					if n < 1 {
+						fmt.Println("Error: days must be at least 1")
+						return false
+					}
+					staleDays = n
+					args = args[1:]
+				}
+			}
+
+			var projects []*storage.Project
+			if len(args) > 0 {
+				projectID, err := GetStore().ResolveProjectID(args[0])
+				if err != nil {
+					fmt.Printf("Error: %v\n", err)
+					return false
+				}
+				project, err := GetStore().GetProject(projectID)
+				if err != nil {
+					fmt.Printf("Error: %v\n", err)
+					return false
+				}
+				projects = []*storage.Project{project}
+			} else {
+				var err error
+				projects, err = GetStore().ListProjects()
+				if err != nil {
+					fmt.Printf("Error listing projects: %v\n", err)
+					return false
+				}
+			}
+
+			runReview(projects, staleDays)
+			return false
+		},
+	})
+}
+
+// runReview walks each project's review groups, asking what to do with
+// every task. Changes are made as the answers come in, so quitting keeps
+// what was already decided.
+func runReview(projects []*storage.Project, staleDays int) {
+	now := time.Now()
+	counts := &reviewCounts{}
+	fmt.Printf("Reviewing overdue tasks, tasks with no edits in %d days, and tasks without due dates.\n", staleDays)
+	fmt.Println("For each: d(one), f (defer a week) or f <day>, x (delete), Enter to skip, q to stop.")
+
+	reviewed := 0
+	for _, project := range projects {
+		tasks, err := GetStore().ListTasks(project.ID)
+		if err != nil {
+			fmt.Printf("Error listing tasks: %v\n", err)
+			return
+		}
+		review := storage.ReviewTasks(tasks, staleDays, now)
+		if review.Len() == 0 {
+			continue
+		}
+		fmt.Printf("\n== %s: %d overdue, %d stale, %d without due dates ==\n",
+			project.Name, len(review.Overdue), len(review.Stale), len(review.Undated))
+
+		groups := []struct {
+			label string
+			tasks []*storage.Task
+		}{
+			{"Overdue", review.Overdue},
+			{"Stale", review.Stale},
+			{"No due date", review.Undated},
+		}
+		for _, group := range groups {
+			for _, t := range group.tasks {
+				fmt.Printf("[%s] %s\n", group.label, describeReviewTask(t, now))
+				if !reviewTask(t, counts, now) {
+					printReviewSummary(reviewed, counts)
+					return
+				}
+				reviewed++
+			}
+		}
+	}
+
+	if reviewed == 0 {
+		fmt.Println("\nNothing to review: no overdue, stale, or undated open tasks.")
+		return
+	}
+	printReviewSummary(reviewed, counts)
+}
+
+// reviewTask asks for and applies one action; false means stop the review
+func reviewTask(t *storage.Task, counts *reviewCounts, now time.Time) bool {
+	for {
+		line, err := lineReader("review> ")
+		if err != nil {
+			return false
+		}
+		action, arg, _ := strings.Cut(strings.TrimSpace(line), " ")
+
+		switch strings.ToLower(action) {
+		case "", "s", "skip":
+			counts.skipped++
+			return true
+		case "q", "quit":
+			return false
+		case "d", "done":
+			Execute("/done " + t.ID)
+			counts.done++
+			return true
+		case "x", "delete":
+			// "x" already answered the question /confirm would ask
+			Execute("/deltask " + t.ID + " " + yesFlag)
+			counts.deleted++
+			return true
+		case "f", "defer":
+			due := now.AddDate(0, 0, 7)
+			if arg = strings.TrimSpace(arg); arg != "" {
+				due, err = storage.ParseDueWord(arg, now)
+				if err != nil {
+					fmt.Printf("  %v\n", err)
+					continue
+				}
+			}
+			Execute("/due " + t.ID + " " + due.Format("2006-01-02"))
+			counts.deferred++
+			return true
+		default:
+			fmt.Println("  Use d (done), f [day] (defer), x (delete), Enter (skip), or q (stop)")
+		}
+	}
+}
+
+// describeReviewTask shows a task with why it's in the review
+func describeReviewTask(t *storage.Task, now time.Time) string {
+	var details []string
+	if t.DueDate != nil {
+		details = append(details, "due "+storage.FormatDue(*t.DueDate))
+	}
+	if t.Priority != "" {
+		details = append(details, string(t.Priority))
+	}
+	days := int(now.Sub(t.LastActivity()).Hours() / 24)
+	switch days {
+	case 0:
+		details = append(details, "edited today")
+	case 1:
+		details = append(details, "last edited 1 day ago")
+	default:
+		details = append(details, fmt.Sprintf("last edited %d days ago", days))
+	}
+	return fmt.Sprintf("%s (ID: %s; %s)", storage.DisplayName(t.Name), shortenID(t.ID), strings.Join(details, ", "))
+}
+
+func printReviewSummary(reviewed int, counts *reviewCounts) {
+	fmt.Printf("\nReviewed %d tasks: %d done, %d deferred, %d deleted, %d skipped.\n",
+		reviewed, counts.done, counts.deferred, counts.deleted, counts.skipped)
+}
@@ -0,0 +1,283 @@
+package commands
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"twooms/storage"
+)
+
+// Rule is a user-defined automation: when an event fires for a task that
+// matches the conditions, apply the actions. Rules live in a JSON file
+// (~/.twooms.rules.json) as a list of objects, e.g.
+//
+//	[{"name": "Billable", "on": "task_created", "project": "Client A",
+//	  "set_duration": "1h", "add_tags": ["billable"]}]
+type Rule struct {
+	Name string `json:"name"`
+	On   string `json:"on"` // task_created, task_done, or due_passed
+
+	// Conditions - empty means "any"
+	Project string `json:"project,omitempty"` // project name or shortcut
+	Tag     string `json:"tag,omitempty"`
+
+	// Actions
+	SetDuration string   `json:"set_duration,omitempty"`
+	SetPriority string   `json:"set_priority,omitempty"`
+	AddTags     []string `json:"add_tags,omitempty"`
+	Webhook     string   `json:"webhook,omitempty"` // URL that receives a JSON POST
+}
+
+var (
+	rules          []*Rule
+	rulesStatePath string
+	webhookClient  = &http.Client{Timeout: 10 * time.Second}
+)
+
+func init() {
+	Subscribe(applyRules)
+
+	Register(&Command{
+		Name:        "/rules",
+		Description: "List automation rules loaded from ~/.twooms.rules.json",
+		Hidden:      true,
+		Handler: func(args []string) bool {
+			if len(rules) == 0 {
+				fmt.Println("No rules loaded. Define them in ~/.twooms.rules.json")
+				return false
+			}
+
+			fmt.Println("Rules:")
+			for _, r := range rules {
+				fmt.Printf("  %s: on %s%s -> %s\n", r.Name, r.On, describeConditions(r), describeActions(r))
+			}
+			return false
+		},
+	})
+}
+
+// LoadRules reads rules from path; a missing file means no rules. Fired
+// due_passed rules are remembered in statePath so each fires once per due date.
+func LoadRules(path, statePath string) error {
+	rules = nil
+	rulesStatePath = statePath
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	var loaded []*Rule
+	if err := json.Unmarshal(data, &loaded); err != nil {
+		return fmt.Errorf("invalid rules file %s: %w", path, err)
+	}
+
+	for i, r := range loaded {
+		if r.Name == "" {
+			r.Name = fmt.Sprintf("rule %d", i+1)
+		}
+		switch r.On {
+		case EventTaskCreated, EventTaskDone, EventDuePassed:
+		default:
+			return fmt.Errorf("rule %q: unknown event %q (use task_created, task_done, or due_passed)", r.Name, r.On)
+		}
+		if r.SetDuration != "" && !storage.IsValidDuration(r.SetDuration) {
+			return fmt.Errorf("rule %q: invalid duration %q", r.Name, r.SetDuration)
+		}
+		if r.SetPriority != "" && !storage.IsValidPriority(r.SetPriority) {
+			return fmt.Errorf("rule %q: invalid priority %q", r.Name, r.SetPriority)
+		}
+		if r.Tag != "" {
+			r.Tag = storage.NormalizeTag(r.Tag)
+		}
+	}
+
+	rules = loaded
+	return nil
+}
+
+// CheckDueRules publishes due_passed for open overdue tasks that haven't
+// already fired for their current due date, when a rule or hook wants it
+func CheckDueRules() {
+	if !hasRulesFor(EventDuePassed) && !hasHooksFor(EventDuePassed) {
+		return
+	}
+
+	tasks, err := GetStore().ListAllTasks()
+	if err != nil {
+		return
+	}
+
+	fired := loadFiredRules()
+	for _, t := range tasks {
+		if !isOverdue(t) {
+			continue
+		}
+		key := t.ID + "@" + t.DueDate.Format("2006-01-02")
+		if fired[key] {
+			continue
+		}
+		Publish(EventDuePassed, t)
+		fired[key] = true
+	}
+	saveFiredRules(fired)
+}
+
+func hasRulesFor(event string) bool {
+	for _, r := range rules {
+		if r.On == event {
+			return true
+		}
+	}
+	return false
+}
+
+// applyRules runs every matching rule's actions for an event
+func applyRules(e Event) {
+	for _, r := range rules {
+		if r.On != e.Name || !ruleMatches(r, e.Task) {
+			continue
+		}
+		if err := runRule(r, e); err != nil {
+			fmt.Printf("Error: rule %q: %v\n", r.Name, err)
+		}
+	}
+}
+
+func ruleMatches(r *Rule, t *storage.Task) bool {
+	if r.Project != "" {
+		project, err := GetStore().GetProject(t.ProjectID)
+		if err != nil {
+			return false
+		}
+		if !strings.EqualFold(project.Name, r.Project) && !strings.EqualFold(project.Shortcut, r.Project) {
+			return false
+		}
+	}
+	if r.Tag != "" && !t.HasTag(r.Tag) {
+		return false
+	}
+	return true
+}
+
+// runRule applies a rule's changes to the event's task as one transaction,
+// so a rule is applied whole or not at all, then calls its webhook
+func runRule(r *Rule, e Event) error {
+	err := GetStore().Transact(fmt.Sprintf("rule %s on %q", r.Name, e.Task.Name), func(tx storage.Store) error {
+		if r.SetDuration != "" {
+			duration, err := storage.ParseDuration(r.SetDuration)
+			if err != nil {
+				return err
+			}
+			if err := tx.SetTaskDuration(e.Task.ID, duration); err != nil {
+				return err
+			}
+		}
+		if r.SetPriority != "" {
+			if err := tx.SetTaskPriority(e.Task.ID, storage.Priority(r.SetPriority)); err != nil {
+				return err
+			}
+		}
+		for _, tag := range r.AddTags {
+			if err := tx.AddTaskTag(e.Task.ID, tag); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	if r.Webhook != "" {
+		if err := postWebhook(r, e); err != nil {
+			return err
+		}
+	}
+
+	fmt.Printf("Rule %s applied to task %s\n", r.Name, e.Task.Name)
+	return nil
+}
+
+func postWebhook(r *Rule, e Event) error {
+	body, err := json.Marshal(map[string]any{
+		"rule":  r.Name,
+		"event": e.Name,
+		"task":  e.Task,
+	})
+	if err != nil {
+		return err
+	}
+
+	resp, err := webhookClient.Post(r.Webhook, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("webhook failed: %w", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook returned status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+func loadFiredRules() map[string]bool {
+	fired := make(map[string]bool)
+	if rulesStatePath == "" {
+		return fired
+	}
+	if data, err := os.ReadFile(rulesStatePath); err == nil {
+		json.Unmarshal(data, &fired)
+	}
+	return fired
+}
+
+func saveFiredRules(fired map[string]bool) {
+	if rulesStatePath == "" {
+		return
+	}
+	if data, err := json.Marshal(fired); err == nil {
+		os.WriteFile(rulesStatePath, data, 0644)
+	}
+}
+
+func describeConditions(r *Rule) string {
+	var conds []string
+	if r.Project != "" {
+		conds = append(conds, "project "+r.Project)
+	}
+	if r.Tag != "" {
+		conds = append(conds, "#"+r.Tag)
+	}
+	if len(conds) == 0 {
+		return ""
+	}
+	return " in " + strings.Join(conds, ", ")
+}
+
+func describeActions(r *Rule) string {
+	var actions []string
+	if r.SetDuration != "" {
+		actions = append(actions, "duration "+r.SetDuration)
+	}
+	if r.SetPriority != "" {
+		actions = append(actions, "priority "+r.SetPriority)
+	}
+	for _, tag := range r.AddTags {
+		actions = append(actions, "tag #"+storage.NormalizeTag(tag))
+	}
+	if r.Webhook != "" {
+		actions = append(actions, "notify "+r.Webhook)
+	}
+	if len(actions) == 0 {
+		return "nothing"
+	}
+	return strings.Join(actions, ", ")
+}
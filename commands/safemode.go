@@ -0,0 +1,52 @@
+package commands
+
+import (
+	"fmt"
+	"strings"
+
+	"twooms/i18n"
+)
+
+// safeMode limits /chat to read-only tools, for demos or letting the model
+// explore without risk; commands typed at the prompt are unaffected
+var safeMode bool
+
+func init() {
+	Register(&Command{
+		Name:        "/safemode",
+		Description: "Turn safe mode on or off: chat may only run read-only tools",
+		Access:      AccessRead,
+		Hidden:      true, // the model mustn't be able to turn it off
+		Handler: func(args []string) bool {
+			if len(args) == 0 {
+				state := "off"
+				if safeMode {
+					state = "on"
+				}
+				fmt.Println(i18n.T("safemode.current", state))
+				return false
+			}
+			switch strings.ToLower(args[0]) {
+			case "on":
+				safeMode = true
+				fmt.Println("Safe mode on: chat can look at your data but not change it.")
+			case "off":
+				safeMode = false
+				fmt.Println("Safe mode off: chat can change your data again.")
+			default:
+				fmt.Println(i18n.T("safemode.usage"))
+			}
+			return false
+		},
+	})
+}
+
+// safeModeRefusal is the tool result for a call safe mode blocks, or "" if
+// the tool may run
+func safeModeRefusal(name string) string {
+	cmd, ok := toolCommand(name)
+	if !safeMode || !ok || cmd.ReadOnly() {
+		return ""
+	}
+	return fmt.Sprintf("Error: safe mode is on, so %s was not run because it changes data. Only read-only tools work; the user can turn safe mode off with /safemode off.", name)
+}
@@ -2,26 +2,47 @@ package commands
 
 import (
 	"fmt"
+	"sort"
 	"strings"
 	"time"
 
 	"twooms/storage"
 )
 
-// ANSI color codes for terminal output
-const (
-	colorRed   = "\033[31m"
-	colorReset = "\033[0m"
-)
+// defaultDueSoonHours is how far ahead /today flags tasks with a due time,
+// when the config doesn't say
+const defaultDueSoonHours = 2
+
+// dueSoonWindow returns how far ahead /today flags tasks with a due time
+// (due_soon_hours in the config), or 0 if flagging is off
+func dueSoonWindow() time.Duration {
+	hours := GetConfig().DueSoonHours
+	switch {
+	case hours < 0:
+		return 0
+	case hours == 0:
+		hours = defaultDueSoonHours
+	}
+	return time.Duration(hours) * time.Hour
+}
+
+// dueWithin returns how long until a task with a due time is due, and
+// whether that's no more than window away (and not already past)
+func dueWithin(t *storage.Task, window time.Duration) (time.Duration, bool) {
+	if window <= 0 || t.DueDate == nil || !storage.HasDueTime(*t.DueDate) {
+		return 0, false
+	}
+	left := time.Until(storage.DueAt(*t.DueDate, time.Local))
+	return left, left > 0 && left <= window
+}
 
-// isOverdue returns true if the task has a due date before today and is not done
+// isOverdue returns true if the task is not done and its due date is before
+// today, or its due time has passed
 func isOverdue(t *storage.Task) bool {
 	if t.Done || t.DueDate == nil {
 		return false
 	}
-	today := dateOnly(time.Now())
-	due := dateOnly(*t.DueDate)
-	return due.Before(today)
+	return storage.DueAt(*t.DueDate, time.Local).Before(time.Now())
 }
 
 func init() {
@@ -29,6 +50,8 @@ func init() {
 		Name:        "/today",
 		Shorthand:   "/td",
 		Description: "List tasks due today (including overdue)",
+		Examples:    []string{"/today", "/today web"},
+		Access:      AccessRead,
 		Params: []Param{
 			{Name: "project_id", Type: ParamTypeString, Description: "Optional project ID to filter by", Required: false},
 		},
@@ -47,7 +70,7 @@ func init() {
 			today := dateOnly(time.Now())
 			tomorrow := today.AddDate(0, 0, 1)
 
-			listTasksInRange("today", today, tomorrow, projectID, true)
+			listTasksInRange("today", today, tomorrow, projectID, true, dueSoonWindow())
 			return false
 		},
 	})
@@ -56,6 +79,7 @@ func init() {
 		Name:        "/tomorrow",
 		Shorthand:   "/tm",
 		Description: "List tasks due tomorrow",
+		Access:      AccessRead,
 		Params: []Param{
 			{Name: "project_id", Type: ParamTypeString, Description: "Optional project ID to filter by", Required: false},
 		},
@@ -75,7 +99,7 @@ func init() {
 			tomorrow := today.AddDate(0, 0, 1)
 			dayAfter := today.AddDate(0, 0, 2)
 
-			listTasksInRange("tomorrow", tomorrow, dayAfter, projectID, false)
+			listTasksInRange("tomorrow", tomorrow, dayAfter, projectID, false, 0)
 			return false
 		},
 	})
@@ -84,6 +108,7 @@ func init() {
 		Name:        "/week",
 		Shorthand:   "/w",
 		Description: "List tasks due this week (Monday through Sunday)",
+		Access:      AccessRead,
 		Params: []Param{
 			{Name: "project_id", Type: ParamTypeString, Description: "Optional project ID to filter by", Required: false},
 		},
@@ -103,7 +128,7 @@ func init() {
 			weekStart := startOfWeek(today)
 			weekEnd := weekStart.AddDate(0, 0, 7)
 
-			listTasksInRange("this week", weekStart, weekEnd, projectID, false)
+			listTasksInRange("this week", weekStart, weekEnd, projectID, false, 0)
 			return false
 		},
 	})
@@ -124,9 +149,11 @@ func startOfWeek(t time.Time) time.Time {
 	return t.AddDate(0, 0, -(weekday - 1))
 }
 
-// listTasksInRange lists tasks with due dates in the given range [start, end)
-// If includeOverdue is true, also includes tasks with due dates before start
-func listTasksInRange(label string, start, end time.Time, projectID string, includeOverdue bool) {
+// listTasksInRange lists tasks with due dates in the given range [start, end),
+// earliest due first (tasks with a due time before those without on the same
+// day). If includeOverdue is true, also includes tasks with due dates before
+// start. Tasks due within soon of now are flagged.
+func listTasksInRange(label string, start, end time.Time, projectID string, includeOverdue bool, soon time.Duration) {
 	var tasks []*storage.Task
 	var err error
 
@@ -172,6 +199,9 @@ func listTasksInRange(label string, start, end time.Time, projectID string, incl
 
 	// Combine overdue tasks first, then regular tasks
 	allTasks := append(overdueTasks, filtered...)
+	sort.SliceStable(allTasks, func(i, j int) bool {
+		return storage.DueAt(*allTasks[i].DueDate, time.Local).Before(storage.DueAt(*allTasks[j].DueDate, time.Local))
+	})
 
 	if len(allTasks) == 0 {
 		fmt.Println("  No tasks due")
@@ -179,7 +209,7 @@ func listTasksInRange(label string, start, end time.Time, projectID string, incl
 	}
 
 	// Build project name lookup for display
-	projectNames := make(map[string]string)
+	projectNames := map[string]string{"": storage.InboxName}
 	if projectID == "" {
 		projects, _ := GetStore().ListProjects()
 		for _, p := range projects {
@@ -189,10 +219,10 @@ func listTasksInRange(label string, start, end time.Time, projectID string, incl
 
 	for _, t := range allTasks {
 		var extras []string
-		if t.Duration != "" {
-			extras = append(extras, string(t.Duration))
+		if t.Duration != 0 {
+			extras = append(extras, t.Duration.String())
 		}
-		extras = append(extras, "due "+t.DueDate.Format("2006-01-02"))
+		extras = append(extras, "due "+storage.FormatDue(*t.DueDate))
 		if projectID == "" {
 			if name, ok := projectNames[t.ProjectID]; ok {
 				extras = append(extras, name)
@@ -204,18 +234,12 @@ func listTasksInRange(label string, start, end time.Time, projectID string, incl
 			extraStr = " (" + strings.Join(extras, ", ") + ")"
 		}
 
-		// Show first 8 chars of task UUID (or full ID if shorter)
-		shortID := t.ID
-		if len(t.ID) > 8 {
-			shortID = t.ID[:8]
+		tagStr := formatTags(t) + blockedMark(t)
+		if left, ok := dueWithin(t, soon); ok {
+			tagStr += fmt.Sprintf(" [due in %s]", storage.FormatMinutes(int(left.Round(time.Minute).Minutes())))
 		}
 
-		// Highlight overdue tasks in red
-		if isOverdue(t) {
-			fmt.Printf("  %s[ ] [%s] %s%s%s\n", colorRed, shortID, t.Name, extraStr, colorReset)
-		} else {
-			fmt.Printf("  [ ] [%s] %s%s\n", shortID, t.Name, extraStr)
-		}
+		fmt.Println("  " + taskLine(t, "[ ]", storage.DisplayName(t.Name)+extraStr+tagStr))
 	}
 
 	// Show total duration
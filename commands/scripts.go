@@ -0,0 +1,386 @@
+package commands
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"go.starlark.net/starlark"
+	"go.starlark.net/starlarkstruct"
+	"go.starlark.net/syntax"
+
+	"twooms/storage"
+)
+
+// Scripts are Starlark files (*.star) loaded at startup from ~/.twooms.scripts.
+// A script registers commands and event handlers with the builtins
+//
+//	command(name, fn, description="")   # fn(args) runs as /name
+//	on(event, fn)                       # fn(event, task) on task_created, task_done, due_passed
+//
+// and reads or changes data through the `store` module, which deliberately
+// has no delete operations:
+//
+//	store.projects(), store.tasks(project=None), store.task(id)
+//	store.create_task(project, name), store.set_done(id, done=True)
+//	store.set_due(id, "YYYY-MM-DD" or None), store.set_duration(id, d)
+//	store.set_priority(id, p), store.add_tag(id, tag)
+
+// script is a loaded Starlark file and what it registered
+type script struct {
+	path     string
+	commands []string
+	events   []string
+}
+
+var scripts []*script
+
+func init() {
+	Register(&Command{
+		Name:        "/scripts",
+		Description: "List loaded scripts and the commands and events they handle",
+		Access:      AccessRead,
+		Hidden:      true,
+		Handler: func(args []string) bool {
+			if len(scripts) == 0 {
+				fmt.Println("No scripts loaded. Add *.star files to ~/.twooms.scripts")
+				return false
+			}
+
+			fmt.Println("Scripts:")
+			for _, s := range scripts {
+				var parts []string
+				if len(s.commands) > 0 {
+					parts = append(parts, "commands "+strings.Join(s.commands, ", "))
+				}
+				if len(s.events) > 0 {
+					parts = append(parts, "on "+strings.Join(s.events, ", "))
+				}
+				if len(parts) == 0 {
+					parts = append(parts, "nothing registered")
+				}
+				fmt.Printf("  %s: %s\n", filepath.Base(s.path), strings.Join(parts, "; "))
+			}
+			return false
+		},
+	})
+}
+
+// LoadScripts runs every *.star file in dir; a missing directory means no
+// scripts. A script that fails to load is reported and skipped.
+func LoadScripts(dir string) []error {
+	paths, _ := filepath.Glob(filepath.Join(dir, "*.star"))
+	sort.Strings(paths)
+
+	var errs []error
+	for _, path := range paths {
+		if err := loadScript(path); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errs
+}
+
+func loadScript(path string) error {
+	s := &script{path: path}
+	name := filepath.Base(path)
+
+	predeclared := starlark.StringDict{
+		"store": storeModule,
+		"command": starlark.NewBuiltin("command", func(thread *starlark.Thread, b *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+			var cmdName, description string
+			var fn starlark.Callable
+			if err := starlark.UnpackArgs(b.Name(), args, kwargs, "name", &cmdName, "fn", &fn, "description?", &description); err != nil {
+				return nil, err
+			}
+			cmdName = "/" + strings.TrimPrefix(cmdName, "/")
+			if _, exists := registry[strings.ToLower(cmdName)]; exists {
+				return nil, fmt.Errorf("command %s already exists", cmdName)
+			}
+			if description == "" {
+				description = "Script command from " + name
+			}
+
+			Register(&Command{
+				Name:        cmdName,
+				Description: description,
+				Hidden:      true,
+				Handler: func(cmdArgs []string) bool {
+					list := make([]starlark.Value, len(cmdArgs))
+					for i, a := range cmdArgs {
+						list[i] = starlark.String(a)
+					}
+					if _, err := callScript(name, fn, starlark.NewList(list)); err != nil {
+						fmt.Printf("Error: %v\n", err)
+					}
+					return false
+				},
+			})
+			s.commands = append(s.commands, cmdName)
+			return starlark.None, nil
+		}),
+		"on": starlark.NewBuiltin("on", func(thread *starlark.Thread, b *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+			var event string
+			var fn starlark.Callable
+			if err := starlark.UnpackArgs(b.Name(), args, kwargs, "event", &event, "fn", &fn); err != nil {
+				return nil, err
+			}
+			switch event {
+			case EventTaskCreated, EventTaskDone, EventDuePassed:
+			default:
+				return nil, fmt.Errorf("unknown event %q (use task_created, task_done, or due_passed)", event)
+			}
+
+			Subscribe(func(e Event) {
+				if e.Name != event {
+					return
+				}
+				if _, err := callScript(name, fn, starlark.String(e.Name), taskValue(e.Task)); err != nil {
+					fmt.Printf("Error: %v\n", err)
+				}
+			})
+			s.events = append(s.events, event)
+			return starlark.None, nil
+		}),
+	}
+
+	thread := newScriptThread(name)
+	if _, err := starlark.ExecFileOptions(&syntax.FileOptions{}, thread, path, nil, predeclared); err != nil {
+		return fmt.Errorf("script %s: %w", name, err)
+	}
+
+	scripts = append(scripts, s)
+	return nil
+}
+
+// callScript runs a script function with its output going to stdout
+func callScript(name string, fn starlark.Callable, args ...starlark.Value) (starlark.Value, error) {
+	v, err := starlark.Call(newScriptThread(name), fn, args, nil)
+	if err != nil {
+		if evalErr, ok := err.(*starlark.EvalError); ok {
+			return nil, fmt.Errorf("script %s: %s", name, evalErr.Backtrace())
+		}
+		return nil, fmt.Errorf("script %s: %w", name, err)
+	}
+	return v, nil
+}
+
+func newScriptThread(name string) *starlark.Thread {
+	return &starlark.Thread{
+		Name:  name,
+		Print: func(_ *starlark.Thread, msg string) { fmt.Println(msg) },
+	}
+}
+
+// storeModule exposes a safe subset of the store to scripts
+var storeModule = &starlarkstruct.Module{
+	Name: "store",
+	Members: starlark.StringDict{
+		"projects": starlark.NewBuiltin("store.projects", func(thread *starlark.Thread, b *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+			if err := starlark.UnpackArgs(b.Name(), args, kwargs); err != nil {
+				return nil, err
+			}
+			projects, err := GetStore().ListProjects()
+			if err != nil {
+				return nil, err
+			}
+			list := make([]starlark.Value, len(projects))
+			for i, p := range projects {
+				list[i] = projectValue(p)
+			}
+			return starlark.NewList(list), nil
+		}),
+		"tasks": starlark.NewBuiltin("store.tasks", func(thread *starlark.Thread, b *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+			var projectRef string
+			if err := starlark.UnpackArgs(b.Name(), args, kwargs, "project?", &projectRef); err != nil {
+				return nil, err
+			}
+
+			var tasks []*storage.Task
+			var err error
+			if projectRef == "" {
+				tasks, err = GetStore().ListAllTasks()
+			} else {
+				var projectID string
+				projectID, err = GetStore().ResolveProjectID(projectRef)
+				if err != nil {
+					return nil, err
+				}
+				tasks, err = GetStore().ListTasks(projectID)
+			}
+			if err != nil {
+				return nil, err
+			}
+
+			list := make([]starlark.Value, len(tasks))
+			for i, t := range tasks {
+				list[i] = taskValue(t)
+			}
+			return starlark.NewList(list), nil
+		}),
+		"task": starlark.NewBuiltin("store.task", func(thread *starlark.Thread, b *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+			task, err := unpackTask(b, args, kwargs)
+			if err != nil {
+				return nil, err
+			}
+			return taskValue(task), nil
+		}),
+		"create_task": starlark.NewBuiltin("store.create_task", func(thread *starlark.Thread, b *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+			var projectRef, name string
+			if err := starlark.UnpackArgs(b.Name(), args, kwargs, "project", &projectRef, "name", &name); err != nil {
+				return nil, err
+			}
+			projectID, err := GetStore().ResolveProjectID(projectRef)
+			if err != nil {
+				return nil, err
+			}
+			task := &storage.Task{ProjectID: projectID, Name: name}
+			if err := applyProjectDefaults(task, time.Now()); err != nil {
+				return nil, err
+			}
+			task, err = GetStore().CreateTaskFrom(task)
+			if err != nil {
+				return nil, err
+			}
+			Publish(EventTaskCreated, task)
+			return taskValue(task), nil
+		}),
+		"set_done": starlark.NewBuiltin("store.set_done", func(thread *starlark.Thread, b *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+			var id string
+			done := true
+			if err := starlark.UnpackArgs(b.Name(), args, kwargs, "id", &id, "done?", &done); err != nil {
+				return nil, err
+			}
+			taskID, err := GetStore().ResolveTaskID(id)
+			if err != nil {
+				return nil, err
+			}
+			if err := GetStore().UpdateTask(taskID, done); err != nil {
+				return nil, err
+			}
+			if done {
+				if task, err := GetStore().GetTask(taskID); err == nil {
+					Publish(EventTaskDone, task)
+				}
+			}
+			return starlark.None, nil
+		}),
+		"set_due": starlark.NewBuiltin("store.set_due", func(thread *starlark.Thread, b *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+			var id string
+			var date starlark.Value = starlark.None
+			if err := starlark.UnpackArgs(b.Name(), args, kwargs, "id", &id, "date", &date); err != nil {
+				return nil, err
+			}
+			taskID, err := GetStore().ResolveTaskID(id)
+			if err != nil {
+				return nil, err
+			}
+
+			var dueDate *time.Time
+			if date != starlark.None {
+				s, ok := starlark.AsString(date)
+				if !ok {
+					return nil, fmt.Errorf("%s: date must be a YYYY-MM-DD string or None", b.Name())
+				}
+				parsed, err := time.Parse("2006-01-02", s)
+				if err != nil {
+					return nil, fmt.Errorf("%s: invalid date %q, use YYYY-MM-DD", b.Name(), s)
+				}
+				dueDate = &parsed
+			}
+			return starlark.None, GetStore().SetTaskDueDate(taskID, dueDate)
+		}),
+		"set_duration": starlark.NewBuiltin("store.set_duration", func(thread *starlark.Thread, b *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+			var id, duration string
+			if err := starlark.UnpackArgs(b.Name(), args, kwargs, "id", &id, "duration", &duration); err != nil {
+				return nil, err
+			}
+			parsed, err := storage.ParseDuration(duration)
+			if err != nil {
+				return nil, fmt.Errorf("%s: invalid duration %q", b.Name(), duration)
+			}
+			taskID, err := GetStore().ResolveTaskID(id)
+			if err != nil {
+				return nil, err
+			}
+			return starlark.None, GetStore().SetTaskDuration(taskID, parsed)
+		}),
+		"set_priority": starlark.NewBuiltin("store.set_priority", func(thread *starlark.Thread, b *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+			var id, priority string
+			if err := starlark.UnpackArgs(b.Name(), args, kwargs, "id", &id, "priority", &priority); err != nil {
+				return nil, err
+			}
+			if priority != "" && !storage.IsValidPriority(priority) {
+				return nil, fmt.Errorf("%s: invalid priority %q", b.Name(), priority)
+			}
+			taskID, err := GetStore().ResolveTaskID(id)
+			if err != nil {
+				return nil, err
+			}
+			return starlark.None, GetStore().SetTaskPriority(taskID, storage.Priority(priority))
+		}),
+		"add_tag": starlark.NewBuiltin("store.add_tag", func(thread *starlark.Thread, b *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+			var id, tag string
+			if err := starlark.UnpackArgs(b.Name(), args, kwargs, "id", &id, "tag", &tag); err != nil {
+				return nil, err
+			}
+			taskID, err := GetStore().ResolveTaskID(id)
+			if err != nil {
+				return nil, err
+			}
+			return starlark.None, GetStore().AddTaskTag(taskID, storage.NormalizeTag(tag))
+		}),
+	},
+}
+
+func unpackTask(b *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (*storage.Task, error) {
+	var id string
+	if err := starlark.UnpackArgs(b.Name(), args, kwargs, "id", &id); err != nil {
+		return nil, err
+	}
+	taskID, err := GetStore().ResolveTaskID(id)
+	if err != nil {
+		return nil, err
+	}
+	return GetStore().GetTask(taskID)
+}
+
+// projectValue converts a project to a Starlark dict
+func projectValue(p *storage.Project) starlark.Value {
+	d := starlark.NewDict(5)
+	d.SetKey(starlark.String("id"), starlark.String(p.ID))
+	d.SetKey(starlark.String("name"), starlark.String(p.Name))
+	d.SetKey(starlark.String("shortcut"), starlark.String(p.Shortcut))
+	d.SetKey(starlark.String("due"), dateValue(p.DueDate))
+	return d
+}
+
+// taskValue converts a task to a Starlark dict; durations are also given in minutes
+func taskValue(t *storage.Task) starlark.Value {
+	tags := make([]starlark.Value, len(t.Tags))
+	for i, tag := range t.Tags {
+		tags[i] = starlark.String(tag)
+	}
+
+	d := starlark.NewDict(11)
+	d.SetKey(starlark.String("id"), starlark.String(t.ID))
+	d.SetKey(starlark.String("project_id"), starlark.String(t.ProjectID))
+	d.SetKey(starlark.String("name"), starlark.String(t.Name))
+	d.SetKey(starlark.String("done"), starlark.Bool(t.Done))
+	d.SetKey(starlark.String("status"), starlark.String(t.CurrentStatus()))
+	d.SetKey(starlark.String("due"), dateValue(t.DueDate))
+	d.SetKey(starlark.String("duration"), starlark.String(t.Duration.String()))
+	d.SetKey(starlark.String("minutes"), starlark.MakeInt(t.Duration.ToMinutes()))
+	d.SetKey(starlark.String("priority"), starlark.String(t.Priority))
+	d.SetKey(starlark.String("tags"), starlark.NewList(tags))
+	return d
+}
+
+func dateValue(t *time.Time) starlark.Value {
+	if t == nil {
+		return starlark.None
+	}
+	return starlark.String(t.Format("2006-01-02"))
+}
@@ -0,0 +1,180 @@
+package commands
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"unicode"
+
+	"twooms/i18n"
+	"twooms/storage"
+)
+
+// maxSearchResults caps how many tasks /search prints
+const maxSearchResults = 20
+
+func init() {
+	Register(&Command{
+		Name:        "/search",
+		Shorthand:   "/s",
+		Description: "Search task and project names across all projects (fuzzy)",
+		Examples:    []string{"/search taxes"},
+		Access:      AccessRead,
+		Params: []Param{
+			{Name: "query", Type: ParamTypeString, Description: "Words or fragments to look for (e.g., taxes)", Required: true},
+		},
+		Handler: func(args []string) bool {
+			if len(args) == 0 {
+				fmt.Println(i18n.T("search.usage"))
+				return false
+			}
+
+			query := strings.Join(args, " ")
+
+			projects, err := GetStore().ListProjects()
+			if err != nil {
+				fmt.Printf("Error listing projects: %v\n", err)
+				return false
+			}
+			tasks, err := GetStore().ListAllTasks()
+			if err != nil {
+				fmt.Printf("Error listing tasks: %v\n", err)
+				return false
+			}
+
+			projectNames := map[string]string{"": storage.InboxName}
+			var projectHits []*storage.Project
+			projectScores := make(map[string]int)
+			for _, p := range projects {
+				projectNames[p.ID] = p.Name
+				if score := fuzzyScore(query, p.Name); score > 0 {
+					projectHits = append(projectHits, p)
+					projectScores[p.ID] = score
+				}
+			}
+			sort.SliceStable(projectHits, func(i, j int) bool {
+				return projectScores[projectHits[i].ID] > projectScores[projectHits[j].ID]
+			})
+
+			var taskHits []*storage.Task
+			taskScores := make(map[string]int)
+			for _, t := range tasks {
+				if score := fuzzyScore(query, t.Name); score > 0 {
+					taskHits = append(taskHits, t)
+					taskScores[t.ID] = score
+				}
+			}
+			// Best matches first; open tasks before done ones on ties
+			sort.SliceStable(taskHits, func(i, j int) bool {
+				a, b := taskHits[i], taskHits[j]
+				if taskScores[a.ID] != taskScores[b.ID] {
+					return taskScores[a.ID] > taskScores[b.ID]
+				}
+				return !a.Done && b.Done
+			})
+
+			if len(projectHits) == 0 && len(taskHits) == 0 {
+				fmt.Printf("No tasks or projects match %q\n", query)
+				return false
+			}
+
+			if len(projectHits) > 0 {
+				fmt.Println("Projects:")
+				for _, p := range projectHits {
+					fmt.Printf("  [%s] %s (ID: %s)\n", p.Shortcut, storage.DisplayName(p.Name), shortenID(p.ID))
+				}
+			}
+
+			if len(taskHits) > 0 {
+				if len(projectHits) > 0 {
+					fmt.Println()
+				}
+				fmt.Println("Tasks:")
+				for i, t := range taskHits {
+					if i == maxSearchResults {
+						fmt.Printf("  ... and %d more (refine your query)\n", len(taskHits)-maxSearchResults)
+						break
+					}
+
+					status := "[ ]"
+					if t.Done {
+						status = "[✓]"
+					}
+
+					var extras []string
+					if name, ok := projectNames[t.ProjectID]; ok {
+						extras = append(extras, name)
+					}
+					if t.DueDate != nil {
+						extras = append(extras, "due "+storage.FormatDue(*t.DueDate))
+					}
+
+					extraStr := ""
+					if len(extras) > 0 {
+						extraStr = " (" + strings.Join(extras, ", ") + ")"
+					}
+
+					fmt.Println("  " + taskLine(t, status, storage.DisplayName(t.Name)+extraStr+formatTags(t)))
+				}
+			}
+
+			return false
+		},
+	})
+}
+
+// fuzzyScore rates how well text matches query; 0 means no match.
+// Every query word must appear in text, either as a substring or, failing
+// that, as an in-order subsequence of letters (so "tx rtrn" finds "Tax return").
+// Whole-word and prefix matches score higher than scattered ones.
+func fuzzyScore(query, text string) int {
+	text = strings.ToLower(text)
+	words := strings.Fields(strings.ToLower(query))
+	if len(words) == 0 {
+		return 0
+	}
+
+	score := 0
+	for _, word := range words {
+		s := wordScore(word, text)
+		if s == 0 {
+			return 0
+		}
+		score += s
+	}
+	if strings.Contains(text, strings.Join(words, " ")) {
+		score += 10
+	}
+	return score
+}
+
+func wordScore(word, text string) int {
+	if i := strings.Index(text, word); i >= 0 {
+		atStart := i == 0 || !isWordChar(rune(text[i-1]))
+		end := i + len(word)
+		atEnd := end == len(text) || !isWordChar(rune(text[end]))
+		switch {
+		case atStart && atEnd:
+			return 8
+		case atStart:
+			return 6
+		default:
+			return 4
+		}
+	}
+
+	// Subsequence: letters in order, possibly with gaps
+	rest := text
+	for _, r := range word {
+		i := strings.IndexRune(rest, r)
+		if i < 0 {
+			return 0
+		}
+		rest = rest[i+len(string(r)):]
+	}
+	return 1
+}
+
+func isWordChar(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r)
+}
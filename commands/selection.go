@@ -0,0 +1,279 @@
+package commands
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"twooms/i18n"
+	"twooms/storage"
+)
+
+// Selection filters, accepted in place of task IDs by /done, /undone,
+// /deltask, and /due
+const (
+	selectDone    = "--done"
+	selectOpen    = "--open"
+	selectOverdue = "--overdue"
+	selectProject = "--project"
+	selectTag     = "--tag"
+)
+
+// selectionFilters lists the filters for error messages
+var selectionFilters = []string{selectDone, selectOpen, selectOverdue, selectProject + " <id>", selectTag + " <tag>"}
+
+// selectTasks reads a selection of tasks: task IDs (separated by spaces or
+// commas), or filters. With a filter, bare words name projects instead, so
+// "--done work" is the completed tasks in work. Filters combine with AND;
+// several projects or tags match any of them. batch reports whether the
+// command should treat the tasks as one batch, which is whenever it's not
+// a single task ID.
+func selectTasks(args []string) (tasks []*storage.Task, batch bool, err error) {
+	var words []string
+	for _, arg := range args {
+		words = append(words, strings.FieldsFunc(arg, func(r rune) bool { return r == ',' || r == ' ' })...)
+	}
+
+	var refs, projectRefs, tags []string
+	done, open, overdue, filtered := false, false, false, false
+	for i := 0; i < len(words); i++ {
+		switch word := strings.ToLower(words[i]); word {
+		case selectDone:
+			done, filtered = true, true
+		case selectOpen:
+			open, filtered = true, true
+		case selectOverdue:
+			overdue, filtered = true, true
+		case selectProject, selectTag:
+			if i+1 == len(words) {
+				return nil, false, fmt.Errorf("%s needs a value", word)
+			}
+			i++
+			if word == selectProject {
+				projectRefs = append(projectRefs, words[i])
+			} else {
+				tags = append(tags, storage.NormalizeTag(words[i]))
+			}
+			filtered = true
+		default:
+			if strings.HasPrefix(word, "--") {
+				return nil, false, fmt.Errorf("unknown filter %s (use %s)", words[i], strings.Join(selectionFilters, ", "))
+			}
+			refs = append(refs, words[i])
+		}
+	}
+
+	if !filtered {
+		seen := make(map[string]bool)
+		for _, ref := range refs {
+			taskID, err := GetStore().ResolveTaskID(ref)
+			if err != nil {
+				return nil, false, err
+			}
+			if seen[taskID] {
+				continue
+			}
+			seen[taskID] = true
+			task, err := GetStore().GetTask(taskID)
+			if err != nil {
+				return nil, false, err
+			}
+			tasks = append(tasks, task)
+		}
+		return tasks, len(refs) != 1, nil
+	}
+
+	if done && (open || overdue) {
+		return nil, false, fmt.Errorf("%s can't be combined with %s or %s", selectDone, selectOpen, selectOverdue)
+	}
+	projects := make(map[string]bool)
+	for _, ref := range append(projectRefs, refs...) {
+		projectID, err := GetStore().ResolveProjectID(ref)
+		if err != nil {
+			return nil, false, err
+		}
+		projects[projectID] = true
+	}
+
+	all, err := GetStore().ListAllTasks()
+	if err != nil {
+		return nil, false, err
+	}
+	for _, t := range all {
+		switch {
+		case done && !t.Done, open && t.Done, overdue && !isOverdue(t):
+			continue
+		case len(projects) > 0 && !projects[t.ProjectID]:
+			continue
+		case len(tags) > 0 && !hasAnyTag(t, tags):
+			continue
+		}
+		tasks = append(tasks, t)
+	}
+	return tasks, true, nil
+}
+
+// hasAnyTag reports whether a task has at least one of the tags
+func hasAnyTag(t *storage.Task, tags []string) bool {
+	for _, tag := range tags {
+		if t.HasTag(tag) {
+			return true
+		}
+	}
+	return false
+}
+
+// taskIDs returns the IDs of tasks, in order
+func taskIDs(tasks []*storage.Task) []string {
+	ids := make([]string, len(tasks))
+	for i, t := range tasks {
+		ids[i] = t.ID
+	}
+	return ids
+}
+
+// printSelection lists the tasks of a batch, one per line
+func printSelection(tasks []*storage.Task) {
+	for _, t := range tasks {
+		fmt.Printf("  [%s] %s (%s)\n", shortenID(t.ID), storage.DisplayName(t.Name), projectName(t.ProjectID))
+	}
+}
+
+// confirmSelection lists the tasks a batch is about to change and asks once
+// for all of them. Batches count as bulk changes, so /confirm always asks,
+// and so does /confirm destructive for a destructive command.
+func confirmSelection(name, question string, tasks []*storage.Task, yes bool) bool {
+	cmd, ok := Lookup(name)
+	if !ok {
+		return true
+	}
+	batch := *cmd
+	batch.Bulk = true
+	if yes || !needsConfirm(&batch) {
+		return true
+	}
+	printSelection(tasks)
+	return askYes(question)
+}
+
+// markTasks marks a batch of tasks done or not done as one change, leaving
+// out the ones already that way
+func markTasks(name string, tasks []*storage.Task, done, yes bool) {
+	var changing []*storage.Task
+	for _, t := range tasks {
+		if t.Done != done {
+			changing = append(changing, t)
+		}
+	}
+	if len(tasks) == 0 {
+		fmt.Println(i18n.T("select.none"))
+		return
+	}
+	if len(changing) == 0 {
+		fmt.Println(i18n.T("select.unchanged"))
+		return
+	}
+
+	key := "done"
+	if !done {
+		key = "undone"
+	}
+	if !confirmSelection(name, i18n.T(key+".batch_confirm", len(changing)), changing, yes) {
+		return
+	}
+	if err := GetStore().UpdateTasks(taskIDs(changing), done); err != nil {
+		fmt.Println(i18n.T("error", err))
+		return
+	}
+	fmt.Println(i18n.T(key+".batch", len(changing)))
+	printSelection(changing)
+	if done {
+		for _, t := range changing {
+			Publish(EventTaskDone, t)
+		}
+	}
+}
+
+// deleteTasks deletes a batch of tasks as one change
+func deleteTasks(tasks []*storage.Task, yes bool) {
+	if len(tasks) == 0 {
+		fmt.Println(i18n.T("select.none"))
+		return
+	}
+	if !confirmSelection("/deltask", i18n.T("deltask.batch_confirm", len(tasks)), tasks, yes) {
+		return
+	}
+	if err := GetStore().TrashTasks(taskIDs(tasks)); err != nil {
+		fmt.Println(i18n.T("error", err))
+		return
+	}
+	fmt.Println(i18n.T("deltask.batch", len(tasks)))
+	printSelection(tasks)
+}
+
+// setDueDates sets or, with "none", clears the due dates of a batch of
+// tasks as one change. Each new date is worked out from the task's own, so
+// a shift like +2d moves every task by two days.
+func setDueDates(tasks []*storage.Task, date, clock string, yes bool) {
+	if len(tasks) == 0 {
+		fmt.Println(i18n.T("select.none"))
+		return
+	}
+
+	now := time.Now()
+	dates := make(map[string]time.Time)
+	before := make(map[string]*time.Time)
+	for _, t := range tasks {
+		due, err := newDueDate(t, date, now)
+		if err == nil && clock != "" && !due.IsZero() {
+			due, err = storage.WithDueTime(due, clock)
+		}
+		if err != nil {
+			fmt.Println(i18n.T("error", err))
+			return
+		}
+		dates[t.ID] = due
+		if t.DueDate != nil {
+			old := *t.DueDate
+			before[t.ID] = &old
+		}
+	}
+	if !confirmSelection("/due", i18n.T("due.batch_confirm", len(tasks)), tasks, yes) {
+		return
+	}
+
+	if err := GetStore().SetTaskDueDates(dates); err != nil {
+		fmt.Println(i18n.T("error", err))
+		return
+	}
+	if strings.EqualFold(date, "none") {
+		fmt.Println(i18n.T("due.batch_cleared", len(tasks)))
+		printSelection(tasks)
+		return
+	}
+	fmt.Println(i18n.T("due.batch", len(tasks)))
+	for _, t := range tasks {
+		fmt.Printf("  [%s] %s: %s\n", shortenID(t.ID), storage.DisplayName(t.Name), dueChange(before[t.ID], dates[t.ID]))
+	}
+}
+
+// errInvalidDue is returned for a /due date that is none of the forms it takes
+var errInvalidDue = errors.New("invalid date: use YYYY-MM-DD, none, or a shift like +2d or next-monday")
+
+// newDueDate is the due date /due gives a task in a batch: none (the zero
+// time), a YYYY-MM-DD date, or a /defer shift from its current one. A single
+// task takes only a date; /defer shifts one.
+func newDueDate(t *storage.Task, date string, now time.Time) (time.Time, error) {
+	if strings.EqualFold(date, "none") {
+		return time.Time{}, nil
+	}
+	if due, err := time.Parse("2006-01-02", date); err == nil {
+		return due, nil
+	}
+	due, err := storage.DeferDue(t.DueDate, date, now)
+	if err != nil {
+		return time.Time{}, errInvalidDue
+	}
+	return due, nil
+}
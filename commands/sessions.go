@@ -0,0 +1,262 @@
+package commands
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"twooms/i18n"
+	"twooms/llm"
+)
+
+const (
+	// maxChatSessions caps how many conversations the chat file keeps
+	maxChatSessions = 20
+	// maxSessionMessages caps the messages saved per conversation; older
+	// turns are dropped first
+	maxSessionMessages = 200
+)
+
+// chatSession is one saved /chat conversation and what it has cost so far
+type chatSession struct {
+	Started      time.Time      `json:"started"`
+	Updated      time.Time      `json:"updated"`
+	ProjectID    string         `json:"project_id,omitempty"` // focused project, if any
+	Messages     []*llm.Message `json:"messages"`
+	Prompts      int            `json:"prompts"`
+	InputTokens  int64          `json:"input_tokens"`
+	OutputTokens int64          `json:"output_tokens"`
+	CachedTokens int64          `json:"cached_tokens,omitempty"` // input tokens read from the prompt cache
+	Cost         float64        `json:"cost"`
+}
+
+var (
+	chatSessionsPath string
+	chatSessions     []*chatSession
+
+	// currentSession is the saved conversation chatHistory belongs to, or nil
+	// until the first save. parkedSessions holds it for other focus scopes,
+	// alongside chatHistories.
+	currentSession *chatSession
+	parkedSessions = make(map[string]*chatSession)
+)
+
+func init() {
+	Register(&Command{
+		Name:        "/sessions",
+		Description: "List saved chat conversations, most recent first",
+		Access:      AccessRead,
+		Hidden:      true,
+		Handler: func(args []string) bool {
+			sessions := sortedSessions()
+			if len(sessions) == 0 {
+				fmt.Println("No saved conversations.")
+				return false
+			}
+
+			fmt.Println("Saved conversations:")
+			for i, s := range sessions {
+				marker := " "
+				if s == currentSession {
+					marker = "*"
+				}
+				scope := ""
+				if s.ProjectID != "" {
+					if project, err := GetStore().GetProject(s.ProjectID); err == nil {
+						scope = fmt.Sprintf(" [%s]", project.Shortcut)
+					}
+				}
+				fmt.Printf("%s %d. %s%s  %s\n", marker, i+1, s.Updated.Format("2006-01-02 15:04"), scope, sessionTitle(s))
+				cached := ""
+				if s.CachedTokens > 0 {
+					cached = fmt.Sprintf(" (%d cached)", s.CachedTokens)
+				}
+				fmt.Printf("     %d messages, %d prompts, %d tokens%s%s\n", len(s.Messages), s.Prompts, s.InputTokens+s.OutputTokens, cached, formatSessionCost(s.Cost))
+			}
+			fmt.Println("Use /resume <n> to continue one.")
+			return false
+		},
+	})
+
+	Register(&Command{
+		Name:        "/resume",
+		Description: "Continue a saved chat conversation (see /sessions)",
+		Hidden:      true,
+		Params: []Param{
+			{Name: "number", Type: ParamTypeInteger, Description: "The conversation's number in /sessions", Required: true},
+		},
+		Handler: func(args []string) bool {
+			if len(args) == 0 {
+				fmt.Println(i18n.T("resume.usage"))
+				return false
+			}
+
+			sessions := sortedSessions()
+			n, err := strconv.Atoi(args[0])
+			if err != nil || n < 1 || n > len(sessions) {
+				fmt.Printf("Error: no conversation %s (see /sessions)\n", args[0])
+				return false
+			}
+			session := sessions[n-1]
+
+			// A conversation about a deleted project continues unscoped
+			projectID := session.ProjectID
+			if projectID != "" {
+				if _, err := GetStore().GetProject(projectID); err != nil {
+					projectID = ""
+				}
+			}
+			setFocus(projectID) // also saves the conversation being left
+			session.ProjectID = projectID
+			currentSession = session
+			chatHistory = append([]*llm.Message(nil), session.Messages...)
+
+			fmt.Printf("Resumed conversation from %s (%d messages): %s\n", session.Started.Format("2006-01-02 15:04"), len(session.Messages), sessionTitle(session))
+			if name := FocusedProject(); name != "" {
+				fmt.Printf("Chat is focused on %s.\n", name)
+			}
+			return false
+		},
+	})
+}
+
+// LoadChatSessions reads saved conversations from path and continues the most
+// recent unscoped one, since chat starts unscoped. Later changes are saved
+// back to path; a missing file means nothing has been saved yet.
+func LoadChatSessions(path string) error {
+	chatSessionsPath = path
+	chatSessions = nil
+	currentSession = nil
+	parkedSessions = make(map[string]*chatSession)
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(data, &chatSessions); err != nil {
+		chatSessions = nil
+		return fmt.Errorf("invalid chat history file %s: %w", path, err)
+	}
+
+	for _, s := range sortedSessions() {
+		if s.ProjectID == "" {
+			currentSession = s
+			chatHistory = append([]*llm.Message(nil), s.Messages...)
+			break
+		}
+	}
+	return nil
+}
+
+// saveChatSession records chatHistory in the current conversation and writes
+// every conversation to the chat file. Errors are ignored: losing saved chat
+// history shouldn't interrupt the user.
+func saveChatSession() {
+	if chatSessionsPath == "" {
+		return
+	}
+
+	// The system prompt is rebuilt every turn, so it isn't saved
+	messages := chatHistory
+	if len(messages) > 0 && messages[0].Role == "system" {
+		messages = messages[1:]
+	}
+	// Commands alone don't make a conversation worth listing
+	if currentSession == nil && sessionTitle(&chatSession{Messages: messages}) == "" {
+		return
+	}
+
+	startChatSession()
+	currentSession.Messages = trimSessionMessages(messages)
+	currentSession.Updated = time.Now()
+
+	// Drop the least recently used conversations over the cap
+	sessions := sortedSessions()
+	if len(sessions) > maxChatSessions {
+		sessions = sessions[:maxChatSessions]
+	}
+	chatSessions = sessions
+
+	data, err := json.MarshalIndent(chatSessions, "", "  ")
+	if err != nil {
+		return
+	}
+	os.WriteFile(chatSessionsPath, data, 0600)
+}
+
+// trimSessionMessages keeps the last maxSessionMessages messages, starting at
+// a user message so no tool result is saved without the call that made it
+func trimSessionMessages(messages []*llm.Message) []*llm.Message {
+	if len(messages) <= maxSessionMessages {
+		return append([]*llm.Message(nil), messages...)
+	}
+	for i := len(messages) - maxSessionMessages; i < len(messages); i++ {
+		if messages[i].Role == "user" {
+			return append([]*llm.Message(nil), messages[i:]...)
+		}
+	}
+	return nil
+}
+
+// startChatSession begins a saved conversation for chatHistory if there isn't one
+func startChatSession() {
+	if currentSession != nil {
+		return
+	}
+	now := time.Now()
+	currentSession = &chatSession{Started: now, Updated: now, ProjectID: focusProjectID}
+	chatSessions = append(chatSessions, currentSession)
+}
+
+// recordSessionUsage adds a /chat turn's token usage to the current conversation
+func recordSessionUsage(response *llm.Response) {
+	startChatSession()
+	currentSession.Prompts++
+	currentSession.InputTokens += response.InputTokens
+	currentSession.OutputTokens += response.OutputTokens
+	currentSession.CachedTokens += response.CachedTokens
+	currentSession.Cost += response.Cost
+}
+
+// sortedSessions returns the saved conversations, most recently updated first
+func sortedSessions() []*chatSession {
+	sessions := append([]*chatSession(nil), chatSessions...)
+	sort.SliceStable(sessions, func(i, j int) bool {
+		return sessions[i].Updated.After(sessions[j].Updated)
+	})
+	return sessions
+}
+
+// sessionTitle is a conversation's first chat message, shortened for listing
+func sessionTitle(s *chatSession) string {
+	for _, msg := range s.Messages {
+		if msg.Role != "user" || strings.HasPrefix(msg.Content, commandContextPrefix) {
+			continue
+		}
+		title := strings.Join(strings.Fields(msg.Content), " ")
+		if runes := []rune(title); len(runes) > 50 {
+			title = string(runes[:47]) + "..."
+		}
+		return fmt.Sprintf("%q", title)
+	}
+	return ""
+}
+
+func formatSessionCost(cost float64) string {
+	switch {
+	case cost <= 0:
+		return ""
+	case cost < 0.01:
+		return fmt.Sprintf(", $%.6f", cost)
+	default:
+		return fmt.Sprintf(", $%.4f", cost)
+	}
+}
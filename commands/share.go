@@ -0,0 +1,157 @@
+package commands
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"twooms/i18n"
+	"twooms/server"
+)
+
+// defaultShareDays is how long a share link lasts when no lifetime is given
+const defaultShareDays = 7
+
+// serveAddr is the address serve mode is listening on, or "" if not running
+var serveAddr string
+
+func init() {
+	Register(&Command{
+		Name:        "/serve",
+		Description: "Start the web server for share links (default 127.0.0.1:8787)",
+		Hidden:      true,
+		Handler: func(args []string) bool {
+			if serveAddr != "" {
+				fmt.Printf("Already serving at %s\n", shareBaseURL())
+				return false
+			}
+
+			addr := "127.0.0.1:8787"
+			if len(args) > 0 {
+				addr = args[0]
+			}
+
+			listener, err := net.Listen("tcp", addr)
+			if err != nil {
+				fmt.Printf("Error: %v\n", err)
+				return false
+			}
+			serveAddr = listener.Addr().String()
+
+			go http.Serve(listener, server.NewHandler(GetStore()))
+
+			fmt.Printf("Serving share links at %s\n", shareBaseURL())
+			return false
+		},
+	})
+
+	Register(&Command{
+		Name:        "/share",
+		Description: "Create an expiring read-only link to a project, or list active links",
+		Hidden:      true,
+		Handler: func(args []string) bool {
+			if len(args) == 0 {
+				listShares()
+				return false
+			}
+
+			// Resolve project ID
+			projectID, err := GetStore().ResolveProjectID(args[0])
+			if err != nil {
+				fmt.Printf("Error: %v\n", err)
+				return false
+			}
+			project, err := GetStore().GetProject(projectID)
+			if err != nil {
+				fmt.Printf("Error: %v\n", err)
+				return false
+			}
+
+			days := defaultShareDays
+			if len(args) > 1 {
+				days, err = strconv.Atoi(args[1])
+				if err != nil || days <= 0 {
+					fmt.Println(i18n.T("share.usage"))
+					return false
+				}
+			}
+
+			share, err := GetStore().CreateShare(projectID, time.Duration(days)*24*time.Hour)
+			if err != nil {
+				fmt.Printf("Error: %v\n", err)
+				return false
+			}
+
+			fmt.Printf("Read-only link for %s (expires %s):\n", project.Name, share.ExpiresAt.Format("2006-01-02 15:04"))
+			fmt.Printf("  %s/share/%s\n", shareBaseURL(), share.Token)
+			if serveAddr == "" && os.Getenv("TWOOMS_SHARE_URL") == "" {
+				fmt.Println("Start the server with /serve so the link can be opened.")
+			}
+			return false
+		},
+	})
+
+	Register(&Command{
+		Name:        "/unshare",
+		Description: "Revoke a share link",
+		Hidden:      true,
+		Handler: func(args []string) bool {
+			if len(args) == 0 {
+				fmt.Println(i18n.T("unshare.usage"))
+				return false
+			}
+
+			if err := GetStore().DeleteShare(args[0]); err != nil {
+				fmt.Printf("Error: %v\n", err)
+				return false
+			}
+
+			fmt.Println("Share link revoked")
+			return false
+		},
+	})
+}
+
+func listShares() {
+	shares, err := GetStore().ListShares()
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		return
+	}
+
+	if len(shares) == 0 {
+		fmt.Println("No active share links. Create one with /share <project-id> [days]")
+		return
+	}
+
+	fmt.Println("Active share links:")
+	for _, sh := range shares {
+		name := shortenID(sh.ProjectID)
+		if p, err := GetStore().GetProject(sh.ProjectID); err == nil {
+			name = p.Name
+		}
+		fmt.Printf("  [%s] %s (expires %s)\n", sh.Token[:8], name, sh.ExpiresAt.Format("2006-01-02 15:04"))
+	}
+}
+
+// shareBaseURL is the public URL prefix for links: TWOOMS_SHARE_URL when set
+// (e.g., behind a reverse proxy), otherwise the local serve address
+func shareBaseURL() string {
+	if base := os.Getenv("TWOOMS_SHARE_URL"); base != "" {
+		return strings.TrimRight(base, "/")
+	}
+	addr := serveAddr
+	if addr == "" {
+		addr = "127.0.0.1:8787"
+	}
+	return "http://" + addr
+}
+
+// Serving reports whether serve mode has been started
+func Serving() bool {
+	return serveAddr != ""
+}
@@ -1,6 +1,10 @@
 package commands
 
-import "fmt"
+import (
+	"fmt"
+
+	"twooms/i18n"
+)
 
 func init() {
 	Register(&Command{
@@ -9,11 +13,11 @@ func init() {
 		Description: "Set a custom shortcut for a project",
 		Params: []Param{
 			{Name: "project_id", Type: ParamTypeString, Description: "The ID or current shortcut of the project", Required: true},
-			{Name: "new_shortcut", Type: ParamTypeString, Description: "The new shortcut (alphanumeric + hyphens, max 20 chars)", Required: true},
+			{Name: "new_shortcut", Type: ParamTypeString, Description: "The new shortcut (alphanumeric + hyphens, max 20 chars; not a reserved word like all, none, or today)", Required: true},
 		},
 		Handler: func(args []string) bool {
 			if len(args) < 2 {
-				fmt.Println("Usage: /shortcut <project-id> <new-shortcut>")
+				fmt.Println(i18n.T("shortcut.usage"))
 				return false
 			}
 
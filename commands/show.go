@@ -0,0 +1,250 @@
+package commands
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"twooms/i18n"
+	"twooms/storage"
+)
+
+// taskDetails is everything known about one task, as task_get returns it
+// to the model and /show prints it
+type taskDetails struct {
+	ID           string        `json:"id"`
+	Name         string        `json:"name"`
+	Project      string        `json:"project"`
+	ProjectID    string        `json:"project_id,omitempty"`
+	Shortcut     string        `json:"project_shortcut,omitempty"`
+	Status       string        `json:"status"`
+	Overdue      bool          `json:"overdue,omitempty"`
+	Priority     string        `json:"priority,omitempty"`
+	Due          string        `json:"due,omitempty"`
+	Duration     string        `json:"duration,omitempty"`
+	Context      string        `json:"context,omitempty"`
+	Tags         []string      `json:"tags,omitempty"`
+	Note         string        `json:"note,omitempty"`
+	BlockedBy    []taskSummary `json:"blocked_by,omitempty"`
+	Blocks       []taskSummary `json:"blocks,omitempty"`
+	LoggedTime   string        `json:"time_logged,omitempty"`
+	TimerRunning bool          `json:"timer_running,omitempty"`
+	Created      string        `json:"created"`
+	Updated      string        `json:"updated,omitempty"`
+	Completed    string        `json:"completed,omitempty"`
+	Archived     string        `json:"archived,omitempty"`
+	Postponed    int           `json:"times_postponed,omitempty"`
+}
+
+// taskSummary names a related task
+type taskSummary struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+	Done bool   `json:"done,omitempty"`
+}
+
+// detailTime is how task details show a timestamp
+const detailTime = "2006-01-02 15:04"
+
+func init() {
+	Register(&Command{
+		Name:        "/show",
+		Description: "Show everything about a task: project, status, dates, priority, tags, note, dependencies, and time logged",
+		Examples:    []string{"/show 1a2b3c4d"},
+		Access:      AccessRead,
+		Hidden:      true, // task_get is the tool version
+		Params: []Param{
+			{Name: "task_id", Type: ParamTypeString, Description: "The ID of the task", Required: true},
+		},
+		Handler: func(args []string) bool {
+			if len(args) != 1 {
+				fmt.Println(i18n.T("show.usage"))
+				return false
+			}
+			d, err := lookupTaskDetails(args[0])
+			if err != nil {
+				fmt.Printf("Error: %v\n", err)
+				return false
+			}
+			printTaskDetails(d)
+			return false
+		},
+	})
+
+	Register(&Command{
+		Name:        "/task_get",
+		Description: "Get one task's full details as JSON: project, status, due date, duration, priority, tags, note, dependencies, time logged, and when it was created, changed, and completed. Cheaper than listing tasks when you only need one.",
+		Access:      AccessRead,
+		Params: []Param{
+			{Name: "task_id", Type: ParamTypeString, Description: "The ID of the task", Required: true},
+		},
+		Handler: func(args []string) bool {
+			if len(args) != 1 {
+				fmt.Println(i18n.T("task_get.usage"))
+				return false
+			}
+			d, err := lookupTaskDetails(args[0])
+			if err != nil {
+				fmt.Printf("Error: %v\n", err)
+				return false
+			}
+			data, err := json.MarshalIndent(d, "", "  ")
+			if err != nil {
+				fmt.Printf("Error: %v\n", err)
+				return false
+			}
+			fmt.Println(string(data))
+			return false
+		},
+	})
+}
+
+// lookupTaskDetails resolves a task ID or prefix and gathers its details
+func lookupTaskDetails(ref string) (*taskDetails, error) {
+	id, err := GetStore().ResolveTaskID(ref)
+	if err != nil {
+		return nil, err
+	}
+	t, err := GetStore().GetTask(id)
+	if err != nil {
+		return nil, err
+	}
+	return buildTaskDetails(t, time.Now())
+}
+
+// buildTaskDetails collects a task's fields, the tasks it waits on and
+// holds up, and the time logged on it
+func buildTaskDetails(t *storage.Task, now time.Time) (*taskDetails, error) {
+	d := &taskDetails{
+		ID:        t.ID,
+		Name:      t.Name,
+		Project:   projectName(t.ProjectID),
+		ProjectID: t.ProjectID,
+		Status:    string(t.CurrentStatus()),
+		Overdue:   isOverdue(t),
+		Priority:  string(t.Priority),
+		Context:   t.Context,
+		Tags:      t.Tags,
+		Note:      t.Note,
+		Created:   t.CreatedAt.Local().Format(detailTime),
+		Postponed: t.Postponed,
+	}
+	if p, err := GetStore().GetProject(t.ProjectID); err == nil {
+		d.Shortcut = p.Shortcut
+	}
+	if t.DueDate != nil {
+		d.Due = storage.FormatDue(*t.DueDate)
+	}
+	if t.Duration != 0 {
+		d.Duration = t.Duration.String()
+	}
+	if t.UpdatedAt != nil {
+		d.Updated = t.UpdatedAt.Local().Format(detailTime)
+	}
+	if t.CompletedAt != nil {
+		d.Completed = t.CompletedAt.Local().Format(detailTime)
+	}
+	if t.ArchivedAt != nil {
+		d.Archived = t.ArchivedAt.Local().Format(detailTime)
+	}
+
+	for _, id := range t.BlockedBy {
+		if blocker, err := GetStore().GetTask(id); err == nil {
+			d.BlockedBy = append(d.BlockedBy, taskSummary{ID: shortenID(blocker.ID), Name: blocker.Name, Done: blocker.Done})
+		}
+	}
+	all, err := GetStore().ListAllTasks()
+	if err != nil {
+		return nil, err
+	}
+	for _, other := range all {
+		for _, id := range other.BlockedBy {
+			if id == t.ID {
+				d.Blocks = append(d.Blocks, taskSummary{ID: shortenID(other.ID), Name: other.Name, Done: other.Done})
+			}
+		}
+	}
+
+	entries, err := GetStore().ListTimeEntries()
+	if err != nil {
+		return nil, err
+	}
+	minutes := 0
+	for _, e := range entries {
+		if e.TaskID == t.ID {
+			minutes += e.Minutes(now)
+			d.TimerRunning = d.TimerRunning || e.Running()
+		}
+	}
+	if minutes > 0 || d.TimerRunning {
+		d.LoggedTime = storage.FormatMinutes(minutes)
+	}
+	return d, nil
+}
+
+// printTaskDetails prints a task's details for /show, skipping empty fields
+func printTaskDetails(d *taskDetails) {
+	fmt.Println(storage.DisplayName(d.Name))
+	field := func(label, value string) {
+		if value != "" {
+			fmt.Printf("  %-12s %s\n", label+":", value)
+		}
+	}
+
+	field("ID", colorID(d.ID))
+	project := d.Project
+	if d.Shortcut != "" {
+		project += " [" + d.Shortcut + "]"
+	}
+	field("Project", project)
+	status := d.Status
+	if d.Overdue {
+		status += ", " + colorOverdue("overdue")
+	}
+	field("Status", status)
+	field("Priority", d.Priority)
+	field("Due", d.Due)
+	field("Duration", d.Duration)
+	if d.Context != "" {
+		field("Context", "@"+d.Context)
+	}
+	if len(d.Tags) > 0 {
+		field("Tags", "#"+strings.Join(d.Tags, " #"))
+	}
+	field("Blocked by", formatSummaries(d.BlockedBy))
+	field("Blocks", formatSummaries(d.Blocks))
+	if d.LoggedTime != "" {
+		logged := d.LoggedTime
+		if d.TimerRunning {
+			logged += " (timer running)"
+		}
+		field("Time logged", logged)
+	}
+	field("Created", d.Created)
+	field("Updated", d.Updated)
+	field("Completed", d.Completed)
+	field("Archived", d.Archived)
+	if d.Postponed > 0 {
+		field("Postponed", fmt.Sprintf("%d times", d.Postponed))
+	}
+	if d.Note != "" {
+		fmt.Println("  Note:")
+		for _, line := range strings.Split(d.Note, "\n") {
+			fmt.Println("    " + line)
+		}
+	}
+}
+
+// formatSummaries lists related tasks as "[1a2b3c4d] Name (done)"
+func formatSummaries(tasks []taskSummary) string {
+	var parts []string
+	for _, t := range tasks {
+		part := fmt.Sprintf("[%s] %s", colorID(t.ID), storage.DisplayName(t.Name))
+		if t.Done {
+			part += " (done)"
+		}
+		parts = append(parts, part)
+	}
+	return strings.Join(parts, ", ")
+}
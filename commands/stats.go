@@ -0,0 +1,116 @@
+package commands
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"twooms/storage"
+)
+
+// statsWeeks is how many weeks of history /stats shows
+const statsWeeks = 8
+
+// statsBarWidth is the length of the longest burndown bar
+const statsBarWidth = 30
+
+func init() {
+	Register(&Command{
+		Name:        "/stats",
+		Description: "Show completion statistics and a weekly burndown chart for a project or all projects",
+		Access:      AccessRead,
+		Params: []Param{
+			{Name: "project_id", Type: ParamTypeString, Description: "Optional project ID or shortcut (all projects if omitted)", Required: false},
+		},
+		Handler: func(args []string) bool {
+			scope := "all projects"
+			var projectID string
+			if len(args) > 0 {
+				resolved, err := GetStore().ResolveProjectID(args[0])
+				if err != nil {
+					fmt.Printf("Error: %v\n", err)
+					return false
+				}
+				project, err := GetStore().GetProject(resolved)
+				if err != nil {
+					fmt.Printf("Error: %v\n", err)
+					return false
+				}
+				projectID, scope = resolved, project.Name
+			}
+
+			// Archived tasks are finished work, so they count too
+			var tasks []*storage.Task
+			var err error
+			if projectID == "" {
+				tasks, err = GetStore().ListAllTasks()
+			} else {
+				tasks, err = GetStore().ListTasks(projectID)
+			}
+			if err != nil {
+				fmt.Printf("Error listing tasks: %v\n", err)
+				return false
+			}
+			archived, err := GetStore().ListArchivedTasks(projectID)
+			if err != nil {
+				fmt.Printf("Error listing tasks: %v\n", err)
+				return false
+			}
+			tasks = append(tasks, archived...)
+
+			printStats(scope, storage.ComputeStats(tasks, time.Now(), statsWeeks))
+			return false
+		},
+	})
+}
+
+// printStats prints the summary followed by one row per week, with a bar for
+// the tasks still open at the end of the week
+func printStats(scope string, s *storage.Stats) {
+	fmt.Printf("Stats for %s:\n", scope)
+	if s.Total == 0 {
+		fmt.Println("  No tasks yet.")
+		return
+	}
+
+	open := s.Total - s.Done
+	fmt.Printf("  Tasks: %d (%d done, %.0f%% complete)\n", s.Total, s.Done, s.CompletionRate()*100)
+	if s.Timed > 0 {
+		fmt.Printf("  Average time to done: %s (over %d tasks)\n", formatElapsed(s.AvgTimeToDone), s.Timed)
+	}
+	if untimed := s.Done - s.Timed; untimed > 0 {
+		fmt.Printf("  (%d done tasks were finished before completion times were recorded)\n", untimed)
+	}
+	outstanding := "no estimates"
+	if s.OpenMinutes > 0 {
+		outstanding = storage.FormatMinutes(s.OpenMinutes)
+	}
+	fmt.Printf("  Outstanding: %s across %d open tasks\n", outstanding, open)
+
+	maxOpen := 0
+	for _, w := range s.Weeks {
+		maxOpen = max(maxOpen, w.Open)
+	}
+
+	fmt.Println()
+	fmt.Println("  Week of     Created  Done  Open")
+	for _, w := range s.Weeks {
+		bar := ""
+		if w.Open > 0 {
+			bar = strings.Repeat("█", max(1, w.Open*statsBarWidth/maxOpen))
+		}
+		fmt.Printf("  %s  %7d  %4d  %4d  %s\n", w.Start.Format("2006-01-02"), w.Created, w.Completed, w.Open, bar)
+	}
+}
+
+// formatElapsed renders a duration in the largest sensible unit, e.g. "3.5 days"
+func formatElapsed(d time.Duration) string {
+	switch {
+	case d < time.Hour:
+		return fmt.Sprintf("%d min", int(d.Minutes()))
+	case d < 48*time.Hour:
+		return fmt.Sprintf("%.1f hours", d.Hours())
+	default:
+		return fmt.Sprintf("%.1f days", d.Hours()/24)
+	}
+}
@@ -0,0 +1,87 @@
+package commands
+
+import (
+	"fmt"
+	"time"
+
+	"twooms/storage"
+)
+
+// suggestDue turns on due-date suggestions for new tasks (REPL only, since
+// accepting one takes a keypress at the next prompt)
+var suggestDue bool
+
+// pendingSuggestion applies the last thing offered with "Press Enter to
+// ...", such as a due date for the task just created, until it is accepted
+// or the next input dismisses it
+var pendingSuggestion func()
+
+// EnableDueSuggestions turns on due-date suggestions for tasks created without one
+func EnableDueSuggestions() {
+	suggestDue = true
+}
+
+// offerDueSuggestion suggests a due date for a new task and remembers it so
+// AcceptSuggestion can apply it
+func offerDueSuggestion(task *storage.Task) {
+	if !suggestDue || chatRunning || task.DueDate != nil {
+		return
+	}
+
+	project, _ := GetStore().GetProject(task.ProjectID)
+	tasks, err := GetStore().ListAllTasks()
+	if err != nil {
+		return
+	}
+	// Archived tasks still count as history for lead times
+	archived, err := GetStore().ListArchivedTasks("")
+	if err != nil {
+		return
+	}
+	tasks = append(tasks, archived...)
+
+	suggestion := storage.SuggestDueDate(task.Name, project, tasks, time.Now())
+	if suggestion == nil {
+		return
+	}
+
+	taskID, date := task.ID, suggestion.Date
+	offerSuggestion(func() { applyDueSuggestion(taskID, date) })
+	fmt.Printf("Suggested due date: %s (%s). Press Enter to accept.\n", suggestion.Date.Format("Mon 2006-01-02"), suggestion.Reason)
+}
+
+// offerSuggestion makes Enter on an empty line run apply, replacing any
+// earlier offer
+func offerSuggestion(apply func()) {
+	pendingSuggestion = apply
+}
+
+// AcceptSuggestion applies the pending suggestion, if any, and reports
+// whether there was one
+func AcceptSuggestion() bool {
+	if pendingSuggestion == nil {
+		return false
+	}
+	apply := pendingSuggestion
+	pendingSuggestion = nil
+	apply()
+	return true
+}
+
+func applyDueSuggestion(taskID string, date time.Time) {
+	if err := GetStore().SetTaskDueDate(taskID, &date); err != nil {
+		fmt.Printf("Error: %v\n", err)
+		return
+	}
+
+	name := shortenID(taskID)
+	if task, err := GetStore().GetTask(taskID); err == nil {
+		name = task.Name
+	}
+	fmt.Printf("Set due date for task %s to %s\n", name, date.Format("2006-01-02"))
+}
+
+// DismissSuggestion drops the pending suggestion without applying it
+func DismissSuggestion() {
+	pendingSuggestion = nil
+}
@@ -0,0 +1,150 @@
+package commands
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"twooms/llm"
+)
+
+// defaultContextTokens is roughly how many tokens of chat history are sent
+// before older turns are summarized, when the config doesn't say
+const defaultContextTokens = 32000
+
+// keepRecentTurns is how many of the latest user messages, and everything
+// after each, stay word for word when older turns are summarized
+const keepRecentTurns = 4
+
+// summaryPrefix identifies the message that stands in for summarized turns
+const summaryPrefix = "[Conversation summary]"
+
+// ContextTokens returns the approximate size in tokens chat history may
+// reach before older turns are summarized (context_tokens in the config),
+// or 0 if summarizing is turned off
+func ContextTokens() int {
+	limit := GetConfig().ContextTokens
+	switch {
+	case limit < 0:
+		return 0
+	case limit == 0:
+		return defaultContextTokens
+	}
+	return limit
+}
+
+// estimateTokens guesses how many tokens messages take, at about four
+// characters a token; close enough to tell when history is getting long
+func estimateTokens(messages []*llm.Message) int {
+	chars := 0
+	for _, msg := range messages {
+		chars += len(msg.Content)
+		for _, call := range msg.ToolCalls {
+			args, _ := json.Marshal(call.Arguments)
+			chars += len(call.Name) + len(args)
+		}
+	}
+	return chars / 4
+}
+
+// summarizeCut returns the index of the first message kept as is: the
+// start of the keepRecentTurns-th last user turn, so no tool result is
+// separated from the call that made it. 0 means there's nothing old
+// enough to summarize.
+func summarizeCut(messages []*llm.Message) int {
+	turns := 0
+	for i := len(messages) - 1; i > 1; i-- {
+		if messages[i].Role != "user" {
+			continue
+		}
+		turns++
+		if turns == keepRecentTurns {
+			return i
+		}
+	}
+	return 0
+}
+
+// compactChatHistory replaces the older turns of chatHistory with a summary
+// written by the model once the history nears ContextTokens. The system
+// prompt and the latest turns are kept. It's quiet unless debug mode is on;
+// if the summary can't be made, the history is sent as it is.
+func compactChatHistory(client llm.Client) {
+	limit := ContextTokens()
+	if limit == 0 || len(chatHistory) == 0 || chatHistory[0].Role != "system" {
+		return
+	}
+	tokens := estimateTokens(chatHistory)
+	if tokens <= limit {
+		return
+	}
+	cut := summarizeCut(chatHistory)
+	if cut == 0 {
+		if IsDebugMode() {
+			fmt.Printf("[DEBUG] Chat history is ~%d tokens (limit %d), but too few turns to summarize\n", tokens, limit)
+		}
+		return
+	}
+
+	old := chatHistory[1:cut]
+	if IsDebugMode() {
+		fmt.Printf("[DEBUG] Chat history is ~%d tokens (limit %d): summarizing %d older messages\n", tokens, limit, len(old))
+	}
+	if err := checkBudget(); err != nil {
+		if IsDebugMode() {
+			fmt.Printf("[DEBUG] Not summarizing: %v\n", err)
+		}
+		return
+	}
+
+	resp, err := client.Chat(commandContext(), summaryRequest(old))
+	if err != nil || strings.TrimSpace(resp.Text) == "" {
+		if IsDebugMode() {
+			fmt.Printf("[DEBUG] Summarizing failed, sending the full history: %v\n", err)
+		}
+		return
+	}
+	sessionInputTokens += resp.InputTokens
+	sessionOutputTokens += resp.OutputTokens
+	sessionCachedTokens += resp.CachedTokens
+	sessionCost += resp.Cost
+	recordMonthlyUsage(resp)
+
+	summary := []*llm.Message{
+		{Role: "user", Content: summaryPrefix + "\n" + strings.TrimSpace(resp.Text)},
+		{Role: "assistant", Content: "Noted."},
+	}
+	history := append([]*llm.Message{chatHistory[0]}, summary...)
+	chatHistory = append(history, chatHistory[cut:]...)
+	if IsDebugMode() {
+		fmt.Printf("[DEBUG] Summarized %d messages (~%d tokens) into ~%d tokens; history is now ~%d tokens\n",
+			len(old), estimateTokens(old), estimateTokens(summary), estimateTokens(chatHistory))
+	}
+}
+
+// summaryRequest is the prompt asking the model to summarize messages
+func summaryRequest(messages []*llm.Message) string {
+	var b strings.Builder
+	b.WriteString("Summarize this earlier part of a conversation between a user and the assistant of a task manager, so the conversation can continue without it. ")
+	b.WriteString("Keep what later messages may refer to: the projects and tasks discussed with their IDs and shortcuts, changes made, decisions, and open requests. ")
+	b.WriteString("Leave out tool output that only listed things. Answer with the summary alone, in a few short paragraphs or bullets.\n\n")
+	for _, msg := range messages {
+		switch {
+		case msg.Role == "tool":
+			fmt.Fprintf(&b, "Tool result: %s\n", msg.Content)
+		case len(msg.ToolCalls) > 0:
+			for _, call := range msg.ToolCalls {
+				args, _ := json.Marshal(call.Arguments)
+				fmt.Fprintf(&b, "Assistant called %s %s\n", call.Name, args)
+			}
+			if msg.Content != "" {
+				fmt.Fprintf(&b, "Assistant: %s\n", msg.Content)
+			}
+		case msg.Role == "assistant":
+			fmt.Fprintf(&b, "Assistant: %s\n", msg.Content)
+		default:
+			fmt.Fprintf(&b, "User: %s\n", msg.Content)
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,427 @@
+package commands
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"twooms/config"
+	"twooms/i18n"
+	"twooms/storage"
+)
+
+func init() {
+	Register(&Command{
+		Name:        "/sync",
+		Description: "Show the state of git sync between machines, or pull and push now",
+		Examples:    []string{"/sync", "/sync now"},
+		Access:      AccessWrite,
+		Hidden:      true, // syncing is the user's business, not the model's
+		Handler: func(args []string) bool {
+			if syncConfig() == nil {
+				fmt.Println(`Sync is off. To share the store between machines, clone a git repository on each and set "sync": {"repo": "<path to the clone>"} in ~/.twooms.config.json.`)
+				return false
+			}
+			if len(args) == 0 {
+				args = []string{"status"}
+			}
+			switch strings.ToLower(args[0]) {
+			case "status":
+				syncStatus()
+			case "now":
+				if err := pullSync(os.Stdout); err != nil {
+					fmt.Printf("Error: %v\n", err)
+					return false
+				}
+				if err := pushSync(); err != nil {
+					fmt.Printf("Error: %v\n", err)
+					return false
+				}
+				fmt.Println("Synced.")
+			default:
+				fmt.Println(i18n.T("sync.usage"))
+			}
+			return false
+		},
+	})
+}
+
+// syncConfig returns the sync settings, or nil when sync is off
+func syncConfig() *config.SyncConfig {
+	if cfg := GetConfig(); cfg != nil && cfg.Sync != nil && cfg.Sync.Repo != "" {
+		return cfg.Sync
+	}
+	return nil
+}
+
+func syncRemote() string {
+	if remote := syncConfig().Remote; remote != "" {
+		return remote
+	}
+	return "origin"
+}
+
+// syncFile is the workspace's file in the sync repository: the /export json
+// snapshot of its store
+func syncFile() string {
+	if activeWorkspace == "" {
+		return "twooms.json"
+	}
+	return activeWorkspace + ".json"
+}
+
+// git runs a git command in the sync repository and returns its output
+func git(args ...string) (string, error) {
+	cmd := exec.Command("git", append([]string{"-C", syncConfig().Repo}, args...)...)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		// git explains itself on its last line, e.g. "fatal: ..."
+		lines := strings.Split(strings.TrimSpace(stderr.String()), "\n")
+		if msg := lines[len(lines)-1]; msg != "" {
+			return "", fmt.Errorf("git %s: %s", args[0], msg)
+		}
+		return "", fmt.Errorf("git %s: %v", args[0], err)
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
+// gitHas reports whether a revision exists
+func gitHas(rev string) bool {
+	_, err := git("rev-parse", "--verify", "--quiet", rev+"^{commit}")
+	return err == nil
+}
+
+// syncMarker is where the repository records that this machine's store has
+// synced with it. Until then the file in the repository isn't what the store
+// last matched, so the first sync merges instead of overwriting either one.
+func syncMarker() (string, error) {
+	dir, err := git("rev-parse", "--absolute-git-dir")
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "twooms-"+syncFile()+".synced"), nil
+}
+
+// syncedBefore reports whether the store has synced with the repository
+func syncedBefore() bool {
+	marker, err := syncMarker()
+	if err != nil {
+		return false
+	}
+	_, err = os.Stat(marker)
+	return err == nil
+}
+
+// storeJSON returns the store as the repository keeps it
+func storeJSON() ([]byte, error) {
+	snap, err := storage.ExportSnapshot(GetStore())
+	if err != nil {
+		return nil, err
+	}
+	var data bytes.Buffer
+	if err := storage.WriteSnapshot(&data, snap, storage.FormatJSON); err != nil {
+		return nil, err
+	}
+	return data.Bytes(), nil
+}
+
+// commitSync writes the store to the repository and commits it, if it has
+// changed since the last commit
+func commitSync(message string) error {
+	data, err := storeJSON()
+	if err != nil {
+		return err
+	}
+	path := filepath.Join(syncConfig().Repo, syncFile())
+	if old, err := os.ReadFile(path); err == nil && bytes.Equal(old, data) {
+		// A commit that failed last time leaves the file changed in git
+		if changed, err := git("status", "--porcelain", "--", syncFile()); err != nil || changed == "" {
+			return err
+		}
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return err
+	}
+	if _, err := git("add", "--", syncFile()); err != nil {
+		return err
+	}
+	_, err = git("commit", "--quiet", "-m", message, "--", syncFile())
+	return err
+}
+
+// commitChanges commits what a command changed, once the store has synced
+// before; it runs after every command while sync is on
+func commitChanges(command string) {
+	if !syncedBefore() {
+		return
+	}
+	host, _ := os.Hostname()
+	if err := commitSync(fmt.Sprintf("%s on %s", command, host)); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: sync commit failed: %v\n", err)
+	}
+}
+
+// readSyncSnapshot reads the store's file at a revision; a revision without
+// the file is an empty store
+func readSyncSnapshot(rev string) (*storage.Snapshot, error) {
+	if rev == "" {
+		return &storage.Snapshot{}, nil
+	}
+	data, err := git("show", rev+":"+syncFile())
+	if err != nil {
+		if _, revErr := git("cat-file", "-e", rev+":"+syncFile()); revErr != nil {
+			return &storage.Snapshot{}, nil
+		}
+		return nil, err
+	}
+	return storage.ReadSnapshot(strings.NewReader(data), storage.FormatJSON)
+}
+
+// syncUpstream fetches the remote and returns its branch matching ours, or ""
+// when there's no remote or it doesn't have the branch yet
+func syncUpstream() (string, error) {
+	remote := syncRemote()
+	if _, err := git("remote", "get-url", remote); err != nil {
+		return "", nil
+	}
+	if _, err := git("fetch", "--quiet", remote); err != nil {
+		return "", err
+	}
+	branch, err := git("symbolic-ref", "--short", "HEAD")
+	if err != nil {
+		return "", err
+	}
+	upstream := remote + "/" + branch
+	if !gitHas(upstream) {
+		return "", nil
+	}
+	return upstream, nil
+}
+
+// pullSync brings in changes from the other machines, merging them with the
+// store's by entity ID, and commits the result. Entities changed on both
+// sides are merged (tasks) or queued for /conflicts (projects). It reports
+// what changed to out.
+func pullSync(out io.Writer) error {
+	host, _ := os.Hostname()
+	first := !syncedBefore()
+	if !first {
+		if err := commitSync("Changes on " + host); err != nil {
+			return err
+		}
+	}
+	upstream, err := syncUpstream()
+	if err != nil {
+		return err
+	}
+
+	head := ""
+	if gitHas("HEAD") {
+		head = "HEAD"
+	}
+	var snap *storage.Snapshot
+	var conflicts []*storage.Conflict
+	switch {
+	case first:
+		// Merge the store with the repository's copy, with nothing deleted
+		if upstream != "" {
+			if _, err := git("merge", "--quiet", "--ff-only", upstream); err != nil {
+				return err
+			}
+			head = "HEAD"
+		}
+		theirs, err := readSyncSnapshot(head)
+		if err != nil {
+			return err
+		}
+		mine, err := storage.ExportSnapshot(GetStore())
+		if err != nil {
+			return err
+		}
+		snap, conflicts = storage.MergeSnapshots(&storage.Snapshot{}, mine, theirs, "sync")
+	case upstream == "":
+	case head == "":
+		// A new clone of a repository the other machines already use
+		if _, err := git("merge", "--quiet", "--ff-only", upstream); err != nil {
+			return err
+		}
+		if snap, err = readSyncSnapshot("HEAD"); err != nil {
+			return err
+		}
+	default:
+		if _, err := git("merge-base", "--is-ancestor", upstream, "HEAD"); err == nil {
+			break // nothing new
+		}
+		if _, err := git("merge-base", "--is-ancestor", "HEAD", upstream); err == nil {
+			if _, err := git("merge", "--quiet", "--ff-only", upstream); err != nil {
+				return err
+			}
+			if snap, err = readSyncSnapshot("HEAD"); err != nil {
+				return err
+			}
+			break
+		}
+
+		// Both sides changed: merge by ID and put the result on top of
+		// theirs, so history stays linear as with pull --rebase
+		base, err := git("merge-base", "HEAD", upstream)
+		if err != nil {
+			return err
+		}
+		snaps := make([]*storage.Snapshot, 3)
+		for i, rev := range []string{base, "HEAD", upstream} {
+			if snaps[i], err = readSyncSnapshot(rev); err != nil {
+				return err
+			}
+		}
+		if err := checkSyncReset(base); err != nil {
+			return err
+		}
+		snap, conflicts = storage.MergeSnapshots(snaps[0], snaps[1], snaps[2], "sync")
+		if _, err := git("reset", "--quiet", "--hard", upstream); err != nil {
+			return err
+		}
+	}
+
+	if snap != nil {
+		result, err := storage.RestoreSnapshot(GetStore(), snap, "sync from "+syncRemote())
+		if err != nil {
+			return fmt.Errorf("applying synced changes: %w", err)
+		}
+		if result.Added+result.Replaced+result.Deleted > 0 {
+			fmt.Fprintf(out, "Synced from %s: %d added, %d changed, %d deleted\n", syncRemote(), result.Added, result.Replaced, result.Deleted)
+		}
+	}
+	for _, c := range conflicts {
+		if err := GetStore().AddConflict(c); err != nil {
+			return err
+		}
+	}
+	if len(conflicts) > 0 {
+		fmt.Fprintf(out, "%d projects were changed here and on another machine; /conflicts shows them\n", len(conflicts))
+	}
+
+	if err := commitSync("Sync on " + host); err != nil {
+		return err
+	}
+	if first {
+		marker, err := syncMarker()
+		if err != nil {
+			return err
+		}
+		return os.WriteFile(marker, nil, 0644)
+	}
+	return nil
+}
+
+// checkSyncReset returns an error if putting the merge on top of theirs, by
+// resetting to it, would lose anything but the store's own commits since
+// base: changes not committed yet, or local commits to other files
+func checkSyncReset(base string) error {
+	dirty, err := git("status", "--porcelain", "--untracked-files=no")
+	if err != nil {
+		return err
+	}
+	if dirty != "" {
+		return fmt.Errorf("%s has uncommitted changes; commit or stash them, then sync again", syncConfig().Repo)
+	}
+	changed, err := git("diff", "--name-only", base, "HEAD")
+	if err != nil {
+		return err
+	}
+	for _, name := range strings.Split(changed, "\n") {
+		if name != "" && name != syncFile() {
+			return fmt.Errorf("%s has local commits to %s that aren't pushed; push or drop them, then sync again", syncConfig().Repo, name)
+		}
+	}
+	return nil
+}
+
+// pushSync commits the store and pushes it to the remote, pulling first if
+// another machine pushed in the meantime
+func pushSync() error {
+	if !syncedBefore() {
+		return nil
+	}
+	host, _ := os.Hostname()
+	if err := commitSync("Changes on " + host); err != nil {
+		return err
+	}
+	remote := syncRemote()
+	if _, err := git("remote", "get-url", remote); err != nil || !gitHas("HEAD") {
+		return nil
+	}
+	if _, err := git("push", "--quiet", "-u", remote, "HEAD"); err == nil {
+		return nil
+	}
+	if err := pullSync(os.Stderr); err != nil {
+		return err
+	}
+	_, err := git("push", "--quiet", "-u", remote, "HEAD")
+	return err
+}
+
+// PullSync pulls the active workspace's store when sync is on; opening a
+// workspace calls it. Problems are warnings, so an offline machine still
+// starts.
+func PullSync() {
+	if syncConfig() == nil {
+		return
+	}
+	if err := pullSync(os.Stderr); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: sync failed: %v\n", err)
+	}
+}
+
+// PushSync pushes the active workspace's store when sync is on; main calls
+// it on exit, and switching workspaces before closing the old store
+func PushSync() {
+	if syncConfig() == nil {
+		return
+	}
+	if err := pushSync(); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: sync failed: %v (the changes are committed in %s and go out with the next sync)\n", err, syncConfig().Repo)
+	}
+}
+
+// syncStatus prints where the store syncs to and how it compares with the
+// remote as of the last fetch
+func syncStatus() {
+	cfg := syncConfig()
+	fmt.Printf("Syncing %s in %s with %s\n", syncFile(), cfg.Repo, syncRemote())
+	if !syncedBefore() {
+		fmt.Println("Not synced yet; /sync now merges the store with the repository")
+		return
+	}
+	if last, err := git("log", "-1", "--format=%h %cr: %s", "--", syncFile()); err == nil && last != "" {
+		fmt.Printf("Last commit: %s\n", last)
+	}
+
+	if branch, err := git("symbolic-ref", "--short", "HEAD"); err == nil && gitHas(syncRemote()+"/"+branch) {
+		counts, err := git("rev-list", "--left-right", "--count", "HEAD..."+syncRemote()+"/"+branch)
+		var ahead, behind int
+		if err == nil {
+			fmt.Sscanf(counts, "%d %d", &ahead, &behind)
+		}
+		switch {
+		case ahead == 0 && behind == 0:
+			fmt.Println("Up to date with the remote as of the last fetch")
+		default:
+			fmt.Printf("%d commits to push, %d to pull as of the last fetch\n", ahead, behind)
+		}
+	} else {
+		fmt.Println("Nothing pushed yet")
+	}
+
+	data, err := storeJSON()
+	old, readErr := os.ReadFile(filepath.Join(cfg.Repo, syncFile()))
+	if err == nil && (readErr != nil || !bytes.Equal(old, data)) {
+		fmt.Println("The store has changes that aren't committed yet")
+	}
+}
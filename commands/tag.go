@@ -0,0 +1,167 @@
+package commands
+
+import (
+	"fmt"
+	"strings"
+
+	"twooms/i18n"
+	"twooms/storage"
+)
+
+func init() {
+	Register(&Command{
+		Name:        "/tag",
+		Shorthand:   "/tg",
+		Description: "Add a tag to a task",
+		Examples:    []string{"/tag 1a2b3c4d errands"},
+		Params: []Param{
+			{Name: "task_id", Type: ParamTypeString, Description: "The ID of the task", Required: true},
+			{Name: "tag", Type: ParamTypeString, Description: "The tag to add (e.g., errands or #errands)", Required: true},
+		},
+		Handler: func(args []string) bool {
+			if len(args) < 2 {
+				fmt.Println(i18n.T("tag.usage"))
+				return false
+			}
+
+			taskRef := args[0]
+			tag := storage.NormalizeTag(args[1])
+
+			// Resolve task ID
+			taskID, err := GetStore().ResolveTaskID(taskRef)
+			if err != nil {
+				fmt.Println(i18n.T("error", err))
+				return false
+			}
+
+			// Get task for display
+			task, err := GetStore().GetTask(taskID)
+			if err != nil {
+				fmt.Println(i18n.T("error", err))
+				return false
+			}
+
+			if err := GetStore().AddTaskTag(taskID, tag); err != nil {
+				fmt.Println(i18n.T("error", err))
+				return false
+			}
+
+			fmt.Println(i18n.T("tag.added", task.Name, tag))
+			return false
+		},
+	})
+
+	Register(&Command{
+		Name:        "/untag",
+		Shorthand:   "/utg",
+		Description: "Remove a tag from a task",
+		Params: []Param{
+			{Name: "task_id", Type: ParamTypeString, Description: "The ID of the task", Required: true},
+			{Name: "tag", Type: ParamTypeString, Description: "The tag to remove", Required: true},
+		},
+		Handler: func(args []string) bool {
+			if len(args) < 2 {
+				fmt.Println(i18n.T("untag.usage"))
+				return false
+			}
+
+			taskRef := args[0]
+			tag := storage.NormalizeTag(args[1])
+
+			// Resolve task ID
+			taskID, err := GetStore().ResolveTaskID(taskRef)
+			if err != nil {
+				fmt.Println(i18n.T("error", err))
+				return false
+			}
+
+			// Get task for display
+			task, err := GetStore().GetTask(taskID)
+			if err != nil {
+				fmt.Println(i18n.T("error", err))
+				return false
+			}
+
+			if err := GetStore().RemoveTaskTag(taskID, tag); err != nil {
+				fmt.Println(i18n.T("error", err))
+				return false
+			}
+
+			fmt.Println(i18n.T("untag.removed", tag, task.Name))
+			return false
+		},
+	})
+
+	Register(&Command{
+		Name:        "/tagged",
+		Shorthand:   "/tgd",
+		Description: "List tasks with a given tag across all projects",
+		Examples:    []string{"/tagged errands"},
+		Access:      AccessRead,
+		Params: []Param{
+			{Name: "tag", Type: ParamTypeString, Description: "The tag to search for (e.g., errands or #errands)", Required: true},
+		},
+		Handler: func(args []string) bool {
+			if len(args) == 0 {
+				fmt.Println(i18n.T("tagged.usage"))
+				return false
+			}
+
+			tag := storage.NormalizeTag(args[0])
+
+			tasks, err := GetStore().ListTasksByTag(tag)
+			if err != nil {
+				fmt.Println(i18n.T("tasks.list_failed", err))
+				return false
+			}
+
+			fmt.Println(i18n.T("tagged.header", tag))
+			if len(tasks) == 0 {
+				fmt.Println(i18n.T("tagged.none"))
+				return false
+			}
+
+			// Build project name lookup for display
+			projectNames := map[string]string{"": storage.InboxName}
+			projects, _ := GetStore().ListProjects()
+			for _, p := range projects {
+				projectNames[p.ID] = p.Name
+			}
+
+			for _, t := range tasks {
+				status := "[ ]"
+				if t.Done {
+					status = "[✓]"
+				}
+
+				var extras []string
+				if t.Duration != 0 {
+					extras = append(extras, t.Duration.String())
+				}
+				if t.DueDate != nil {
+					extras = append(extras, i18n.T("list.due", storage.FormatDue(*t.DueDate)))
+				}
+				if name, ok := projectNames[t.ProjectID]; ok {
+					extras = append(extras, name)
+				}
+
+				extraStr := ""
+				if len(extras) > 0 {
+					extraStr = " (" + strings.Join(extras, ", ") + ")"
+				}
+
+				fmt.Println("  " + taskLine(t, status, storage.DisplayName(t.Name)+extraStr+formatTags(t)+blockedMark(t)))
+			}
+
+			return false
+		},
+	})
+}
+
+// formatTags renders a task's tags as " #a #b", or "" if the task has none
+func formatTags(t *storage.Task) string {
+	if len(t.Tags) == 0 {
+		return ""
+	}
+	return " #" + strings.Join(t.Tags, " #")
+}
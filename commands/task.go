@@ -5,6 +5,7 @@ import (
 	"strings"
 	"time"
 
+	"twooms/i18n"
 	"twooms/storage"
 )
 
@@ -13,38 +14,31 @@ func init() {
 		Name:        "/task",
 		Shorthand:   "/t",
 		Description: "Add a task to a project",
+		Examples:    []string{"/task web Write landing page copy", "/task web Call the printer !p1 @phone due:fri ~30m #print"},
+		Access:      AccessCreate,
 		Params: []Param{
 			{Name: "project_id", Type: ParamTypeString, Description: "The ID or shortcut of the project to add the task to", Required: true},
-			{Name: "task_name", Type: ParamTypeString, Description: "The name of the task to create", Required: true},
+			{Name: "task_name", Type: ParamTypeString, Description: "The name of the task to create; may include inline metadata like !p1 @context due:fri ~2h #tag", Required: true},
 		},
 		Handler: func(args []string) bool {
 			if len(args) < 2 {
-				fmt.Println("Usage: /task <project-id> <task name>")
+				fmt.Println(i18n.T("task.usage"))
 				return false
 			}
 
-			projectRef := args[0]
-			taskName := strings.Join(args[1:], " ")
-
-			// Resolve project ID
-			projectID, err := GetStore().ResolveProjectID(projectRef)
+			createTask(args[0], args[1:])
+			return false
+		},
+		ToolHandler: func(args map[string]any) (string, error) {
+			projectRef, err := stringArg(args, "project_id")
 			if err != nil {
-				fmt.Printf("Error: %v\n", err)
-				return false
+				return "", err
 			}
-
-			task, err := GetStore().CreateTask(projectID, taskName)
+			taskName, err := stringArg(args, "task_name")
 			if err != nil {
-				fmt.Printf("Error creating task: %v\n", err)
-				return false
+				return "", err
 			}
-
-			shortID := task.ID
-			if len(task.ID) > 8 {
-				shortID = task.ID[:8]
-			}
-			fmt.Printf("Created task: %s (ID: %s)\n", task.Name, shortID)
-			return false
+			return captureOutput(func() { createTask(projectRef, strings.Fields(taskName)) }), nil
 		},
 	})
 
@@ -52,308 +46,678 @@ func init() {
 		Name:        "/tasks",
 		Shorthand:   "/ts",
 		Description: "List tasks in a project. Call 'projects' first if you only have the project name.",
+		Examples:    []string{"/tasks web", "/tasks web --status todo --sort due", "/tasks web --group"},
+		Access:      AccessRead,
 		Params: []Param{
 			{Name: "project_id", Type: ParamTypeString, Description: "The ID or shortcut of the project to list tasks for", Required: true},
+			{Name: "status", Type: ParamTypeString, Description: "Only list tasks with this status", Required: false, Enum: statusValues()},
+			{Name: "sort", Type: ParamTypeString, Description: "Order of the tasks (defaults to the user's saved order)", Required: false, Enum: taskSorts},
+			{Name: "group", Type: ParamTypeBoolean, Description: "Group the tasks by due date: overdue, today, this week, later", Required: false},
 		},
 		Handler: func(args []string) bool {
-			if len(args) == 0 {
-				fmt.Println("Usage: /tasks <project-id>")
-				return false
+			var projectRef, status, sortBy string
+			group, save := false, false
+			for i := 0; i < len(args); i++ {
+				switch {
+				case args[i] == "--status" && i+1 < len(args):
+					i++
+					status = args[i]
+				case strings.HasPrefix(args[i], "--status="):
+					status = strings.TrimPrefix(args[i], "--status=")
+				case args[i] == "--sort" && i+1 < len(args):
+					i++
+					sortBy = args[i]
+				case strings.HasPrefix(args[i], "--sort="):
+					sortBy = strings.TrimPrefix(args[i], "--sort=")
+				case args[i] == "--group":
+					group = true
+				case args[i] == "--save":
+					save = true
+				case projectRef == "":
+					projectRef = args[i]
+				}
 			}
 
-			projectRef := args[0]
-
-			// Resolve project ID
-			projectID, err := GetStore().ResolveProjectID(projectRef)
-			if err != nil {
-				fmt.Printf("Error: %v\n", err)
+			sortBy = strings.ToLower(sortBy)
+			if sortBy != "" && !isTaskSort(sortBy) {
+				fmt.Println(i18n.T("tasks.sort_invalid", sortBy))
+				return false
+			}
+			if save {
+				if sortBy == "" {
+					fmt.Println(i18n.T("tasks.usage"))
+					return false
+				}
+				saveTaskSort(sortBy)
+			}
+			if projectRef == "" {
+				if !save {
+					fmt.Println(i18n.T("tasks.usage"))
+				}
 				return false
 			}
 
-			// Get project info
-			project, err := GetStore().GetProject(projectID)
+			if sortBy == "" {
+				sortBy = defaultTaskSort()
+			}
+			listTasks(projectRef, status, sortBy, group)
+			return false
+		},
+		ToolHandler: func(args map[string]any) (string, error) {
+			projectRef, err := stringArg(args, "project_id")
 			if err != nil {
-				fmt.Printf("Error: %v\n", err)
+				return "", err
+			}
+			status, _ := args["status"].(string)
+			sortBy, _ := args["sort"].(string)
+			group, _ := args["group"].(bool)
+			if sortBy = strings.ToLower(strings.TrimSpace(sortBy)); !isTaskSort(sortBy) {
+				sortBy = defaultTaskSort()
+			}
+			return captureOutput(func() { listTasks(projectRef, strings.TrimSpace(status), sortBy, group) }), nil
+		},
+	})
+
+	Register(&Command{
+		Name:        "/done",
+		Shorthand:   "/d",
+		Description: "Mark a task as done, or several at once: IDs separated by spaces, or filters like --overdue, --project work, or --tag errands",
+		Examples:    []string{"/done 1a2b3c4d", "/done 1a2b3c4d 5e6f7a8b", "/done --overdue --tag errands"},
+		Params: []Param{
+			{Name: "task_id", Type: ParamTypeString, Description: "The ID of the task to mark as done; several IDs separated by spaces mark them all as one change", Required: true},
+		},
+		Handler: func(args []string) bool {
+			args, yes := takeYes(args)
+			if len(args) == 0 {
+				fmt.Println(i18n.T("done.usage"))
 				return false
 			}
 
-			tasks, err := GetStore().ListTasks(projectID)
+			tasks, batch, err := selectTasks(args)
 			if err != nil {
-				fmt.Printf("Error listing tasks: %v\n", err)
+				fmt.Println(i18n.T("error", err))
 				return false
 			}
-
-			fmt.Printf("Tasks in %s:\n", project.Name)
-			if len(tasks) == 0 {
-				fmt.Println("  No tasks yet. Add one with /task <project-id> <name>")
+			if batch {
+				markTasks("/done", tasks, true, yes)
 				return false
 			}
+			task := tasks[0]
+			taskID := task.ID
 
-			// Filter incomplete tasks for duration calculation
-			var incompleteTasks []*storage.Task
-			for _, t := range tasks {
-				status := "[ ]"
-				if t.Done {
-					status = "[✓]"
-				} else {
-					incompleteTasks = append(incompleteTasks, t)
-				}
-
-				// Build extra info string
-				var extras []string
-				if t.Duration != "" {
-					extras = append(extras, string(t.Duration))
-				}
-				if t.DueDate != nil {
-					extras = append(extras, "due "+t.DueDate.Format("2006-01-02"))
-				}
+			if err := GetStore().UpdateTask(taskID, true); err != nil {
+				fmt.Println(i18n.T("error", err))
+				return false
+			}
 
-				extraStr := ""
-				if len(extras) > 0 {
-					extraStr = " (" + strings.Join(extras, ", ") + ")"
+			fmt.Println(i18n.T("done.marked", task.Name))
+			if blockers := openBlockers(task); len(blockers) > 0 {
+				var names []string
+				for _, b := range blockers {
+					names = append(names, b.Name)
 				}
+				fmt.Println(i18n.T("done.was_blocked", strings.Join(names, ", ")))
+			}
+			Publish(EventTaskDone, task)
+			return false
+		},
+	})
 
-				// Show first 8 chars of task UUID (or full ID if shorter)
-				shortID := t.ID
-				if len(t.ID) > 8 {
-					shortID = t.ID[:8]
-				}
+	Register(&Command{
+		Name:        "/undone",
+		Shorthand:   "/ud",
+		Description: "Mark a task as not done, or several at once (IDs separated by spaces, or filters like --done work)",
+		Examples:    []string{"/undone 1a2b3c4d", "/undone 1a2b3c4d 5e6f7a8b"},
+		Params: []Param{
+			{Name: "task_id", Type: ParamTypeString, Description: "The ID of the task to mark as not done; several IDs separated by spaces reopen them all as one change", Required: true},
+		},
+		Handler: func(args []string) bool {
+			args, yes := takeYes(args)
+			if len(args) == 0 {
+				fmt.Println(i18n.T("undone.usage"))
+				return false
+			}
 
-				// Highlight overdue tasks in red
-				if isOverdue(t) {
-					fmt.Printf("  %s%s [%s] %s%s%s\n", colorRed, status, shortID, t.Name, extraStr, colorReset)
-				} else {
-					fmt.Printf("  %s [%s] %s%s\n", status, shortID, t.Name, extraStr)
-				}
+			tasks, batch, err := selectTasks(args)
+			if err != nil {
+				fmt.Println(i18n.T("error", err))
+				return false
+			}
+			if batch {
+				markTasks("/undone", tasks, false, yes)
+				return false
 			}
+			task := tasks[0]
+			taskID := task.ID
 
-			// Show total duration for incomplete tasks
-			totalMinutes := storage.TotalDuration(incompleteTasks)
-			if totalMinutes > 0 {
-				fmt.Printf("\nTotal: %s\n", storage.FormatMinutes(totalMinutes))
+			if err := GetStore().UpdateTask(taskID, false); err != nil {
+				fmt.Println(i18n.T("error", err))
+				return false
 			}
 
+			fmt.Println(i18n.T("undone.marked", task.Name))
 			return false
 		},
 	})
 
 	Register(&Command{
-		Name:        "/done",
-		Shorthand:   "/d",
-		Description: "Mark a task as done",
+		Name:        "/deltask",
+		Shorthand:   "/dt",
+		Description: "Delete a task, or several at once: IDs separated by spaces, or filters like --done work (the completed tasks in work)",
+		Examples:    []string{"/deltask 1a2b3c4d", "/deltask 1a2b3c4d 5e6f7a8b", "/deltask --done work"},
+		Destructive: true,
+		Interactive: true,
 		Params: []Param{
-			{Name: "task_id", Type: ParamTypeString, Description: "The ID of the task to mark as done", Required: true},
+			{Name: "task_id", Type: ParamTypeString, Description: "The ID of the task to delete", Required: true},
 		},
 		Handler: func(args []string) bool {
+			args, yes := takeYes(args)
 			if len(args) == 0 {
-				fmt.Println("Usage: /done <task-id>")
+				fmt.Println(i18n.T("deltask.usage"))
 				return false
 			}
 
-			taskRef := args[0]
-
-			// Resolve task ID
-			taskID, err := GetStore().ResolveTaskID(taskRef)
+			tasks, batch, err := selectTasks(args)
 			if err != nil {
-				fmt.Printf("Error: %v\n", err)
+				fmt.Println(i18n.T("error", err))
+				return false
+			}
+			if batch {
+				deleteTasks(tasks, yes)
 				return false
 			}
+			task := tasks[0]
+			taskID := task.ID
 
-			// Get task for display
-			task, err := GetStore().GetTask(taskID)
-			if err != nil {
-				fmt.Printf("Error: %v\n", err)
+			if !confirm("/deltask", i18n.T("deltask.confirm", storage.DisplayName(task.Name)), yes) {
 				return false
 			}
 
-			if err := GetStore().UpdateTask(taskID, true); err != nil {
-				fmt.Printf("Error: %v\n", err)
+			if err := GetStore().TrashTasks([]string{taskID}); err != nil {
+				fmt.Println(i18n.T("error", err))
 				return false
 			}
 
-			fmt.Printf("Marked task %s as done ✓\n", task.Name)
+			fmt.Println(i18n.T("deltask.deleted", task.Name))
 			return false
 		},
 	})
 
 	Register(&Command{
-		Name:        "/undone",
-		Shorthand:   "/ud",
-		Description: "Mark a task as not done",
+		Name:        "/due",
+		Shorthand:   "/du",
+		Description: "Set a task's due date, and optionally the time it's due; several tasks at once take IDs separated by spaces, or filters like --project work --overdue, and a date or a shift like +2d",
+		Examples:    []string{"/due 1a2b3c4d 2025-03-01", "/due 1a2b3c4d 2025-03-01 14:30", "/due 1a2b3c4d none", "/due --project work --overdue +2d"},
 		Params: []Param{
-			{Name: "task_id", Type: ParamTypeString, Description: "The ID of the task to mark as not done", Required: true},
+			{Name: "task_id", Type: ParamTypeString, Description: "The ID of the task", Required: true},
+			{Name: "date", Type: ParamTypeString, Description: "Due date in YYYY-MM-DD format, or 'none' to clear", Required: true, Format: ParamFormatDate},
+			{Name: "time", Type: ParamTypeString, Description: "Optional time it's due, HH:MM (24-hour); only for real deadlines like meetings or cutoffs", Required: false, Format: ParamFormatTime},
 		},
 		Handler: func(args []string) bool {
-			if len(args) == 0 {
-				fmt.Println("Usage: /undone <task-id>")
+			args, yes := takeYes(args)
+			if len(args) < 2 {
+				fmt.Println(i18n.T("due.usage"))
 				return false
 			}
 
-			taskRef := args[0]
+			// The date, and the time if there is one, come after the tasks
+			value := args[len(args)-1:]
+			if len(args) > 2 {
+				_, dateErr := time.Parse("2006-01-02", args[len(args)-2])
+				_, clockErr := storage.WithDueTime(time.Time{}, args[len(args)-1])
+				if dateErr == nil || clockErr == nil {
+					value = args[len(args)-2:]
+				}
+			}
+			dateStr, clock := value[0], ""
+			if len(value) > 1 {
+				clock = value[1]
+			}
 
-			// Resolve task ID
-			taskID, err := GetStore().ResolveTaskID(taskRef)
+			tasks, batch, err := selectTasks(args[:len(args)-len(value)])
 			if err != nil {
-				fmt.Printf("Error: %v\n", err)
+				fmt.Println(i18n.T("error", err))
+				return false
+			}
+			if batch {
+				setDueDates(tasks, dateStr, clock, yes)
 				return false
 			}
+			task := tasks[0]
+			taskID := task.ID
 
-			// Get task for display
-			task, err := GetStore().GetTask(taskID)
+			if dateStr == "none" {
+				if err := GetStore().SetTaskDueDate(taskID, nil); err != nil {
+					fmt.Println(i18n.T("error", err))
+					return false
+				}
+				fmt.Println(i18n.T("due.cleared", task.Name))
+				return false
+			}
+
+			dueDate, err := time.Parse("2006-01-02", dateStr)
 			if err != nil {
-				fmt.Printf("Error: %v\n", err)
+				fmt.Println(i18n.T("error.invalid_date"))
 				return false
 			}
+			if clock != "" {
+				if dueDate, err = storage.WithDueTime(dueDate, clock); err != nil {
+					fmt.Println(i18n.T("error", err))
+					return false
+				}
+			}
 
-			if err := GetStore().UpdateTask(taskID, false); err != nil {
-				fmt.Printf("Error: %v\n", err)
+			if err := GetStore().SetTaskDueDate(taskID, &dueDate); err != nil {
+				fmt.Println(i18n.T("error", err))
 				return false
 			}
 
-			fmt.Printf("Marked task %s as not done\n", task.Name)
+			fmt.Println(i18n.T("due.set", task.Name, storage.FormatDue(dueDate)))
 			return false
 		},
 	})
 
 	Register(&Command{
-		Name:        "/deltask",
-		Shorthand:   "/dt",
-		Description: "Delete a task",
-		Destructive: true,
+		Name:        "/duration",
+		Shorthand:   "/dur",
+		Description: "Set a task's duration, in minutes and/or hours",
+		Examples:    []string{"/duration 1a2b3c4d 2h", "/duration 1a2b3c4d 45m", "/duration 1a2b3c4d 1h30m"},
 		Params: []Param{
-			{Name: "task_id", Type: ParamTypeString, Description: "The ID of the task to delete", Required: true},
+			{Name: "task_id", Type: ParamTypeString, Description: "The ID of the task", Required: true},
+			{Name: "duration", Type: ParamTypeString, Description: "Duration in minutes and/or hours, e.g. 15m, 45m, 1h30m, or 3h", Required: true, Format: ParamFormatDuration},
 		},
 		Handler: func(args []string) bool {
-			if len(args) == 0 {
-				fmt.Println("Usage: /deltask <task-id>")
+			if len(args) < 2 {
+				fmt.Println(i18n.T("duration.usage"))
 				return false
 			}
 
 			taskRef := args[0]
+			durationStr := args[1]
+
+			duration, err := storage.ParseDuration(durationStr)
+			if err != nil {
+				fmt.Println(i18n.T("duration.invalid"))
+				return false
+			}
 
 			// Resolve task ID
 			taskID, err := GetStore().ResolveTaskID(taskRef)
 			if err != nil {
-				fmt.Printf("Error: %v\n", err)
+				fmt.Println(i18n.T("error", err))
 				return false
 			}
 
 			// Get task for display
 			task, err := GetStore().GetTask(taskID)
 			if err != nil {
-				fmt.Printf("Error: %v\n", err)
+				fmt.Println(i18n.T("error", err))
 				return false
 			}
 
-			if err := GetStore().DeleteTask(taskID); err != nil {
-				fmt.Printf("Error: %v\n", err)
+			if err := GetStore().SetTaskDuration(taskID, duration); err != nil {
+				fmt.Println(i18n.T("error", err))
 				return false
 			}
 
-			fmt.Printf("Deleted task: %s\n", task.Name)
+			fmt.Println(i18n.T("duration.set", task.Name, duration.String()))
 			return false
 		},
 	})
 
 	Register(&Command{
-		Name:        "/due",
-		Shorthand:   "/du",
-		Description: "Set a task's due date",
+		Name:        "/priority",
+		Shorthand:   "/pr",
+		Description: "Set or clear a task's priority",
+		Examples:    []string{"/priority 1a2b3c4d urgent", "/priority 1a2b3c4d none"},
 		Params: []Param{
 			{Name: "task_id", Type: ParamTypeString, Description: "The ID of the task", Required: true},
-			{Name: "date", Type: ParamTypeString, Description: "Due date in YYYY-MM-DD format, or 'none' to clear", Required: true},
+			{Name: "priority", Type: ParamTypeString, Description: "Priority: low, medium, high, urgent, or 'none' to clear", Required: true, Enum: priorityValues()},
 		},
 		Handler: func(args []string) bool {
 			if len(args) < 2 {
-				fmt.Println("Usage: /due <task-id> <YYYY-MM-DD|none>")
+				fmt.Println(i18n.T("priority.usage"))
 				return false
 			}
 
 			taskRef := args[0]
-			dateStr := args[1]
+			priorityStr := strings.ToLower(args[1])
+
+			if priorityStr != "none" && !storage.IsValidPriority(priorityStr) {
+				fmt.Println(i18n.T("priority.invalid"))
+				return false
+			}
 
 			// Resolve task ID
 			taskID, err := GetStore().ResolveTaskID(taskRef)
 			if err != nil {
-				fmt.Printf("Error: %v\n", err)
+				fmt.Println(i18n.T("error", err))
 				return false
 			}
 
 			// Get task for display
 			task, err := GetStore().GetTask(taskID)
 			if err != nil {
-				fmt.Printf("Error: %v\n", err)
+				fmt.Println(i18n.T("error", err))
 				return false
 			}
 
-			if dateStr == "none" {
-				if err := GetStore().SetTaskDueDate(taskID, nil); err != nil {
-					fmt.Printf("Error: %v\n", err)
+			if priorityStr == "none" {
+				if err := GetStore().SetTaskPriority(taskID, ""); err != nil {
+					fmt.Println(i18n.T("error", err))
 					return false
 				}
-				fmt.Printf("Cleared due date for task %s\n", task.Name)
-				return false
-			}
-
-			dueDate, err := time.Parse("2006-01-02", dateStr)
-			if err != nil {
-				fmt.Println("Error: Invalid date format. Use YYYY-MM-DD (e.g., 2024-12-31)")
+				fmt.Println(i18n.T("priority.cleared", task.Name))
 				return false
 			}
 
-			if err := GetStore().SetTaskDueDate(taskID, &dueDate); err != nil {
-				fmt.Printf("Error: %v\n", err)
+			if err := GetStore().SetTaskPriority(taskID, storage.Priority(priorityStr)); err != nil {
+				fmt.Println(i18n.T("error", err))
 				return false
 			}
 
-			fmt.Printf("Set due date for task %s to %s\n", task.Name, dateStr)
+			fmt.Println(i18n.T("priority.set", task.Name, priorityStr))
 			return false
 		},
 	})
 
 	Register(&Command{
-		Name:        "/duration",
-		Shorthand:   "/dur",
-		Description: "Set a task's duration",
+		Name:        "/status",
+		Description: "Set a task's status: todo, in-progress, blocked, or done",
+		Examples:    []string{"/status 1a2b3c4d in-progress"},
 		Params: []Param{
 			{Name: "task_id", Type: ParamTypeString, Description: "The ID of the task", Required: true},
-			{Name: "duration", Type: ParamTypeString, Description: "Duration: 15m, 30m, 1h, 2h, or 4h", Required: true},
+			{Name: "status", Type: ParamTypeString, Description: "Status: todo, in-progress, blocked, or done", Required: true, Enum: statusValues()},
 		},
 		Handler: func(args []string) bool {
 			if len(args) < 2 {
-				fmt.Println("Usage: /duration <task-id> <15m|30m|1h|2h|4h>")
+				fmt.Println(i18n.T("status.usage"))
 				return false
 			}
 
 			taskRef := args[0]
-			durationStr := args[1]
+			statusStr := strings.ToLower(args[1])
 
-			if !storage.IsValidDuration(durationStr) {
-				fmt.Println("Error: Invalid duration. Use 15m, 30m, 1h, 2h, or 4h")
+			if !storage.IsValidStatus(statusStr) {
+				fmt.Println(i18n.T("status.invalid"))
 				return false
 			}
 
 			// Resolve task ID
 			taskID, err := GetStore().ResolveTaskID(taskRef)
 			if err != nil {
-				fmt.Printf("Error: %v\n", err)
+				fmt.Println(i18n.T("error", err))
 				return false
 			}
 
 			// Get task for display
 			task, err := GetStore().GetTask(taskID)
 			if err != nil {
-				fmt.Printf("Error: %v\n", err)
+				fmt.Println(i18n.T("error", err))
 				return false
 			}
+			wasDone := task.Done
+
+			if err := GetStore().SetTaskStatus(taskID, storage.Status(statusStr)); err != nil {
+				fmt.Println(i18n.T("error", err))
+				return false
+			}
+
+			fmt.Println(i18n.T("status.set", task.Name, statusStr))
+			if !wasDone && statusStr == string(storage.StatusDone) {
+				Publish(EventTaskDone, task)
+			}
+			return false
+		},
+	})
 
-			if err := GetStore().SetTaskDuration(taskID, storage.Duration(durationStr)); err != nil {
-				fmt.Printf("Error: %v\n", err)
+	Register(&Command{
+		Name:        "/note",
+		Description: "Set or clear a task's note",
+		Examples:    []string{"/note 1a2b3c4d \"Waiting on the quote from Sam\"", "/note 1a2b3c4d none"},
+		Params: []Param{
+			{Name: "task_id", Type: ParamTypeString, Description: "The ID of the task", Required: true},
+			{Name: "note", Type: ParamTypeString, Description: "The note text, or 'none' to clear", Required: true},
+		},
+		Handler: func(args []string) bool {
+			if len(args) < 2 {
+				fmt.Println(i18n.T("note.usage"))
 				return false
 			}
 
-			fmt.Printf("Set duration for task %s to %s\n", task.Name, durationStr)
+			setNote(args[0], strings.Join(args[1:], " "))
 			return false
 		},
+		ToolHandler: func(args map[string]any) (string, error) {
+			taskRef, err := stringArg(args, "task_id")
+			if err != nil {
+				return "", err
+			}
+			note, err := stringArg(args, "note")
+			if err != nil {
+				return "", err
+			}
+			return captureOutput(func() { setNote(taskRef, note) }), nil
+		},
 	})
 }
+
+// durationValues lists common durations, which /duration completes; any
+// other in minutes or hours is accepted too
+func durationValues() []string {
+	values := make([]string, 0, len(storage.ValidDurations))
+	for _, d := range storage.ValidDurations {
+		values = append(values, d.String())
+	}
+	return values
+}
+
+// priorityValues lists the values /priority accepts, for tool schemas
+func priorityValues() []string {
+	values := make([]string, 0, len(storage.ValidPriorities)+1)
+	for _, p := range storage.ValidPriorities {
+		values = append(values, string(p))
+	}
+	return append(values, "none")
+}
+
+// statusValues lists the values /status accepts, for tool schemas
+func statusValues() []string {
+	values := make([]string, 0, len(storage.ValidStatuses))
+	for _, st := range storage.ValidStatuses {
+		values = append(values, string(st))
+	}
+	return values
+}
+
+// statusMarks are the checkboxes /tasks shows for each status
+var statusMarks = map[storage.Status]string{
+	storage.StatusTodo:       "[ ]",
+	storage.StatusInProgress: "[~]",
+	storage.StatusBlocked:    "[!]",
+	storage.StatusDone:       "[✓]",
+}
+
+// listTasks prints a project's tasks, only those with the given status when
+// it isn't empty, in sortBy order (see sortTasks). With group, it's grouped
+// by when tasks are due. Otherwise, once any task is in progress or blocked,
+// the list is grouped by status in board order; until then it reads as a
+// plain list.
+func listTasks(projectRef, status, sortBy string, group bool) {
+	status = strings.ToLower(status)
+	if status != "" && !storage.IsValidStatus(status) {
+		fmt.Println(i18n.T("status.invalid"))
+		return
+	}
+
+	// Resolve project ID
+	projectID, err := GetStore().ResolveProjectID(projectRef)
+	if err != nil {
+		fmt.Println(i18n.T("error", err))
+		return
+	}
+
+	// Get project info
+	project, err := GetStore().GetProject(projectID)
+	if err != nil {
+		fmt.Println(i18n.T("error", err))
+		return
+	}
+
+	tasks, err := GetStore().ListTasks(projectID)
+	if err != nil {
+		fmt.Println(i18n.T("tasks.list_failed", err))
+		return
+	}
+
+	fmt.Println(i18n.T("tasks.header", project.Name))
+	if len(tasks) == 0 {
+		fmt.Println(i18n.T("tasks.none"))
+		return
+	}
+	sortTasks(tasks, sortBy)
+
+	groups := make(map[storage.Status][]*storage.Task)
+	for _, t := range tasks {
+		st := t.CurrentStatus()
+		if status == "" || st == storage.Status(status) {
+			groups[st] = append(groups[st], t)
+		}
+	}
+	if status != "" {
+		if len(groups[storage.Status(status)]) == 0 {
+			fmt.Println(i18n.T("tasks.no_status", status))
+		}
+		if group {
+			printDueBuckets(groups[storage.Status(status)])
+		} else {
+			printTasks(groups[storage.Status(status)], "  ")
+		}
+	} else if group {
+		printDueBuckets(tasks)
+	} else if len(groups[storage.StatusInProgress]) == 0 && len(groups[storage.StatusBlocked]) == 0 {
+		printTasks(tasks, "  ")
+	} else {
+		for _, st := range storage.ValidStatuses {
+			if len(groups[st]) == 0 {
+				continue
+			}
+			fmt.Printf("  %s (%d):\n", i18n.T("status."+string(st)), len(groups[st]))
+			printTasks(groups[st], "    ")
+		}
+	}
+
+	// Show total duration for incomplete tasks
+	var incompleteTasks []*storage.Task
+	for st, group := range groups {
+		if st != storage.StatusDone {
+			incompleteTasks = append(incompleteTasks, group...)
+		}
+	}
+	totalMinutes := storage.TotalDuration(incompleteTasks)
+	if totalMinutes > 0 {
+		fmt.Printf("\n%s\n", i18n.T("tasks.total", storage.FormatMinutes(totalMinutes)))
+	}
+}
+
+// printTasks prints one line per task for /tasks, each after indent
+func printTasks(tasks []*storage.Task, indent string) {
+	for _, t := range tasks {
+		status := statusMarks[t.CurrentStatus()]
+
+		// Build extra info string
+		var extras []string
+		if t.Priority != "" {
+			extras = append(extras, string(t.Priority))
+		}
+		if t.Context != "" {
+			extras = append(extras, "@"+t.Context)
+		}
+		if t.Duration != 0 {
+			extras = append(extras, t.Duration.String())
+		}
+		if t.DueDate != nil {
+			extras = append(extras, i18n.T("list.due", storage.FormatDue(*t.DueDate)))
+		}
+
+		extraStr := ""
+		if len(extras) > 0 {
+			extraStr = " (" + strings.Join(extras, ", ") + ")"
+		}
+
+		tagStr := formatTags(t) + blockedMark(t)
+		fmt.Println(indent + taskLine(t, status, storage.DisplayName(t.Name)+extraStr+tagStr))
+	}
+}
+
+// createTask adds a task to a project, named by words that may carry inline
+// metadata (quoted words with spaces are kept as typed)
+func createTask(projectRef string, words []string) {
+	projectID, err := GetStore().ResolveProjectID(projectRef)
+	if err != nil {
+		fmt.Println(i18n.T("error", err))
+		return
+	}
+	addTask(projectID, words)
+}
+
+// addTask creates a task in a project, or in the inbox when projectID is
+// empty, from words that may carry inline metadata
+func addTask(projectID string, words []string) {
+	now := time.Now()
+	task, err := storage.ParseInlineWords(words, now)
+	if err != nil {
+		fmt.Println(i18n.T("error", err))
+		return
+	}
+	task.ProjectID = projectID
+	if err := applyProjectDefaults(task, now); err != nil {
+		fmt.Println(i18n.T("error", err))
+		return
+	}
+
+	task, err = GetStore().CreateTaskFrom(task)
+	if err != nil {
+		fmt.Println(i18n.T("task.create_failed", err))
+		return
+	}
+
+	shortID := task.ID
+	if len(task.ID) > 8 {
+		shortID = task.ID[:8]
+	}
+	fmt.Println(i18n.T("task.created", task.Name, shortID))
+	Publish(EventTaskCreated, task)
+	offerDueSuggestion(task)
+}
+
+// setNote sets a task's note, or clears it when note is "none"
+func setNote(taskRef, note string) {
+	if note == "none" {
+		note = ""
+	}
+
+	taskID, err := GetStore().ResolveTaskID(taskRef)
+	if err != nil {
+		fmt.Println(i18n.T("error", err))
+		return
+	}
+
+	// Get task for display
+	task, err := GetStore().GetTask(taskID)
+	if err != nil {
+		fmt.Println(i18n.T("error", err))
+		return
+	}
+
+	if err := GetStore().SetTaskNote(taskID, note); err != nil {
+		fmt.Println(i18n.T("error", err))
+		return
+	}
+
+	if note == "" {
+		fmt.Println(i18n.T("note.cleared", task.Name))
+	} else {
+		fmt.Println(i18n.T("note.set", task.Name))
+	}
+}
@@ -1,12 +1,20 @@
 package commands
 
 import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
 	"os"
 	"path/filepath"
 	"regexp"
 	"strings"
 	"testing"
+	"time"
 
+	"twooms/config"
+	"twooms/server"
 	"twooms/storage"
 )
 
@@ -98,7 +106,7 @@ func TestProjectCommands(t *testing.T) {
 
 	// Delete first project using shortcut
 	output = captureCommandOutput(t, "/delproject "+shortcut1)
-	if !strings.Contains(output, "Deleted project: My Test Project") {
+	if !strings.Contains(output, "Moved project My Test Project and its tasks to the trash") {
 		t.Errorf("Expected deletion message, got: %s", output)
 	}
 
@@ -159,7 +167,7 @@ func TestTaskCommands(t *testing.T) {
 
 	// Delete task
 	output = captureCommandOutput(t, "/deltask "+taskID)
-	if !strings.Contains(output, "Deleted task: Buy groceries") {
+	if !strings.Contains(output, "Moved task to the trash: Buy groceries") {
 		t.Errorf("Expected deletion message, got: %s", output)
 	}
 
@@ -239,7 +247,7 @@ func TestDurationCommand(t *testing.T) {
 	taskID := extractTaskID(output)
 
 	// Test all valid durations
-	validDurations := []string{"15m", "30m", "1h", "2h", "4h"}
+	validDurations := []string{"15m", "30m", "1h", "2h", "4h", "45m", "3h", "1h30m"}
 	for _, dur := range validDurations {
 		output := captureCommandOutput(t, "/duration "+taskID+" "+dur)
 		if !strings.Contains(output, "Set duration for task Quick task to "+dur) {
@@ -265,7 +273,7 @@ func TestDurationInvalid(t *testing.T) {
 	taskID := extractTaskID(output)
 
 	// Try invalid durations
-	invalidDurations := []string{"10m", "45m", "3h", "1d", "invalid"}
+	invalidDurations := []string{"0m", "1h60m", "101h", "1d", "invalid"}
 	for _, dur := range invalidDurations {
 		output := captureCommandOutput(t, "/duration "+taskID+" "+dur)
 		if !strings.Contains(output, "Invalid duration") {
@@ -384,6 +392,61 @@ func TestDeleteProjectDeletesTasks(t *testing.T) {
 	}
 }
 
+func TestNameReferences(t *testing.T) {
+	cleanup := setupTestStore(t)
+	defer cleanup()
+
+	groceries := extractShortcut(captureCommandOutput(t, "/project Groceries"))
+	captureCommandOutput(t, "/project Growth Plan")
+	captureCommandOutput(t, "/task "+groceries+" Buy oat milk")
+	captureCommandOutput(t, "/task "+groceries+" Buy bread")
+
+	if output := captureCommandOutput(t, "/tasks grocer"); !strings.Contains(output, "Groceries") || !strings.Contains(output, "Buy oat milk") {
+		t.Errorf("Expected part of the name to pick Groceries, got: %s", output)
+	}
+	if output := captureCommandOutput(t, "/tasks gro"); !strings.Contains(output, "ambiguous project name") || !strings.Contains(output, "Growth Plan") {
+		t.Errorf("Expected the candidates listed, got: %s", output)
+	}
+	if output := captureCommandOutput(t, "/done milk"); !strings.Contains(output, "Buy oat milk") {
+		t.Errorf("Expected part of a task name to pick the task, got: %s", output)
+	}
+	if output := captureCommandOutput(t, "/done buy"); !strings.Contains(output, "Buy bread") {
+		t.Errorf("Expected the open task picked over the done one, got: %s", output)
+	}
+}
+
+func TestReorderCommands(t *testing.T) {
+	cleanup := setupTestStore(t)
+	defer cleanup()
+
+	work := extractShortcut(captureCommandOutput(t, "/project Work"))
+	first := extractTaskID(captureCommandOutput(t, "/task "+work+" Write report"))
+	captureCommandOutput(t, "/task "+work+" Review PR")
+	last := extractTaskID(captureCommandOutput(t, "/task "+work+" Send invoice"))
+
+	if output := captureCommandOutput(t, "/moveup "+first); !strings.Contains(output, "already at the top") {
+		t.Errorf("Expected the first task to stay put, got: %s", output)
+	}
+	if output := captureCommandOutput(t, "/reorder "+last+" 1"); !strings.Contains(output, "Moved Send invoice to number 1 of 3") {
+		t.Errorf("Expected the task moved to the top, got: %s", output)
+	}
+	if output := captureCommandOutput(t, "/movedown "+first); !strings.Contains(output, "number 3 of 3") {
+		t.Errorf("Expected the task moved down one, got: %s", output)
+	}
+
+	output := captureCommandOutput(t, "/tasks "+work)
+	invoice, review, report := strings.Index(output, "Send invoice"), strings.Index(output, "Review PR"), strings.Index(output, "Write report")
+	if invoice < 0 || !(invoice < review && review < report) {
+		t.Errorf("Expected /tasks in the new order, got: %s", output)
+	}
+	if output := captureCommandOutput(t, "/tasks "+work+" --sort created"); strings.Index(output, "Write report") > strings.Index(output, "Send invoice") {
+		t.Errorf("Expected --sort created to ignore the manual order, got: %s", output)
+	}
+	if output := captureCommandOutput(t, "/reorder "+first+" top"); !strings.Contains(output, "Error") {
+		t.Errorf("Expected an error for a position that isn't a number, got: %s", output)
+	}
+}
+
 func TestEmptyProjectTaskList(t *testing.T) {
 	cleanup := setupTestStore(t)
 	defer cleanup()
@@ -399,6 +462,67 @@ func TestEmptyProjectTaskList(t *testing.T) {
 	}
 }
 
+func TestTasksSortAndGroup(t *testing.T) {
+	cleanup := setupTestStore(t)
+	defer cleanup()
+
+	configPath := filepath.Join(t.TempDir(), "config.json")
+	c, _ := config.Load(configPath)
+	SetConfig(c)
+	defer SetConfig(&config.Config{})
+
+	shortcut := extractShortcut(captureCommandOutput(t, "/project Sorting"))
+	now := time.Now()
+	captureCommandOutput(t, "/task "+shortcut+" Zebra !low due:"+now.AddDate(0, 0, 60).Format("2006-01-02"))
+	captureCommandOutput(t, "/task "+shortcut+" apple")
+	captureCommandOutput(t, "/task "+shortcut+" Mango !urgent due:"+now.Format("2006-01-02"))
+	captureCommandOutput(t, "/task "+shortcut+" Kiwi due:"+now.AddDate(0, 0, -3).Format("2006-01-02"))
+
+	order := func(output string, names ...string) bool {
+		last := -1
+		for _, name := range names {
+			i := strings.Index(output, name)
+			if i < last {
+				return false
+			}
+			last = i
+		}
+		return true
+	}
+
+	if output := captureCommandOutput(t, "/tasks "+shortcut); !order(output, "Zebra", "apple", "Mango", "Kiwi") {
+		t.Errorf("Expected insertion order by default, got: %s", output)
+	}
+	if output := captureCommandOutput(t, "/tasks "+shortcut+" --sort due"); !order(output, "Kiwi", "Mango", "Zebra", "apple") {
+		t.Errorf("Expected due order, undated last, got: %s", output)
+	}
+	if output := captureCommandOutput(t, "/tasks "+shortcut+" --sort=priority"); !order(output, "Mango", "Zebra", "Kiwi", "apple") {
+		t.Errorf("Expected priority order, got: %s", output)
+	}
+	if output := captureCommandOutput(t, "/tasks "+shortcut+" --sort name"); !order(output, "apple", "Kiwi", "Mango", "Zebra") {
+		t.Errorf("Expected name order ignoring case, got: %s", output)
+	}
+	if output := captureCommandOutput(t, "/tasks "+shortcut+" --sort size"); !strings.Contains(output, `Error: unknown sort "size"`) {
+		t.Errorf("Expected unknown sort error, got: %s", output)
+	}
+
+	output := captureCommandOutput(t, "/tasks "+shortcut+" --group")
+	if !order(output, "Overdue (1):", "Kiwi", "Today (1):", "Mango", "Later (1):", "Zebra", "No due date (1):", "apple") {
+		t.Errorf("Expected due buckets, got: %s", output)
+	}
+
+	// The saved order is the default from then on
+	if output := captureCommandOutput(t, "/tasks --sort name --save"); !strings.Contains(output, "sorts by name by default (saved)") {
+		t.Errorf("Expected sort saved, got: %s", output)
+	}
+	if data, _ := os.ReadFile(configPath); !strings.Contains(string(data), `"tasks_sort": "name"`) {
+		t.Errorf("Expected sort in config, got: %s", data)
+	}
+	if output := captureCommandOutput(t, "/tasks "+shortcut); !order(output, "apple", "Kiwi", "Mango", "Zebra") {
+		t.Errorf("Expected saved name order, got: %s", output)
+	}
+}
+
 func TestNoProjectsMessage(t *testing.T) {
 	// Use a completely fresh temp directory
 	tmpDir, err := os.MkdirTemp("", "twooms-test-*")
@@ -540,3 +664,2262 @@ func TestUUIDPrefixMatching(t *testing.T) {
 		t.Errorf("Expected task not found with 5-char prefix, got: %s", output)
 	}
 }
+
+func TestTagCommands(t *testing.T) {
+	cleanup := setupTestStore(t)
+	defer cleanup()
+
+	// Setup: two projects with one task each
+	output := captureCommandOutput(t, "/project Home")
+	home := extractShortcut(output)
+	output = captureCommandOutput(t, "/project Work")
+	work := extractShortcut(output)
+	output = captureCommandOutput(t, "/task "+home+" Buy milk")
+	milkID := extractTaskID(output)
+	output = captureCommandOutput(t, "/task "+work+" Pick up dry cleaning")
+	cleaningID := extractTaskID(output)
+
+	// Tags are normalized (leading '#' stripped, lowercased)
+	output = captureCommandOutput(t, "/tag "+milkID+" #Errands")
+	if !strings.Contains(output, "Tagged task Buy milk with #errands") {
+		t.Errorf("Expected tag message, got: %s", output)
+	}
+	captureCommandOutput(t, "/tag "+cleaningID+" errands")
+
+	// Tags show up in /tasks
+	output = captureCommandOutput(t, "/tasks "+home)
+	if !strings.Contains(output, "Buy milk #errands") {
+		t.Errorf("Expected tag in task list, got: %s", output)
+	}
+
+	// /tagged lists across projects
+	output = captureCommandOutput(t, "/tagged #errands")
+	if !strings.Contains(output, "Buy milk") || !strings.Contains(output, "Pick up dry cleaning") {
+		t.Errorf("Expected both tasks in tagged list, got: %s", output)
+	}
+
+	// Untag removes it from the listing
+	output = captureCommandOutput(t, "/untag "+milkID+" errands")
+	if !strings.Contains(output, "Removed #errands from task Buy milk") {
+		t.Errorf("Expected untag message, got: %s", output)
+	}
+	output = captureCommandOutput(t, "/tagged errands")
+	if strings.Contains(output, "Buy milk") {
+		t.Errorf("Untagged task should not appear, got: %s", output)
+	}
+
+	// Invalid tags are rejected
+	output = captureCommandOutput(t, "/tag "+milkID+" bad!tag")
+	if !strings.Contains(output, "invalid tag") {
+		t.Errorf("Expected invalid tag error, got: %s", output)
+	}
+}
+
+func TestProjectDueCommand(t *testing.T) {
+	cleanup := setupTestStore(t)
+	defer cleanup()
+
+	output := captureCommandOutput(t, "/project Launch")
+	shortcut := extractShortcut(output)
+
+	// Due in 2 days with 16h of open work (more than 3 days x 4h) is at risk
+	due := time.Now().AddDate(0, 0, 2).Format("2006-01-02")
+	output = captureCommandOutput(t, "/projectdue "+shortcut+" "+due)
+	if !strings.Contains(output, "Set due date for project Launch to "+due) {
+		t.Errorf("Expected project due date message, got: %s", output)
+	}
+	for i := 0; i < 4; i++ {
+		output = captureCommandOutput(t, "/task "+shortcut+" Big task")
+		captureCommandOutput(t, "/duration "+extractTaskID(output)+" 4h")
+	}
+
+	output = captureCommandOutput(t, "/projects")
+	if !strings.Contains(output, "due "+due+", 2 days left") {
+		t.Errorf("Expected countdown in project list, got: %s", output)
+	}
+	if !strings.Contains(output, "at risk: 16h open") {
+		t.Errorf("Expected at-risk indicator, got: %s", output)
+	}
+
+	// The chat snapshot includes the deadline without color codes
+	SetColorTerminal(true)
+	defer SetColorTerminal(false)
+	snapshot := getStoreSnapshot()
+	if !strings.Contains(snapshot, "Launch (4 open tasks) - due "+due) || strings.Contains(snapshot, "\033[") {
+		t.Errorf("Expected plain project due info in snapshot, got: %s", snapshot)
+	}
+
+	// Clearing removes the countdown
+	captureCommandOutput(t, "/projectdue "+shortcut+" none")
+	output = captureCommandOutput(t, "/projects")
+	if strings.Contains(output, "days left") {
+		t.Errorf("Expected no countdown after clearing, got: %s", output)
+	}
+}
+
+func TestProjectSetCommand(t *testing.T) {
+	cleanup := setupTestStore(t)
+	defer cleanup()
+
+	shortcut := extractShortcut(captureCommandOutput(t, "/project Work"))
+
+	output := captureCommandOutput(t, "/projectset "+shortcut+" duration 30m")
+	if !strings.Contains(output, "New tasks in Work now take 30m") {
+		t.Errorf("Expected default duration message, got: %s", output)
+	}
+	output = captureCommandOutput(t, "/projectset "+shortcut+" due +7d")
+	week := time.Now().AddDate(0, 0, 7).Format("2006-01-02")
+	if !strings.Contains(output, "are now due +7d") || !strings.Contains(output, week) {
+		t.Errorf("Expected default due message, got: %s", output)
+	}
+	for _, bad := range []string{"duration 3d", "due someday", "color red"} {
+		output = captureCommandOutput(t, "/projectset "+shortcut+" "+bad)
+		if !strings.Contains(output, "Error") && !strings.Contains(output, "Usage") {
+			t.Errorf("Expected /projectset %s to fail, got: %s", bad, output)
+		}
+	}
+
+	output = captureCommandOutput(t, "/projects")
+	if !strings.Contains(output, "defaults 30m, due +7d") {
+		t.Errorf("Expected defaults in project list, got: %s", output)
+	}
+
+	// New tasks get the defaults, but keep what they give inline
+	plainID := extractTaskID(captureCommandOutput(t, "/task "+shortcut+" Plain"))
+	tomorrow := time.Now().AddDate(0, 0, 1).Format("2006-01-02")
+	ownID := extractTaskID(captureCommandOutput(t, "/task "+shortcut+" Own ~2h due:"+tomorrow))
+	plainID, _ = GetStore().ResolveTaskID(plainID)
+	ownID, _ = GetStore().ResolveTaskID(ownID)
+	plain, _ := GetStore().GetTask(plainID)
+	if plain.Duration != 30 || plain.DueDate == nil || storage.FormatDue(*plain.DueDate) != week {
+		t.Errorf("Expected 30m due %s, got %s due %v", week, plain.Duration, plain.DueDate)
+	}
+	own, _ := GetStore().GetTask(ownID)
+	if own.Duration != storage.Duration2h || own.DueDate == nil || storage.FormatDue(*own.DueDate) != tomorrow {
+		t.Errorf("Expected 2h due %s, got %s due %v", tomorrow, own.Duration, own.DueDate)
+	}
+
+	// Clearing one default leaves the other
+	captureCommandOutput(t, "/projectset "+shortcut+" due none")
+	output = captureCommandOutput(t, "/projectset "+shortcut)
+	if !strings.Contains(output, "duration: 30m") || !strings.Contains(output, "due: none") {
+		t.Errorf("Expected only the duration default left, got: %s", output)
+	}
+	laterID := extractTaskID(captureCommandOutput(t, "/task "+shortcut+" Later"))
+	laterID, _ = GetStore().ResolveTaskID(laterID)
+	if later, _ := GetStore().GetTask(laterID); later.DueDate != nil || later.Duration != 30 {
+		t.Errorf("Expected 30m and no due date, got %s due %v", later.Duration, later.DueDate)
+	}
+}
+
+func TestTaskSelections(t *testing.T) {
+	cleanup := setupTestStore(t)
+	defer cleanup()
+
+	work := extractShortcut(captureCommandOutput(t, "/project Work"))
+	home := extractShortcut(captureCommandOutput(t, "/project Home"))
+	past := time.Now().AddDate(0, 0, -3).Format("2006-01-02")
+	reportID := extractTaskID(captureCommandOutput(t, "/task "+work+" Report due:"+past))
+	slidesID := extractTaskID(captureCommandOutput(t, "/task "+work+" Slides due:"+past))
+	emailID := extractTaskID(captureCommandOutput(t, "/task "+work+" Email"))
+	lawnID := extractTaskID(captureCommandOutput(t, "/task "+home+" Mow lawn due:"+past))
+
+	// Several IDs are one change
+	output := captureCommandOutput(t, "/done "+reportID+" "+emailID)
+	if !strings.Contains(output, "Marked 2 tasks as done") || !strings.Contains(output, "Report (Work)") {
+		t.Errorf("Expected 2 tasks marked done, got: %s", output)
+	}
+	captureCommandOutput(t, "/undo")
+	if output := captureCommandOutput(t, "/tasks "+work); strings.Contains(output, "✓") {
+		t.Errorf("Expected one /undo to reopen both, got: %s", output)
+	}
+
+	// Filters: bare words are projects, and only matching tasks change
+	output = captureCommandOutput(t, "/due --project "+work+" --overdue +2d")
+	if !strings.Contains(output, "Set the due dates of 2 tasks") || strings.Contains(output, "Mow lawn") {
+		t.Errorf("Expected the two overdue Work tasks moved, got: %s", output)
+	}
+	inTwoDays := time.Now().AddDate(0, 0, 2).Format("2006-01-02")
+	slidesFull, _ := GetStore().ResolveTaskID(slidesID)
+	if slides, _ := GetStore().GetTask(slidesFull); slides.DueDate == nil || storage.FormatDue(*slides.DueDate) != inTwoDays {
+		t.Errorf("Expected Slides due %s, got %v", inTwoDays, slides.DueDate)
+	}
+	lawnFull, _ := GetStore().ResolveTaskID(lawnID)
+	if lawn, _ := GetStore().GetTask(lawnFull); lawn.DueDate == nil || storage.FormatDue(*lawn.DueDate) != past {
+		t.Errorf("Expected Mow lawn left alone, got %v", lawn.DueDate)
+	}
+	output = captureCommandOutput(t, "/due "+reportID+" "+slidesID+" none")
+	if !strings.Contains(output, "Cleared the due dates of 2 tasks") {
+		t.Errorf("Expected due dates cleared, got: %s", output)
+	}
+
+	captureCommandOutput(t, "/done "+emailID)
+	output = captureCommandOutput(t, "/deltask --done "+work)
+	if !strings.Contains(output, "Moved 1 tasks to the trash") || !strings.Contains(output, "Email") || strings.Contains(output, "Report") {
+		t.Errorf("Expected only the completed Work task deleted, got: %s", output)
+	}
+
+	for _, bad := range []string{"/done --late", "/done --tag", "/undone --done --open"} {
+		if output := captureCommandOutput(t, bad); !strings.Contains(output, "Error") {
+			t.Errorf("Expected %s to fail, got: %s", bad, output)
+		}
+	}
+	if output := captureCommandOutput(t, "/done --overdue --tag nothing"); !strings.Contains(output, "No tasks match.") {
+		t.Errorf("Expected no match, got: %s", output)
+	}
+
+	// Under /confirm always a batch asks once, and outside the REPL needs --yes
+	GetConfig().Confirm = confirmAlways
+	defer func() { GetConfig().Confirm = "" }()
+	if output := captureCommandOutput(t, "/done "+reportID+" "+slidesID); !strings.Contains(output, "Run again with --yes") {
+		t.Errorf("Expected the batch to need --yes, got: %s", output)
+	}
+	if output := captureCommandOutput(t, "/done "+reportID+" "+slidesID+" --yes"); !strings.Contains(output, "Marked 2 tasks as done") {
+		t.Errorf("Expected the batch to run with --yes, got: %s", output)
+	}
+	if output := captureCommandOutput(t, "/undone "+reportID); !strings.Contains(output, "Marked task Report as not done") {
+		t.Errorf("Expected a single task not to ask, got: %s", output)
+	}
+}
+
+func TestDepsCommand(t *testing.T) {
+	cleanup := setupTestStore(t)
+	defer cleanup()
+
+	output := captureCommandOutput(t, "/project Launch")
+	shortcut := extractShortcut(output)
+
+	apiID := extractTaskID(captureCommandOutput(t, "/task "+shortcut+" Finalize API"))
+	docsID := extractTaskID(captureCommandOutput(t, "/task "+shortcut+" Write docs"))
+	shipID := extractTaskID(captureCommandOutput(t, "/task "+shortcut+" Ship"))
+	captureCommandOutput(t, "/task "+shortcut+" Loose end")
+
+	output = captureCommandOutput(t, "/blocks "+docsID+" "+apiID)
+	if !strings.Contains(output, "Task Write docs is now blocked by Finalize API") {
+		t.Errorf("Expected blocked message, got: %s", output)
+	}
+	captureCommandOutput(t, "/blocks "+shipID+" "+docsID)
+
+	// Cycles are rejected
+	output = captureCommandOutput(t, "/blocks "+apiID+" "+shipID)
+	if !strings.Contains(output, "dependency cycle") {
+		t.Errorf("Expected cycle error, got: %s", output)
+	}
+
+	output = captureCommandOutput(t, "/deps "+shortcut)
+	if !strings.Contains(output, "Critical path (*): Finalize API → Write docs → Ship") {
+		t.Errorf("Expected critical path, got: %s", output)
+	}
+	if !strings.Contains(output, "1 tasks without dependencies") {
+		t.Errorf("Expected independent task count, got: %s", output)
+	}
+
+	output = captureCommandOutput(t, "/deps "+shortcut+" --dot")
+	if !strings.Contains(output, "digraph \"Launch\"") {
+		t.Errorf("Expected DOT graph, got: %s", output)
+	}
+	if !strings.Contains(output, "\""+apiID+"\" -> \""+docsID+"\" [color=red, penwidth=2];") {
+		t.Errorf("Expected critical edge in DOT output, got: %s", output)
+	}
+
+	// Listings mark tasks still waiting on open blockers
+	output = captureCommandOutput(t, "/tasks "+shortcut)
+	if !strings.Contains(output, "Write docs [blocked by "+apiID+"]") || strings.Contains(output, "Finalize API [blocked") {
+		t.Errorf("Expected blocked marker on docs only, got: %s", output)
+	}
+
+	// /ready leaves out blocked tasks
+	output = captureCommandOutput(t, "/ready")
+	if !strings.Contains(output, "Launch (2):") || !strings.Contains(output, "Finalize API") || strings.Contains(output, "Write docs") {
+		t.Errorf("Expected only unblocked tasks ready, got: %s", output)
+	}
+
+	// Finishing a blocked task warns, and finishing its blocker unblocks the next
+	output = captureCommandOutput(t, "/done "+shipID)
+	if !strings.Contains(output, "Note: it was blocked by tasks that are still open: Write docs") {
+		t.Errorf("Expected a warning about open blockers, got: %s", output)
+	}
+	captureCommandOutput(t, "/undone "+shipID)
+	captureCommandOutput(t, "/done "+apiID)
+	output = captureCommandOutput(t, "/ready "+shortcut)
+	if !strings.Contains(output, "Write docs") || strings.Contains(output, "Ship") {
+		t.Errorf("Expected docs ready once the API is done, got: %s", output)
+	}
+}
+
+func TestSearchCommand(t *testing.T) {
+	cleanup := setupTestStore(t)
+	defer cleanup()
+
+	output := captureCommandOutput(t, "/project Finance")
+	finance := extractShortcut(output)
+	output = captureCommandOutput(t, "/project Home")
+	home := extractShortcut(output)
+
+	taxID := extractTaskID(captureCommandOutput(t, "/task "+finance+" File tax return"))
+	captureCommandOutput(t, "/due "+taxID+" 2030-04-15")
+	captureCommandOutput(t, "/task "+home+" Fix the faucet")
+
+	// Substring match shows project, due date, and ID
+	output = captureCommandOutput(t, "/search tax")
+	if !strings.Contains(output, "[ ] ["+taxID+"] File tax return (Finance, due 2030-04-15)") {
+		t.Errorf("Expected tax task in results, got: %s", output)
+	}
+	if strings.Contains(output, "faucet") {
+		t.Errorf("Unrelated task should not match, got: %s", output)
+	}
+
+	// Fuzzy match on abbreviated words
+	output = captureCommandOutput(t, "/search tx rtrn")
+	if !strings.Contains(output, "File tax return") {
+		t.Errorf("Expected fuzzy match, got: %s", output)
+	}
+
+	// Project names are searched too
+	output = captureCommandOutput(t, "/search fin")
+	if !strings.Contains(output, "Projects:") || !strings.Contains(output, "Finance") {
+		t.Errorf("Expected project match, got: %s", output)
+	}
+
+	output = captureCommandOutput(t, "/search zebra")
+	if !strings.Contains(output, "No tasks or projects match") {
+		t.Errorf("Expected no results message, got: %s", output)
+	}
+}
+
+func TestRules(t *testing.T) {
+	cleanup := setupTestStore(t)
+	defer cleanup()
+
+	var hooked []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			Event string        `json:"event"`
+			Task  *storage.Task `json:"task"`
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		hooked = append(hooked, body.Event+":"+body.Task.Name)
+	}))
+	defer server.Close()
+
+	tmpDir := t.TempDir()
+	rulesPath := filepath.Join(tmpDir, "rules.json")
+	statePath := filepath.Join(tmpDir, "rules.state.json")
+	os.WriteFile(rulesPath, []byte(`[
+		{"name": "Billable", "on": "task_created", "project": "Client A", "set_duration": "1h", "add_tags": ["billable"]},
+		{"name": "Late", "on": "due_passed", "webhook": "`+server.URL+`"}
+	]`), 0644)
+	if err := LoadRules(rulesPath, statePath); err != nil {
+		t.Fatalf("Failed to load rules: %v", err)
+	}
+	defer LoadRules("", "")
+
+	clientA := extractShortcut(captureCommandOutput(t, "/project Client A"))
+	other := extractShortcut(captureCommandOutput(t, "/project Other"))
+
+	// Creating a task in the matching project applies the actions
+	output := captureCommandOutput(t, "/task "+clientA+" Kickoff call")
+	if !strings.Contains(output, "Rule Billable applied to task Kickoff call") {
+		t.Errorf("Expected rule to apply, got: %s", output)
+	}
+	taskID, _ := GetStore().ResolveTaskID(extractTaskID(output))
+	task, _ := GetStore().GetTask(taskID)
+	if task.Duration != storage.Duration1h || !task.HasTag("billable") {
+		t.Errorf("Expected 1h and #billable, got %s %v", task.Duration, task.Tags)
+	}
+
+	// Other projects are left alone
+	output = captureCommandOutput(t, "/task "+other+" Groceries")
+	if strings.Contains(output, "Rule Billable") {
+		t.Errorf("Rule should not apply to other projects, got: %s", output)
+	}
+
+	// Overdue tasks notify the webhook once per due date
+	captureCommandOutput(t, "/due "+extractTaskID(output)+" 2020-01-01")
+	captureOutput(CheckDueRules)
+	captureOutput(CheckDueRules)
+	if len(hooked) != 1 || hooked[0] != "due_passed:Groceries" {
+		t.Errorf("Expected one due_passed webhook, got %v", hooked)
+	}
+
+	output = captureCommandOutput(t, "/rules")
+	if !strings.Contains(output, "Billable: on task_created in project Client A -> duration 1h, tag #billable") {
+		t.Errorf("Expected rule listing, got: %s", output)
+	}
+
+	// Invalid rules are rejected on load
+	os.WriteFile(rulesPath, []byte(`[{"name": "Bad", "on": "sometime"}]`), 0644)
+	if err := LoadRules(rulesPath, statePath); err == nil {
+		t.Error("Expected error for unknown event")
+	}
+}
+
+func TestHooks(t *testing.T) {
+	cleanup := setupTestStore(t)
+	defer cleanup()
+
+	var posted []string
+	var auth string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		posted = append(posted, string(body))
+		auth = r.Header.Get("Authorization")
+	}))
+	defer server.Close()
+
+	out := filepath.Join(t.TempDir(), "hook.out")
+	t.Setenv("HOOK_TOKEN", "secret")
+	SetConfig(&config.Config{Hooks: []config.HookConfig{
+		{Name: "slack", On: "task_done", URL: server.URL, Headers: map[string]string{"Authorization": "Bearer $HOOK_TOKEN"},
+			Payload: `{"text": {{json (printf "Done: %s (%s)" .Task.Name .Project)}}}`},
+		{On: "task_created", Command: `printf '%s|' "$TWOOMS_EVENT" "$TWOOMS_TASK_NAME" >> ` + out + `; cat >> ` + out},
+		{Name: "broken", On: "someday", URL: server.URL},
+	}})
+	defer SetConfig(&config.Config{})
+
+	if errs := CheckHooks(); len(errs) != 1 || !strings.Contains(errs[0].Error(), `hook "broken": unknown event "someday"`) {
+		t.Errorf("Expected the broken hook reported, got %v", errs)
+	}
+
+	work := extractShortcut(captureCommandOutput(t, "/project Work"))
+	taskID := extractTaskID(captureCommandOutput(t, "/task "+work+` 'Say "hi"'`))
+	data, _ := os.ReadFile(out)
+	if !strings.HasPrefix(string(data), `task_created|Say "hi"|{"event":"task_created"`) || !strings.Contains(string(data), `"project":"Work"`) {
+		t.Errorf("Expected the command to get the event in its environment and JSON on stdin, got %s", data)
+	}
+
+	captureCommandOutput(t, "/done "+taskID)
+	if len(posted) != 1 || posted[0] != `{"text": "Done: Say \"hi\" (Work)"}` || auth != "Bearer secret" {
+		t.Errorf("Expected the templated Slack payload with the header, got %v (auth %q)", posted, auth)
+	}
+
+	output := captureCommandOutput(t, "/hooks")
+	for _, want := range []string{"slack: on task_done -> post to " + server.URL, "hook 2: on task_created -> run printf", "broken: on someday", "(unknown event"} {
+		if !strings.Contains(output, want) {
+			t.Errorf("Expected %q in the hook list, got:\n%s", want, output)
+		}
+	}
+	output = captureCommandOutput(t, "/hooks test Slack "+taskID)
+	if !strings.Contains(output, "Hook slack fired for task") || len(posted) != 2 {
+		t.Errorf("Expected a test firing, got: %s", output)
+	}
+	if output := captureCommandOutput(t, "/hooks test broken "+taskID); !strings.Contains(output, "Error: hook \"broken\": unknown event") {
+		t.Errorf("Expected the broken hook to refuse, got: %s", output)
+	}
+}
+
+func TestScripts(t *testing.T) {
+	cleanup := setupTestStore(t)
+	defer cleanup()
+
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "report.star"), []byte(`
+def open_count(args):
+    for p in store.projects():
+        open_tasks = [t for t in store.tasks(p["id"]) if not t["done"]]
+        print("%s: %d open" % (p["name"], len(open_tasks)))
+
+def tag_urgent(event, task):
+    if "urgent" in task["name"].lower():
+        store.set_priority(task["id"], "urgent")
+
+command("opencount", open_count, description = "Count open tasks per project")
+on("task_created", tag_urgent)
+`), 0644)
+	os.WriteFile(filepath.Join(dir, "broken.star"), []byte(`store.delete_task("x")`), 0644)
+
+	errs := LoadScripts(dir)
+	if len(errs) != 1 || !strings.Contains(errs[0].Error(), "broken.star") {
+		t.Errorf("Expected one error for broken.star, got %v", errs)
+	}
+
+	shortcut := extractShortcut(captureCommandOutput(t, "/project Launch"))
+	captureCommandOutput(t, "/task "+shortcut+" Write docs")
+	output := captureCommandOutput(t, "/task "+shortcut+" Urgent fix")
+
+	// Event handlers run on task creation
+	taskID, _ := GetStore().ResolveTaskID(extractTaskID(output))
+	task, _ := GetStore().GetTask(taskID)
+	if task.Priority != storage.PriorityUrgent {
+		t.Errorf("Expected script to set urgent priority, got %q", task.Priority)
+	}
+
+	// Script commands are registered like built-ins
+	output = captureCommandOutput(t, "/opencount")
+	if !strings.Contains(output, "Launch: 2 open") {
+		t.Errorf("Expected script report, got: %s", output)
+	}
+
+	output = captureCommandOutput(t, "/scripts")
+	if !strings.Contains(output, "report.star: commands /opencount; on task_created") {
+		t.Errorf("Expected script listing, got: %s", output)
+	}
+}
+
+func TestShareCommand(t *testing.T) {
+	cleanup := setupTestStore(t)
+	defer cleanup()
+
+	shortcut := extractShortcut(captureCommandOutput(t, "/project Client <Site>"))
+	taskID := extractTaskID(captureCommandOutput(t, "/task "+shortcut+" Draft homepage"))
+	captureCommandOutput(t, "/task "+shortcut+" Launch")
+	captureCommandOutput(t, "/done "+taskID)
+
+	output := captureCommandOutput(t, "/share "+shortcut+" 3")
+	match := regexp.MustCompile(`/share/([a-f0-9]{32})`).FindStringSubmatch(output)
+	if match == nil {
+		t.Fatalf("Expected share URL, got: %s", output)
+	}
+	token := match[1]
+
+	// The share page renders the project as an escaped read-only checklist
+	handler := server.NewHandler(GetStore())
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest("GET", "/share/"+token, nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d", rec.Code)
+	}
+	body := rec.Body.String()
+	if !strings.Contains(body, "Client &lt;Site&gt;") || !strings.Contains(body, "1 of 2 tasks complete") {
+		t.Errorf("Expected project checklist, got: %s", body)
+	}
+
+	// Only GET on share pages is served
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest("POST", "/share/"+token, nil))
+	if rec.Code == http.StatusOK {
+		t.Error("Expected POST to be rejected")
+	}
+
+	output = captureCommandOutput(t, "/share")
+	if !strings.Contains(output, "["+token[:8]+"] Client <Site>") {
+		t.Errorf("Expected active share in list, got: %s", output)
+	}
+
+	// Revoked links 404
+	captureCommandOutput(t, "/unshare "+token[:8])
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest("GET", "/share/"+token, nil))
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("Expected 404 after revoke, got %d", rec.Code)
+	}
+}
+
+// TestShareWhileEditing serves a share page while the REPL edits the
+// project; run with -race to check the handler never reads a task mid-write
+func TestShareWhileEditing(t *testing.T) {
+	cleanup := setupTestStore(t)
+	defer cleanup()
+
+	shortcut := extractShortcut(captureCommandOutput(t, "/project Client"))
+	taskID, _ := GetStore().ResolveTaskID(extractTaskID(captureCommandOutput(t, "/task "+shortcut+" Draft homepage")))
+	projectID, _ := GetStore().ResolveProjectID(shortcut)
+	share, err := GetStore().CreateShare(projectID, time.Hour)
+	if err != nil {
+		t.Fatalf("Failed to create share: %v", err)
+	}
+
+	srv := httptest.NewServer(server.NewHandler(GetStore()))
+	defer srv.Close()
+	stop := make(chan struct{})
+	served := make(chan error)
+	go func() {
+		for {
+			select {
+			case <-stop:
+				served <- nil
+				return
+			default:
+			}
+			resp, err := http.Get(srv.URL + "/share/" + share.Token)
+			if err != nil {
+				served <- err
+				return
+			}
+			io.Copy(io.Discard, resp.Body)
+			resp.Body.Close()
+		}
+	}()
+
+	for i := 0; i < 50; i++ {
+		due := time.Now().AddDate(0, 0, i)
+		GetStore().UpdateTask(taskID, i%2 == 0)
+		GetStore().SetTaskDueDate(taskID, &due)
+		GetStore().SetProjectDueDate(projectID, &due)
+	}
+	close(stop)
+	if err := <-served; err != nil {
+		t.Errorf("Expected the share page served throughout, got %v", err)
+	}
+}
+
+func TestTimeTracking(t *testing.T) {
+	cleanup := setupTestStore(t)
+	defer cleanup()
+
+	shortcut := extractShortcut(captureCommandOutput(t, "/project Work"))
+	firstID := extractTaskID(captureCommandOutput(t, "/task "+shortcut+" Write report"))
+	captureCommandOutput(t, "/duration "+firstID+" 1h")
+	secondID := extractTaskID(captureCommandOutput(t, "/task "+shortcut+" Review PR"))
+
+	output := captureCommandOutput(t, "/stop")
+	if !strings.Contains(output, "no timer is running") {
+		t.Errorf("Expected error with no timer, got: %s", output)
+	}
+
+	output = captureCommandOutput(t, "/start "+firstID)
+	if !strings.Contains(output, "Started timer for Write report") {
+		t.Errorf("Expected timer to start, got: %s", output)
+	}
+
+	// Starting another task stops the first timer
+	output = captureCommandOutput(t, "/start "+secondID)
+	if !strings.Contains(output, "Stopped timer for Write report") || !strings.Contains(output, "Started timer for Review PR") {
+		t.Errorf("Expected timer switch, got: %s", output)
+	}
+
+	output = captureCommandOutput(t, "/timelog "+shortcut)
+	if !strings.Contains(output, "Write report: 0m logged / 1h est (-1h)") {
+		t.Errorf("Expected logged vs estimate, got: %s", output)
+	}
+	if !strings.Contains(output, "Review PR: 0m logged (running)") {
+		t.Errorf("Expected running timer, got: %s", output)
+	}
+
+	output = captureCommandOutput(t, "/stop")
+	if !strings.Contains(output, "Stopped timer for Review PR") {
+		t.Errorf("Expected timer to stop, got: %s", output)
+	}
+	if active, _ := GetStore().ActiveTimer(); active != nil {
+		t.Error("Expected no running timer")
+	}
+}
+
+func TestPlanCommand(t *testing.T) {
+	cleanup := setupTestStore(t)
+	defer cleanup()
+
+	shortcut := extractShortcut(captureCommandOutput(t, "/project Work"))
+	reportID := extractTaskID(captureCommandOutput(t, "/task "+shortcut+" Write report"))
+	captureCommandOutput(t, "/duration "+reportID+" 2h")
+	emailID := extractTaskID(captureCommandOutput(t, "/task "+shortcut+" Answer email"))
+	captureCommandOutput(t, "/duration "+emailID+" 30m")
+	captureCommandOutput(t, "/priority "+emailID+" high")
+
+	output := captureCommandOutput(t, "/plan 1")
+	if !strings.Contains(output, "You have 1h: do this task (30m)") || !strings.Contains(output, "1. ["+emailID+"] Answer email") {
+		t.Errorf("Expected email planned, got: %s", output)
+	}
+	if !strings.Contains(output, "Doesn't fit today:\n  ["+reportID+"] Write report") {
+		t.Errorf("Expected report deferred, got: %s", output)
+	}
+
+	output = captureCommandOutput(t, "/plan 150m "+shortcut)
+	if !strings.Contains(output, "do these 2 tasks (2h 30m)") {
+		t.Errorf("Expected both tasks planned, got: %s", output)
+	}
+
+	output = captureCommandOutput(t, "/plan soon")
+	if !strings.Contains(output, "invalid time budget") {
+		t.Errorf("Expected budget error, got: %s", output)
+	}
+}
+
+func TestDueSuggestion(t *testing.T) {
+	cleanup := setupTestStore(t)
+	defer cleanup()
+
+	shortcut := extractShortcut(captureCommandOutput(t, "/project Work"))
+
+	// Off unless enabled
+	output := captureCommandOutput(t, "/task "+shortcut+" Send invoice tomorrow")
+	if strings.Contains(output, "Suggested due date") {
+		t.Errorf("Expected no suggestion while disabled, got: %s", output)
+	}
+
+	EnableDueSuggestions()
+	defer func() { suggestDue = false }()
+
+	output = captureCommandOutput(t, "/task "+shortcut+" Call bank tomorrow")
+	if !strings.Contains(output, "Suggested due date") || !strings.Contains(output, "Press Enter to accept") {
+		t.Fatalf("Expected suggestion, got: %s", output)
+	}
+	taskID, _ := GetStore().ResolveTaskID(extractTaskID(output))
+
+	output = captureOutput(func() { AcceptSuggestion() })
+	tomorrow := time.Now().AddDate(0, 0, 1).Format("2006-01-02")
+	if !strings.Contains(output, "Set due date for task Call bank tomorrow to "+tomorrow) {
+		t.Errorf("Expected suggestion applied, got: %s", output)
+	}
+	task, _ := GetStore().GetTask(taskID)
+	if task.DueDate == nil || task.DueDate.Format("2006-01-02") != tomorrow {
+		t.Errorf("Expected due date %s, got %v", tomorrow, task.DueDate)
+	}
+
+	// A dismissed suggestion can't be accepted
+	captureCommandOutput(t, "/task "+shortcut+" Pay rent friday")
+	DismissSuggestion()
+	if AcceptSuggestion() {
+		t.Error("Expected no pending suggestion after dismiss")
+	}
+}
+
+func TestInlineMetadata(t *testing.T) {
+	cleanup := setupTestStore(t)
+	defer cleanup()
+
+	shortcut := extractShortcut(captureCommandOutput(t, "/project Work"))
+
+	output := captureCommandOutput(t, "/task "+shortcut+" Ship report !p1 @office due:fri ~2h #q3")
+	if !strings.Contains(output, "Created task: Ship report (ID:") {
+		t.Fatalf("Expected metadata stripped from name, got: %s", output)
+	}
+	taskID, _ := GetStore().ResolveTaskID(extractTaskID(output))
+	task, _ := GetStore().GetTask(taskID)
+	if task.Priority != storage.PriorityUrgent || task.Context != "office" || task.Duration != storage.Duration2h || !task.HasTag("q3") {
+		t.Errorf("Expected parsed metadata, got %+v", task)
+	}
+	if task.DueDate == nil || task.DueDate.Weekday() != time.Friday {
+		t.Errorf("Expected due Friday, got %v", task.DueDate)
+	}
+
+	// A single undo removes the task along with its metadata
+	captureCommandOutput(t, "/undo")
+	if _, err := GetStore().GetTask(taskID); err == nil {
+		t.Error("Expected undo to remove the task")
+	}
+
+	output = captureCommandOutput(t, "/task "+shortcut+" Ship report due:someday")
+	if !strings.Contains(output, "Error: invalid due date") {
+		t.Errorf("Expected due date error, got: %s", output)
+	}
+}
+
+func TestArchiveFile(t *testing.T) {
+	cleanup := setupTestStore(t)
+	defer cleanup()
+
+	shortcut := extractShortcut(captureCommandOutput(t, "/project Client"))
+	captureCommandOutput(t, "/task "+shortcut+" Final invoice")
+	path := filepath.Join(t.TempDir(), "client.json")
+
+	output := captureCommandOutput(t, "/archivefile "+shortcut+" "+path)
+	if !strings.Contains(output, "Archived project Client (1 tasks) to "+path) {
+		t.Fatalf("Expected archive confirmation, got: %s", output)
+	}
+	if projects, _ := GetStore().ListProjects(); len(projects) != 0 {
+		t.Errorf("Expected project removed, got %d projects", len(projects))
+	}
+
+	output = captureCommandOutput(t, "/restorefile "+path)
+	if !strings.Contains(output, "Restored project Client (1 tasks)") {
+		t.Errorf("Expected restore confirmation, got: %s", output)
+	}
+	output = captureCommandOutput(t, "/tasks "+shortcut)
+	if !strings.Contains(output, "Final invoice") {
+		t.Errorf("Expected restored task listed, got: %s", output)
+	}
+
+	output = captureCommandOutput(t, "/restorefile "+path)
+	if !strings.Contains(output, "Error: can't restore project Client") {
+		t.Errorf("Expected duplicate restore refused, got: %s", output)
+	}
+}
+
+func TestArchiveTasks(t *testing.T) {
+	cleanup := setupTestStore(t)
+	defer cleanup()
+
+	shortcut := extractShortcut(captureCommandOutput(t, "/project Work"))
+	first := extractTaskID(captureCommandOutput(t, "/task "+shortcut+" Write report"))
+	second := extractTaskID(captureCommandOutput(t, "/task "+shortcut+" Review PR"))
+	open := extractTaskID(captureCommandOutput(t, "/task "+shortcut+" Plan sprint"))
+	captureCommandOutput(t, "/duration "+first+" 1h")
+	captureCommandOutput(t, "/duration "+open+" 30m")
+
+	output := captureCommandOutput(t, "/archive "+open)
+	if !strings.Contains(output, "Error: task is not done: Plan sprint") {
+		t.Errorf("Expected open task refused, got: %s", output)
+	}
+
+	captureCommandOutput(t, "/done "+first)
+	captureCommandOutput(t, "/done "+second)
+	output = captureCommandOutput(t, "/archive "+first)
+	if !strings.Contains(output, "Archived task Write report") {
+		t.Errorf("Expected archive confirmation, got: %s", output)
+	}
+	output = captureCommandOutput(t, "/archive --done "+shortcut)
+	if !strings.Contains(output, "Archived 1 done tasks in Work") {
+		t.Errorf("Expected bulk archive, got: %s", output)
+	}
+
+	output = captureCommandOutput(t, "/tasks "+shortcut)
+	if strings.Contains(output, "Write report") || strings.Contains(output, "Review PR") || !strings.Contains(output, "Total: 30m") {
+		t.Errorf("Expected archived tasks hidden from list and total, got: %s", output)
+	}
+
+	output = captureCommandOutput(t, "/archived "+shortcut)
+	if !strings.Contains(output, "Write report (1h, archived ") || !strings.Contains(output, "Review PR") {
+		t.Errorf("Expected archived tasks listed, got: %s", output)
+	}
+
+	// Undo restores the bulk archive in one step
+	captureCommandOutput(t, "/undo")
+	output = captureCommandOutput(t, "/tasks "+shortcut)
+	if !strings.Contains(output, "Review PR") || strings.Contains(output, "Write report") {
+		t.Errorf("Expected only the bulk archive undone, got: %s", output)
+	}
+}
+
+func TestComplete(t *testing.T) {
+	cleanup := setupTestStore(t)
+	defer cleanup()
+
+	texts := func(completions []Completion) []string {
+		var out []string
+		for _, c := range completions {
+			out = append(out, c.Text)
+		}
+		return out
+	}
+
+	// Command names
+	got := texts(Complete("/tas"))
+	if strings.Join(got, " ") != "/task /task_get /taskbatch /tasks" {
+		t.Errorf("Expected /task, /task_get, /taskbatch, and /tasks, got %v", got)
+	}
+	if got := Complete("hello"); got != nil {
+		t.Errorf("Expected no completion for chat text, got %v", got)
+	}
+
+	output := captureCommandOutput(t, "/project Work")
+	shortcut := extractShortcut(output)
+	taskID := extractTaskID(captureCommandOutput(t, "/task "+shortcut+" Write report"))
+
+	// Project shortcuts for project_id, including via a shorthand
+	for _, line := range []string{"/tasks ", "/ts " + shortcut[:1], "/task "} {
+		completions := Complete(line)
+		if len(completions) != 1 || completions[0].Text != shortcut || completions[0].Description != "Work" {
+			t.Errorf("%q: expected project %s, got %v", line, shortcut, completions)
+		}
+	}
+
+	// Task IDs with names for task_id
+	for _, line := range []string{"/done " + taskID[:2], "/blocks " + taskID + " ", "/archive "} {
+		completions := Complete(line)
+		if len(completions) != 1 || completions[0].Text != taskID || completions[0].Description != "Write report" {
+			t.Errorf("%q: expected task %s, got %v", line, taskID, completions)
+		}
+	}
+
+	// Free-text arguments aren't completed
+	if got := Complete("/task " + shortcut + " Wr"); got != nil {
+		t.Errorf("Expected no completion for task name, got %v", got)
+	}
+
+	// A quoted argument is one slot, as Execute reads it
+	if got := texts(Complete(`/blocks "Write report" `)); len(got) != 1 || got[0] != taskID {
+		t.Errorf("Expected the blocking task after a quoted task name, got %v", got)
+	}
+
+	// Enum params complete their allowed values
+	got = texts(Complete("/duration " + taskID + " 1"))
+	if len(got) != 2 || got[0] != "15m" || got[1] != "1h" {
+		t.Errorf("Expected 15m and 1h, got %v", got)
+	}
+
+	// Matching is case-sensitive, as readline only appends the rest of a
+	// candidate to what was typed
+	captureCommandOutput(t, "/shortcut "+shortcut+" Work")
+	if got := texts(Complete("/tasks W")); len(got) != 1 || got[0] != "Work" {
+		t.Errorf("Expected Work, got %v", got)
+	}
+	for _, line := range []string{"/TA", "/tasks w", "/duration " + taskID + " 1H"} {
+		if got := Complete(line); got != nil {
+			t.Errorf("%q: expected no completion in another case, got %v", line, got)
+		}
+	}
+}
+
+func TestPlanWeek(t *testing.T) {
+	cleanup := setupTestStore(t)
+	defer cleanup()
+
+	output := captureCommandOutput(t, "/planweek")
+	if !strings.Contains(output, "Error: /planweek is interactive") {
+		t.Errorf("Expected refusal without a line reader, got: %s", output)
+	}
+
+	shortcut := extractShortcut(captureCommandOutput(t, "/project Work"))
+	report := extractTaskID(captureCommandOutput(t, "/task "+shortcut+" Write report"))
+	review := extractTaskID(captureCommandOutput(t, "/task "+shortcut+" Review PR"))
+	email := extractTaskID(captureCommandOutput(t, "/task "+shortcut+" Answer email"))
+	captureCommandOutput(t, "/duration "+report+" 1h")
+	captureCommandOutput(t, "/duration "+review+" 30m")
+
+	// Shortest first: the unestimated email is skipped, the review fills half of
+	// today, so the report is refused there and goes to tomorrow
+	answers := []string{"", "today", "today", "someday", "tomorrow"}
+	SetLineReader(func(prompt string) (string, error) {
+		if len(answers) == 0 {
+			t.Fatal("Wizard asked for more input than expected")
+		}
+		answer := answers[0]
+		answers = answers[1:]
+		return answer, nil
+	})
+	defer SetLineReader(nil)
+
+	output = captureCommandOutput(t, "/planweek 1 "+shortcut)
+	if !strings.Contains(output, "Planning 3 tasks") || !strings.Contains(output, "per day") {
+		t.Errorf("Expected wizard header, got: %s", output)
+	}
+	if !strings.Contains(output, "is full: 30m of 1h left, task needs 1h") {
+		t.Errorf("Expected full day refused, got: %s", output)
+	}
+	if !strings.Contains(output, "invalid due date: someday") {
+		t.Errorf("Expected bad day rejected, got: %s", output)
+	}
+	if !strings.Contains(output, "Set due dates for 2 tasks") {
+		t.Errorf("Expected batched save, got: %s", output)
+	}
+
+	today := time.Now().Format("2006-01-02")
+	tomorrow := time.Now().AddDate(0, 0, 1).Format("2006-01-02")
+	for id, want := range map[string]string{report: tomorrow, review: today, email: ""} {
+		fullID, _ := GetStore().ResolveTaskID(id)
+		task, _ := GetStore().GetTask(fullID)
+		got := ""
+		if task.DueDate != nil {
+			got = task.DueDate.Format("2006-01-02")
+		}
+		if got != want {
+			t.Errorf("%s: expected due %q, got %q", task.Name, want, got)
+		}
+	}
+
+	// One undo reverts the whole batch
+	captureCommandOutput(t, "/undo")
+	for _, id := range []string{report, review} {
+		fullID, _ := GetStore().ResolveTaskID(id)
+		if task, _ := GetStore().GetTask(fullID); task.DueDate != nil {
+			t.Errorf("Expected undo to clear due date of %s", task.Name)
+		}
+	}
+}
+
+func TestLastTask(t *testing.T) {
+	cleanup := setupTestStore(t)
+	defer cleanup()
+
+	lastTaskID = ""
+	if output := captureCommandOutput(t, "/last"); !strings.Contains(output, "No task created this session") {
+		t.Errorf("Expected no last task, got: %s", output)
+	}
+
+	shortcut := extractShortcut(captureCommandOutput(t, "/project Work"))
+	taskID := extractTaskID(captureCommandOutput(t, "/task "+shortcut+" Write report"))
+
+	// Without a line reader, /last only shows the task
+	output := captureCommandOutput(t, "/last")
+	if !strings.Contains(output, "Last task: Write report (ID: "+taskID+") in Work") || strings.Contains(output, "Edit with") {
+		t.Errorf("Expected task shown without editing, got: %s", output)
+	}
+
+	answers := []string{"d tomorrow", "t", "1h", "p high", "n Ask Sam first", "x 1", ""}
+	SetLineReader(func(prompt string) (string, error) {
+		if len(answers) == 0 {
+			t.Fatal("/last asked for more input than expected")
+		}
+		answer := answers[0]
+		answers = answers[1:]
+		return answer, nil
+	})
+	defer SetLineReader(nil)
+
+	output = captureCommandOutput(t, "/l")
+	if !strings.Contains(output, `Unknown field "x"`) {
+		t.Errorf("Expected unknown field rejected, got: %s", output)
+	}
+
+	task, _ := GetStore().ResolveTaskID(taskID)
+	got, _ := GetStore().GetTask(task)
+	tomorrow := dateOnly(time.Now()).AddDate(0, 0, 1)
+	if got.DueDate == nil || !got.DueDate.Equal(tomorrow) {
+		t.Errorf("Expected due tomorrow, got %v", got.DueDate)
+	}
+	if got.Duration != storage.Duration1h || got.Priority != storage.PriorityHigh || got.Note != "Ask Sam first" {
+		t.Errorf("Expected duration, priority, and note set, got %+v", got)
+	}
+}
+
+func TestTaskBatch(t *testing.T) {
+	cleanup := setupTestStore(t)
+	defer cleanup()
+
+	shortcut := extractShortcut(captureCommandOutput(t, "/project Work"))
+	projectID, _ := GetStore().ResolveProjectID(shortcut)
+
+	// A ';'-separated list with inline metadata
+	output := captureCommandOutput(t, "/taskbatch "+shortcut+" Write report ~2h; ; Review PR !p1")
+	if !strings.Contains(output, "Created 2 tasks:") || !strings.Contains(output, "Review PR") {
+		t.Fatalf("Expected 2 tasks created, got: %s", output)
+	}
+	tasks, _ := GetStore().ListTasks(projectID)
+	if len(tasks) != 2 || tasks[0].Name != "Write report" || tasks[0].Duration != storage.Duration2h || tasks[1].Priority != storage.PriorityUrgent {
+		t.Errorf("Expected tasks in order with metadata, got %+v", tasks)
+	}
+	if lastTaskID != tasks[1].ID {
+		t.Errorf("Expected the batch's last task to be /last's")
+	}
+
+	// One undo removes the whole batch
+	if output := captureCommandOutput(t, "/undo"); !strings.Contains(output, "create 2 tasks") {
+		t.Errorf("Expected batch undone as one change, got: %s", output)
+	}
+
+	// A bad entry stops the batch
+	output = captureCommandOutput(t, "/taskbatch "+shortcut+" Pay rent; Call bank due:someday")
+	if !strings.Contains(output, "No tasks created") {
+		t.Errorf("Expected batch rejected, got: %s", output)
+	}
+	if tasks, _ := GetStore().ListTasks(projectID); len(tasks) != 0 {
+		t.Errorf("Expected no tasks after rejected batch, got %d", len(tasks))
+	}
+
+	// One task per line until an empty line
+	output = captureCommandOutput(t, "/taskbatch "+shortcut)
+	if !strings.Contains(output, "Error: give the tasks as a ';'-separated list") {
+		t.Errorf("Expected refusal without a line reader, got: %s", output)
+	}
+	answers := []string{"Pay rent", "Call bank; Email Sam", ""}
+	SetLineReader(func(prompt string) (string, error) {
+		answer := answers[0]
+		answers = answers[1:]
+		return answer, nil
+	})
+	defer SetLineReader(nil)
+	output = captureCommandOutput(t, "/tb "+shortcut)
+	if !strings.Contains(output, "Created 3 tasks:") {
+		t.Errorf("Expected 3 tasks from lines, got: %s", output)
+	}
+}
+
+func TestICSCommand(t *testing.T) {
+	cleanup := setupTestStore(t)
+	defer cleanup()
+
+	work := extractShortcut(captureCommandOutput(t, "/project Work"))
+	home := extractShortcut(captureCommandOutput(t, "/project Home"))
+	captureCommandOutput(t, "/task "+work+" Write report due:2026-03-01")
+	captureCommandOutput(t, "/task "+work+" Someday")
+	captureCommandOutput(t, "/task "+home+" Pay rent due:2026-03-02")
+
+	file := filepath.Join(t.TempDir(), "work.ics")
+	output := captureCommandOutput(t, "/ics "+work+" "+file)
+	if !strings.Contains(output, "Exported 1 tasks with due dates") {
+		t.Fatalf("Expected one task exported, got: %s", output)
+	}
+	data, _ := os.ReadFile(file)
+	if !strings.Contains(string(data), "SUMMARY:Write report") || strings.Contains(string(data), "Pay rent") {
+		t.Errorf("Expected only the project's task, got:\n%s", data)
+	}
+
+	// A lone .ics argument is the file, covering every project
+	output = captureCommandOutput(t, "/ics "+file+" --events")
+	if !strings.Contains(output, "Exported 2 tasks") {
+		t.Errorf("Expected both tasks exported, got: %s", output)
+	}
+	data, _ = os.ReadFile(file)
+	if !strings.Contains(string(data), "BEGIN:VEVENT") {
+		t.Errorf("Expected events, got:\n%s", data)
+	}
+}
+
+func TestNotify(t *testing.T) {
+	cleanup := setupTestStore(t)
+	defer cleanup()
+
+	configPath := filepath.Join(t.TempDir(), "config.json")
+	c, _ := config.Load(configPath)
+	SetConfig(c)
+	defer SetConfig(&config.Config{})
+
+	var titles, bodies []string
+	sendNotification = func(title, body string) error {
+		titles = append(titles, title)
+		bodies = append(bodies, body)
+		return nil
+	}
+	defer func() { sendNotification = desktopNotify }()
+
+	// Nothing due means nothing sent
+	if output := captureCommandOutput(t, "/notify test"); !strings.Contains(output, "Nothing due today") || len(titles) != 0 {
+		t.Errorf("Expected no reminder, got: %s", output)
+	}
+
+	shortcut := extractShortcut(captureCommandOutput(t, "/project Work"))
+	captureCommandOutput(t, "/task "+shortcut+" Pay rent due:today")
+	overdue := extractTaskID(captureCommandOutput(t, "/task "+shortcut+" File taxes"))
+	captureCommandOutput(t, "/due "+overdue+" 2020-01-01")
+	captureCommandOutput(t, "/task "+shortcut+" Plan trip due:"+time.Now().AddDate(0, 0, 3).Format("2006-01-02"))
+
+	output := captureCommandOutput(t, "/notify test")
+	if !strings.Contains(output, "Sent a reminder for 2 tasks") {
+		t.Fatalf("Expected a reminder for 2 tasks, got: %s", output)
+	}
+	if titles[0] != "Twooms: 2 tasks due" || !strings.Contains(bodies[0], "File taxes (overdue)") || strings.Contains(bodies[0], "Plan trip") {
+		t.Errorf("Expected due and overdue tasks in the notification, got %q: %q", titles[0], bodies[0])
+	}
+
+	if output := captureCommandOutput(t, "/notify on 25:00"); !strings.Contains(output, "invalid time") || Notifying() {
+		t.Errorf("Expected invalid time rejected, got: %s", output)
+	}
+	output = captureCommandOutput(t, "/notify on 17:30 8:05")
+	defer stopReminders()
+	if !strings.Contains(output, "Reminders on: tasks due today or overdue at 08:05, 17:30") || !Notifying() {
+		t.Errorf("Expected reminders on, got: %s", output)
+	}
+	if data, _ := os.ReadFile(configPath); !strings.Contains(string(data), `"08:05"`) {
+		t.Errorf("Expected times saved to config, got: %s", data)
+	}
+	if output := captureCommandOutput(t, "/notify off"); !strings.Contains(output, "Reminders off") || Notifying() {
+		t.Errorf("Expected reminders off, got: %s", output)
+	}
+
+	now := time.Date(2026, 5, 4, 12, 0, 0, 0, time.UTC)
+	if got := nextReminder(now, []string{"09:00", "17:30"}); !got.Equal(time.Date(2026, 5, 4, 17, 30, 0, 0, time.UTC)) {
+		t.Errorf("Expected 17:30 today, got %v", got)
+	}
+	if got := nextReminder(now, []string{"09:00", "12:00"}); !got.Equal(time.Date(2026, 5, 5, 9, 0, 0, 0, time.UTC)) {
+		t.Errorf("Expected 09:00 tomorrow, got %v", got)
+	}
+}
+
+// TestRemindersWhileEditing runs the reminder goroutine without pause while
+// the REPL edits the tasks it reads; run with -race
+func TestRemindersWhileEditing(t *testing.T) {
+	cleanup := setupTestStore(t)
+	defer cleanup()
+
+	sent := make(chan string, 1)
+	sendNotification = func(title, body string) error {
+		select {
+		case sent <- body:
+		default:
+		}
+		return nil
+	}
+	untilReminder = func([]string) time.Duration { return time.Millisecond }
+	defer func() {
+		sendNotification = desktopNotify
+		untilReminder = func(times []string) time.Duration { return time.Until(nextReminder(time.Now(), times)) }
+	}()
+
+	shortcut := extractShortcut(captureCommandOutput(t, "/project Work"))
+	taskID, _ := GetStore().ResolveTaskID(extractTaskID(captureCommandOutput(t, "/task "+shortcut+" Pay rent due:today")))
+
+	startReminders([]string{"09:00"})
+	for i := 0; i < 50; i++ {
+		due := time.Now().AddDate(0, 0, -i%3)
+		GetStore().SetTaskDueDate(taskID, &due)
+		GetStore().SetTaskNote(taskID, fmt.Sprintf("edit %d", i))
+	}
+	select {
+	case body := <-sent:
+		if !strings.Contains(body, "Pay rent") {
+			t.Errorf("Expected the due task in the reminder, got %q", body)
+		}
+	case <-time.After(5 * time.Second):
+		t.Error("Expected a reminder while editing")
+	}
+
+	// Once stopped, nothing more goes out
+	stopReminders()
+	select {
+	case <-sent:
+	default:
+	}
+	time.Sleep(20 * time.Millisecond)
+	select {
+	case body := <-sent:
+		t.Errorf("Expected no reminder after stopping, got %q", body)
+	default:
+	}
+}
+
+func TestDigest(t *testing.T) {
+	cleanup := setupTestStore(t)
+	defer cleanup()
+
+	work := extractShortcut(captureCommandOutput(t, "/project Work"))
+	home := extractShortcut(captureCommandOutput(t, "/project Home"))
+	workID, _ := GetStore().ResolveProjectID(work)
+
+	// A Wednesday, so Friday is this week and next Monday is later
+	now := time.Date(2026, 10, 14, 10, 0, 0, 0, time.Local)
+	for _, input := range []string{
+		work + " Write report ~2h due:2026-10-14",
+		work + " Call bank !p1 ~15m due:2026-10-14",
+		work + " File taxes !p2 due:2026-10-01",
+		work + " Review PR ~1h due:2026-10-16",
+		work + " Plan offsite due:2026-10-19",
+		work + " Read book",
+		home + " Pay rent !p1 due:2026-10-16",
+	} {
+		captureCommandOutput(t, "/task "+input)
+	}
+	doneID := extractTaskID(captureCommandOutput(t, "/task "+work+" Sent invoice due:2026-10-14"))
+	captureCommandOutput(t, "/done "+doneID)
+
+	project, _ := GetStore().GetProject(workID)
+	tasks, _ := GetStore().ListTasks(workID)
+	output := captureOutput(func() { printDigest(project, tasks, now) })
+	want := `Digest for Work (2026-10-14): 6 open, 3h 15m
+Overdue (1)
+  - [high] File taxes (due Thu Oct 1)
+Today (2, 2h 15m)
+  - [urgent] Call bank (15m)
+  - Write report (2h)
+This week (1, 1h)
+  - Review PR (1h, due Fri Oct 16)
+Later (1)
+  - Plan offsite (due Mon Oct 19)
+No date (1)
+  - Read book`
+	if output != want {
+		t.Errorf("Expected digest:\n%s\ngot:\n%s", want, output)
+	}
+
+	// Across projects each task names its project
+	all, _ := GetStore().ListAllTasks()
+	output = captureOutput(func() { printDigest(nil, all, now) })
+	if !strings.Contains(output, "Digest for all projects") || !strings.Contains(output, "  - [urgent] Pay rent (due Fri Oct 16, Home)\n  - Review PR (1h, due Fri Oct 16, Work)") {
+		t.Errorf("Expected tasks from every project, got:\n%s", output)
+	}
+
+	if output := captureCommandOutput(t, "/digest nope"); !strings.Contains(output, "Error:") {
+		t.Errorf("Expected unknown project error, got: %s", output)
+	}
+}
+
+func TestStatsCommand(t *testing.T) {
+	cleanup := setupTestStore(t)
+	defer cleanup()
+
+	if output := captureCommandOutput(t, "/stats"); !strings.Contains(output, "No tasks yet") {
+		t.Errorf("Expected no tasks, got: %s", output)
+	}
+
+	shortcut := extractShortcut(captureCommandOutput(t, "/project Work"))
+	captureCommandOutput(t, "/task "+shortcut+" Write report ~2h")
+	captureCommandOutput(t, "/task "+shortcut+" Review PR ~30m")
+	doneID := extractTaskID(captureCommandOutput(t, "/task "+shortcut+" Send invoice"))
+	captureCommandOutput(t, "/done "+doneID)
+	captureCommandOutput(t, "/archive "+doneID)
+
+	output := captureCommandOutput(t, "/stats "+shortcut)
+	for _, want := range []string{
+		"Stats for Work:",
+		"Tasks: 3 (1 done, 33% complete)",
+		"Average time to done: 0 min (over 1 tasks)",
+		"Outstanding: 2h 30m across 2 open tasks",
+		"Week of     Created  Done  Open",
+	} {
+		if !strings.Contains(output, want) {
+			t.Errorf("Expected %q in stats, got:\n%s", want, output)
+		}
+	}
+	lines := strings.Split(output, "\n")
+	if last := lines[len(lines)-1]; !strings.HasSuffix(last, "      3     1     2  "+strings.Repeat("█", statsBarWidth)) {
+		t.Errorf("Expected this week's row with a full bar, got %q", last)
+	}
+}
+
+func TestBackup(t *testing.T) {
+	cleanup := setupTestStore(t)
+	defer cleanup()
+
+	dir := filepath.Join(t.TempDir(), "backups")
+	SetBackupDir(dir)
+	defer SetBackupDir("")
+	SetConfig(&config.Config{Backup: &config.BackupConfig{Keep: 2, Targets: []config.BackupTarget{
+		{Name: "nas", Type: "scp", Dest: "nas:backups/"},
+		{Name: "b2", Type: "s3", Dest: "s3://bucket/twooms", Endpoint: "https://s3.example.com"},
+		{Name: "typo", Type: "ftp", Dest: "ftp://host"},
+	}}})
+	defer SetConfig(&config.Config{})
+
+	// The fake tool "uploads" into remote and "downloads" from it
+	remote := t.TempDir()
+	var calls [][]string
+	realTool := runBackupTool
+	defer func() { runBackupTool = realTool }()
+	runBackupTool = func(args []string) error {
+		calls = append(calls, args)
+		src, dst := args[len(args)-2], args[len(args)-1]
+		if args[0] == "aws" {
+			src, dst = args[len(args)-4], args[len(args)-3]
+		}
+		if strings.HasPrefix(dst, dir) {
+			data, err := os.ReadFile(filepath.Join(remote, filepath.Base(src)))
+			if err != nil {
+				return err
+			}
+			return os.WriteFile(dst, data, 0600)
+		}
+		data, _ := os.ReadFile(src)
+		return os.WriteFile(filepath.Join(remote, filepath.Base(dst)), data, 0600)
+	}
+
+	captureCommandOutput(t, "/project Work")
+	output := captureCommandOutput(t, "/backup now")
+	if !strings.Contains(output, "Backed up to "+dir) || !strings.Contains(output, "Copied to nas") || !strings.Contains(output, `Error copying to typo: unknown backup target type "ftp"`) {
+		t.Fatalf("Expected backup copied to the working targets, got: %s", output)
+	}
+	backups, _ := storage.ListBackups(dir)
+	name := backups[0].Name
+	if want := []string{"scp", "-q", "-B", filepath.Join(dir, name), "nas:backups/" + name}; strings.Join(calls[0], " ") != strings.Join(want, " ") {
+		t.Errorf("Expected %v, got %v", want, calls[0])
+	}
+	if want := []string{"aws", "s3", "cp", "--only-show-errors", filepath.Join(dir, name), "s3://bucket/twooms/" + name, "--endpoint-url", "https://s3.example.com"}; strings.Join(calls[1], " ") != strings.Join(want, " ") {
+		t.Errorf("Expected %v, got %v", want, calls[1])
+	}
+
+	// Only the newest Keep backups stay
+	for _, stamp := range []string{"20240101-090000", "20240102-090000"} {
+		os.WriteFile(filepath.Join(dir, "twooms-"+stamp+".json"), []byte("{}"), 0600)
+	}
+	captureCommandOutput(t, "/backup now")
+	if backups, _ := storage.ListBackups(dir); len(backups) != 2 || backups[1].Stamp() != "20240102-090000" {
+		t.Errorf("Expected the 2 newest backups kept, got %v", backups)
+	}
+	if backupDue(24*time.Hour, time.Now()) {
+		t.Error("Expected no backup due right after one")
+	}
+
+	// Restoring from a target downloads the backup and imports it
+	os.Remove(filepath.Join(dir, name))
+	cleanup2 := setupTestStore(t)
+	defer cleanup2()
+	output = captureCommandOutput(t, "/backup restore "+name+" nas")
+	if !strings.Contains(output, "Downloaded "+name+" from nas") || !strings.Contains(output, "Imported 1 projects and 0 tasks") {
+		t.Errorf("Expected restore from nas, got: %s", output)
+	}
+	if output := captureCommandOutput(t, "/backup restore ../x.json"); !strings.Contains(output, "Error: give a backup name") {
+		t.Errorf("Expected path rejected, got: %s", output)
+	}
+}
+
+func TestRestore(t *testing.T) {
+	cleanup := setupTestStore(t)
+	defer cleanup()
+
+	dir := filepath.Join(t.TempDir(), "backups")
+	SetBackupDir(dir)
+	defer SetBackupDir("")
+	SetStore(autoBackupStore(GetStore(), dir))
+
+	shortcut := extractShortcut(captureCommandOutput(t, "/project Work"))
+	captureCommandOutput(t, "/task "+shortcut+" Write report")
+	storage.WriteBackup(GetStore(), dir, false, 0, time.Date(2024, 1, 1, 9, 0, 0, 0, time.Local))
+	captureCommandOutput(t, "/task "+shortcut+" Review PR")
+	captureCommandOutput(t, "/in Call bank")
+
+	output := captureCommandOutput(t, "/backups")
+	if !strings.Contains(output, "20240101-090000  Mon 2024-01-01 09:00:00") || !strings.Contains(output, "(automatic)") {
+		t.Errorf("Expected manual and automatic backups listed, got: %s", output)
+	}
+	if output := captureCommandOutput(t, "/backup list"); strings.Contains(output, "(automatic)") || !strings.Contains(output, "automatic backups from before changes; /backups lists them") {
+		t.Errorf("Expected automatic backups only counted, got: %s", output)
+	}
+
+	output = captureCommandOutput(t, "/restore 20240101-090000")
+	if !strings.Contains(output, "Rolled back to 20240101-090000: 0 added, 0 changed, 2 deleted") {
+		t.Fatalf("Expected the new tasks rolled back, got: %s", output)
+	}
+	if tasks, _ := GetStore().ListAllTasks(); len(tasks) != 1 || tasks[0].Name != "Write report" {
+		t.Errorf("Expected only the backed up task left, got %v", tasks)
+	}
+
+	// The roll back itself can be undone
+	stamp := regexp.MustCompile(`/restore (\S+) puts it back`).FindStringSubmatch(output)
+	if stamp == nil {
+		t.Fatalf("Expected the backup from before the roll back named, got: %s", output)
+	}
+	captureCommandOutput(t, "/restore "+stamp[1])
+	if tasks, _ := GetStore().ListAllTasks(); len(tasks) != 3 {
+		t.Errorf("Expected the 3 tasks back, got %d", len(tasks))
+	}
+
+	if output := captureCommandOutput(t, "/restore 20200101-090000"); !strings.Contains(output, "Error: no backup 20200101-090000") {
+		t.Errorf("Expected unknown backup rejected, got: %s", output)
+	}
+}
+
+func TestTrashCommands(t *testing.T) {
+	cleanup := setupTestStore(t)
+	defer cleanup()
+	SetConfig(&config.Config{})
+	defer SetConfig(&config.Config{})
+
+	shortcut := extractShortcut(captureCommandOutput(t, "/project Work"))
+	taskID := extractTaskID(captureCommandOutput(t, "/task "+shortcut+" Write report"))
+	captureCommandOutput(t, "/task "+shortcut+" Review PR")
+
+	if output := captureCommandOutput(t, "/trash"); !strings.Contains(output, "The trash is empty") {
+		t.Errorf("Expected an empty trash, got: %s", output)
+	}
+
+	captureCommandOutput(t, "/deltask "+taskID)
+	captureCommandOutput(t, "/delproject "+shortcut)
+	output := captureCommandOutput(t, "/trash")
+	if !strings.Contains(output, "kept for 30 days") || !strings.Contains(output, "Work (1 tasks)") ||
+		!strings.Contains(output, "Write report (project deleted; restores to the inbox)") {
+		t.Errorf("Expected the project and the task listed, got: %s", output)
+	}
+
+	output = captureCommandOutput(t, "/restore "+taskID)
+	if !strings.Contains(output, "Restored task Write report to Inbox") {
+		t.Errorf("Expected the task restored to the inbox, got: %s", output)
+	}
+	if output := captureCommandOutput(t, "/restore "+taskID); !strings.Contains(output, "not in the trash") {
+		t.Errorf("Expected the restored task gone from the trash, got: %s", output)
+	}
+
+	// Startup purges what is older than trash_days; a negative value keeps it
+	GetConfig().TrashDays = -1
+	PurgeTrash(time.Now().AddDate(1, 0, 0))
+	if items, _ := GetStore().ListTrash(); len(items) != 1 {
+		t.Errorf("Expected the project kept, got %v", items)
+	}
+	GetConfig().TrashDays = 7
+	PurgeTrash(time.Now().AddDate(0, 0, 8))
+	if output := captureCommandOutput(t, "/trash"); !strings.Contains(output, "The trash is empty") {
+		t.Errorf("Expected the project purged after 7 days, got: %s", output)
+	}
+
+	captureCommandOutput(t, "/deltask "+taskID)
+	if output := captureCommandOutput(t, "/trash empty --yes"); !strings.Contains(output, "Emptied the trash (1 items)") {
+		t.Errorf("Expected the trash emptied, got: %s", output)
+	}
+}
+
+func TestCapacity(t *testing.T) {
+	cleanup := setupTestStore(t)
+	defer cleanup()
+
+	shortcut := extractShortcut(captureCommandOutput(t, "/project Work"))
+	now := time.Date(2026, 10, 14, 10, 0, 0, 0, time.Local)
+	for _, input := range []string{
+		"Write report ~2h due:2026-10-14",
+		"Review PR ~1h due:2026-10-15",
+		"Call bank due:2026-10-15",
+		"Plan offsite ~4h due:2026-10-16",
+		"Read book",
+	} {
+		captureCommandOutput(t, "/task "+shortcut+" "+input)
+	}
+
+	tasks, _ := GetStore().ListAllTasks()
+	output := captureOutput(func() { printCapacity(storage.NewWeekPlan(tasks, 0, now)) })
+	want := `Capacity for the next 7 days (4h per day; unestimated tasks count as 30m):
+  Wed 2026-10-14 (today): 2h due, 2h free
+  Thu 2026-10-15 (tomorrow): 1h 30m due, 2h 30m free
+  Fri 2026-10-16: 4h due, 0m free
+  Sat 2026-10-17: 0m due, 4h free
+  Sun 2026-10-18: 0m due, 4h free
+  Mon 2026-10-19: 0m due, 4h free
+  Tue 2026-10-20: 0m due, 4h free
+Least loaded: Sat 2026-10-17`
+	if output != want {
+		t.Errorf("Expected capacity:\n%s\ngot:\n%s", want, output)
+	}
+
+	if output := captureCommandOutput(t, "/capacity 6"); !strings.Contains(output, "(6h per day;") {
+		t.Errorf("Expected a 6h capacity, got: %s", output)
+	}
+	if output := captureCommandOutput(t, "/capacity soon"); !strings.Contains(output, "Error: invalid time budget") {
+		t.Errorf("Expected invalid budget error, got: %s", output)
+	}
+}
+
+func TestAgenda(t *testing.T) {
+	cleanup := setupTestStore(t)
+	defer cleanup()
+	SetConfig(&config.Config{DailyCapacity: "3h"})
+	defer SetConfig(&config.Config{})
+
+	work := extractShortcut(captureCommandOutput(t, "/project Work"))
+	home := extractShortcut(captureCommandOutput(t, "/project Home"))
+	today := dateOnly(time.Now())
+	day := func(offset int) string { return today.AddDate(0, 0, offset).Format("2006-01-02") }
+	captureCommandOutput(t, "/task "+work+" File taxes due:2020-01-01")
+	captureCommandOutput(t, "/task "+work+" Write report ~2h due:"+day(0))
+	captureCommandOutput(t, "/task "+work+" Review PR ~2h due:"+day(1))
+	captureCommandOutput(t, "/task "+home+" Fix sink ~90m due:"+day(1))
+	captureCommandOutput(t, "/task "+work+" Plan offsite due:"+day(9))
+
+	output := captureCommandOutput(t, "/agenda")
+	tomorrow := today.AddDate(0, 0, 1).Format("Mon Jan 2")
+	for _, want := range []string{
+		"(3h per day; unestimated tasks count as 30m)",
+		"Overdue (1):\n  [ ] [",
+		"File taxes (due 2020-01-01, Work)",
+		today.Format("Mon Jan 2") + " (today): 2h / 3h",
+		tomorrow + " (tomorrow): 3h 30m / 3h, over by 30m",
+		"Fix sink (1h30m, Home)",
+		"Over capacity: " + today.AddDate(0, 0, 1).Format("Mon"),
+	} {
+		if !strings.Contains(output, want) {
+			t.Errorf("Expected %q in the agenda, got:\n%s", want, output)
+		}
+	}
+	if strings.Contains(output, "Plan offsite") || strings.Count(output, "Nothing due") != 5 {
+		t.Errorf("Expected only this week's tasks, got:\n%s", output)
+	}
+
+	// A project's agenda still counts the other projects' work
+	output = captureCommandOutput(t, "/agenda week "+home)
+	if strings.Contains(output, "Review PR") || !strings.Contains(output, "over by 30m") || !strings.Contains(output, "in Home") {
+		t.Errorf("Expected Home's tasks against the whole day's load, got:\n%s", output)
+	}
+	if output := captureCommandOutput(t, "/agenda "+home); !strings.Contains(output, "Fix sink") || strings.Contains(output, "Review PR") {
+		t.Errorf("Expected a lone project argument to filter, got:\n%s", output)
+	}
+
+	output = captureCommandOutput(t, "/agenda next-week")
+	if !strings.Contains(output, "Plan offsite (Work)") || strings.Contains(output, "File taxes") || strings.Contains(output, "Write report") {
+		t.Errorf("Expected next week's tasks only, got:\n%s", output)
+	}
+	if output := captureCommandOutput(t, "/agenda later "+work); !strings.Contains(output, "Usage: /agenda") {
+		t.Errorf("Expected usage, got: %s", output)
+	}
+}
+
+func TestShowTask(t *testing.T) {
+	cleanup := setupTestStore(t)
+	defer cleanup()
+
+	work := extractShortcut(captureCommandOutput(t, "/project Work"))
+	auditID := extractTaskID(captureCommandOutput(t, "/task "+work+" Security audit ~2h due:2030-03-14 #compliance"))
+	reportID := extractTaskID(captureCommandOutput(t, "/task "+work+" Write report"))
+	captureCommandOutput(t, "/priority "+auditID+" high")
+	captureCommandOutput(t, "/note "+auditID+" Ask Sam for the checklist")
+	captureCommandOutput(t, "/blocks "+reportID+" "+auditID)
+	captureCommandOutput(t, "/start "+auditID)
+
+	output := captureCommandOutput(t, "/show "+auditID)
+	for _, want := range []string{
+		"Security audit\n",
+		"Project:     Work [" + work + "]",
+		"Status:      todo",
+		"Priority:    high",
+		"Due:         2030-03-14",
+		"Duration:    2h",
+		"Tags:        #compliance",
+		"Blocks:      [" + reportID + "] Write report",
+		"(timer running)",
+		"  Note:\n    Ask Sam for the checklist",
+	} {
+		if !strings.Contains(output, want) {
+			t.Errorf("Expected %q in /show, got:\n%s", want, output)
+		}
+	}
+	if strings.Contains(output, "Blocked by") || strings.Contains(output, "Completed") {
+		t.Errorf("Expected empty fields to be skipped, got:\n%s", output)
+	}
+
+	output = captureCommandOutput(t, "/task_get "+reportID)
+	var details taskDetails
+	if err := json.Unmarshal([]byte(output), &details); err != nil {
+		t.Fatalf("Expected JSON from task_get, got %v:\n%s", err, output)
+	}
+	if details.Name != "Write report" || details.Project != "Work" || details.Status != "todo" ||
+		len(details.BlockedBy) != 1 || details.BlockedBy[0].Name != "Security audit" {
+		t.Errorf("Unexpected task_get details: %+v", details)
+	}
+
+	if output := captureCommandOutput(t, "/show nope"); !strings.Contains(output, "Error:") {
+		t.Errorf("Expected an error for an unknown task, got: %s", output)
+	}
+}
+
+func TestReorg(t *testing.T) {
+	cleanup := setupTestStore(t)
+	defer cleanup()
+
+	work := extractShortcut(captureCommandOutput(t, "/project Work"))
+	misc := extractShortcut(captureCommandOutput(t, "/project Misc"))
+	home := extractShortcut(captureCommandOutput(t, "/project Home"))
+	report := extractTaskID(captureCommandOutput(t, "/task "+misc+" Write report"))
+	captureCommandOutput(t, "/task "+misc+" Buy stamps")
+	lawn := extractTaskID(captureCommandOutput(t, "/task "+work+" Mow lawn #chores"))
+	lawnID, _ := GetStore().ResolveTaskID(lawn)
+	reportID, _ := GetStore().ResolveTaskID(report)
+
+	path := filepath.Join(t.TempDir(), "reorg.txt")
+	os.WriteFile(path, []byte("# cleanup\nmerge "+misc+" "+home+"\nmove "+work+" "+report+"\nmove "+home+" #chores\nshortcut "+home+" house\n"), 0644)
+
+	// Without --yes outside the REPL it only previews
+	output := captureCommandOutput(t, "/reorg "+path)
+	for _, want := range []string{
+		"  Merge Misc (2 tasks) into Home, deleting Misc",
+		`  Move "Mow lawn" from Work to Home`,
+		`  Move "Write report" from Misc to Work`,
+		"  Shortcut of Home: " + home + " -> house",
+		"Run again with --yes to apply.",
+	} {
+		if !strings.Contains(output, want) {
+			t.Errorf("Expected %q in preview, got: %s", want, output)
+		}
+	}
+	if _, err := GetStore().ResolveProjectID(misc); err != nil {
+		t.Error("Expected preview to change nothing")
+	}
+
+	output = captureCommandOutput(t, "/reorg "+path+" --yes")
+	if !strings.Contains(output, "Reorganized.") {
+		t.Fatalf("Expected reorg applied, got: %s", output)
+	}
+	homeID, err := GetStore().ResolveProjectID("house")
+	if err != nil {
+		t.Fatalf("Expected new shortcut: %v", err)
+	}
+	if tasks, _ := GetStore().ListTasks(homeID); len(tasks) != 2 {
+		t.Errorf("Expected stamps and lawn in Home, got %d tasks", len(tasks))
+	}
+	if task, _ := GetStore().GetTask(lawnID); task.ProjectID != homeID {
+		t.Error("Expected tagged task moved to Home")
+	}
+	if _, err := GetStore().ResolveProjectID(misc); err == nil {
+		t.Error("Expected Misc deleted")
+	}
+	captureCommandOutput(t, "/undo")
+	if _, err := GetStore().ResolveProjectID(misc); err != nil {
+		t.Error("Expected one undo to bring Misc back")
+	}
+
+	// Typed instructions, with a bad one rejected and the rest applied
+	answers := []string{"merge " + misc + " " + misc, "move " + work + " " + report, "", "y"}
+	SetLineReader(func(prompt string) (string, error) {
+		if len(answers) == 0 {
+			t.Fatal("Asked for more input than expected")
+		}
+		answer := answers[0]
+		answers = answers[1:]
+		return answer, nil
+	})
+	defer SetLineReader(nil)
+
+	output = captureCommandOutput(t, "/reorg")
+	if !strings.Contains(output, "can't merge a project into itself") || !strings.Contains(output, "Reorganized.") {
+		t.Errorf("Expected bad line rejected and reorg applied, got: %s", output)
+	}
+	workID, _ := GetStore().ResolveProjectID(work)
+	if task, _ := GetStore().GetTask(reportID); task.ProjectID != workID {
+		t.Error("Expected report moved to Work")
+	}
+
+	os.WriteFile(path, []byte("shortcut "+work+" today\n"), 0644)
+	if output := captureCommandOutput(t, "/reorg "+path+" --yes"); !strings.Contains(output, "reserved word") || !strings.Contains(output, "Nothing changed.") {
+		t.Errorf("Expected reserved shortcut rejected, got: %s", output)
+	}
+	if output := captureCommandOutput(t, "/reorg "+path+" extra"); !strings.Contains(output, "Usage:") {
+		t.Errorf("Expected usage, got: %s", output)
+	}
+}
+
+func TestReview(t *testing.T) {
+	cleanup := setupTestStore(t)
+	defer cleanup()
+
+	shortcut := extractShortcut(captureCommandOutput(t, "/project Work"))
+	projectID, _ := GetStore().ResolveProjectID(shortcut)
+	overdue := extractTaskID(captureCommandOutput(t, "/task "+shortcut+" File taxes due:2020-01-01"))
+	captureCommandOutput(t, "/task "+shortcut+" Read book")
+	captureCommandOutput(t, "/task "+shortcut+" Plan offsite due:"+time.Now().AddDate(0, 0, 3).Format("2006-01-02"))
+	old := &storage.Task{ID: "0ld00000-task", ProjectID: projectID, Name: "Clean garage", CreatedAt: time.Now().AddDate(0, 0, -30)}
+	if err := GetStore().ImportTask(old); err != nil {
+		t.Fatal(err)
+	}
+
+	answers := []string{"d", "f someday", "f 2030-01-01", "huh", ""}
+	SetLineReader(func(prompt string) (string, error) {
+		if len(answers) == 0 {
+			t.Fatal("Review asked for more input than expected")
+		}
+		answer := answers[0]
+		answers = answers[1:]
+		return answer, nil
+	})
+	defer SetLineReader(nil)
+
+	output := captureCommandOutput(t, "/review")
+	for _, want := range []string{
+		"== Work: 1 overdue, 1 stale, 1 without due dates ==",
+		"[Overdue] File taxes (ID: " + overdue + "; due 2020-01-01, edited today)",
+		"[Stale] Clean garage (ID: 0ld00000; last edited 30 days ago)",
+		"[No due date] Read book",
+		"invalid due date: someday",
+		"Use d (done)",
+		"Reviewed 3 tasks: 1 done, 1 deferred, 0 deleted, 1 skipped.",
+	} {
+		if !strings.Contains(output, want) {
+			t.Errorf("Expected %q in review, got: %s", want, output)
+		}
+	}
+	if strings.Contains(output, "Plan offsite") {
+		t.Error("Expected a recently added task with a due date left out")
+	}
+	if task, _ := GetStore().GetTask(old.ID); task.DueDate == nil || task.DueDate.Format("2006-01-02") != "2030-01-01" || task.UpdatedAt == nil {
+		t.Errorf("Expected stale task deferred and marked edited, got %+v", task)
+	}
+
+	// Deferring made the task active again, so a second review skips it
+	answers = []string{"q"}
+	output = captureCommandOutput(t, "/review 1 "+shortcut)
+	if strings.Contains(output, "Clean garage") || !strings.Contains(output, "Reviewed 0 tasks") {
+		t.Errorf("Expected review stopped at the first task, got: %s", output)
+	}
+
+	SetLineReader(nil)
+	if output := captureCommandOutput(t, "/review"); !strings.Contains(output, "only runs in the REPL") {
+		t.Errorf("Expected REPL-only error, got: %s", output)
+	}
+}
+
+func TestStoreSizeWarning(t *testing.T) {
+	cleanup := setupTestStore(t)
+	defer cleanup()
+	SetConfig(&config.Config{StoreWarnMB: 1})
+	defer SetConfig(&config.Config{})
+
+	shortcut := extractShortcut(captureCommandOutput(t, "/project Work"))
+	captureCommandOutput(t, "/task "+shortcut+" Write report")
+
+	// Under the limit, nothing is said or offered
+	if output := captureOutput(WarnStoreSize); output != "" {
+		t.Errorf("Expected no warning for a small store, got: %s", output)
+	}
+
+	// Long notes, and their copies in the undo history, push it over
+	taskID := extractTaskID(captureCommandOutput(t, "/task "+shortcut+" Read papers"))
+	fullID, _ := GetStore().ResolveTaskID(taskID)
+	for i := 0; i < 4; i++ {
+		note := strings.Repeat(string(rune('a'+i)), 300<<10)
+		if err := GetStore().SetTaskNote(fullID, note); err != nil {
+			t.Fatalf("SetTaskNote failed: %v", err)
+		}
+	}
+
+	output := captureOutput(WarnStoreSize)
+	if !strings.Contains(output, "over the 1.0 MB warning size") || !strings.Contains(output, "/compact drops the undo history") {
+		t.Fatalf("Expected size warning, got: %s", output)
+	}
+	if !strings.Contains(output, "Press Enter to compact now.") {
+		t.Errorf("Expected compaction offered with nothing old to archive, got: %s", output)
+	}
+
+	output = captureOutput(func() { AcceptSuggestion() })
+	if !strings.Contains(output, "Dropped 7 undo entries") {
+		t.Errorf("Expected compaction on Enter, got: %s", output)
+	}
+	if output := captureCommandOutput(t, "/undo"); !strings.Contains(output, "nothing to undo") {
+		t.Errorf("Expected undo history gone, got: %s", output)
+	}
+	if size, _ := GetStore().Size(); size >= StoreWarnSize() {
+		t.Errorf("Expected the store under the limit after compaction, got %d bytes", size)
+	}
+
+	// /compact confirms first, or needs --yes outside the REPL
+	SetLineReader(nil)
+	captureCommandOutput(t, "/done "+taskID)
+	output = captureCommandOutput(t, "/compact 30")
+	if !strings.Contains(output, "Move 0 tasks completed more than 30 days ago") || !strings.Contains(output, "Run again with --yes") {
+		t.Errorf("Expected preview without applying, got: %s", output)
+	}
+	output = captureCommandOutput(t, "/compact --yes")
+	if !strings.Contains(output, "Dropped 1 undo entries") {
+		t.Errorf("Expected compaction with --yes, got: %s", output)
+	}
+
+	// The warning can be turned off
+	GetConfig().StoreWarnMB = -1
+	if StoreWarnSize() != 0 {
+		t.Errorf("Expected no warning size when off, got %d", StoreWarnSize())
+	}
+}
+
+func TestCalendarPlan(t *testing.T) {
+	cleanup := setupTestStore(t)
+	defer cleanup()
+
+	shortcut := extractShortcut(captureCommandOutput(t, "/project Work"))
+	captureCommandOutput(t, "/task "+shortcut+" Ship release ~1h due:today")
+	captureCommandOutput(t, "/task "+shortcut+" Tidy backlog ~15m")
+
+	// A meeting that fills the rest of the day leaves no room for the due task
+	now := time.Now()
+	if now.Hour() == 23 && now.Minute() >= 50 {
+		t.Skip("too close to midnight to plan the rest of the day")
+	}
+	stamp := func(t time.Time) string { return t.UTC().Format("20060102T150405Z") }
+	ics := strings.Join([]string{
+		"BEGIN:VCALENDAR", "BEGIN:VEVENT", "UID:offsite", "SUMMARY:Offsite",
+		"DTSTART:" + stamp(now.Add(-time.Hour)), "DTEND:" + stamp(now.Add(25*time.Hour)),
+		"END:VEVENT", "END:VCALENDAR",
+	}, "\r\n")
+	icsPath := filepath.Join(t.TempDir(), "work.ics")
+	os.WriteFile(icsPath, []byte(ics), 0644)
+
+	SetConfig(&config.Config{Calendar: &config.CalendarConfig{URL: icsPath, DayStart: "00:00", DayEnd: "23:59"}})
+	defer SetConfig(&config.Config{})
+
+	output := captureCommandOutput(t, "/plan")
+	if !strings.Contains(output, "Nothing fits") || !strings.Contains(output, "(Offsite)") {
+		t.Errorf("Expected no free time around the offsite, got: %s", output)
+	}
+	if !strings.Contains(output, "Conflicts: due, but no free time") || !strings.Contains(output, "Ship release") {
+		t.Errorf("Expected the due task as a conflict, got: %s", output)
+	}
+	if !strings.Contains(output, "Doesn't fit today:") || !strings.Contains(output, "Tidy backlog") {
+		t.Errorf("Expected the undated task deferred, got: %s", output)
+	}
+
+	// CalDAV is asked for the day's events, with recurring ones expanded
+	var method, depth, body string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		method, depth = r.Method, r.Header.Get("Depth")
+		data, _ := io.ReadAll(r.Body)
+		body = string(data)
+		if user, pass, _ := r.BasicAuth(); user != "me" || pass != "secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.WriteHeader(http.StatusMultiStatus)
+		fmt.Fprint(w, `<?xml version="1.0"?><d:multistatus xmlns:d="DAV:" xmlns:cal="urn:ietf:params:xml:ns:caldav"><d:response><d:propstat><d:prop><cal:calendar-data>`+
+			strings.ReplaceAll(ics, "Offsite", "Board meeting")+`</cal:calendar-data></d:prop></d:propstat></d:response></d:multistatus>`)
+	}))
+	defer server.Close()
+	t.Setenv("TWOOMS_TEST_CALDAV_PASSWORD", "secret")
+	GetConfig().Calendar = &config.CalendarConfig{URL: server.URL, Type: "caldav", Username: "me", PasswordEnv: "TWOOMS_TEST_CALDAV_PASSWORD", DayStart: "00:00", DayEnd: "23:59"}
+
+	output = captureCommandOutput(t, "/plan 2 "+shortcut)
+	if method != "REPORT" || depth != "1" || !strings.Contains(body, "<C:expand") || !strings.Contains(body, "<C:time-range") {
+		t.Errorf("Expected a calendar-query REPORT, got %s (Depth %s): %s", method, depth, body)
+	}
+	if !strings.Contains(output, "(Board meeting)") {
+		t.Errorf("Expected the CalDAV event in the plan, got: %s", output)
+	}
+
+	t.Setenv("TWOOMS_TEST_CALDAV_PASSWORD", "wrong")
+	if output := captureCommandOutput(t, "/plan"); !strings.Contains(output, "Error reading the calendar") || !strings.Contains(output, "401") {
+		t.Errorf("Expected the auth failure reported, got: %s", output)
+	}
+}
+
+func TestQuotedArgs(t *testing.T) {
+	cleanup := setupTestStore(t)
+	defer cleanup()
+
+	output := captureCommandOutput(t, `/project "Home Renovation"`)
+	if !strings.Contains(output, "Home Renovation") {
+		t.Fatalf("Expected project created, got: %s", output)
+	}
+
+	// A quoted project name works where an ID does, and a quoted task name
+	// keeps words that look like metadata
+	output = captureCommandOutput(t, `/task 'home renovation' "Fix bug #12: crash" !p1`)
+	if !strings.Contains(output, "Created task: Fix bug #12: crash") {
+		t.Fatalf("Expected the quoted name kept, got: %s", output)
+	}
+	taskID, _ := GetStore().ResolveTaskID(extractTaskID(output))
+	task, _ := GetStore().GetTask(taskID)
+	if task.Priority != storage.PriorityUrgent || len(task.Tags) != 0 {
+		t.Errorf("Expected priority from !p1 and no tags, got %+v", task)
+	}
+
+	captureCommandOutput(t, `/note `+taskID+` 'Ask "Sam" first'`)
+	if task, _ := GetStore().GetTask(taskID); task.Note != `Ask "Sam" first` {
+		t.Errorf("Expected the note with its inner quotes, got %q", task.Note)
+	}
+
+	output = captureCommandOutput(t, `/tasks "Home Renovation"`)
+	if !strings.Contains(output, "Fix bug #12: crash") {
+		t.Errorf("Expected the task listed by project name, got: %s", output)
+	}
+
+	// Apostrophes are just text
+	output = captureCommandOutput(t, `/task "Home Renovation" Don't forget Bob's keys`)
+	if !strings.Contains(output, "Created task: Don't forget Bob's keys") {
+		t.Errorf("Expected apostrophes kept, got: %s", output)
+	}
+}
+
+func TestMoveTask(t *testing.T) {
+	cleanup := setupTestStore(t)
+	defer cleanup()
+
+	groceries, _ := GetStore().CreateProject("Groceries")
+	personal, _ := GetStore().CreateProject("Personal")
+	captureCommandOutput(t, "/task "+groceries.Shortcut+" Buy milk due:2030-01-02 ~30m")
+	tasks, _ := GetStore().ListTasks(groceries.ID)
+	if len(tasks) != 1 {
+		t.Fatalf("Expected 1 task, got %d", len(tasks))
+	}
+	taskID := tasks[0].ID
+	GetStore().UpdateTask(taskID, true)
+
+	output := captureCommandOutput(t, "/move "+shortenID(taskID)+" personal")
+	if !strings.Contains(output, "Moved Buy milk to Personal") {
+		t.Fatalf("Expected task moved, got: %s", output)
+	}
+	task, _ := GetStore().GetTask(taskID)
+	if task.ProjectID != personal.ID || !task.Done || task.DueDate == nil || task.Duration != storage.Duration30m {
+		t.Errorf("Expected task moved with its due date, duration, and done status, got %+v", task)
+	}
+
+	output = captureCommandOutput(t, "/mv "+shortenID(taskID)+" "+personal.Shortcut)
+	if !strings.Contains(output, "Error:") || !strings.Contains(output, "already in Personal") {
+		t.Errorf("Expected already-in error, got: %s", output)
+	}
+	if output := captureCommandOutput(t, "/move "+shortenID(taskID)); !strings.HasPrefix(output, "Usage:") {
+		t.Errorf("Expected usage, got: %s", output)
+	}
+
+	// The LLM names the task and the project
+	output = runTool("move", map[string]any{"task_id": taskID, "project_id": groceries.Shortcut})
+	if !strings.Contains(output, "Moved Buy milk to Groceries") {
+		t.Errorf("Expected tool call to move the task back, got: %s", output)
+	}
+}
+
+func TestInbox(t *testing.T) {
+	cleanup := setupTestStore(t)
+	defer cleanup()
+
+	if output := captureCommandOutput(t, "/inbox"); !strings.Contains(output, "The inbox is empty") {
+		t.Errorf("Expected empty inbox, got: %s", output)
+	}
+
+	output := captureCommandOutput(t, "/in Call dentist due:today ~15m")
+	if !strings.Contains(output, "Created task: Call dentist") {
+		t.Fatalf("Expected inbox task created, got: %s", output)
+	}
+	taskID, _ := GetStore().ResolveTaskID(extractTaskID(output))
+	task, _ := GetStore().GetTask(taskID)
+	if task.ProjectID != "" || task.DueDate == nil || task.Duration != storage.Duration15m {
+		t.Errorf("Expected a projectless task with its metadata, got %+v", task)
+	}
+
+	if output := captureCommandOutput(t, "/inbox"); !strings.Contains(output, "Inbox (1):") || !strings.Contains(output, "Call dentist") {
+		t.Errorf("Expected the task in the inbox, got: %s", output)
+	}
+
+	// Schedule views include inbox tasks
+	if output := captureCommandOutput(t, "/today"); !strings.Contains(output, "Call dentist (15m, due "+time.Now().Format("2006-01-02")+", Inbox)") {
+		t.Errorf("Expected the inbox task due today, got: %s", output)
+	}
+
+	health, _ := GetStore().CreateProject("Health")
+	if output := captureCommandOutput(t, "/move "+shortenID(taskID)+" "+health.Shortcut); !strings.Contains(output, "Moved Call dentist to Health") {
+		t.Errorf("Expected the task filed, got: %s", output)
+	}
+	if output := captureCommandOutput(t, "/inbox"); !strings.Contains(output, "The inbox is empty") {
+		t.Errorf("Expected empty inbox after filing, got: %s", output)
+	}
+	if output := captureCommandOutput(t, "/move "+shortenID(taskID)+" inbox"); !strings.Contains(output, "Moved Call dentist to Inbox") {
+		t.Errorf("Expected the task back in the inbox, got: %s", output)
+	}
+
+	output = runTool("in", map[string]any{"task_name": "Renew passport #errands"})
+	if !strings.Contains(output, "Created task: Renew passport") {
+		t.Errorf("Expected tool call to capture the task, got: %s", output)
+	}
+	if tasks, _ := GetStore().ListTasks(""); len(tasks) != 2 {
+		t.Errorf("Expected 2 inbox tasks, got %d", len(tasks))
+	}
+}
+
+func TestNameRendering(t *testing.T) {
+	cleanup := setupTestStore(t)
+	defer cleanup()
+
+	project, _ := GetStore().CreateProject("Café Crème")
+	output := captureCommandOutput(t, `/shortcut `+project.Shortcut+` "Café Crème"`)
+	if !strings.Contains(output, `invalid shortcut`) || !strings.Contains(output, `"cafe-creme"`) {
+		t.Errorf("Expected an ASCII shortcut suggested, got: %s", output)
+	}
+
+	// Names from tool calls keep to one line
+	runTool("task", map[string]any{"project_id": project.Shortcut, "task_name": "Call\nAnn\x1b[2J"})
+	output = captureCommandOutput(t, "/tasks "+project.Shortcut)
+	if !strings.Contains(output, "] Call Ann [2J") || strings.Contains(output, "\x1b[2J") {
+		t.Errorf("Expected the name cleaned, got: %q", output)
+	}
+
+	// Right-to-left names don't swallow the shortcut next to them
+	hebrew, _ := GetStore().CreateProject("קניות")
+	output = captureCommandOutput(t, "/projects")
+	if !strings.Contains(output, "["+hebrew.Shortcut+"] \u2068קניות\u2069 (") {
+		t.Errorf("Expected the right-to-left name isolated, got: %q", output)
+	}
+}
+
+func TestDueTime(t *testing.T) {
+	cleanup := setupTestStore(t)
+	defer cleanup()
+	SetConfig(&config.Config{})
+
+	project, _ := GetStore().CreateProject("Work")
+	untimed, _ := GetStore().CreateTask(project.ID, "Tidy desk")
+	timed, _ := GetStore().CreateTask(project.ID, "Submit grant")
+
+	output := captureCommandOutput(t, "/due "+shortenID(timed.ID)+" 2030-06-15 14:00")
+	if !strings.Contains(output, "Set due date for task Submit grant to 2030-06-15 14:00") {
+		t.Fatalf("Expected due time set, got: %s", output)
+	}
+	if output := captureCommandOutput(t, "/due "+shortenID(timed.ID)+" 2030-06-15 2pm"); !strings.Contains(output, "invalid due time") {
+		t.Errorf("Expected invalid time error, got: %s", output)
+	}
+	output = captureCommandOutput(t, "/tasks "+project.Shortcut)
+	if !strings.Contains(output, "Submit grant (due 2030-06-15 14:00)") {
+		t.Errorf("Expected the due time listed, got: %s", output)
+	}
+
+	now := time.Now()
+	if now.Hour() >= 21 {
+		t.Skip("too close to midnight to fit due times later today")
+	}
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	soon := today.Add(time.Duration(now.Hour()+1)*time.Hour + time.Duration(now.Minute())*time.Minute)
+	later := soon.Add(2 * time.Hour)
+	GetStore().SetTaskDueDate(untimed.ID, &today)
+	GetStore().SetTaskDueDate(timed.ID, &later)
+	urgent, _ := GetStore().CreateTask(project.ID, "Call bank")
+	GetStore().SetTaskDueDate(urgent.ID, &soon)
+
+	// Timed tasks come first, in time order, and the one due within the hour is flagged
+	output = captureCommandOutput(t, "/today")
+	bank, grant, desk := strings.Index(output, "Call bank"), strings.Index(output, "Submit grant"), strings.Index(output, "Tidy desk")
+	if bank < 0 || !(bank < grant && grant < desk) {
+		t.Errorf("Expected today's tasks sorted by due time, got: %s", output)
+	}
+	if !strings.Contains(output, "Call bank (due "+storage.FormatDue(soon)+", Work) [due in") || strings.Count(output, "[due in") != 1 {
+		t.Errorf("Expected only the task due within 2h flagged, got: %s", output)
+	}
+
+	SetConfig(&config.Config{DueSoonHours: -1})
+	defer SetConfig(&config.Config{})
+	if output := captureCommandOutput(t, "/today"); strings.Contains(output, "[due in") {
+		t.Errorf("Expected no flags with due_soon_hours off, got: %s", output)
+	}
+
+	// A due time that has passed makes the task overdue
+	past := today.Add(time.Duration(now.Hour())*time.Hour - time.Minute)
+	if now.Hour() > 0 {
+		GetStore().SetTaskDueDate(urgent.ID, &past)
+		task, _ := GetStore().GetTask(urgent.ID)
+		if !isOverdue(task) {
+			t.Errorf("Expected a task due at %s to be overdue", storage.FormatDue(past))
+		}
+	}
+}
+
+func TestTaskStatus(t *testing.T) {
+	cleanup := setupTestStore(t)
+	defer cleanup()
+
+	project, _ := GetStore().CreateProject("Work")
+	GetStore().SetProjectShortcut(project.ID, "work")
+	draft, _ := GetStore().CreateTask(project.ID, "Draft report")
+	waiting, _ := GetStore().CreateTask(project.ID, "Get sign-off")
+	GetStore().CreateTask(project.ID, "Book room")
+
+	// Until a task is in progress or blocked, the list reads as before
+	output := captureCommandOutput(t, "/tasks work")
+	if strings.Contains(output, "To do (") {
+		t.Errorf("Expected a plain list, got: %s", output)
+	}
+
+	output = captureCommandOutput(t, "/status "+shortenID(draft.ID)+" in-progress")
+	if !strings.Contains(output, "Set status for task Draft report to in-progress") {
+		t.Fatalf("Expected status set, got: %s", output)
+	}
+	captureCommandOutput(t, "/status "+shortenID(waiting.ID)+" Blocked")
+	if output := captureCommandOutput(t, "/status "+shortenID(waiting.ID)+" waiting"); !strings.Contains(output, "Invalid status") {
+		t.Errorf("Expected invalid status error, got: %s", output)
+	}
+
+	// Grouped in board order, each with its own mark
+	output = captureCommandOutput(t, "/tasks work")
+	todo, doing, blocked := strings.Index(output, "To do (1):"), strings.Index(output, "In progress (1):"), strings.Index(output, "Blocked (1):")
+	if todo < 0 || !(todo < doing && doing < blocked) {
+		t.Errorf("Expected tasks grouped by status, got: %s", output)
+	}
+	if !strings.Contains(output, "[~] ["+shortenID(draft.ID)+"] Draft report") || !strings.Contains(output, "[!] ["+shortenID(waiting.ID)+"] Get sign-off") {
+		t.Errorf("Expected status marks, got: %s", output)
+	}
+
+	output = captureCommandOutput(t, "/tasks work --status blocked")
+	if !strings.Contains(output, "Get sign-off") || strings.Contains(output, "Draft report") || strings.Contains(output, "Book room") {
+		t.Errorf("Expected only blocked tasks, got: %s", output)
+	}
+	if output := captureCommandOutput(t, "/tasks work --status=done"); !strings.Contains(output, "No done tasks") {
+		t.Errorf("Expected no done tasks, got: %s", output)
+	}
+	output = runTool("tasks", map[string]any{"project_id": "work", "status": "in-progress"})
+	if !strings.Contains(output, "Draft report") || strings.Contains(output, "Get sign-off") {
+		t.Errorf("Expected the tool to filter by status, got: %s", output)
+	}
+
+	// done is the same as /done
+	captureCommandOutput(t, "/status "+shortenID(draft.ID)+" done")
+	if task, _ := GetStore().GetTask(draft.ID); !task.Done {
+		t.Error("Expected done status to mark the task done")
+	}
+}
+
+func TestNowReminders(t *testing.T) {
+	cleanup := setupTestStore(t)
+	defer cleanup()
+	SetConfig(&config.Config{})
+
+	project, _ := GetStore().CreateProject("Work")
+	due := func(name string, hour, minute int) {
+		task, _ := GetStore().CreateTask(project.ID, name)
+		at := time.Date(2030, 6, 15, hour, minute, 0, 0, time.UTC)
+		GetStore().SetTaskDueDate(task.ID, &at)
+	}
+	due("Evening run", 19, 0)
+	due("Submit grant", 15, 0)
+	due("Call bank", 9, 0)
+	due("Tidy desk", 0, 0) // no time
+
+	now := time.Date(2030, 6, 15, 14, 40, 0, 0, time.Local)
+	summary := nowSummary(now)
+	if !strings.HasPrefix(summary, "Now: Saturday 2030-06-15 14:40\n") {
+		t.Errorf("Expected the current time first, got: %s", summary)
+	}
+	bank, grant, run := strings.Index(summary, "09:00 Call bank"), strings.Index(summary, "15:00 Submit grant"), strings.Index(summary, "19:00 Evening run")
+	if bank < 0 || !(bank < grant && grant < run) || strings.Contains(summary, "Tidy desk") {
+		t.Errorf("Expected timed tasks in time order, got: %s", summary)
+	}
+	if !strings.Contains(summary, "(Work), in 20m") || !strings.Contains(summary, "(Work), 5h 40m ago, overdue") {
+		t.Errorf("Expected time until and since each task, got: %s", summary)
+	}
+
+	// The chat prompt carries only the tasks due within the window
+	reminders := upcomingReminders(now)
+	if !strings.Contains(reminders, "DUE SOON:") || !strings.Contains(reminders, "Submit grant") || strings.Contains(reminders, "Evening run") || strings.Contains(reminders, "Call bank") {
+		t.Errorf("Expected only the task due within 2h, got: %s", reminders)
+	}
+	SetConfig(&config.Config{DueSoonHours: 5})
+	if reminders := upcomingReminders(now); !strings.Contains(reminders, "Evening run") {
+		t.Errorf("Expected a wider window from the config, got: %s", reminders)
+	}
+	SetConfig(&config.Config{})
+
+	if prompt := getSystemPrompt(); !strings.Contains(prompt, "CURRENT TIME: ") || !strings.Contains(prompt, `call "now"`) {
+		t.Errorf("Expected the time and the now tool in the system prompt, got: %s", prompt)
+	}
+}
+
+func TestDeferCommand(t *testing.T) {
+	cleanup := setupTestStore(t)
+	defer cleanup()
+
+	shortcut := extractShortcut(captureCommandOutput(t, "/project Home"))
+	rentID := extractTaskID(captureCommandOutput(t, "/task "+shortcut+" Pay rent"))
+	taxesID := extractTaskID(captureCommandOutput(t, "/task "+shortcut+" File taxes"))
+	plantsID := extractTaskID(captureCommandOutput(t, "/task "+shortcut+" Water plants"))
+	laterID := extractTaskID(captureCommandOutput(t, "/task "+shortcut+" Plan trip"))
+
+	today := time.Now()
+	day := func(offset int) string { return today.AddDate(0, 0, offset).Format("2006-01-02") }
+	captureCommandOutput(t, "/due "+rentID+" "+day(-3))
+	captureCommandOutput(t, "/due "+taxesID+" "+day(-1))
+	captureCommandOutput(t, "/due "+laterID+" "+day(5))
+
+	output := captureCommandOutput(t, "/defer "+laterID+" +1w")
+	if !strings.Contains(output, `Deferred "Plan trip": `+day(5)+" -> "+day(12)) {
+		t.Errorf("Expected the due date pushed a week, got: %s", output)
+	}
+	output = captureCommandOutput(t, "/defer "+plantsID+" +2d")
+	if !strings.Contains(output, "no due date -> "+day(2)) {
+		t.Errorf("Expected two days from today, got: %s", output)
+	}
+	output = captureCommandOutput(t, "/defer "+plantsID+" someday")
+	if !strings.Contains(output, "invalid shift: someday") {
+		t.Errorf("Expected an invalid shift error, got: %s", output)
+	}
+
+	output = captureCommandOutput(t, "/defer-all-overdue +1d")
+	if !strings.Contains(output, "Deferred 2 overdue tasks") || !strings.Contains(output, "Pay rent: "+day(-3)+" -> "+day(1)) || strings.Contains(output, "Plan trip") {
+		t.Errorf("Expected both overdue tasks moved to tomorrow, got: %s", output)
+	}
+	if output := captureCommandOutput(t, "/defer-all-overdue +1d"); !strings.Contains(output, "No overdue tasks.") {
+		t.Errorf("Expected nothing left overdue, got: %s", output)
+	}
+
+	// One /undo puts the whole batch back
+	captureCommandOutput(t, "/undo")
+	resolved, _ := GetStore().ResolveTaskID(rentID)
+	if task, _ := GetStore().GetTask(resolved); storage.FormatDue(*task.DueDate) != day(-3) {
+		t.Errorf("Expected /undo to restore the due date, got %s", storage.FormatDue(*task.DueDate))
+	}
+}
+
+func TestLogCommand(t *testing.T) {
+	cleanup := setupTestStore(t)
+	defer cleanup()
+
+	work := extractShortcut(captureCommandOutput(t, "/project Work"))
+	reportID := extractTaskID(captureCommandOutput(t, "/task "+work+" Write report"))
+	slidesID := extractTaskID(captureCommandOutput(t, "/task "+work+" Make slides"))
+	oldID := extractTaskID(captureCommandOutput(t, "/in Renew passport"))
+	captureCommandOutput(t, "/task "+work+" Still open")
+
+	if output := captureCommandOutput(t, "/log"); !strings.Contains(output, "Nothing completed today.") {
+		t.Errorf("Expected an empty log, got: %s", output)
+	}
+
+	captureCommandOutput(t, "/duration "+reportID+" 1h")
+	captureCommandOutput(t, "/done "+reportID)
+	captureCommandOutput(t, "/done "+slidesID)
+	captureCommandOutput(t, "/done "+oldID)
+
+	// Completed yesterday, and archived since
+	resolved, _ := GetStore().ResolveTaskID(oldID)
+	task, _ := GetStore().GetTask(resolved)
+	yesterday := time.Now().AddDate(0, 0, -1)
+	moved := *task
+	moved.CompletedAt = &yesterday
+	GetStore().ReplaceTask(&moved)
+	GetStore().ArchiveTasks([]string{resolved})
+
+	output := captureCommandOutput(t, "/log")
+	if !strings.Contains(output, "Completed today") || !strings.Contains(output, "Write report (Work) - 1h est") || strings.Contains(output, "Renew passport") || strings.Contains(output, "Still open") {
+		t.Errorf("Expected today's completions, got: %s", output)
+	}
+	if !strings.Contains(output, "Total: 2 tasks, 1h estimated (1 without a duration)") {
+		t.Errorf("Expected totals, got: %s", output)
+	}
+
+	output = captureCommandOutput(t, "/log yesterday")
+	if !strings.Contains(output, "Renew passport (Inbox)") || strings.Contains(output, "Write report") {
+		t.Errorf("Expected the archived task completed yesterday, got: %s", output)
+	}
+
+	output = captureCommandOutput(t, "/log "+work+" week")
+	if !strings.Contains(output, "Completed in Work this week") || !strings.Contains(output, time.Now().Format("Monday 2006-01-02")) || strings.Contains(output, "Renew passport") {
+		t.Errorf("Expected this week's work completions by day, got: %s", output)
+	}
+
+	// Reopening a task takes it off the log
+	captureCommandOutput(t, "/undone "+slidesID)
+	if output := captureCommandOutput(t, "/log"); strings.Contains(output, "Make slides") {
+		t.Errorf("Expected the reopened task left out, got: %s", output)
+	}
+}
@@ -0,0 +1,140 @@
+package commands
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"twooms/i18n"
+	"twooms/storage"
+)
+
+func init() {
+	Register(&Command{
+		Name:        "/taskbatch",
+		Shorthand:   "/tb",
+		ToolName:    "tasks_create_batch",
+		Description: "Add several tasks to a project with one change: a ';'-separated list, or one per line until an empty line. Use this instead of calling task repeatedly.",
+		Access:      AccessCreate,
+		Interactive: true,
+		Bulk:        true,
+		Params: []Param{
+			{Name: "project_id", Type: ParamTypeString, Description: "The ID or shortcut of the project to add the tasks to", Required: true},
+			{Name: "tasks", Type: ParamTypeString, Description: "Task names separated by ';'; each may include inline metadata like !p1 @context due:fri ~2h #tag. Required for tool calls; typed without it, /taskbatch asks for one per line.", Required: false},
+		},
+		Handler: func(args []string) bool {
+			args, yes := takeYes(args)
+			if len(args) == 0 {
+				fmt.Println(i18n.T("taskbatch.usage"))
+				return false
+			}
+
+			projectID, err := GetStore().ResolveProjectID(args[0])
+			if err != nil {
+				fmt.Printf("Error: %v\n", err)
+				return false
+			}
+
+			if len(args) > 1 {
+				names := strings.Split(strings.Join(args[1:], " "), ";")
+				if confirmBatch("/taskbatch", projectID, names, yes) {
+					createTaskBatch(projectID, names)
+				}
+				return false
+			}
+
+			if lineReader == nil {
+				fmt.Println("Error: give the tasks as a ';'-separated list outside the REPL")
+				return false
+			}
+			fmt.Println("Enter one task per line (inline metadata like ~1h due:fri works). Empty line to create them, 'cancel' to stop.")
+			var names []string
+			for {
+				line, err := lineReader("task> ")
+				if err != nil || strings.TrimSpace(line) == "cancel" {
+					fmt.Println("Cancelled. No tasks created.")
+					return false
+				}
+				if strings.TrimSpace(line) == "" {
+					break
+				}
+				names = append(names, strings.Split(line, ";")...)
+			}
+			createTaskBatch(projectID, names)
+			return false
+		},
+		ToolHandler: func(args map[string]any) (string, error) {
+			projectRef, err := stringArg(args, "project_id")
+			if err != nil {
+				return "", err
+			}
+			names, err := stringArg(args, "tasks")
+			if err != nil {
+				return "", err
+			}
+			return captureOutput(func() {
+				projectID, err := GetStore().ResolveProjectID(projectRef)
+				if err != nil {
+					fmt.Printf("Error: %v\n", err)
+					return
+				}
+				createTaskBatch(projectID, strings.Split(names, ";"))
+			}), nil
+		},
+	})
+}
+
+// confirmBatch asks before command creates the named tasks, if /confirm
+// always is set
+func confirmBatch(command, projectID string, names []string, yes bool) bool {
+	count := 0
+	for _, name := range names {
+		if strings.TrimSpace(name) != "" {
+			count++
+		}
+	}
+	return confirm(command, fmt.Sprintf("Create %d tasks in %s?", count, projectName(projectID)), yes)
+}
+
+// createTaskBatch parses each name's inline metadata and creates the tasks as
+// one change (so one /undo removes them all). Blank names are skipped, and an
+// invalid one stops the batch before anything is created.
+func createTaskBatch(projectID string, names []string) {
+	now := time.Now()
+	var tasks []*storage.Task
+	for _, name := range names {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+		task, err := storage.ParseInlineMetadata(name, now)
+		if err != nil {
+			fmt.Printf("Error: %q: %v. No tasks created.\n", name, err)
+			return
+		}
+		task.ProjectID = projectID
+		if err := applyProjectDefaults(task, now); err != nil {
+			fmt.Printf("Error: %v. No tasks created.\n", err)
+			return
+		}
+		tasks = append(tasks, task)
+	}
+	if len(tasks) == 0 {
+		fmt.Println("Error: no task names given")
+		return
+	}
+
+	created, err := GetStore().CreateTasks(tasks)
+	if err != nil {
+		fmt.Printf("Error creating tasks: %v\n", err)
+		return
+	}
+
+	fmt.Printf("Created %d tasks:\n", len(created))
+	for _, task := range created {
+		fmt.Printf("  %s  %s\n", shortenID(task.ID), storage.DisplayName(task.Name))
+	}
+	for _, task := range created {
+		Publish(EventTaskCreated, task)
+	}
+}
@@ -0,0 +1,129 @@
+package commands
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"twooms/i18n"
+	"twooms/storage"
+)
+
+// taskSorts are the orders /tasks --sort accepts. "manual" is the store's
+// order, which /reorder, /moveup, and /movedown change; until a project is
+// reordered it's the order tasks were added in.
+var taskSorts = []string{"manual", "due", "priority", "created", "name"}
+
+func isTaskSort(s string) bool {
+	for _, valid := range taskSorts {
+		if s == valid {
+			return true
+		}
+	}
+	return false
+}
+
+// defaultTaskSort returns the saved /tasks sort order, or "manual"
+func defaultTaskSort() string {
+	if s := GetConfig().TasksSort; isTaskSort(s) {
+		return s
+	}
+	return "manual"
+}
+
+// saveTaskSort makes by the default order of /tasks
+func saveTaskSort(by string) {
+	GetConfig().TasksSort = by
+	if by == "manual" {
+		GetConfig().TasksSort = ""
+	}
+	if err := GetConfig().Save(); err != nil {
+		fmt.Println(i18n.T("tasks.sort_unsaved", by, err))
+		return
+	}
+	fmt.Println(i18n.T("tasks.sort_saved", by))
+}
+
+// sortTasks orders tasks in place, leaving them in the store's order for
+// "manual". Ties keep their current order; tasks without a due date or
+// priority go last when sorting by it.
+func sortTasks(tasks []*storage.Task, by string) {
+	var less func(a, b *storage.Task) bool
+	switch by {
+	case "due":
+		less = dueBefore
+	case "priority":
+		less = func(a, b *storage.Task) bool {
+			if a.Priority.Rank() != b.Priority.Rank() {
+				return a.Priority.Rank() > b.Priority.Rank()
+			}
+			return dueBefore(a, b)
+		}
+	case "name":
+		less = func(a, b *storage.Task) bool {
+			return strings.ToLower(storage.DisplayName(a.Name)) < strings.ToLower(storage.DisplayName(b.Name))
+		}
+	case "created":
+		less = func(a, b *storage.Task) bool { return a.CreatedAt.Before(b.CreatedAt) }
+	default:
+		return
+	}
+	sort.SliceStable(tasks, func(i, j int) bool { return less(tasks[i], tasks[j]) })
+}
+
+// dueBefore reports whether a is due before b; tasks without a due date
+// come after those with one
+func dueBefore(a, b *storage.Task) bool {
+	switch {
+	case a.DueDate == nil:
+		return false
+	case b.DueDate == nil:
+		return true
+	}
+	return storage.DueAt(*a.DueDate, time.Local).Before(storage.DueAt(*b.DueDate, time.Local))
+}
+
+// dueBuckets are the groups of /tasks --group, in display order; each is
+// also the suffix of its heading's i18n key
+var dueBuckets = []string{"overdue", "today", "week", "later", "none", "done"}
+
+// dueBucket says which group of /tasks --group a task belongs in: overdue,
+// due today, due later this week (to Sunday), due later, no due date, or done
+func dueBucket(t *storage.Task, now time.Time) string {
+	switch {
+	case t.Done:
+		return "done"
+	case t.DueDate == nil:
+		return "none"
+	case isOverdue(t):
+		return "overdue"
+	}
+	today := dateOnly(now)
+	due := dateOnly(*t.DueDate)
+	switch {
+	case !due.After(today):
+		return "today"
+	case due.Before(startOfWeek(today).AddDate(0, 0, 7)):
+		return "week"
+	}
+	return "later"
+}
+
+// printDueBuckets prints tasks for /tasks --group, under a heading for each
+// due bucket that has any
+func printDueBuckets(tasks []*storage.Task) {
+	now := time.Now()
+	buckets := make(map[string][]*storage.Task)
+	for _, t := range tasks {
+		b := dueBucket(t, now)
+		buckets[b] = append(buckets[b], t)
+	}
+	for _, b := range dueBuckets {
+		if len(buckets[b]) == 0 {
+			continue
+		}
+		fmt.Printf("  %s (%d):\n", i18n.T("tasks.bucket."+b), len(buckets[b]))
+		printTasks(buckets[b], "    ")
+	}
+}
@@ -0,0 +1,177 @@
+package commands
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+	"time"
+
+	"twooms/i18n"
+	"twooms/storage"
+)
+
+// colorReset ends any color or style
+const colorReset = "\033[0m"
+
+// theme is the colors listings use for each kind of text; an empty color
+// leaves that text plain
+type theme struct {
+	done     string // done tasks
+	dueToday string // tasks due later today
+	overdue  string // overdue tasks, and other warnings like a full day
+	id       string // task IDs
+}
+
+// themes are the presets /theme picks from; dark is the default
+var themes = map[string]theme{
+	"dark": {
+		done:     "\033[32m",
+		dueToday: "\033[33m",
+		overdue:  "\033[31m",
+		id:       "\033[2m",
+	},
+	// Yellow is hard to read on a white background, so due today is a
+	// darker orange, and IDs are gray rather than faint
+	"light": {
+		done:     "\033[32m",
+		dueToday: "\033[38;5;130m",
+		overdue:  "\033[31m",
+		id:       "\033[90m",
+	},
+	"none": {},
+}
+
+// themeNames lists the presets in the order /theme shows them
+var themeNames = []string{"dark", "light", "none"}
+
+// colorTerminal is whether standard output is a terminal; main.go sets it
+// at startup, and without one (pipes, files, tests) nothing is colored
+var colorTerminal bool
+
+// SetColorTerminal says whether standard output is a terminal that can
+// show colors
+func SetColorTerminal(ok bool) {
+	colorTerminal = ok
+}
+
+// ansiCodes matches the color and style escape codes output may contain
+var ansiCodes = regexp.MustCompile("\033\\[[0-9;]*m")
+
+// themeName returns the /theme preset in use
+func themeName() string {
+	if GetConfig() != nil {
+		if _, ok := themes[GetConfig().Theme]; ok {
+			return GetConfig().Theme
+		}
+	}
+	return "dark"
+}
+
+// colorsOn reports whether output is colored: on a terminal, without
+// NO_COLOR set (https://no-color.org), and with a theme other than none
+func colorsOn() bool {
+	return colorTerminal && os.Getenv("NO_COLOR") == "" && themeName() != "none"
+}
+
+// activeTheme returns the colors to use, which are all empty when colors
+// are off
+func activeTheme() theme {
+	if !colorsOn() {
+		return theme{}
+	}
+	return themes[themeName()]
+}
+
+// paint wraps s in color, unless color is empty
+func paint(color, s string) string {
+	if color == "" || s == "" {
+		return s
+	}
+	return color + s + colorReset
+}
+
+// colorOverdue colors overdue tasks and other warnings
+func colorOverdue(s string) string {
+	return paint(activeTheme().overdue, s)
+}
+
+// colorID colors a task ID
+func colorID(id string) string {
+	return paint(activeTheme().id, id)
+}
+
+// taskColor returns the color of a task: done, overdue, due today, or none
+func taskColor(t *storage.Task) string {
+	th := activeTheme()
+	switch {
+	case t.Done:
+		return th.done
+	case isOverdue(t):
+		return th.overdue
+	case t.DueDate != nil && dateOnly(*t.DueDate).Equal(dateOnly(time.Now())):
+		return th.dueToday
+	}
+	return ""
+}
+
+// taskLine is how listings show a task: its status mark, short ID, and
+// text, colored by whether it's done, overdue, or due today
+func taskLine(t *storage.Task, mark, text string) string {
+	color := taskColor(t)
+	return fmt.Sprintf("%s [%s] %s", paint(color, mark), colorID(shortenID(t.ID)), paint(color, text))
+}
+
+// stripColors removes the color and style codes from terminal output, for
+// text the model reads
+func stripColors(s string) string {
+	return ansiCodes.ReplaceAllString(s, "")
+}
+
+func init() {
+	Register(&Command{
+		Name:        "/theme",
+		Description: "Show or set the output colors: dark, light, or none (the choice is saved)",
+		Examples: []string{
+			"/theme",
+			"/theme light",
+		},
+		Hidden: true,
+		Params: []Param{
+			{Name: "theme", Type: ParamTypeString, Description: "dark, light, or none", Required: false},
+		},
+		Handler: func(args []string) bool {
+			if len(args) == 0 {
+				fmt.Printf("Theme: %s (available: %s)\n", themeName(), strings.Join(themeNames, ", "))
+				switch {
+				case !colorTerminal:
+					fmt.Println("Output isn't a terminal, so nothing is colored.")
+				case os.Getenv("NO_COLOR") != "":
+					fmt.Println("NO_COLOR is set, so nothing is colored.")
+				}
+				if colorsOn() {
+					th := activeTheme()
+					fmt.Printf("  %s  %s  %s  %s\n", paint(th.done, "done"), paint(th.dueToday, "due today"), paint(th.overdue, "overdue"), paint(th.id, "1a2b3c4d"))
+				}
+				return false
+			}
+			name := strings.ToLower(args[0])
+			if _, ok := themes[name]; !ok {
+				fmt.Println(i18n.T("theme.usage_names", strings.Join(themeNames, "|")))
+				return false
+			}
+
+			// Dark is the default, so it's saved as no setting
+			GetConfig().Theme = name
+			if name == "dark" {
+				GetConfig().Theme = ""
+			}
+			if err := GetConfig().Save(); err != nil {
+				fmt.Printf("Theme set to %s for this session, but could not save it: %v\n", name, err)
+				return false
+			}
+			fmt.Printf("Theme set to %s (saved)\n", name)
+			return false
+		},
+	})
+}
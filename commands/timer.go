@@ -0,0 +1,201 @@
+package commands
+
+import (
+	"fmt"
+	"time"
+
+	"twooms/i18n"
+	"twooms/storage"
+)
+
+func init() {
+	Register(&Command{
+		Name:        "/start",
+		Description: "Start a timer on a task (stops any running timer first)",
+		Params: []Param{
+			{Name: "task_id", Type: ParamTypeString, Description: "The ID of the task to work on", Required: true},
+		},
+		Handler: func(args []string) bool {
+			if len(args) == 0 {
+				fmt.Println(i18n.T("start.usage"))
+				return false
+			}
+
+			// Resolve task ID
+			taskID, err := GetStore().ResolveTaskID(args[0])
+			if err != nil {
+				fmt.Printf("Error: %v\n", err)
+				return false
+			}
+
+			task, err := GetStore().GetTask(taskID)
+			if err != nil {
+				fmt.Printf("Error: %v\n", err)
+				return false
+			}
+
+			// Switching tasks stops the previous timer
+			if active, _ := GetStore().ActiveTimer(); active != nil {
+				if active.TaskID == taskID {
+					fmt.Printf("Timer already running for %s (%s so far)\n", task.Name, storage.FormatMinutes(active.Minutes(time.Now())))
+					return false
+				}
+				stopTimer()
+			}
+
+			if _, err := GetStore().StartTimer(taskID); err != nil {
+				fmt.Printf("Error: %v\n", err)
+				return false
+			}
+
+			fmt.Printf("Started timer for %s\n", task.Name)
+			return false
+		},
+	})
+
+	Register(&Command{
+		Name:        "/stop",
+		Description: "Stop the running task timer",
+		Handler: func(args []string) bool {
+			stopTimer()
+			return false
+		},
+	})
+
+	Register(&Command{
+		Name:        "/timelog",
+		Shorthand:   "/tl",
+		Description: "Show time logged per task compared to estimated durations",
+		Access:      AccessRead,
+		Params: []Param{
+			{Name: "project_id", Type: ParamTypeString, Description: "Optional project ID to filter by", Required: false},
+		},
+		Handler: func(args []string) bool {
+			var projectID string
+			if len(args) > 0 {
+				// Resolve project ID
+				resolved, err := GetStore().ResolveProjectID(args[0])
+				if err != nil {
+					fmt.Printf("Error: %v\n", err)
+					return false
+				}
+				projectID = resolved
+			}
+
+			printTimeLog(projectID)
+			return false
+		},
+	})
+}
+
+// stopTimer stops the running timer and reports how long it ran
+func stopTimer() {
+	entry, err := GetStore().StopTimer()
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		return
+	}
+
+	name := shortenID(entry.TaskID)
+	if task, err := GetStore().GetTask(entry.TaskID); err == nil {
+		name = task.Name
+	}
+	fmt.Printf("Stopped timer for %s (%s)\n", name, storage.FormatMinutes(entry.Minutes(time.Now())))
+}
+
+func printTimeLog(projectID string) {
+	entries, err := GetStore().ListTimeEntries()
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		return
+	}
+
+	var tasks []*storage.Task
+	if projectID != "" {
+		project, err := GetStore().GetProject(projectID)
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			return
+		}
+		tasks, err = GetStore().ListTasks(projectID)
+		if err != nil {
+			fmt.Printf("Error listing tasks: %v\n", err)
+			return
+		}
+		fmt.Printf("Time log for %s:\n", project.Name)
+	} else {
+		tasks, err = GetStore().ListAllTasks()
+		if err != nil {
+			fmt.Printf("Error listing tasks: %v\n", err)
+			return
+		}
+		fmt.Println("Time log:")
+	}
+
+	// Sum logged minutes per task
+	now := time.Now()
+	logged := make(map[string]int)
+	running := make(map[string]bool)
+	for _, e := range entries {
+		logged[e.TaskID] += e.Minutes(now)
+		if e.Running() {
+			running[e.TaskID] = true
+		}
+	}
+
+	totalLogged, totalEstimated := 0, 0
+	shown := 0
+	for _, t := range tasks {
+		if _, ok := logged[t.ID]; !ok {
+			continue
+		}
+		shown++
+
+		minutes := logged[t.ID]
+		estimate := t.Duration.ToMinutes()
+		totalLogged += minutes
+		totalEstimated += estimate
+
+		status := "[ ]"
+		if t.Done {
+			status = "[✓]"
+		}
+
+		line := fmt.Sprintf("  %s [%s] %s: %s logged", status, shortenID(t.ID), storage.DisplayName(t.Name), storage.FormatMinutes(minutes))
+		if estimate > 0 {
+			line += fmt.Sprintf(" / %s est (%s)", t.Duration, formatVariance(minutes-estimate))
+		}
+		if running[t.ID] {
+			line += " (running)"
+		}
+
+		// Highlight tasks that ran over their estimate
+		if estimate > 0 && minutes > estimate {
+			line = colorOverdue(line)
+		}
+		fmt.Println(line)
+	}
+
+	if shown == 0 {
+		fmt.Println("  No time logged. Start a timer with /start <task-id>")
+		return
+	}
+
+	fmt.Printf("\nTotal: %s logged", storage.FormatMinutes(totalLogged))
+	if totalEstimated > 0 {
+		fmt.Printf(" / %s estimated", storage.FormatMinutes(totalEstimated))
+	}
+	fmt.Println()
+}
+
+// formatVariance renders a minute difference as "+20m", "-1h", or "on estimate"
+func formatVariance(diff int) string {
+	switch {
+	case diff > 0:
+		return "+" + storage.FormatMinutes(diff)
+	case diff < 0:
+		return "-" + storage.FormatMinutes(-diff)
+	default:
+		return "on estimate"
+	}
+}
@@ -0,0 +1,139 @@
+package commands
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"twooms/i18n"
+	"twooms/llm"
+)
+
+func init() {
+	Register(&Command{
+		Name:        "/tools",
+		Description: "List the commands the LLM can call, or export their schemas as OpenAPI or JSON Schema for other agents",
+		Access:      AccessRead,
+		Hidden:      true,
+		Params: []Param{
+			{Name: "action", Type: ParamTypeString, Description: "list or export", Required: false, Enum: []string{"list", "export"}},
+			{Name: "format", Type: ParamTypeString, Description: "Export format: openapi (default) or schema", Required: false, Enum: []string{"openapi", "schema"}},
+			{Name: "file", Type: ParamTypeString, Description: "Optional output file (prints to the terminal if omitted)", Required: false},
+		},
+		Handler: func(args []string) bool {
+			if len(args) == 0 || strings.EqualFold(args[0], "list") {
+				tools := sortedTools()
+				fmt.Printf("%d tools:\n", len(tools))
+				for _, t := range tools {
+					// Tools that change data are the ones safe mode and dry runs stop
+					kind := "changes"
+					if cmd, ok := Lookup("/" + t.Name); ok && cmd.ReadOnly() {
+						kind = "read-only"
+					}
+					fmt.Printf("  %-20s %-9s  %s\n", t.Name, kind, t.Description)
+				}
+				fmt.Println("Use /tools export [openapi|schema] [file] to export their schemas.")
+				return false
+			}
+			if !strings.EqualFold(args[0], "export") || len(args) > 3 {
+				fmt.Println(i18n.T("tools.usage"))
+				return false
+			}
+
+			format := "openapi"
+			if len(args) > 1 {
+				format = strings.ToLower(args[1])
+			}
+			var doc map[string]any
+			switch format {
+			case "openapi":
+				doc = ToolsOpenAPI()
+			case "schema":
+				doc = ToolsJSONSchema()
+			default:
+				fmt.Printf("Error: unknown format: %s (use openapi or schema)\n", args[1])
+				return false
+			}
+
+			data, err := json.MarshalIndent(doc, "", "  ")
+			if err != nil {
+				fmt.Printf("Error: %v\n", err)
+				return false
+			}
+			if len(args) < 3 {
+				fmt.Println(string(data))
+				return false
+			}
+			if err := os.WriteFile(args[2], append(data, '\n'), 0644); err != nil {
+				fmt.Printf("Error: %v\n", err)
+				return false
+			}
+			fmt.Printf("Exported %d tools to %s\n", len(sortedTools()), args[2])
+			return false
+		},
+	})
+}
+
+// sortedTools returns the tool definitions sorted by name, so exports are stable
+func sortedTools() []*llm.Tool {
+	tools := GenerateToolDefinitions()
+	sort.Slice(tools, func(i, j int) bool { return tools[i].Name < tools[j].Name })
+	return tools
+}
+
+// ToolsJSONSchema describes every tool as a JSON Schema (draft 2020-12)
+// document, with each tool's arguments under $defs by tool name
+func ToolsJSONSchema() map[string]any {
+	defs := make(map[string]any)
+	for _, t := range sortedTools() {
+		schema := t.InputSchema()
+		schema["description"] = t.Description
+		defs[t.Name] = schema
+	}
+	return map[string]any{
+		"$schema":     "https://json-schema.org/draft/2020-12/schema",
+		"title":       "Twooms tools",
+		"description": "Arguments of each Twooms command the LLM can call, by tool name",
+		"$defs":       defs,
+	}
+}
+
+// ToolsOpenAPI describes every tool as an OpenAPI 3.1 operation: a POST to
+// /tools/{name} with the arguments as a JSON body, answered with the
+// command's text output
+func ToolsOpenAPI() map[string]any {
+	paths := make(map[string]any)
+	for _, t := range sortedTools() {
+		paths["/tools/"+t.Name] = map[string]any{
+			"post": map[string]any{
+				"operationId": t.Name,
+				"summary":     t.Description,
+				"requestBody": map[string]any{
+					"required": true,
+					"content": map[string]any{
+						"application/json": map[string]any{"schema": t.InputSchema()},
+					},
+				},
+				"responses": map[string]any{
+					"200": map[string]any{
+						"description": "The command's output, as shown in the terminal",
+						"content": map[string]any{
+							"text/plain": map[string]any{"schema": map[string]any{"type": "string"}},
+						},
+					},
+				},
+			},
+		}
+	}
+	return map[string]any{
+		"openapi": "3.1.0",
+		"info": map[string]any{
+			"title":       "Twooms tools",
+			"description": "The Twooms commands the LLM can call, with the same names and arguments",
+			"version":     "1.0.0",
+		},
+		"paths": paths,
+	}
+}
@@ -0,0 +1,132 @@
+package commands
+
+import (
+	"fmt"
+	"time"
+
+	"twooms/i18n"
+	"twooms/storage"
+)
+
+// defaultTrashDays is how long deleted items stay in the trash when the
+// config doesn't say
+const defaultTrashDays = 30
+
+func init() {
+	Register(&Command{
+		Name:        "/trash",
+		Description: "List deleted projects and tasks that /restore can bring back, or empty the trash for good",
+		Examples:    []string{"/trash", "/trash empty"},
+		Destructive: true,
+		Interactive: true,
+		Params: []Param{
+			{Name: "action", Type: ParamTypeString, Description: "empty to purge everything in the trash", Required: false, Enum: []string{"empty"}},
+		},
+		Handler: func(args []string) bool {
+			args, yes := takeYes(args)
+			if len(args) == 0 {
+				listTrash()
+				return false
+			}
+			if len(args) != 1 || args[0] != "empty" {
+				fmt.Println(i18n.T("trash.usage"))
+				return false
+			}
+
+			items, err := GetStore().ListTrash()
+			if err != nil {
+				fmt.Printf("Error: %v\n", err)
+				return false
+			}
+			if len(items) == 0 {
+				fmt.Println("The trash is empty.")
+				return false
+			}
+			if !confirm("/trash", fmt.Sprintf("Permanently delete the %d items in the trash?", len(items)), yes) {
+				return false
+			}
+			n, err := GetStore().PurgeTrash(time.Time{})
+			if err != nil {
+				fmt.Printf("Error: %v\n", err)
+				return false
+			}
+			fmt.Printf("Emptied the trash (%d items).\n", n)
+			return false
+		},
+	})
+}
+
+// trashDays returns how many days items stay in the trash (trash_days in
+// the config), or 0 if they stay until /trash empty
+func trashDays() int {
+	days := defaultTrashDays
+	if GetConfig() != nil && GetConfig().TrashDays != 0 {
+		days = GetConfig().TrashDays
+	}
+	if days < 0 {
+		return 0
+	}
+	return days
+}
+
+// PurgeTrash permanently deletes what has been in the trash longer than
+// trash_days; main.go runs it at startup
+func PurgeTrash(now time.Time) error {
+	days := trashDays()
+	if days == 0 {
+		return nil
+	}
+	_, err := GetStore().PurgeTrash(now.AddDate(0, 0, -days))
+	return err
+}
+
+// restoreFromTrash brings a deleted project or task back for /restore
+func restoreFromTrash(ref string) {
+	item, err := GetStore().RestoreTrash(ref)
+	if err != nil {
+		fmt.Printf("Error: %v (see /trash)\n", err)
+		return
+	}
+	if item.Project != nil {
+		fmt.Printf("Restored project %s [%s] with %d tasks\n", item.Project.Name, item.Project.Shortcut, len(item.Tasks))
+		return
+	}
+	task := item.Tasks[0]
+	fmt.Printf("Restored task %s to %s\n", task.Name, projectName(task.ProjectID))
+}
+
+// projectExists reports whether a project is still in the store
+func projectExists(id string) bool {
+	_, err := GetStore().GetProject(id)
+	return err == nil
+}
+
+// listTrash prints the trash for /trash
+func listTrash() {
+	items, err := GetStore().ListTrash()
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		return
+	}
+	if len(items) == 0 {
+		fmt.Println("The trash is empty.")
+		return
+	}
+
+	if days := trashDays(); days > 0 {
+		fmt.Printf("Trash (kept for %d days; /restore <id> brings an item back):\n", days)
+	} else {
+		fmt.Println("Trash (/restore <id> brings an item back):")
+	}
+	for _, item := range items {
+		name := storage.DisplayName(item.Name())
+		if item.Project != nil {
+			name = fmt.Sprintf("%s (%d tasks)", name, len(item.Tasks))
+		} else if projectID := item.Tasks[0].ProjectID; projectID == "" || projectExists(projectID) {
+			name = fmt.Sprintf("%s (%s)", name, projectName(projectID))
+		} else {
+			name += " (project deleted; restores to the inbox)"
+		}
+		fmt.Printf("  %s  %-7s  %s, deleted %s\n", colorID(shortenID(item.ID())), item.Kind, name, item.DeletedAt.Format("2006-01-02 15:04"))
+	}
+}
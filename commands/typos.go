@@ -0,0 +1,90 @@
+package commands
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// maxSuggestions caps how many close command names an unknown command lists
+const maxSuggestions = 3
+
+// UnknownCommandError is returned by Execute for a name that isn't
+// registered, with the closest command names as suggestions
+type UnknownCommandError struct {
+	Name        string
+	Suggestions []string // closest first
+	Likely      string   // the one suggestion close enough to offer running, or ""
+}
+
+func (e *UnknownCommandError) Error() string {
+	if len(e.Suggestions) == 0 {
+		return fmt.Sprintf("unknown command: %s", e.Name)
+	}
+	return fmt.Sprintf("unknown command: %s (did you mean %s?)", e.Name, strings.Join(e.Suggestions, ", "))
+}
+
+// unknownCommand builds the error for name, suggesting commands within a
+// couple of edits. A single suggestion one edit away is taken as Likely.
+func unknownCommand(name string) *UnknownCommandError {
+	word := strings.TrimPrefix(name, "/")
+	limit := min(2, len(word)/2)
+
+	type match struct {
+		name     string
+		distance int
+	}
+	var matches []match
+	for _, cmd := range List() {
+		d := editDistance(word, strings.TrimPrefix(cmd.Name, "/"))
+		if d <= limit {
+			matches = append(matches, match{cmd.Name, d})
+		}
+	}
+	sort.Slice(matches, func(i, j int) bool {
+		if matches[i].distance != matches[j].distance {
+			return matches[i].distance < matches[j].distance
+		}
+		return matches[i].name < matches[j].name
+	})
+
+	err := &UnknownCommandError{Name: name}
+	for i, m := range matches {
+		if i == maxSuggestions {
+			break
+		}
+		err.Suggestions = append(err.Suggestions, m.name)
+	}
+	if len(matches) > 0 && matches[0].distance == 1 && (len(matches) == 1 || matches[1].distance > 1) {
+		err.Likely = matches[0].name
+	}
+	return err
+}
+
+// editDistance is the Levenshtein distance between a and b, counting a swap
+// of adjacent letters ("/tsak") as one edit rather than two
+func editDistance(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	// d[i][j] is the distance between the first i runes of a and first j of b
+	d := make([][]int, len(ra)+1)
+	for i := range d {
+		d[i] = make([]int, len(rb)+1)
+		d[i][0] = i
+	}
+	for j := range d[0] {
+		d[0][j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			d[i][j] = min(d[i-1][j]+1, d[i][j-1]+1, d[i-1][j-1]+cost)
+			if i > 1 && j > 1 && ra[i-1] == rb[j-2] && ra[i-2] == rb[j-1] {
+				d[i][j] = min(d[i][j], d[i-2][j-2]+1)
+			}
+		}
+	}
+	return d[len(ra)][len(rb)]
+}
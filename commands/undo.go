@@ -0,0 +1,63 @@
+package commands
+
+import (
+	"fmt"
+	"strconv"
+
+	"twooms/i18n"
+	"twooms/storage"
+)
+
+func init() {
+	Register(&Command{
+		Name:        "/undo",
+		Shorthand:   "/z",
+		Description: "Undo the last N changes (default 1)",
+		Hidden:      true,
+		Params: []Param{
+			{Name: "count", Type: ParamTypeInteger, Description: "Number of changes to undo", Required: false},
+		},
+		Handler: func(args []string) bool {
+			runJournal(args, "undo.usage", "Undid", GetStore().Undo)
+			return false
+		},
+	})
+
+	Register(&Command{
+		Name:        "/redo",
+		Shorthand:   "/y",
+		Description: "Redo the last N undone changes (default 1)",
+		Hidden:      true,
+		Params: []Param{
+			{Name: "count", Type: ParamTypeInteger, Description: "Number of changes to redo", Required: false},
+		},
+		Handler: func(args []string) bool {
+			runJournal(args, "redo.usage", "Redid", GetStore().Redo)
+			return false
+		},
+	})
+}
+
+// runJournal calls step up to N times (from args), printing each operation
+func runJournal(args []string, usageKey, verb string, step func() (*storage.JournalEntry, error)) {
+	count := 1
+	if len(args) > 0 {
+		n, err := strconv.Atoi(args[0])
+		if err != nil || n < 1 {
+			fmt.Println(i18n.T(usageKey))
+			return
+		}
+		count = n
+	}
+
+	for i := 0; i < count; i++ {
+		entry, err := step()
+		if err != nil {
+			if i == 0 {
+				fmt.Printf("Error: %v\n", err)
+			}
+			return
+		}
+		fmt.Printf("%s: %s\n", verb, entry.Op)
+	}
+}
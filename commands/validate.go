@@ -0,0 +1,131 @@
+package commands
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"twooms/llm"
+	"twooms/storage"
+)
+
+// validateToolArgs checks a tool call against the command's Params before
+// it runs: the tool must be one the model was offered, required arguments
+// must be present, and values must have the right type, be one of the
+// Enum, and match the Format. It returns "" when the call is fine, and
+// otherwise one error listing every problem, so the model can fix them all
+// in its next call instead of failing partway through the command.
+func validateToolArgs(tools []*llm.Tool, name string, args map[string]any) string {
+	if !slices.ContainsFunc(tools, func(t *llm.Tool) bool { return t.Name == name }) {
+		return fmt.Sprintf("Error: there is no tool named %s", name)
+	}
+	cmd, ok := toolCommand(name)
+	if !ok {
+		return fmt.Sprintf("Error: there is no tool named %s", name)
+	}
+
+	var problems []string
+	known := make(map[string]bool)
+	for _, p := range cmd.Params {
+		known[p.Name] = true
+		val, present := args[p.Name]
+		if !present || val == nil || val == "" {
+			if p.Required {
+				problems = append(problems, fmt.Sprintf("%s: required (%s)", p.Name, p.Description))
+			}
+			continue
+		}
+		if problem := checkParamValue(p, val); problem != "" {
+			problems = append(problems, p.Name+": "+problem)
+		}
+	}
+
+	var unknown []string
+	for key := range args {
+		if !known[key] {
+			unknown = append(unknown, key)
+		}
+	}
+	sort.Strings(unknown)
+	for _, key := range unknown {
+		var names []string
+		for _, p := range cmd.Params {
+			names = append(names, p.Name)
+		}
+		expected := "none"
+		if len(names) > 0 {
+			expected = strings.Join(names, ", ")
+		}
+		problems = append(problems, fmt.Sprintf("%s: unknown argument (expected: %s)", key, expected))
+	}
+
+	if len(problems) == 0 {
+		return ""
+	}
+	return fmt.Sprintf("Error: invalid arguments for %s:\n- %s\nCall %s again with corrected arguments.",
+		name, strings.Join(problems, "\n- "), name)
+}
+
+// checkParamValue describes what's wrong with one argument, or returns ""
+func checkParamValue(p Param, val any) string {
+	switch p.Type {
+	case ParamTypeBoolean:
+		if _, ok := val.(bool); !ok {
+			return fmt.Sprintf("must be true or false, got %v", val)
+		}
+		return ""
+	case ParamTypeInteger:
+		switch v := val.(type) {
+		case float64:
+			if v != float64(int64(v)) {
+				return fmt.Sprintf("must be a whole number, got %v", v)
+			}
+		case string:
+			if _, err := strconv.Atoi(v); err != nil {
+				return fmt.Sprintf("must be a whole number, got %q", v)
+			}
+		default:
+			return fmt.Sprintf("must be a whole number, got %v", val)
+		}
+		return ""
+	}
+
+	// Strings; numbers are accepted too, since they join the command line the same way
+	var s string
+	switch v := val.(type) {
+	case string:
+		s = v
+	case float64:
+		s = strconv.FormatFloat(v, 'f', -1, 64)
+	default:
+		return fmt.Sprintf("must be a string, got %v", val)
+	}
+
+	if len(p.Enum) > 0 && !slices.ContainsFunc(p.Enum, func(e string) bool { return strings.EqualFold(e, s) }) {
+		return fmt.Sprintf("must be one of %s, got %q", strings.Join(p.Enum, ", "), s)
+	}
+	if p.Format == ParamFormatDate && s != "none" {
+		if _, err := time.Parse("2006-01-02", s); err != nil {
+			problem := fmt.Sprintf("must be a YYYY-MM-DD date or 'none', got %q", s)
+			// Words like "tomorrow" have a clear meaning, so suggest the date
+			if due, err := storage.ParseDueWord(s, time.Now()); err == nil {
+				problem += fmt.Sprintf(" (for %s use %s)", s, due.Format("2006-01-02"))
+			}
+			return problem
+		}
+	}
+	if p.Format == ParamFormatDuration {
+		if _, err := storage.ParseDuration(s); err != nil {
+			return fmt.Sprintf("must be a duration like 45m, 3h, or 1h30m, got %q", s)
+		}
+	}
+	if p.Format == ParamFormatTime {
+		if _, err := time.Parse("15:04", s); err != nil {
+			return fmt.Sprintf("must be a 24-hour HH:MM time, got %q", s)
+		}
+	}
+	return ""
+}
@@ -0,0 +1,222 @@
+package commands
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"slices"
+	"strings"
+
+	"twooms/i18n"
+	"twooms/llm"
+	"twooms/storage"
+)
+
+// defaultWorkspace names the workspace kept in the original store file
+const defaultWorkspace = "default"
+
+// workspaceNameRegex limits workspace names to what's safe in a file name
+var workspaceNameRegex = regexp.MustCompile(`^[A-Za-z0-9-]{1,20}$`)
+
+// Workspace is one task database and the files that belong with it
+type Workspace struct {
+	Store     storage.Store
+	ChatPath  string // saved chat conversations
+	BackupDir string // local backups
+}
+
+var (
+	// workspaceOpener opens a workspace by name ("" for the default one), set
+	// by SetWorkspaceOpener
+	workspaceOpener func(name string) (*Workspace, error)
+	activeWorkspace string
+)
+
+func init() {
+	Register(&Command{
+		Name:        "/workspace",
+		Shorthand:   "/ws",
+		Description: "List, create, or switch workspaces: separate task databases such as work and personal (the choice is saved)",
+		Hidden:      true,
+		Params: []Param{
+			{Name: "action", Type: ParamTypeString, Description: "list, create, or switch", Required: false, Enum: []string{"list", "create", "switch"}},
+			{Name: "name", Type: ParamTypeString, Description: "Workspace name (letters, digits, and hyphens)", Required: false},
+		},
+		Handler: func(args []string) bool {
+			if len(args) == 0 {
+				args = []string{"list"}
+			}
+
+			switch strings.ToLower(args[0]) {
+			case "list":
+				listWorkspaces()
+			case "create":
+				if len(args) != 2 {
+					fmt.Println(i18n.T("workspace.usage_create"))
+					return false
+				}
+				createWorkspace(args[1])
+			case "switch":
+				if len(args) != 2 {
+					fmt.Println(i18n.T("workspace.usage_switch"))
+					return false
+				}
+				switchWorkspace(args[1])
+			default:
+				fmt.Println(i18n.T("workspace.usage"))
+			}
+			return false
+		},
+	})
+}
+
+// SetWorkspaceOpener sets how workspaces are opened. Without one, only the
+// store given to SetStore is available.
+func SetWorkspaceOpener(open func(name string) (*Workspace, error)) {
+	workspaceOpener = open
+}
+
+// ActiveWorkspace returns the name of the workspace in use, or "" for the
+// default one
+func ActiveWorkspace() string {
+	return activeWorkspace
+}
+
+// OpenWorkspace makes a workspace active ("" for the default one). Commands,
+// chat tools, saved conversations, and backups all use it from then on, and
+// the previous workspace's store is closed. Focus and the conversation in
+// progress belong to the old workspace, so they're put away.
+func OpenWorkspace(name string) error {
+	if workspaceOpener == nil {
+		return fmt.Errorf("workspaces are not available")
+	}
+	ws, err := workspaceOpener(name)
+	if err != nil {
+		return err
+	}
+
+	if GetStore() != nil {
+		saveChatSession()
+		PushSync()
+		GetStore().Close()
+	}
+	SetStore(autoBackupStore(ws.Store, ws.BackupDir))
+	activeWorkspace = name
+	SetBackupDir(ws.BackupDir)
+	DismissSuggestion()
+
+	focusProjectID = ""
+	focusTaskID = ""
+	chatHistory = nil
+	chatHistories = make(map[string][]*llm.Message)
+	if err := LoadChatSessions(ws.ChatPath); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: %v (starting a new conversation)\n", err)
+	}
+
+	// Shortcuts that are now reserved words, or shared by two projects, get
+	// their default back
+	renames, err := storage.FixShortcuts(ws.Store)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+	}
+	for _, r := range renames {
+		fmt.Fprintf(os.Stderr, "Renamed shortcut of %s from %q to %q (%s)\n", r.Project.Name, r.Old, r.Project.Shortcut, r.Reason)
+	}
+
+	PullSync()
+	return nil
+}
+
+// workspaceNames returns every workspace, the default one first
+func workspaceNames() []string {
+	names := append([]string{defaultWorkspace}, GetConfig().Workspaces...)
+	// A workspace set in the config by hand is still listed
+	if activeWorkspace != "" && !slices.Contains(names, activeWorkspace) {
+		names = append(names, activeWorkspace)
+	}
+	return names
+}
+
+func listWorkspaces() {
+	active := activeWorkspace
+	if active == "" {
+		active = defaultWorkspace
+	}
+	fmt.Println("Workspaces:")
+	for _, name := range workspaceNames() {
+		marker := " "
+		if name == active {
+			marker = "*"
+		}
+		fmt.Printf("%s %s\n", marker, name)
+	}
+	fmt.Println("Use /workspace switch <name> to change, or /workspace create <name> to add one.")
+}
+
+func createWorkspace(name string) {
+	if !workspaceNameRegex.MatchString(name) {
+		fmt.Println("Error: workspace names are 1-20 letters, digits, or hyphens")
+		return
+	}
+	if slices.ContainsFunc(workspaceNames(), func(n string) bool { return strings.EqualFold(n, name) }) {
+		fmt.Printf("Error: workspace %s already exists\n", name)
+		return
+	}
+	if workspaceOpener == nil {
+		fmt.Println("Error: workspaces are not available")
+		return
+	}
+
+	// Opening the store creates it, and shows any problem now rather than on switch
+	ws, err := workspaceOpener(name)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		return
+	}
+	ws.Store.Close()
+
+	GetConfig().Workspaces = append(GetConfig().Workspaces, name)
+	if err := GetConfig().Save(); err != nil {
+		fmt.Printf("Created workspace %s for this session, but could not save it: %v\n", name, err)
+		return
+	}
+	fmt.Printf("Created workspace %s. Use /workspace switch %s to use it.\n", name, name)
+}
+
+func switchWorkspace(name string) {
+	if strings.EqualFold(name, defaultWorkspace) {
+		name = ""
+	} else if i := slices.IndexFunc(workspaceNames(), func(n string) bool { return strings.EqualFold(n, name) }); i >= 0 {
+		name = workspaceNames()[i]
+	} else {
+		fmt.Printf("Error: no workspace named %s (create it with /workspace create %s)\n", name, name)
+		return
+	}
+
+	display := name
+	if display == "" {
+		display = defaultWorkspace
+	}
+	if name == activeWorkspace {
+		fmt.Printf("Already in workspace %s.\n", display)
+		return
+	}
+	// The share server keeps the store it was started with
+	if Serving() {
+		fmt.Println("Error: can't switch workspaces while serving share links")
+		return
+	}
+
+	if err := OpenWorkspace(name); err != nil {
+		fmt.Printf("Error: %v\n", err)
+		return
+	}
+
+	GetConfig().Workspace = name
+	saved := " (saved)"
+	if err := GetConfig().Save(); err != nil {
+		saved = fmt.Sprintf(" for this session; could not save it: %v", err)
+	}
+	projects, _ := GetStore().ListProjects()
+	fmt.Printf("Switched to workspace %s, %d projects%s\n", display, len(projects), saved)
+}
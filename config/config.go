@@ -0,0 +1,176 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+)
+
+// Config holds user preferences that persist across sessions
+type Config struct {
+	Model  string `json:"model,omitempty"`  // LLM model chosen with /model
+	Prompt string `json:"prompt,omitempty"` // REPL prompt template set with /prompt
+	Locale string `json:"locale,omitempty"` // language of command output set with /locale
+
+	// Persona is the chat prompt profile chosen with /persona; empty for the
+	// default
+	Persona string `json:"persona,omitempty"`
+
+	// Render is how /chat prints answers, set with /render: "plain" prints
+	// the model's markdown as written; empty renders it for the terminal
+	Render string `json:"render,omitempty"`
+
+	// Theme is the output colors set with /theme: "light", "none", or
+	// empty for dark
+	Theme string `json:"theme,omitempty"`
+
+	// TasksSort is the default order of /tasks: "due", "priority", "name",
+	// "created", or "manual" (the default), set with /tasks --sort <order> --save
+	TasksSort string `json:"tasks_sort,omitempty"`
+
+	// Confirm is which commands ask before changing data, set with /confirm:
+	// "always", "destructive", or "never" (the default)
+	Confirm string `json:"confirm,omitempty"`
+
+	// Workspace is the active workspace chosen with /workspace switch; empty
+	// for the default one. Workspaces lists those made with /workspace create.
+	Workspace  string   `json:"workspace,omitempty"`
+	Workspaces []string `json:"workspaces,omitempty"`
+
+	// StoreWarnMB is the data file size in MB above which startup suggests
+	// archiving and compaction (default 5; negative turns it off); edited by hand
+	StoreWarnMB int `json:"store_warn_mb,omitempty"`
+
+	// DailyCapacity is how much work fits in a day, e.g. "6h" or "90m"
+	// (default 4h), for /agenda, /capacity, and /planweek; edited by hand
+	DailyCapacity string `json:"daily_capacity,omitempty"`
+
+	// DueSoonHours is how far ahead /today flags tasks with a due time
+	// (default 2; negative turns it off); edited by hand
+	DueSoonHours int `json:"due_soon_hours,omitempty"`
+
+	// ContextTokens is roughly how many tokens of chat history are sent
+	// before older turns are summarized (default 32000; negative turns it
+	// off); edited by hand
+	ContextTokens int `json:"context_tokens,omitempty"`
+
+	// TrashDays is how long deleted projects and tasks stay in the trash
+	// for /restore before startup purges them (default 30; negative keeps
+	// them until /trash empty); edited by hand
+	TrashDays int `json:"trash_days,omitempty"`
+
+	// NewDayIdle is how long the REPL sits idle before it prints a new day's
+	// summary, e.g. "30m" (default 1h), or "off"; edited by hand
+	NewDayIdle string `json:"new_day_idle,omitempty"`
+
+	// FocusTimer starts a task's timer when /focus marks it and stops it
+	// when focus moves on; edited by hand
+	FocusTimer bool `json:"focus_timer,omitempty"`
+
+	// NotifyTimes are the "HH:MM" times reminders go out, set with /notify on
+	NotifyTimes []string `json:"notify_times,omitempty"`
+
+	// Backup configures /backup and scheduled backups; edited by hand
+	Backup *BackupConfig `json:"backup,omitempty"`
+
+	// Sync keeps the store in a git repository shared between machines;
+	// edited by hand
+	Sync *SyncConfig `json:"sync,omitempty"`
+
+	// Calendar is where /plan reads meetings from; edited by hand
+	Calendar *CalendarConfig `json:"calendar,omitempty"`
+
+	// Channels maps integration channels to what they may do: "read-only",
+	// "create-only", or "full", e.g. {"slack": "create-only"} for a bot that
+	// runs `twooms --channel slack ...`; edited by hand
+	Channels map[string]string `json:"channels,omitempty"`
+
+	// Keys overrides REPL key bindings, e.g. {"ctrl+g": "/tasks "}; edited by hand
+	Keys map[string]string `json:"keys,omitempty"`
+
+	// Hooks run shell commands or post to webhooks when task events happen;
+	// edited by hand
+	Hooks []HookConfig `json:"hooks,omitempty"`
+
+	path string
+}
+
+// BackupConfig says how often to back up and where to copy each backup
+type BackupConfig struct {
+	Every   string         `json:"every,omitempty"` // interval for scheduled backups, e.g. "24h"; empty for /backup now only
+	Keep    int            `json:"keep,omitempty"`  // local backups to keep (default 10)
+	Auto    int            `json:"auto,omitempty"`  // automatic backups before changes to keep (default 10; negative turns them off)
+	Targets []BackupTarget `json:"targets,omitempty"`
+}
+
+// SyncConfig says which git repository /sync keeps the store in
+type SyncConfig struct {
+	Repo   string `json:"repo"`             // a clone of the repository the machines share
+	Remote string `json:"remote,omitempty"` // remote to pull from and push to (default "origin")
+}
+
+// CalendarConfig says where to find busy times and which hours are for work
+type CalendarConfig struct {
+	URL         string `json:"url"`                    // .ics feed (https:// or webcal:// URL, or a file) or CalDAV calendar URL
+	Type        string `json:"type,omitempty"`         // "ics" (default) or "caldav"
+	Username    string `json:"username,omitempty"`     // for basic auth
+	PasswordEnv string `json:"password_env,omitempty"` // environment variable holding the password
+	DayStart    string `json:"day_start,omitempty"`    // "HH:MM" the workday starts (default 09:00)
+	DayEnd      string `json:"day_end,omitempty"`      // "HH:MM" the workday ends (default 17:00)
+}
+
+// HookConfig is a shell command or webhook fired on a task event. The
+// payload is a text/template; without one it's the event and task as JSON.
+type HookConfig struct {
+	Name    string            `json:"name,omitempty"`
+	On      string            `json:"on"`                // task_created, task_done, or due_passed
+	Command string            `json:"command,omitempty"` // run with sh -c, the payload on its standard input
+	URL     string            `json:"url,omitempty"`     // receives the payload as a POST
+	Headers map[string]string `json:"headers,omitempty"` // extra request headers for URL
+	Payload string            `json:"payload,omitempty"` // template for the payload, e.g. {"text": {{json .Task.Name}}}
+}
+
+// BackupTarget is a remote place each backup is copied to
+type BackupTarget struct {
+	Name     string `json:"name"`
+	Type     string `json:"type"`               // "s3", "rclone", or "scp"
+	Dest     string `json:"dest"`               // s3://bucket/prefix, remote:path, or host:dir
+	Endpoint string `json:"endpoint,omitempty"` // endpoint URL for S3-compatible services
+}
+
+// Load reads the config file at path; a missing file yields an empty config
+func Load(path string) (*Config, error) {
+	cfg := &Config{path: path}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return cfg, nil
+	}
+	if err != nil {
+		return cfg, err
+	}
+
+	if err := json.Unmarshal(data, cfg); err != nil {
+		return cfg, fmt.Errorf("invalid config file %s: %w", path, err)
+	}
+	return cfg, nil
+}
+
+// Save writes the config back to the file it was loaded from
+func (c *Config) Save() error {
+	if c.path == "" {
+		return fmt.Errorf("config has no file path")
+	}
+
+	// Prompt templates usually contain '>', which shouldn't be escaped as \u003e
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(c); err != nil {
+		return err
+	}
+	return os.WriteFile(c.path, buf.Bytes(), 0644)
+}
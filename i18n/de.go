@@ -0,0 +1,183 @@
+package i18n
+
+var german = Catalog{
+	"prefix.error": "Fehler",
+	"prefix.usage": "Verwendung:",
+
+	"error":              "Fehler: %v",
+	"error.invalid_date": "Fehler: Ungültiges Datumsformat. Verwende JJJJ-MM-TT (z. B. 2024-12-31)",
+	"list.due":           "fällig %s",
+	"list.blocked":       "[blockiert durch %s]",
+
+	"help.header":          "Verfügbare Befehle:",
+	"help.more":            "Gib /help <Befehl> ein, um seine Parameter und Beispiele zu sehen.",
+	"help.usage":           "Verwendung: %s",
+	"help.params":          "Parameter:",
+	"help.examples":        "Beispiele:",
+	"help.required":        "erforderlich",
+	"help.optional":        "optional",
+	"help.flag":            "Schalter, geschrieben --%s",
+	"help.enum":            "einer von %s",
+	"help.integer":         "eine ganze Zahl",
+	"help.format.date":     "JJJJ-MM-TT, oder none zum Entfernen",
+	"help.format.time":     "HH:MM, 24-Stunden",
+	"help.format.duration": "Minuten und/oder Stunden, z. B. 45m, 3h oder 1h30m",
+
+	"confirm.rerun":     "Mit --yes erneut ausführen, um es anzuwenden.",
+	"confirm.cancelled": "Abgebrochen. Nichts wurde geändert.",
+
+	"locale.current":  "Aktuelle Sprache: %s (verfügbar: %s)",
+	"locale.hint":     "Mit /locale <Sprache> wechseln",
+	"locale.unknown":  "Fehler: unbekannte Sprache %q (verfügbar: %s)",
+	"locale.switched": "Sprache auf %s umgestellt (gespeichert)",
+	"locale.unsaved":  "Für diese Sitzung auf %s umgestellt, aber Speichern fehlgeschlagen: %v",
+
+	"project.usage":         "Verwendung: /project <Name>",
+	"project.create_failed": "Fehler beim Anlegen des Projekts: %v",
+	"project.created":       "Projekt angelegt: %s (Kürzel: %s)",
+	"projects.list_failed":  "Fehler beim Auflisten der Projekte: %v",
+	"projects.none":         "Noch keine Projekte. Lege eines mit /project <Name> an",
+	"projects.header":       "Projekte:",
+	"projects.item":         "  [%s] %s (%d/%d Aufgaben erledigt)%s",
+	"projects.defaults":     "Standard %s",
+	"delproject.usage":      "Verwendung: /delproject <Projekt-ID> [--yes]",
+	"delproject.failed":     "Fehler beim Löschen des Projekts: %v",
+	"delproject.deleted":    "Projekt %s und seine Aufgaben in den Papierkorb verschoben (/restore holt es zurück)",
+	"delproject.confirm":    "Projekt %s und seine %d Aufgaben löschen?",
+	"projectdue.usage":      "Verwendung: /projectdue <Projekt-ID> <JJJJ-MM-TT|none>",
+	"projectdue.cleared":    "Fälligkeitsdatum von Projekt %s entfernt",
+	"projectdue.set":        "Fälligkeitsdatum von Projekt %s auf %s gesetzt",
+	"projectdue.overdue":    "%d Tage überfällig",
+	"projectdue.days_ago":   "vor %d Tagen",
+	"projectdue.today":      "heute",
+	"projectdue.one_left":   "noch 1 Tag",
+	"projectdue.days_left":  "noch %d Tage",
+	"projectdue.at_risk":    "gefährdet: %s offen",
+	"task.usage":            "Verwendung: /task <Projekt-ID> <Aufgabenname> [!p1] [@Kontext] [due:<Datum>] [~<Dauer>] [#Tag]",
+	"task.create_failed":    "Fehler beim Anlegen der Aufgabe: %v",
+	"task.created":          "Aufgabe angelegt: %s (ID: %s)",
+	"tasks.usage":           "Verwendung: /tasks <Projekt-ID> [--status <todo|in-progress|blocked|done>] [--sort <manual|due|priority|created|name>] [--group] [--save]",
+	"tasks.list_failed":     "Fehler beim Auflisten der Aufgaben: %v",
+	"tasks.header":          "Aufgaben in %s:",
+	"tasks.none":            "  Noch keine Aufgaben. Füge eine mit /task <Projekt-ID> <Name> hinzu",
+	"tasks.no_status":       "  Keine Aufgaben mit Status %s",
+	"tasks.total":           "Gesamt: %s",
+	"tasks.sort_invalid":    "Fehler: unbekannte Sortierung %q. Verwende manual, due, priority, created oder name",
+	"tasks.sort_saved":      "/tasks sortiert jetzt standardmäßig nach %s (gespeichert)",
+	"tasks.sort_unsaved":    "/tasks sortiert in dieser Sitzung nach %s, aber Speichern fehlgeschlagen: %v",
+	"tasks.bucket.overdue":  "Überfällig",
+	"tasks.bucket.today":    "Heute",
+	"tasks.bucket.week":     "Diese Woche",
+	"tasks.bucket.later":    "Später",
+	"tasks.bucket.none":     "Ohne Fälligkeitsdatum",
+	"tasks.bucket.done":     "Erledigt",
+	"done.usage":            "Verwendung: /done <Aufgaben-ID>... [--yes] (oder Filter statt IDs: --done, --open, --overdue, --project <id>, --tag <tag>)",
+	"done.marked":           "Aufgabe %s als erledigt markiert ✓",
+	"done.was_blocked":      "Hinweis: Sie war durch noch offene Aufgaben blockiert: %s",
+	"done.batch":            "%d Aufgaben als erledigt markiert ✓ (ein /undo macht es rückgängig):",
+	"done.batch_confirm":    "Diese %d Aufgaben als erledigt markieren?",
+	"undone.usage":          "Verwendung: /undone <Aufgaben-ID>... [--yes] (oder Filter statt IDs: --done, --open, --overdue, --project <id>, --tag <tag>)",
+	"undone.marked":         "Aufgabe %s als nicht erledigt markiert",
+	"undone.batch":          "%d Aufgaben als nicht erledigt markiert (ein /undo macht es rückgängig):",
+	"undone.batch_confirm":  "Diese %d Aufgaben als nicht erledigt markieren?",
+	"deltask.usage":         "Verwendung: /deltask <Aufgaben-ID>... [--yes] (oder Filter statt IDs: --done, --open, --overdue, --project <id>, --tag <tag>)",
+	"deltask.deleted":       "Aufgabe in den Papierkorb verschoben: %s (/restore holt sie zurück)",
+	"deltask.confirm":       "Aufgabe %s löschen?",
+	"deltask.batch":         "%d Aufgaben in den Papierkorb verschoben (ein /undo holt sie zurück):",
+	"deltask.batch_confirm": "Diese %d Aufgaben löschen?",
+	"due.usage":             "Verwendung: /due <Aufgaben-ID> <JJJJ-MM-TT|none> [HH:MM] (für mehrere Aufgaben: IDs oder Filter wie --project work --overdue, dann ein Datum oder eine Verschiebung wie +2d)",
+	"due.cleared":           "Fälligkeitsdatum von Aufgabe %s entfernt",
+	"due.set":               "Fälligkeitsdatum von Aufgabe %s auf %s gesetzt",
+	"due.batch":             "Fälligkeitsdaten von %d Aufgaben gesetzt (ein /undo macht es rückgängig):",
+	"due.batch_cleared":     "Fälligkeitsdaten von %d Aufgaben entfernt (ein /undo macht es rückgängig):",
+	"due.batch_confirm":     "Fälligkeitsdaten dieser %d Aufgaben ändern?",
+	"select.none":           "Keine Aufgaben passen.",
+	"select.unchanged":      "Nichts zu ändern: Die ausgewählten Aufgaben sind schon so.",
+	"duration.usage":        "Verwendung: /duration <Aufgaben-ID> <Dauer, z. B. 45m, 2h, 1h30m>",
+	"duration.invalid":      "Fehler: Ungültige Dauer. Verwende Minuten und/oder Stunden bis 100h, z. B. 15m, 45m, 3h oder 1h30m",
+	"duration.set":          "Dauer von Aufgabe %s auf %s gesetzt",
+	"priority.usage":        "Verwendung: /priority <Aufgaben-ID> <low|medium|high|urgent|none>",
+	"priority.invalid":      "Fehler: Ungültige Priorität. Verwende low, medium, high, urgent oder none",
+	"priority.cleared":      "Priorität von Aufgabe %s entfernt",
+	"priority.set":          "Priorität von Aufgabe %s auf %s gesetzt",
+	"status.usage":          "Verwendung: /status <Aufgaben-ID> <todo|in-progress|blocked|done>",
+	"status.invalid":        "Fehler: Ungültiger Status. Verwende todo, in-progress, blocked oder done",
+	"status.set":            "Status von Aufgabe %s auf %s gesetzt",
+	"status.todo":           "Offen",
+	"status.in-progress":    "In Arbeit",
+	"status.blocked":        "Blockiert",
+	"status.done":           "Erledigt",
+	"note.usage":            "Verwendung: /note <Aufgaben-ID> <Text|none>",
+	"note.cleared":          "Notiz von Aufgabe %s entfernt",
+	"note.set":              "Notiz für Aufgabe %s gespeichert",
+	"tag.usage":             "Verwendung: /tag <Aufgaben-ID> <Tag>",
+	"tag.added":             "Aufgabe %s mit #%s getaggt",
+	"untag.usage":           "Verwendung: /untag <Aufgaben-ID> <Tag>",
+	"untag.removed":         "#%s von Aufgabe %s entfernt",
+	"tagged.usage":          "Verwendung: /tagged <Tag>",
+	"tagged.header":         "Aufgaben mit #%s:",
+	"tagged.none":           "  Keine Aufgaben mit diesem Tag",
+
+	// Usage messages of the other commands
+	"agenda.usage":            "Verwendung: /agenda [week|next-week] [Projekt-ID]",
+	"archive.usage":           "Verwendung: /archive <Aufgaben-ID> | /archive --done <Projekt-ID>",
+	"archived.usage":          "Verwendung: /archived <Projekt-ID>",
+	"archivefile.usage":       "Verwendung: /archivefile <Projekt-ID> [Datei]",
+	"restorefile.usage":       "Verwendung: /restorefile <Datei>",
+	"backup.usage_restore":    "Verwendung: /backup restore <Name> [Ziel]",
+	"backup.usage":            "Verwendung: /backup [now|list|restore <Name> [Ziel]]",
+	"restore.usage":           "Verwendung: /restore <ID|Zeitstempel> [--yes] (siehe /trash und /backups)",
+	"bench.usage":             "Verwendung: /bench [Aufgaben ...] [--backend json|bolt] [--profile <Datei>]",
+	"usage.usage":             "Verwendung: /usage [override]",
+	"chat.usage":              "Verwendung: /chat <Nachricht>",
+	"chat.usage_dry_run":      "Verwendung: /chat --dry-run <Nachricht>",
+	"chat.usage_plan":         "Verwendung: /chat --plan <Nachricht>",
+	"compact.usage":           "Verwendung: /compact [Tage] [Datei] [--yes]",
+	"confirm.current":         "Bestätigung: %s. Verwendung: /confirm always|destructive|never",
+	"confirm.usage":           "Verwendung: /confirm always|destructive|never",
+	"debug.usage":             "Verwendung: /debug [file [Pfad|off]]",
+	"debug.usage_file":        "Verwendung: /debug file <Pfad>",
+	"defer.usage":             "Verwendung: /defer <Aufgaben-ID> <+1d|+1w|next-monday>",
+	"defer-all-overdue.usage": "Verwendung: /defer-all-overdue <+1d|+1w|next-monday> [Projekt-ID] [--yes]",
+	"blocks.usage":            "Verwendung: /blocks <Aufgaben-ID> <ID-der-blockierenden-Aufgabe>",
+	"deps.usage":              "Verwendung: /deps <Projekt-ID> [--dot]",
+	"dryrun.current":          "Probelauf: %s. Verwendung: /dryrun on|off (oder /chat --dry-run <Nachricht> für eine Nachricht)",
+	"dryrun.usage":            "Verwendung: /dryrun on|off",
+	"estimate.usage":          "Verwendung: /estimate <Aufgaben-ID|Projekt-ID> [--yes]",
+	"export.usage":            "Verwendung: /export <json|csv|md> [Datei]",
+	"import.usage":            "Verwendung: /import [todoist|asana] [--dry-run] [--yes] <Datei.json|Datei.csv|Datei.md>",
+	"hooks.usage":             "Verwendung: /hooks [test <Hook> <Aufgaben-ID>]",
+	"ics.usage":               "Verwendung: /ics [Projekt-ID] [Datei.ics] [--events]",
+	"in.usage":                "Verwendung: /in <Aufgabenname> [!p1] [@Kontext] [due:<Datum>] [~<Dauer>] [#Tag]",
+	"move.usage":              "Verwendung: /move <Aufgaben-ID> <Projekt-ID|inbox>",
+	"notify.usage":            "Verwendung: /notify [on [HH:MM ...]|off|status|test]",
+	"reorder.usage":           "Verwendung: /reorder <Aufgaben-ID> <Position>",
+	"moveup.usage":            "Verwendung: /moveup <Aufgaben-ID>",
+	"movedown.usage":          "Verwendung: /movedown <Aufgaben-ID>",
+	"persona.usage":           "Verwendung: /persona <Name>|default|show",
+	"plan.usage":              "Verwendung: /plan <Stunden> [Projekt-ID] [--why] (Stunden sind mit einem Kalender optional)",
+	"projectset.usage":        "Verwendung: /projectset <Projekt-ID> [<duration|due> <Wert|none>]",
+	"projectset.usage_field":  "Verwendung: /projectset <Projekt-ID> <duration|due> <Wert|none>",
+	"render.usage":            "Verwendung: /render [pretty|plain]",
+	"reorg.usage":             "Verwendung: /reorg [Datei] [--yes]",
+	"safemode.current":        "Sicherer Modus: %s. Verwendung: /safemode on|off",
+	"safemode.usage":          "Verwendung: /safemode on|off",
+	"search.usage":            "Verwendung: /search <Suchbegriff>",
+	"resume.usage":            "Verwendung: /resume <n>",
+	"share.usage":             "Verwendung: /share <Projekt-ID> [Tage]",
+	"unshare.usage":           "Verwendung: /unshare <Token>",
+	"shortcut.usage":          "Verwendung: /shortcut <Projekt-ID> <neues-Kürzel>",
+	"show.usage":              "Verwendung: /show <Aufgaben-ID>",
+	"task_get.usage":          "Verwendung: /task_get <Aufgaben-ID>",
+	"sync.usage":              "Verwendung: /sync [status|now]",
+	"taskbatch.usage":         "Verwendung: /taskbatch <Projekt-ID> [Aufgabe; Aufgabe; ...] [--yes]",
+	"theme.usage_names":       "Verwendung: /theme [%s]",
+	"start.usage":             "Verwendung: /start <Aufgaben-ID>",
+	"tools.usage":             "Verwendung: /tools [list|export [openapi|schema] [Datei]]",
+	"trash.usage":             "Verwendung: /trash [empty] [--yes]",
+	"workspace.usage_create":  "Verwendung: /workspace create <Name>",
+	"workspace.usage_switch":  "Verwendung: /workspace switch <Name>",
+	"workspace.usage":         "Verwendung: /workspace [list|create <Name>|switch <Name>]",
+	"undo.usage":              "Verwendung: /undo [Anzahl]",
+	"redo.usage":              "Verwendung: /redo [Anzahl]",
+}
@@ -0,0 +1,186 @@
+package i18n
+
+// english is the reference catalog: every key used in the code is here, and
+// the other catalogs translate it with the same format verbs in the same order
+var english = Catalog{
+	// Prefixes that mark a line as an error or usage message
+	"prefix.error": "Error",
+	"prefix.usage": "Usage:",
+
+	"error":              "Error: %v",
+	"error.invalid_date": "Error: Invalid date format. Use YYYY-MM-DD (e.g., 2024-12-31)",
+	"list.due":           "due %s",
+	"list.blocked":       "[blocked by %s]",
+
+	"help.header":          "Available commands:",
+	"help.more":            "Type /help <command> for its parameters and examples.",
+	"help.usage":           "Usage: %s",
+	"help.params":          "Parameters:",
+	"help.examples":        "Examples:",
+	"help.required":        "required",
+	"help.optional":        "optional",
+	"help.flag":            "flag, written --%s",
+	"help.enum":            "one of %s",
+	"help.integer":         "a whole number",
+	"help.format.date":     "YYYY-MM-DD, or none to clear",
+	"help.format.time":     "HH:MM, 24-hour",
+	"help.format.duration": "minutes and/or hours, like 45m, 3h, or 1h30m",
+
+	"confirm.rerun":     "Run again with --yes to apply.",
+	"confirm.cancelled": "Cancelled. Nothing changed.",
+
+	"locale.current":  "Current language: %s (available: %s)",
+	"locale.hint":     "Use /locale <language> to switch",
+	"locale.unknown":  "Error: unknown language %q (available: %s)",
+	"locale.switched": "Switched language to %s (saved)",
+	"locale.unsaved":  "Switched to %s for this session, but could not save it: %v",
+
+	"project.usage":         "Usage: /project <name>",
+	"project.create_failed": "Error creating project: %v",
+	"project.created":       "Created project: %s (shortcut: %s)",
+	"projects.list_failed":  "Error listing projects: %v",
+	"projects.none":         "No projects yet. Create one with /project <name>",
+	"projects.header":       "Projects:",
+	"projects.item":         "  [%s] %s (%d/%d tasks complete)%s",
+	"projects.defaults":     "defaults %s",
+	"delproject.usage":      "Usage: /delproject <project-id> [--yes]",
+	"delproject.failed":     "Error deleting project: %v",
+	"delproject.deleted":    "Moved project %s and its tasks to the trash (/restore brings it back)",
+	"delproject.confirm":    "Delete project %s and its %d tasks?",
+	"projectdue.usage":      "Usage: /projectdue <project-id> <YYYY-MM-DD|none>",
+	"projectdue.cleared":    "Cleared due date for project %s",
+	"projectdue.set":        "Set due date for project %s to %s",
+	"projectdue.overdue":    "%d days overdue",
+	"projectdue.days_ago":   "%d days ago",
+	"projectdue.today":      "today",
+	"projectdue.one_left":   "1 day left",
+	"projectdue.days_left":  "%d days left",
+	"projectdue.at_risk":    "at risk: %s open",
+	"task.usage":            "Usage: /task <project-id> <task name> [!p1] [@context] [due:<date>] [~<duration>] [#tag]",
+	"task.create_failed":    "Error creating task: %v",
+	"task.created":          "Created task: %s (ID: %s)",
+	"tasks.usage":           "Usage: /tasks <project-id> [--status <todo|in-progress|blocked|done>] [--sort <manual|due|priority|created|name>] [--group] [--save]",
+	"tasks.list_failed":     "Error listing tasks: %v",
+	"tasks.header":          "Tasks in %s:",
+	"tasks.none":            "  No tasks yet. Add one with /task <project-id> <name>",
+	"tasks.no_status":       "  No %s tasks",
+	"tasks.total":           "Total: %s",
+	"tasks.sort_invalid":    "Error: unknown sort %q. Use manual, due, priority, created, or name",
+	"tasks.sort_saved":      "/tasks now sorts by %s by default (saved)",
+	"tasks.sort_unsaved":    "/tasks sorts by %s for this session, but could not save it: %v",
+	"tasks.bucket.overdue":  "Overdue",
+	"tasks.bucket.today":    "Today",
+	"tasks.bucket.week":     "This week",
+	"tasks.bucket.later":    "Later",
+	"tasks.bucket.none":     "No due date",
+	"tasks.bucket.done":     "Done",
+	"done.usage":            "Usage: /done <task-id>... [--yes] (or filters instead of IDs: --done, --open, --overdue, --project <id>, --tag <tag>)",
+	"done.marked":           "Marked task %s as done ✓",
+	"done.was_blocked":      "Note: it was blocked by tasks that are still open: %s",
+	"done.batch":            "Marked %d tasks as done ✓ (one /undo puts them back):",
+	"done.batch_confirm":    "Mark these %d tasks as done?",
+	"undone.usage":          "Usage: /undone <task-id>... [--yes] (or filters instead of IDs: --done, --open, --overdue, --project <id>, --tag <tag>)",
+	"undone.marked":         "Marked task %s as not done",
+	"undone.batch":          "Marked %d tasks as not done (one /undo puts them back):",
+	"undone.batch_confirm":  "Mark these %d tasks as not done?",
+	"deltask.usage":         "Usage: /deltask <task-id>... [--yes] (or filters instead of IDs: --done, --open, --overdue, --project <id>, --tag <tag>)",
+	"deltask.deleted":       "Moved task to the trash: %s (/restore brings it back)",
+	"deltask.confirm":       "Delete task %s?",
+	"deltask.batch":         "Moved %d tasks to the trash (one /undo brings them back):",
+	"deltask.batch_confirm": "Delete these %d tasks?",
+	"due.usage":             "Usage: /due <task-id> <YYYY-MM-DD|none> [HH:MM] (for several tasks: IDs or filters like --project work --overdue, then a date or a shift like +2d)",
+	"due.cleared":           "Cleared due date for task %s",
+	"due.set":               "Set due date for task %s to %s",
+	"due.batch":             "Set the due dates of %d tasks (one /undo puts them back):",
+	"due.batch_cleared":     "Cleared the due dates of %d tasks (one /undo puts them back):",
+	"due.batch_confirm":     "Change the due dates of these %d tasks?",
+	"select.none":           "No tasks match.",
+	"select.unchanged":      "Nothing to change: the selected tasks are already like that.",
+	"duration.usage":        "Usage: /duration <task-id> <duration, e.g. 45m, 2h, 1h30m>",
+	"duration.invalid":      "Error: Invalid duration. Use minutes and/or hours up to 100h, like 15m, 45m, 3h, or 1h30m",
+	"duration.set":          "Set duration for task %s to %s",
+	"priority.usage":        "Usage: /priority <task-id> <low|medium|high|urgent|none>",
+	"priority.invalid":      "Error: Invalid priority. Use low, medium, high, urgent, or none",
+	"priority.cleared":      "Cleared priority for task %s",
+	"priority.set":          "Set priority for task %s to %s",
+	"status.usage":          "Usage: /status <task-id> <todo|in-progress|blocked|done>",
+	"status.invalid":        "Error: Invalid status. Use todo, in-progress, blocked, or done",
+	"status.set":            "Set status for task %s to %s",
+	"status.todo":           "To do",
+	"status.in-progress":    "In progress",
+	"status.blocked":        "Blocked",
+	"status.done":           "Done",
+	"note.usage":            "Usage: /note <task-id> <text|none>",
+	"note.cleared":          "Cleared note for task %s",
+	"note.set":              "Set note for task %s",
+	"tag.usage":             "Usage: /tag <task-id> <tag>",
+	"tag.added":             "Tagged task %s with #%s",
+	"untag.usage":           "Usage: /untag <task-id> <tag>",
+	"untag.removed":         "Removed #%s from task %s",
+	"tagged.usage":          "Usage: /tagged <tag>",
+	"tagged.header":         "Tasks tagged #%s:",
+	"tagged.none":           "  No tasks with this tag",
+
+	// Usage messages of the other commands
+	"agenda.usage":            "Usage: /agenda [week|next-week] [project-id]",
+	"archive.usage":           "Usage: /archive <task-id> | /archive --done <project-id>",
+	"archived.usage":          "Usage: /archived <project-id>",
+	"archivefile.usage":       "Usage: /archivefile <project-id> [file]",
+	"restorefile.usage":       "Usage: /restorefile <file>",
+	"backup.usage_restore":    "Usage: /backup restore <name> [target]",
+	"backup.usage":            "Usage: /backup [now|list|restore <name> [target]]",
+	"restore.usage":           "Usage: /restore <id|timestamp> [--yes] (see /trash and /backups)",
+	"bench.usage":             "Usage: /bench [tasks ...] [--backend json|bolt] [--profile <file>]",
+	"usage.usage":             "Usage: /usage [override]",
+	"chat.usage":              "Usage: /chat <message>",
+	"chat.usage_dry_run":      "Usage: /chat --dry-run <message>",
+	"chat.usage_plan":         "Usage: /chat --plan <message>",
+	"compact.usage":           "Usage: /compact [days] [file] [--yes]",
+	"confirm.current":         "Confirmation is %s. Usage: /confirm always|destructive|never",
+	"confirm.usage":           "Usage: /confirm always|destructive|never",
+	"debug.usage":             "Usage: /debug [file [path|off]]",
+	"debug.usage_file":        "Usage: /debug file <path>",
+	"defer.usage":             "Usage: /defer <task-id> <+1d|+1w|next-monday>",
+	"defer-all-overdue.usage": "Usage: /defer-all-overdue <+1d|+1w|next-monday> [project-id] [--yes]",
+	"blocks.usage":            "Usage: /blocks <task-id> <blocking-task-id>",
+	"deps.usage":              "Usage: /deps <project-id> [--dot]",
+	"dryrun.current":          "Dry run is %s. Usage: /dryrun on|off (or /chat --dry-run <message> for one message)",
+	"dryrun.usage":            "Usage: /dryrun on|off",
+	"estimate.usage":          "Usage: /estimate <task-id|project-id> [--yes]",
+	"export.usage":            "Usage: /export <json|csv|md> [file]",
+	"import.usage":            "Usage: /import [todoist|asana] [--dry-run] [--yes] <file.json|file.csv|file.md>",
+	"hooks.usage":             "Usage: /hooks [test <hook> <task-id>]",
+	"ics.usage":               "Usage: /ics [project-id] [file.ics] [--events]",
+	"in.usage":                "Usage: /in <task name> [!p1] [@context] [due:<date>] [~<duration>] [#tag]",
+	"move.usage":              "Usage: /move <task-id> <project-id|inbox>",
+	"notify.usage":            "Usage: /notify [on [HH:MM ...]|off|status|test]",
+	"reorder.usage":           "Usage: /reorder <task-id> <position>",
+	"moveup.usage":            "Usage: /moveup <task-id>",
+	"movedown.usage":          "Usage: /movedown <task-id>",
+	"persona.usage":           "Usage: /persona <name>|default|show",
+	"plan.usage":              "Usage: /plan <hours> [project-id] [--why] (hours is optional with a calendar)",
+	"projectset.usage":        "Usage: /projectset <project-id> [<duration|due> <value|none>]",
+	"projectset.usage_field":  "Usage: /projectset <project-id> <duration|due> <value|none>",
+	"render.usage":            "Usage: /render [pretty|plain]",
+	"reorg.usage":             "Usage: /reorg [file] [--yes]",
+	"safemode.current":        "Safe mode is %s. Usage: /safemode on|off",
+	"safemode.usage":          "Usage: /safemode on|off",
+	"search.usage":            "Usage: /search <query>",
+	"resume.usage":            "Usage: /resume <n>",
+	"share.usage":             "Usage: /share <project-id> [days]",
+	"unshare.usage":           "Usage: /unshare <token>",
+	"shortcut.usage":          "Usage: /shortcut <project-id> <new-shortcut>",
+	"show.usage":              "Usage: /show <task-id>",
+	"task_get.usage":          "Usage: /task_get <task-id>",
+	"sync.usage":              "Usage: /sync [status|now]",
+	"taskbatch.usage":         "Usage: /taskbatch <project-id> [task; task; ...] [--yes]",
+	"theme.usage_names":       "Usage: /theme [%s]",
+	"start.usage":             "Usage: /start <task-id>",
+	"tools.usage":             "Usage: /tools [list|export [openapi|schema] [file]]",
+	"trash.usage":             "Usage: /trash [empty] [--yes]",
+	"workspace.usage_create":  "Usage: /workspace create <name>",
+	"workspace.usage_switch":  "Usage: /workspace switch <name>",
+	"workspace.usage":         "Usage: /workspace [list|create <name>|switch <name>]",
+	"undo.usage":              "Usage: /undo [count]",
+	"redo.usage":              "Usage: /redo [count]",
+}
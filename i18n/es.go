@@ -0,0 +1,183 @@
+package i18n
+
+var spanish = Catalog{
+	"prefix.error": "Error",
+	"prefix.usage": "Uso:",
+
+	"error":              "Error: %v",
+	"error.invalid_date": "Error: formato de fecha no válido. Usa AAAA-MM-DD (p. ej., 2024-12-31)",
+	"list.due":           "vence %s",
+	"list.blocked":       "[bloqueada por %s]",
+
+	"help.header":          "Comandos disponibles:",
+	"help.more":            "Escribe /help <comando> para ver sus parámetros y ejemplos.",
+	"help.usage":           "Uso: %s",
+	"help.params":          "Parámetros:",
+	"help.examples":        "Ejemplos:",
+	"help.required":        "obligatorio",
+	"help.optional":        "opcional",
+	"help.flag":            "opción, escrita --%s",
+	"help.enum":            "uno de %s",
+	"help.integer":         "un número entero",
+	"help.format.date":     "AAAA-MM-DD, o none para quitarla",
+	"help.format.time":     "HH:MM, formato de 24 horas",
+	"help.format.duration": "minutos y/u horas, como 45m, 3h o 1h30m",
+
+	"confirm.rerun":     "Vuelve a ejecutarlo con --yes para aplicarlo.",
+	"confirm.cancelled": "Cancelado. No se cambió nada.",
+
+	"locale.current":  "Idioma actual: %s (disponibles: %s)",
+	"locale.hint":     "Usa /locale <idioma> para cambiarlo",
+	"locale.unknown":  "Error: idioma desconocido %q (disponibles: %s)",
+	"locale.switched": "Idioma cambiado a %s (guardado)",
+	"locale.unsaved":  "Cambiado a %s para esta sesión, pero no se pudo guardar: %v",
+
+	"project.usage":         "Uso: /project <nombre>",
+	"project.create_failed": "Error al crear el proyecto: %v",
+	"project.created":       "Proyecto creado: %s (atajo: %s)",
+	"projects.list_failed":  "Error al listar los proyectos: %v",
+	"projects.none":         "Aún no hay proyectos. Crea uno con /project <nombre>",
+	"projects.header":       "Proyectos:",
+	"projects.item":         "  [%s] %s (%d/%d tareas completadas)%s",
+	"projects.defaults":     "por defecto %s",
+	"delproject.usage":      "Uso: /delproject <id-proyecto> [--yes]",
+	"delproject.failed":     "Error al eliminar el proyecto: %v",
+	"delproject.deleted":    "Proyecto %s y sus tareas movidos a la papelera (/restore lo recupera)",
+	"delproject.confirm":    "¿Eliminar el proyecto %s y sus %d tareas?",
+	"projectdue.usage":      "Uso: /projectdue <id-proyecto> <AAAA-MM-DD|none>",
+	"projectdue.cleared":    "Fecha límite eliminada del proyecto %s",
+	"projectdue.set":        "Fecha límite del proyecto %s fijada en %s",
+	"projectdue.overdue":    "%d días de retraso",
+	"projectdue.days_ago":   "hace %d días",
+	"projectdue.today":      "hoy",
+	"projectdue.one_left":   "queda 1 día",
+	"projectdue.days_left":  "quedan %d días",
+	"projectdue.at_risk":    "en riesgo: %s pendientes",
+	"task.usage":            "Uso: /task <id-proyecto> <nombre de la tarea> [!p1] [@contexto] [due:<fecha>] [~<duración>] [#etiqueta]",
+	"task.create_failed":    "Error al crear la tarea: %v",
+	"task.created":          "Tarea creada: %s (ID: %s)",
+	"tasks.usage":           "Uso: /tasks <id-proyecto> [--status <todo|in-progress|blocked|done>] [--sort <manual|due|priority|created|name>] [--group] [--save]",
+	"tasks.list_failed":     "Error al listar las tareas: %v",
+	"tasks.header":          "Tareas en %s:",
+	"tasks.none":            "  Aún no hay tareas. Añade una con /task <id-proyecto> <nombre>",
+	"tasks.no_status":       "  No hay tareas con estado %s",
+	"tasks.total":           "Total: %s",
+	"tasks.sort_invalid":    "Error: orden desconocido %q. Usa manual, due, priority, created o name",
+	"tasks.sort_saved":      "/tasks ahora ordena por %s de forma predeterminada (guardado)",
+	"tasks.sort_unsaved":    "/tasks ordena por %s en esta sesión, pero no se pudo guardar: %v",
+	"tasks.bucket.overdue":  "Vencidas",
+	"tasks.bucket.today":    "Hoy",
+	"tasks.bucket.week":     "Esta semana",
+	"tasks.bucket.later":    "Más adelante",
+	"tasks.bucket.none":     "Sin fecha límite",
+	"tasks.bucket.done":     "Hechas",
+	"done.usage":            "Uso: /done <id-tarea>... [--yes] (o filtros en lugar de IDs: --done, --open, --overdue, --project <id>, --tag <tag>)",
+	"done.marked":           "Tarea %s marcada como hecha ✓",
+	"done.was_blocked":      "Nota: estaba bloqueada por tareas aún abiertas: %s",
+	"done.batch":            "%d tareas marcadas como hechas ✓ (un /undo lo deshace):",
+	"done.batch_confirm":    "¿Marcar estas %d tareas como hechas?",
+	"undone.usage":          "Uso: /undone <id-tarea>... [--yes] (o filtros en lugar de IDs: --done, --open, --overdue, --project <id>, --tag <tag>)",
+	"undone.marked":         "Tarea %s marcada como no hecha",
+	"undone.batch":          "%d tareas marcadas como no hechas (un /undo lo deshace):",
+	"undone.batch_confirm":  "¿Marcar estas %d tareas como no hechas?",
+	"deltask.usage":         "Uso: /deltask <id-tarea>... [--yes] (o filtros en lugar de IDs: --done, --open, --overdue, --project <id>, --tag <tag>)",
+	"deltask.deleted":       "Tarea movida a la papelera: %s (/restore la recupera)",
+	"deltask.confirm":       "¿Eliminar la tarea %s?",
+	"deltask.batch":         "%d tareas movidas a la papelera (un /undo las recupera):",
+	"deltask.batch_confirm": "¿Eliminar estas %d tareas?",
+	"due.usage":             "Uso: /due <id-tarea> <AAAA-MM-DD|none> [HH:MM] (para varias tareas: IDs o filtros como --project work --overdue, y luego una fecha o un desplazamiento como +2d)",
+	"due.cleared":           "Fecha límite eliminada de la tarea %s",
+	"due.set":               "Fecha límite de la tarea %s fijada en %s",
+	"due.batch":             "Fechas límite de %d tareas fijadas (un /undo lo deshace):",
+	"due.batch_cleared":     "Fechas límite de %d tareas borradas (un /undo lo deshace):",
+	"due.batch_confirm":     "¿Cambiar las fechas límite de estas %d tareas?",
+	"select.none":           "Ninguna tarea coincide.",
+	"select.unchanged":      "Nada que cambiar: las tareas seleccionadas ya están así.",
+	"duration.usage":        "Uso: /duration <id-tarea> <duración, p. ej. 45m, 2h, 1h30m>",
+	"duration.invalid":      "Error: duración no válida. Usa minutos y/u horas hasta 100h, como 15m, 45m, 3h o 1h30m",
+	"duration.set":          "Duración de la tarea %s fijada en %s",
+	"priority.usage":        "Uso: /priority <id-tarea> <low|medium|high|urgent|none>",
+	"priority.invalid":      "Error: prioridad no válida. Usa low, medium, high, urgent o none",
+	"priority.cleared":      "Prioridad eliminada de la tarea %s",
+	"priority.set":          "Prioridad de la tarea %s fijada en %s",
+	"status.usage":          "Uso: /status <id-tarea> <todo|in-progress|blocked|done>",
+	"status.invalid":        "Error: estado no válido. Usa todo, in-progress, blocked o done",
+	"status.set":            "Estado de la tarea %s fijado en %s",
+	"status.todo":           "Por hacer",
+	"status.in-progress":    "En curso",
+	"status.blocked":        "Bloqueada",
+	"status.done":           "Hecha",
+	"note.usage":            "Uso: /note <id-tarea> <texto|none>",
+	"note.cleared":          "Nota eliminada de la tarea %s",
+	"note.set":              "Nota guardada en la tarea %s",
+	"tag.usage":             "Uso: /tag <id-tarea> <etiqueta>",
+	"tag.added":             "Tarea %s etiquetada con #%s",
+	"untag.usage":           "Uso: /untag <id-tarea> <etiqueta>",
+	"untag.removed":         "Se quitó #%s de la tarea %s",
+	"tagged.usage":          "Uso: /tagged <etiqueta>",
+	"tagged.header":         "Tareas con la etiqueta #%s:",
+	"tagged.none":           "  Ninguna tarea tiene esta etiqueta",
+
+	// Usage messages of the other commands
+	"agenda.usage":            "Uso: /agenda [week|next-week] [id-proyecto]",
+	"archive.usage":           "Uso: /archive <id-tarea> | /archive --done <id-proyecto>",
+	"archived.usage":          "Uso: /archived <id-proyecto>",
+	"archivefile.usage":       "Uso: /archivefile <id-proyecto> [archivo]",
+	"restorefile.usage":       "Uso: /restorefile <archivo>",
+	"backup.usage_restore":    "Uso: /backup restore <nombre> [destino]",
+	"backup.usage":            "Uso: /backup [now|list|restore <nombre> [destino]]",
+	"restore.usage":           "Uso: /restore <id|marca-de-tiempo> [--yes] (ver /trash y /backups)",
+	"bench.usage":             "Uso: /bench [tareas ...] [--backend json|bolt] [--profile <archivo>]",
+	"usage.usage":             "Uso: /usage [override]",
+	"chat.usage":              "Uso: /chat <mensaje>",
+	"chat.usage_dry_run":      "Uso: /chat --dry-run <mensaje>",
+	"chat.usage_plan":         "Uso: /chat --plan <mensaje>",
+	"compact.usage":           "Uso: /compact [días] [archivo] [--yes]",
+	"confirm.current":         "Confirmación: %s. Uso: /confirm always|destructive|never",
+	"confirm.usage":           "Uso: /confirm always|destructive|never",
+	"debug.usage":             "Uso: /debug [file [ruta|off]]",
+	"debug.usage_file":        "Uso: /debug file <ruta>",
+	"defer.usage":             "Uso: /defer <id-tarea> <+1d|+1w|next-monday>",
+	"defer-all-overdue.usage": "Uso: /defer-all-overdue <+1d|+1w|next-monday> [id-proyecto] [--yes]",
+	"blocks.usage":            "Uso: /blocks <id-tarea> <id-tarea-que-bloquea>",
+	"deps.usage":              "Uso: /deps <id-proyecto> [--dot]",
+	"dryrun.current":          "Simulación: %s. Uso: /dryrun on|off (o /chat --dry-run <mensaje> para un solo mensaje)",
+	"dryrun.usage":            "Uso: /dryrun on|off",
+	"estimate.usage":          "Uso: /estimate <id-tarea|id-proyecto> [--yes]",
+	"export.usage":            "Uso: /export <json|csv|md> [archivo]",
+	"import.usage":            "Uso: /import [todoist|asana] [--dry-run] [--yes] <archivo.json|archivo.csv|archivo.md>",
+	"hooks.usage":             "Uso: /hooks [test <hook> <id-tarea>]",
+	"ics.usage":               "Uso: /ics [id-proyecto] [archivo.ics] [--events]",
+	"in.usage":                "Uso: /in <nombre de la tarea> [!p1] [@contexto] [due:<fecha>] [~<duración>] [#etiqueta]",
+	"move.usage":              "Uso: /move <id-tarea> <id-proyecto|inbox>",
+	"notify.usage":            "Uso: /notify [on [HH:MM ...]|off|status|test]",
+	"reorder.usage":           "Uso: /reorder <id-tarea> <posición>",
+	"moveup.usage":            "Uso: /moveup <id-tarea>",
+	"movedown.usage":          "Uso: /movedown <id-tarea>",
+	"persona.usage":           "Uso: /persona <nombre>|default|show",
+	"plan.usage":              "Uso: /plan <horas> [id-proyecto] [--why] (las horas son opcionales con un calendario)",
+	"projectset.usage":        "Uso: /projectset <id-proyecto> [<duration|due> <valor|none>]",
+	"projectset.usage_field":  "Uso: /projectset <id-proyecto> <duration|due> <valor|none>",
+	"render.usage":            "Uso: /render [pretty|plain]",
+	"reorg.usage":             "Uso: /reorg [archivo] [--yes]",
+	"safemode.current":        "Modo seguro: %s. Uso: /safemode on|off",
+	"safemode.usage":          "Uso: /safemode on|off",
+	"search.usage":            "Uso: /search <consulta>",
+	"resume.usage":            "Uso: /resume <n>",
+	"share.usage":             "Uso: /share <id-proyecto> [días]",
+	"unshare.usage":           "Uso: /unshare <token>",
+	"shortcut.usage":          "Uso: /shortcut <id-proyecto> <nuevo-atajo>",
+	"show.usage":              "Uso: /show <id-tarea>",
+	"task_get.usage":          "Uso: /task_get <id-tarea>",
+	"sync.usage":              "Uso: /sync [status|now]",
+	"taskbatch.usage":         "Uso: /taskbatch <id-proyecto> [tarea; tarea; ...] [--yes]",
+	"theme.usage_names":       "Uso: /theme [%s]",
+	"start.usage":             "Uso: /start <id-tarea>",
+	"tools.usage":             "Uso: /tools [list|export [openapi|schema] [archivo]]",
+	"trash.usage":             "Uso: /trash [empty] [--yes]",
+	"workspace.usage_create":  "Uso: /workspace create <nombre>",
+	"workspace.usage_switch":  "Uso: /workspace switch <nombre>",
+	"workspace.usage":         "Uso: /workspace [list|create <nombre>|switch <nombre>]",
+	"undo.usage":              "Uso: /undo [cantidad]",
+	"redo.usage":              "Uso: /redo [cantidad]",
+}
@@ -0,0 +1,93 @@
+// Package i18n translates user-facing command output. Messages are looked up
+// by key in the current locale's catalog; a key a catalog lacks falls back to
+// English, so a partial translation still shows every message.
+package i18n
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// DefaultLocale is used until SetLocale picks another
+const DefaultLocale = "en"
+
+// Catalog maps message keys to fmt format strings
+type Catalog map[string]string
+
+var catalogs = map[string]Catalog{
+	"en": english,
+	"es": spanish,
+	"de": german,
+}
+
+var current = DefaultLocale
+
+// Locales lists the available locales, sorted
+func Locales() []string {
+	locales := make([]string, 0, len(catalogs))
+	for locale := range catalogs {
+		locales = append(locales, locale)
+	}
+	sort.Strings(locales)
+	return locales
+}
+
+// Locale returns the current locale
+func Locale() string {
+	return current
+}
+
+// SetLocale switches the locale. Region and encoding suffixes are ignored,
+// so "es", "es-MX", and "es_ES.UTF-8" all select Spanish.
+func SetLocale(locale string) error {
+	normalized := Normalize(locale)
+	if _, ok := catalogs[normalized]; !ok {
+		return fmt.Errorf("unknown locale %q (available: %s)", locale, strings.Join(Locales(), ", "))
+	}
+	current = normalized
+	return nil
+}
+
+// Normalize reduces a locale like "de_DE.UTF-8" to its language, "de"
+func Normalize(locale string) string {
+	locale = strings.ToLower(strings.TrimSpace(locale))
+	if i := strings.IndexAny(locale, "_-."); i >= 0 {
+		locale = locale[:i]
+	}
+	return locale
+}
+
+// T formats the message for key in the current locale. An unknown key is
+// returned as is, which makes a missing English entry easy to spot.
+func T(key string, args ...any) string {
+	format, ok := catalogs[current][key]
+	if !ok {
+		format, ok = english[key]
+	}
+	if !ok {
+		return key
+	}
+	if len(args) == 0 {
+		return format
+	}
+	return fmt.Sprintf(format, args...)
+}
+
+// Has reports whether key is a known message
+func Has(key string) bool {
+	_, ok := english[key]
+	return ok
+}
+
+// All returns the message for key in every locale, for recognizing output
+// (like an "Error" prefix) whatever language it was printed in
+func All(key string) []string {
+	var messages []string
+	for _, locale := range Locales() {
+		if format, ok := catalogs[locale][key]; ok {
+			messages = append(messages, format)
+		}
+	}
+	return messages
+}
@@ -0,0 +1,62 @@
+package i18n
+
+import (
+	"regexp"
+	"slices"
+	"testing"
+)
+
+// verbRegex matches fmt verbs, ignoring flags and widths
+var verbRegex = regexp.MustCompile(`%[-+# 0-9.]*[a-zA-Z%]`)
+
+func TestCatalogsMatchEnglish(t *testing.T) {
+	for locale, catalog := range catalogs {
+		for key, format := range catalog {
+			want, ok := english[key]
+			if !ok {
+				t.Errorf("%s: key %q is not in the English catalog", locale, key)
+				continue
+			}
+			if got, want := verbRegex.FindAllString(format, -1), verbRegex.FindAllString(want, -1); !slices.Equal(got, want) {
+				t.Errorf("%s: %q has verbs %v, English has %v", locale, key, got, want)
+			}
+		}
+		for key := range english {
+			if _, ok := catalog[key]; !ok {
+				t.Errorf("%s: missing translation for %q", locale, key)
+			}
+		}
+	}
+}
+
+func TestT(t *testing.T) {
+	defer SetLocale(DefaultLocale)
+
+	if got := T("project.created", "Work", "wk"); got != "Created project: Work (shortcut: wk)" {
+		t.Errorf("Expected English message, got %q", got)
+	}
+
+	if err := SetLocale("de_DE.UTF-8"); err != nil || Locale() != "de" {
+		t.Fatalf("Expected de_DE.UTF-8 to select German, got %q, %v", Locale(), err)
+	}
+	if got := T("project.created", "Arbeit", "ar"); got != "Projekt angelegt: Arbeit (Kürzel: ar)" {
+		t.Errorf("Expected German message, got %q", got)
+	}
+
+	// Keys missing from a catalog fall back to English, then to the key
+	english["test.only_english"] = "only %s"
+	defer delete(english, "test.only_english")
+	if got := T("test.only_english", "English"); got != "only English" {
+		t.Errorf("Expected English fallback, got %q", got)
+	}
+	if got := T("test.missing"); got != "test.missing" {
+		t.Errorf("Expected the key for an unknown message, got %q", got)
+	}
+	if !Has("test.only_english") || Has("test.missing") {
+		t.Error("Expected Has to report only known keys")
+	}
+
+	if err := SetLocale("fr"); err == nil || Locale() != "de" {
+		t.Errorf("Expected unknown locale rejected and the locale unchanged, got %q, %v", Locale(), err)
+	}
+}
@@ -0,0 +1,229 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/chzyer/readline"
+
+	"twooms/commands"
+)
+
+// defaultKeyBindings are the REPL shortcuts; the "keys" section of
+// ~/.twooms.config.json adds to them, changes them, or turns one off with "none"
+var defaultKeyBindings = map[string]string{
+	"ctrl+t":    "task",
+	"ctrl+d":    "today",
+	"alt+enter": "chat",
+}
+
+// keyActions describes the named actions a key can be bound to. Any value
+// starting with "/" is also an action: it inserts that text.
+var keyActions = map[string]string{
+	"task":  "insert /task and the focused project's shortcut",
+	"today": "toggle showing today's tasks before each prompt",
+	"chat":  "send the line to /chat, even if it starts with /",
+	"none":  "no action (turns off a default binding)",
+}
+
+const (
+	// keyAltEnter stands in for Esc+Enter, which readline otherwise reads as Enter
+	keyAltEnter rune = 0xE000
+	// keyInsertBase is the first of the runes that stand in for insert
+	// bindings, so the listener can tell them apart from typed text
+	keyInsertBase rune = 0xE100
+)
+
+// keyBindings maps keys to actions. Insert actions go through readline's
+// listener, which can rewrite the line; the others have to act before
+// readline handles the key (Ctrl+D would otherwise exit), so they're done in
+// the input filter.
+type keyBindings struct {
+	actions map[rune]string // key to action
+	inserts map[rune]string // stand-in rune to an action that inserts text
+
+	mu        sync.Mutex
+	forceChat bool
+	todayView bool
+	out       io.Writer
+}
+
+// newKeyBindings combines the defaults with the configured overrides,
+// skipping (and reporting) unknown keys and actions
+func newKeyBindings(overrides map[string]string) (*keyBindings, []error) {
+	bindings := make(map[string]string)
+	for key, action := range defaultKeyBindings {
+		bindings[key] = action
+	}
+	for key, action := range overrides {
+		bindings[strings.ToLower(key)] = action
+	}
+
+	k := &keyBindings{actions: make(map[rune]string), inserts: make(map[rune]string)}
+	var errs []error
+	for _, name := range sortedBindingKeys(bindings) {
+		action := bindings[name]
+		if _, ok := keyActions[action]; !ok && !strings.HasPrefix(action, "/") {
+			errs = append(errs, fmt.Errorf("key %s: unknown action %q (use task, today, chat, none, or text starting with /)", name, action))
+			continue
+		}
+		key, err := parseKey(name)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		if action == "none" {
+			continue
+		}
+		k.actions[key] = action
+		if action != "chat" && action != "today" {
+			k.inserts[keyInsertBase+key] = action
+		}
+	}
+	return k, errs
+}
+
+// parseKey turns a key name like "ctrl+t" or "alt+enter" into the rune
+// readline reads for it
+func parseKey(name string) (rune, error) {
+	if name == "alt+enter" {
+		return keyAltEnter, nil
+	}
+	if letter, ok := strings.CutPrefix(name, "ctrl+"); ok && len(letter) == 1 && letter[0] >= 'a' && letter[0] <= 'z' {
+		// Ctrl+C, Tab, Enter, and Backspace can't be rebound
+		if strings.Contains("chijm", letter) {
+			return 0, fmt.Errorf("key %s can't be rebound", name)
+		}
+		return rune(letter[0]-'a') + 1, nil
+	}
+	return 0, fmt.Errorf("unknown key %q (use ctrl+<letter> or alt+enter)", name)
+}
+
+// keyAction maps a key to its action and the rune readline should read in
+// its place: Enter for "chat", a stand-in rune for an insert binding, and
+// nothing (pass is false) for "today". Unbound keys map to themselves.
+func (k *keyBindings) keyAction(r rune) (action string, out rune, pass bool) {
+	action, ok := k.actions[r]
+	switch {
+	case !ok:
+		return "", r, true
+	case action == "chat":
+		return action, readline.CharEnter, true
+	case action == "today":
+		return action, r, false
+	}
+	return action, keyInsertBase + r, true
+}
+
+// filter runs before readline handles a key. Insert bindings are swapped for
+// a stand-in rune for the listener; "chat" submits the line and flags it;
+// "today" toggles the view and prints it right away.
+func (k *keyBindings) filter(r rune) (rune, bool) {
+	action, out, pass := k.keyAction(r)
+
+	switch action {
+	case "chat":
+		k.mu.Lock()
+		k.forceChat = true
+		k.mu.Unlock()
+	case "today":
+		k.mu.Lock()
+		k.todayView = !k.todayView
+		on := k.todayView
+		k.mu.Unlock()
+		if on {
+			fmt.Fprintln(k.out, "\nToday view on (shown before each prompt):")
+			fmt.Fprintln(k.out, todayView())
+		} else {
+			fmt.Fprintln(k.out, "\nToday view off.")
+		}
+	}
+
+	return out, pass
+}
+
+// OnChange implements readline.Listener, replacing an insert binding's
+// stand-in rune with its text
+func (k *keyBindings) OnChange(line []rune, pos int, key rune) ([]rune, int, bool) {
+	action, ok := k.inserts[key]
+	if !ok || pos == 0 || line[pos-1] != key {
+		return nil, 0, false
+	}
+
+	text := insertText(action)
+	newLine := append(append(append([]rune{}, line[:pos-1]...), text...), line[pos:]...)
+	return newLine, pos - 1 + len(text), true
+}
+
+// insertText is the text an insert action puts in the line
+func insertText(action string) []rune {
+	if action != "task" {
+		return []rune(action)
+	}
+	text := "/task "
+	if shortcut := commands.FocusedShortcut(); shortcut != "" {
+		text += shortcut + " "
+	}
+	return []rune(text)
+}
+
+// takeForceChat reports whether the last line was sent with the chat key,
+// clearing the flag
+func (k *keyBindings) takeForceChat() bool {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	force := k.forceChat
+	k.forceChat = false
+	return force
+}
+
+// showToday reports whether the today view is on
+func (k *keyBindings) showToday() bool {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	return k.todayView
+}
+
+// todayView lists the tasks due today, like /today
+func todayView() string {
+	_, output, err := commands.ExecuteWithOutput("/today")
+	if err != nil {
+		return fmt.Sprintf("Error: %v", err)
+	}
+	return output
+}
+
+func sortedBindingKeys(bindings map[string]string) []string {
+	keys := make([]string, 0, len(bindings))
+	for key := range bindings {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// altEnterReader rewrites Esc+Enter, which most terminals send for Alt+Enter,
+// to keyAltEnter. Readline drops the Esc and reads a plain Enter, so the key
+// has to be told apart before readline sees it.
+type altEnterReader struct {
+	r       io.Reader
+	pending []byte
+}
+
+func (a *altEnterReader) Read(p []byte) (int, error) {
+	if len(a.pending) == 0 {
+		buf := make([]byte, len(p))
+		n, err := a.r.Read(buf)
+		if n == 0 {
+			return 0, err
+		}
+		a.pending = bytes.ReplaceAll(buf[:n], []byte("\x1b\r"), []byte(string(keyAltEnter)))
+	}
+	n := copy(p, a.pending)
+	a.pending = a.pending[n:]
+	return n, nil
+}
@@ -0,0 +1,186 @@
+package main
+
+import (
+	"bytes"
+	"io"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/chzyer/readline"
+
+	"twooms/commands"
+	"twooms/storage"
+)
+
+const (
+	ctrlD rune = 4
+	ctrlG rune = 7
+	ctrlT rune = 20
+	ctrlY rune = 25
+)
+
+// setupKeysStore gives commands a store in a temporary home, so focus and
+// /today have something to read
+func setupKeysStore(t *testing.T) storage.Store {
+	t.Helper()
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	store, err := storage.NewJSONStore(filepath.Join(home, "test.json"))
+	if err != nil {
+		t.Fatalf("Failed to create test store: %v", err)
+	}
+	commands.SetStore(store)
+	t.Cleanup(func() { store.Close() })
+	return store
+}
+
+func TestNewKeyBindings(t *testing.T) {
+	for _, tc := range []struct {
+		name      string
+		overrides map[string]string
+		want      map[rune]string
+		wantErrs  []string
+	}{
+		{
+			name: "defaults",
+			want: map[rune]string{ctrlT: "task", ctrlD: "today", keyAltEnter: "chat"},
+		},
+		{
+			name:      "overrides",
+			overrides: map[string]string{"Ctrl+D": "none", "ctrl+g": "today", "ctrl+y": "/tasks "},
+			want:      map[rune]string{ctrlT: "task", ctrlG: "today", ctrlY: "/tasks ", keyAltEnter: "chat"},
+		},
+		{
+			name:      "bad bindings are skipped",
+			overrides: map[string]string{"ctrl+c": "chat", "ctrl+y": "list", "f5": "today", "ctrl+tab": "task"},
+			want:      map[rune]string{ctrlT: "task", ctrlD: "today", keyAltEnter: "chat"},
+			wantErrs:  []string{"key ctrl+c can't be rebound", `unknown key "ctrl+tab"`, "key ctrl+y: unknown action \"list\"", `unknown key "f5"`},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			k, errs := newKeyBindings(tc.overrides)
+			if len(k.actions) != len(tc.want) {
+				t.Errorf("Expected %d bindings, got %v", len(tc.want), k.actions)
+			}
+			for key, action := range tc.want {
+				if k.actions[key] != action {
+					t.Errorf("Expected key %d bound to %q, got %q", key, action, k.actions[key])
+				}
+			}
+			if len(errs) != len(tc.wantErrs) {
+				t.Fatalf("Expected %d errors, got %v", len(tc.wantErrs), errs)
+			}
+			for i, want := range tc.wantErrs {
+				if !strings.Contains(errs[i].Error(), want) {
+					t.Errorf("Expected error %q, got %q", want, errs[i])
+				}
+			}
+		})
+	}
+}
+
+func TestKeyAction(t *testing.T) {
+	k, _ := newKeyBindings(map[string]string{"ctrl+y": "/tasks "})
+
+	for _, tc := range []struct {
+		name       string
+		key        rune
+		wantAction string
+		wantRune   rune
+		wantPass   bool
+	}{
+		{"ctrl+t inserts /task", ctrlT, "task", keyInsertBase + ctrlT, true},
+		{"ctrl+d toggles today", ctrlD, "today", ctrlD, false},
+		{"alt+enter sends to chat", keyAltEnter, "chat", readline.CharEnter, true},
+		{"text binding inserts", ctrlY, "/tasks ", keyInsertBase + ctrlY, true},
+		{"unbound key", ctrlG, "", ctrlG, true},
+		{"typed letter", 'd', "", 'd', true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			action, out, pass := k.keyAction(tc.key)
+			if action != tc.wantAction || out != tc.wantRune || pass != tc.wantPass {
+				t.Errorf("Expected (%q, %d, %v), got (%q, %d, %v)", tc.wantAction, tc.wantRune, tc.wantPass, action, out, pass)
+			}
+		})
+	}
+}
+
+func TestKeyFilter(t *testing.T) {
+	setupKeysStore(t)
+	var out bytes.Buffer
+	k, _ := newKeyBindings(nil)
+	k.out = &out
+
+	// Alt+Enter submits the line and flags it for chat, once
+	if r, pass := k.filter(keyAltEnter); r != readline.CharEnter || !pass {
+		t.Errorf("Expected Enter for alt+enter, got %d, %v", r, pass)
+	}
+	if !k.takeForceChat() || k.takeForceChat() {
+		t.Error("Expected the chat flag set once")
+	}
+
+	// Ctrl+D toggles the today view instead of exiting
+	if _, pass := k.filter(ctrlD); pass || !k.showToday() {
+		t.Errorf("Expected ctrl+d swallowed and the view on, got pass %v", pass)
+	}
+	if !strings.Contains(out.String(), "Today view on") {
+		t.Errorf("Expected the view shown, got %q", out.String())
+	}
+	if k.filter(ctrlD); k.showToday() || !strings.Contains(out.String(), "Today view off.") {
+		t.Errorf("Expected the view off, got %q", out.String())
+	}
+}
+
+func TestKeyInsert(t *testing.T) {
+	store := setupKeysStore(t)
+	k, _ := newKeyBindings(map[string]string{"ctrl+y": "/tasks "})
+
+	insert := func(line string, key rune) (string, int, bool) {
+		runes := append([]rune(line), keyInsertBase+key)
+		newLine, pos, ok := k.OnChange(runes, len(runes), keyInsertBase+key)
+		return string(newLine), pos, ok
+	}
+
+	// Ctrl+T with no focus
+	if line, pos, ok := insert("", ctrlT); !ok || line != "/task " || pos != 6 {
+		t.Errorf("Expected \"/task \" with the cursor after it, got %q at %d (%v)", line, pos, ok)
+	}
+
+	// A text binding inserts at the cursor
+	if line, pos, ok := insert("x ", ctrlY); !ok || line != "x /tasks " || pos != 9 {
+		t.Errorf("Expected \"x /tasks \" with the cursor after it, got %q at %d (%v)", line, pos, ok)
+	}
+
+	// Typed text is left alone
+	if _, _, ok := k.OnChange([]rune("t"), 1, 't'); ok {
+		t.Error("Expected typed text left alone")
+	}
+
+	// Ctrl+T adds the focused project's shortcut
+	project, err := store.CreateProject("Work")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := store.SetProjectShortcut(project.ID, "w"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := commands.Execute("/focus w"); err != nil {
+		t.Fatal(err)
+	}
+	defer commands.Execute("/focus off")
+	if line, _, _ := insert("", ctrlT); line != "/task w " {
+		t.Errorf("Expected \"/task w \" when focused, got %q", line)
+	}
+}
+
+func TestAltEnterReader(t *testing.T) {
+	r := &altEnterReader{r: strings.NewReader("hi\x1b\rthere\r")}
+	data, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "hi" + string(keyAltEnter) + "there\r"; string(data) != want {
+		t.Errorf("Expected %q, got %q", want, data)
+	}
+}
@@ -0,0 +1,59 @@
+package llm
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+)
+
+// promptCaching reads LLM_PROMPT_CACHE (default on). With it on, the
+// OpenRouter client marks the fixed start of the system prompt with
+// cache_control, so Anthropic and Gemini models reuse it, along with the
+// tool definitions sent before it, instead of reading it in full every turn.
+func promptCaching() bool {
+	if s := os.Getenv("LLM_PROMPT_CACHE"); s != "" {
+		if on, err := strconv.ParseBool(s); err == nil {
+			return on
+		}
+		fmt.Fprintf(os.Stderr, "Warning: invalid LLM_PROMPT_CACHE %q, using true\n", s)
+	}
+	return true
+}
+
+// openRouterContentPart is one block of a message sent as a list of parts,
+// which is the only way to attach cache_control
+type openRouterContentPart struct {
+	Type         string                  `json:"type"`
+	Text         string                  `json:"text"`
+	CacheControl *openRouterCacheControl `json:"cache_control,omitempty"`
+}
+
+type openRouterCacheControl struct {
+	Type string `json:"type"`
+}
+
+// MarshalJSON sends the message as usual, or, when the start of its content
+// is cacheable, as two text parts with a cache breakpoint after the first.
+// Anthropic caches everything up to a breakpoint (tools, then the system
+// prompt), so one breakpoint on the system prompt covers the tools too.
+func (m openRouterMessage) MarshalJSON() ([]byte, error) {
+	type plain openRouterMessage
+	if m.cachePrefix <= 0 || m.cachePrefix > len(m.Content) {
+		return json.Marshal(plain(m))
+	}
+
+	parts := []openRouterContentPart{{
+		Type:         "text",
+		Text:         m.Content[:m.cachePrefix],
+		CacheControl: &openRouterCacheControl{Type: "ephemeral"},
+	}}
+	if rest := m.Content[m.cachePrefix:]; rest != "" {
+		parts = append(parts, openRouterContentPart{Type: "text", Text: rest})
+	}
+	// The outer Content hides the embedded string one
+	return json.Marshal(struct {
+		plain
+		Content []openRouterContentPart `json:"content"`
+	}{plain(m), parts})
+}
@@ -6,9 +6,13 @@ import (
 )
 
 var (
-	ErrMissingAPIKey = errors.New("OPENROUTER_API_KEY environment variable not set")
-	ErrEmptyPrompt   = errors.New("prompt cannot be empty")
-	ErrNoResponse    = errors.New("no response from model")
+	ErrMissingAPIKey        = errors.New("OPENROUTER_API_KEY environment variable not set")
+	ErrMissingGeminiAPIKey  = errors.New("GEMINI_API_KEY environment variable not set")
+	ErrMissingOpenAIBaseURL = errors.New("OPENAI_BASE_URL environment variable not set")
+	ErrNoProviders          = errors.New("no LLM API key set (OPENROUTER_API_KEY, GEMINI_API_KEY, or OPENAI_BASE_URL for an OpenAI-compatible API, or TWOOMS_LLM_PROVIDER=ollama for a local model)")
+	ErrEmptyPrompt          = errors.New("prompt cannot be empty")
+	ErrNoResponse           = errors.New("no response from model")
+	ErrInvalidStructured    = errors.New("model reply is not the requested JSON")
 )
 
 // ToolExecutor is called when the LLM wants to execute a tool.
@@ -19,6 +23,9 @@ type Client interface {
 	Chat(ctx context.Context, prompt string) (*Response, error)
 	ChatWithConfig(ctx context.Context, prompt string, config *Config) (*Response, error)
 	ChatWithTools(ctx context.Context, message string, history []*Message, tools []*Tool, executor ToolExecutor) (*Response, []*Message, error)
+	// ChatStructured asks for a reply that is JSON matching the schema and
+	// decodes it into out, as json.Unmarshal does
+	ChatStructured(ctx context.Context, prompt string, schema *ResponseSchema, out any) (*Response, error)
 	SetDebug(enabled bool)
 	Close() error
 }
@@ -0,0 +1,54 @@
+package llm
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// defaultToolResultMaxChars is how much of a tool result is kept once the
+// model has seen it in full
+const defaultToolResultMaxChars = 2000
+
+// toolResultMaxChars reads LLM_TOOL_RESULT_MAX_CHARS; 0 turns compression off
+func toolResultMaxChars() int {
+	if s := os.Getenv("LLM_TOOL_RESULT_MAX_CHARS"); s != "" {
+		if n, err := strconv.Atoi(s); err == nil && n >= 0 {
+			return n
+		}
+		fmt.Fprintf(os.Stderr, "Warning: invalid LLM_TOOL_RESULT_MAX_CHARS %q, using %d\n", s, defaultToolResultMaxChars)
+	}
+	return defaultToolResultMaxChars
+}
+
+// compressToolResult shortens a tool result to at most maxChars (plus a short
+// note), keeping whole lines from the start and saying how much was cut.
+// Tool results are sent in full in the request right after the call; this
+// version replaces them in later requests and in the returned history, so a
+// long listing isn't paid for again on every round.
+func compressToolResult(result string, maxChars int) string {
+	if maxChars <= 0 || len(result) <= maxChars {
+		return result
+	}
+
+	lines := strings.Split(strings.TrimRight(result, "\n"), "\n")
+	var kept strings.Builder
+	keptLines := 0
+	for _, line := range lines {
+		if kept.Len()+len(line)+1 > maxChars {
+			break
+		}
+		kept.WriteString(line)
+		kept.WriteString("\n")
+		keptLines++
+	}
+
+	// A single line longer than the limit is cut mid-line
+	if keptLines == 0 {
+		return strings.ToValidUTF8(result[:maxChars], "") +
+			fmt.Sprintf("... [truncated %d of %d chars; call the tool again for the full output]", len(result)-maxChars, len(result))
+	}
+	return kept.String() +
+		fmt.Sprintf("[truncated %d of %d lines; call the tool again for the full output]", len(lines)-keptLines, len(lines))
+}
@@ -0,0 +1,60 @@
+package llm
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestCompressToolResult(t *testing.T) {
+	// Three 8-byte lines: 27 bytes with their newlines
+	listing := "task one\ntask two\ntask 333\n"
+
+	for _, tc := range []struct {
+		name     string
+		result   string
+		maxChars int
+		want     string
+	}{
+		{"under the limit", listing, len(listing) + 1, listing},
+		{"exactly at the limit", listing, len(listing), listing},
+		{"off", strings.Repeat("x", 5000), 0, strings.Repeat("x", 5000)},
+		{"over by whole lines", listing, 20, "task one\ntask two\n[truncated 1 of 3 lines; call the tool again for the full output]"},
+		{"a line that just fits", listing, 18, "task one\ntask two\n[truncated 1 of 3 lines; call the tool again for the full output]"},
+		{"a line one byte over", listing, 17, "task one\n[truncated 2 of 3 lines; call the tool again for the full output]"},
+		{"one long line", strings.Repeat("x", 30), 10, strings.Repeat("x", 10) + "... [truncated 20 of 30 chars; call the tool again for the full output]"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := compressToolResult(tc.result, tc.maxChars); got != tc.want {
+				t.Errorf("Expected %q, got %q", tc.want, got)
+			}
+		})
+	}
+}
+
+func TestCompressToolResultMultibyte(t *testing.T) {
+	// Every rune is 3 bytes, so most limits fall inside one
+	line := strings.Repeat("日本語", 10)
+
+	for maxChars := 1; maxChars < len(line); maxChars++ {
+		got := compressToolResult(line, maxChars)
+		kept, _, ok := strings.Cut(got, "... [truncated")
+		if !ok {
+			t.Fatalf("Limit %d: expected a truncation note, got %q", maxChars, got)
+		}
+		if !utf8.ValidString(got) {
+			t.Errorf("Limit %d: split a rune: %q", maxChars, got)
+		}
+		// Whole runes only, as many as fit
+		if want := line[:maxChars/3*3]; kept != want {
+			t.Errorf("Limit %d: expected %q kept, got %q", maxChars, want, kept)
+		}
+	}
+
+	// A line of multibyte text that fits is kept whole
+	listing := "日本語\nemoji 🎉\n" + strings.Repeat("é", 20) + "\n"
+	want := "日本語\nemoji 🎉\n[truncated 1 of 3 lines; call the tool again for the full output]"
+	if got := compressToolResult(listing, 25); got != want {
+		t.Errorf("Expected %q, got %q", want, got)
+	}
+}
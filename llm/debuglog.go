@@ -0,0 +1,238 @@
+package llm
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// defaultLogMaxMB is how large the debug log grows before it's rotated
+const defaultLogMaxMB = 10
+
+// redacted replaces API keys in the debug log
+const redacted = "[REDACTED]"
+
+// secretHeaders carry API keys, so their values never reach the log
+var secretHeaders = []string{"Authorization", "Api-Key", "X-Goog-Api-Key"}
+
+// logMaxBytes reads LLM_DEBUG_LOG_MAX_MB
+func logMaxBytes() int64 {
+	if s := os.Getenv("LLM_DEBUG_LOG_MAX_MB"); s != "" {
+		if n, err := strconv.Atoi(s); err == nil && n >= 1 {
+			return int64(n) << 20
+		}
+		fmt.Fprintf(os.Stderr, "Warning: invalid LLM_DEBUG_LOG_MAX_MB %q, using %d\n", s, defaultLogMaxMB)
+	}
+	return defaultLogMaxMB << 20
+}
+
+// requestLog is a JSONL file of every API request and response and every
+// tool call, with timings. Once a write would take it past max bytes, the
+// file is moved to path.1 (replacing the last one) and a new one started.
+type requestLog struct {
+	mu   sync.Mutex
+	path string
+	max  int64
+	file *os.File
+	size int64
+}
+
+// activeLog is the log being written, or nil; every client shares it
+var activeLog atomic.Pointer[requestLog]
+
+// SetLogFile starts writing raw API traffic and tool calls to a JSONL file
+// at path, appending to what's there; an empty path stops logging
+func SetLogFile(path string) error {
+	var log *requestLog
+	if path != "" {
+		f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
+		if err != nil {
+			return err
+		}
+		info, err := f.Stat()
+		if err != nil {
+			f.Close()
+			return err
+		}
+		log = &requestLog{path: path, max: logMaxBytes(), file: f, size: info.Size()}
+	}
+	if old := activeLog.Swap(log); old != nil {
+		old.mu.Lock()
+		old.file.Close()
+		old.mu.Unlock()
+	}
+	return nil
+}
+
+// LogFile returns the path of the debug log, or "" when logging is off
+func LogFile() string {
+	if log := activeLog.Load(); log != nil {
+		return log.path
+	}
+	return ""
+}
+
+// logEntry is one line of the debug log: an HTTP exchange or a tool call
+type logEntry struct {
+	Time       time.Time         `json:"time"`
+	Kind       string            `json:"kind"` // "http" or "tool"
+	Method     string            `json:"method,omitempty"`
+	URL        string            `json:"url,omitempty"`
+	Headers    map[string]string `json:"headers,omitempty"`
+	Request    json.RawMessage   `json:"request,omitempty"`
+	Status     int               `json:"status,omitempty"`
+	Response   json.RawMessage   `json:"response,omitempty"`
+	Tool       string            `json:"tool,omitempty"`
+	Arguments  map[string]any    `json:"arguments,omitempty"`
+	Result     *string           `json:"result,omitempty"`
+	DurationMS int64             `json:"duration_ms"`
+	Error      string            `json:"error,omitempty"`
+}
+
+// write adds an entry as one line, with every secret replaced
+func (l *requestLog) write(entry *logEntry, secrets []string) {
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return
+	}
+	for _, secret := range secrets {
+		if len(secret) >= 8 {
+			line = bytes.ReplaceAll(line, []byte(secret), []byte(redacted))
+		}
+	}
+	line = append(line, '\n')
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.size > 0 && l.size+int64(len(line)) > l.max {
+		l.rotate()
+	}
+	n, _ := l.file.Write(line)
+	l.size += int64(n)
+}
+
+// rotate moves the full log aside and starts an empty one
+func (l *requestLog) rotate() {
+	l.file.Close()
+	os.Rename(l.path, l.path+".1")
+	f, err := os.OpenFile(l.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		// Keep appending to the old file rather than losing entries
+		f, err = os.OpenFile(l.path+".1", os.O_WRONLY|os.O_APPEND, 0600)
+		if err != nil {
+			return
+		}
+	}
+	l.file = f
+	l.size = 0
+}
+
+// logJSON keeps a body as JSON when it is, or as a JSON string otherwise
+func logJSON(body []byte) json.RawMessage {
+	if len(body) == 0 {
+		return nil
+	}
+	if json.Valid(body) {
+		return json.RawMessage(body)
+	}
+	quoted, _ := json.Marshal(string(body))
+	return quoted
+}
+
+// logTool records a tool call, its result, and how long it ran
+func logTool(call ToolCall, result string, took time.Duration) {
+	log := activeLog.Load()
+	if log == nil {
+		return
+	}
+	log.write(&logEntry{
+		Time:       time.Now(),
+		Kind:       "tool",
+		Tool:       call.Name,
+		Arguments:  call.Arguments,
+		Result:     &result,
+		DurationMS: took.Milliseconds(),
+	}, nil)
+}
+
+// loggingTransport sends requests with http.DefaultTransport and, while a
+// debug log is open, records each exchange in full with its API keys
+// redacted. The response body is read here and handed on unchanged.
+type loggingTransport struct{}
+
+func (loggingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	log := activeLog.Load()
+	if log == nil {
+		return http.DefaultTransport.RoundTrip(req)
+	}
+
+	var reqBody []byte
+	if req.GetBody != nil {
+		if body, err := req.GetBody(); err == nil {
+			reqBody, _ = io.ReadAll(body)
+			body.Close()
+		}
+	}
+
+	// Keys travel in headers, or in Gemini's case possibly the query
+	var secrets []string
+	headers := make(map[string]string)
+	for name := range req.Header {
+		headers[name] = req.Header.Get(name)
+	}
+	for _, name := range secretHeaders {
+		if value := req.Header.Get(name); value != "" {
+			secrets = append(secrets, value)
+			if _, token, ok := strings.Cut(value, " "); ok {
+				secrets = append(secrets, token)
+			}
+			headers[http.CanonicalHeaderKey(name)] = redacted
+		}
+	}
+	u := *req.URL
+	if key := u.Query().Get("key"); key != "" {
+		secrets = append(secrets, key)
+		q := u.Query()
+		q.Set("key", redacted)
+		u.RawQuery = q.Encode()
+	}
+
+	entry := &logEntry{
+		Time:    time.Now(),
+		Kind:    "http",
+		Method:  req.Method,
+		URL:     u.String(),
+		Headers: headers,
+		Request: logJSON(reqBody),
+	}
+	start := time.Now()
+	resp, err := http.DefaultTransport.RoundTrip(req)
+	if err != nil {
+		entry.DurationMS = time.Since(start).Milliseconds()
+		entry.Error = err.Error()
+		log.write(entry, secrets)
+		return nil, err
+	}
+
+	respBody, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	entry.DurationMS = time.Since(start).Milliseconds()
+	entry.Status = resp.StatusCode
+	entry.Response = logJSON(respBody)
+	if err != nil {
+		entry.Error = err.Error()
+		log.write(entry, secrets)
+		return nil, err
+	}
+	log.write(entry, secrets)
+	resp.Body = io.NopCloser(bytes.NewReader(respBody))
+	return resp, nil
+}
@@ -0,0 +1,131 @@
+package llm
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// startLog writes the debug log to a file in a temporary directory until
+// the test ends
+func startLog(t *testing.T) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "llm.jsonl")
+	if err := SetLogFile(path); err != nil {
+		t.Fatalf("SetLogFile: %v", err)
+	}
+	t.Cleanup(func() { SetLogFile("") })
+	return path
+}
+
+func TestDebugLogRedactsKeys(t *testing.T) {
+	const (
+		bearerKey = "sk-or-v1-bearer-secret"
+		headerKey = "azure-header-secret"
+		googleKey = "AIza-google-secret"
+		queryKey  = "AIza-query-secret"
+	)
+	path := startLog(t)
+
+	// A server that complains about the key, quoting it back
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+		fmt.Fprintf(w, `{"error": {"message": "invalid key %s%s%s%s"}}`,
+			r.Header.Get("Authorization"), r.Header.Get("Api-Key"), r.Header.Get("X-Goog-Api-Key"), r.URL.Query().Get("key"))
+	}))
+	defer srv.Close()
+	client := &http.Client{Timeout: 10 * time.Second, Transport: loggingTransport{}}
+
+	// OpenRouter sends a bearer token; the prompt quotes it too
+	openRouter := testOpenRouter(srv.URL, 1)
+	openRouter.headers = map[string]string{"Authorization": "Bearer " + bearerKey}
+	openRouter.httpClient = client
+	if _, err := openRouter.Chat(context.Background(), "is "+bearerKey+" my key?"); err == nil {
+		t.Fatal("Expected the 401 as an error")
+	}
+
+	// Azure's api-key, Gemini's header, and a key in the query
+	for _, header := range [][2]string{{"Api-Key", headerKey}, {"X-Goog-Api-Key", googleKey}} {
+		req, _ := http.NewRequest("POST", srv.URL, strings.NewReader(`{}`))
+		req.Header.Set(header[0], header[1])
+		resp, err := client.Do(req)
+		if err != nil {
+			t.Fatalf("Request with %s: %v", header[0], err)
+		}
+		resp.Body.Close()
+	}
+	resp, err := client.Get(srv.URL + "/models?key=" + queryKey)
+	if err != nil {
+		t.Fatalf("Request with a key parameter: %v", err)
+	}
+	resp.Body.Close()
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Reading the log: %v", err)
+	}
+	log := string(data)
+	if lines := strings.Count(log, "\n"); lines != 4 {
+		t.Errorf("Expected a line per request, got %d:\n%s", lines, log)
+	}
+	for _, key := range []string{bearerKey, headerKey, googleKey, queryKey} {
+		if strings.Contains(log, key) {
+			t.Errorf("Key %s is in the log:\n%s", key, log)
+		}
+	}
+	if !strings.Contains(log, redacted) || !strings.Contains(log, `"status":401`) {
+		t.Errorf("Expected redacted exchanges with their status, got:\n%s", log)
+	}
+}
+
+func TestDebugLogRotation(t *testing.T) {
+	t.Setenv("LLM_DEBUG_LOG_MAX_MB", "1")
+	path := startLog(t)
+	const max = 1 << 20
+
+	// About 3 MB of tool calls
+	result := strings.Repeat("x", 1000)
+	for i := 0; i < 3000; i++ {
+		logTool(ToolCall{Name: "tasks", Arguments: map[string]any{"n": i}}, result, time.Millisecond)
+	}
+
+	for _, p := range []string{path, path + ".1"} {
+		info, err := os.Stat(p)
+		if err != nil {
+			t.Fatalf("Expected %s: %v", filepath.Base(p), err)
+		}
+		if info.Size() == 0 || info.Size() > max {
+			t.Errorf("Expected %s to hold up to %d bytes, got %d", filepath.Base(p), max, info.Size())
+		}
+	}
+	if _, err := os.Stat(path + ".2"); !os.IsNotExist(err) {
+		t.Errorf("Expected only one old log kept, got %s.2 (%v)", filepath.Base(path), err)
+	}
+
+	// Rotation happens between lines, so each file is whole JSONL and the
+	// newest call is in the current one
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(nil, max)
+	last := ""
+	for scanner.Scan() {
+		last = scanner.Text()
+		if !strings.HasPrefix(last, "{") || !strings.HasSuffix(last, "}") {
+			t.Fatalf("Expected whole JSON lines, got %.80q", last)
+		}
+	}
+	if !strings.Contains(last, `"n":2999`) {
+		t.Errorf("Expected the last call at the end of the log, got %.80q", last)
+	}
+}
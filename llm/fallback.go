@@ -0,0 +1,221 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// FallbackClient tries each client in order, moving to the next when a
+// request fails. A tool-calling request only falls back if no tool has run
+// yet, so commands the model already executed are never repeated, and a
+// cancelled request never falls back.
+type FallbackClient struct {
+	clients []Client
+	names   []string
+}
+
+// NewFallbackClient builds a client from every provider whose API key (or,
+// for an OpenAI-compatible API, base URL) is set, tried in the order
+// OpenRouter, Gemini, OpenAI-compatible; the provider setting moves one of
+// them first. With it set to ollama, the local Ollama server is the only
+// provider, so nothing leaves the machine. Returns ErrNoProviders if no key
+// is set.
+func NewFallbackClient(ctx context.Context) (*FallbackClient, error) {
+	type provider struct {
+		name string
+		new  func(context.Context) (Client, error)
+	}
+	providers := []provider{
+		{"openrouter", func(ctx context.Context) (Client, error) { return NewOpenRouterClient(ctx) }},
+		{"gemini", func(ctx context.Context) (Client, error) { return NewGeminiClient(ctx) }},
+		{"openai", func(ctx context.Context) (Client, error) { return NewOpenAIClient(ctx) }},
+	}
+	switch name := providerSetting(); name {
+	case "", "openrouter":
+	case "gemini", "openai":
+		for i, p := range providers {
+			if p.name == name {
+				providers = append([]provider{p}, append(providers[:i:i], providers[i+1:]...)...)
+				break
+			}
+		}
+	case "ollama":
+		providers = []provider{{"ollama", func(ctx context.Context) (Client, error) { return NewOllamaClient(ctx) }}}
+	default:
+		return nil, fmt.Errorf("unknown LLM provider %q (use openrouter, gemini, openai, or ollama)", name)
+	}
+
+	f := &FallbackClient{}
+	for _, p := range providers {
+		client, err := p.new(ctx)
+		if err != nil {
+			if errors.Is(err, ErrMissingAPIKey) || errors.Is(err, ErrMissingGeminiAPIKey) || errors.Is(err, ErrMissingOpenAIBaseURL) {
+				continue
+			}
+			return nil, fmt.Errorf("%s: %w", p.name, err)
+		}
+		f.clients = append(f.clients, client)
+		f.names = append(f.names, p.name)
+	}
+
+	if len(f.clients) == 0 {
+		return nil, ErrNoProviders
+	}
+	return f, nil
+}
+
+// providerSetting reads TWOOMS_LLM_PROVIDER, or the older LLM_PROVIDER
+func providerSetting() string {
+	name := os.Getenv("TWOOMS_LLM_PROVIDER")
+	if name == "" {
+		name = os.Getenv("LLM_PROVIDER")
+	}
+	return strings.ToLower(strings.TrimSpace(name))
+}
+
+// ProviderStatus describes whether a provider is used for chat, and why not
+type ProviderStatus struct {
+	Name   string
+	Active bool
+	Reason string // what enables it, or why it's inactive
+}
+
+// ProviderStatuses reports every provider in fallback order, from the same
+// settings NewFallbackClient reads, so a user can see why chat is offline or
+// which provider answers
+func ProviderStatuses() []ProviderStatus {
+	keyed := func(name, env string) ProviderStatus {
+		if os.Getenv(env) == "" {
+			return ProviderStatus{Name: name, Reason: env + " not set"}
+		}
+		return ProviderStatus{Name: name, Active: true, Reason: env + " set"}
+	}
+	host, model := ollamaSettings()
+	local := fmt.Sprintf("local server at %s, model %s", host, model)
+
+	hosted := []ProviderStatus{keyed("openrouter", "OPENROUTER_API_KEY"), keyed("gemini", "GEMINI_API_KEY"), keyed("openai", "OPENAI_BASE_URL")}
+	if hosted[2].Active {
+		hosted[2].Reason = "OPENAI_BASE_URL set (" + os.Getenv("OPENAI_BASE_URL") + ")"
+	}
+
+	switch name := providerSetting(); name {
+	case "", "openrouter", "gemini", "openai":
+		statuses := []ProviderStatus{}
+		for _, s := range hosted {
+			if s.Name == name {
+				statuses = append([]ProviderStatus{s}, statuses...)
+			} else {
+				statuses = append(statuses, s)
+			}
+		}
+		return append(statuses, ProviderStatus{Name: "ollama", Reason: "not selected (TWOOMS_LLM_PROVIDER=ollama uses the " + local + ")"})
+	case "ollama":
+		statuses := []ProviderStatus{{Name: "ollama", Active: true, Reason: local}}
+		for _, s := range hosted {
+			statuses = append(statuses, ProviderStatus{Name: s.Name, Reason: "skipped: TWOOMS_LLM_PROVIDER=ollama keeps chat on this machine"})
+		}
+		return statuses
+	default:
+		unknown := fmt.Sprintf("TWOOMS_LLM_PROVIDER=%s is not a provider (use openrouter, gemini, openai, or ollama)", name)
+		var statuses []ProviderStatus
+		for _, s := range append(hosted, ProviderStatus{Name: "ollama"}) {
+			statuses = append(statuses, ProviderStatus{Name: s.Name, Reason: unknown})
+		}
+		return statuses
+	}
+}
+
+// Providers returns the names of the configured providers in fallback order
+func (f *FallbackClient) Providers() []string {
+	return f.names
+}
+
+func (f *FallbackClient) Chat(ctx context.Context, prompt string) (*Response, error) {
+	return f.ChatWithConfig(ctx, prompt, DefaultConfig())
+}
+
+func (f *FallbackClient) ChatWithConfig(ctx context.Context, prompt string, config *Config) (*Response, error) {
+	var errs []error
+	for i, client := range f.clients {
+		resp, err := client.ChatWithConfig(ctx, prompt, config)
+		if err == nil {
+			return resp, nil
+		}
+		if errors.Is(err, ErrEmptyPrompt) || ctx.Err() != nil {
+			return nil, err
+		}
+		errs = append(errs, fmt.Errorf("%s: %w", f.names[i], err))
+		f.logFallback(i, err)
+	}
+	return nil, errors.Join(errs...)
+}
+
+func (f *FallbackClient) ChatWithTools(ctx context.Context, message string, history []*Message, tools []*Tool, executor ToolExecutor) (*Response, []*Message, error) {
+	var errs []error
+	for i, client := range f.clients {
+		toolsRun := 0
+		counted := func(name string, args map[string]any) string {
+			toolsRun++
+			return executor(name, args)
+		}
+
+		resp, newHistory, err := client.ChatWithTools(ctx, message, history, tools, counted)
+		if err == nil {
+			return resp, newHistory, nil
+		}
+		if errors.Is(err, ErrEmptyPrompt) || toolsRun > 0 || ctx.Err() != nil {
+			return nil, newHistory, err
+		}
+		errs = append(errs, fmt.Errorf("%s: %w", f.names[i], err))
+		f.logFallback(i, err)
+	}
+	return nil, history, errors.Join(errs...)
+}
+
+// logFallback notes a failed provider when another one is left to try
+func (f *FallbackClient) logFallback(i int, err error) {
+	if i+1 < len(f.clients) {
+		fmt.Fprintf(os.Stderr, "Warning: %s failed (%v), falling back to %s\n", f.names[i], err, f.names[i+1])
+	}
+}
+
+func (f *FallbackClient) SetDebug(enabled bool) {
+	for _, client := range f.clients {
+		client.SetDebug(enabled)
+	}
+}
+
+func (f *FallbackClient) Close() error {
+	var errs []error
+	for _, client := range f.clients {
+		errs = append(errs, client.Close())
+	}
+	return errors.Join(errs...)
+}
+
+// Model returns the primary provider's model
+func (f *FallbackClient) Model() string {
+	if s, ok := f.clients[0].(ModelSelector); ok {
+		return s.Model()
+	}
+	return ""
+}
+
+// SetModel switches the primary provider's model; model names are
+// provider-specific, so fallback providers keep their own
+func (f *FallbackClient) SetModel(model string) {
+	if s, ok := f.clients[0].(ModelSelector); ok {
+		s.SetModel(model)
+	}
+}
+
+// ListModels lists the primary provider's models
+func (f *FallbackClient) ListModels(ctx context.Context) ([]*ModelInfo, error) {
+	if l, ok := f.clients[0].(ModelLister); ok {
+		return l.ListModels(ctx)
+	}
+	return nil, fmt.Errorf("%s does not support listing models", f.names[0])
+}
@@ -0,0 +1,183 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// fakeClient answers with its name, or fails with err after running
+// toolCalls tools. cancel, if set, is called before it fails, as if the user
+// pressed Ctrl-C mid-request.
+type fakeClient struct {
+	name      string
+	err       error
+	toolCalls int
+	cancel    context.CancelFunc
+	model     string
+	calls     int
+}
+
+func (c *fakeClient) answer() (*Response, error) {
+	c.calls++
+	if c.cancel != nil {
+		c.cancel()
+	}
+	if c.err != nil {
+		return nil, c.err
+	}
+	return &Response{Text: c.name}, nil
+}
+
+func (c *fakeClient) Chat(ctx context.Context, prompt string) (*Response, error) {
+	return c.answer()
+}
+
+func (c *fakeClient) ChatWithConfig(ctx context.Context, prompt string, config *Config) (*Response, error) {
+	return c.answer()
+}
+
+func (c *fakeClient) ChatWithTools(ctx context.Context, message string, history []*Message, tools []*Tool, executor ToolExecutor) (*Response, []*Message, error) {
+	for i := 0; i < c.toolCalls; i++ {
+		executor("tasks", nil)
+	}
+	resp, err := c.answer()
+	return resp, history, err
+}
+
+func (c *fakeClient) ChatStructured(ctx context.Context, prompt string, schema *ResponseSchema, out any) (*Response, error) {
+	return c.answer()
+}
+
+func (c *fakeClient) SetDebug(enabled bool) {}
+func (c *fakeClient) Close() error          { return nil }
+func (c *fakeClient) Model() string         { return c.model }
+func (c *fakeClient) SetModel(model string) { c.model = model }
+
+func TestFallbackChat(t *testing.T) {
+	failed := errors.New("status 503")
+
+	for _, tc := range []struct {
+		name      string
+		primary   fakeClient
+		cancel    bool // the primary is interrupted
+		tools     bool // a tool-calling request
+		wantText  string
+		wantCalls [2]int // requests to the primary and the fallback
+	}{
+		{name: "primary answers", wantText: "primary", wantCalls: [2]int{1, 0}},
+		{name: "primary fails", primary: fakeClient{err: failed}, wantText: "fallback", wantCalls: [2]int{1, 1}},
+		{name: "fails before any tool", primary: fakeClient{err: failed}, tools: true, wantText: "fallback", wantCalls: [2]int{1, 1}},
+		{name: "fails after a tool ran", primary: fakeClient{err: failed, toolCalls: 1}, tools: true, wantCalls: [2]int{1, 0}},
+		{name: "cancelled", primary: fakeClient{err: context.Canceled}, cancel: true, wantCalls: [2]int{1, 0}},
+		{name: "cancelled with tools", primary: fakeClient{err: context.Canceled}, cancel: true, tools: true, wantCalls: [2]int{1, 0}},
+		{name: "empty prompt", primary: fakeClient{err: ErrEmptyPrompt}, wantCalls: [2]int{1, 0}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			primary, fallback := tc.primary, fakeClient{name: "fallback"}
+			primary.name = "primary"
+			if tc.cancel {
+				primary.cancel = cancel
+			}
+			f := &FallbackClient{clients: []Client{&primary, &fallback}, names: []string{"primary", "fallback"}}
+
+			var resp *Response
+			var err error
+			toolsRun := 0
+			if tc.tools {
+				resp, _, err = f.ChatWithTools(ctx, "hi", nil, nil, func(string, map[string]any) string {
+					toolsRun++
+					return ""
+				})
+			} else {
+				resp, err = f.Chat(ctx, "hi")
+			}
+
+			if got := [2]int{primary.calls, fallback.calls}; got != tc.wantCalls {
+				t.Errorf("Expected requests %v to the primary and fallback, got %v", tc.wantCalls, got)
+			}
+			if tc.wantText == "" {
+				if !errors.Is(err, primary.err) {
+					t.Errorf("Expected the primary's error, got %v", err)
+				}
+			} else if err != nil || resp.Text != tc.wantText {
+				t.Errorf("Expected %s to answer, got %+v, %v", tc.wantText, resp, err)
+			}
+			if toolsRun != primary.toolCalls {
+				t.Errorf("Expected %d tool runs, got %d", primary.toolCalls, toolsRun)
+			}
+		})
+	}
+}
+
+func TestFallbackAllFail(t *testing.T) {
+	f := &FallbackClient{
+		clients: []Client{&fakeClient{err: errors.New("status 503")}, &fakeClient{err: errors.New("status 429")}},
+		names:   []string{"openrouter", "gemini"},
+	}
+	_, err := f.Chat(context.Background(), "hi")
+	if err == nil || !strings.Contains(err.Error(), "openrouter: status 503") || !strings.Contains(err.Error(), "gemini: status 429") {
+		t.Errorf("Expected every provider's error, got %v", err)
+	}
+}
+
+func TestFallbackSetModel(t *testing.T) {
+	primary := &fakeClient{model: "openai/gpt-4o-mini"}
+	fallback := &fakeClient{model: "gemini-2.5-flash"}
+	f := &FallbackClient{clients: []Client{primary, fallback}, names: []string{"openrouter", "gemini"}}
+
+	// Model names belong to one provider, so only the primary switches
+	f.SetModel("anthropic/claude-sonnet-4")
+	if f.Model() != "anthropic/claude-sonnet-4" || primary.model != "anthropic/claude-sonnet-4" {
+		t.Errorf("Expected the primary switched, got %q", f.Model())
+	}
+	if fallback.model != "gemini-2.5-flash" {
+		t.Errorf("Expected the fallback to keep its model, got %q", fallback.model)
+	}
+}
+
+func TestNewFallbackClient(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		env      map[string]string
+		want     []string
+		wantErr  error
+		errMatch string
+	}{
+		{name: "no keys", wantErr: ErrNoProviders},
+		{name: "only gemini", env: map[string]string{"GEMINI_API_KEY": "g-key"}, want: []string{"gemini"}},
+		{name: "every key", env: map[string]string{"OPENROUTER_API_KEY": "or-key", "GEMINI_API_KEY": "g-key", "OPENAI_BASE_URL": "http://localhost:8080/v1"}, want: []string{"openrouter", "gemini", "openai"}},
+		{name: "gemini first", env: map[string]string{"OPENROUTER_API_KEY": "or-key", "GEMINI_API_KEY": "g-key", "TWOOMS_LLM_PROVIDER": "gemini"}, want: []string{"gemini", "openrouter"}},
+		{name: "older setting", env: map[string]string{"OPENROUTER_API_KEY": "or-key", "OPENAI_BASE_URL": "http://localhost:8080/v1", "LLM_PROVIDER": "OpenAI"}, want: []string{"openai", "openrouter"}},
+		{name: "ollama only", env: map[string]string{"OPENROUTER_API_KEY": "or-key", "TWOOMS_LLM_PROVIDER": "ollama"}, want: []string{"ollama"}},
+		{name: "unknown provider", env: map[string]string{"OPENROUTER_API_KEY": "or-key", "TWOOMS_LLM_PROVIDER": "claude"}, errMatch: `unknown LLM provider "claude"`},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			for _, name := range []string{"OPENROUTER_API_KEY", "GEMINI_API_KEY", "OPENAI_BASE_URL", "OPENAI_API_KEY", "TWOOMS_LLM_PROVIDER", "LLM_PROVIDER"} {
+				t.Setenv(name, tc.env[name])
+			}
+
+			f, err := NewFallbackClient(context.Background())
+			switch {
+			case tc.wantErr != nil:
+				if !errors.Is(err, tc.wantErr) {
+					t.Errorf("Expected %v, got %v", tc.wantErr, err)
+				}
+			case tc.errMatch != "":
+				if err == nil || !strings.Contains(err.Error(), tc.errMatch) {
+					t.Errorf("Expected an error with %q, got %v", tc.errMatch, err)
+				}
+			case err != nil:
+				t.Fatalf("NewFallbackClient: %v", err)
+			default:
+				if got := f.Providers(); !reflect.DeepEqual(got, tc.want) {
+					t.Errorf("Expected providers %v, got %v", tc.want, got)
+				}
+			}
+		})
+	}
+}
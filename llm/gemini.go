@@ -0,0 +1,483 @@
+package llm
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+const (
+	geminiURL          = "https://generativelanguage.googleapis.com/v1beta/models/%s:generateContent"
+	defaultGeminiModel = "gemini-2.0-flash"
+)
+
+type GeminiClient struct {
+	apiKey        string
+	model         string
+	httpClient    *http.Client
+	debug         bool
+	toolResultMax int // tool results are compressed to this size after one round
+}
+
+func NewGeminiClient(ctx context.Context) (*GeminiClient, error) {
+	apiKey := os.Getenv("GEMINI_API_KEY")
+	if apiKey == "" {
+		return nil, ErrMissingGeminiAPIKey
+	}
+
+	model := defaultGeminiModel
+	if modelOverride := os.Getenv("GEMINI_MODEL"); modelOverride != "" {
+		model = modelOverride
+	}
+
+	return &GeminiClient{
+		apiKey: apiKey,
+		model:  model,
+		httpClient: &http.Client{
+			Timeout:   120 * time.Second,
+			Transport: loggingTransport{},
+		},
+		toolResultMax: toolResultMaxChars(),
+	}, nil
+}
+
+func (c *GeminiClient) Chat(ctx context.Context, prompt string) (*Response, error) {
+	return c.ChatWithConfig(ctx, prompt, DefaultConfig())
+}
+
+func (c *GeminiClient) ChatWithConfig(ctx context.Context, prompt string, config *Config) (*Response, error) {
+	if strings.TrimSpace(prompt) == "" {
+		return nil, ErrEmptyPrompt
+	}
+
+	if config == nil {
+		config = DefaultConfig()
+	}
+
+	req := geminiRequest{
+		Contents: []geminiContent{
+			{Role: "user", Parts: []geminiPart{{Text: prompt}}},
+		},
+	}
+	if config.System != "" {
+		req.SystemInstruction = &geminiContent{Parts: []geminiPart{{Text: config.System}}}
+	}
+
+	resp, err := c.sendRequest(ctx, config, req)
+	if err != nil {
+		return nil, err
+	}
+	if len(resp.Candidates) == 0 {
+		return nil, ErrNoResponse
+	}
+
+	candidate := resp.Candidates[0]
+	return &Response{
+		Text:         candidate.text(),
+		FinishReason: candidate.FinishReason,
+		TokensUsed:   resp.UsageMetadata.TotalTokenCount,
+		InputTokens:  resp.UsageMetadata.PromptTokenCount,
+		OutputTokens: resp.UsageMetadata.CandidatesTokenCount,
+		CachedTokens: resp.UsageMetadata.CachedContentTokenCount,
+	}, nil
+}
+
+func (c *GeminiClient) ChatWithTools(ctx context.Context, message string, history []*Message, tools []*Tool, executor ToolExecutor) (*Response, []*Message, error) {
+	if strings.TrimSpace(message) == "" {
+		return nil, history, ErrEmptyPrompt
+	}
+
+	config := DefaultConfig()
+
+	// Build contents from history plus new message; Gemini takes the system
+	// prompt separately
+	req := geminiRequest{Tools: convertToolsToGemini(tools)}
+	req.Contents, req.SystemInstruction = convertHistoryToGemini(history)
+	req.Contents = append(req.Contents, geminiContent{Role: "user", Parts: []geminiPart{{Text: message}}})
+
+	// Update history with new user message
+	newHistory := append(history, &Message{Role: "user", Content: message})
+
+	if c.debug {
+		fmt.Printf("[DEBUG] Request: %d contents, %d tools\n", len(req.Contents), len(tools))
+	}
+
+	var totalTokens, totalInputTokens, totalOutputTokens, totalCachedTokens int64
+	var accumulatedContent strings.Builder
+	var toolResults []string                  // Track tool results for fallback response
+	var fullResults []*geminiFunctionResponse // Responses the model hasn't seen yet
+
+	// Tool calling loop
+	for {
+		resp, err := c.sendRequest(ctx, config, req)
+		if err != nil {
+			return nil, newHistory, err
+		}
+
+		// The model has now seen these results in full; later rounds get the short version
+		for _, r := range fullResults {
+			r.Response["output"] = compressToolResult(r.Response["output"].(string), c.toolResultMax)
+		}
+		fullResults = nil
+
+		totalTokens += resp.UsageMetadata.TotalTokenCount
+		totalInputTokens += resp.UsageMetadata.PromptTokenCount
+		totalOutputTokens += resp.UsageMetadata.CandidatesTokenCount
+		totalCachedTokens += resp.UsageMetadata.CachedContentTokenCount
+
+		if len(resp.Candidates) == 0 {
+			return nil, newHistory, ErrNoResponse
+		}
+
+		candidate := resp.Candidates[0]
+
+		var calls []geminiFunctionCall
+		for _, part := range candidate.Content.Parts {
+			if part.FunctionCall != nil {
+				calls = append(calls, *part.FunctionCall)
+			}
+		}
+
+		if c.debug {
+			fmt.Printf("[DEBUG] Response: finish_reason=%s, tool_calls=%d\n", candidate.FinishReason, len(calls))
+		}
+
+		// Accumulate any content from this response
+		if text := candidate.text(); text != "" {
+			if accumulatedContent.Len() > 0 {
+				accumulatedContent.WriteString(" ")
+			}
+			accumulatedContent.WriteString(text)
+		}
+
+		// Check for tool calls
+		if len(calls) > 0 {
+			// Add model's turn with the calls to contents
+			req.Contents = append(req.Contents, geminiContent{Role: "model", Parts: candidate.Content.Parts})
+
+			// Add to history
+			assistantMsg := &Message{
+				Role:      "assistant",
+				Content:   candidate.text(),
+				ToolCalls: make([]ToolCall, len(calls)),
+			}
+			for i, call := range calls {
+				// Gemini doesn't assign call IDs; derive ones unique within the history
+				assistantMsg.ToolCalls[i] = ToolCall{
+					ID:        fmt.Sprintf("call_%d_%d", len(newHistory), i),
+					Name:      call.Name,
+					Arguments: call.Args,
+				}
+			}
+			newHistory = append(newHistory, assistantMsg)
+
+			// Execute the tool calls in order and add their
+			// responses in call order in a single user turn
+			if c.debug {
+				for _, tc := range assistantMsg.ToolCalls {
+					args, _ := json.Marshal(tc.Arguments)
+					fmt.Printf("[DEBUG] Tool call: %s\n", tc.Name)
+					fmt.Printf("[DEBUG]   Arguments: %s\n", args)
+				}
+			}
+			results, err := runTools(ctx, assistantMsg.ToolCalls, executor)
+			var responses []geminiPart
+			for i, result := range results {
+				tc := assistantMsg.ToolCalls[i]
+
+				if c.debug {
+					// Truncate long outputs for readability
+					debugResult := result
+					if len(debugResult) > 200 {
+						debugResult = debugResult[:200] + "..."
+					}
+					fmt.Printf("[DEBUG]   Output of %s: %s\n", tc.Name, debugResult)
+				}
+
+				toolResults = append(toolResults, result)
+
+				response := &geminiFunctionResponse{
+					Name:     tc.Name,
+					Response: map[string]any{"output": result},
+				}
+				fullResults = append(fullResults, response)
+				responses = append(responses, geminiPart{FunctionResponse: response})
+
+				// Add to history, compressed since it's sent again with every later message
+				compressed := compressToolResult(result, c.toolResultMax)
+				if c.debug && len(compressed) < len(result) {
+					fmt.Printf("[DEBUG]   Compressed for history: %d of %d chars\n", len(compressed), len(result))
+				}
+				newHistory = append(newHistory, &Message{
+					Role:       "tool",
+					Content:    compressed,
+					ToolCallID: tc.ID,
+				})
+			}
+			if err != nil {
+				return nil, newHistory, err
+			}
+			req.Contents = append(req.Contents, geminiContent{Role: "user", Parts: responses})
+
+			continue
+		}
+
+		// No tool calls - return the accumulated text response
+		finalContent := strings.TrimSpace(accumulatedContent.String())
+
+		// If no text content but tools were called, provide a simple confirmation
+		// (The actual tool outputs are printed by the executor as they happen)
+		if finalContent == "" && len(toolResults) > 0 {
+			finalContent = "Done."
+		}
+
+		if finalContent == "" && len(toolResults) == 0 && totalInputTokens == 0 {
+			return nil, newHistory, fmt.Errorf("received empty response from API (no content or tool calls)")
+		}
+
+		newHistory = append(newHistory, &Message{
+			Role:    "assistant",
+			Content: finalContent,
+		})
+
+		return &Response{
+			Text:         finalContent,
+			FinishReason: candidate.FinishReason,
+			TokensUsed:   totalTokens,
+			InputTokens:  totalInputTokens,
+			OutputTokens: totalOutputTokens,
+			CachedTokens: totalCachedTokens,
+		}, newHistory, nil
+	}
+}
+
+// Model returns the model used for chat requests
+func (c *GeminiClient) Model() string {
+	return c.model
+}
+
+// SetModel switches the model used for chat requests
+func (c *GeminiClient) SetModel(model string) {
+	c.model = model
+}
+
+func (c *GeminiClient) SetDebug(enabled bool) {
+	c.debug = enabled
+}
+
+func (c *GeminiClient) Close() error {
+	return nil
+}
+
+// Internal types for the Gemini API
+
+type geminiPart struct {
+	Text             string                  `json:"text,omitempty"`
+	FunctionCall     *geminiFunctionCall     `json:"functionCall,omitempty"`
+	FunctionResponse *geminiFunctionResponse `json:"functionResponse,omitempty"`
+}
+
+type geminiFunctionCall struct {
+	Name string         `json:"name"`
+	Args map[string]any `json:"args"`
+}
+
+type geminiFunctionResponse struct {
+	Name     string         `json:"name"`
+	Response map[string]any `json:"response"`
+}
+
+type geminiContent struct {
+	Role  string       `json:"role,omitempty"`
+	Parts []geminiPart `json:"parts"`
+}
+
+type geminiTool struct {
+	FunctionDeclarations []geminiFunctionDeclaration `json:"functionDeclarations"`
+}
+
+type geminiFunctionDeclaration struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	Parameters  any    `json:"parameters,omitempty"`
+}
+
+type geminiRequest struct {
+	SystemInstruction *geminiContent  `json:"systemInstruction,omitempty"`
+	Contents          []geminiContent `json:"contents"`
+	Tools             []geminiTool    `json:"tools,omitempty"`
+	GenerationConfig  struct {
+		MaxOutputTokens  int32          `json:"maxOutputTokens,omitempty"`
+		Temperature      float32        `json:"temperature,omitempty"`
+		ResponseMimeType string         `json:"responseMimeType,omitempty"`
+		ResponseSchema   map[string]any `json:"responseSchema,omitempty"`
+	} `json:"generationConfig"`
+}
+
+type geminiCandidate struct {
+	Content      geminiContent `json:"content"`
+	FinishReason string        `json:"finishReason"`
+}
+
+// text joins the candidate's text parts
+func (c geminiCandidate) text() string {
+	var parts []string
+	for _, p := range c.Content.Parts {
+		if p.Text != "" {
+			parts = append(parts, p.Text)
+		}
+	}
+	return strings.Join(parts, "")
+}
+
+type geminiResponse struct {
+	Candidates    []geminiCandidate `json:"candidates"`
+	UsageMetadata struct {
+		PromptTokenCount        int64 `json:"promptTokenCount"`
+		CandidatesTokenCount    int64 `json:"candidatesTokenCount"`
+		TotalTokenCount         int64 `json:"totalTokenCount"`
+		CachedContentTokenCount int64 `json:"cachedContentTokenCount"` // Gemini's implicit caching
+	} `json:"usageMetadata"`
+	Error *struct {
+		Message string `json:"message"`
+		Status  string `json:"status"`
+	} `json:"error"`
+}
+
+func (c *GeminiClient) sendRequest(ctx context.Context, config *Config, reqBody geminiRequest) (*geminiResponse, error) {
+	reqBody.GenerationConfig.MaxOutputTokens = config.MaxTokens
+	reqBody.GenerationConfig.Temperature = config.Temperature
+	if config.Schema != nil {
+		reqBody.GenerationConfig.ResponseMimeType = "application/json"
+		reqBody.GenerationConfig.ResponseSchema = config.Schema.Schema
+	}
+
+	jsonBody, err := json.Marshal(reqBody)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	url := fmt.Sprintf(geminiURL, c.model)
+	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewReader(jsonBody))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("x-goog-api-key", c.apiKey)
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response: %w", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("API error (status %d): %s", resp.StatusCode, string(body))
+	}
+
+	var result geminiResponse
+	if err := json.Unmarshal(body, &result); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal response: %w", err)
+	}
+
+	if result.Error != nil {
+		return nil, fmt.Errorf("API error: %s (status: %s)", result.Error.Message, result.Error.Status)
+	}
+
+	return &result, nil
+}
+
+func convertToolsToGemini(tools []*Tool) []geminiTool {
+	if len(tools) == 0 {
+		return nil
+	}
+
+	var decls []geminiFunctionDeclaration
+	for _, t := range tools {
+		decl := geminiFunctionDeclaration{
+			Name:        t.Name,
+			Description: t.Description,
+		}
+
+		// Gemini rejects object schemas with no properties, so omit them
+		if t.Parameters != nil && len(t.Parameters.Properties) > 0 {
+			props := make(map[string]any)
+			for name, prop := range t.Parameters.Properties {
+				schema := prop.schema()
+				// Gemini only honors enums on strings marked with the enum format
+				if len(prop.Enum) > 0 {
+					schema["format"] = "enum"
+				}
+				props[name] = schema
+			}
+			params := map[string]any{
+				"type":       t.Parameters.Type,
+				"properties": props,
+			}
+			if len(t.Parameters.Required) > 0 {
+				params["required"] = t.Parameters.Required
+			}
+			decl.Parameters = params
+		}
+
+		decls = append(decls, decl)
+	}
+
+	return []geminiTool{{FunctionDeclarations: decls}}
+}
+
+// convertHistoryToGemini maps shared messages to Gemini contents. System
+// messages become the system instruction; tool results are matched back to
+// the function name through the preceding assistant tool calls, since Gemini
+// identifies responses by name rather than call ID.
+func convertHistoryToGemini(history []*Message) ([]geminiContent, *geminiContent) {
+	var contents []geminiContent
+	var system *geminiContent
+	callNames := make(map[string]string)
+
+	for _, msg := range history {
+		switch msg.Role {
+		case "system":
+			system = &geminiContent{Parts: []geminiPart{{Text: msg.Content}}}
+		case "assistant":
+			var parts []geminiPart
+			if msg.Content != "" {
+				parts = append(parts, geminiPart{Text: msg.Content})
+			}
+			for _, tc := range msg.ToolCalls {
+				callNames[tc.ID] = tc.Name
+				parts = append(parts, geminiPart{FunctionCall: &geminiFunctionCall{Name: tc.Name, Args: tc.Arguments}})
+			}
+			if len(parts) > 0 {
+				contents = append(contents, geminiContent{Role: "model", Parts: parts})
+			}
+		case "tool":
+			part := geminiPart{FunctionResponse: &geminiFunctionResponse{
+				Name:     callNames[msg.ToolCallID],
+				Response: map[string]any{"output": msg.Content},
+			}}
+			// Consecutive tool results belong in the same user turn
+			if n := len(contents); n > 0 && contents[n-1].Role == "user" && contents[n-1].Parts[0].FunctionResponse != nil {
+				contents[n-1].Parts = append(contents[n-1].Parts, part)
+			} else {
+				contents = append(contents, geminiContent{Role: "user", Parts: []geminiPart{part}})
+			}
+		default:
+			contents = append(contents, geminiContent{Role: "user", Parts: []geminiPart{{Text: msg.Content}}})
+		}
+	}
+
+	return contents, system
+}
@@ -0,0 +1,89 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+)
+
+const openRouterModelsURL = "https://openrouter.ai/api/v1/models"
+
+// ModelSelector is implemented by clients whose model can be switched at runtime
+type ModelSelector interface {
+	Model() string
+	SetModel(model string)
+}
+
+// ModelLister is implemented by clients that can list the models they offer
+type ModelLister interface {
+	ListModels(ctx context.Context) ([]*ModelInfo, error)
+}
+
+// ModelInfo describes an available model. Prices are in USD per token.
+type ModelInfo struct {
+	ID              string
+	Name            string
+	ContextLength   int64
+	PromptPrice     float64
+	CompletionPrice float64
+}
+
+// ListModels queries the OpenRouter models API, sorted by ID
+func (c *OpenRouterClient) ListModels(ctx context.Context) ([]*ModelInfo, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", openRouterModelsURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+c.apiKey)
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response: %w", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("API error (status %d): %s", resp.StatusCode, string(body))
+	}
+
+	var result struct {
+		Data []struct {
+			ID            string `json:"id"`
+			Name          string `json:"name"`
+			ContextLength int64  `json:"context_length"`
+			Pricing       struct {
+				Prompt     string `json:"prompt"`
+				Completion string `json:"completion"`
+			} `json:"pricing"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(body, &result); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal response: %w", err)
+	}
+
+	models := make([]*ModelInfo, 0, len(result.Data))
+	for _, m := range result.Data {
+		// Prices arrive as decimal strings; unparseable ones are left at zero
+		prompt, _ := strconv.ParseFloat(m.Pricing.Prompt, 64)
+		completion, _ := strconv.ParseFloat(m.Pricing.Completion, 64)
+		models = append(models, &ModelInfo{
+			ID:              m.ID,
+			Name:            m.Name,
+			ContextLength:   m.ContextLength,
+			PromptPrice:     prompt,
+			CompletionPrice: completion,
+		})
+	}
+	sort.Slice(models, func(i, j int) bool { return models[i].ID < models[j].ID })
+
+	return models, nil
+}
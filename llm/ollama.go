@@ -0,0 +1,458 @@
+package llm
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	defaultOllamaHost  = "http://localhost:11434"
+	defaultOllamaModel = "llama3.1"
+)
+
+// OllamaClient talks to a local Ollama server, so chat works offline. The
+// model must support tools for /chat (llama3.1, qwen2.5, mistral-nemo, ...).
+type OllamaClient struct {
+	host          string
+	model         string
+	httpClient    *http.Client
+	debug         bool
+	toolResultMax int // tool results are compressed to this size after one round
+	maxAttempts   int // sends of an overloaded or failing request before giving up
+}
+
+// ollamaSettings returns the server address and model from OLLAMA_HOST and
+// OLLAMA_MODEL, or their defaults
+func ollamaSettings() (host, model string) {
+	host = defaultOllamaHost
+	if hostOverride := os.Getenv("OLLAMA_HOST"); hostOverride != "" {
+		host = hostOverride
+		// Ollama itself accepts a bare host:port here
+		if !strings.Contains(host, "://") {
+			host = "http://" + host
+		}
+	}
+
+	model = defaultOllamaModel
+	if modelOverride := os.Getenv("OLLAMA_MODEL"); modelOverride != "" {
+		model = modelOverride
+	}
+	return strings.TrimRight(host, "/"), model
+}
+
+// NewOllamaClient connects to OLLAMA_HOST (default http://localhost:11434)
+// with OLLAMA_MODEL (default llama3.1). Nothing is sent until the first
+// request, so a server that isn't running shows up as that request's error.
+func NewOllamaClient(ctx context.Context) (*OllamaClient, error) {
+	host, model := ollamaSettings()
+	return &OllamaClient{
+		host:  host,
+		model: model,
+		httpClient: &http.Client{
+			// Local models on a laptop can take a while, especially the first load
+			Timeout:   5 * time.Minute,
+			Transport: loggingTransport{},
+		},
+		toolResultMax: toolResultMaxChars(),
+		maxAttempts:   maxAttempts(),
+	}, nil
+}
+
+func (c *OllamaClient) Chat(ctx context.Context, prompt string) (*Response, error) {
+	return c.ChatWithConfig(ctx, prompt, DefaultConfig())
+}
+
+func (c *OllamaClient) ChatWithConfig(ctx context.Context, prompt string, config *Config) (*Response, error) {
+	if strings.TrimSpace(prompt) == "" {
+		return nil, ErrEmptyPrompt
+	}
+
+	if config == nil {
+		config = DefaultConfig()
+	}
+
+	messages := []ollamaMessage{{Role: "user", Content: prompt}}
+	if config.System != "" {
+		messages = append([]ollamaMessage{{Role: "system", Content: config.System}}, messages...)
+	}
+
+	resp, err := c.sendRequest(ctx, config, messages, nil)
+	if err != nil {
+		return nil, err
+	}
+	return &Response{
+		Text:         resp.Message.Content,
+		FinishReason: resp.DoneReason,
+		TokensUsed:   resp.PromptEvalCount + resp.EvalCount,
+		InputTokens:  resp.PromptEvalCount,
+		OutputTokens: resp.EvalCount,
+	}, nil
+}
+
+func (c *OllamaClient) ChatWithTools(ctx context.Context, message string, history []*Message, tools []*Tool, executor ToolExecutor) (*Response, []*Message, error) {
+	if strings.TrimSpace(message) == "" {
+		return nil, history, ErrEmptyPrompt
+	}
+
+	config := DefaultConfig()
+	olTools := convertToolsToOllama(tools)
+
+	// Build messages from history (which should include a system prompt
+	// from the caller) plus the new message
+	messages := convertHistoryToOllama(history)
+	messages = append(messages, ollamaMessage{Role: "user", Content: message})
+
+	// Update history with new user message
+	newHistory := append(history, &Message{Role: "user", Content: message})
+
+	if c.debug {
+		fmt.Printf("[DEBUG] Request: %d messages, %d tools\n", len(messages), len(olTools))
+	}
+
+	var totalInputTokens, totalOutputTokens int64
+	var accumulatedContent strings.Builder
+	var toolResults []string // Track tool results for fallback response
+	var fullResults []int    // Indexes of tool messages the model hasn't seen yet
+	callCount := 0           // Ollama doesn't number tool calls, so history IDs are made up
+
+	// Tool calling loop
+	for {
+		resp, err := c.sendRequest(ctx, config, messages, olTools)
+		if err != nil {
+			return nil, newHistory, err
+		}
+
+		// The model has now seen these results in full; later rounds get the short version
+		for _, i := range fullResults {
+			messages[i].Content = compressToolResult(messages[i].Content, c.toolResultMax)
+		}
+		fullResults = nil
+
+		totalInputTokens += resp.PromptEvalCount
+		totalOutputTokens += resp.EvalCount
+
+		if c.debug {
+			fmt.Printf("[DEBUG] Response: done_reason=%s, tool_calls=%d\n", resp.DoneReason, len(resp.Message.ToolCalls))
+		}
+
+		// Accumulate any content from this response
+		if content := strings.TrimSpace(resp.Message.Content); content != "" {
+			if accumulatedContent.Len() > 0 {
+				accumulatedContent.WriteString(" ")
+			}
+			accumulatedContent.WriteString(content)
+		}
+
+		if len(resp.Message.ToolCalls) > 0 {
+			messages = append(messages, resp.Message)
+
+			assistantMsg := &Message{
+				Role:      "assistant",
+				Content:   resp.Message.Content,
+				ToolCalls: make([]ToolCall, len(resp.Message.ToolCalls)),
+			}
+			for i, tc := range resp.Message.ToolCalls {
+				callCount++
+				assistantMsg.ToolCalls[i] = ToolCall{
+					ID:        fmt.Sprintf("ollama-%d-%d", time.Now().UnixNano(), callCount),
+					Name:      tc.Function.Name,
+					Arguments: tc.Function.Arguments,
+				}
+			}
+			newHistory = append(newHistory, assistantMsg)
+
+			// Execute each tool call, in the order the model made them, and add responses
+			if c.debug {
+				for _, tc := range assistantMsg.ToolCalls {
+					args, _ := json.Marshal(tc.Arguments)
+					fmt.Printf("[DEBUG] Tool call: %s\n", tc.Name)
+					fmt.Printf("[DEBUG]   Arguments: %s\n", args)
+				}
+			}
+			results, err := runTools(ctx, assistantMsg.ToolCalls, executor)
+			for i, result := range results {
+				tc := assistantMsg.ToolCalls[i]
+
+				if c.debug {
+					// Truncate long outputs for readability
+					debugResult := result
+					if len(debugResult) > 200 {
+						debugResult = debugResult[:200] + "..."
+					}
+					fmt.Printf("[DEBUG]   Output of %s: %s\n", tc.Name, debugResult)
+				}
+
+				toolResults = append(toolResults, result)
+
+				fullResults = append(fullResults, len(messages))
+				messages = append(messages, ollamaMessage{Role: "tool", Content: result, ToolName: tc.Name})
+
+				// Add to history, compressed since it's sent again with every later message
+				compressed := compressToolResult(result, c.toolResultMax)
+				if c.debug && len(compressed) < len(result) {
+					fmt.Printf("[DEBUG]   Compressed for history: %d of %d chars\n", len(compressed), len(result))
+				}
+				newHistory = append(newHistory, &Message{
+					Role:       "tool",
+					Content:    compressed,
+					ToolCallID: tc.ID,
+				})
+			}
+			if err != nil {
+				return nil, newHistory, err
+			}
+
+			continue
+		}
+
+		finalContent := strings.TrimSpace(accumulatedContent.String())
+
+		// If no text content but tools were called, provide a simple confirmation
+		// (The actual tool outputs are printed by the executor as they happen)
+		if finalContent == "" && len(toolResults) > 0 {
+			finalContent = "Done."
+		}
+		if finalContent == "" && len(toolResults) == 0 {
+			return nil, newHistory, fmt.Errorf("received empty response from Ollama (no content or tool calls)")
+		}
+
+		newHistory = append(newHistory, &Message{Role: "assistant", Content: finalContent})
+
+		// Local models are free, so Cost stays zero
+		return &Response{
+			Text:         finalContent,
+			FinishReason: resp.DoneReason,
+			TokensUsed:   totalInputTokens + totalOutputTokens,
+			InputTokens:  totalInputTokens,
+			OutputTokens: totalOutputTokens,
+		}, newHistory, nil
+	}
+}
+
+// Model returns the model used for chat requests
+func (c *OllamaClient) Model() string {
+	return c.model
+}
+
+// SetModel switches the model used for chat requests
+func (c *OllamaClient) SetModel(model string) {
+	c.model = model
+}
+
+func (c *OllamaClient) SetDebug(enabled bool) {
+	c.debug = enabled
+}
+
+func (c *OllamaClient) Close() error {
+	return nil
+}
+
+// ListModels lists the models pulled into the Ollama server, sorted by name
+func (c *OllamaClient) ListModels(ctx context.Context) ([]*ModelInfo, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", c.host+"/api/tags", nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, c.connectError(err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("API error (status %d): %s", resp.StatusCode, string(body))
+	}
+
+	var result struct {
+		Models []struct {
+			Name string `json:"name"`
+		} `json:"models"`
+	}
+	if err := json.Unmarshal(body, &result); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal response: %w", err)
+	}
+
+	models := make([]*ModelInfo, 0, len(result.Models))
+	for _, m := range result.Models {
+		models = append(models, &ModelInfo{ID: m.Name, Name: m.Name})
+	}
+	sort.Slice(models, func(i, j int) bool { return models[i].ID < models[j].ID })
+	return models, nil
+}
+
+// Internal types for the Ollama /api/chat API
+
+type ollamaMessage struct {
+	Role      string           `json:"role"`
+	Content   string           `json:"content"`
+	ToolCalls []ollamaToolCall `json:"tool_calls,omitempty"`
+	ToolName  string           `json:"tool_name,omitempty"` // on tool results, the tool that produced them
+}
+
+type ollamaToolCall struct {
+	Function struct {
+		Name      string         `json:"name"`
+		Arguments map[string]any `json:"arguments"`
+	} `json:"function"`
+}
+
+type ollamaTool struct {
+	Type     string `json:"type"`
+	Function struct {
+		Name        string         `json:"name"`
+		Description string         `json:"description"`
+		Parameters  map[string]any `json:"parameters"`
+	} `json:"function"`
+}
+
+type ollamaRequest struct {
+	Model    string          `json:"model"`
+	Messages []ollamaMessage `json:"messages"`
+	Tools    []ollamaTool    `json:"tools,omitempty"`
+	Stream   bool            `json:"stream"`
+	Format   map[string]any  `json:"format,omitempty"` // a JSON Schema the reply must match
+	Options  struct {
+		Temperature float32 `json:"temperature,omitempty"`
+		NumPredict  int32   `json:"num_predict,omitempty"`
+	} `json:"options"`
+}
+
+type ollamaResponse struct {
+	Message         ollamaMessage `json:"message"`
+	DoneReason      string        `json:"done_reason"`
+	PromptEvalCount int64         `json:"prompt_eval_count"`
+	EvalCount       int64         `json:"eval_count"`
+	Error           string        `json:"error"`
+}
+
+func (c *OllamaClient) sendRequest(ctx context.Context, config *Config, messages []ollamaMessage, tools []ollamaTool) (*ollamaResponse, error) {
+	reqBody := ollamaRequest{
+		Model:    c.model,
+		Messages: messages,
+		Tools:    tools,
+	}
+	reqBody.Options.Temperature = config.Temperature
+	reqBody.Options.NumPredict = config.MaxTokens
+	if config.Schema != nil {
+		reqBody.Format = config.Schema.Schema
+	}
+
+	jsonBody, err := json.Marshal(reqBody)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	// An overloaded server (503) and other server errors are retried with backoff
+	var body []byte
+	for attempt := 1; ; attempt++ {
+		status, respBody, err := c.post(ctx, jsonBody)
+		if err != nil {
+			return nil, err
+		}
+		if status == http.StatusOK {
+			body = respBody
+			break
+		}
+		if status == http.StatusNotFound {
+			return nil, fmt.Errorf("model %q not found; pull it with `ollama pull %s` or pick another with /model", c.model, c.model)
+		}
+		if !retryable(status) || attempt >= c.maxAttempts {
+			return nil, fmt.Errorf("API error (status %d): %s", status, string(respBody))
+		}
+
+		delay := retryDelay(attempt, "", time.Now())
+		if c.debug {
+			fmt.Printf("[DEBUG] Status %d on attempt %d of %d, retrying in %s\n", status, attempt, c.maxAttempts, delay.Round(time.Millisecond))
+		}
+		if err := sleepContext(ctx, delay); err != nil {
+			return nil, fmt.Errorf("request failed: %w", err)
+		}
+	}
+
+	var result ollamaResponse
+	if err := json.Unmarshal(body, &result); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal response: %w", err)
+	}
+	if result.Error != "" {
+		return nil, fmt.Errorf("API error: %s", result.Error)
+	}
+	return &result, nil
+}
+
+// post sends one chat request, returning the status and the body
+func (c *OllamaClient) post(ctx context.Context, jsonBody []byte) (int, []byte, error) {
+	req, err := http.NewRequestWithContext(ctx, "POST", c.host+"/api/chat", bytes.NewReader(jsonBody))
+	if err != nil {
+		return 0, nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return 0, nil, c.connectError(err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return 0, nil, fmt.Errorf("failed to read response: %w", err)
+	}
+	return resp.StatusCode, body, nil
+}
+
+// connectError explains a failed connection, which usually means the
+// server isn't running
+func (c *OllamaClient) connectError(err error) error {
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return fmt.Errorf("request failed: %w", err)
+	}
+	return fmt.Errorf("request failed (is Ollama running at %s? start it with `ollama serve`): %w", c.host, err)
+}
+
+func convertToolsToOllama(tools []*Tool) []ollamaTool {
+	var result []ollamaTool
+	for _, t := range tools {
+		olTool := ollamaTool{Type: "function"}
+		olTool.Function.Name = t.Name
+		olTool.Function.Description = t.Description
+		olTool.Function.Parameters = t.InputSchema()
+		result = append(result, olTool)
+	}
+	return result
+}
+
+// convertHistoryToOllama converts chat history to Ollama messages. Tool
+// results are matched to their calls by name, since Ollama has no call IDs.
+func convertHistoryToOllama(history []*Message) []ollamaMessage {
+	names := make(map[string]string) // tool call ID -> tool name
+	messages := make([]ollamaMessage, 0, len(history))
+	for _, msg := range history {
+		olMsg := ollamaMessage{Role: msg.Role, Content: msg.Content}
+		for _, tc := range msg.ToolCalls {
+			names[tc.ID] = tc.Name
+			var call ollamaToolCall
+			call.Function.Name = tc.Name
+			call.Function.Arguments = tc.Arguments
+			olMsg.ToolCalls = append(olMsg.ToolCalls, call)
+		}
+		if msg.Role == "tool" {
+			olMsg.ToolName = names[msg.ToolCallID]
+		}
+		messages = append(messages, olMsg)
+	}
+	return messages
+}
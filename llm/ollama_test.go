@@ -0,0 +1,269 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// testOllama returns an Ollama client for a server at host
+func testOllama(host string) *OllamaClient {
+	return &OllamaClient{
+		host:          host,
+		model:         "llama3.1",
+		httpClient:    &http.Client{Timeout: 10 * time.Second},
+		toolResultMax: defaultToolResultMaxChars,
+		maxAttempts:   1,
+	}
+}
+
+// ollamaServer answers /api/chat with each reply in turn, the last one
+// again once they run out, and records the requests
+type ollamaServer struct {
+	*httptest.Server
+	mu       sync.Mutex
+	replies  []string
+	requests []ollamaRequest
+}
+
+func newOllamaServer(t *testing.T, replies ...string) *ollamaServer {
+	t.Helper()
+	s := &ollamaServer{replies: replies}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/chat" || r.Method != "POST" {
+			t.Errorf("Unexpected request %s %s", r.Method, r.URL.Path)
+		}
+		var req ollamaRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Errorf("Request isn't JSON: %v", err)
+		}
+		s.mu.Lock()
+		reply := s.replies[min(len(s.requests), len(s.replies)-1)]
+		s.requests = append(s.requests, req)
+		s.mu.Unlock()
+		fmt.Fprint(w, reply)
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+func TestOllamaChat(t *testing.T) {
+	srv := newOllamaServer(t, `{"message": {"role": "assistant", "content": "Hello there"}, "done_reason": "stop", "prompt_eval_count": 12, "eval_count": 5}`)
+
+	config := DefaultConfig()
+	config.System = "Be brief."
+	config.Temperature = 0.2
+	config.MaxTokens = 256
+	resp, err := testOllama(srv.URL).ChatWithConfig(context.Background(), "hi", config)
+	if err != nil {
+		t.Fatalf("ChatWithConfig: %v", err)
+	}
+
+	req := srv.requests[0]
+	if req.Model != "llama3.1" || req.Stream || req.Options.Temperature != 0.2 || req.Options.NumPredict != 256 {
+		t.Errorf("Expected the model and options without streaming, got %+v", req)
+	}
+	want := []ollamaMessage{{Role: "system", Content: "Be brief."}, {Role: "user", Content: "hi"}}
+	if !reflect.DeepEqual(req.Messages, want) {
+		t.Errorf("Expected messages %+v, got %+v", want, req.Messages)
+	}
+	if resp.Text != "Hello there" || resp.FinishReason != "stop" || resp.InputTokens != 12 || resp.OutputTokens != 5 || resp.TokensUsed != 17 || resp.Cost != 0 {
+		t.Errorf("Expected the reply with its token counts, got %+v", resp)
+	}
+
+	if _, err := testOllama(srv.URL).Chat(context.Background(), "  "); err != ErrEmptyPrompt {
+		t.Errorf("Expected ErrEmptyPrompt, got %v", err)
+	}
+}
+
+func TestOllamaChatWithTools(t *testing.T) {
+	longListing := strings.Repeat("[1a2b3c4d] Write report\n", 10)
+	srv := newOllamaServer(t,
+		`{"message": {"role": "assistant", "content": "", "tool_calls": [
+			{"function": {"name": "tasks", "arguments": {"project_id": "work"}}}
+		]}, "done_reason": "stop", "prompt_eval_count": 100, "eval_count": 10}`,
+		`{"message": {"role": "assistant", "content": "Setting it.", "tool_calls": [
+			{"function": {"name": "due", "arguments": {"task_id": "1a2b3c4d", "date": "tomorrow"}}},
+			{"function": {"name": "duration", "arguments": {"task_id": "1a2b3c4d", "duration": "2h"}}}
+		]}, "done_reason": "stop", "prompt_eval_count": 200, "eval_count": 20}`,
+		`{"message": {"role": "assistant", "content": "All set."}, "done_reason": "stop", "prompt_eval_count": 300, "eval_count": 30}`,
+	)
+	client := testOllama(srv.URL)
+	client.toolResultMax = 50
+
+	tools := []*Tool{{
+		Name:        "due",
+		Description: "Set a task's due date",
+		Parameters: &ToolParameters{
+			Type: "object",
+			Properties: map[string]*ToolProperty{
+				"task_id": {Type: "string", Description: "The task"},
+				"date":    {Type: "string", Description: "The date"},
+			},
+			Required: []string{"task_id", "date"},
+		},
+	}}
+	history := []*Message{{Role: "system", Content: "You manage tasks."}}
+
+	var calls []string
+	executor := func(name string, args map[string]any) string {
+		calls = append(calls, fmt.Sprintf("%s %v", name, args))
+		if name == "tasks" {
+			return longListing
+		}
+		return "Set " + name
+	}
+
+	resp, newHistory, err := client.ChatWithTools(context.Background(), "give the report 2h, due tomorrow", history, tools, executor)
+	if err != nil {
+		t.Fatalf("ChatWithTools: %v", err)
+	}
+
+	// Tool calls run in order with the model's arguments
+	wantCalls := []string{"tasks map[project_id:work]", "due map[date:tomorrow task_id:1a2b3c4d]", "duration map[duration:2h task_id:1a2b3c4d]"}
+	if !reflect.DeepEqual(calls, wantCalls) {
+		t.Errorf("Expected calls %q, got %q", wantCalls, calls)
+	}
+
+	// Content from every round is kept, and tokens add up
+	if resp.Text != "Setting it. All set." || resp.InputTokens != 600 || resp.OutputTokens != 60 || resp.TokensUsed != 660 {
+		t.Errorf("Expected the joined reply and summed tokens, got %+v", resp)
+	}
+
+	// The tools go out as functions with their schema
+	first := srv.requests[0]
+	if len(first.Tools) != 1 || first.Tools[0].Type != "function" || first.Tools[0].Function.Name != "due" || first.Tools[0].Function.Parameters["required"] == nil {
+		t.Errorf("Expected the due tool with its schema, got %+v", first.Tools)
+	}
+	if len(first.Messages) != 2 || first.Messages[0].Role != "system" || first.Messages[1].Content != "give the report 2h, due tomorrow" {
+		t.Errorf("Expected the system prompt and the message, got %+v", first.Messages)
+	}
+
+	// Results go back named by tool, in full the first time the model sees
+	// them and compressed after that
+	second := srv.requests[1].Messages
+	if len(second) != 4 || second[2].Role != "assistant" || len(second[2].ToolCalls) != 1 || second[3].Role != "tool" || second[3].ToolName != "tasks" || second[3].Content != longListing {
+		t.Errorf("Expected the call and its full result, got %+v", second)
+	}
+	third := srv.requests[2].Messages
+	if len(third) != 7 || third[3].Content != compressToolResult(longListing, 50) || third[5].ToolName != "due" || third[6].ToolName != "duration" || third[6].Content != "Set duration" {
+		t.Errorf("Expected the old result compressed and the new ones in order, got %+v", third)
+	}
+
+	// History: the system prompt, the message, each round's calls and
+	// results with matching IDs, and the answer
+	var roles []string
+	for _, m := range newHistory {
+		roles = append(roles, m.Role)
+	}
+	if want := []string{"system", "user", "assistant", "tool", "assistant", "tool", "tool", "assistant"}; !reflect.DeepEqual(roles, want) {
+		t.Fatalf("Expected history %v, got %v", want, roles)
+	}
+	calls2 := newHistory[4].ToolCalls
+	if len(calls2) != 2 || calls2[0].ID == calls2[1].ID || newHistory[5].ToolCallID != calls2[0].ID || newHistory[6].ToolCallID != calls2[1].ID {
+		t.Errorf("Expected tool results matched to their calls, got %+v and %+v", newHistory[5], newHistory[6])
+	}
+	if newHistory[3].Content != compressToolResult(longListing, 50) || newHistory[7].Content != "Setting it. All set." {
+		t.Errorf("Expected compressed results and the answer in history, got %q and %q", newHistory[3].Content, newHistory[7].Content)
+	}
+
+	// Sent again, the history names each result's tool
+	converted := convertHistoryToOllama(newHistory)
+	if converted[5].ToolName != "due" || converted[6].ToolName != "duration" || len(converted[4].ToolCalls) != 2 {
+		t.Errorf("Expected tool names from the calls, got %+v", converted)
+	}
+}
+
+func TestOllamaToolsOnlyReply(t *testing.T) {
+	srv := newOllamaServer(t,
+		`{"message": {"role": "assistant", "tool_calls": [{"function": {"name": "done", "arguments": {"task_id": "1a2b3c4d"}}}]}}`,
+		`{"message": {"role": "assistant", "content": ""}, "done_reason": "stop"}`,
+	)
+	resp, _, err := testOllama(srv.URL).ChatWithTools(context.Background(), "finish it", nil, nil, func(string, map[string]any) string { return "Marked done" })
+	if err != nil || resp.Text != "Done." {
+		t.Errorf("Expected Done. after tools with no text, got %+v, %v", resp, err)
+	}
+}
+
+func TestOllamaErrors(t *testing.T) {
+	for _, tc := range []struct {
+		name   string
+		status int
+		body   string
+		want   string
+	}{
+		{"model not pulled", http.StatusNotFound, `{"error": "model 'llama3.1' not found"}`, "pull it with `ollama pull llama3.1`"},
+		{"bad request", http.StatusBadRequest, `{"error": "invalid format"}`, "API error (status 400): {\"error\": \"invalid format\"}"},
+		{"server error", http.StatusInternalServerError, `{"error": "out of memory"}`, "API error (status 500)"},
+		{"error in the body", http.StatusOK, `{"error": "model is loading"}`, "API error: model is loading"},
+		{"not JSON", http.StatusOK, `<html>`, "failed to unmarshal response"},
+		{"empty reply", http.StatusOK, `{"message": {"role": "assistant", "content": ""}}`, "received empty response from Ollama"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(tc.status)
+				fmt.Fprint(w, tc.body)
+			}))
+			defer srv.Close()
+
+			_, _, err := testOllama(srv.URL).ChatWithTools(context.Background(), "hi", nil, nil, nil)
+			if err == nil || !strings.Contains(err.Error(), tc.want) {
+				t.Errorf("Expected an error with %q, got %v", tc.want, err)
+			}
+		})
+	}
+
+	// A server that isn't running gets a hint
+	srv := httptest.NewServer(http.NotFoundHandler())
+	srv.Close()
+	_, err := testOllama(srv.URL).Chat(context.Background(), "hi")
+	if err == nil || !strings.Contains(err.Error(), "is Ollama running at "+srv.URL) {
+		t.Errorf("Expected a hint to start Ollama, got %v", err)
+	}
+}
+
+func TestOllamaRetry(t *testing.T) {
+	requests := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if requests == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			fmt.Fprint(w, `{"error": "server busy"}`)
+			return
+		}
+		fmt.Fprint(w, `{"message": {"role": "assistant", "content": "Hello"}}`)
+	}))
+	defer srv.Close()
+
+	client := testOllama(srv.URL)
+	client.maxAttempts = 2
+	resp, err := client.Chat(context.Background(), "hi")
+	if err != nil || resp.Text != "Hello" || requests != 2 {
+		t.Errorf("Expected the reply after one retry, got %+v, %v after %d requests", resp, err, requests)
+	}
+}
+
+func TestOllamaListModels(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/tags" {
+			t.Errorf("Expected /api/tags, got %s", r.URL.Path)
+		}
+		fmt.Fprint(w, `{"models": [{"name": "qwen2.5:7b"}, {"name": "llama3.1:latest"}]}`)
+	}))
+	defer srv.Close()
+
+	models, err := testOllama(srv.URL).ListModels(context.Background())
+	if err != nil {
+		t.Fatalf("ListModels: %v", err)
+	}
+	if len(models) != 2 || models[0].ID != "llama3.1:latest" || models[1].ID != "qwen2.5:7b" {
+		t.Errorf("Expected the models sorted by name, got %+v", models)
+	}
+}
@@ -0,0 +1,123 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+	"time"
+)
+
+const defaultOpenAIModel = "gpt-4o-mini"
+
+// OpenAIClient talks to any OpenAI-compatible chat completions API: OpenAI
+// itself, Azure OpenAI, Together, Groq, or a corporate proxy. OpenRouter
+// speaks the same protocol, so this is the OpenRouter client pointed at
+// another endpoint, with its request and response types and tool-calling
+// loop; only listing models differs.
+type OpenAIClient struct {
+	*OpenRouterClient
+	baseURL *url.URL
+}
+
+// NewOpenAIClient connects to OPENAI_BASE_URL (e.g. https://api.groq.com/openai/v1)
+// with OPENAI_API_KEY and OPENAI_MODEL (default gpt-4o-mini). The key may be
+// left unset for a proxy that doesn't need one. An Azure OpenAI URL gets the
+// key in its api-key header; give it the deployment path and api-version,
+// e.g. https://x.openai.azure.com/openai/deployments/gpt-4o?api-version=2024-06-01.
+func NewOpenAIClient(ctx context.Context) (*OpenAIClient, error) {
+	rawURL := strings.TrimSpace(os.Getenv("OPENAI_BASE_URL"))
+	if rawURL == "" {
+		return nil, ErrMissingOpenAIBaseURL
+	}
+	base, err := url.Parse(strings.TrimRight(rawURL, "/"))
+	if err != nil || (base.Scheme != "http" && base.Scheme != "https") || base.Host == "" {
+		return nil, fmt.Errorf("invalid OPENAI_BASE_URL %q (use a URL like https://api.openai.com/v1)", rawURL)
+	}
+
+	apiKey := os.Getenv("OPENAI_API_KEY")
+	headers := make(map[string]string)
+	switch {
+	case apiKey == "":
+	case strings.HasSuffix(base.Hostname(), ".openai.azure.com"):
+		headers["api-key"] = apiKey
+	default:
+		headers["Authorization"] = "Bearer " + apiKey
+	}
+
+	model := defaultOpenAIModel
+	if modelOverride := os.Getenv("OPENAI_MODEL"); modelOverride != "" {
+		model = modelOverride
+	}
+
+	return &OpenAIClient{
+		OpenRouterClient: &OpenRouterClient{
+			apiKey:  apiKey,
+			url:     openAIEndpoint(base, "chat/completions"),
+			headers: headers,
+			model:   model,
+			httpClient: &http.Client{
+				Timeout:   120 * time.Second,
+				Transport: loggingTransport{},
+			},
+			toolResultMax: toolResultMaxChars(),
+			maxAttempts:   maxAttempts(),
+		},
+		baseURL: base,
+	}, nil
+}
+
+// openAIEndpoint appends path to the base URL's path, keeping its query
+// (Azure's api-version)
+func openAIEndpoint(base *url.URL, path string) string {
+	u := *base
+	u.Path = strings.TrimRight(u.Path, "/") + "/" + path
+	return u.String()
+}
+
+// ListModels queries the /models endpoint, sorted by ID. The API has no
+// prices, so they're left at zero.
+func (c *OpenAIClient) ListModels(ctx context.Context) ([]*ModelInfo, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", openAIEndpoint(c.baseURL, "models"), nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	for name, value := range c.headers {
+		req.Header.Set(name, value)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("API error (status %d): %s", resp.StatusCode, string(body))
+	}
+
+	var result struct {
+		Data []struct {
+			ID string `json:"id"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(body, &result); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal response: %w", err)
+	}
+
+	models := make([]*ModelInfo, 0, len(result.Data))
+	for _, m := range result.Data {
+		models = append(models, &ModelInfo{ID: m.ID, Name: m.ID})
+	}
+	sort.Slice(models, func(i, j int) bool { return models[i].ID < models[j].ID })
+	return models, nil
+}
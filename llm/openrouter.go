@@ -15,9 +15,15 @@ import (
 const openRouterURL = "https://openrouter.ai/api/v1/chat/completions"
 
 type OpenRouterClient struct {
-	apiKey     string
-	httpClient *http.Client
-	debug      bool
+	apiKey        string
+	url           string            // chat completions endpoint
+	headers       map[string]string // sent with every chat request, including auth
+	model         string
+	httpClient    *http.Client
+	debug         bool
+	toolResultMax int  // tool results are compressed to this size after one round
+	maxAttempts   int  // sends of a rate-limited or failing request before giving up
+	promptCache   bool // mark the fixed start of the system prompt for caching
 }
 
 func NewOpenRouterClient(ctx context.Context) (*OpenRouterClient, error) {
@@ -26,11 +32,27 @@ func NewOpenRouterClient(ctx context.Context) (*OpenRouterClient, error) {
 		return nil, ErrMissingAPIKey
 	}
 
+	model := DefaultConfig().Model
+	if modelOverride := os.Getenv("OPENROUTER_MODEL"); modelOverride != "" {
+		model = modelOverride
+	}
+
 	return &OpenRouterClient{
-		apiKey:     apiKey,
+		apiKey: apiKey,
+		url:    openRouterURL,
+		headers: map[string]string{
+			"Authorization": "Bearer " + apiKey,
+			"HTTP-Referer":  "https://github.com/connachermurphy/twooms",
+			"X-Title":       "Twooms",
+		},
+		model: model,
 		httpClient: &http.Client{
-			Timeout: 120 * time.Second,
+			Timeout:   120 * time.Second,
+			Transport: loggingTransport{},
 		},
+		toolResultMax: toolResultMaxChars(),
+		maxAttempts:   maxAttempts(),
+		promptCache:   promptCaching(),
 	}, nil
 }
 
@@ -64,11 +86,7 @@ func (c *OpenRouterClient) ChatWithTools(ctx context.Context, message string, hi
 	}
 
 	config := DefaultConfig()
-
-	// Check for model override
-	if modelOverride := os.Getenv("OPENROUTER_MODEL"); modelOverride != "" {
-		config.Model = modelOverride
-	}
+	config.Model = c.model
 
 	// Convert tools to OpenRouter format
 	orTools := convertToolsToOpenRouter(tools)
@@ -78,7 +96,11 @@ func (c *OpenRouterClient) ChatWithTools(ctx context.Context, message string, hi
 
 	// Add history (which should include a system prompt from the caller)
 	for _, msg := range history {
-		messages = append(messages, convertMessageToOpenRouter(msg))
+		orMsg := convertMessageToOpenRouter(msg)
+		if c.promptCache {
+			orMsg.cachePrefix = msg.CachePrefix
+		}
+		messages = append(messages, orMsg)
 	}
 
 	// Add new user message
@@ -91,10 +113,11 @@ func (c *OpenRouterClient) ChatWithTools(ctx context.Context, message string, hi
 		fmt.Printf("[DEBUG] Request: %d messages, %d tools\n", len(messages), len(orTools))
 	}
 
-	var totalTokens, totalInputTokens, totalOutputTokens int64
+	var totalTokens, totalInputTokens, totalOutputTokens, totalCachedTokens int64
 	var totalCost float64
 	var accumulatedContent strings.Builder
 	var toolResults []string // Track tool results for fallback response
+	var fullResults []int    // Indexes of tool messages the model hasn't seen yet
 
 	// Tool calling loop
 	for {
@@ -103,9 +126,16 @@ func (c *OpenRouterClient) ChatWithTools(ctx context.Context, message string, hi
 			return nil, newHistory, err
 		}
 
+		// The model has now seen these results in full; later rounds get the short version
+		for _, i := range fullResults {
+			messages[i].Content = compressToolResult(messages[i].Content, c.toolResultMax)
+		}
+		fullResults = nil
+
 		totalTokens += resp.usage.TotalTokens
 		totalInputTokens += resp.usage.PromptTokens
 		totalOutputTokens += resp.usage.CompletionTokens
+		totalCachedTokens += resp.usage.PromptTokensDetails.CachedTokens
 		totalCost += resp.usage.Cost
 
 		if len(resp.choices) == 0 {
@@ -148,17 +178,16 @@ func (c *OpenRouterClient) ChatWithTools(ctx context.Context, message string, hi
 			}
 			newHistory = append(newHistory, assistantMsg)
 
-			// Execute each tool call and add responses
-			for _, tc := range choice.Message.ToolCalls {
-				var args map[string]any
-				json.Unmarshal([]byte(tc.Function.Arguments), &args)
-
-				if c.debug {
+			// Execute each tool call, in the order the model made them, and add responses
+			if c.debug {
+				for _, tc := range choice.Message.ToolCalls {
 					fmt.Printf("[DEBUG] Tool call: %s\n", tc.Function.Name)
 					fmt.Printf("[DEBUG]   Arguments: %s\n", tc.Function.Arguments)
 				}
-
-				result := executor(tc.Function.Name, args)
+			}
+			results, err := runTools(ctx, assistantMsg.ToolCalls, executor)
+			for i, result := range results {
+				tc := choice.Message.ToolCalls[i]
 
 				if c.debug {
 					// Truncate long outputs for readability
@@ -166,25 +195,33 @@ func (c *OpenRouterClient) ChatWithTools(ctx context.Context, message string, hi
 					if len(debugResult) > 200 {
 						debugResult = debugResult[:200] + "..."
 					}
-					fmt.Printf("[DEBUG]   Output: %s\n", debugResult)
+					fmt.Printf("[DEBUG]   Output of %s: %s\n", tc.Function.Name, debugResult)
 				}
 
 				toolResults = append(toolResults, result)
 
 				// Add tool response to messages
+				fullResults = append(fullResults, len(messages))
 				messages = append(messages, openRouterMessage{
 					Role:       "tool",
 					Content:    result,
 					ToolCallID: tc.ID,
 				})
 
-				// Add to history
+				// Add to history, compressed since it's sent again with every later message
+				compressed := compressToolResult(result, c.toolResultMax)
+				if c.debug && len(compressed) < len(result) {
+					fmt.Printf("[DEBUG]   Compressed for history: %d of %d chars\n", len(compressed), len(result))
+				}
 				newHistory = append(newHistory, &Message{
 					Role:       "tool",
-					Content:    result,
+					Content:    compressed,
 					ToolCallID: tc.ID,
 				})
 			}
+			if err != nil {
+				return nil, newHistory, err
+			}
 
 			continue
 		}
@@ -216,11 +253,22 @@ func (c *OpenRouterClient) ChatWithTools(ctx context.Context, message string, hi
 			TokensUsed:   totalTokens,
 			InputTokens:  totalInputTokens,
 			OutputTokens: totalOutputTokens,
+			CachedTokens: totalCachedTokens,
 			Cost:         totalCost,
 		}, newHistory, nil
 	}
 }
 
+// Model returns the model used for chat requests
+func (c *OpenRouterClient) Model() string {
+	return c.model
+}
+
+// SetModel switches the model used for chat requests
+func (c *OpenRouterClient) SetModel(model string) {
+	c.model = model
+}
+
 func (c *OpenRouterClient) SetDebug(enabled bool) {
 	c.debug = enabled
 }
@@ -236,6 +284,8 @@ type openRouterMessage struct {
 	Content    string               `json:"content"`
 	ToolCalls  []openRouterToolCall `json:"tool_calls,omitempty"`
 	ToolCallID string               `json:"tool_call_id,omitempty"`
+
+	cachePrefix int // bytes of Content to send as a cacheable block
 }
 
 type openRouterToolCall struct {
@@ -257,11 +307,12 @@ type openRouterTool struct {
 }
 
 type openRouterRequest struct {
-	Model       string              `json:"model"`
-	Messages    []openRouterMessage `json:"messages"`
-	MaxTokens   int32               `json:"max_tokens,omitempty"`
-	Temperature float32             `json:"temperature,omitempty"`
-	Tools       []openRouterTool    `json:"tools,omitempty"`
+	Model          string              `json:"model"`
+	Messages       []openRouterMessage `json:"messages"`
+	MaxTokens      int32               `json:"max_tokens,omitempty"`
+	Temperature    float32             `json:"temperature,omitempty"`
+	Tools          []openRouterTool    `json:"tools,omitempty"`
+	ResponseFormat map[string]any      `json:"response_format,omitempty"`
 }
 
 type openRouterResponse struct {
@@ -270,10 +321,13 @@ type openRouterResponse struct {
 		FinishReason string            `json:"finish_reason"`
 	}
 	usage struct {
-		PromptTokens     int64   `json:"prompt_tokens"`
-		CompletionTokens int64   `json:"completion_tokens"`
-		TotalTokens      int64   `json:"total_tokens"`
-		Cost             float64 `json:"cost"`
+		PromptTokens        int64   `json:"prompt_tokens"`
+		CompletionTokens    int64   `json:"completion_tokens"`
+		TotalTokens         int64   `json:"total_tokens"`
+		Cost                float64 `json:"cost"`
+		PromptTokensDetails struct {
+			CachedTokens int64 `json:"cached_tokens"`
+		} `json:"prompt_tokens_details"`
 	}
 }
 
@@ -293,6 +347,7 @@ func (c *OpenRouterClient) sendRequest(ctx context.Context, config *Config, mess
 		TokensUsed:   resp.usage.TotalTokens,
 		InputTokens:  resp.usage.PromptTokens,
 		OutputTokens: resp.usage.CompletionTokens,
+		CachedTokens: resp.usage.PromptTokensDetails.CachedTokens,
 		Cost:         resp.usage.Cost,
 	}, nil
 }
@@ -308,35 +363,37 @@ func (c *OpenRouterClient) sendRequestWithTools(ctx context.Context, config *Con
 	if len(tools) > 0 {
 		reqBody.Tools = tools
 	}
+	if config.Schema != nil {
+		reqBody.ResponseFormat = openRouterResponseFormat(config.Schema)
+	}
 
 	jsonBody, err := json.Marshal(reqBody)
 	if err != nil {
 		return nil, fmt.Errorf("failed to marshal request: %w", err)
 	}
 
-	req, err := http.NewRequestWithContext(ctx, "POST", openRouterURL, bytes.NewReader(jsonBody))
-	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %w", err)
-	}
-
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("Authorization", "Bearer "+c.apiKey)
-	req.Header.Set("HTTP-Referer", "https://github.com/connachermurphy/twooms")
-	req.Header.Set("X-Title", "Twooms")
-
-	resp, err := c.httpClient.Do(req)
-	if err != nil {
-		return nil, fmt.Errorf("request failed: %w", err)
-	}
-	defer resp.Body.Close()
-
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, fmt.Errorf("failed to read response: %w", err)
-	}
+	// Rate limits (429) and server errors (5xx) are retried with backoff
+	var body []byte
+	for attempt := 1; ; attempt++ {
+		status, retryAfter, respBody, err := c.post(ctx, jsonBody)
+		if err != nil {
+			return nil, err
+		}
+		if status == http.StatusOK {
+			body = respBody
+			break
+		}
+		if !retryable(status) || attempt >= c.maxAttempts {
+			return nil, fmt.Errorf("API error (status %d): %s", status, string(respBody))
+		}
 
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("API error (status %d): %s", resp.StatusCode, string(body))
+		delay := retryDelay(attempt, retryAfter, time.Now())
+		if c.debug {
+			fmt.Printf("[DEBUG] Status %d on attempt %d of %d, retrying in %s\n", status, attempt, c.maxAttempts, delay.Round(time.Millisecond))
+		}
+		if err := sleepContext(ctx, delay); err != nil {
+			return nil, fmt.Errorf("request failed: %w", err)
+		}
 	}
 
 	var result struct {
@@ -345,10 +402,13 @@ func (c *OpenRouterClient) sendRequestWithTools(ctx context.Context, config *Con
 			FinishReason string            `json:"finish_reason"`
 		} `json:"choices"`
 		Usage struct {
-			PromptTokens     int64   `json:"prompt_tokens"`
-			CompletionTokens int64   `json:"completion_tokens"`
-			TotalTokens      int64   `json:"total_tokens"`
-			Cost             float64 `json:"cost"`
+			PromptTokens        int64   `json:"prompt_tokens"`
+			CompletionTokens    int64   `json:"completion_tokens"`
+			TotalTokens         int64   `json:"total_tokens"`
+			Cost                float64 `json:"cost"`
+			PromptTokensDetails struct {
+				CachedTokens int64 `json:"cached_tokens"`
+			} `json:"prompt_tokens_details"`
 		} `json:"usage"`
 		Error *struct {
 			Message string `json:"message"`
@@ -371,6 +431,32 @@ func (c *OpenRouterClient) sendRequestWithTools(ctx context.Context, config *Con
 	}, nil
 }
 
+// post sends one chat completion request, returning the status, the
+// Retry-After header, and the body
+func (c *OpenRouterClient) post(ctx context.Context, jsonBody []byte) (int, string, []byte, error) {
+	req, err := http.NewRequestWithContext(ctx, "POST", c.url, bytes.NewReader(jsonBody))
+	if err != nil {
+		return 0, "", nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	for name, value := range c.headers {
+		req.Header.Set(name, value)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return 0, "", nil, fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return 0, "", nil, fmt.Errorf("failed to read response: %w", err)
+	}
+	return resp.StatusCode, resp.Header.Get("Retry-After"), body, nil
+}
+
 func convertToolsToOpenRouter(tools []*Tool) []openRouterTool {
 	var result []openRouterTool
 
@@ -388,10 +474,7 @@ func convertToolsToOpenRouter(tools []*Tool) []openRouterTool {
 			if len(t.Parameters.Properties) > 0 {
 				props := make(map[string]any)
 				for name, prop := range t.Parameters.Properties {
-					props[name] = map[string]string{
-						"type":        prop.Type,
-						"description": prop.Description,
-					}
+					props[name] = prop.schema()
 				}
 				params["properties"] = props
 			}
@@ -429,4 +512,3 @@ func convertMessageToOpenRouter(msg *Message) openRouterMessage {
 
 	return orMsg
 }
-
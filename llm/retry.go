@@ -0,0 +1,82 @@
+package llm
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"net/http"
+	"os"
+	"strconv"
+	"time"
+)
+
+// defaultMaxAttempts is how many times a rate-limited or failing request is
+// sent before giving up
+const defaultMaxAttempts = 4
+
+const (
+	retryBaseDelay = time.Second
+	retryMaxDelay  = 30 * time.Second
+	// retryAfterMax caps how long a server's Retry-After can hold the prompt
+	retryAfterMax = 60 * time.Second
+)
+
+// maxAttempts reads LLM_MAX_ATTEMPTS; 1 turns retries off
+func maxAttempts() int {
+	if s := os.Getenv("LLM_MAX_ATTEMPTS"); s != "" {
+		if n, err := strconv.Atoi(s); err == nil && n >= 1 {
+			return n
+		}
+		fmt.Fprintf(os.Stderr, "Warning: invalid LLM_MAX_ATTEMPTS %q, using %d\n", s, defaultMaxAttempts)
+	}
+	return defaultMaxAttempts
+}
+
+// retryable reports whether a response status is worth retrying: rate
+// limits and server errors
+func retryable(status int) bool {
+	return status == http.StatusTooManyRequests || status >= 500
+}
+
+// retryDelay is how long to wait before the next attempt after the given
+// one (counting from 1). A Retry-After header wins when present; otherwise
+// the delay doubles each attempt, with up to half again added as jitter so
+// concurrent sessions don't retry in lockstep.
+func retryDelay(attempt int, retryAfter string, now time.Time) time.Duration {
+	if d, ok := parseRetryAfter(retryAfter, now); ok {
+		return min(d, retryAfterMax)
+	}
+	d := retryBaseDelay
+	for i := 1; i < attempt && d < retryMaxDelay; i++ {
+		d *= 2
+	}
+	d = min(d, retryMaxDelay)
+	return d + time.Duration(rand.Int63n(int64(d)/2+1))
+}
+
+// parseRetryAfter reads a Retry-After value in seconds or as an HTTP date
+func parseRetryAfter(value string, now time.Time) (time.Duration, bool) {
+	if value == "" {
+		return 0, false
+	}
+	if secs, err := strconv.Atoi(value); err == nil && secs >= 0 {
+		return time.Duration(secs) * time.Second, true
+	}
+	if t, err := http.ParseTime(value); err == nil {
+		return max(t.Sub(now), 0), true
+	}
+	return 0, false
+}
+
+// sleepContext waits for d, returning early with the context's error if it
+// is cancelled
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,185 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// okReply is a chat completion answering "hello"
+const okReply = `{"choices": [{"message": {"role": "assistant", "content": "hello"}, "finish_reason": "stop"}]}`
+
+// testOpenRouter returns an OpenRouter client that sends to url
+func testOpenRouter(url string, attempts int) *OpenRouterClient {
+	return &OpenRouterClient{
+		url:         url,
+		model:       "test/model",
+		httpClient:  &http.Client{Timeout: 10 * time.Second},
+		maxAttempts: attempts,
+	}
+}
+
+// failingServer answers the first failures requests with status and the
+// Retry-After header, if any, and the rest with okReply. It counts requests.
+func failingServer(t *testing.T, failures, status int, retryAfter string) (*httptest.Server, *atomic.Int32) {
+	t.Helper()
+	var requests atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if int(requests.Add(1)) <= failures {
+			if retryAfter != "" {
+				w.Header().Set("Retry-After", retryAfter)
+			}
+			w.WriteHeader(status)
+			fmt.Fprint(w, `{"error": {"message": "try again"}}`)
+			return
+		}
+		fmt.Fprint(w, okReply)
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &requests
+}
+
+func TestRetryWithRetryAfter(t *testing.T) {
+	past := time.Now().Add(-time.Minute).UTC().Format(http.TimeFormat)
+	for _, tc := range []struct {
+		name       string
+		status     int
+		retryAfter string
+	}{
+		{"429 in seconds", http.StatusTooManyRequests, "0"},
+		{"503 as a date", http.StatusServiceUnavailable, past},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			srv, requests := failingServer(t, 2, tc.status, tc.retryAfter)
+
+			start := time.Now()
+			resp, err := testOpenRouter(srv.URL, 3).Chat(context.Background(), "hi")
+			if err != nil {
+				t.Fatalf("Chat: %v", err)
+			}
+			if resp.Text != "hello" {
+				t.Errorf("Expected the reply after the retries, got %q", resp.Text)
+			}
+			if n := requests.Load(); n != 3 {
+				t.Errorf("Expected 3 requests, got %d", n)
+			}
+			// Retry-After says to go now, so there's no backoff
+			if elapsed := time.Since(start); elapsed >= retryBaseDelay {
+				t.Errorf("Expected Retry-After to replace the backoff, took %s", elapsed)
+			}
+		})
+	}
+}
+
+func TestRetryBackoffWithoutRetryAfter(t *testing.T) {
+	srv, requests := failingServer(t, 1, http.StatusInternalServerError, "")
+
+	start := time.Now()
+	resp, err := testOpenRouter(srv.URL, 3).Chat(context.Background(), "hi")
+	if err != nil {
+		t.Fatalf("Chat: %v", err)
+	}
+	if resp.Text != "hello" || requests.Load() != 2 {
+		t.Errorf("Expected the reply on the second request, got %q after %d", resp.Text, requests.Load())
+	}
+	if elapsed := time.Since(start); elapsed < retryBaseDelay {
+		t.Errorf("Expected a backoff of at least %s, took %s", retryBaseDelay, elapsed)
+	}
+}
+
+func TestRetriesRunOut(t *testing.T) {
+	srv, requests := failingServer(t, 10, http.StatusTooManyRequests, "0")
+
+	_, err := testOpenRouter(srv.URL, 3).Chat(context.Background(), "hi")
+	if err == nil || !strings.Contains(err.Error(), "status 429") || !strings.Contains(err.Error(), "try again") {
+		t.Errorf("Expected the last 429 and its body, got %v", err)
+	}
+	if n := requests.Load(); n != 3 {
+		t.Errorf("Expected 3 attempts, got %d", n)
+	}
+}
+
+func TestNoRetryForClientErrors(t *testing.T) {
+	srv, requests := failingServer(t, 10, http.StatusBadRequest, "0")
+
+	_, err := testOpenRouter(srv.URL, 3).Chat(context.Background(), "hi")
+	if err == nil || !strings.Contains(err.Error(), "status 400") {
+		t.Errorf("Expected the 400, got %v", err)
+	}
+	if n := requests.Load(); n != 1 {
+		t.Errorf("Expected a bad request to be sent once, got %d", n)
+	}
+}
+
+func TestRetryWaitCancelled(t *testing.T) {
+	srv, requests := failingServer(t, 10, http.StatusTooManyRequests, "30")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	_, err := testOpenRouter(srv.URL, 3).Chat(ctx, "hi")
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected the wait to end with the context, got %v", err)
+	}
+	if n := requests.Load(); n != 1 {
+		t.Errorf("Expected no request after the cancelled wait, got %d", n)
+	}
+}
+
+func TestRetryDelay(t *testing.T) {
+	now := time.Date(2030, 1, 15, 12, 0, 0, 0, time.UTC)
+
+	if d := retryDelay(1, "7", now); d != 7*time.Second {
+		t.Errorf("Expected Retry-After of 7s to win, got %s", d)
+	}
+	if d := retryDelay(1, "3600", now); d != retryAfterMax {
+		t.Errorf("Expected a long Retry-After to be capped at %s, got %s", retryAfterMax, d)
+	}
+
+	// Without one the delay doubles from retryBaseDelay up to retryMaxDelay,
+	// plus up to half again as jitter
+	for _, tc := range []struct {
+		attempt int
+		base    time.Duration
+	}{
+		{1, retryBaseDelay},
+		{2, 2 * retryBaseDelay},
+		{3, 4 * retryBaseDelay},
+		{20, retryMaxDelay},
+	} {
+		for _, header := range []string{"", "soon"} {
+			d := retryDelay(tc.attempt, header, now)
+			if d < tc.base || d > tc.base+tc.base/2 {
+				t.Errorf("Attempt %d with Retry-After %q: expected %s to %s, got %s", tc.attempt, header, tc.base, tc.base+tc.base/2, d)
+			}
+		}
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2030, 1, 15, 12, 0, 0, 0, time.UTC)
+
+	for _, tc := range []struct {
+		value string
+		want  time.Duration
+		ok    bool
+	}{
+		{"120", 2 * time.Minute, true},
+		{"0", 0, true},
+		{now.Add(90 * time.Second).Format(http.TimeFormat), 90 * time.Second, true},
+		{now.Add(-time.Hour).Format(http.TimeFormat), 0, true},
+		{"", 0, false},
+		{"-5", 0, false},
+		{"soon", 0, false},
+	} {
+		got, ok := parseRetryAfter(tc.value, now)
+		if got != tc.want || ok != tc.ok {
+			t.Errorf("parseRetryAfter(%q): expected %s, %v, got %s, %v", tc.value, tc.want, tc.ok, got, ok)
+		}
+	}
+}
@@ -0,0 +1,21 @@
+package llm
+
+// InputSchema returns the tool's arguments as a standalone JSON Schema
+// object: the contract the providers send, for callers outside chat
+func (t *Tool) InputSchema() map[string]any {
+	props := make(map[string]any)
+	schema := map[string]any{
+		"type":       "object",
+		"properties": props,
+	}
+	if t.Parameters == nil {
+		return schema
+	}
+	for name, prop := range t.Parameters.Properties {
+		props[name] = prop.schema()
+	}
+	if len(t.Parameters.Required) > 0 {
+		schema["required"] = t.Parameters.Required
+	}
+	return schema
+}
@@ -0,0 +1,92 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// ResponseSchema asks a provider for a reply that is JSON matching a
+// schema: OpenRouter and OpenAI-compatible APIs get it as response_format,
+// Gemini as responseSchema, and Ollama as format. Keep schemas to the part
+// of JSON Schema they all read: type, properties, required, items, enum,
+// and description.
+type ResponseSchema struct {
+	Name   string         // names the schema for the provider, e.g. "estimates"
+	Schema map[string]any // a JSON Schema object
+}
+
+// structuredConfig is the default config with the reply held to schema
+func structuredConfig(schema *ResponseSchema) *Config {
+	config := DefaultConfig()
+	config.Schema = schema
+	return config
+}
+
+// decodeStructured decodes a structured reply into out. Models that don't
+// support schemas natively sometimes wrap the JSON in a code fence, so one
+// is removed first.
+func decodeStructured(resp *Response, out any) error {
+	text := strings.TrimSpace(resp.Text)
+	if fenced, ok := strings.CutPrefix(text, "```"); ok {
+		fenced = strings.TrimPrefix(fenced, "json")
+		text = strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(fenced), "```"))
+	}
+	if err := json.Unmarshal([]byte(text), out); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidStructured, err)
+	}
+	return nil
+}
+
+// ChatStructured sends prompt through response_format and decodes the reply
+func (c *OpenRouterClient) ChatStructured(ctx context.Context, prompt string, schema *ResponseSchema, out any) (*Response, error) {
+	config := structuredConfig(schema)
+	config.Model = c.model
+	resp, err := c.ChatWithConfig(ctx, prompt, config)
+	if err != nil {
+		return nil, err
+	}
+	return resp, decodeStructured(resp, out)
+}
+
+// ChatStructured sends prompt with a responseSchema and decodes the reply
+func (c *GeminiClient) ChatStructured(ctx context.Context, prompt string, schema *ResponseSchema, out any) (*Response, error) {
+	resp, err := c.ChatWithConfig(ctx, prompt, structuredConfig(schema))
+	if err != nil {
+		return nil, err
+	}
+	return resp, decodeStructured(resp, out)
+}
+
+// ChatStructured sends prompt with the schema as its format and decodes the
+// reply
+func (c *OllamaClient) ChatStructured(ctx context.Context, prompt string, schema *ResponseSchema, out any) (*Response, error) {
+	resp, err := c.ChatWithConfig(ctx, prompt, structuredConfig(schema))
+	if err != nil {
+		return nil, err
+	}
+	return resp, decodeStructured(resp, out)
+}
+
+// ChatStructured tries each provider in turn until one answers, then
+// decodes the reply
+func (f *FallbackClient) ChatStructured(ctx context.Context, prompt string, schema *ResponseSchema, out any) (*Response, error) {
+	resp, err := f.ChatWithConfig(ctx, prompt, structuredConfig(schema))
+	if err != nil {
+		return nil, err
+	}
+	return resp, decodeStructured(resp, out)
+}
+
+// openRouterResponseFormat is the response_format for a schema
+func openRouterResponseFormat(schema *ResponseSchema) map[string]any {
+	name := schema.Name
+	if name == "" {
+		name = "response"
+	}
+	return map[string]any{
+		"type":        "json_schema",
+		"json_schema": map[string]any{"name": name, "schema": schema.Schema},
+	}
+}
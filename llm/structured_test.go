@@ -0,0 +1,215 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// estimatesSchema is a structured reply like /estimate's
+var estimatesSchema = &ResponseSchema{
+	Name: "estimates",
+	Schema: map[string]any{
+		"type": "object",
+		"properties": map[string]any{
+			"estimates": map[string]any{
+				"type": "array",
+				"items": map[string]any{
+					"type": "object",
+					"properties": map[string]any{
+						"task_id": map[string]any{"type": "string"},
+						"minutes": map[string]any{"type": "integer"},
+					},
+					"required": []any{"task_id", "minutes"},
+				},
+			},
+		},
+		"required": []any{"estimates"},
+	},
+}
+
+type estimates struct {
+	Estimates []struct {
+		TaskID  string `json:"task_id"`
+		Minutes int    `json:"minutes"`
+	} `json:"estimates"`
+}
+
+// redirectTransport sends every request to a test server, for providers
+// whose URL is fixed
+type redirectTransport struct {
+	target *url.URL
+}
+
+func (t redirectTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	req = req.Clone(req.Context())
+	req.URL.Scheme = t.target.Scheme
+	req.URL.Host = t.target.Host
+	return http.DefaultTransport.RoundTrip(req)
+}
+
+// structuredProvider is one provider's side of a structured chat: its
+// client, how it wraps the reply text, and where its request carries the
+// schema
+type structuredProvider struct {
+	name   string
+	client func(srvURL string) Client
+	reply  func(text string) any
+	schema func(req map[string]any) any
+}
+
+var structuredProviders = []structuredProvider{
+	{
+		name: "openrouter",
+		client: func(srvURL string) Client {
+			return testOpenRouter(srvURL, 1)
+		},
+		reply: func(text string) any {
+			return map[string]any{"choices": []any{map[string]any{
+				"message":       map[string]any{"role": "assistant", "content": text},
+				"finish_reason": "stop",
+			}}}
+		},
+		schema: func(req map[string]any) any {
+			format, _ := req["response_format"].(map[string]any)
+			if format["type"] != "json_schema" {
+				return nil
+			}
+			spec, _ := format["json_schema"].(map[string]any)
+			if spec["name"] != "estimates" {
+				return nil
+			}
+			return spec["schema"]
+		},
+	},
+	{
+		name: "gemini",
+		client: func(srvURL string) Client {
+			target, _ := url.Parse(srvURL)
+			return &GeminiClient{
+				apiKey:     "test-key",
+				model:      "gemini-test",
+				httpClient: &http.Client{Timeout: 10 * time.Second, Transport: redirectTransport{target: target}},
+			}
+		},
+		reply: func(text string) any {
+			return map[string]any{"candidates": []any{map[string]any{
+				"content":      map[string]any{"role": "model", "parts": []any{map[string]any{"text": text}}},
+				"finishReason": "STOP",
+			}}}
+		},
+		schema: func(req map[string]any) any {
+			config, _ := req["generationConfig"].(map[string]any)
+			if config["responseMimeType"] != "application/json" {
+				return nil
+			}
+			return config["responseSchema"]
+		},
+	},
+	{
+		name: "ollama",
+		client: func(srvURL string) Client {
+			return &OllamaClient{
+				host:        srvURL,
+				model:       "llama3.1",
+				httpClient:  &http.Client{Timeout: 10 * time.Second},
+				maxAttempts: 1,
+			}
+		},
+		reply: func(text string) any {
+			return map[string]any{
+				"message":     map[string]any{"role": "assistant", "content": text},
+				"done_reason": "stop",
+			}
+		},
+		schema: func(req map[string]any) any {
+			return req["format"]
+		},
+	},
+}
+
+// structuredServer answers every request with text as the model's reply,
+// wrapped the provider's way, and records the last request
+func structuredServer(t *testing.T, p structuredProvider, text string, request *map[string]any) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := json.NewDecoder(r.Body).Decode(request); err != nil {
+			t.Errorf("%s: request isn't JSON: %v", p.name, err)
+		}
+		json.NewEncoder(w).Encode(p.reply(text))
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestChatStructured(t *testing.T) {
+	// The schema goes out as JSON, so compare it the way it reads back
+	var wantSchema any
+	data, _ := json.Marshal(estimatesSchema.Schema)
+	json.Unmarshal(data, &wantSchema)
+
+	for _, p := range structuredProviders {
+		for _, tc := range []struct {
+			name string
+			text string
+		}{
+			{"plain", `{"estimates": [{"task_id": "1a2b3c4d", "minutes": 45}, {"task_id": "5e6f7a8b", "minutes": 120}]}`},
+			{"fenced", "```json\n{\"estimates\": [{\"task_id\": \"1a2b3c4d\", \"minutes\": 45}, {\"task_id\": \"5e6f7a8b\", \"minutes\": 120}]}\n```"},
+		} {
+			t.Run(p.name+"/"+tc.name, func(t *testing.T) {
+				var request map[string]any
+				srv := structuredServer(t, p, tc.text, &request)
+
+				var out estimates
+				resp, err := p.client(srv.URL).ChatStructured(context.Background(), "estimate these", estimatesSchema, &out)
+				if err != nil {
+					t.Fatalf("ChatStructured: %v", err)
+				}
+				if resp.Text != tc.text {
+					t.Errorf("Expected the raw reply in the response, got %q", resp.Text)
+				}
+				if len(out.Estimates) != 2 || out.Estimates[0].TaskID != "1a2b3c4d" || out.Estimates[0].Minutes != 45 || out.Estimates[1].Minutes != 120 {
+					t.Errorf("Expected the two estimates decoded, got %+v", out)
+				}
+				if got := p.schema(request); !reflect.DeepEqual(got, wantSchema) {
+					t.Errorf("Expected the schema in the request, got %v", got)
+				}
+			})
+		}
+	}
+}
+
+func TestChatStructuredInvalid(t *testing.T) {
+	for _, p := range structuredProviders {
+		for _, tc := range []struct {
+			name string
+			text string
+		}{
+			{"malformed", `{"estimates": [{"task_id": "1a2b3c4d", "minutes": 45}`},
+			{"prose", "Write report: about 45 minutes."},
+			{"wrong type", `{"estimates": [{"task_id": "1a2b3c4d", "minutes": "45m"}]}`},
+			{"wrong shape", `[{"task_id": "1a2b3c4d", "minutes": 45}]`},
+		} {
+			t.Run(p.name+"/"+tc.name, func(t *testing.T) {
+				var request map[string]any
+				srv := structuredServer(t, p, tc.text, &request)
+
+				var out estimates
+				resp, err := p.client(srv.URL).ChatStructured(context.Background(), "estimate these", estimatesSchema, &out)
+				if !errors.Is(err, ErrInvalidStructured) {
+					t.Errorf("Expected ErrInvalidStructured, got %v", err)
+				}
+				// The reply still comes back, so its usage can be counted
+				if resp == nil || resp.Text != tc.text {
+					t.Errorf("Expected the raw reply alongside the error, got %+v", resp)
+				}
+			})
+		}
+	}
+}
@@ -0,0 +1,30 @@
+package llm
+
+import (
+	"context"
+	"time"
+)
+
+// runTools runs the tool calls of one model turn through executor, one at a
+// time in the order the model made them, and returns their results in that
+// order. They are not run concurrently, even when the model makes several
+// independent calls: every command, read-only ones included, produces its
+// result by swapping the process-wide os.Stdout (captureOutput in commands),
+// and may ask the user to confirm, so two calls can't overlap. Overlapping only
+// the calls that leave the store alone would not help, since those print too.
+// None start once ctx is cancelled: the results then cover the calls that ran,
+// which are the first ones, and the error is ctx's.
+func runTools(ctx context.Context, calls []ToolCall, executor ToolExecutor) ([]string, error) {
+	results := make([]string, 0, len(calls))
+	for _, call := range calls {
+		// Stop before the next command once the user has interrupted
+		if ctx.Err() != nil {
+			return results, ctx.Err()
+		}
+		start := time.Now()
+		result := executor(call.Name, call.Arguments)
+		logTool(call, result, time.Since(start))
+		results = append(results, result)
+	}
+	return results, nil
+}
@@ -0,0 +1,62 @@
+package llm
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+func TestRunTools(t *testing.T) {
+	calls := []ToolCall{
+		{Name: "due", Arguments: map[string]any{"task_id": "a"}},
+		{Name: "duration", Arguments: map[string]any{"task_id": "a"}},
+		{Name: "priority", Arguments: map[string]any{"task_id": "b"}},
+	}
+
+	var ran []string
+	running := false
+	executor := func(name string, args map[string]any) string {
+		if running {
+			t.Errorf("%s started while another call was running", name)
+		}
+		running = true
+		defer func() { running = false }()
+		ran = append(ran, name)
+		return fmt.Sprintf("%s %s", name, args["task_id"])
+	}
+
+	results, err := runTools(context.Background(), calls, executor)
+	if err != nil {
+		t.Fatalf("runTools: %v", err)
+	}
+	if want := []string{"due a", "duration a", "priority b"}; !reflect.DeepEqual(results, want) {
+		t.Errorf("Expected results %q, got %q", want, results)
+	}
+	if want := []string{"due", "duration", "priority"}; !reflect.DeepEqual(ran, want) {
+		t.Errorf("Expected calls in order %q, got %q", want, ran)
+	}
+}
+
+func TestRunToolsCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	calls := []ToolCall{{Name: "first"}, {Name: "second"}, {Name: "third"}}
+
+	var ran []string
+	executor := func(name string, args map[string]any) string {
+		ran = append(ran, name)
+		if name == "second" {
+			cancel()
+		}
+		return name
+	}
+
+	// The call that's running when the user interrupts finishes; no more start
+	results, err := runTools(ctx, calls, executor)
+	if err != context.Canceled {
+		t.Errorf("Expected context.Canceled, got %v", err)
+	}
+	if want := []string{"first", "second"}; !reflect.DeepEqual(results, want) || !reflect.DeepEqual(ran, want) {
+		t.Errorf("Expected only %q to run, got results %q from %q", want, results, ran)
+	}
+}
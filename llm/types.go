@@ -6,6 +6,7 @@ type Response struct {
 	TokensUsed   int64
 	InputTokens  int64
 	OutputTokens int64
+	CachedTokens int64   // input tokens read from the provider's prompt cache
 	Cost         float64 // Cost in USD
 }
 
@@ -14,6 +15,7 @@ type Config struct {
 	MaxTokens   int32
 	Temperature float32
 	System      string
+	Schema      *ResponseSchema // if set, the reply is JSON matching it
 }
 
 func DefaultConfig() *Config {
@@ -31,6 +33,11 @@ type Message struct {
 	Content    string
 	ToolCalls  []ToolCall
 	ToolCallID string // For tool response messages
+
+	// CachePrefix is how many bytes at the start of Content are the same in
+	// every request, so providers with prompt caching can mark them
+	// cacheable. It's set on the system prompt each turn, so it isn't saved.
+	CachePrefix int `json:"-"`
 }
 
 // ToolCall represents a function call made by the model
@@ -56,6 +63,19 @@ type ToolParameters struct {
 
 // ToolProperty defines a single parameter property
 type ToolProperty struct {
-	Type        string
+	Type        string // JSON Schema type: "string", "integer", or "boolean"
 	Description string
+	Enum        []string // allowed values, if limited
+}
+
+// schema returns the property as a JSON Schema object
+func (p *ToolProperty) schema() map[string]any {
+	s := map[string]any{
+		"type":        p.Type,
+		"description": p.Description,
+	}
+	if len(p.Enum) > 0 {
+		s["enum"] = p.Enum
+	}
+	return s
 }
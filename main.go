@@ -5,14 +5,21 @@ import (
 	"fmt"
 	"io"
 	"os"
+	"os/signal"
 	"path/filepath"
+	"strconv"
 	"strings"
+	"syscall"
+	"time"
 
 	"github.com/chzyer/readline"
 	"github.com/joho/godotenv"
 
 	"twooms/commands"
+	"twooms/config"
+	"twooms/i18n"
 	"twooms/llm"
+	"twooms/repl"
 	"twooms/storage"
 )
 
@@ -30,95 +37,357 @@ func main() {
 	// Also try loading from ~/.twooms.env
 	godotenv.Load(filepath.Join(homeDir, ".twooms.env"))
 
-	dbPath := filepath.Join(homeDir, ".twooms.json")
-	store, err := storage.NewJSONStore(dbPath)
+	// Load persistent preferences (model choice, active workspace, etc.)
+	cfg, err := config.Load(filepath.Join(homeDir, ".twooms.config.json"))
 	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+	}
+	commands.SetConfig(cfg)
+
+	// Open the active workspace's store, saved chats, and backups;
+	// /workspace switch opens another the same way
+	commands.SetWorkspaceOpener(func(name string) (*commands.Workspace, error) {
+		return openWorkspace(homeDir, name)
+	})
+	if err := commands.OpenWorkspace(cfg.Workspace); err != nil {
 		fmt.Fprintf(os.Stderr, "Error initializing storage: %v\n", err)
 		os.Exit(1)
 	}
-	defer store.Close()
+	// Switching workspaces closes the old store, so close whichever is open,
+	// pushing it first when sync is on
+	defer func() {
+		commands.PushSync()
+		commands.GetStore().Close()
+	}()
 
-	// Set store for commands to use
-	commands.SetStore(store)
+	// Command output language; /locale changes it
+	if cfg.Locale != "" {
+		if err := i18n.SetLocale(cfg.Locale); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+		}
+	}
 
 	// Initialize LLM client (optional)
 	ctx := context.Background()
-	llmClient, err := llm.NewOpenRouterClient(ctx)
+	llmClient, err := llm.NewFallbackClient(ctx)
 	if err != nil {
-		if err == llm.ErrMissingAPIKey {
-			fmt.Fprintf(os.Stderr, "Warning: %v (LLM features disabled)\n", err)
-		} else {
+		// Without a provider, /chat runs the offline assistant; the banner
+		// says so, and single-shot output stays uncluttered
+		if err != llm.ErrNoProviders {
 			fmt.Fprintf(os.Stderr, "Error initializing LLM client: %v\n", err)
 			os.Exit(1)
 		}
 	} else {
+		if cfg.Model != "" {
+			llmClient.SetModel(cfg.Model)
+		}
 		commands.SetLLMClient(llmClient)
 		defer llmClient.Close()
 	}
 
+	// /debug file logs raw LLM traffic here unless given a path
+	commands.SetDebugLogPath(filepath.Join(homeDir, ".twooms.llm.jsonl"))
+
+	// LLM spending by month, checked against TWOOMS_MONTHLY_BUDGET
+	if err := commands.LoadUsageLedger(filepath.Join(homeDir, ".twooms.usage.json")); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+	}
+
+	// ~/.twooms/system_prompt.md and ~/.twooms/personas/ customize chat
+	commands.SetPromptDir(filepath.Join(homeDir, ".twooms"))
+	// Rendered chat answers wrap at the terminal's width
+	commands.SetScreenWidth(readline.GetScreenWidth)
+	// Output is colored only on a terminal (and without NO_COLOR)
+	if info, err := os.Stdout.Stat(); err == nil {
+		commands.SetColorTerminal(info.Mode()&os.ModeCharDevice != 0)
+	}
+
+	// Load automation rules and fire any due-date rules that came due
+	if err := commands.LoadRules(filepath.Join(homeDir, ".twooms.rules.json"), filepath.Join(homeDir, ".twooms.rules.state.json")); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: %v (rules disabled)\n", err)
+	}
+
+	for _, err := range commands.CheckHooks() {
+		fmt.Fprintf(os.Stderr, "Warning: %v (hook disabled)\n", err)
+	}
+
+	// Load user scripts; each registers its own commands and event handlers
+	for _, err := range commands.LoadScripts(filepath.Join(homeDir, ".twooms.scripts")) {
+		fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+	}
+
+	// Opt-in priority escalation, evaluated once per startup
+	escalations, err := commands.GetStore().Escalate(storage.EscalationPolicyFromEnv(), time.Now())
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error escalating tasks: %v\n", err)
+	}
+	if len(escalations) > 0 && len(os.Args) <= 1 {
+		fmt.Printf("Escalated %d tasks:\n", len(escalations))
+		for _, e := range escalations {
+			fmt.Printf("  %s -> %s (%s)\n", e.TaskName, e.To, e.Reason)
+		}
+	}
+
+	// Purge what has been in the trash longer than trash_days
+	if err := commands.PurgeTrash(time.Now()); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+	}
+
+	commands.CheckDueRules()
+
+	// Single-shot mode: run the command from argv and exit
+	if len(os.Args) > 1 {
+		args := os.Args[1:]
+		// `twooms --channel slack ...` runs with that channel's permissions
+		if args[0] == "--channel" {
+			if len(args) < 3 {
+				fmt.Fprintln(os.Stderr, "Usage: twooms --channel <name> <command> [args...]")
+				os.Exit(2)
+			}
+			if err := commands.SetChannel(args[1]); err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(2)
+			}
+			args = args[2:]
+		}
+		// `twooms --notify` runs reminders without the REPL
+		if args[0] == "--notify" {
+			args = append([]string{"notify", "on"}, args[1:]...)
+		}
+		// `twooms tui` needs the terminal, so its output isn't captured
+		if args[0] == "tui" || args[0] == "board" {
+			_, err := commands.Execute("/board")
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(2)
+			}
+			commands.PushSync()
+			commands.GetStore().Close()
+			os.Exit(0)
+		}
+		code := runOnce(args)
+		// `twooms serve` and `twooms --notify` keep running until interrupted
+		if code == 0 && (commands.Serving() || commands.Notifying()) {
+			startBackupSchedule()
+			sig := make(chan os.Signal, 1)
+			signal.Notify(sig, os.Interrupt, syscall.SIGTERM)
+			<-sig
+		}
+		commands.PushSync()
+		commands.GetStore().Close()
+		os.Exit(code)
+	}
+
+	// Due-date suggestions are accepted with Enter, so they only make sense in the REPL
+	if enabled, _ := strconv.ParseBool(os.Getenv("TWOOMS_SUGGEST_DUE")); enabled {
+		commands.EnableDueSuggestions()
+	}
+
+	// Key shortcuts like Ctrl+T; bad entries in the config are skipped
+	keys, keyErrs := newKeyBindings(cfg.Keys)
+	for _, err := range keyErrs {
+		fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+	}
+
 	// Start REPL with readline support
+	comp := &completer{}
 	rl, err := readline.NewEx(&readline.Config{
-		Prompt:          "> ",
-		HistoryLimit:    100,
-		InterruptPrompt: "^C",
-		EOFPrompt:       "exit",
+		Prompt:              "> ",
+		HistoryLimit:        100,
+		InterruptPrompt:     "^C",
+		EOFPrompt:           "exit",
+		AutoComplete:        comp,
+		Listener:            keys,
+		FuncFilterInputRune: keys.filter,
+		Stdin:               readline.NewCancelableStdin(&altEnterReader{r: os.Stdin}),
 	})
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "Error initializing readline: %v\n", err)
 		os.Exit(1)
 	}
 	defer rl.Close()
+	comp.out = rl.Stdout()
+	keys.out = rl.Stdout()
+
+	startBackupSchedule()
 
 	fmt.Println("Welcome to Twooms! Type /help for available commands.")
+	fmt.Println(commands.ChatMode())
+	// A data file that has grown large gets a warning and a one-key fix
+	commands.WarnStoreSize()
+
+	session := &repl.Session{
+		ReadLine: func(prompt string) (string, error) {
+			rl.SetPrompt(prompt)
+			line, err := rl.Readline()
+			if err == readline.ErrInterrupt {
+				err = repl.ErrInterrupt
+			}
+			return line, err
+		},
+		BeforePrompt: func() {
+			if keys.showToday() {
+				fmt.Println(todayView())
+			}
+		},
+		ForceChat: keys.takeForceChat,
+	}
+
+	// Catch up when the session is left open past midnight
+	if idle, err := commands.NewDayIdle(); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: %v (new day summaries disabled)\n", err)
+	} else if idle > 0 {
+		newDay := make(chan struct{}, 1)
+		since := time.Now()
+		session.AfterRead = func() {
+			select {
+			case <-newDay:
+				now := time.Now()
+				fmt.Println(commands.NewDay(since, now))
+				since = now
+			default:
+			}
+		}
+		go watchNewDay(session, idle, newDay)
+	}
+
+	if err := session.Run(); err != nil {
+		fmt.Printf("Error: %v\n", err)
+	}
+}
 
-	for {
-		line, err := rl.Readline()
-		if err == readline.ErrInterrupt {
+// watchNewDay signals newDay once the date has changed and the session has
+// been idle for at least idle. It checks every minute, so a session left
+// open overnight is caught up before it's used. It only reads the clock and
+// the session; the summary and the rules it fires run on the REPL goroutine
+// (Session.AfterRead), never beside a command.
+func watchNewDay(session *repl.Session, idle time.Duration, newDay chan<- struct{}) {
+	last := time.Now()
+	for now := range time.Tick(time.Minute) {
+		if now.Format("2006-01-02") == last.Format("2006-01-02") || now.Sub(session.LastInput()) < idle {
 			continue
 		}
-		if err == io.EOF {
-			break
+		// One pending signal covers every day since the last summary
+		select {
+		case newDay <- struct{}{}:
+		default:
 		}
+		last = now
+	}
+}
+
+// startBackupSchedule starts scheduled backups for a long-running process, so
+// one-off single-shot commands never wait on an upload
+func startBackupSchedule() {
+	if err := commands.StartBackupSchedule(); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: %v (scheduled backups disabled)\n", err)
+	}
+}
+
+// openWorkspace opens a workspace's store and finds its other files. The
+// default workspace ("") keeps the original files in the home directory;
+// named ones live in ~/.twooms/, e.g. ~/.twooms/work.json.
+func openWorkspace(homeDir, name string) (*commands.Workspace, error) {
+	if name == "" {
+		store, err := openStore(filepath.Join(homeDir, ".twooms"))
 		if err != nil {
-			fmt.Printf("Error: %v\n", err)
-			break
+			return nil, err
 		}
+		return &commands.Workspace{
+			Store:     store,
+			ChatPath:  filepath.Join(homeDir, ".twooms_chat.json"),
+			BackupDir: filepath.Join(homeDir, ".twooms.backups"),
+		}, nil
+	}
 
-		input := strings.TrimSpace(line)
-		if input == "" {
-			continue
-		}
+	dir := filepath.Join(homeDir, ".twooms")
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, err
+	}
+	base := filepath.Join(dir, name)
+	store, err := openStore(base)
+	if err != nil {
+		return nil, err
+	}
+	return &commands.Workspace{
+		Store:     store,
+		ChatPath:  base + ".chat.json",
+		BackupDir: base + ".backups",
+	}, nil
+}
 
-		// Default to /chat if no slash command specified
-		if !strings.HasPrefix(input, "/") {
-			input = "/chat " + input
-		}
+// openStore opens the JSON store at base+".json", or the bbolt store at
+// base+".db" when TWOOMS_STORAGE=bolt. The first time the bbolt store is
+// used it imports the JSON file.
+func openStore(base string) (storage.Store, error) {
+	jsonPath := base + ".json"
+	if os.Getenv("TWOOMS_STORAGE") != "bolt" {
+		return storage.NewJSONStore(jsonPath)
+	}
 
-		// Check if this is a direct command (not /chat) that should be recorded in chat history
-		isDirectCommand := !strings.HasPrefix(strings.ToLower(input), "/chat")
-
-		var quit bool
-		var cmdErr error
-		if isDirectCommand {
-			// Execute with output capture for direct commands
-			var output string
-			quit, output, cmdErr = commands.ExecuteWithOutput(input)
-			if cmdErr == nil && output != "" {
-				// Print the output (since it was captured)
-				fmt.Println(output)
-				// Add to chat history for LLM context
-				commands.AddCommandContext(input, output)
-			}
-		} else {
-			// Execute normally for /chat commands
-			quit, cmdErr = commands.Execute(input)
-		}
+	store, err := storage.NewBoltStore(base + ".db")
+	if err != nil {
+		return nil, err
+	}
+	migrated, err := store.MigrateFromJSON(jsonPath)
+	if err != nil {
+		store.Close()
+		return nil, err
+	}
+	if migrated {
+		fmt.Fprintf(os.Stderr, "Imported %s into the bbolt store\n", jsonPath)
+	}
+	return store, nil
+}
 
-		if cmdErr != nil {
-			fmt.Printf("Error: %v\n", cmdErr)
-		}
-		if quit {
-			break
+// completer adapts commands.Complete to readline. Readline can only list the
+// candidates themselves, so when several match, their descriptions (task and
+// project names) are printed above the prompt.
+type completer struct {
+	out io.Writer
+}
+
+func (c *completer) Do(line []rune, pos int) ([][]rune, int) {
+	input := string(line[:pos])
+	word := []rune(input[strings.LastIndex(input, " ")+1:])
+
+	completions := commands.Complete(input)
+	candidates := make([][]rune, 0, len(completions))
+	for _, comp := range completions {
+		candidates = append(candidates, append([]rune(comp.Text)[len(word):], ' '))
+	}
+
+	if len(completions) > 1 && completions[0].Description != "" && c.out != nil {
+		for _, comp := range completions {
+			fmt.Fprintf(c.out, "  %-10s %s\n", comp.Text, comp.Description)
 		}
 	}
+	return candidates, len(word)
+}
+
+// runOnce executes a single command given as program arguments (e.g.
+// `twooms task work "Pay rent"`) and returns the process exit code:
+// 0 on success, 1 if the command reported an error, 2 for an unknown command.
+func runOnce(args []string) int {
+	name := args[0]
+	if !strings.HasPrefix(name, "/") {
+		name = "/" + name
+	}
+	// The shell already split the arguments; quote them so they stay that way
+	input := name + " " + commands.JoinArgs(args[1:])
+
+	_, output, err := commands.ExecuteWithOutput(input)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 2
+	}
+
+	if commands.OutputIndicatesError(output) {
+		fmt.Fprintln(os.Stderr, output)
+		return 1
+	}
+	if output != "" {
+		fmt.Println(output)
+	}
+	return 0
 }
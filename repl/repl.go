@@ -0,0 +1,171 @@
+// Package repl runs Twooms' read-eval-print loop. Input comes from a line
+// reader rather than the terminal, so main.go can drive a session with
+// readline and tests can drive one from a script (see Script).
+package repl
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strings"
+	"sync"
+	"time"
+
+	"twooms/commands"
+)
+
+// ErrInterrupt is returned by a Session's ReadLine when the user pressed
+// Ctrl-C at the prompt. The line is discarded and the prompt shown again.
+var ErrInterrupt = errors.New("interrupt")
+
+// Session is one REPL session. ReadLine is required; the hooks are optional.
+type Session struct {
+	// ReadLine shows a prompt and returns the line typed. It returns
+	// ErrInterrupt to discard the line and io.EOF to end the session.
+	ReadLine func(prompt string) (string, error)
+
+	// Out receives the session's own output: command results, errors, and
+	// the typo prompt's outcome. Nil means os.Stdout. Chat replies and
+	// interactive commands always print to os.Stdout.
+	Out io.Writer
+
+	// BeforePrompt runs before each main prompt, e.g. to show today's tasks
+	BeforePrompt func()
+	// AfterRead runs after each line is read at the main prompt, before it
+	// runs, e.g. to catch up on a new day the session sat idle through
+	AfterRead func()
+
+	// ForceChat reports whether the line just read should go to /chat even
+	// if it starts with "/". It's called after every read, so it can clear
+	// its state.
+	ForceChat func() bool
+
+	mu        sync.Mutex
+	lastInput time.Time
+}
+
+// Run reads and executes lines until /quit or the end of input. Interactive
+// commands prompt through ReadLine too. It returns nil when the session
+// ends normally and the read error otherwise.
+func (s *Session) Run() error {
+	s.touch()
+
+	// Interactive commands share the session's input; answers are never chat messages
+	commands.SetLineReader(func(prompt string) (string, error) {
+		line, err := s.ReadLine(prompt)
+		s.touch()
+		s.forceChat()
+		return line, err
+	})
+	defer commands.SetLineReader(nil)
+
+	for {
+		if s.BeforePrompt != nil {
+			s.BeforePrompt()
+		}
+
+		// The prompt template can show live values like the focused project
+		line, err := s.ReadLine(commands.Prompt())
+		s.touch()
+		if s.AfterRead != nil {
+			s.AfterRead()
+		}
+		forceChat := s.forceChat()
+		if err == ErrInterrupt {
+			continue
+		}
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		input := strings.TrimSpace(line)
+		if input == "" {
+			// Enter on an empty line accepts a pending due-date suggestion
+			commands.AcceptSuggestion()
+			continue
+		}
+		commands.DismissSuggestion()
+
+		// Default to /chat if no slash command specified (or the chat key was used)
+		if forceChat || !strings.HasPrefix(input, "/") {
+			input = "/chat " + input
+		}
+
+		quit, cmdErr := s.Execute(input)
+
+		// For a likely typo, offer to run the intended command with the same arguments
+		var unknown *commands.UnknownCommandError
+		if errors.As(cmdErr, &unknown) && unknown.Likely != "" {
+			corrected := unknown.Likely + strings.TrimPrefix(input, strings.Fields(input)[0])
+			answer, err := s.ReadLine(fmt.Sprintf("Unknown command %s. Run %s? [y/N] ", unknown.Name, corrected))
+			s.touch()
+			s.forceChat()
+			if err == nil && strings.EqualFold(strings.TrimSpace(answer), "y") {
+				quit, cmdErr = s.Execute(corrected)
+			} else {
+				cmdErr = nil
+			}
+		}
+
+		if cmdErr != nil {
+			fmt.Fprintf(s.out(), "Error: %v\n", cmdErr)
+		}
+		if quit {
+			return nil
+		}
+	}
+}
+
+// Execute runs one line of input the way the prompt does, with Ctrl-C
+// cancelling its LLM requests (a slow /chat returns to the prompt) instead
+// of exiting. Direct commands (not /chat) have their output recorded in chat
+// history; interactive commands prompt as they go, so their output can't be
+// captured. It reports whether the command asked to quit.
+func (s *Session) Execute(input string) (bool, error) {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	commands.SetCommandContext(ctx)
+	defer commands.SetCommandContext(nil)
+
+	if strings.HasPrefix(strings.ToLower(input), "/chat") || commands.IsInteractive(input) {
+		return commands.Execute(input)
+	}
+
+	quit, output, err := commands.ExecuteWithOutput(input)
+	if err == nil && output != "" {
+		fmt.Fprintln(s.out(), output)
+		commands.AddCommandContext(input, output)
+	}
+	return quit, err
+}
+
+// LastInput returns when a line was last read, or when Run started. It's
+// safe to call from another goroutine, e.g. to act while the session is idle.
+func (s *Session) LastInput() time.Time {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.lastInput
+}
+
+func (s *Session) touch() {
+	s.mu.Lock()
+	s.lastInput = time.Now()
+	s.mu.Unlock()
+}
+
+func (s *Session) out() io.Writer {
+	if s.Out != nil {
+		return s.Out
+	}
+	return os.Stdout
+}
+
+func (s *Session) forceChat() bool {
+	return s.ForceChat != nil && s.ForceChat()
+}
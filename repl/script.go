@@ -0,0 +1,259 @@
+package repl
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strings"
+
+	"twooms/commands"
+	"twooms/llm"
+)
+
+// A Script is a REPL session written down for end-to-end tests. Each line of
+// a script is one step:
+//
+//	> /project Work          a line typed at a prompt, including the prompts
+//	                         of interactive commands
+//	< Created project {id}   output expected after the previous match; a
+//	                         prompt shows up with its answer, as on a terminal
+//	tool done {"task_id": "{task}"}
+//	                         a tool call the model makes on the next /chat
+//	sent Result: Created     text the model must be sent on the next /chat,
+//	                         such as the output of an earlier direct command
+//	# comment
+//
+// In "<" lines, {name} matches a word (a run of non-space characters) the
+// first time it appears and stands for that word in every later step.
+// "tool" and "sent" lines apply to the next /chat, so they go before the
+// input line that sends it. The scripted model answers every message with
+// "Done." after making its tool calls.
+type Script struct {
+	name  string
+	steps []step
+}
+
+type step struct {
+	line int
+	kind string // ">", "<", "tool", or "sent"
+	text string
+}
+
+// placeholderRegex matches {name} in a step
+var placeholderRegex = regexp.MustCompile(`\{(\w+)\}`)
+
+// LoadScript reads a script file
+func LoadScript(path string) (*Script, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return ParseScript(path, f)
+}
+
+// ParseScript reads a script; name is used in error messages
+func ParseScript(name string, r io.Reader) (*Script, error) {
+	sc := &Script{name: name}
+	scanner := bufio.NewScanner(r)
+	for n := 1; scanner.Scan(); n++ {
+		line := scanner.Text()
+		if strings.TrimSpace(line) == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		kind, text, _ := strings.Cut(line, " ")
+		switch kind {
+		case ">", "<", "sent":
+		case "tool":
+			if text == "" {
+				return nil, fmt.Errorf("%s:%d: usage: tool <name> [json arguments]", name, n)
+			}
+		default:
+			return nil, fmt.Errorf("%s:%d: unknown step %q (use >, <, tool, or sent)", name, n, kind)
+		}
+		sc.steps = append(sc.steps, step{line: n, kind: kind, text: text})
+	}
+	return sc, scanner.Err()
+}
+
+// Run plays the script against the current command store with a scripted
+// model, returning the first step that didn't go as written. Standard output
+// is redirected while it runs, so it can be matched.
+func (sc *Script) Run() error {
+	f, err := os.CreateTemp("", "twooms-script-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+	defer f.Close()
+
+	stdout := os.Stdout
+	os.Stdout = f
+	defer func() { os.Stdout = stdout }()
+
+	r := &scriptRun{script: sc, out: f, vars: make(map[string]string)}
+	client := commands.GetLLMClient()
+	commands.SetLLMClient(&scriptedModel{run: r})
+	defer commands.SetLLMClient(client)
+
+	session := &Session{ReadLine: r.readLine}
+	if err := session.Run(); err != nil && r.err == nil {
+		r.err = err
+	}
+	if r.err == nil {
+		// Expectations after the last input
+		r.advance()
+	}
+	if r.err == nil && (len(r.calls) > 0 || len(r.sent) > 0) {
+		r.err = fmt.Errorf("%s: tool or sent steps with no /chat after them", sc.name)
+	}
+	return r.err
+}
+
+// scriptRun is the state of a script being played
+type scriptRun struct {
+	script *Script
+	out    *os.File
+	next   int               // index of the next step
+	pos    int               // how much output earlier expectations consumed
+	vars   map[string]string // words captured by {name}
+	calls  []step            // tool calls for the next /chat
+	sent   []step            // text the next /chat must send
+	err    error
+}
+
+// readLine checks the steps up to the next input and returns it; the end of
+// the script, or a failed step, ends the session
+func (r *scriptRun) readLine(prompt string) (string, error) {
+	if !r.advance() || r.next == len(r.script.steps) {
+		return "", io.EOF
+	}
+	input := r.substitute(r.script.steps[r.next].text)
+	r.next++
+	// Echo the prompt and input like a terminal, so failures show the transcript
+	fmt.Fprintf(r.out, "%s%s\n", prompt, input)
+	return input, nil
+}
+
+// advance runs the steps before the next input, returning false once a step
+// fails
+func (r *scriptRun) advance() bool {
+	for r.err == nil && r.next < len(r.script.steps) {
+		s := r.script.steps[r.next]
+		switch s.kind {
+		case ">":
+			return true
+		case "<":
+			r.err = r.expect(s)
+		case "tool":
+			r.calls = append(r.calls, s)
+		case "sent":
+			r.sent = append(r.sent, s)
+		}
+		r.next++
+	}
+	return r.err == nil
+}
+
+// expect finds a "<" step's text in the output not yet matched
+func (r *scriptRun) expect(s step) error {
+	output, err := os.ReadFile(r.out.Name())
+	if err != nil {
+		return err
+	}
+	rest := string(output[r.pos:])
+
+	var pattern strings.Builder
+	var names []string
+	last := 0
+	for _, m := range placeholderRegex.FindAllStringSubmatchIndex(s.text, -1) {
+		pattern.WriteString(regexp.QuoteMeta(s.text[last:m[0]]))
+		name := s.text[m[2]:m[3]]
+		if value, ok := r.vars[name]; ok {
+			pattern.WriteString(regexp.QuoteMeta(value))
+		} else {
+			pattern.WriteString(`(\S+)`)
+			names = append(names, name)
+		}
+		last = m[1]
+	}
+	pattern.WriteString(regexp.QuoteMeta(s.text[last:]))
+
+	m := regexp.MustCompile(pattern.String()).FindStringSubmatchIndex(rest)
+	if m == nil {
+		return fmt.Errorf("%s:%d: expected %q in output:\n%s", r.script.name, s.line, s.text, rest)
+	}
+	for i, name := range names {
+		value := rest[m[2*i+2]:m[2*i+3]]
+		if prev, ok := r.vars[name]; ok && prev != value {
+			return fmt.Errorf("%s:%d: {%s} matched both %q and %q", r.script.name, s.line, name, prev, value)
+		}
+		r.vars[name] = value
+	}
+	r.pos += m[1]
+	return nil
+}
+
+// substitute replaces captured {name}s in a step
+func (r *scriptRun) substitute(text string) string {
+	return placeholderRegex.ReplaceAllStringFunc(text, func(match string) string {
+		if value, ok := r.vars[match[1:len(match)-1]]; ok {
+			return value
+		}
+		return match
+	})
+}
+
+// scriptedModel stands in for the LLM, making the script's tool calls
+type scriptedModel struct {
+	llm.Client
+	run *scriptRun
+}
+
+func (m *scriptedModel) SetDebug(enabled bool) {}
+
+func (m *scriptedModel) ChatWithTools(ctx context.Context, message string, history []*llm.Message, tools []*llm.Tool, executor llm.ToolExecutor) (*llm.Response, []*llm.Message, error) {
+	r := m.run
+	calls, sent := r.calls, r.sent
+	r.calls, r.sent = nil, nil
+
+	var request strings.Builder
+	for _, msg := range history {
+		request.WriteString(msg.Content + "\n")
+	}
+	request.WriteString(message)
+	for _, s := range sent {
+		if want := r.substitute(s.text); !strings.Contains(request.String(), want) {
+			r.fail(fmt.Errorf("%s:%d: chat request doesn't contain %q", r.script.name, s.line, want))
+		}
+	}
+
+	for _, s := range calls {
+		if err := ctx.Err(); err != nil {
+			return nil, history, err
+		}
+		name, argsJSON, _ := strings.Cut(s.text, " ")
+		args := make(map[string]any)
+		if argsJSON = strings.TrimSpace(r.substitute(argsJSON)); argsJSON != "" {
+			if err := json.Unmarshal([]byte(argsJSON), &args); err != nil {
+				r.fail(fmt.Errorf("%s:%d: tool arguments: %v", r.script.name, s.line, err))
+				continue
+			}
+		}
+		executor(name, args)
+	}
+
+	history = append(history, &llm.Message{Role: "user", Content: message}, &llm.Message{Role: "assistant", Content: "Done."})
+	return &llm.Response{Text: "Done."}, history, nil
+}
+
+// fail records the first error, which ends the session at the next input
+func (r *scriptRun) fail(err error) {
+	if r.err == nil {
+		r.err = err
+	}
+}
@@ -0,0 +1,72 @@
+package repl
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"twooms/commands"
+	"twooms/storage"
+)
+
+// TestScripts plays every testdata/*.session script against a fresh store
+func TestScripts(t *testing.T) {
+	paths, err := filepath.Glob("testdata/*.session")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(paths) == 0 {
+		t.Fatal("no scripts in testdata")
+	}
+
+	for _, path := range paths {
+		t.Run(strings.TrimSuffix(filepath.Base(path), ".session"), func(t *testing.T) {
+			store, err := storage.NewJSONStore(filepath.Join(t.TempDir(), "test.json"))
+			if err != nil {
+				t.Fatalf("Failed to create test store: %v", err)
+			}
+			defer store.Close()
+			commands.SetStore(store)
+			commands.ExecuteWithOutput("/clearchat")
+
+			script, err := LoadScript(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := script.Run(); err != nil {
+				t.Error(err)
+			}
+		})
+	}
+}
+
+func TestScriptFailures(t *testing.T) {
+	store, err := storage.NewJSONStore(filepath.Join(t.TempDir(), "test.json"))
+	if err != nil {
+		t.Fatalf("Failed to create test store: %v", err)
+	}
+	defer store.Close()
+	commands.SetStore(store)
+
+	tests := []struct {
+		name   string
+		script string
+		want   string
+	}{
+		{"missing output", "> /projects\n< Created project", `expected "Created project"`},
+		{"unsent context", "> /projects\nsent nothing like this\n> hello", `doesn't contain "nothing like this"`},
+		{"unused tool call", "tool projects\n> /projects", "no /chat after them"},
+		{"unknown step", "! /projects", "unknown step"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			script, err := ParseScript(tt.name, strings.NewReader(tt.script))
+			if err == nil {
+				err = script.Run()
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("Expected error containing %q, got %v", tt.want, err)
+			}
+		})
+	}
+}
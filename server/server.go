@@ -0,0 +1,87 @@
+package server
+
+import (
+	"html/template"
+	"net/http"
+
+	"twooms/storage"
+)
+
+// NewHandler returns the HTTP handler for serve mode. It only exposes
+// read-only share pages at /share/<token>; everything else is 404.
+func NewHandler(store storage.Store) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /share/{token}", func(w http.ResponseWriter, r *http.Request) {
+		serveShare(store, w, r)
+	})
+	return mux
+}
+
+// sharePage is the data rendered by shareTemplate
+type sharePage struct {
+	Project   *storage.Project
+	Tasks     []*storage.Task
+	Done      int
+	ExpiresAt string
+}
+
+var shareTemplate = template.Must(template.New("share").Funcs(template.FuncMap{"due": storage.FormatDue}).Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<meta name="robots" content="noindex">
+<title>{{.Project.Name}}</title>
+<style>
+body { font-family: system-ui, sans-serif; max-width: 40rem; margin: 2rem auto; padding: 0 1rem; color: #222; }
+ul { list-style: none; padding: 0; }
+li { padding: 0.4rem 0; border-bottom: 1px solid #eee; }
+.done { color: #888; text-decoration: line-through; }
+.meta { color: #666; font-size: 0.85rem; }
+</style>
+</head>
+<body>
+<h1>{{.Project.Name}}</h1>
+<p class="meta">{{.Done}} of {{len .Tasks}} tasks complete{{if .Project.DueDate}} &middot; due {{.Project.DueDate.Format "2006-01-02"}}{{end}}</p>
+<ul>
+{{range .Tasks}}<li{{if .Done}} class="done"{{end}}>{{if .Done}}&#9745;{{else}}&#9744;{{end}} {{.Name}}{{if .DueDate}} <span class="meta">due {{due .DueDate}}</span>{{end}}</li>
+{{else}}<li>No tasks yet.</li>
+{{end}}</ul>
+<p class="meta">Read-only view. Link expires {{.ExpiresAt}}.</p>
+</body>
+</html>
+`))
+
+func serveShare(store storage.Store, w http.ResponseWriter, r *http.Request) {
+	// Unknown and expired tokens look the same so links can't be probed
+	share, err := store.GetShare(r.PathValue("token"))
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+	project, err := store.GetProject(share.ProjectID)
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+	tasks, err := store.ListTasks(project.ID)
+	if err != nil {
+		http.Error(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+
+	page := sharePage{
+		Project:   project,
+		Tasks:     tasks,
+		ExpiresAt: share.ExpiresAt.Format("2006-01-02 15:04"),
+	}
+	for _, t := range tasks {
+		if t.Done {
+			page.Done++
+		}
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-store")
+	shareTemplate.Execute(w, page)
+}
@@ -0,0 +1,114 @@
+package storage
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+)
+
+// ProjectArchive is a standalone file holding one project and its tasks,
+// written by ArchiveProject and read back by RestoreProject
+type ProjectArchive struct {
+	ArchivedAt time.Time `json:"archived_at"`
+	Snapshot
+}
+
+// ArchiveProject writes a project and its tasks to path and removes them from
+// the store. The file is never overwritten, and it is removed again if the
+// project can't be deleted, so the project always lives in exactly one place.
+func ArchiveProject(s Store, projectID, path string) (*ProjectArchive, error) {
+	project, err := s.GetProject(projectID)
+	if err != nil {
+		return nil, err
+	}
+	tasks, err := listEveryTask(s, projectID)
+	if err != nil {
+		return nil, err
+	}
+
+	archive := &ProjectArchive{
+		ArchivedAt: time.Now(),
+		Snapshot:   Snapshot{Projects: []*Project{project}, Tasks: tasks},
+	}
+	data, err := json.MarshalIndent(archive, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		os.Remove(path)
+		return nil, err
+	}
+	if err := f.Close(); err != nil {
+		os.Remove(path)
+		return nil, err
+	}
+
+	if err := s.DeleteProject(projectID); err != nil {
+		os.Remove(path)
+		return nil, err
+	}
+	return archive, nil
+}
+
+// ReadProjectArchive loads an archive file written by ArchiveProject
+func ReadProjectArchive(path string) (*ProjectArchive, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	archive := &ProjectArchive{}
+	if err := json.Unmarshal(data, archive); err != nil {
+		return nil, fmt.Errorf("invalid archive: %w", err)
+	}
+	if len(archive.Projects) != 1 {
+		return nil, fmt.Errorf("invalid archive: expected 1 project, found %d", len(archive.Projects))
+	}
+	return archive, nil
+}
+
+// RestoreProject puts an archived project and its tasks back into the store
+// with their original IDs. It fails without changing anything if the project
+// is already present or its shortcut has been taken since.
+func RestoreProject(s Store, archive *ProjectArchive) (*ImportResult, error) {
+	plan, err := PlanImport(s, &archive.Snapshot)
+	if err != nil {
+		return nil, err
+	}
+	for _, e := range plan.Entries {
+		if e.Kind == "project" && e.Action != ImportCreate {
+			reason := e.Reason
+			if e.Action == ImportUnchanged {
+				reason = "it is already in the store"
+			}
+			return nil, fmt.Errorf("can't restore project %s: %s", e.Name, reason)
+		}
+	}
+	return ApplyImport(s, plan)
+}
+
+// checkArchivable reports why a task can't be archived, if it can't
+func checkArchivable(t *Task) error {
+	if !t.Done {
+		return fmt.Errorf("task is not done: %s", t.Name)
+	}
+	if t.IsArchived() {
+		return fmt.Errorf("task is already archived: %s", t.Name)
+	}
+	return nil
+}
+
+// archiveOp describes an ArchiveTasks call for the journal
+func archiveOp(tasks []*Task) string {
+	if len(tasks) == 1 {
+		return fmt.Sprintf("archive task %q", tasks[0].Name)
+	}
+	return fmt.Sprintf("archive %d tasks", len(tasks))
+}
@@ -0,0 +1,66 @@
+package storage
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestArchiveProject(t *testing.T) {
+	store := newTestStore(t)
+	path := filepath.Join(t.TempDir(), "work.json")
+
+	work, _ := store.CreateProject("Work")
+	report, _ := store.CreateTask(work.ID, "Write report")
+	store.SetTaskPriority(report.ID, PriorityHigh)
+	store.CreateTask(work.ID, "Review PR")
+	home, _ := store.CreateProject("Home")
+
+	archive, err := ArchiveProject(store, work.ID, path)
+	if err != nil {
+		t.Fatalf("Failed to archive: %v", err)
+	}
+	if len(archive.Tasks) != 2 {
+		t.Errorf("Expected 2 archived tasks, got %d", len(archive.Tasks))
+	}
+	if _, err := store.GetProject(work.ID); err == nil {
+		t.Error("Expected project removed from the store")
+	}
+	if tasks, _ := store.ListAllTasks(); len(tasks) != 0 {
+		t.Errorf("Expected tasks removed from the store, got %d", len(tasks))
+	}
+
+	// Existing files are never overwritten
+	if _, err := ArchiveProject(store, home.ID, path); err == nil {
+		t.Error("Expected archiving over an existing file to fail")
+	}
+	if _, err := store.GetProject(home.ID); err != nil {
+		t.Error("Expected project kept when the archive can't be written")
+	}
+
+	read, err := ReadProjectArchive(path)
+	if err != nil {
+		t.Fatalf("Failed to read archive: %v", err)
+	}
+	result, err := RestoreProject(store, read)
+	if err != nil {
+		t.Fatalf("Failed to restore: %v", err)
+	}
+	if result.ProjectsCreated != 1 || result.TasksCreated != 2 {
+		t.Errorf("Expected 1 project and 2 tasks restored, got %+v", result)
+	}
+	got, err := store.GetTask(report.ID)
+	if err != nil || got.Priority != PriorityHigh {
+		t.Errorf("Expected task restored with its ID and fields, got %v, %v", got, err)
+	}
+
+	// Restoring twice is refused
+	if _, err := RestoreProject(store, read); err == nil {
+		t.Error("Expected second restore to fail")
+	}
+
+	os.WriteFile(path, []byte(`{"projects": []}`), 0644)
+	if _, err := ReadProjectArchive(path); err == nil {
+		t.Error("Expected archive without a project to be rejected")
+	}
+}
@@ -0,0 +1,515 @@
+package storage
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// BackupStampFormat is the timestamp in backup file names; it sorts
+// chronologically
+const BackupStampFormat = "20060102-150405"
+
+// Backup is a JSON snapshot (the /export json format) in a backup directory,
+// named twooms-<stamp>.json, or twooms-<stamp>-auto.json when it was taken
+// automatically before a change
+type Backup struct {
+	Name string
+	Time time.Time
+	Auto bool
+}
+
+// Stamp returns the backup's timestamp, e.g. "20250101-090000"
+func (b *Backup) Stamp() string {
+	return b.Time.Format(BackupStampFormat)
+}
+
+func backupName(now time.Time, auto bool) string {
+	if auto {
+		return "twooms-" + now.Format(BackupStampFormat) + "-auto.json"
+	}
+	return "twooms-" + now.Format(BackupStampFormat) + ".json"
+}
+
+// parseBackupName reads a backup file name, reporting false for other files
+func parseBackupName(name string) (*Backup, bool) {
+	stamp, ok := strings.CutPrefix(name, "twooms-")
+	if !ok {
+		return nil, false
+	}
+	stamp, ok = strings.CutSuffix(stamp, ".json")
+	if !ok {
+		return nil, false
+	}
+	stamp, auto := strings.CutSuffix(stamp, "-auto")
+	t, err := time.ParseInLocation(BackupStampFormat, stamp, time.Local)
+	if err != nil {
+		return nil, false
+	}
+	return &Backup{Name: name, Time: t, Auto: auto}, true
+}
+
+// IsBackupRef reports whether ref names a backup by its timestamp or file
+// name, as opposed to an ID; a timestamp can't be the start of a UUID
+func IsBackupRef(ref string) bool {
+	if _, ok := parseBackupName(ref); ok {
+		return true
+	}
+	_, err := time.ParseInLocation(BackupStampFormat, ref, time.Local)
+	return err == nil
+}
+
+// ListBackups returns the backups in dir, newest first; a manual backup
+// comes before an automatic one from the same second. A missing directory
+// has none.
+func ListBackups(dir string) ([]*Backup, error) {
+	entries, err := os.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var backups []*Backup
+	for _, e := range entries {
+		if b, ok := parseBackupName(e.Name()); ok && !e.IsDir() {
+			backups = append(backups, b)
+		}
+	}
+	sort.SliceStable(backups, func(i, j int) bool {
+		if !backups[i].Time.Equal(backups[j].Time) {
+			return backups[i].Time.After(backups[j].Time)
+		}
+		return !backups[i].Auto && backups[j].Auto
+	})
+	return backups, nil
+}
+
+// FindBackup finds a backup in dir by file name or timestamp
+func FindBackup(dir, ref string) (*Backup, error) {
+	backups, err := ListBackups(dir)
+	if err != nil {
+		return nil, err
+	}
+	for _, b := range backups {
+		if b.Name == ref || b.Stamp() == ref {
+			return b, nil
+		}
+	}
+	return nil, fmt.Errorf("no backup %s in %s", ref, dir)
+}
+
+// WriteBackup writes a JSON snapshot of the store to dir, then removes the
+// oldest backups of its kind (manual or automatic) beyond keep; keep <= 0
+// keeps them all. An automatic backup is skipped, returning the newest one,
+// when the store hasn't changed since it or it's from the same second, since
+// that one already holds the state from before the change.
+func WriteBackup(s Store, dir string, auto bool, keep int, now time.Time) (*Backup, error) {
+	snap, err := ExportSnapshot(s)
+	if err != nil {
+		return nil, err
+	}
+	var data bytes.Buffer
+	if err := WriteSnapshot(&data, snap, FormatJSON); err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, err
+	}
+
+	name := backupName(now, auto)
+	if auto {
+		if last := newestBackup(dir, true); last != nil {
+			old, err := os.ReadFile(filepath.Join(dir, last.Name))
+			if last.Name == name || (err == nil && bytes.Equal(old, data.Bytes())) {
+				return last, nil
+			}
+		}
+	}
+
+	// Write and rename, so a crash never leaves half a backup
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path+".tmp", data.Bytes(), 0600); err != nil {
+		return nil, err
+	}
+	if err := os.Rename(path+".tmp", path); err != nil {
+		return nil, err
+	}
+
+	if keep > 0 {
+		if err := pruneBackups(dir, auto, keep); err != nil {
+			return nil, err
+		}
+	}
+	b, _ := parseBackupName(name)
+	return b, nil
+}
+
+// newestBackup returns the newest manual or automatic backup in dir, or nil
+func newestBackup(dir string, auto bool) *Backup {
+	backups, _ := ListBackups(dir)
+	for _, b := range backups {
+		if b.Auto == auto {
+			return b
+		}
+	}
+	return nil
+}
+
+// pruneBackups removes all but the newest keep backups of one kind
+func pruneBackups(dir string, auto bool, keep int) error {
+	backups, err := ListBackups(dir)
+	if err != nil {
+		return err
+	}
+	for _, b := range backups {
+		if b.Auto != auto {
+			continue
+		}
+		if keep > 0 {
+			keep--
+			continue
+		}
+		if err := os.Remove(filepath.Join(dir, b.Name)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ReadBackup loads the snapshot in a backup
+func ReadBackup(dir string, b *Backup) (*Snapshot, error) {
+	f, err := os.Open(filepath.Join(dir, b.Name))
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return ReadSnapshot(f, FormatJSON)
+}
+
+// RestoreResult counts the projects and tasks RestoreSnapshot changed
+type RestoreResult struct {
+	Added    int
+	Replaced int
+	Deleted  int
+}
+
+// RestoreSnapshot rolls a store back to a snapshot: projects and tasks the
+// snapshot doesn't have are deleted, and the rest are put back as they were.
+// It only uses Store methods, so it works for any backend, and runs as one
+// transaction, journaled as op: it is applied completely or not at all, and
+// one /undo reverses it. Time entries, shares, and conflicts are left alone.
+func RestoreSnapshot(s Store, snap *Snapshot, op string) (*RestoreResult, error) {
+	current, err := ExportSnapshot(s)
+	if err != nil {
+		return nil, err
+	}
+	result := &RestoreResult{}
+
+	keepProjects := make(map[string]bool)
+	for _, p := range snap.Projects {
+		keepProjects[p.ID] = true
+	}
+	keepTasks := make(map[string]bool)
+	for _, t := range snap.Tasks {
+		keepTasks[t.ID] = true
+	}
+	currentProjects := make(map[string]*Project)
+	for _, p := range current.Projects {
+		currentProjects[p.ID] = p
+	}
+	currentTasks := make(map[string]*Task)
+	for _, t := range current.Tasks {
+		currentTasks[t.ID] = t
+	}
+
+	err = s.Transact(op, func(tx Store) error {
+		// Deleting a project deletes its tasks, so tasks the snapshot keeps
+		// wait in the inbox until they're put back; and projects go before
+		// others are added, which may want their shortcuts
+		var deleteTasks []string
+		for _, t := range current.Tasks {
+			switch {
+			case !keepTasks[t.ID]:
+				deleteTasks = append(deleteTasks, t.ID)
+			case t.ProjectID != "" && !keepProjects[t.ProjectID]:
+				if err := tx.MoveTask(t.ID, ""); err != nil {
+					return err
+				}
+			}
+		}
+		if len(deleteTasks) > 0 {
+			if err := tx.DeleteTasks(deleteTasks); err != nil {
+				return err
+			}
+			result.Deleted += len(deleteTasks)
+		}
+		for _, p := range current.Projects {
+			if !keepProjects[p.ID] {
+				if err := tx.DeleteProject(p.ID); err != nil {
+					return err
+				}
+				result.Deleted++
+			}
+		}
+
+		for _, p := range snap.Projects {
+			var err error
+			old, ok := currentProjects[p.ID]
+			switch {
+			case !ok:
+				err = tx.ImportProject(p)
+				result.Added++
+			case !sameJSON(old, p):
+				err = tx.ReplaceProject(p)
+				result.Replaced++
+			}
+			if err != nil {
+				return err
+			}
+		}
+		for _, t := range snap.Tasks {
+			var err error
+			old, ok := currentTasks[t.ID]
+			switch {
+			case !ok:
+				err = tx.ImportTask(t)
+				result.Added++
+			case !sameJSON(old, t): // tasks moved to the inbox above differ too
+				err = tx.ReplaceTask(t)
+				result.Replaced++
+			}
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// AutoBackupStore wraps any Store and takes an automatic backup before each
+// change to projects or tasks, so the state from before it can be restored.
+// Changes to side data (timers, shares, conflicts, trash purges, compaction)
+// aren't in a backup and don't take one.
+type AutoBackupStore struct {
+	Store
+	dir  string
+	keep int
+
+	// OnError is told when a backup fails; the change goes ahead anyway
+	OnError func(error)
+}
+
+// NewAutoBackupStore backs up s in dir before each change, keeping the
+// newest keep automatic backups
+func NewAutoBackupStore(s Store, dir string, keep int) *AutoBackupStore {
+	return &AutoBackupStore{Store: s, dir: dir, keep: keep}
+}
+
+// backup takes the automatic backup before a change
+func (s *AutoBackupStore) backup() {
+	if _, err := WriteBackup(s.Store, s.dir, true, s.keep, time.Now()); err != nil && s.OnError != nil {
+		s.OnError(err)
+	}
+}
+
+// Transact takes one backup for the whole transaction; tx is the wrapped
+// store's, so the changes inside don't take their own
+func (s *AutoBackupStore) Transact(op string, fn func(tx Store) error) error {
+	s.backup()
+	return s.Store.Transact(op, fn)
+}
+
+func (s *AutoBackupStore) CreateProject(name string) (*Project, error) {
+	s.backup()
+	return s.Store.CreateProject(name)
+}
+
+func (s *AutoBackupStore) DeleteProject(id string) error {
+	s.backup()
+	return s.Store.DeleteProject(id)
+}
+
+func (s *AutoBackupStore) SetProjectShortcut(projectID, shortcut string) error {
+	s.backup()
+	return s.Store.SetProjectShortcut(projectID, shortcut)
+}
+
+func (s *AutoBackupStore) SetProjectDueDate(projectID string, dueDate *time.Time) error {
+	s.backup()
+	return s.Store.SetProjectDueDate(projectID, dueDate)
+}
+
+func (s *AutoBackupStore) SetProjectDefaults(projectID string, duration Duration, due string) error {
+	s.backup()
+	return s.Store.SetProjectDefaults(projectID, duration, due)
+}
+
+func (s *AutoBackupStore) Reorganize(r *Reorg) error {
+	s.backup()
+	return s.Store.Reorganize(r)
+}
+
+func (s *AutoBackupStore) CreateTask(projectID, name string) (*Task, error) {
+	s.backup()
+	return s.Store.CreateTask(projectID, name)
+}
+
+func (s *AutoBackupStore) CreateTaskFrom(task *Task) (*Task, error) {
+	s.backup()
+	return s.Store.CreateTaskFrom(task)
+}
+
+func (s *AutoBackupStore) CreateTasks(tasks []*Task) ([]*Task, error) {
+	s.backup()
+	return s.Store.CreateTasks(tasks)
+}
+
+func (s *AutoBackupStore) UpdateTask(id string, done bool) error {
+	s.backup()
+	return s.Store.UpdateTask(id, done)
+}
+
+func (s *AutoBackupStore) SetTaskDueDate(id string, dueDate *time.Time) error {
+	s.backup()
+	return s.Store.SetTaskDueDate(id, dueDate)
+}
+
+func (s *AutoBackupStore) SetTaskDueDates(dates map[string]time.Time) error {
+	s.backup()
+	return s.Store.SetTaskDueDates(dates)
+}
+
+func (s *AutoBackupStore) SetTaskDuration(id string, duration Duration) error {
+	s.backup()
+	return s.Store.SetTaskDuration(id, duration)
+}
+
+func (s *AutoBackupStore) UpdateTasks(ids []string, done bool) error {
+	s.backup()
+	return s.Store.UpdateTasks(ids, done)
+}
+
+func (s *AutoBackupStore) SetTaskDurations(durations map[string]Duration) error {
+	s.backup()
+	return s.Store.SetTaskDurations(durations)
+}
+
+func (s *AutoBackupStore) SetTaskPriority(id string, priority Priority) error {
+	s.backup()
+	return s.Store.SetTaskPriority(id, priority)
+}
+
+func (s *AutoBackupStore) SetTaskNote(id, note string) error {
+	s.backup()
+	return s.Store.SetTaskNote(id, note)
+}
+
+func (s *AutoBackupStore) SetTaskStatus(id string, status Status) error {
+	s.backup()
+	return s.Store.SetTaskStatus(id, status)
+}
+
+func (s *AutoBackupStore) MoveTask(id, projectID string) error {
+	s.backup()
+	return s.Store.MoveTask(id, projectID)
+}
+
+func (s *AutoBackupStore) ReorderTask(id string, position int) error {
+	s.backup()
+	return s.Store.ReorderTask(id, position)
+}
+
+func (s *AutoBackupStore) ArchiveTasks(ids []string) error {
+	s.backup()
+	return s.Store.ArchiveTasks(ids)
+}
+
+func (s *AutoBackupStore) DeleteTask(id string) error {
+	s.backup()
+	return s.Store.DeleteTask(id)
+}
+
+func (s *AutoBackupStore) DeleteTasks(ids []string) error {
+	s.backup()
+	return s.Store.DeleteTasks(ids)
+}
+
+func (s *AutoBackupStore) TrashProject(id string) error {
+	s.backup()
+	return s.Store.TrashProject(id)
+}
+
+func (s *AutoBackupStore) TrashTasks(ids []string) error {
+	s.backup()
+	return s.Store.TrashTasks(ids)
+}
+
+func (s *AutoBackupStore) RestoreTrash(idPrefix string) (*TrashItem, error) {
+	s.backup()
+	return s.Store.RestoreTrash(idPrefix)
+}
+
+func (s *AutoBackupStore) AddTaskTag(id, tag string) error {
+	s.backup()
+	return s.Store.AddTaskTag(id, tag)
+}
+
+func (s *AutoBackupStore) RemoveTaskTag(id, tag string) error {
+	s.backup()
+	return s.Store.RemoveTaskTag(id, tag)
+}
+
+func (s *AutoBackupStore) AddTaskDependency(taskID, blockerID string) error {
+	s.backup()
+	return s.Store.AddTaskDependency(taskID, blockerID)
+}
+
+func (s *AutoBackupStore) RemoveTaskDependency(taskID, blockerID string) error {
+	s.backup()
+	return s.Store.RemoveTaskDependency(taskID, blockerID)
+}
+
+func (s *AutoBackupStore) Escalate(policy EscalationPolicy, now time.Time) ([]*Escalation, error) {
+	s.backup()
+	return s.Store.Escalate(policy, now)
+}
+
+func (s *AutoBackupStore) ImportProject(project *Project) error {
+	s.backup()
+	return s.Store.ImportProject(project)
+}
+
+func (s *AutoBackupStore) ImportTask(task *Task) error {
+	s.backup()
+	return s.Store.ImportTask(task)
+}
+
+func (s *AutoBackupStore) ReplaceProject(project *Project) error {
+	s.backup()
+	return s.Store.ReplaceProject(project)
+}
+
+func (s *AutoBackupStore) ReplaceTask(task *Task) error {
+	s.backup()
+	return s.Store.ReplaceTask(task)
+}
+
+func (s *AutoBackupStore) Undo() (*JournalEntry, error) {
+	s.backup()
+	return s.Store.Undo()
+}
+
+func (s *AutoBackupStore) Redo() (*JournalEntry, error) {
+	s.backup()
+	return s.Store.Redo()
+}
@@ -0,0 +1,117 @@
+package storage
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestWriteBackup(t *testing.T) {
+	store := newTestStore(t)
+	dir := filepath.Join(t.TempDir(), "backups")
+	now := time.Date(2025, 1, 1, 9, 0, 0, 0, time.Local)
+
+	store.CreateProject("Work")
+	if _, err := WriteBackup(store, dir, false, 2, now); err != nil {
+		t.Fatalf("Failed to back up: %v", err)
+	}
+	auto, err := WriteBackup(store, dir, true, 2, now)
+	if err != nil || auto.Name != "twooms-20250101-090000-auto.json" {
+		t.Fatalf("Expected an automatic backup, got %v, %v", auto, err)
+	}
+
+	// An automatic backup of an unchanged store is skipped
+	if b, _ := WriteBackup(store, dir, true, 2, now.Add(time.Minute)); b.Name != auto.Name {
+		t.Errorf("Expected the unchanged store not backed up again, got %s", b.Name)
+	}
+
+	// Each kind keeps its own newest backups
+	for i := 1; i <= 3; i++ {
+		store.CreateProject("Home")
+		WriteBackup(store, dir, true, 2, now.Add(time.Duration(i)*time.Hour))
+	}
+	backups, err := ListBackups(dir)
+	if err != nil {
+		t.Fatalf("Failed to list: %v", err)
+	}
+	var stamps []string
+	for _, b := range backups {
+		stamps = append(stamps, b.Stamp())
+	}
+	if len(backups) != 3 || !backups[0].Auto || backups[0].Stamp() != "20250101-120000" || backups[2].Auto {
+		t.Errorf("Expected 2 newest automatic backups and the manual one, got %v", stamps)
+	}
+
+	// Other files are ignored, and a stamp finds the manual backup first
+	os.WriteFile(filepath.Join(dir, "notes.txt"), nil, 0600)
+	if b, err := FindBackup(dir, "20250101-090000"); err != nil || b.Auto {
+		t.Errorf("Expected the manual backup, got %v, %v", b, err)
+	}
+	if _, err := FindBackup(dir, "20240101-090000"); err == nil {
+		t.Error("Expected an unknown stamp to fail")
+	}
+}
+
+func TestRestoreSnapshot(t *testing.T) {
+	backends := map[string]func(t *testing.T) Store{
+		"json": func(t *testing.T) Store { return newTestStore(t) },
+		"bolt": func(t *testing.T) Store {
+			store, err := NewBoltStore(filepath.Join(t.TempDir(), "test.db"))
+			if err != nil {
+				t.Fatalf("Failed to create store: %v", err)
+			}
+			t.Cleanup(func() { store.Close() })
+			return store
+		},
+	}
+
+	for name, open := range backends {
+		t.Run(name, func(t *testing.T) {
+			dir := t.TempDir()
+			store := NewAutoBackupStore(open(t), dir, 10)
+			store.OnError = func(err error) { t.Errorf("Backup failed: %v", err) }
+
+			work, _ := store.CreateProject("Work")
+			store.SetProjectShortcut(work.ID, "work")
+			report, _ := store.CreateTask(work.ID, "Write report")
+			inbox, _ := store.CreateTaskFrom(&Task{Name: "Call bank"})
+			saved, err := WriteBackup(store, dir, false, 0, time.Now())
+			if err != nil {
+				t.Fatalf("Failed to back up: %v", err)
+			}
+
+			// Delete the project and reuse its shortcut, change and add tasks
+			store.SetTaskPriority(inbox.ID, PriorityHigh)
+			store.MoveTask(report.ID, "")
+			store.DeleteProject(work.ID)
+			other, _ := store.CreateProject("Other")
+			store.SetProjectShortcut(other.ID, "work")
+			store.CreateTask(other.ID, "Plan offsite")
+
+			// Changes within a second share the backup from before the first
+			if b := newestBackup(dir, true); b == nil {
+				t.Fatal("Expected an automatic backup before the changes")
+			}
+
+			before, err := ReadBackup(dir, saved)
+			if err != nil {
+				t.Fatalf("Failed to read backup: %v", err)
+			}
+			result, err := RestoreSnapshot(store, before, "roll back")
+			if err != nil {
+				t.Fatalf("Failed to restore: %v", err)
+			}
+			if result.Added != 1 || result.Replaced != 2 || result.Deleted != 2 {
+				t.Errorf("Expected 1 added, 2 replaced, 2 deleted, got %+v", result)
+			}
+			after, _ := ExportSnapshot(store)
+			if !sameJSON(before, after) {
+				t.Errorf("Expected the store rolled back\nwant %+v\ngot  %+v", before, after)
+			}
+			if _, err := store.GetProject(other.ID); err == nil {
+				t.Error("Expected the project created since removed")
+			}
+		})
+	}
+}
@@ -0,0 +1,145 @@
+package storage
+
+import (
+	"fmt"
+	"time"
+)
+
+// BenchResult is how one backend performed with a synthetic dataset
+type BenchResult struct {
+	Tasks   int
+	Create  time.Duration // adding every task, as one batch
+	Load    time.Duration // opening the store with the dataset in it
+	Save    time.Duration // one small edit (a priority change), on average
+	Resolve time.Duration // resolving an 8-character task ID prefix, on average
+	List    time.Duration // listing one project's tasks, on average
+	ListAll time.Duration // listing every task, on average
+	Size    int64         // bytes on disk
+}
+
+// Operations timed per measurement; enough to average out noise without
+// making large JSON datasets (where every save rewrites the file) crawl
+const (
+	benchSaves    = 10
+	benchResolves = 200
+	benchLists    = 50
+	benchListAlls = 10
+)
+
+// benchTasksPerProject is how big the synthetic projects are
+const benchTasksPerProject = 100
+
+// Bench fills an empty store from open with a synthetic dataset of the given
+// number of tasks, spread over projects of 100 with due dates, durations,
+// and tags like real data, and times the operations every command relies on.
+// open is called twice: once to fill the store, then again to time loading.
+func Bench(open func() (Store, error), tasks int) (*BenchResult, error) {
+	if tasks < 1 {
+		return nil, fmt.Errorf("dataset needs at least one task")
+	}
+	store, err := open()
+	if err != nil {
+		return nil, err
+	}
+
+	ids, projectID, create, err := benchFill(store, tasks)
+	store.Close()
+	if err != nil {
+		return nil, err
+	}
+	r := &BenchResult{Tasks: tasks, Create: create}
+
+	start := time.Now()
+	store, err = open()
+	if err != nil {
+		return nil, err
+	}
+	defer store.Close()
+	r.Load = time.Since(start)
+
+	r.Save, err = benchAverage(benchSaves, func(i int) error {
+		return store.SetTaskPriority(ids[i*len(ids)/benchSaves], ValidPriorities[i%len(ValidPriorities)])
+	})
+	if err != nil {
+		return nil, err
+	}
+	r.Resolve, err = benchAverage(benchResolves, func(i int) error {
+		// With 100k tasks some 8-character prefixes are shared; an ambiguous
+		// prefix costs the same lookup, so its error isn't a failure
+		store.ResolveTaskID(ids[i*len(ids)/benchResolves%len(ids)][:8])
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	r.List, err = benchAverage(benchLists, func(int) error {
+		_, err := store.ListTasks(projectID)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	r.ListAll, err = benchAverage(benchListAlls, func(int) error {
+		_, err := store.ListAllTasks()
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	if r.Size, err = store.Size(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// benchFill creates the dataset, returning the task IDs, one project to list,
+// and how long creating the tasks took
+func benchFill(store Store, tasks int) ([]string, string, time.Duration, error) {
+	var fields []*Task
+	var projectID string
+	base := time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC)
+	for i := 0; i < tasks; i++ {
+		if i%benchTasksPerProject == 0 {
+			project, err := store.CreateProject(fmt.Sprintf("Project %d", i/benchTasksPerProject+1))
+			if err != nil {
+				return nil, "", 0, err
+			}
+			projectID = project.ID
+		}
+		task := &Task{
+			ProjectID: projectID,
+			Name:      fmt.Sprintf("Synthetic task %d", i+1),
+			Duration:  ValidDurations[i%len(ValidDurations)],
+			Tags:      []string{fmt.Sprintf("tag%d", i%7)},
+		}
+		if i%3 != 0 {
+			due := base.AddDate(0, 0, i%90)
+			task.DueDate = &due
+		}
+		fields = append(fields, task)
+	}
+
+	start := time.Now()
+	created, err := store.CreateTasks(fields)
+	if err != nil {
+		return nil, "", 0, err
+	}
+	elapsed := time.Since(start)
+
+	ids := make([]string, len(created))
+	for i, t := range created {
+		ids[i] = t.ID
+	}
+	return ids, projectID, elapsed, nil
+}
+
+// benchAverage runs fn n times and returns the average time per run
+func benchAverage(n int, fn func(i int) error) (time.Duration, error) {
+	start := time.Now()
+	for i := 0; i < n; i++ {
+		if err := fn(i); err != nil {
+			return 0, err
+		}
+	}
+	return time.Since(start) / time.Duration(n), nil
+}
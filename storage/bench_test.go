@@ -0,0 +1,41 @@
+package storage
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestBench(t *testing.T) {
+	for name, open := range map[string]func(path string) (Store, error){
+		"json": func(path string) (Store, error) { return NewJSONStore(path + ".json") },
+		"bolt": func(path string) (Store, error) { return NewBoltStore(path + ".db") },
+	} {
+		t.Run(name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "bench")
+			opened := 0
+			r, err := Bench(func() (Store, error) { opened++; return open(path) }, 250)
+			if err != nil {
+				t.Fatalf("Bench failed: %v", err)
+			}
+			if r.Tasks != 250 || opened != 2 {
+				t.Errorf("Expected 250 tasks and the store opened twice, got %d tasks, %d opens", r.Tasks, opened)
+			}
+			if r.Create <= 0 || r.Load <= 0 || r.Save <= 0 || r.ListAll <= 0 || r.Size == 0 {
+				t.Errorf("Expected every measurement taken, got %+v", r)
+			}
+
+			// The dataset is left behind as the store saw it
+			store, _ := open(path)
+			defer store.Close()
+			projects, _ := store.ListProjects()
+			tasks, _ := store.ListAllTasks()
+			if len(projects) != 3 || len(tasks) != 250 {
+				t.Errorf("Expected 3 projects and 250 tasks, got %d and %d", len(projects), len(tasks))
+			}
+		})
+	}
+
+	if _, err := Bench(func() (Store, error) { return nil, nil }, 0); err == nil {
+		t.Error("Expected an empty dataset to be rejected")
+	}
+}
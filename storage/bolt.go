@@ -0,0 +1,1787 @@
+package storage
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+// Bucket names. Projects and tasks are keyed by UUID so ID prefixes can be
+// resolved with a cursor seek. Side data (journal, conflicts, shares, time
+// entries) is small and kept as JSON lists under keys in the meta bucket.
+var (
+	projectsBucket = []byte("projects")
+	tasksBucket    = []byte("tasks")
+	metaBucket     = []byte("meta")
+)
+
+// Meta bucket keys
+const (
+	metaJournal      = "journal"
+	metaRedo         = "redo"
+	metaConflicts    = "conflicts"
+	metaShares       = "shares"
+	metaTimeEntries  = "time_entries"
+	metaTrash        = "trash"
+	metaMigratedFrom = "migrated_from"
+)
+
+// BoltStore implements Store using a bbolt database. Every operation runs in
+// its own transaction, so a mutation and its journal entry commit together.
+type BoltStore struct {
+	db *bolt.DB
+
+	// tx is set on the store Transact hands its closure; operations then
+	// run in that transaction instead of their own
+	tx *bolt.Tx
+}
+
+// NewBoltStore creates or opens a bbolt-backed store
+func NewBoltStore(filename string) (*BoltStore, error) {
+	db, err := bolt.Open(filename, 0644, &bolt.Options{Timeout: time.Second})
+	if err != nil {
+		return nil, fmt.Errorf("failed to open database: %w", err)
+	}
+
+	err = db.Update(func(tx *bolt.Tx) error {
+		for _, name := range [][]byte{projectsBucket, tasksBucket, metaBucket} {
+			if _, err := tx.CreateBucketIfNotExists(name); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to create buckets: %w", err)
+	}
+
+	return &BoltStore{db: db}, nil
+}
+
+// MigrateFromJSON copies everything from a JSON store file into an empty
+// database. It runs once: afterwards, or if the database already has data or
+// the file doesn't exist, it does nothing and returns false. The JSON file is
+// left in place.
+func (s *BoltStore) MigrateFromJSON(jsonPath string) (bool, error) {
+	if s.tx != nil {
+		return false, errInTransaction
+	}
+	if _, err := os.Stat(jsonPath); err != nil {
+		return false, nil
+	}
+
+	var empty bool
+	err := s.view(func(tx *bolt.Tx) error {
+		meta := tx.Bucket(metaBucket)
+		empty = meta.Get([]byte(metaMigratedFrom)) == nil &&
+			tx.Bucket(projectsBucket).Stats().KeyN == 0 &&
+			tx.Bucket(tasksBucket).Stats().KeyN == 0
+		return nil
+	})
+	if err != nil || !empty {
+		return false, err
+	}
+
+	// Loading through JSONStore also upgrades legacy IDs
+	source, err := NewJSONStore(jsonPath)
+	if err != nil {
+		return false, err
+	}
+	defer source.Close()
+	data := source.data
+
+	err = s.update(func(tx *bolt.Tx) error {
+		for _, p := range data.Projects {
+			if err := putJSON(tx.Bucket(projectsBucket), p.ID, p); err != nil {
+				return err
+			}
+		}
+		for _, t := range data.Tasks {
+			if err := putJSON(tx.Bucket(tasksBucket), t.ID, t); err != nil {
+				return err
+			}
+		}
+
+		meta := tx.Bucket(metaBucket)
+		lists := map[string]any{
+			metaJournal:     data.Journal,
+			metaRedo:        data.Redo,
+			metaConflicts:   data.Conflicts,
+			metaShares:      data.Shares,
+			metaTimeEntries: data.TimeEntries,
+			metaTrash:       data.Trash,
+		}
+		for key, list := range lists {
+			if err := putJSON(meta, key, list); err != nil {
+				return err
+			}
+		}
+		return meta.Put([]byte(metaMigratedFrom), []byte(jsonPath))
+	})
+	if err != nil {
+		return false, fmt.Errorf("failed to migrate %s: %w", jsonPath, err)
+	}
+	return true, nil
+}
+
+// update runs fn in a read-write transaction of its own, or in the one
+// Transact opened
+func (s *BoltStore) update(fn func(tx *bolt.Tx) error) error {
+	if s.tx != nil {
+		return fn(s.tx)
+	}
+	return s.db.Update(fn)
+}
+
+// view runs fn in a read-only transaction of its own, or in the one
+// Transact opened
+func (s *BoltStore) view(fn func(tx *bolt.Tx) error) error {
+	if s.tx != nil {
+		return fn(s.tx)
+	}
+	return s.db.View(fn)
+}
+
+// Transact runs fn against a store whose operations all run in one bbolt
+// transaction: their changes commit together, as one journal entry named
+// op, when fn returns nil, and roll back when it returns an error. tx is
+// only valid inside fn. Calling Transact on tx runs fn as part of the
+// outer transaction.
+func (s *BoltStore) Transact(op string, fn func(tx Store) error) error {
+	if s.tx != nil {
+		return fn(s)
+	}
+
+	return s.db.Update(func(tx *bolt.Tx) error {
+		meta := tx.Bucket(metaBucket)
+		var before []*JournalEntry
+		if _, err := getJSON(meta, metaJournal, &before); err != nil {
+			return err
+		}
+		mark := len(before)
+
+		if err := fn(&BoltStore{db: s.db, tx: tx}); err != nil {
+			return err
+		}
+
+		var journal []*JournalEntry
+		if _, err := getJSON(meta, metaJournal, &journal); err != nil {
+			return err
+		}
+		folded := foldJournal(op, journal[mark:])
+		if folded == nil {
+			return nil
+		}
+		after, err := captureTx(tx, folded.ProjectIDs, folded.TaskIDs)
+		if err != nil {
+			return err
+		}
+		folded.After = after
+		journal = append(journal[:mark], folded)
+		if len(journal) > maxJournalEntries {
+			journal = journal[len(journal)-maxJournalEntries:]
+		}
+		return putJSON(meta, metaJournal, journal)
+	})
+}
+
+// putJSON stores v as JSON under key
+func putJSON(b *bolt.Bucket, key string, v any) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	return b.Put([]byte(key), data)
+}
+
+// getJSON decodes the value under key into v, reporting whether it existed
+func getJSON(b *bolt.Bucket, key string, v any) (bool, error) {
+	data := b.Get([]byte(key))
+	if data == nil {
+		return false, nil
+	}
+	return true, json.Unmarshal(data, v)
+}
+
+func getProject(tx *bolt.Tx, id string) (*Project, error) {
+	var p Project
+	ok, err := getJSON(tx.Bucket(projectsBucket), id, &p)
+	if err != nil || !ok {
+		return nil, err
+	}
+	return &p, nil
+}
+
+// hasProject reports whether tasks can go in projectID: an existing project,
+// or the inbox ("")
+func hasProject(tx *bolt.Tx, projectID string) bool {
+	return projectID == "" || tx.Bucket(projectsBucket).Get([]byte(projectID)) != nil
+}
+
+func getTask(tx *bolt.Tx, id string) (*Task, error) {
+	var t Task
+	ok, err := getJSON(tx.Bucket(tasksBucket), id, &t)
+	if err != nil || !ok {
+		return nil, err
+	}
+	return &t, nil
+}
+
+// allProjects returns every project in creation order
+func allProjects(tx *bolt.Tx) ([]*Project, error) {
+	projects := []*Project{}
+	err := tx.Bucket(projectsBucket).ForEach(func(k, v []byte) error {
+		var p Project
+		if err := json.Unmarshal(v, &p); err != nil {
+			return err
+		}
+		projects = append(projects, &p)
+		return nil
+	})
+	sort.SliceStable(projects, func(i, j int) bool { return projects[i].CreatedAt.Before(projects[j].CreatedAt) })
+	return projects, err
+}
+
+// allTasks returns every task in creation order
+func allTasks(tx *bolt.Tx) ([]*Task, error) {
+	tasks := []*Task{}
+	err := tx.Bucket(tasksBucket).ForEach(func(k, v []byte) error {
+		var t Task
+		if err := json.Unmarshal(v, &t); err != nil {
+			return err
+		}
+		tasks = append(tasks, &t)
+		return nil
+	})
+	sort.SliceStable(tasks, func(i, j int) bool { return tasks[i].CreatedAt.Before(tasks[j].CreatedAt) })
+	return tasks, err
+}
+
+// prefixKeys returns the keys in a bucket that start with prefix
+func prefixKeys(b *bolt.Bucket, prefix string) []string {
+	var keys []string
+	c := b.Cursor()
+	for k, _ := c.Seek([]byte(prefix)); k != nil && bytes.HasPrefix(k, []byte(prefix)); k, _ = c.Next() {
+		keys = append(keys, string(k))
+	}
+	return keys
+}
+
+// journaled runs a mutation and records it in the journal within tx. If
+// mutate fails the transaction is rolled back, so nothing is recorded.
+func (s *BoltStore) journaled(tx *bolt.Tx, op string, projectIDs, taskIDs []string, mutate func() error) error {
+	before, err := captureTx(tx, projectIDs, taskIDs)
+	if err != nil {
+		return err
+	}
+	if err := mutate(); err != nil {
+		return err
+	}
+	after, err := captureTx(tx, projectIDs, taskIDs)
+	if err != nil {
+		return err
+	}
+
+	meta := tx.Bucket(metaBucket)
+	var journal []*JournalEntry
+	if _, err := getJSON(meta, metaJournal, &journal); err != nil {
+		return err
+	}
+	journal = append(journal, &JournalEntry{
+		Op:         op,
+		Time:       time.Now(),
+		ProjectIDs: projectIDs,
+		TaskIDs:    taskIDs,
+		Before:     before,
+		After:      after,
+	})
+	// A transaction trims once it has folded its entries into one
+	if s.tx == nil && len(journal) > maxJournalEntries {
+		journal = journal[len(journal)-maxJournalEntries:]
+	}
+	if err := putJSON(meta, metaJournal, journal); err != nil {
+		return err
+	}
+	// A new change invalidates anything that was undone
+	return meta.Delete([]byte(metaRedo))
+}
+
+// updateTask applies fn to a single task as a journaled operation
+func (s *BoltStore) updateTask(id, op string, fn func(t *Task) error) error {
+	return s.update(func(tx *bolt.Tx) error {
+		task, err := getTask(tx, id)
+		if err != nil {
+			return err
+		}
+		if task == nil {
+			return fmt.Errorf("task not found: %s", id)
+		}
+		return s.journaled(tx, fmt.Sprintf("%s %q", op, task.Name), nil, []string{id}, func() error {
+			if err := fn(task); err != nil {
+				return err
+			}
+			task.touch(time.Now())
+			return putJSON(tx.Bucket(tasksBucket), id, task)
+		})
+	})
+}
+
+// updateProject applies fn to a single project as a journaled operation
+func (s *BoltStore) updateProject(id, op string, fn func(p *Project) error) error {
+	return s.update(func(tx *bolt.Tx) error {
+		project, err := getProject(tx, id)
+		if err != nil {
+			return err
+		}
+		if project == nil {
+			return fmt.Errorf("project not found: %s", id)
+		}
+		return s.journaled(tx, fmt.Sprintf("%s %q", op, project.Name), []string{id}, nil, func() error {
+			if err := fn(project); err != nil {
+				return err
+			}
+			return putJSON(tx.Bucket(projectsBucket), id, project)
+		})
+	})
+}
+
+// captureTx copies the current state of the given projects and tasks
+func captureTx(tx *bolt.Tx, projectIDs, taskIDs []string) (*JournalState, error) {
+	state := &JournalState{}
+	for _, id := range projectIDs {
+		p, err := getProject(tx, id)
+		if err != nil {
+			return nil, err
+		}
+		if p != nil {
+			state.Projects = append(state.Projects, p)
+		}
+	}
+	for _, id := range taskIDs {
+		t, err := getTask(tx, id)
+		if err != nil {
+			return nil, err
+		}
+		if t != nil {
+			state.Tasks = append(state.Tasks, t)
+		}
+	}
+	return state, nil
+}
+
+// restoreTx puts the entry's affected entities back into the given state
+func restoreTx(tx *bolt.Tx, entry *JournalEntry, state *JournalState) error {
+	for _, id := range entry.ProjectIDs {
+		if p := findProject(state.Projects, func(p *Project) bool { return p.ID == id }); p != nil {
+			if err := putJSON(tx.Bucket(projectsBucket), id, p); err != nil {
+				return err
+			}
+		} else if err := tx.Bucket(projectsBucket).Delete([]byte(id)); err != nil {
+			return err
+		}
+	}
+	for _, id := range entry.TaskIDs {
+		if t := findTask(state.Tasks, func(t *Task) bool { return t.ID == id }); t != nil {
+			if err := putJSON(tx.Bucket(tasksBucket), id, t); err != nil {
+				return err
+			}
+		} else if err := tx.Bucket(tasksBucket).Delete([]byte(id)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// CreateProject creates a new project
+func (s *BoltStore) CreateProject(name string) (*Project, error) {
+	name = CleanName(name)
+	id := generateUUID()
+	project := &Project{
+		ID:        id,
+		Name:      name,
+		Shortcut:  id[:8], // Default shortcut is first 8 chars of UUID
+		CreatedAt: time.Now(),
+	}
+
+	err := s.update(func(tx *bolt.Tx) error {
+		return s.journaled(tx, fmt.Sprintf("create project %q", name), []string{id}, nil, func() error {
+			return putJSON(tx.Bucket(projectsBucket), id, project)
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return project, nil
+}
+
+// ListProjects returns all projects
+func (s *BoltStore) ListProjects() ([]*Project, error) {
+	var projects []*Project
+	err := s.view(func(tx *bolt.Tx) error {
+		var err error
+		projects, err = allProjects(tx)
+		return err
+	})
+	return projects, err
+}
+
+// GetProject retrieves a project by ID
+func (s *BoltStore) GetProject(id string) (*Project, error) {
+	var project *Project
+	err := s.view(func(tx *bolt.Tx) error {
+		var err error
+		project, err = getProject(tx, id)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	if project == nil {
+		return nil, fmt.Errorf("project not found: %s", id)
+	}
+	return project, nil
+}
+
+// DeleteProject removes a project and its tasks
+func (s *BoltStore) DeleteProject(id string) error {
+	return s.update(func(tx *bolt.Tx) error {
+		project, err := getProject(tx, id)
+		if err != nil {
+			return err
+		}
+		if project == nil {
+			return fmt.Errorf("project not found: %s", id)
+		}
+
+		tasks, err := allTasks(tx)
+		if err != nil {
+			return err
+		}
+		var taskIDs []string
+		for _, t := range tasks {
+			if t.ProjectID == id {
+				taskIDs = append(taskIDs, t.ID)
+			}
+		}
+
+		return s.journaled(tx, fmt.Sprintf("delete project %q", project.Name), []string{id}, taskIDs, func() error {
+			if err := tx.Bucket(projectsBucket).Delete([]byte(id)); err != nil {
+				return err
+			}
+			// Remove all tasks in this project
+			for _, taskID := range taskIDs {
+				if err := tx.Bucket(tasksBucket).Delete([]byte(taskID)); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+	})
+}
+
+// SetProjectShortcut sets a custom shortcut for a project
+func (s *BoltStore) SetProjectShortcut(projectID, shortcut string) error {
+	// Validate shortcut format and reserved words
+	if err := validateShortcut(shortcut); err != nil {
+		return err
+	}
+
+	return s.update(func(tx *bolt.Tx) error {
+		// Check for shortcut conflicts
+		projects, err := allProjects(tx)
+		if err != nil {
+			return err
+		}
+		var project *Project
+		for _, p := range projects {
+			if p.ID != projectID && p.Shortcut == shortcut {
+				return fmt.Errorf("shortcut already in use by project: %s", p.Name)
+			}
+			if p.ID == projectID {
+				project = p
+			}
+		}
+		if project == nil {
+			return fmt.Errorf("project not found: %s", projectID)
+		}
+
+		return s.journaled(tx, fmt.Sprintf("set shortcut of %q", project.Name), []string{projectID}, nil, func() error {
+			project.Shortcut = shortcut
+			return putJSON(tx.Bucket(projectsBucket), projectID, project)
+		})
+	})
+}
+
+// SetProjectDueDate sets or clears a project's due date
+func (s *BoltStore) SetProjectDueDate(projectID string, dueDate *time.Time) error {
+	return s.updateProject(projectID, "set due date of project", func(p *Project) error {
+		p.DueDate = dueDate
+		return nil
+	})
+}
+
+// SetProjectDefaults sets the duration and due shift given to tasks
+// created in a project
+func (s *BoltStore) SetProjectDefaults(projectID string, duration Duration, due string) error {
+	return s.updateProject(projectID, "set defaults of project", func(p *Project) error {
+		p.DefaultDuration = duration
+		p.DefaultDue = due
+		return nil
+	})
+}
+
+// ResolveProjectID resolves a project identifier to its full UUID
+// It checks: exact UUID match → shortcut match → UUID prefix (min 6 chars) → name → part of a name
+func (s *BoltStore) ResolveProjectID(idOrShortcut string) (string, error) {
+	var resolved string
+	err := s.view(func(tx *bolt.Tx) error {
+		b := tx.Bucket(projectsBucket)
+
+		// First, try exact UUID match
+		if b.Get([]byte(idOrShortcut)) != nil {
+			resolved = idOrShortcut
+			return nil
+		}
+
+		// Second, try shortcut match
+		projects, err := allProjects(tx)
+		if err != nil {
+			return err
+		}
+		for _, p := range projects {
+			if p.Shortcut == idOrShortcut {
+				resolved = p.ID
+				return nil
+			}
+		}
+
+		// Third, try UUID prefix match (min 6 chars)
+		if len(idOrShortcut) >= 6 {
+			matches := prefixKeys(b, idOrShortcut)
+			if len(matches) == 1 {
+				resolved = matches[0]
+				return nil
+			}
+			if len(matches) > 1 {
+				return fmt.Errorf("%w project ID prefix: %s (matches %d projects)", ErrAmbiguous, idOrShortcut, len(matches))
+			}
+		}
+
+		// Finally, try the project's name
+		if resolved, err = projectNamed(projects, idOrShortcut); resolved != "" || err != nil {
+			return err
+		}
+
+		return fmt.Errorf("project not found: %s", idOrShortcut)
+	})
+	return resolved, err
+}
+
+// ResolveTaskID resolves a task identifier to its full UUID
+// It checks: exact UUID match → UUID prefix (min 6 chars) → name → part of a name
+func (s *BoltStore) ResolveTaskID(idOrPrefix string) (string, error) {
+	var resolved string
+	err := s.view(func(tx *bolt.Tx) error {
+		b := tx.Bucket(tasksBucket)
+
+		// First, try exact UUID match
+		if b.Get([]byte(idOrPrefix)) != nil {
+			resolved = idOrPrefix
+			return nil
+		}
+
+		// Second, try UUID prefix match (min 6 chars)
+		if len(idOrPrefix) >= 6 {
+			matches := prefixKeys(b, idOrPrefix)
+			if len(matches) == 1 {
+				resolved = matches[0]
+				return nil
+			}
+			if len(matches) > 1 {
+				return fmt.Errorf("%w task ID prefix: %s (matches %d tasks)", ErrAmbiguous, idOrPrefix, len(matches))
+			}
+		}
+
+		// Finally, try the task's name
+		tasks, err := allTasks(tx)
+		if err != nil {
+			return err
+		}
+		if resolved, err = taskNamed(tasks, idOrPrefix); resolved != "" || err != nil {
+			return err
+		}
+
+		return fmt.Errorf("task not found: %s", idOrPrefix)
+	})
+	return resolved, err
+}
+
+// CreateTask creates a new task in a project
+func (s *BoltStore) CreateTask(projectID, name string) (*Task, error) {
+	return s.CreateTaskFrom(&Task{ProjectID: projectID, Name: name})
+}
+
+// CreateTaskFrom creates a new task with the given fields, assigning a fresh
+// ID and creation time
+func (s *BoltStore) CreateTaskFrom(fields *Task) (*Task, error) {
+	task := copyTask(fields)
+	task.ID = generateUUID()
+	task.Name = CleanName(task.Name)
+	task.CreatedAt = time.Now()
+
+	err := s.update(func(tx *bolt.Tx) error {
+		// Verify project exists
+		if !hasProject(tx, task.ProjectID) {
+			return fmt.Errorf("project not found: %s", task.ProjectID)
+		}
+		return s.journaled(tx, fmt.Sprintf("create task %q", task.Name), nil, []string{task.ID}, func() error {
+			return putJSON(tx.Bucket(tasksBucket), task.ID, task)
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return task, nil
+}
+
+// CreateTasks creates several tasks as one journaled change, in order
+func (s *BoltStore) CreateTasks(fields []*Task) ([]*Task, error) {
+	tasks, ids := newBatchTasks(fields, time.Now())
+
+	err := s.update(func(tx *bolt.Tx) error {
+		for _, task := range tasks {
+			if !hasProject(tx, task.ProjectID) {
+				return fmt.Errorf("project not found: %s", task.ProjectID)
+			}
+		}
+		return s.journaled(tx, fmt.Sprintf("create %d tasks", len(tasks)), nil, ids, func() error {
+			for _, task := range tasks {
+				if err := putJSON(tx.Bucket(tasksBucket), task.ID, task); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return tasks, nil
+}
+
+// ListTasks returns all tasks for a project
+func (s *BoltStore) ListTasks(projectID string) ([]*Task, error) {
+	return s.filterTasks(func(t *Task) bool { return t.ProjectID == projectID && !t.IsArchived() })
+}
+
+// ListAllTasks returns all tasks across all projects
+func (s *BoltStore) ListAllTasks() ([]*Task, error) {
+	return s.filterTasks(func(t *Task) bool { return !t.IsArchived() })
+}
+
+// ListTasksByTag returns all tasks across all projects carrying the given
+// tag. It scans every task on purpose; see the Store interface.
+func (s *BoltStore) ListTasksByTag(tag string) ([]*Task, error) {
+	return s.filterTasks(func(t *Task) bool { return t.HasTag(tag) && !t.IsArchived() })
+}
+
+// ListArchivedTasks returns archived tasks for a project, or for all projects
+// when projectID is empty
+func (s *BoltStore) ListArchivedTasks(projectID string) ([]*Task, error) {
+	return s.filterTasks(func(t *Task) bool {
+		return t.IsArchived() && (projectID == "" || t.ProjectID == projectID)
+	})
+}
+
+func (s *BoltStore) filterTasks(match func(t *Task) bool) ([]*Task, error) {
+	tasks := []*Task{}
+	err := s.view(func(tx *bolt.Tx) error {
+		all, err := allTasks(tx)
+		for _, t := range all {
+			if match(t) {
+				tasks = append(tasks, t)
+			}
+		}
+		return err
+	})
+	sortByOrder(tasks)
+	return tasks, err
+}
+
+// GetTask retrieves a task by ID
+func (s *BoltStore) GetTask(id string) (*Task, error) {
+	var task *Task
+	err := s.view(func(tx *bolt.Tx) error {
+		var err error
+		task, err = getTask(tx, id)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	if task == nil {
+		return nil, fmt.Errorf("task not found: %s", id)
+	}
+	return task, nil
+}
+
+// UpdateTask updates a task's done status
+func (s *BoltStore) UpdateTask(id string, done bool) error {
+	op := "mark done"
+	if !done {
+		op = "mark not done"
+	}
+	return s.updateTask(id, op, func(t *Task) error {
+		t.markDone(done, time.Now())
+		return nil
+	})
+}
+
+// UpdateTasks marks several tasks done or not done at once
+func (s *BoltStore) UpdateTasks(ids []string, done bool) error {
+	op := fmt.Sprintf("mark %d tasks done", len(ids))
+	if !done {
+		op = fmt.Sprintf("mark %d tasks not done", len(ids))
+	}
+	return s.update(func(tx *bolt.Tx) error {
+		var tasks []*Task
+		for _, id := range ids {
+			task, err := getTask(tx, id)
+			if err != nil {
+				return err
+			}
+			if task == nil {
+				return fmt.Errorf("task not found: %s", id)
+			}
+			tasks = append(tasks, task)
+		}
+
+		return s.journaled(tx, op, nil, ids, func() error {
+			now := time.Now()
+			for _, t := range tasks {
+				t.markDone(done, now)
+				t.touch(now)
+				if err := putJSON(tx.Bucket(tasksBucket), t.ID, t); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+	})
+}
+
+// ArchiveTasks moves done tasks out of the main list
+func (s *BoltStore) ArchiveTasks(ids []string) error {
+	return s.update(func(tx *bolt.Tx) error {
+		var tasks []*Task
+		for _, id := range ids {
+			task, err := getTask(tx, id)
+			if err != nil {
+				return err
+			}
+			if task == nil {
+				return fmt.Errorf("task not found: %s", id)
+			}
+			if err := checkArchivable(task); err != nil {
+				return err
+			}
+			tasks = append(tasks, task)
+		}
+
+		return s.journaled(tx, archiveOp(tasks), nil, ids, func() error {
+			now := time.Now()
+			for _, t := range tasks {
+				t.ArchivedAt = &now
+				if err := putJSON(tx.Bucket(tasksBucket), t.ID, t); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+	})
+}
+
+// SetTaskDueDate sets or clears a task's due date
+func (s *BoltStore) SetTaskDueDate(id string, dueDate *time.Time) error {
+	return s.updateTask(id, "set due date of", func(t *Task) error {
+		if dueDate == nil {
+			t.DueDate = nil
+			return nil
+		}
+		setDueDate(t, *dueDate)
+		return nil
+	})
+}
+
+// SetTaskDueDates sets the due dates of several tasks at once
+func (s *BoltStore) SetTaskDueDates(dates map[string]time.Time) error {
+	ids := sortedKeys(dates)
+	return s.update(func(tx *bolt.Tx) error {
+		var tasks []*Task
+		for _, id := range ids {
+			task, err := getTask(tx, id)
+			if err != nil {
+				return err
+			}
+			if task == nil {
+				return fmt.Errorf("task not found: %s", id)
+			}
+			tasks = append(tasks, task)
+		}
+
+		return s.journaled(tx, fmt.Sprintf("set due dates of %d tasks", len(ids)), nil, ids, func() error {
+			now := time.Now()
+			for _, t := range tasks {
+				setDueDate(t, dates[t.ID])
+				t.touch(now)
+				if err := putJSON(tx.Bucket(tasksBucket), t.ID, t); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+	})
+}
+
+// SetTaskDurations sets the durations of several tasks at once
+func (s *BoltStore) SetTaskDurations(durations map[string]Duration) error {
+	ids := sortedKeys(durations)
+	return s.update(func(tx *bolt.Tx) error {
+		var tasks []*Task
+		for _, id := range ids {
+			task, err := getTask(tx, id)
+			if err != nil {
+				return err
+			}
+			if task == nil {
+				return fmt.Errorf("task not found: %s", id)
+			}
+			tasks = append(tasks, task)
+		}
+
+		return s.journaled(tx, fmt.Sprintf("set durations of %d tasks", len(ids)), nil, ids, func() error {
+			now := time.Now()
+			for _, t := range tasks {
+				t.Duration = durations[t.ID]
+				t.touch(now)
+				if err := putJSON(tx.Bucket(tasksBucket), t.ID, t); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+	})
+}
+
+// ReorderTask moves a task to a position in its project's list
+func (s *BoltStore) ReorderTask(id string, position int) error {
+	return s.update(func(tx *bolt.Tx) error {
+		task, err := getTask(tx, id)
+		if err != nil {
+			return err
+		}
+		if task == nil {
+			return fmt.Errorf("task not found: %s", id)
+		}
+		if task.IsArchived() {
+			return fmt.Errorf("%q is archived", task.Name)
+		}
+		all, err := allTasks(tx)
+		if err != nil {
+			return err
+		}
+		var list []*Task
+		for _, t := range all {
+			if t.ProjectID == task.ProjectID && !t.IsArchived() {
+				list = append(list, t)
+			}
+		}
+		sortByOrder(list)
+
+		ids, orders := reorder(list, id, position)
+		if len(ids) == 0 {
+			return nil
+		}
+		return s.journaled(tx, reorderOp(task), nil, ids, func() error {
+			for _, t := range list {
+				if order, ok := orders[t.ID]; ok {
+					t.Order = order
+					if err := putJSON(tx.Bucket(tasksBucket), t.ID, t); err != nil {
+						return err
+					}
+				}
+			}
+			return nil
+		})
+	})
+}
+
+// Reorganize moves tasks, merges projects, and changes shortcuts as one
+// journal entry; nothing changes if any part is invalid
+func (s *BoltStore) Reorganize(r *Reorg) error {
+	return s.update(func(tx *bolt.Tx) error {
+		projects, err := allProjects(tx)
+		if err != nil {
+			return err
+		}
+		tasks, err := allTasks(tx)
+		if err != nil {
+			return err
+		}
+		c, err := resolveReorg(r, projects, tasks)
+		if err != nil {
+			return err
+		}
+
+		return s.journaled(tx, c.op(), c.projectIDs, c.taskIDs, func() error {
+			now := time.Now()
+			for _, t := range tasks {
+				if projectID, ok := c.moves[t.ID]; ok {
+					t.moveTo(projectID)
+					t.touch(now)
+					if err := putJSON(tx.Bucket(tasksBucket), t.ID, t); err != nil {
+						return err
+					}
+				}
+			}
+			for _, p := range projects {
+				if shortcut, ok := c.shortcuts[p.ID]; ok {
+					p.Shortcut = shortcut
+					if err := putJSON(tx.Bucket(projectsBucket), p.ID, p); err != nil {
+						return err
+					}
+				}
+			}
+			for id := range c.deleted {
+				if err := tx.Bucket(projectsBucket).Delete([]byte(id)); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+	})
+}
+
+// MoveTask moves a task to another project, or to the inbox when projectID
+// is empty, keeping its due date, duration, done status, and everything else
+func (s *BoltStore) MoveTask(id, projectID string) error {
+	return s.update(func(tx *bolt.Tx) error {
+		task, err := getTask(tx, id)
+		if err != nil {
+			return err
+		}
+		if task == nil {
+			return fmt.Errorf("task not found: %s", id)
+		}
+		project, err := getProject(tx, projectID)
+		if err != nil {
+			return err
+		}
+		if project == nil && projectID != "" {
+			return fmt.Errorf("project not found: %s", projectID)
+		}
+		if task.ProjectID == projectID {
+			name := InboxName
+			if project != nil {
+				name = project.Name
+			}
+			return fmt.Errorf("%q is already in %s", task.Name, name)
+		}
+		return s.journaled(tx, fmt.Sprintf("move %q", task.Name), nil, []string{id}, func() error {
+			task.moveTo(projectID)
+			task.touch(time.Now())
+			return putJSON(tx.Bucket(tasksBucket), id, task)
+		})
+	})
+}
+
+// SetTaskDuration sets a task's duration
+func (s *BoltStore) SetTaskDuration(id string, duration Duration) error {
+	return s.updateTask(id, "set duration of", func(t *Task) error {
+		t.Duration = duration
+		return nil
+	})
+}
+
+// SetTaskPriority sets a task's priority (empty clears it)
+func (s *BoltStore) SetTaskPriority(id string, priority Priority) error {
+	return s.updateTask(id, "set priority of", func(t *Task) error {
+		t.Priority = priority
+		return nil
+	})
+}
+
+// SetTaskStatus moves a task to a status; done and not done also mark it so
+func (s *BoltStore) SetTaskStatus(id string, status Status) error {
+	return s.updateTask(id, "set status of", func(t *Task) error {
+		t.setStatus(status, time.Now())
+		return nil
+	})
+}
+
+// SetTaskNote sets a task's note (empty clears it)
+func (s *BoltStore) SetTaskNote(id, note string) error {
+	return s.updateTask(id, "set note of", func(t *Task) error {
+		t.Note = note
+		return nil
+	})
+}
+
+// DeleteTask removes a task
+func (s *BoltStore) DeleteTask(id string) error {
+	return s.update(func(tx *bolt.Tx) error {
+		task, err := getTask(tx, id)
+		if err != nil {
+			return err
+		}
+		if task == nil {
+			return fmt.Errorf("task not found: %s", id)
+		}
+		return s.journaled(tx, fmt.Sprintf("delete task %q", task.Name), nil, []string{id}, func() error {
+			return tx.Bucket(tasksBucket).Delete([]byte(id))
+		})
+	})
+}
+
+// DeleteTasks deletes several tasks as one journaled operation
+func (s *BoltStore) DeleteTasks(ids []string) error {
+	return s.update(func(tx *bolt.Tx) error {
+		for _, id := range ids {
+			task, err := getTask(tx, id)
+			if err != nil {
+				return err
+			}
+			if task == nil {
+				return fmt.Errorf("task not found: %s", id)
+			}
+		}
+		return s.journaled(tx, fmt.Sprintf("delete %d tasks", len(ids)), nil, ids, func() error {
+			for _, id := range ids {
+				if err := tx.Bucket(tasksBucket).Delete([]byte(id)); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+	})
+}
+
+// AddTaskTag adds a tag to a task (no-op if already present)
+func (s *BoltStore) AddTaskTag(id, tag string) error {
+	tag = NormalizeTag(tag)
+	if !tagRegex.MatchString(tag) {
+		return fmt.Errorf("invalid tag: must be 1-30 alphanumeric characters, hyphens, or underscores")
+	}
+
+	task, err := s.GetTask(id)
+	if err != nil {
+		return err
+	}
+	if task.HasTag(tag) {
+		return nil
+	}
+
+	return s.updateTask(id, "tag #"+tag+" on", func(t *Task) error {
+		t.Tags = append(t.Tags, tag)
+		return nil
+	})
+}
+
+// RemoveTaskTag removes a tag from a task
+func (s *BoltStore) RemoveTaskTag(id, tag string) error {
+	tag = NormalizeTag(tag)
+	return s.updateTask(id, "untag #"+tag+" from", func(t *Task) error {
+		for i, existing := range t.Tags {
+			if existing == tag {
+				t.Tags = append(t.Tags[:i], t.Tags[i+1:]...)
+				return nil
+			}
+		}
+		return fmt.Errorf("task %s does not have tag: %s", t.Name, tag)
+	})
+}
+
+// AddTaskDependency records that taskID cannot be done until blockerID is done
+func (s *BoltStore) AddTaskDependency(taskID, blockerID string) error {
+	if taskID == blockerID {
+		return fmt.Errorf("a task cannot block itself")
+	}
+
+	return s.update(func(tx *bolt.Tx) error {
+		task, err := getTask(tx, taskID)
+		if err != nil {
+			return err
+		}
+		if task == nil {
+			return fmt.Errorf("task not found: %s", taskID)
+		}
+		blocker, err := getTask(tx, blockerID)
+		if err != nil {
+			return err
+		}
+		if blocker == nil {
+			return fmt.Errorf("task not found: %s", blockerID)
+		}
+		if task.IsBlockedBy(blockerID) {
+			return nil
+		}
+
+		tasks, err := allTasks(tx)
+		if err != nil {
+			return err
+		}
+		if dependencyPath(tasks, blockerID, taskID) != nil {
+			return fmt.Errorf("dependency cycle: %q already depends on %q", blocker.Name, task.Name)
+		}
+
+		return s.journaled(tx, fmt.Sprintf("add blocker to %q", task.Name), nil, []string{taskID}, func() error {
+			task.BlockedBy = append(task.BlockedBy, blockerID)
+			task.touch(time.Now())
+			return putJSON(tx.Bucket(tasksBucket), taskID, task)
+		})
+	})
+}
+
+// RemoveTaskDependency removes blockerID from taskID's dependencies
+func (s *BoltStore) RemoveTaskDependency(taskID, blockerID string) error {
+	return s.updateTask(taskID, "remove blocker from", func(t *Task) error {
+		for i, id := range t.BlockedBy {
+			if id == blockerID {
+				t.BlockedBy = append(t.BlockedBy[:i], t.BlockedBy[i+1:]...)
+				return nil
+			}
+		}
+		return fmt.Errorf("task %s is not blocked by %s", t.Name, blockerID)
+	})
+}
+
+// Escalate raises the priority of every open task the policy matches, as one
+// journal entry per task
+func (s *BoltStore) Escalate(policy EscalationPolicy, now time.Time) ([]*Escalation, error) {
+	if !policy.Enabled() {
+		return nil, nil
+	}
+
+	var applied []*Escalation
+	err := s.update(func(tx *bolt.Tx) error {
+		tasks, err := allTasks(tx)
+		if err != nil {
+			return err
+		}
+		for _, t := range tasks {
+			to, reason := policy.target(t, now)
+			if to.Rank() <= t.Priority.Rank() {
+				continue
+			}
+
+			task := t
+			e := &Escalation{TaskID: t.ID, TaskName: t.Name, From: t.Priority, To: to, Reason: reason}
+			op := fmt.Sprintf("escalate %q to %s (%s)", t.Name, to, reason)
+			if err := s.journaled(tx, op, nil, []string{t.ID}, func() error {
+				task.Priority = to
+				return putJSON(tx.Bucket(tasksBucket), task.ID, task)
+			}); err != nil {
+				return err
+			}
+			applied = append(applied, e)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return applied, nil
+}
+
+// ImportProject inserts a project with its existing ID and shortcut
+func (s *BoltStore) ImportProject(project *Project) error {
+	return s.update(func(tx *bolt.Tx) error {
+		projects, err := allProjects(tx)
+		if err != nil {
+			return err
+		}
+		for _, p := range projects {
+			if p.ID == project.ID {
+				return fmt.Errorf("project ID already exists: %s", project.ID)
+			}
+			if p.Shortcut == project.Shortcut {
+				return fmt.Errorf("shortcut already in use by project: %s", p.Name)
+			}
+		}
+
+		return s.journaled(tx, fmt.Sprintf("import project %q", project.Name), []string{project.ID}, nil, func() error {
+			return putJSON(tx.Bucket(projectsBucket), project.ID, project)
+		})
+	})
+}
+
+// ImportTask inserts a task with its existing ID into an existing project or the inbox
+func (s *BoltStore) ImportTask(task *Task) error {
+	return s.update(func(tx *bolt.Tx) error {
+		if !hasProject(tx, task.ProjectID) {
+			return fmt.Errorf("project not found: %s", task.ProjectID)
+		}
+		if tx.Bucket(tasksBucket).Get([]byte(task.ID)) != nil {
+			return fmt.Errorf("task ID already exists: %s", task.ID)
+		}
+
+		return s.journaled(tx, fmt.Sprintf("import task %q", task.Name), nil, []string{task.ID}, func() error {
+			return putJSON(tx.Bucket(tasksBucket), task.ID, task)
+		})
+	})
+}
+
+// ReplaceProject overwrites an existing project with the given version
+func (s *BoltStore) ReplaceProject(project *Project) error {
+	return s.update(func(tx *bolt.Tx) error {
+		projects, err := allProjects(tx)
+		if err != nil {
+			return err
+		}
+		for _, p := range projects {
+			if p.ID != project.ID && p.Shortcut == project.Shortcut {
+				return fmt.Errorf("shortcut already in use by project: %s", p.Name)
+			}
+		}
+		if tx.Bucket(projectsBucket).Get([]byte(project.ID)) == nil {
+			return fmt.Errorf("project not found: %s", project.ID)
+		}
+
+		return s.journaled(tx, fmt.Sprintf("replace project %q", project.Name), []string{project.ID}, nil, func() error {
+			return putJSON(tx.Bucket(projectsBucket), project.ID, project)
+		})
+	})
+}
+
+// ReplaceTask overwrites an existing task with the given version
+func (s *BoltStore) ReplaceTask(task *Task) error {
+	return s.update(func(tx *bolt.Tx) error {
+		if !hasProject(tx, task.ProjectID) {
+			return fmt.Errorf("project not found: %s", task.ProjectID)
+		}
+		if tx.Bucket(tasksBucket).Get([]byte(task.ID)) == nil {
+			return fmt.Errorf("task not found: %s", task.ID)
+		}
+
+		return s.journaled(tx, fmt.Sprintf("replace task %q", task.Name), nil, []string{task.ID}, func() error {
+			return putJSON(tx.Bucket(tasksBucket), task.ID, task)
+		})
+	})
+}
+
+// updateMeta loads the list stored under key into list, lets fn change it,
+// and writes it back
+func (s *BoltStore) updateMeta(key string, list any, fn func() error) error {
+	return s.update(func(tx *bolt.Tx) error {
+		meta := tx.Bucket(metaBucket)
+		if _, err := getJSON(meta, key, list); err != nil {
+			return err
+		}
+		if err := fn(); err != nil {
+			return err
+		}
+		return putJSON(meta, key, list)
+	})
+}
+
+// viewMeta loads the list stored under key into list
+func (s *BoltStore) viewMeta(key string, list any) error {
+	return s.view(func(tx *bolt.Tx) error {
+		_, err := getJSON(tx.Bucket(metaBucket), key, list)
+		return err
+	})
+}
+
+// AddConflict queues a conflict for later resolution
+func (s *BoltStore) AddConflict(conflict *Conflict) error {
+	var conflicts []*Conflict
+	return s.updateMeta(metaConflicts, &conflicts, func() error {
+		conflicts = append(conflicts, conflict)
+		return nil
+	})
+}
+
+// ListConflicts returns all queued conflicts, oldest first
+func (s *BoltStore) ListConflicts() ([]*Conflict, error) {
+	conflicts := []*Conflict{}
+	return conflicts, s.viewMeta(metaConflicts, &conflicts)
+}
+
+// DeleteConflict removes a conflict from the queue
+func (s *BoltStore) DeleteConflict(id string) error {
+	var conflicts []*Conflict
+	return s.updateMeta(metaConflicts, &conflicts, func() error {
+		for i, c := range conflicts {
+			if c.ID == id {
+				conflicts = append(conflicts[:i], conflicts[i+1:]...)
+				return nil
+			}
+		}
+		return fmt.Errorf("conflict not found: %s", id)
+	})
+}
+
+// trashTx appends items to the trash within tx
+func trashTx(tx *bolt.Tx, items ...*TrashItem) error {
+	meta := tx.Bucket(metaBucket)
+	var trash []*TrashItem
+	if _, err := getJSON(meta, metaTrash, &trash); err != nil {
+		return err
+	}
+	return putJSON(meta, metaTrash, append(trash, items...))
+}
+
+// inStoreTx reports whether a trash item's project or task exists again
+func inStoreTx(tx *bolt.Tx) func(item *TrashItem) bool {
+	return func(item *TrashItem) bool {
+		if item.Project != nil {
+			return tx.Bucket(projectsBucket).Get([]byte(item.Project.ID)) != nil
+		}
+		return tx.Bucket(tasksBucket).Get([]byte(item.ID())) != nil
+	}
+}
+
+// TrashProject moves a project and its tasks, archived ones included, to
+// the trash
+func (s *BoltStore) TrashProject(id string) error {
+	return s.update(func(tx *bolt.Tx) error {
+		project, err := getProject(tx, id)
+		if err != nil {
+			return err
+		}
+		if project == nil {
+			return fmt.Errorf("project not found: %s", id)
+		}
+		all, err := allTasks(tx)
+		if err != nil {
+			return err
+		}
+		var tasks []*Task
+		for _, t := range all {
+			if t.ProjectID == id {
+				tasks = append(tasks, t)
+			}
+		}
+
+		item := newTrashItem(project, tasks, time.Now())
+		projectIDs, taskIDs := trashIDs(item)
+		return s.journaled(tx, trashOp("delete", item), projectIDs, taskIDs, func() error {
+			if err := tx.Bucket(projectsBucket).Delete([]byte(id)); err != nil {
+				return err
+			}
+			for _, taskID := range taskIDs {
+				if err := tx.Bucket(tasksBucket).Delete([]byte(taskID)); err != nil {
+					return err
+				}
+			}
+			return trashTx(tx, item)
+		})
+	})
+}
+
+// TrashTasks moves tasks to the trash, each as its own item
+func (s *BoltStore) TrashTasks(ids []string) error {
+	return s.update(func(tx *bolt.Tx) error {
+		now := time.Now()
+		var items []*TrashItem
+		for _, id := range ids {
+			task, err := getTask(tx, id)
+			if err != nil {
+				return err
+			}
+			if task == nil {
+				return fmt.Errorf("task not found: %s", id)
+			}
+			items = append(items, newTrashItem(nil, []*Task{task}, now))
+		}
+
+		op := fmt.Sprintf("delete %d tasks", len(ids))
+		if len(items) == 1 {
+			op = trashOp("delete", items[0])
+		}
+		return s.journaled(tx, op, nil, ids, func() error {
+			for _, id := range ids {
+				if err := tx.Bucket(tasksBucket).Delete([]byte(id)); err != nil {
+					return err
+				}
+			}
+			return trashTx(tx, items...)
+		})
+	})
+}
+
+// ListTrash returns the items that can be restored, newest first
+func (s *BoltStore) ListTrash() ([]*TrashItem, error) {
+	var restorable []*TrashItem
+	err := s.view(func(tx *bolt.Tx) error {
+		var trash []*TrashItem
+		if _, err := getJSON(tx.Bucket(metaBucket), metaTrash, &trash); err != nil {
+			return err
+		}
+		restorable = restorableTrash(trash, inStoreTx(tx))
+		return nil
+	})
+	return restorable, err
+}
+
+// RestoreTrash puts the item whose ID starts with idPrefix back
+func (s *BoltStore) RestoreTrash(idPrefix string) (*TrashItem, error) {
+	var restored *TrashItem
+	err := s.update(func(tx *bolt.Tx) error {
+		var trash []*TrashItem
+		if _, err := getJSON(tx.Bucket(metaBucket), metaTrash, &trash); err != nil {
+			return err
+		}
+		item, err := findTrash(restorableTrash(trash, inStoreTx(tx)), idPrefix)
+		if err != nil {
+			return err
+		}
+		projects, err := allProjects(tx)
+		if err != nil {
+			return err
+		}
+		project, tasks := restoredEntities(item, func(shortcut string) bool {
+			return findProject(projects, func(p *Project) bool { return p.Shortcut == shortcut }) != nil
+		}, func(id string) bool { return hasProject(tx, id) })
+
+		projectIDs, taskIDs := trashIDs(item)
+		restored = &TrashItem{Kind: item.Kind, DeletedAt: item.DeletedAt, Project: project, Tasks: tasks}
+		return s.journaled(tx, trashOp("restore", item), projectIDs, taskIDs, func() error {
+			if project != nil {
+				if err := putJSON(tx.Bucket(projectsBucket), project.ID, project); err != nil {
+					return err
+				}
+			}
+			for _, t := range tasks {
+				if err := putJSON(tx.Bucket(tasksBucket), t.ID, t); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return restored, nil
+}
+
+// PurgeTrash permanently removes the items deleted before the given time
+func (s *BoltStore) PurgeTrash(before time.Time) (int, error) {
+	purged := 0
+	err := s.update(func(tx *bolt.Tx) error {
+		meta := tx.Bucket(metaBucket)
+		var trash []*TrashItem
+		if _, err := getJSON(meta, metaTrash, &trash); err != nil {
+			return err
+		}
+		var kept []*TrashItem
+		kept, purged = keepTrash(trash, before, inStoreTx(tx))
+		if len(kept) == len(trash) {
+			return nil
+		}
+		return putJSON(meta, metaTrash, kept)
+	})
+	return purged, err
+}
+
+// StartTimer starts a timer on a task. Only one timer can run at a time.
+func (s *BoltStore) StartTimer(taskID string) (*TimeEntry, error) {
+	entry := &TimeEntry{
+		ID:     generateUUID(),
+		TaskID: taskID,
+		Start:  time.Now(),
+	}
+
+	err := s.update(func(tx *bolt.Tx) error {
+		if tx.Bucket(tasksBucket).Get([]byte(taskID)) == nil {
+			return fmt.Errorf("task not found: %s", taskID)
+		}
+
+		meta := tx.Bucket(metaBucket)
+		var entries []*TimeEntry
+		if _, err := getJSON(meta, metaTimeEntries, &entries); err != nil {
+			return err
+		}
+		for _, e := range entries {
+			if e.Running() {
+				name := e.TaskID
+				if t, err := getTask(tx, e.TaskID); err == nil && t != nil {
+					name = t.Name
+				}
+				return fmt.Errorf("a timer is already running for %s", name)
+			}
+		}
+		return putJSON(meta, metaTimeEntries, append(entries, entry))
+	})
+	if err != nil {
+		return nil, err
+	}
+	return entry, nil
+}
+
+// StopTimer stops the running timer and returns the finished entry
+func (s *BoltStore) StopTimer() (*TimeEntry, error) {
+	var stopped *TimeEntry
+	var entries []*TimeEntry
+	err := s.updateMeta(metaTimeEntries, &entries, func() error {
+		for _, e := range entries {
+			if e.Running() {
+				now := time.Now()
+				e.End = &now
+				stopped = e
+				return nil
+			}
+		}
+		return fmt.Errorf("no timer is running")
+	})
+	if err != nil {
+		return nil, err
+	}
+	return stopped, nil
+}
+
+// ActiveTimer returns the running timer, or nil if none is running
+func (s *BoltStore) ActiveTimer() (*TimeEntry, error) {
+	entries, err := s.ListTimeEntries()
+	if err != nil {
+		return nil, err
+	}
+	for _, e := range entries {
+		if e.Running() {
+			return e, nil
+		}
+	}
+	return nil, nil
+}
+
+// ListTimeEntries returns all time entries, oldest first
+func (s *BoltStore) ListTimeEntries() ([]*TimeEntry, error) {
+	entries := []*TimeEntry{}
+	return entries, s.viewMeta(metaTimeEntries, &entries)
+}
+
+// CreateShare mints a read-only share link for a project that expires after ttl
+func (s *BoltStore) CreateShare(projectID string, ttl time.Duration) (*Share, error) {
+	if _, err := s.GetProject(projectID); err != nil {
+		return nil, err
+	}
+	if ttl <= 0 {
+		return nil, fmt.Errorf("share lifetime must be positive")
+	}
+
+	now := time.Now()
+	share := &Share{
+		Token:     newShareToken(),
+		ProjectID: projectID,
+		CreatedAt: now,
+		ExpiresAt: now.Add(ttl),
+	}
+
+	var shares []*Share
+	err := s.updateMeta(metaShares, &shares, func() error {
+		// Drop expired shares while we're here so the list doesn't grow forever
+		active := shares[:0]
+		for _, sh := range shares {
+			if !sh.Expired(now) {
+				active = append(active, sh)
+			}
+		}
+		shares = append(active, share)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return share, nil
+}
+
+// GetShare returns the share for a token, or an error if it is unknown or expired
+func (s *BoltStore) GetShare(token string) (*Share, error) {
+	var shares []*Share
+	if err := s.viewMeta(metaShares, &shares); err != nil {
+		return nil, err
+	}
+	for _, sh := range shares {
+		if sh.Token == token {
+			if sh.Expired(time.Now()) {
+				return nil, fmt.Errorf("share link expired")
+			}
+			return sh, nil
+		}
+	}
+	return nil, fmt.Errorf("share not found")
+}
+
+// ListShares returns all unexpired shares, oldest first
+func (s *BoltStore) ListShares() ([]*Share, error) {
+	var shares []*Share
+	if err := s.viewMeta(metaShares, &shares); err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	var active []*Share
+	for _, sh := range shares {
+		if !sh.Expired(now) {
+			active = append(active, sh)
+		}
+	}
+	return active, nil
+}
+
+// DeleteShare revokes a share; the token may be abbreviated to a unique prefix
+func (s *BoltStore) DeleteShare(token string) error {
+	var shares []*Share
+	return s.updateMeta(metaShares, &shares, func() error {
+		match := -1
+		for i, sh := range shares {
+			if sh.Token == token || (len(token) >= 6 && len(sh.Token) > len(token) && strings.HasPrefix(sh.Token, token)) {
+				if match >= 0 {
+					return fmt.Errorf("ambiguous share token: %s", token)
+				}
+				match = i
+			}
+		}
+		if match < 0 {
+			return fmt.Errorf("share not found: %s", token)
+		}
+		shares = append(shares[:match], shares[match+1:]...)
+		return nil
+	})
+}
+
+// Undo reverses the most recent journaled operation
+func (s *BoltStore) Undo() (*JournalEntry, error) {
+	return s.replay(metaJournal, metaRedo, "nothing to undo", func(e *JournalEntry) *JournalState { return e.Before })
+}
+
+// Redo re-applies the most recently undone operation
+func (s *BoltStore) Redo() (*JournalEntry, error) {
+	return s.replay(metaRedo, metaJournal, "nothing to redo", func(e *JournalEntry) *JournalState { return e.After })
+}
+
+// replay pops the newest entry from one stack, restores the state chosen by
+// pick, and pushes the entry onto the other stack
+func (s *BoltStore) replay(from, to, emptyMsg string, pick func(e *JournalEntry) *JournalState) (*JournalEntry, error) {
+	if s.tx != nil {
+		return nil, errInTransaction
+	}
+	var entry *JournalEntry
+	err := s.update(func(tx *bolt.Tx) error {
+		meta := tx.Bucket(metaBucket)
+
+		var source, target []*JournalEntry
+		if _, err := getJSON(meta, from, &source); err != nil {
+			return err
+		}
+		if len(source) == 0 {
+			return fmt.Errorf("%s", emptyMsg)
+		}
+		if _, err := getJSON(meta, to, &target); err != nil {
+			return err
+		}
+
+		entry = source[len(source)-1]
+		if err := restoreTx(tx, entry, pick(entry)); err != nil {
+			return err
+		}
+
+		if err := putJSON(meta, from, source[:len(source)-1]); err != nil {
+			return err
+		}
+		return putJSON(meta, to, append(target, entry))
+	})
+	if err != nil {
+		return nil, err
+	}
+	return entry, nil
+}
+
+// Close closes the database
+// Size returns the size of the database file in bytes
+func (s *BoltStore) Size() (int64, error) {
+	info, err := os.Stat(s.db.Path())
+	if err != nil {
+		return 0, err
+	}
+	return info.Size(), nil
+}
+
+// Compact drops the undo and redo history. bbolt keeps freed pages in the
+// file, so the live data is then copied into a fresh file that replaces it.
+func (s *BoltStore) Compact() (*Compaction, error) {
+	if s.tx != nil {
+		return nil, errInTransaction
+	}
+	c := &Compaction{}
+	var err error
+	if c.SizeBefore, err = s.Size(); err != nil {
+		return nil, err
+	}
+
+	err = s.update(func(tx *bolt.Tx) error {
+		meta := tx.Bucket(metaBucket)
+		for _, key := range []string{metaJournal, metaRedo} {
+			var entries []*JournalEntry
+			if _, err := getJSON(meta, key, &entries); err != nil {
+				return err
+			}
+			c.UndoEntries += len(entries)
+			if err := meta.Delete([]byte(key)); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	path := s.db.Path()
+	tmp := path + ".compact"
+	os.Remove(tmp)
+	dst, err := bolt.Open(tmp, 0644, &bolt.Options{Timeout: time.Second})
+	if err != nil {
+		return nil, err
+	}
+	if err := bolt.Compact(dst, s.db, 0); err != nil {
+		dst.Close()
+		os.Remove(tmp)
+		return nil, err
+	}
+	if err := dst.Close(); err != nil {
+		os.Remove(tmp)
+		return nil, err
+	}
+
+	// The store can't be used while the file is swapped, so reopen whichever
+	// file is in place even if the swap fails
+	s.db.Close()
+	renameErr := os.Rename(tmp, path)
+	if s.db, err = bolt.Open(path, 0644, &bolt.Options{Timeout: time.Second}); err != nil {
+		return nil, fmt.Errorf("failed to reopen database: %w", err)
+	}
+	if renameErr != nil {
+		os.Remove(tmp)
+		return nil, renameErr
+	}
+	if c.SizeAfter, err = s.Size(); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+func (s *BoltStore) Close() error {
+	if s.tx != nil {
+		return errInTransaction
+	}
+	return s.db.Close()
+}
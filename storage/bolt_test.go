@@ -0,0 +1,519 @@
+package storage
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestBackends runs the same operations against every Store implementation
+// testBackends opens an empty store of each kind
+var testBackends = map[string]func(t *testing.T) Store{
+	"json": func(t *testing.T) Store { return newTestStore(t) },
+	"bolt": func(t *testing.T) Store {
+		store, err := NewBoltStore(filepath.Join(t.TempDir(), "test.db"))
+		if err != nil {
+			t.Fatalf("Failed to create store: %v", err)
+		}
+		t.Cleanup(func() { store.Close() })
+		return store
+	},
+}
+
+func TestBackends(t *testing.T) {
+	for name, open := range testBackends {
+		t.Run(name, func(t *testing.T) {
+			store := open(t)
+
+			work, _ := store.CreateProject("Work")
+			home, _ := store.CreateProject("Home")
+			report, err := store.CreateTask(work.ID, "Write report")
+			if err != nil {
+				t.Fatalf("Failed to create task: %v", err)
+			}
+			review, _ := store.CreateTask(work.ID, "Review PR")
+			store.CreateTask(home.ID, "Mow lawn")
+
+			// Listing keeps creation order
+			projects, _ := store.ListProjects()
+			if len(projects) != 2 || projects[0].Name != "Work" || projects[1].Name != "Home" {
+				t.Errorf("Expected Work, Home in order, got %v", projects)
+			}
+			tasks, _ := store.ListTasks(work.ID)
+			if len(tasks) != 2 || tasks[0].ID != report.ID || tasks[1].ID != review.ID {
+				t.Errorf("Expected 2 tasks in creation order, got %v", tasks)
+			}
+
+			// ID resolution by shortcut and prefix
+			if id, err := store.ResolveProjectID(work.Shortcut); err != nil || id != work.ID {
+				t.Errorf("Expected shortcut to resolve, got %s, %v", id, err)
+			}
+			if id, err := store.ResolveProjectID(strings.ToUpper(work.Name)); err != nil || id != work.ID {
+				t.Errorf("Expected name to resolve, got %s, %v", id, err)
+			}
+			if id, err := store.ResolveTaskID(report.ID[:8]); err != nil || id != report.ID {
+				t.Errorf("Expected prefix to resolve, got %s, %v", id, err)
+			}
+			if _, err := store.ResolveTaskID(report.ID[:5]); err == nil {
+				t.Error("Expected short prefix to be rejected")
+			}
+
+			// Mutations and undo/redo
+			due := time.Date(2025, 3, 14, 0, 0, 0, 0, time.UTC)
+			store.SetTaskDueDate(report.ID, &due)
+			store.AddTaskTag(report.ID, "#Urgent")
+			store.UpdateTask(report.ID, true)
+			got, _ := store.GetTask(report.ID)
+			if !got.Done || !got.HasTag("urgent") || got.DueDate == nil || !got.DueDate.Equal(due) {
+				t.Errorf("Expected task updated, got %+v", got)
+			}
+			if got.CompletedAt == nil || got.CompletedAt.Before(got.CreatedAt) {
+				t.Errorf("Expected completion time recorded, got %v", got.CompletedAt)
+			}
+			if got.UpdatedAt == nil || got.LastActivity().Before(got.CreatedAt) {
+				t.Errorf("Expected edit time recorded, got %v", got.UpdatedAt)
+			}
+			if fresh, _ := store.GetTask(review.ID); fresh.UpdatedAt != nil || !fresh.LastActivity().Equal(fresh.CreatedAt) {
+				t.Errorf("Expected unedited task to fall back to its creation time, got %v", fresh.UpdatedAt)
+			}
+
+			entry, err := store.Undo()
+			if err != nil || entry.Op != `mark done "Write report"` {
+				t.Errorf("Expected undo of mark done, got %v, %v", entry, err)
+			}
+			if got, _ := store.GetTask(report.ID); got.Done {
+				t.Error("Expected undo to reopen task")
+			}
+			store.Redo()
+			if got, _ := store.GetTask(report.ID); !got.Done {
+				t.Error("Expected redo to complete task")
+			}
+
+			// Batched due dates are one journal entry
+			store.SetTaskDueDates(map[string]time.Time{report.ID: due.AddDate(0, 0, 1), review.ID: due})
+			if got, _ := store.GetTask(report.ID); got.DueDate == nil || !got.DueDate.Equal(due.AddDate(0, 0, 1)) || got.Postponed != 1 {
+				t.Errorf("Expected batched due date and postponement, got %+v", got)
+			}
+			if entry, err := store.Undo(); err != nil || entry.Op != "set due dates of 2 tasks" {
+				t.Errorf("Expected undo of batch, got %v, %v", entry, err)
+			}
+			if got, _ := store.GetTask(review.ID); got.DueDate != nil {
+				t.Error("Expected undo to clear the batched due date")
+			}
+
+			// So are batched durations
+			store.SetTaskDurations(map[string]Duration{report.ID: 45, review.ID: Duration2h})
+			if got, _ := store.GetTask(review.ID); got.Duration != Duration2h {
+				t.Errorf("Expected batched duration, got %v", got.Duration)
+			}
+			if entry, err := store.Undo(); err != nil || entry.Op != "set durations of 2 tasks" {
+				t.Errorf("Expected undo of duration batch, got %v, %v", entry, err)
+			}
+
+			// Marking several tasks is one entry
+			if err := store.UpdateTasks([]string{report.ID, review.ID}, true); err != nil {
+				t.Fatalf("Failed to mark tasks: %v", err)
+			}
+			if got, _ := store.GetTask(review.ID); !got.Done || got.CompletedAt == nil {
+				t.Error("Expected the tasks done")
+			}
+			if entry, err := store.Undo(); err != nil || entry.Op != "mark 2 tasks done" {
+				t.Errorf("Expected undo of marking, got %v, %v", entry, err)
+			}
+			if got, _ := store.GetTask(review.ID); got.Done {
+				t.Error("Expected undo to reopen the task")
+			}
+
+			// A zero time in a due date batch clears the date
+			store.SetTaskDueDates(map[string]time.Time{review.ID: time.Date(2026, 3, 2, 0, 0, 0, 0, time.UTC)})
+			store.SetTaskDueDates(map[string]time.Time{review.ID: {}})
+			if got, _ := store.GetTask(review.ID); got.DueDate != nil {
+				t.Errorf("Expected the due date cleared, got %v", got.DueDate)
+			}
+			store.Undo()
+			store.Undo()
+
+			// Project defaults are saved and undone like other changes
+			store.SetProjectDefaults(work.ID, 30, "+7d")
+			if got, _ := store.GetProject(work.ID); got.DefaultDuration != 30 || got.DefaultDue != "+7d" {
+				t.Errorf("Expected project defaults, got %v, %q", got.DefaultDuration, got.DefaultDue)
+			}
+			if _, err := store.Undo(); err != nil {
+				t.Errorf("Expected undo of project defaults, got %v", err)
+			}
+			if got, _ := store.GetProject(work.ID); got.DefaultDuration != 0 || got.DefaultDue != "" {
+				t.Errorf("Expected undo to clear project defaults, got %v, %q", got.DefaultDuration, got.DefaultDue)
+			}
+
+			// Batches keep their order and undo as one entry
+			batch, err := store.CreateTasks([]*Task{{ProjectID: home.ID, Name: "Rake"}, {ProjectID: home.ID, Name: "Bag", Duration: Duration15m}})
+			if err != nil {
+				t.Fatalf("Failed to create batch: %v", err)
+			}
+			if homeTasks, _ := store.ListTasks(home.ID); len(homeTasks) != 3 || homeTasks[1].ID != batch[0].ID || homeTasks[2].Duration != Duration15m {
+				t.Errorf("Expected batch after Mow lawn in order, got %v", homeTasks)
+			}
+			if entry, err := store.Undo(); err != nil || entry.Op != "create 2 tasks" {
+				t.Errorf("Expected undo of batch, got %v, %v", entry, err)
+			}
+			if _, err := store.CreateTasks([]*Task{{ProjectID: "missing", Name: "Nope"}}); err == nil {
+				t.Error("Expected batch in a missing project to fail")
+			}
+
+			// Notes are journaled like other fields
+			store.SetTaskNote(review.ID, "Check the tests")
+			if got, _ := store.GetTask(review.ID); got.Note != "Check the tests" {
+				t.Errorf("Expected note set, got %q", got.Note)
+			}
+			if entry, err := store.Undo(); err != nil || entry.Op != `set note of "Review PR"` {
+				t.Errorf("Expected undo of note, got %v, %v", entry, err)
+			}
+
+			// Deleting a project removes its tasks, and undo brings them back
+			store.DeleteProject(work.ID)
+			if all, _ := store.ListAllTasks(); len(all) != 1 {
+				t.Errorf("Expected 1 task after delete, got %d", len(all))
+			}
+			store.Undo()
+			if all, _ := store.ListAllTasks(); len(all) != 3 {
+				t.Errorf("Expected 3 tasks after undo, got %d", len(all))
+			}
+
+			// Dependencies reject cycles
+			if err := store.AddTaskDependency(review.ID, report.ID); err != nil {
+				t.Errorf("Failed to add dependency: %v", err)
+			}
+			if err := store.AddTaskDependency(report.ID, review.ID); err == nil {
+				t.Error("Expected cycle to be rejected")
+			}
+
+			// Archiving hides done tasks from listings, and reopening brings them back
+			if err := store.ArchiveTasks([]string{review.ID}); err == nil {
+				t.Error("Expected archiving an open task to be rejected")
+			}
+			if err := store.ArchiveTasks([]string{report.ID}); err != nil {
+				t.Errorf("Failed to archive: %v", err)
+			}
+			if tasks, _ := store.ListTasks(work.ID); len(tasks) != 1 || tasks[0].ID != review.ID {
+				t.Errorf("Expected archived task hidden, got %v", tasks)
+			}
+			if archived, _ := store.ListArchivedTasks(work.ID); len(archived) != 1 || archived[0].ID != report.ID {
+				t.Errorf("Expected 1 archived task, got %v", archived)
+			}
+			store.UpdateTask(report.ID, false)
+			if got, _ := store.GetTask(report.ID); got.IsArchived() || got.CompletedAt != nil {
+				t.Error("Expected reopened task to leave the archive and lose its completion time")
+			}
+			store.Undo()
+			if archived, _ := store.ListArchivedTasks(""); len(archived) != 1 {
+				t.Errorf("Expected undo to re-archive the task, got %d", len(archived))
+			}
+
+			// Side data
+			if _, err := store.StartTimer(review.ID); err != nil {
+				t.Errorf("Failed to start timer: %v", err)
+			}
+			if _, err := store.StartTimer(report.ID); err == nil {
+				t.Error("Expected second timer to be rejected")
+			}
+			if active, _ := store.ActiveTimer(); active == nil || active.TaskID != review.ID {
+				t.Errorf("Expected running timer, got %v", active)
+			}
+			store.StopTimer()
+
+			share, err := store.CreateShare(home.ID, time.Hour)
+			if err != nil {
+				t.Fatalf("Failed to create share: %v", err)
+			}
+			if _, err := store.GetShare(share.Token); err != nil {
+				t.Errorf("Expected share, got %v", err)
+			}
+			if err := store.DeleteShare(share.Token[:8]); err != nil {
+				t.Errorf("Failed to delete share: %v", err)
+			}
+
+			store.AddConflict(NewConflict("test", nil, report))
+			if conflicts, _ := store.ListConflicts(); len(conflicts) != 1 {
+				t.Errorf("Expected 1 conflict, got %d", len(conflicts))
+			}
+
+			// Reorganizing is all or nothing, and one undo reverts it
+			misc, _ := store.CreateProject("Misc")
+			errands, _ := store.CreateProject("Errands")
+			stamps, _ := store.CreateTask(misc.ID, "Buy stamps")
+			gutters, _ := store.CreateTask(misc.ID, "Clean gutters")
+			bad := &Reorg{Merges: map[string]string{misc.ID: errands.ID}, Shortcuts: map[string]string{home.ID: "all"}}
+			if err := store.Reorganize(bad); err == nil {
+				t.Error("Expected reserved shortcut to fail the reorg")
+			}
+			if _, err := store.GetProject(misc.ID); err != nil {
+				t.Error("Expected failed reorg to change nothing")
+			}
+			reorg := &Reorg{
+				Moves:     map[string]string{gutters.ID: home.ID},
+				Merges:    map[string]string{misc.ID: errands.ID},
+				Shortcuts: map[string]string{errands.ID: "errands"},
+			}
+			if err := store.Reorganize(reorg); err != nil {
+				t.Fatalf("Failed to reorganize: %v", err)
+			}
+			if got, _ := store.GetTask(stamps.ID); got.ProjectID != errands.ID {
+				t.Error("Expected merged project's task in Errands")
+			}
+			if got, _ := store.GetTask(gutters.ID); got.ProjectID != home.ID {
+				t.Error("Expected explicitly moved task in Home")
+			}
+			if _, err := store.GetProject(misc.ID); err == nil {
+				t.Error("Expected merged project to be deleted")
+			}
+			if id, _ := store.ResolveProjectID("errands"); id != errands.ID {
+				t.Error("Expected new shortcut to resolve")
+			}
+			if entry, err := store.Undo(); err != nil || entry.Op != "reorganize projects (2 tasks moved, 1 projects merged, 1 shortcuts changed)" {
+				t.Errorf("Expected one journal entry for the reorg, got %v, %v", entry, err)
+			}
+			if got, _ := store.GetTask(gutters.ID); got.ProjectID != misc.ID {
+				t.Error("Expected undo to move the task back")
+			}
+			if _, err := store.GetProject(misc.ID); err != nil {
+				t.Error("Expected undo to restore the merged project")
+			}
+
+			// Moving a task keeps everything but the project
+			moveDue := time.Date(2030, 1, 2, 0, 0, 0, 0, time.UTC)
+			store.SetTaskDueDate(gutters.ID, &moveDue)
+			store.SetTaskDuration(gutters.ID, Duration2h)
+			if err := store.MoveTask(gutters.ID, errands.ID); err != nil {
+				t.Fatalf("Failed to move task: %v", err)
+			}
+			if got, _ := store.GetTask(gutters.ID); got.ProjectID != errands.ID || got.DueDate == nil || !got.DueDate.Equal(moveDue) || got.Duration != Duration2h {
+				t.Errorf("Expected task moved with its due date and duration, got %+v", got)
+			}
+			if err := store.MoveTask(gutters.ID, errands.ID); err == nil || !strings.Contains(err.Error(), "already in Errands") {
+				t.Errorf("Expected already-in error, got %v", err)
+			}
+			if err := store.MoveTask(gutters.ID, "missing"); err == nil {
+				t.Error("Expected missing project error")
+			}
+			if entry, err := store.Undo(); err != nil || entry.Op != `move "Clean gutters"` {
+				t.Errorf("Expected one journal entry for the move, got %v, %v", entry, err)
+			}
+			if got, _ := store.GetTask(gutters.ID); got.ProjectID != misc.ID {
+				t.Error("Expected undo to move the task back")
+			}
+
+			// Statuses keep Done in step, and reopening a done task makes it todo
+			if err := store.SetTaskStatus(gutters.ID, StatusBlocked); err != nil {
+				t.Fatalf("Failed to set status: %v", err)
+			}
+			if got, _ := store.GetTask(gutters.ID); got.CurrentStatus() != StatusBlocked || got.Done {
+				t.Errorf("Expected blocked and not done, got %+v", got)
+			}
+			store.SetTaskStatus(gutters.ID, StatusDone)
+			if got, _ := store.GetTask(gutters.ID); !got.Done || got.CompletedAt == nil {
+				t.Errorf("Expected done status to mark the task done, got %+v", got)
+			}
+			store.UpdateTask(gutters.ID, false)
+			if got, _ := store.GetTask(gutters.ID); got.CurrentStatus() != StatusTodo || got.CompletedAt != nil {
+				t.Errorf("Expected reopened task todo, got %+v", got)
+			}
+			store.Undo()
+			if entry, err := store.Undo(); err != nil || entry.Op != `set status of "Clean gutters"` {
+				t.Errorf("Expected a journal entry for the status, got %v, %v", entry, err)
+			}
+			store.Undo()
+
+			// Tasks with no project go in the inbox until they're moved
+			inbox, err := store.CreateTask("", "Call dentist")
+			if err != nil {
+				t.Fatalf("Failed to create inbox task: %v", err)
+			}
+			if tasks, _ := store.ListTasks(""); len(tasks) != 1 || tasks[0].ID != inbox.ID {
+				t.Errorf("Expected the inbox task listed, got %v", tasks)
+			}
+			if err := store.MoveTask(inbox.ID, home.ID); err != nil {
+				t.Fatalf("Failed to move inbox task: %v", err)
+			}
+			if tasks, _ := store.ListTasks(""); len(tasks) != 0 {
+				t.Errorf("Expected the inbox empty after the move, got %v", tasks)
+			}
+			if err := store.MoveTask(inbox.ID, ""); err != nil {
+				t.Errorf("Expected a move back to the inbox, got %v", err)
+			}
+			if err := store.MoveTask(inbox.ID, ""); err == nil || !strings.Contains(err.Error(), "already in Inbox") {
+				t.Errorf("Expected already in Inbox, got %v", err)
+			}
+			store.DeleteTask(inbox.ID)
+
+			// Old completed tasks move to an archive file in one journal entry
+			store.UpdateTask(stamps.ID, true)
+			store.UpdateTask(gutters.ID, true)
+			archivePath := filepath.Join(t.TempDir(), "completed.json")
+			archived, err := ArchiveCompletedTasks(store, time.Now().Add(time.Minute), archivePath)
+			if err != nil || len(archived) < 2 {
+				t.Fatalf("Expected the done tasks archived, got %d, %v", len(archived), err)
+			}
+			if _, err := store.GetTask(stamps.ID); err == nil {
+				t.Error("Expected archived task deleted from the store")
+			}
+			if data, _ := os.ReadFile(archivePath); !strings.Contains(string(data), "Buy stamps") {
+				t.Errorf("Expected archived task in the file, got: %s", data)
+			}
+			if _, err := ArchiveCompletedTasks(store, time.Now().Add(time.Minute), archivePath); err != nil {
+				t.Errorf("Expected nothing left to archive, got %v", err)
+			}
+			if entry, err := store.Undo(); err != nil || entry.Op != fmt.Sprintf("delete %d tasks", len(archived)) {
+				t.Errorf("Expected one journal entry for the delete, got %v, %v", entry, err)
+			}
+			if _, err := store.GetTask(stamps.ID); err != nil {
+				t.Error("Expected undo to restore the archived task")
+			}
+
+			// Compaction drops the undo history and keeps the data
+			compaction, err := store.Compact()
+			if err != nil || compaction.UndoEntries == 0 || compaction.SizeAfter <= 0 {
+				t.Fatalf("Expected undo entries dropped, got %+v, %v", compaction, err)
+			}
+			if _, err := store.Undo(); err == nil {
+				t.Error("Expected nothing to undo after compaction")
+			}
+			if _, err := store.Redo(); err == nil {
+				t.Error("Expected nothing to redo after compaction")
+			}
+			if got, err := store.GetTask(stamps.ID); err != nil || got.Name != "Buy stamps" {
+				t.Errorf("Expected tasks kept after compaction, got %v, %v", got, err)
+			}
+			if size, err := store.Size(); err != nil || size != compaction.SizeAfter {
+				t.Errorf("Expected size %d, got %d, %v", compaction.SizeAfter, size, err)
+			}
+		})
+	}
+}
+
+func TestListTasksByTag(t *testing.T) {
+	for name, open := range testBackends {
+		t.Run(name, func(t *testing.T) {
+			store := open(t)
+
+			work, _ := store.CreateProject("Work")
+			home, _ := store.CreateProject("Home")
+			report, _ := store.CreateTask(work.ID, "Write report")
+			milk, _ := store.CreateTask(home.ID, "Buy milk")
+			lawn, _ := store.CreateTask(home.ID, "Mow lawn")
+			old, _ := store.CreateTask(home.ID, "Old errand")
+			inbox, _ := store.CreateTask("", "Call bank")
+
+			tag := func(id string, tags ...string) {
+				for _, tag := range tags {
+					if err := store.AddTaskTag(id, tag); err != nil {
+						t.Fatalf("Failed to tag: %v", err)
+					}
+				}
+			}
+			// Tags differing only in case are one tag
+			tag(report.ID, "Urgent", "work")
+			tag(milk.ID, "errands", "URGENT", "urgent")
+			tag(lawn.ID, "errands", "weekend")
+			tag(old.ID, "errands")
+			tag(inbox.ID, "#Errands")
+			store.UpdateTask(old.ID, true)
+			store.ArchiveTasks([]string{old.ID})
+
+			if task, _ := store.GetTask(milk.ID); len(task.Tags) != 2 || task.Tags[1] != "urgent" {
+				t.Errorf("Expected errands and urgent once each, got %v", task.Tags)
+			}
+
+			names := func(query string) string {
+				tasks, err := store.ListTasksByTag(query)
+				if err != nil {
+					t.Fatalf("ListTasksByTag(%q): %v", query, err)
+				}
+				var got []string
+				for _, task := range tasks {
+					got = append(got, task.Name)
+				}
+				return strings.Join(got, ", ")
+			}
+			for _, tc := range []struct {
+				query string
+				want  string
+			}{
+				{"urgent", "Write report, Buy milk"},
+				{"URGENT", "Write report, Buy milk"},
+				{"#Urgent", "Write report, Buy milk"},
+				{"errands", "Buy milk, Mow lawn, Call bank"}, // across projects and the inbox, not archived
+				{"Errands", "Buy milk, Mow lawn, Call bank"},
+				{"weekend", "Mow lawn"},
+				{"work", "Write report"},
+				{"errand", ""},
+				{"missing", ""},
+			} {
+				if got := names(tc.query); got != tc.want {
+					t.Errorf("Tag %q: expected [%s], got [%s]", tc.query, tc.want, got)
+				}
+			}
+
+			// Each listing sees the latest tags
+			store.RemoveTaskTag(milk.ID, "Errands")
+			tag(report.ID, "errands")
+			if got := names("errands"); got != "Write report, Mow lawn, Call bank" {
+				t.Errorf("Expected tag changes reflected, got [%s]", got)
+			}
+		})
+	}
+}
+
+func TestBoltMigrateFromJSON(t *testing.T) {
+	dir := t.TempDir()
+	jsonPath := filepath.Join(dir, "twooms.json")
+
+	source, err := NewJSONStore(jsonPath)
+	if err != nil {
+		t.Fatalf("Failed to create JSON store: %v", err)
+	}
+	project, _ := source.CreateProject("Work")
+	task, _ := source.CreateTask(project.ID, "Write report")
+	source.StartTimer(task.ID)
+
+	dbPath := filepath.Join(dir, "twooms.db")
+	store, err := NewBoltStore(dbPath)
+	if err != nil {
+		t.Fatalf("Failed to create bolt store: %v", err)
+	}
+
+	migrated, err := store.MigrateFromJSON(jsonPath)
+	if err != nil || !migrated {
+		t.Fatalf("Expected migration, got %v, %v", migrated, err)
+	}
+	if got, err := store.GetTask(task.ID); err != nil || got.Name != "Write report" {
+		t.Errorf("Expected migrated task, got %v, %v", got, err)
+	}
+	if active, _ := store.ActiveTimer(); active == nil {
+		t.Error("Expected running timer to carry over")
+	}
+	// Journal carries over, so the migrated history can still be undone
+	if entry, err := store.Undo(); err != nil || entry.Op != `create task "Write report"` {
+		t.Errorf("Expected journal to carry over, got %v, %v", entry, err)
+	}
+	store.Close()
+
+	// Data persists, and migration only runs once
+	store, err = NewBoltStore(dbPath)
+	if err != nil {
+		t.Fatalf("Failed to reopen bolt store: %v", err)
+	}
+	defer store.Close()
+
+	if migrated, _ := store.MigrateFromJSON(jsonPath); migrated {
+		t.Error("Expected migration to run only once")
+	}
+	if tasks, _ := store.ListAllTasks(); len(tasks) != 0 {
+		t.Errorf("Expected undone task to stay deleted, got %d tasks", len(tasks))
+	}
+	if _, err := store.GetProject(project.ID); err != nil {
+		t.Errorf("Expected project to persist: %v", err)
+	}
+}
@@ -0,0 +1,431 @@
+package storage
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// maxOccurrences stops expanding a recurring event that never reaches the
+// planning window, e.g. a daily rule started decades ago
+const maxOccurrences = 20000
+
+// TimeSpan is a stretch of time from Start up to End
+type TimeSpan struct {
+	Start time.Time
+	End   time.Time
+}
+
+// Minutes returns the span's length in whole minutes
+func (s TimeSpan) Minutes() int {
+	return int(s.End.Sub(s.Start).Minutes())
+}
+
+// Busy is a calendar event that takes up time
+type Busy struct {
+	TimeSpan
+	Summary string
+}
+
+// icsEvent is the part of a VEVENT that matters for busy times
+type icsEvent struct {
+	uid          string
+	summary      string
+	start        time.Time
+	allDay       bool
+	length       time.Duration
+	rrule        map[string]string
+	exdates      []time.Time
+	recurrenceID *time.Time
+	free         bool // TRANSP:TRANSPARENT or STATUS:CANCELLED
+}
+
+// ParseBusyICS returns the events in an iCalendar file that overlap from..to
+// and take up time, in start order. Cancelled and transparent ("free")
+// events are skipped. Recurring events are expanded for DAILY, WEEKLY,
+// MONTHLY, and YEARLY rules with INTERVAL, COUNT, UNTIL, and weekly BYDAY,
+// minus EXDATEs and instances moved by a RECURRENCE-ID; other rules only
+// count their first instance. Times without a zone are local.
+func ParseBusyICS(r io.Reader, from, to time.Time) ([]Busy, error) {
+	events, err := readICSEvents(r)
+	if err != nil {
+		return nil, err
+	}
+
+	// Moved or cancelled instances replace the rule's own instance
+	moved := make(map[string][]time.Time)
+	for _, e := range events {
+		if e.recurrenceID != nil {
+			moved[e.uid] = append(moved[e.uid], *e.recurrenceID)
+		}
+	}
+
+	var busy []Busy
+	for _, e := range events {
+		if e.free || e.length <= 0 {
+			continue
+		}
+		var skip []time.Time
+		if e.recurrenceID == nil {
+			skip = append(append(skip, e.exdates...), moved[e.uid]...)
+		}
+		for _, start := range e.occurrences(from.Add(-e.length), to) {
+			if containsTime(skip, start) {
+				continue
+			}
+			span := TimeSpan{Start: start, End: start.Add(e.length)}
+			if span.End.After(from) && span.Start.Before(to) {
+				busy = append(busy, Busy{TimeSpan: span, Summary: e.summary})
+			}
+		}
+	}
+	sort.SliceStable(busy, func(i, j int) bool { return busy[i].Start.Before(busy[j].Start) })
+	return busy, nil
+}
+
+// FreeTime returns the gaps in from..to that no busy span covers
+func FreeTime(busy []Busy, from, to time.Time) []TimeSpan {
+	sorted := append([]Busy(nil), busy...)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].Start.Before(sorted[j].Start) })
+
+	var free []TimeSpan
+	cursor := from
+	for _, b := range sorted {
+		if !b.End.After(cursor) {
+			continue
+		}
+		if !b.Start.Before(to) {
+			break
+		}
+		if b.Start.After(cursor) {
+			free = append(free, TimeSpan{Start: cursor, End: b.Start})
+		}
+		cursor = b.End
+	}
+	if cursor.Before(to) {
+		free = append(free, TimeSpan{Start: cursor, End: to})
+	}
+	return free
+}
+
+// readICSEvents collects the VEVENTs of an iCalendar file
+func readICSEvents(r io.Reader) ([]*icsEvent, error) {
+	lines, err := readICSLines(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var events []*icsEvent
+	var event *icsEvent
+	var end, duration *time.Duration
+	nested := 0 // depth of components inside the VEVENT, like VALARM
+	for n, line := range lines {
+		name, params, value, ok := parseICSProp(line)
+		if !ok {
+			continue
+		}
+		switch {
+		case name == "BEGIN" && strings.EqualFold(value, "VEVENT"):
+			event, end, duration, nested = &icsEvent{}, nil, nil, 0
+			continue
+		case event == nil:
+			continue
+		case name == "BEGIN":
+			nested++
+			continue
+		case name == "END" && nested > 0:
+			nested--
+			continue
+		case name == "END" && strings.EqualFold(value, "VEVENT"):
+			switch {
+			case duration != nil:
+				event.length = *duration
+			case end != nil:
+				event.length = *end
+			case event.allDay:
+				// An all-day event without an end lasts the day
+				event.length = 24 * time.Hour
+			}
+			if !event.start.IsZero() {
+				events = append(events, event)
+			}
+			event = nil
+			continue
+		case nested > 0:
+			continue
+		}
+
+		var perr error
+		switch name {
+		case "UID":
+			event.uid = value
+		case "SUMMARY":
+			event.summary = unescapeICSText(value)
+		case "DTSTART":
+			event.start, perr = parseICSTime(value, params)
+			event.allDay = params["VALUE"] == "DATE" || len(strings.TrimSpace(value)) == 8
+		case "DTEND":
+			var t time.Time
+			if t, perr = parseICSTime(value, params); perr == nil && !event.start.IsZero() {
+				d := t.Sub(event.start)
+				end = &d
+			}
+		case "DURATION":
+			var d time.Duration
+			if d, perr = parseICSDuration(value); perr == nil {
+				duration = &d
+			}
+		case "RRULE":
+			event.rrule = parseRRule(value)
+		case "EXDATE":
+			for _, v := range strings.Split(value, ",") {
+				t, err := parseICSTime(v, params)
+				if err != nil {
+					perr = err
+					break
+				}
+				event.exdates = append(event.exdates, t)
+			}
+		case "RECURRENCE-ID":
+			var t time.Time
+			if t, perr = parseICSTime(value, params); perr == nil {
+				event.recurrenceID = &t
+			}
+		case "TRANSP":
+			event.free = event.free || strings.EqualFold(value, "TRANSPARENT")
+		case "STATUS":
+			event.free = event.free || strings.EqualFold(value, "CANCELLED")
+		}
+		if perr != nil {
+			return nil, fmt.Errorf("line %d: %s: %w", n+1, name, perr)
+		}
+	}
+	return events, nil
+}
+
+// readICSLines splits an iCalendar file into content lines, unfolding the
+// lines that continue on the next one
+func readICSLines(r io.Reader) ([]string, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1<<20)
+	var lines []string
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+		if (strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t")) && len(lines) > 0 {
+			lines[len(lines)-1] += line[1:]
+			continue
+		}
+		lines = append(lines, line)
+	}
+	return lines, scanner.Err()
+}
+
+// parseICSProp splits "NAME;PARAM=value:VALUE" into its parts; parameter
+// values may be quoted and contain colons
+func parseICSProp(line string) (name string, params map[string]string, value string, ok bool) {
+	quoted := false
+	colon := -1
+	for i, c := range line {
+		if c == '"' {
+			quoted = !quoted
+		} else if c == ':' && !quoted {
+			colon = i
+			break
+		}
+	}
+	if colon < 0 {
+		return "", nil, "", false
+	}
+
+	parts := strings.Split(line[:colon], ";")
+	params = make(map[string]string)
+	for _, p := range parts[1:] {
+		if k, v, found := strings.Cut(p, "="); found {
+			params[strings.ToUpper(k)] = strings.Trim(v, `"`)
+		}
+	}
+	return strings.ToUpper(parts[0]), params, line[colon+1:], true
+}
+
+// parseICSTime reads a DATE or DATE-TIME value. UTC times end in Z, a TZID
+// parameter names the zone, and anything else is local; zones Go doesn't
+// know, like Windows names, are taken as local too.
+func parseICSTime(value string, params map[string]string) (time.Time, error) {
+	value = strings.TrimSpace(value)
+	if params["VALUE"] == "DATE" || len(value) == 8 {
+		return time.ParseInLocation("20060102", value, time.Local)
+	}
+	if strings.HasSuffix(value, "Z") {
+		t, err := time.Parse("20060102T150405Z", value)
+		return t.In(time.Local), err
+	}
+	loc := time.Local
+	if tzid := params["TZID"]; tzid != "" {
+		if l, err := time.LoadLocation(tzid); err == nil {
+			loc = l
+		}
+	}
+	t, err := time.ParseInLocation("20060102T150405", value, loc)
+	return t.In(time.Local), err
+}
+
+// parseICSDuration reads a DURATION value like PT1H30M or P1D
+func parseICSDuration(value string) (time.Duration, error) {
+	s := strings.TrimPrefix(strings.TrimSpace(value), "+")
+	negative := strings.HasPrefix(s, "-")
+	s = strings.TrimPrefix(s, "-")
+	if !strings.HasPrefix(s, "P") {
+		return 0, fmt.Errorf("invalid duration %q", value)
+	}
+
+	units := map[byte]time.Duration{'W': 7 * 24 * time.Hour, 'D': 24 * time.Hour, 'H': time.Hour, 'M': time.Minute, 'S': time.Second}
+	var d time.Duration
+	number := ""
+	for i := 1; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case c == 'T':
+		case c >= '0' && c <= '9':
+			number += string(c)
+		default:
+			n, err := strconv.Atoi(number)
+			unit, ok := units[c]
+			if err != nil || !ok {
+				return 0, fmt.Errorf("invalid duration %q", value)
+			}
+			d += time.Duration(n) * unit
+			number = ""
+		}
+	}
+	if number != "" {
+		return 0, fmt.Errorf("invalid duration %q", value)
+	}
+	if negative {
+		d = -d
+	}
+	return d, nil
+}
+
+// parseRRule splits a recurrence rule into its parts, e.g. FREQ -> WEEKLY
+func parseRRule(value string) map[string]string {
+	rule := make(map[string]string)
+	for _, part := range strings.Split(value, ";") {
+		if k, v, found := strings.Cut(part, "="); found {
+			rule[strings.ToUpper(k)] = strings.ToUpper(v)
+		}
+	}
+	return rule
+}
+
+// icsWeekdays maps BYDAY codes to their offset from Monday
+var icsWeekdays = map[string]int{"MO": 0, "TU": 1, "WE": 2, "TH": 3, "FR": 4, "SA": 5, "SU": 6}
+
+// occurrences returns the event's start times up to before, beginning with
+// the first at or after from
+func (e *icsEvent) occurrences(from, before time.Time) []time.Time {
+	if e.rrule == nil {
+		return []time.Time{e.start}
+	}
+
+	interval, _ := strconv.Atoi(e.rrule["INTERVAL"])
+	if interval < 1 {
+		interval = 1
+	}
+	count, _ := strconv.Atoi(e.rrule["COUNT"])
+	var until time.Time
+	if v := e.rrule["UNTIL"]; v != "" {
+		t, err := parseICSTime(v, nil)
+		if err != nil {
+			return []time.Time{e.start}
+		}
+		until = t
+		if len(v) == 8 {
+			until = until.AddDate(0, 0, 1).Add(-time.Second) // a date includes that day
+		}
+	}
+
+	// Weekly rules may name the days; others must repeat on the start's day
+	var weekdays []int
+	if byday := e.rrule["BYDAY"]; byday != "" {
+		if e.rrule["FREQ"] != "WEEKLY" {
+			return []time.Time{e.start}
+		}
+		for _, code := range strings.Split(byday, ",") {
+			offset, ok := icsWeekdays[code]
+			if !ok {
+				return []time.Time{e.start}
+			}
+			weekdays = append(weekdays, offset)
+		}
+		sort.Ints(weekdays)
+	}
+
+	// next returns the i-th candidate, or false if it doesn't exist (the
+	// 31st of a short month)
+	var next func(i int) (time.Time, bool)
+	switch e.rrule["FREQ"] {
+	case "DAILY":
+		next = func(i int) (time.Time, bool) { return e.start.AddDate(0, 0, i*interval), true }
+	case "WEEKLY":
+		if len(weekdays) == 0 {
+			next = func(i int) (time.Time, bool) { return e.start.AddDate(0, 0, 7*i*interval), true }
+			break
+		}
+		monday := e.start.AddDate(0, 0, -((int(e.start.Weekday()) + 6) % 7))
+		next = func(i int) (time.Time, bool) {
+			week, day := i/len(weekdays), weekdays[i%len(weekdays)]
+			t := monday.AddDate(0, 0, 7*week*interval+day)
+			return t, !t.Before(e.start)
+		}
+	case "MONTHLY":
+		next = func(i int) (time.Time, bool) {
+			t := e.start.AddDate(0, i*interval, 0)
+			return t, t.Day() == e.start.Day()
+		}
+	case "YEARLY":
+		next = func(i int) (time.Time, bool) {
+			t := e.start.AddDate(i*interval, 0, 0)
+			return t, t.Day() == e.start.Day()
+		}
+	default:
+		return []time.Time{e.start}
+	}
+
+	var starts []time.Time
+	found := 0
+	for i := 0; i < maxOccurrences; i++ {
+		t, ok := next(i)
+		if !t.Before(before) || (!until.IsZero() && t.After(until)) {
+			break
+		}
+		if !ok {
+			continue
+		}
+		found++
+		if count > 0 && found > count {
+			break
+		}
+		if !t.Before(from) {
+			starts = append(starts, t)
+		}
+	}
+	return starts
+}
+
+func containsTime(times []time.Time, t time.Time) bool {
+	for _, other := range times {
+		if other.Equal(t) {
+			return true
+		}
+	}
+	return false
+}
+
+// unescapeICSText reverses escapeICSText
+func unescapeICSText(s string) string {
+	return strings.NewReplacer(`\\`, `\`, `\;`, ";", `\,`, ",", `\n`, "\n", `\N`, "\n").Replace(s)
+}
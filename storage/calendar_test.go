@@ -0,0 +1,116 @@
+package storage
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseBusyICS(t *testing.T) {
+	day := time.Date(2026, 3, 4, 0, 0, 0, 0, time.Local) // a Wednesday
+	at := func(hour, minute int) time.Time {
+		return day.Add(time.Duration(hour)*time.Hour + time.Duration(minute)*time.Minute)
+	}
+	utc := func(hour int) string { return at(hour, 0).UTC().Format("20060102T150405Z") }
+
+	ics := strings.Join([]string{
+		"BEGIN:VCALENDAR",
+		"BEGIN:VEVENT",
+		"UID:standup",
+		"SUMMARY:Standup\\, daily",
+		"DTSTART:20260302T093000",
+		"DURATION:PT15M",
+		"RRULE:FREQ=DAILY;COUNT=10",
+		"EXDATE:20260303T093000",
+		"BEGIN:VALARM",
+		"DTSTART:20260101T000000",
+		"END:VALARM",
+		"END:VEVENT",
+		"BEGIN:VEVENT",
+		"UID:review",
+		"SUMMARY:Design review",
+		"DTSTART:20260225T140000",
+		"DTEND:20260225T150000",
+		"RRULE:FREQ=WEEKLY;BYDAY=MO,WE",
+		"END:VEVENT",
+		"BEGIN:VEVENT",
+		"UID:review",
+		"RECURRENCE-ID:20260304T140000",
+		"SUMMARY:Design review (moved)",
+		"DTSTART:20260304T160000",
+		"DTEND:20260304T170000",
+		"END:VEVENT",
+		"BEGIN:VEVENT",
+		"UID:lunch",
+		"SUMMARY:Lunch with a very long name that the calendar app folded onto a second li",
+		" ne",
+		"DTSTART:" + utc(12),
+		"DTEND:" + utc(13),
+		"END:VEVENT",
+		"BEGIN:VEVENT",
+		"UID:focus",
+		"SUMMARY:Focus time",
+		"DTSTART:" + utc(10),
+		"DTEND:" + utc(11),
+		"TRANSP:TRANSPARENT",
+		"END:VEVENT",
+		"BEGIN:VEVENT",
+		"UID:cancelled",
+		"SUMMARY:Cancelled",
+		"DTSTART:" + utc(11),
+		"DTEND:" + utc(12),
+		"STATUS:CANCELLED",
+		"END:VEVENT",
+		"BEGIN:VEVENT",
+		"UID:tomorrow",
+		"SUMMARY:Tomorrow",
+		"DTSTART;VALUE=DATE:20260305",
+		"END:VEVENT",
+		"END:VCALENDAR",
+	}, "\r\n")
+
+	busy, err := ParseBusyICS(strings.NewReader(ics), day, day.AddDate(0, 0, 1))
+	if err != nil {
+		t.Fatalf("Failed to parse: %v", err)
+	}
+
+	want := []struct {
+		summary    string
+		start, end time.Time
+	}{
+		{"Standup, daily", at(9, 30), at(9, 45)},
+		{"Lunch with a very long name that the calendar app folded onto a second line", at(12, 0), at(13, 0)},
+		{"Design review (moved)", at(16, 0), at(17, 0)},
+	}
+	if len(busy) != len(want) {
+		t.Fatalf("Expected %d busy times, got %v", len(want), busy)
+	}
+	for i, w := range want {
+		if busy[i].Summary != w.summary || !busy[i].Start.Equal(w.start) || !busy[i].End.Equal(w.end) {
+			t.Errorf("Busy %d: expected %s %s-%s, got %s %s-%s", i, w.summary, w.start.Format("15:04"), w.end.Format("15:04"),
+				busy[i].Summary, busy[i].Start.Format("15:04"), busy[i].End.Format("15:04"))
+		}
+	}
+
+	// The weekly rule lands on Mondays, the exception only moved Wednesday's,
+	// and an all-day event fills its day
+	monday := time.Date(2026, 3, 9, 0, 0, 0, 0, time.Local)
+	busy, _ = ParseBusyICS(strings.NewReader(ics), monday, monday.AddDate(0, 0, 1))
+	if len(busy) != 2 || busy[1].Summary != "Design review" || busy[1].Start.Hour() != 14 {
+		t.Errorf("Expected the standup and Monday's review, got %v", busy)
+	}
+	thursday := day.AddDate(0, 0, 1)
+	busy, _ = ParseBusyICS(strings.NewReader(ics), thursday, thursday.AddDate(0, 0, 1))
+	if len(busy) != 2 || busy[0].Summary != "Tomorrow" || busy[0].End.Sub(busy[0].Start) != 24*time.Hour {
+		t.Errorf("Expected the all-day event and the standup, got %v", busy)
+	}
+	// COUNT=10 ends the standup on the 11th, with the 3rd excluded
+	busy, _ = ParseBusyICS(strings.NewReader(ics), time.Date(2026, 3, 12, 0, 0, 0, 0, time.Local), time.Date(2026, 3, 13, 0, 0, 0, 0, time.Local))
+	if len(busy) != 0 {
+		t.Errorf("Expected the standup to have ended, got %v", busy)
+	}
+
+	if _, err := ParseBusyICS(strings.NewReader("BEGIN:VEVENT\nDTSTART:tomorrow\nEND:VEVENT\n"), day, day); err == nil {
+		t.Error("Expected an error for a bad DTSTART")
+	}
+}
@@ -0,0 +1,84 @@
+package storage
+
+import (
+	"os"
+	"sort"
+	"time"
+)
+
+// Compaction reports what Store.Compact removed
+type Compaction struct {
+	UndoEntries int   // undo and redo entries dropped
+	SizeBefore  int64 // data file size in bytes
+	SizeAfter   int64
+}
+
+// CompletedBefore returns done tasks, archived ones included, that were
+// completed before cutoff. Tasks finished before completion times were
+// recorded go by their last activity.
+func CompletedBefore(s Store, cutoff time.Time) ([]*Task, error) {
+	tasks, err := listEveryTask(s, "")
+	if err != nil {
+		return nil, err
+	}
+	var old []*Task
+	for _, t := range tasks {
+		if !t.Done {
+			continue
+		}
+		completed := t.LastActivity()
+		if t.CompletedAt != nil {
+			completed = *t.CompletedAt
+		}
+		if completed.Before(cutoff) {
+			old = append(old, t)
+		}
+	}
+	return old, nil
+}
+
+// ArchiveCompletedTasks writes the tasks completed before cutoff to path, in
+// the /export json format along with their projects, and deletes them from
+// the store as one journal entry. The file is never overwritten, and it is
+// removed again if the tasks can't be deleted. It returns the archived tasks.
+func ArchiveCompletedTasks(s Store, cutoff time.Time, path string) ([]*Task, error) {
+	tasks, err := CompletedBefore(s, cutoff)
+	if err != nil || len(tasks) == 0 {
+		return nil, err
+	}
+
+	snap := &Snapshot{Tasks: tasks}
+	seen := make(map[string]bool)
+	var ids []string
+	for _, t := range tasks {
+		ids = append(ids, t.ID)
+		if seen[t.ProjectID] {
+			continue
+		}
+		seen[t.ProjectID] = true
+		if p, err := s.GetProject(t.ProjectID); err == nil {
+			snap.Projects = append(snap.Projects, p)
+		}
+	}
+	sort.Slice(snap.Projects, func(i, j int) bool { return snap.Projects[i].Name < snap.Projects[j].Name })
+
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+	if err != nil {
+		return nil, err
+	}
+	if err := WriteSnapshot(f, snap, FormatJSON); err != nil {
+		f.Close()
+		os.Remove(path)
+		return nil, err
+	}
+	if err := f.Close(); err != nil {
+		os.Remove(path)
+		return nil, err
+	}
+
+	if err := s.DeleteTasks(ids); err != nil {
+		os.Remove(path)
+		return nil, err
+	}
+	return tasks, nil
+}
@@ -0,0 +1,97 @@
+package storage
+
+import (
+	"time"
+)
+
+// Conflict records an incoming version of a project or task that differs from
+// the stored one. Conflicts are queued instead of applied so the user can pick
+// a resolution; the stored ("mine") version is read fresh at resolution time.
+type Conflict struct {
+	ID         string    `json:"id"`
+	Kind       string    `json:"kind"` // "project" or "task"
+	EntityID   string    `json:"entity_id"`
+	Source     string    `json:"source,omitempty"` // where the incoming version came from (e.g., a file path)
+	DetectedAt time.Time `json:"detected_at"`
+	Project    *Project  `json:"project,omitempty"` // incoming ("theirs") project
+	Task       *Task     `json:"task,omitempty"`    // incoming ("theirs") task
+}
+
+// NewConflict creates a queued conflict for an incoming project or task
+func NewConflict(source string, project *Project, task *Task) *Conflict {
+	c := &Conflict{
+		ID:         generateUUID(),
+		Source:     source,
+		DetectedAt: time.Now(),
+		Project:    project,
+		Task:       task,
+	}
+	if project != nil {
+		c.Kind = "project"
+		c.EntityID = project.ID
+	} else {
+		c.Kind = "task"
+		c.EntityID = task.ID
+	}
+	return c
+}
+
+// MergeTasks combines two versions of the same task field by field:
+// done if either is done (at the earlier completion time), else mine's
+// status unless it has none, the later edit
+// time, the earlier due date, the longer duration, the union of tags, and
+// mine's name, project, and place in it unless they are empty.
+func MergeTasks(mine, theirs *Task) *Task {
+	merged := *mine
+	merged.Tags = append([]string{}, mine.Tags...)
+
+	if merged.Name == "" {
+		merged.Name = theirs.Name
+	}
+	if merged.ProjectID == "" {
+		merged.ProjectID = theirs.ProjectID
+		merged.Order = theirs.Order
+	}
+	if merged.Order == 0 && merged.ProjectID == theirs.ProjectID {
+		merged.Order = theirs.Order
+	}
+	merged.Done = mine.Done || theirs.Done
+	if theirs.CompletedAt != nil && (merged.CompletedAt == nil || theirs.CompletedAt.Before(*merged.CompletedAt)) {
+		completed := *theirs.CompletedAt
+		merged.CompletedAt = &completed
+	}
+	if !merged.Done {
+		merged.CompletedAt = nil
+	}
+	switch {
+	case merged.Done:
+		merged.Status = StatusDone
+	case merged.Status == "" || merged.Status == StatusDone:
+		merged.Status = theirs.CurrentStatus()
+	}
+	if theirs.UpdatedAt != nil && (merged.UpdatedAt == nil || theirs.UpdatedAt.After(*merged.UpdatedAt)) {
+		updated := *theirs.UpdatedAt
+		merged.UpdatedAt = &updated
+	}
+	if merged.Note == "" {
+		merged.Note = theirs.Note
+	}
+
+	if theirs.DueDate != nil && (merged.DueDate == nil || theirs.DueDate.Before(*merged.DueDate)) {
+		due := *theirs.DueDate
+		merged.DueDate = &due
+	}
+	if theirs.Duration.ToMinutes() > merged.Duration.ToMinutes() {
+		merged.Duration = theirs.Duration
+	}
+	if theirs.CreatedAt.Before(merged.CreatedAt) {
+		merged.CreatedAt = theirs.CreatedAt
+	}
+	for _, tag := range theirs.Tags {
+		if !merged.HasTag(tag) {
+			merged.Tags = append(merged.Tags, tag)
+		}
+	}
+
+	return &merged
+}
@@ -0,0 +1,159 @@
+package storage
+
+import "fmt"
+
+// IsBlockedBy returns true if the task lists blockerID among its dependencies
+func (t *Task) IsBlockedBy(blockerID string) bool {
+	for _, id := range t.BlockedBy {
+		if id == blockerID {
+			return true
+		}
+	}
+	return false
+}
+
+// AddTaskDependency records that taskID cannot be done until blockerID is done
+func (s *JSONStore) AddTaskDependency(taskID, blockerID string) error {
+	release, err := s.beginWrite()
+	if err != nil {
+		return err
+	}
+	defer release()
+
+	if taskID == blockerID {
+		return fmt.Errorf("a task cannot block itself")
+	}
+	task := s.taskByID(taskID)
+	if task == nil {
+		return fmt.Errorf("task not found: %s", taskID)
+	}
+	blocker := s.taskByID(blockerID)
+	if blocker == nil {
+		return fmt.Errorf("task not found: %s", blockerID)
+	}
+	if task.IsBlockedBy(blockerID) {
+		return nil
+	}
+	if dependencyPath(s.data.Tasks, blockerID, taskID) != nil {
+		return fmt.Errorf("dependency cycle: %q already depends on %q", blocker.Name, task.Name)
+	}
+
+	return s.updateTask(taskID, "add blocker to", func(t *Task) error {
+		t.BlockedBy = append(t.BlockedBy, blockerID)
+		return nil
+	})
+}
+
+// RemoveTaskDependency removes blockerID from taskID's dependencies
+func (s *JSONStore) RemoveTaskDependency(taskID, blockerID string) error {
+	release, err := s.beginWrite()
+	if err != nil {
+		return err
+	}
+	defer release()
+
+	return s.updateTask(taskID, "remove blocker from", func(t *Task) error {
+		for i, id := range t.BlockedBy {
+			if id == blockerID {
+				t.BlockedBy = append(t.BlockedBy[:i], t.BlockedBy[i+1:]...)
+				return nil
+			}
+		}
+		return fmt.Errorf("task %s is not blocked by %s", t.Name, blockerID)
+	})
+}
+
+// dependencyPath returns the chain of task IDs from -> ... -> to following
+// BlockedBy edges, or nil if to is not reachable from from
+func dependencyPath(tasks []*Task, from, to string) []string {
+	byID := make(map[string]*Task, len(tasks))
+	for _, t := range tasks {
+		byID[t.ID] = t
+	}
+
+	visited := make(map[string]bool)
+	var walk func(id string) []string
+	walk = func(id string) []string {
+		if id == to {
+			return []string{id}
+		}
+		if visited[id] {
+			return nil
+		}
+		visited[id] = true
+		t, ok := byID[id]
+		if !ok {
+			return nil
+		}
+		for _, next := range t.BlockedBy {
+			if rest := walk(next); rest != nil {
+				return append([]string{id}, rest...)
+			}
+		}
+		return nil
+	}
+	return walk(from)
+}
+
+// CriticalPath returns the longest chain of open tasks through the dependency
+// graph, ordered from the first task to do to the last. Chains are weighed by
+// estimated minutes, with unestimated tasks counting as one minute so that
+// chain length still matters. Done tasks and unknown blockers are skipped.
+func CriticalPath(tasks []*Task) []*Task {
+	byID := make(map[string]*Task, len(tasks))
+	for _, t := range tasks {
+		byID[t.ID] = t
+	}
+
+	type result struct {
+		weight int
+		chain  []*Task // blockers first, ending with the task itself
+	}
+	memo := make(map[string]result)
+	inProgress := make(map[string]bool)
+
+	var longest func(t *Task) result
+	longest = func(t *Task) result {
+		if r, ok := memo[t.ID]; ok {
+			return r
+		}
+		if inProgress[t.ID] {
+			return result{} // cycle guard; cycles are rejected on insert
+		}
+		inProgress[t.ID] = true
+		defer delete(inProgress, t.ID)
+
+		var best result
+		for _, id := range t.BlockedBy {
+			blocker, ok := byID[id]
+			if !ok || blocker.Done {
+				continue
+			}
+			if r := longest(blocker); r.weight > best.weight {
+				best = r
+			}
+		}
+
+		weight := t.Duration.ToMinutes()
+		if weight == 0 {
+			weight = 1
+		}
+		r := result{
+			weight: best.weight + weight,
+			chain:  append(append([]*Task{}, best.chain...), t),
+		}
+		memo[t.ID] = r
+		return r
+	}
+
+	var best result
+	for _, t := range tasks {
+		if t.Done {
+			continue
+		}
+		if r := longest(t); r.weight > best.weight {
+			best = r
+		}
+	}
+	return best.chain
+}
@@ -0,0 +1,118 @@
+package storage
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Due dates are calendar dates stored as midnight UTC. A task due at a time
+// of day keeps it on the same UTC clock: 2025-06-15 14:00 is stored as
+// 2025-06-15T14:00:00Z and means 14:00 wherever the user is, just as the
+// date means that day wherever they are. Midnight means no due time.
+
+// HasDueTime reports whether a due date carries a time of day
+func HasDueTime(due time.Time) bool {
+	return due.Hour() != 0 || due.Minute() != 0
+}
+
+// FormatDue renders a due date as YYYY-MM-DD, with " HH:MM" when it has a time
+func FormatDue(due time.Time) string {
+	if HasDueTime(due) {
+		return due.Format("2006-01-02 15:04")
+	}
+	return due.Format("2006-01-02")
+}
+
+// formatDueWord is FormatDue without a space, for formats that separate
+// fields with spaces (Markdown metadata)
+func formatDueWord(due time.Time) string {
+	if HasDueTime(due) {
+		return due.Format("2006-01-02T15:04")
+	}
+	return due.Format("2006-01-02")
+}
+
+// ParseDue parses a due date written by FormatDue: YYYY-MM-DD, optionally
+// followed by a space or T and HH:MM
+func ParseDue(s string) (time.Time, error) {
+	date, clock, found := strings.Cut(s, " ")
+	if !found {
+		date, clock, found = strings.Cut(s, "T")
+	}
+	due, err := time.Parse("2006-01-02", date)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid due date: %s (use YYYY-MM-DD or YYYY-MM-DD HH:MM)", s)
+	}
+	if !found {
+		return due, nil
+	}
+	return WithDueTime(due, clock)
+}
+
+// WithDueTime sets the time of day of a due date from a 24-hour HH:MM clock
+// time; "00:00" clears it, since midnight means no time
+func WithDueTime(due time.Time, clock string) (time.Time, error) {
+	t, err := time.Parse("15:04", clock)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid due time: %s (use HH:MM, 24-hour)", clock)
+	}
+	return time.Date(due.Year(), due.Month(), due.Day(), t.Hour(), t.Minute(), 0, 0, time.UTC), nil
+}
+
+// DueAt returns the moment a task with this due date is due, in loc: at its
+// time of day, or at the end of the day when it has none
+func DueAt(due time.Time, loc *time.Location) time.Time {
+	if HasDueTime(due) {
+		return time.Date(due.Year(), due.Month(), due.Day(), due.Hour(), due.Minute(), 0, 0, loc)
+	}
+	return time.Date(due.Year(), due.Month(), due.Day()+1, 0, 0, 0, 0, loc)
+}
+
+// dueShiftRegex matches relative shifts like +3d, +2w, or +1m
+var dueShiftRegex = regexp.MustCompile(`^\+?(\d+)([dwm])$`)
+
+// DeferDue moves a due date later by shift: "+3d", "+2w", or "+1m" (days,
+// weeks, or months), "next-monday" (the first Monday after it), or a date
+// ParseDueWord reads, like "tomorrow" or "fri". due is nil for a task without
+// one. Shifts count from the due date, or from today when there is none or it
+// has passed, so deferring never leaves a task overdue. A due time is kept.
+func DeferDue(due *time.Time, shift string, now time.Time) (time.Time, error) {
+	base := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	if due != nil {
+		if DueAt(*due, now.Location()).After(now) {
+			base = *due
+		} else {
+			base = base.Add(time.Duration(due.Hour())*time.Hour + time.Duration(due.Minute())*time.Minute)
+		}
+	}
+	clock := time.Duration(base.Hour())*time.Hour + time.Duration(base.Minute())*time.Minute
+
+	shift = strings.ToLower(strings.TrimSpace(shift))
+	if m := dueShiftRegex.FindStringSubmatch(shift); m != nil {
+		n, _ := strconv.Atoi(m[1])
+		switch m[2] {
+		case "d":
+			return base.AddDate(0, 0, n), nil
+		case "w":
+			return base.AddDate(0, 0, 7*n), nil
+		default:
+			return base.AddDate(0, n, 0), nil
+		}
+	}
+	if name, ok := strings.CutPrefix(strings.ReplaceAll(shift, " ", "-"), "next-"); ok {
+		if day, ok := weekdays[name]; ok {
+			return nextWeekday(base.AddDate(0, 0, 1), day), nil
+		}
+		if day, ok := map[string]time.Weekday{"sat": time.Saturday, "sun": time.Sunday, "week": time.Monday}[name]; ok {
+			return nextWeekday(base.AddDate(0, 0, 1), day), nil
+		}
+	}
+	date, err := ParseDueWord(shift, now)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid shift: %s (use +1d, +2w, +1m, next-monday, or a date like tomorrow, fri, or YYYY-MM-DD)", shift)
+	}
+	return date.Add(clock), nil
+}
@@ -0,0 +1,84 @@
+package storage
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseDue(t *testing.T) {
+	tests := []struct {
+		in   string
+		want time.Time
+		text string // FormatDue of the result
+	}{
+		{"2025-06-15", time.Date(2025, 6, 15, 0, 0, 0, 0, time.UTC), "2025-06-15"},
+		{"2025-06-15 14:00", time.Date(2025, 6, 15, 14, 0, 0, 0, time.UTC), "2025-06-15 14:00"},
+		{"2025-06-15T9:05", time.Date(2025, 6, 15, 9, 5, 0, 0, time.UTC), "2025-06-15 09:05"},
+		{"2025-06-15 00:00", time.Date(2025, 6, 15, 0, 0, 0, 0, time.UTC), "2025-06-15"}, // midnight is no time
+	}
+	for _, tt := range tests {
+		got, err := ParseDue(tt.in)
+		if err != nil || !got.Equal(tt.want) {
+			t.Errorf("ParseDue(%q) = %v, %v; want %v", tt.in, got, err, tt.want)
+			continue
+		}
+		if text := FormatDue(got); text != tt.text {
+			t.Errorf("FormatDue(%v) = %q, want %q", got, text, tt.text)
+		}
+	}
+	for _, bad := range []string{"2025-06-15 2pm", "2025-06-15 25:00", "tomorrow"} {
+		if _, err := ParseDue(bad); err == nil {
+			t.Errorf("Expected an error for %q", bad)
+		}
+	}
+
+	// A due time is local wall-clock time; a date alone is due by the end of the day
+	loc := time.FixedZone("UTC-5", -5*3600)
+	if got := DueAt(time.Date(2025, 6, 15, 14, 0, 0, 0, time.UTC), loc); !got.Equal(time.Date(2025, 6, 15, 14, 0, 0, 0, loc)) {
+		t.Errorf("Expected 14:00 local, got %v", got)
+	}
+	if got := DueAt(time.Date(2025, 6, 15, 0, 0, 0, 0, time.UTC), loc); !got.Equal(time.Date(2025, 6, 16, 0, 0, 0, 0, loc)) {
+		t.Errorf("Expected the end of the day, got %v", got)
+	}
+}
+
+func TestDeferDue(t *testing.T) {
+	now := time.Date(2025, 6, 11, 10, 0, 0, 0, time.Local) // a Wednesday
+	date := func(s string) *time.Time {
+		d, err := ParseDue(s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return &d
+	}
+	tests := []struct {
+		due   *time.Time
+		shift string
+		want  string
+	}{
+		{date("2025-06-20"), "+1d", "2025-06-21"},
+		{date("2025-06-20"), "+2w", "2025-07-04"},
+		{date("2025-06-20"), "+1m", "2025-07-20"},
+		{date("2025-06-20 14:30"), "+1d", "2025-06-21 14:30"},
+		{nil, "+1d", "2025-06-12"},
+		{date("2025-06-11"), "+1d", "2025-06-12"},             // due later today
+		{date("2025-06-02"), "+1d", "2025-06-12"},             // overdue: from today
+		{date("2025-06-11 09:00"), "+1d", "2025-06-12 09:00"}, // passed an hour ago
+		{date("2025-06-20"), "next-monday", "2025-06-23"},     // after the due date
+		{date("2025-06-16"), "next-mon", "2025-06-23"},        // a Monday moves a week
+		{date("2025-06-02 08:00"), "next monday", "2025-06-16 08:00"},
+		{date("2025-06-20"), "tomorrow", "2025-06-12"},
+		{date("2025-06-20 14:30"), "fri", "2025-06-13 14:30"},
+	}
+	for _, tt := range tests {
+		got, err := DeferDue(tt.due, tt.shift, now)
+		if err != nil || FormatDue(got) != tt.want {
+			t.Errorf("DeferDue(%v, %q) = %s, %v; want %s", tt.due, tt.shift, FormatDue(got), err, tt.want)
+		}
+	}
+	for _, bad := range []string{"+1y", "-1d", "next-year", "soon"} {
+		if _, err := DeferDue(nil, bad, now); err == nil {
+			t.Errorf("Expected an error for %q", bad)
+		}
+	}
+}
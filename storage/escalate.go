@@ -0,0 +1,108 @@
+package storage
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+)
+
+// EscalationPolicy decides when open tasks get their priority raised.
+// A zero field disables that rule; a zero policy disables escalation.
+type EscalationPolicy struct {
+	DueWithinDays int // raise to high when due within this many days (urgent once overdue)
+	SnoozeLimit   int // raise to high once the due date has been pushed back this many times
+}
+
+// Enabled reports whether any escalation rule is active
+func (p EscalationPolicy) Enabled() bool {
+	return p.DueWithinDays > 0 || p.SnoozeLimit > 0
+}
+
+// EscalationPolicyFromEnv reads TWOOMS_ESCALATE_DUE_DAYS and
+// TWOOMS_ESCALATE_SNOOZES. Escalation is opt-in, so unset or invalid values
+// leave the rule off.
+func EscalationPolicyFromEnv() EscalationPolicy {
+	atoi := func(name string) int {
+		n, err := strconv.Atoi(os.Getenv(name))
+		if err != nil || n < 0 {
+			return 0
+		}
+		return n
+	}
+	return EscalationPolicy{
+		DueWithinDays: atoi("TWOOMS_ESCALATE_DUE_DAYS"),
+		SnoozeLimit:   atoi("TWOOMS_ESCALATE_SNOOZES"),
+	}
+}
+
+// Escalation records one priority change applied by the policy
+type Escalation struct {
+	TaskID   string
+	TaskName string
+	From     Priority
+	To       Priority
+	Reason   string
+}
+
+// target returns the priority the policy calls for and why, or "" if none.
+// Rules only ever set a floor, so evaluating them repeatedly is a no-op.
+func (p EscalationPolicy) target(t *Task, now time.Time) (Priority, string) {
+	if t.Done {
+		return "", ""
+	}
+
+	if t.DueDate != nil && p.DueWithinDays > 0 {
+		today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+		due := time.Date(t.DueDate.Year(), t.DueDate.Month(), t.DueDate.Day(), 0, 0, 0, 0, time.UTC)
+		days := int(due.Sub(today).Hours() / 24)
+		switch {
+		case days < 0:
+			return PriorityUrgent, "overdue"
+		case days <= p.DueWithinDays:
+			return PriorityHigh, fmt.Sprintf("due in %d days", days)
+		}
+	}
+
+	if p.SnoozeLimit > 0 && t.Postponed >= p.SnoozeLimit {
+		return PriorityHigh, fmt.Sprintf("postponed %d times", t.Postponed)
+	}
+
+	return "", ""
+}
+
+// Escalate raises the priority of every open task the policy matches. Each
+// change is journaled separately, so it shows up in the undo history and can
+// be reverted on its own.
+func (s *JSONStore) Escalate(policy EscalationPolicy, now time.Time) ([]*Escalation, error) {
+	release, err := s.beginWrite()
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	if !policy.Enabled() {
+		return nil, nil
+	}
+
+	var applied []*Escalation
+	for _, t := range s.data.Tasks {
+		to, reason := policy.target(t, now)
+		if to.Rank() <= t.Priority.Rank() {
+			continue
+		}
+
+		task := t
+		e := &Escalation{TaskID: t.ID, TaskName: t.Name, From: t.Priority, To: to, Reason: reason}
+		op := fmt.Sprintf("escalate %q to %s (%s)", t.Name, to, reason)
+		if err := s.journaled(op, nil, []string{t.ID}, func() error {
+			task.Priority = to
+			return nil
+		}); err != nil {
+			return applied, err
+		}
+		applied = append(applied, e)
+	}
+
+	return applied, nil
+}
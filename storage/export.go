@@ -0,0 +1,654 @@
+package storage
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Supported export/import formats
+const (
+	FormatJSON     = "json"
+	FormatCSV      = "csv"
+	FormatMarkdown = "md"
+)
+
+// ValidFormats lists all supported export/import formats
+var ValidFormats = []string{FormatJSON, FormatCSV, FormatMarkdown}
+
+// Snapshot is a portable copy of projects and tasks used for export and import
+type Snapshot struct {
+	Projects []*Project `json:"projects"`
+	Tasks    []*Task    `json:"tasks"`
+
+	generated map[string]bool // IDs assigned during parsing (absent from the source file)
+}
+
+// ParseFormat normalizes a format name (accepts "markdown" as an alias for "md")
+func ParseFormat(s string) (string, error) {
+	s = strings.ToLower(strings.TrimPrefix(s, "."))
+	if s == "markdown" {
+		s = FormatMarkdown
+	}
+	for _, f := range ValidFormats {
+		if f == s {
+			return s, nil
+		}
+	}
+	return "", fmt.Errorf("unknown format: %s (use json, csv, or md)", s)
+}
+
+// FormatFromFilename infers the format from a file's extension
+func FormatFromFilename(filename string) (string, error) {
+	return ParseFormat(filepath.Ext(filename))
+}
+
+// ExportSnapshot collects all projects and tasks, archived ones included, from a store
+func ExportSnapshot(s Store) (*Snapshot, error) {
+	projects, err := s.ListProjects()
+	if err != nil {
+		return nil, err
+	}
+	tasks, err := listEveryTask(s, "")
+	if err != nil {
+		return nil, err
+	}
+	return &Snapshot{Projects: projects, Tasks: tasks}, nil
+}
+
+// listEveryTask returns active and archived tasks, for a project or for all
+// projects when projectID is empty
+func listEveryTask(s Store, projectID string) ([]*Task, error) {
+	var tasks []*Task
+	var err error
+	if projectID == "" {
+		tasks, err = s.ListAllTasks()
+	} else {
+		tasks, err = s.ListTasks(projectID)
+	}
+	if err != nil {
+		return nil, err
+	}
+	archived, err := s.ListArchivedTasks(projectID)
+	if err != nil {
+		return nil, err
+	}
+	return append(tasks, archived...), nil
+}
+
+// WriteSnapshot serializes a snapshot in the given format
+func WriteSnapshot(w io.Writer, snap *Snapshot, format string) error {
+	switch format {
+	case FormatJSON:
+		data, err := json.MarshalIndent(snap, "", "  ")
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintln(w, string(data))
+		return err
+	case FormatCSV:
+		return writeCSV(w, snap)
+	case FormatMarkdown:
+		return writeMarkdown(w, snap)
+	default:
+		return fmt.Errorf("unknown format: %s", format)
+	}
+}
+
+// ReadSnapshot parses a snapshot in the given format
+func ReadSnapshot(r io.Reader, format string) (*Snapshot, error) {
+	switch format {
+	case FormatJSON:
+		snap := &Snapshot{}
+		if err := json.NewDecoder(r).Decode(snap); err != nil {
+			return nil, fmt.Errorf("invalid JSON: %w", err)
+		}
+		for _, p := range snap.Projects {
+			snap.fillProjectDefaults(p)
+		}
+		for _, t := range snap.Tasks {
+			snap.fillTaskDefaults(t)
+		}
+		return snap, nil
+	case FormatCSV:
+		return readCSV(r)
+	case FormatMarkdown:
+		return readMarkdown(r)
+	default:
+		return nil, fmt.Errorf("unknown format: %s", format)
+	}
+}
+
+// csvHeader lists the CSV columns; projects without tasks get a row with empty
+// task columns, and inbox tasks one with empty project columns
+var csvHeader = []string{
+	"project_id", "project_name", "project_shortcut", "project_created_at", "project_due_date", "project_default_duration", "project_default_due",
+	"task_id", "task_name", "done", "status", "created_at", "due_date", "duration", "tags", "blocked_by", "priority", "context", "archived_at", "note", "completed_at", "updated_at", "order",
+}
+
+func writeCSV(w io.Writer, snap *Snapshot) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write(csvHeader); err != nil {
+		return err
+	}
+
+	// Inbox tasks have no project, so their project columns are empty
+	writeTask := func(projectCols []string, t *Task) error {
+		due := ""
+		if t.DueDate != nil {
+			due = FormatDue(*t.DueDate)
+		}
+		archived := ""
+		if t.ArchivedAt != nil {
+			archived = t.ArchivedAt.Format(time.RFC3339Nano)
+		}
+		completed := ""
+		if t.CompletedAt != nil {
+			completed = t.CompletedAt.Format(time.RFC3339Nano)
+		}
+		updated := ""
+		if t.UpdatedAt != nil {
+			updated = t.UpdatedAt.Format(time.RFC3339Nano)
+		}
+		order := ""
+		if t.Order != 0 {
+			order = strconv.Itoa(t.Order)
+		}
+		row := append(append([]string{}, projectCols...),
+			t.ID, t.Name, strconv.FormatBool(t.Done), string(t.Status), t.CreatedAt.Format(time.RFC3339Nano),
+			due, t.Duration.String(), strings.Join(t.Tags, " "), strings.Join(t.BlockedBy, " "), string(t.Priority), t.Context, archived, t.Note, completed, updated, order)
+		return cw.Write(row)
+	}
+
+	for _, p := range snap.Projects {
+		projectDue := ""
+		if p.DueDate != nil {
+			projectDue = p.DueDate.Format("2006-01-02")
+		}
+		projectCols := []string{p.ID, p.Name, p.Shortcut, p.CreatedAt.Format(time.RFC3339Nano), projectDue, p.DefaultDuration.String(), p.DefaultDue}
+		wroteTask := false
+		for _, t := range snap.Tasks {
+			if t.ProjectID != p.ID {
+				continue
+			}
+			wroteTask = true
+			if err := writeTask(projectCols, t); err != nil {
+				return err
+			}
+		}
+		if !wroteTask {
+			row := append(append([]string{}, projectCols...), "", "", "", "", "", "", "", "", "", "", "", "", "", "", "", "")
+			if err := cw.Write(row); err != nil {
+				return err
+			}
+		}
+	}
+	for _, t := range snap.Tasks {
+		if t.ProjectID == "" {
+			if err := writeTask(make([]string, 7), t); err != nil {
+				return err
+			}
+		}
+	}
+
+	cw.Flush()
+	return cw.Error()
+}
+
+func readCSV(r io.Reader) (*Snapshot, error) {
+	cr := csv.NewReader(r)
+	rows, err := cr.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("invalid CSV: %w", err)
+	}
+	if len(rows) == 0 {
+		return &Snapshot{}, nil
+	}
+
+	// Map header names to column indexes so column order doesn't matter
+	col := make(map[string]int)
+	for i, name := range rows[0] {
+		col[strings.TrimSpace(name)] = i
+	}
+	for _, required := range []string{"project_name", "task_name"} {
+		if _, ok := col[required]; !ok {
+			return nil, fmt.Errorf("invalid CSV: missing %s column", required)
+		}
+	}
+	get := func(row []string, name string) string {
+		if i, ok := col[name]; ok && i < len(row) {
+			return strings.TrimSpace(row[i])
+		}
+		return ""
+	}
+
+	snap := &Snapshot{}
+	projectsByKey := make(map[string]*Project)
+	for lineNo, row := range rows[1:] {
+		// Projects are keyed by ID when present, otherwise by name; a row with
+		// neither is an inbox task
+		key := get(row, "project_id")
+		if key == "" && get(row, "project_name") != "" {
+			key = "name:" + get(row, "project_name")
+		}
+		project, ok := projectsByKey[key]
+		if key == "" {
+			project, ok = &Project{}, true
+		}
+		if !ok {
+			project = &Project{
+				ID:       get(row, "project_id"),
+				Name:     get(row, "project_name"),
+				Shortcut: get(row, "project_shortcut"),
+			}
+			if created := get(row, "project_created_at"); created != "" {
+				project.CreatedAt, err = time.Parse(time.RFC3339Nano, created)
+				if err != nil {
+					return nil, fmt.Errorf("line %d: invalid project_created_at: %w", lineNo+2, err)
+				}
+			}
+			if due := get(row, "project_due_date"); due != "" {
+				dueDate, err := time.Parse("2006-01-02", due)
+				if err != nil {
+					return nil, fmt.Errorf("line %d: invalid project_due_date: %w", lineNo+2, err)
+				}
+				project.DueDate = &dueDate
+			}
+			if err := readProjectDefaults(project, get(row, "project_default_duration"), get(row, "project_default_due")); err != nil {
+				return nil, fmt.Errorf("line %d: %w", lineNo+2, err)
+			}
+			snap.fillProjectDefaults(project)
+			projectsByKey[key] = project
+			snap.Projects = append(snap.Projects, project)
+		}
+
+		name := get(row, "task_name")
+		if name == "" {
+			continue
+		}
+		task := &Task{
+			ID:        get(row, "task_id"),
+			ProjectID: project.ID,
+			Name:      name,
+			Done:      get(row, "done") == "true",
+			Status:    Status(get(row, "status")),
+			Tags:      strings.Fields(get(row, "tags")),
+			BlockedBy: strings.Fields(get(row, "blocked_by")),
+			Priority:  Priority(get(row, "priority")),
+			Context:   get(row, "context"),
+			Note:      get(row, "note"),
+		}
+		if created := get(row, "created_at"); created != "" {
+			task.CreatedAt, err = time.Parse(time.RFC3339Nano, created)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: invalid created_at: %w", lineNo+2, err)
+			}
+		}
+		if due := get(row, "due_date"); due != "" {
+			dueDate, err := ParseDue(due)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: invalid due_date: %w", lineNo+2, err)
+			}
+			task.DueDate = &dueDate
+		}
+		if archived := get(row, "archived_at"); archived != "" {
+			archivedAt, err := time.Parse(time.RFC3339Nano, archived)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: invalid archived_at: %w", lineNo+2, err)
+			}
+			task.ArchivedAt = &archivedAt
+		}
+		if completed := get(row, "completed_at"); completed != "" {
+			completedAt, err := time.Parse(time.RFC3339Nano, completed)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: invalid completed_at: %w", lineNo+2, err)
+			}
+			task.CompletedAt = &completedAt
+		}
+		if updated := get(row, "updated_at"); updated != "" {
+			updatedAt, err := time.Parse(time.RFC3339Nano, updated)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: invalid updated_at: %w", lineNo+2, err)
+			}
+			task.UpdatedAt = &updatedAt
+		}
+		if order := get(row, "order"); order != "" {
+			if task.Order, err = strconv.Atoi(order); err != nil || task.Order < 0 {
+				return nil, fmt.Errorf("line %d: invalid order: %s", lineNo+2, order)
+			}
+		}
+		if duration := get(row, "duration"); duration != "" {
+			if task.Duration, err = ParseDuration(duration); err != nil {
+				return nil, fmt.Errorf("line %d: %w", lineNo+2, err)
+			}
+		}
+		if task.Priority != "" && !IsValidPriority(string(task.Priority)) {
+			return nil, fmt.Errorf("line %d: invalid priority: %s", lineNo+2, task.Priority)
+		}
+		if task.Status != "" && !IsValidStatus(string(task.Status)) {
+			return nil, fmt.Errorf("line %d: invalid status: %s", lineNo+2, task.Status)
+		}
+		snap.fillTaskDefaults(task)
+		snap.Tasks = append(snap.Tasks, task)
+	}
+
+	return snap, nil
+}
+
+// Markdown export writes one "## Project" heading per project followed by a
+// checklist, then the inbox under "## Inbox <!-- inbox=true -->". Metadata needed for a lossless round-trip is kept in trailing
+// HTML comments, so the file still renders as a plain checklist. Entries
+// without those comments (hand-written checklists) are deduped by name on import.
+// A task's note follows it as "> " quote lines.
+func writeMarkdown(w io.Writer, snap *Snapshot) error {
+	bw := bufio.NewWriter(w)
+	for i, p := range snap.Projects {
+		if i > 0 {
+			fmt.Fprintln(bw)
+		}
+		meta := []string{"id=" + p.ID, "shortcut=" + p.Shortcut, "created=" + p.CreatedAt.Format(time.RFC3339Nano)}
+		if p.DueDate != nil {
+			meta = append(meta, "due="+p.DueDate.Format("2006-01-02"))
+		}
+		if p.DefaultDuration != 0 {
+			meta = append(meta, "default_duration="+p.DefaultDuration.String())
+		}
+		if p.DefaultDue != "" {
+			meta = append(meta, "default_due="+p.DefaultDue)
+		}
+		// Names written before they were cleaned on save could still break a line
+		fmt.Fprintf(bw, "## %s <!-- %s -->\n\n", CleanName(p.Name), strings.Join(meta, " "))
+		for _, t := range snap.Tasks {
+			if t.ProjectID == p.ID {
+				writeMarkdownTask(bw, t)
+			}
+		}
+	}
+
+	// The inbox goes last, under a heading marked so it's read back as the inbox
+	wroteInbox := false
+	for _, t := range snap.Tasks {
+		if t.ProjectID != "" {
+			continue
+		}
+		if !wroteInbox {
+			if len(snap.Projects) > 0 {
+				fmt.Fprintln(bw)
+			}
+			fmt.Fprintf(bw, "## %s <!-- inbox=true -->\n\n", InboxName)
+			wroteInbox = true
+		}
+		writeMarkdownTask(bw, t)
+	}
+	return bw.Flush()
+}
+
+// writeMarkdownTask writes one checklist line for a task, and its note
+func writeMarkdownTask(bw *bufio.Writer, t *Task) {
+	check := " "
+	if t.Done {
+		check = "x"
+	}
+	meta := []string{"id=" + t.ID, "created=" + t.CreatedAt.Format(time.RFC3339Nano)}
+	if t.DueDate != nil {
+		meta = append(meta, "due="+formatDueWord(*t.DueDate))
+	}
+	if t.Status != "" {
+		meta = append(meta, "status="+string(t.Status))
+	}
+	if t.Duration != 0 {
+		meta = append(meta, "duration="+t.Duration.String())
+	}
+	if t.Priority != "" {
+		meta = append(meta, "priority="+string(t.Priority))
+	}
+	if t.Context != "" {
+		meta = append(meta, "context="+t.Context)
+	}
+	if t.ArchivedAt != nil {
+		meta = append(meta, "archived="+t.ArchivedAt.Format(time.RFC3339Nano))
+	}
+	if t.CompletedAt != nil {
+		meta = append(meta, "completed="+t.CompletedAt.Format(time.RFC3339Nano))
+	}
+	if t.UpdatedAt != nil {
+		meta = append(meta, "updated="+t.UpdatedAt.Format(time.RFC3339Nano))
+	}
+	if t.Order != 0 {
+		meta = append(meta, "order="+strconv.Itoa(t.Order))
+	}
+	if len(t.Tags) > 0 {
+		meta = append(meta, "tags="+strings.Join(t.Tags, ","))
+	}
+	if len(t.BlockedBy) > 0 {
+		meta = append(meta, "blocked_by="+strings.Join(t.BlockedBy, ","))
+	}
+	fmt.Fprintf(bw, "- [%s] %s <!-- %s -->\n", check, CleanName(t.Name), strings.Join(meta, " "))
+	// Notes follow their task as an indented quote, one line per line
+	if t.Note != "" {
+		for _, line := range strings.Split(t.Note, "\n") {
+			fmt.Fprintf(bw, "  > %s\n", line)
+		}
+	}
+}
+
+func readMarkdown(r io.Reader) (*Snapshot, error) {
+	snap := &Snapshot{}
+	var current *Project
+	var lastTask *Task // the task quote lines add a note to
+
+	scanner := bufio.NewScanner(r)
+	lineNo := 0
+	for scanner.Scan() {
+		lineNo++
+		line := strings.TrimSpace(scanner.Text())
+
+		if strings.HasPrefix(line, "#") {
+			text, meta := splitMarkdownMeta(strings.TrimSpace(strings.TrimLeft(line, "#")))
+			lastTask = nil
+			// Tasks under the inbox heading get an empty project ID
+			if meta["inbox"] == "true" {
+				current = &Project{}
+				continue
+			}
+			current = &Project{ID: meta["id"], Name: text, Shortcut: meta["shortcut"]}
+			if created := meta["created"]; created != "" {
+				t, err := time.Parse(time.RFC3339Nano, created)
+				if err != nil {
+					return nil, fmt.Errorf("line %d: invalid created time: %w", lineNo, err)
+				}
+				current.CreatedAt = t
+			}
+			if due := meta["due"]; due != "" {
+				dueDate, err := time.Parse("2006-01-02", due)
+				if err != nil {
+					return nil, fmt.Errorf("line %d: invalid due date: %w", lineNo, err)
+				}
+				current.DueDate = &dueDate
+			}
+			if err := readProjectDefaults(current, meta["default_duration"], meta["default_due"]); err != nil {
+				return nil, fmt.Errorf("line %d: %w", lineNo, err)
+			}
+			snap.fillProjectDefaults(current)
+			snap.Projects = append(snap.Projects, current)
+			continue
+		}
+
+		if note, ok := strings.CutPrefix(line, ">"); ok && lastTask != nil {
+			note = strings.TrimPrefix(note, " ")
+			if lastTask.Note != "" {
+				note = lastTask.Note + "\n" + note
+			}
+			lastTask.Note = note
+			continue
+		}
+
+		var done bool
+		switch {
+		case strings.HasPrefix(line, "- [ ] "):
+		case strings.HasPrefix(line, "- [x] "), strings.HasPrefix(line, "- [X] "):
+			done = true
+		default:
+			lastTask = nil
+			continue
+		}
+		if current == nil {
+			return nil, fmt.Errorf("line %d: task appears before any project heading", lineNo)
+		}
+
+		text, meta := splitMarkdownMeta(line[len("- [ ] "):])
+		task := &Task{
+			ID:        meta["id"],
+			ProjectID: current.ID,
+			Name:      text,
+			Done:      done,
+			Status:    Status(meta["status"]),
+			Priority:  Priority(meta["priority"]),
+			Context:   meta["context"],
+		}
+		if created := meta["created"]; created != "" {
+			t, err := time.Parse(time.RFC3339Nano, created)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: invalid created time: %w", lineNo, err)
+			}
+			task.CreatedAt = t
+		}
+		if due := meta["due"]; due != "" {
+			dueDate, err := ParseDue(due)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %w", lineNo, err)
+			}
+			task.DueDate = &dueDate
+		}
+		if archived := meta["archived"]; archived != "" {
+			archivedAt, err := time.Parse(time.RFC3339Nano, archived)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: invalid archived time: %w", lineNo, err)
+			}
+			task.ArchivedAt = &archivedAt
+		}
+		if completed := meta["completed"]; completed != "" {
+			completedAt, err := time.Parse(time.RFC3339Nano, completed)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: invalid completed time: %w", lineNo, err)
+			}
+			task.CompletedAt = &completedAt
+		}
+		if updated := meta["updated"]; updated != "" {
+			updatedAt, err := time.Parse(time.RFC3339Nano, updated)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: invalid updated time: %w", lineNo, err)
+			}
+			task.UpdatedAt = &updatedAt
+		}
+		if order := meta["order"]; order != "" {
+			n, err := strconv.Atoi(order)
+			if err != nil || n < 0 {
+				return nil, fmt.Errorf("line %d: invalid order: %s", lineNo, order)
+			}
+			task.Order = n
+		}
+		if duration := meta["duration"]; duration != "" {
+			d, err := ParseDuration(duration)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %w", lineNo, err)
+			}
+			task.Duration = d
+		}
+		if task.Priority != "" && !IsValidPriority(string(task.Priority)) {
+			return nil, fmt.Errorf("line %d: invalid priority: %s", lineNo, task.Priority)
+		}
+		if task.Status != "" && !IsValidStatus(string(task.Status)) {
+			return nil, fmt.Errorf("line %d: invalid status: %s", lineNo, task.Status)
+		}
+		if tags := meta["tags"]; tags != "" {
+			task.Tags = strings.Split(tags, ",")
+		}
+		if blockedBy := meta["blocked_by"]; blockedBy != "" {
+			task.BlockedBy = strings.Split(blockedBy, ",")
+		}
+		snap.fillTaskDefaults(task)
+		snap.Tasks = append(snap.Tasks, task)
+		lastTask = task
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return snap, nil
+}
+
+// splitMarkdownMeta splits "text <!-- k=v k=v -->" into the text and its metadata
+func splitMarkdownMeta(s string) (string, map[string]string) {
+	meta := make(map[string]string)
+	start := strings.LastIndex(s, "<!--")
+	if start == -1 || !strings.HasSuffix(s, "-->") {
+		return strings.TrimSpace(s), meta
+	}
+	for _, field := range strings.Fields(s[start+len("<!--") : len(s)-len("-->")]) {
+		if k, v, ok := strings.Cut(field, "="); ok {
+			meta[k] = v
+		}
+	}
+	return strings.TrimSpace(s[:start]), meta
+}
+
+// readProjectDefaults sets a project's task defaults from their text in a
+// CSV or Markdown file
+func readProjectDefaults(p *Project, duration, due string) error {
+	if duration != "" {
+		d, err := ParseDuration(duration)
+		if err != nil {
+			return fmt.Errorf("invalid default duration: %w", err)
+		}
+		p.DefaultDuration = d
+	}
+	if due != "" {
+		if _, err := DeferDue(nil, due, time.Now()); err != nil {
+			return fmt.Errorf("invalid default due %q: %w", due, err)
+		}
+		p.DefaultDue = due
+	}
+	return nil
+}
+
+// fillProjectDefaults assigns an ID, shortcut, and creation time to projects
+// that were imported without them
+func (snap *Snapshot) fillProjectDefaults(p *Project) {
+	if p.ID == "" {
+		p.ID = snap.newID()
+	}
+	if p.Shortcut == "" {
+		p.Shortcut = shortID(p.ID)
+	}
+	if p.CreatedAt.IsZero() {
+		p.CreatedAt = time.Now()
+	}
+}
+
+// fillTaskDefaults assigns an ID and creation time to tasks imported without them
+func (snap *Snapshot) fillTaskDefaults(t *Task) {
+	if t.ID == "" {
+		t.ID = snap.newID()
+	}
+	if t.CreatedAt.IsZero() {
+		t.CreatedAt = time.Now()
+	}
+}
+
+// newID generates an ID for an entry that had none in the source file and
+// remembers it, so imports can dedupe such entries by name instead
+func (snap *Snapshot) newID() string {
+	id := generateUUID()
+	if snap.generated == nil {
+		snap.generated = make(map[string]bool)
+	}
+	snap.generated[id] = true
+	return id
+}
@@ -0,0 +1,255 @@
+package storage
+
+import (
+	"bytes"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// newTestStore creates a JSONStore in a temp directory
+func newTestStore(t *testing.T) *JSONStore {
+	t.Helper()
+
+	store, err := NewJSONStore(filepath.Join(t.TempDir(), "test.json"))
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+	t.Cleanup(func() { store.Close() })
+	return store
+}
+
+func TestExportImportRoundTrip(t *testing.T) {
+	for _, format := range ValidFormats {
+		t.Run(format, func(t *testing.T) {
+			src := newTestStore(t)
+
+			project, _ := src.CreateProject("Work")
+			src.SetProjectShortcut(project.ID, "work")
+			projectDue := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+			src.SetProjectDueDate(project.ID, &projectDue)
+			src.SetProjectDefaults(project.ID, Duration1h, "+7d")
+			src.CreateProject("Empty")
+			task, _ := src.CreateTask(project.ID, "Write report, draft 2")
+			due := time.Date(2025, 12, 31, 0, 0, 0, 0, time.UTC)
+			src.SetTaskDueDate(task.ID, &due)
+			src.SetTaskDuration(task.ID, Duration2h)
+			src.AddTaskTag(task.ID, "q4")
+			src.SetTaskNote(task.ID, "Ask Sam for the figures\nthen > send")
+			src.SetTaskStatus(task.ID, StatusInProgress)
+			done, _ := src.CreateTask(project.ID, "Send invoice")
+			src.UpdateTask(done.ID, true)
+			src.AddTaskDependency(done.ID, task.ID)
+			dueAt := time.Date(2026, 1, 2, 14, 30, 0, 0, time.UTC)
+			src.SetTaskDueDate(done.ID, &dueAt)
+			src.ReorderTask(done.ID, 1)
+			inbox, _ := src.CreateTask("", "Call dentist")
+			src.AddTaskTag(inbox.ID, "health")
+
+			snap, err := ExportSnapshot(src)
+			if err != nil {
+				t.Fatalf("Failed to export: %v", err)
+			}
+			var buf bytes.Buffer
+			if err := WriteSnapshot(&buf, snap, format); err != nil {
+				t.Fatalf("Failed to write snapshot: %v", err)
+			}
+
+			parsed, err := ReadSnapshot(&buf, format)
+			if err != nil {
+				t.Fatalf("Failed to read snapshot: %v", err)
+			}
+
+			dst := newTestStore(t)
+			result, err := ImportSnapshot(dst, parsed)
+			if err != nil {
+				t.Fatalf("Failed to import: %v", err)
+			}
+			if result.ProjectsCreated != 2 || result.TasksCreated != 3 {
+				t.Errorf("Expected 2 projects and 3 tasks created, got %+v", result)
+			}
+
+			// Every field should survive the round trip
+			for _, p := range snap.Projects {
+				got, err := dst.GetProject(p.ID)
+				if err != nil {
+					t.Fatalf("Project %s missing after import: %v", p.Name, err)
+				}
+				if !sameJSON(got, p) {
+					t.Errorf("Project mismatch:\n  want %+v\n  got  %+v", p, got)
+				}
+			}
+			for _, task := range snap.Tasks {
+				got, err := dst.GetTask(task.ID)
+				if err != nil {
+					t.Fatalf("Task %s missing after import: %v", task.Name, err)
+				}
+				if !sameJSON(got, task) {
+					t.Errorf("Task mismatch:\n  want %+v\n  got  %+v", task, got)
+				}
+			}
+
+			// So should the order of the list
+			if tasks, _ := dst.ListTasks(project.ID); len(tasks) != 2 || tasks[0].ID != done.ID {
+				t.Errorf("Expected the reordered task first after import, got %v", tasks)
+			}
+
+			// Importing again is a no-op
+			result, _ = ImportSnapshot(dst, parsed)
+			if result.ProjectsCreated != 0 || result.TasksCreated != 0 || result.Unchanged != 5 {
+				t.Errorf("Expected re-import to be unchanged, got %+v", result)
+			}
+		})
+	}
+}
+
+func TestImportConflicts(t *testing.T) {
+	store := newTestStore(t)
+	project, _ := store.CreateProject("Work")
+	task, _ := store.CreateTask(project.ID, "Original name")
+
+	// Same task ID with a different name conflicts and is not overwritten
+	changed := *task
+	changed.Name = "Edited elsewhere"
+	result, err := ImportSnapshot(store, &Snapshot{Tasks: []*Task{&changed}})
+	if err != nil {
+		t.Fatalf("Failed to import: %v", err)
+	}
+	if len(result.Conflicts) != 1 {
+		t.Fatalf("Expected 1 conflict, got %+v", result)
+	}
+	got, _ := store.GetTask(task.ID)
+	if got.Name != "Original name" {
+		t.Errorf("Conflicting import should not overwrite, got name %q", got.Name)
+	}
+
+	// New project reusing an existing shortcut conflicts
+	clash := &Project{ID: "new-project-id", Name: "Clash", Shortcut: project.Shortcut, CreatedAt: time.Now()}
+	result, _ = ImportSnapshot(store, &Snapshot{Projects: []*Project{clash}})
+	if len(result.Conflicts) != 1 || !strings.Contains(result.Conflicts[0], "shortcut already in use") {
+		t.Errorf("Expected shortcut conflict, got %+v", result)
+	}
+}
+
+func TestReadMarkdownPlainChecklist(t *testing.T) {
+	input := "# Groceries\n\n- [ ] Milk\n- [x] Eggs\nSome notes\n"
+	snap, err := ReadSnapshot(strings.NewReader(input), FormatMarkdown)
+	if err != nil {
+		t.Fatalf("Failed to read markdown: %v", err)
+	}
+	if len(snap.Projects) != 1 || snap.Projects[0].Name != "Groceries" {
+		t.Fatalf("Expected one Groceries project, got %+v", snap.Projects)
+	}
+	if len(snap.Tasks) != 2 {
+		t.Fatalf("Expected 2 tasks, got %d", len(snap.Tasks))
+	}
+	if snap.Tasks[0].Name != "Milk" || snap.Tasks[0].Done {
+		t.Errorf("Unexpected first task: %+v", snap.Tasks[0])
+	}
+	if !snap.Tasks[1].Done {
+		t.Errorf("Expected Eggs to be done")
+	}
+	if snap.Tasks[0].ProjectID != snap.Projects[0].ID {
+		t.Errorf("Task should reference its heading's project")
+	}
+}
+
+func TestPlanImportDedupesByName(t *testing.T) {
+	store := newTestStore(t)
+	input := "# Groceries\n\n- [ ] Milk\n- [ ] Eggs\n"
+
+	snap, _ := ReadSnapshot(strings.NewReader(input), FormatMarkdown)
+	if _, err := ImportSnapshot(store, snap); err != nil {
+		t.Fatalf("Failed to import: %v", err)
+	}
+
+	// Re-importing the same hand-written file plans only skips, keyed by name
+	input += "- [ ] Bread\n"
+	snap, _ = ReadSnapshot(strings.NewReader(input), FormatMarkdown)
+	plan, err := PlanImport(store, snap)
+	if err != nil {
+		t.Fatalf("Failed to plan import: %v", err)
+	}
+	if plan.Count(ImportSkip) != 3 || plan.Count(ImportCreate) != 1 {
+		t.Fatalf("Expected 3 skips and 1 create, got %+v", plan.Entries)
+	}
+	for _, e := range plan.Entries {
+		if e.Action == ImportCreate && e.Key != "name:Groceries/Bread" {
+			t.Errorf("Unexpected create key: %s", e.Key)
+		}
+	}
+
+	// Planning must not modify the store
+	tasks, _ := store.ListAllTasks()
+	if len(tasks) != 2 {
+		t.Errorf("Dry-run planning should not create tasks, got %d", len(tasks))
+	}
+
+	// Applying the plan adds the new task to the existing project
+	result, _ := ApplyImport(store, plan)
+	if result.TasksCreated != 1 || result.ProjectsCreated != 0 {
+		t.Errorf("Expected only Bread to be created, got %+v", result)
+	}
+	projects, _ := store.ListProjects()
+	if len(projects) != 1 {
+		t.Errorf("Expected a single Groceries project, got %d", len(projects))
+	}
+}
+
+func TestImportQueuesConflicts(t *testing.T) {
+	store := newTestStore(t)
+	project, _ := store.CreateProject("Work")
+	task, _ := store.CreateTask(project.ID, "Report")
+
+	theirs := *task
+	theirs.Done = true
+	snap := &Snapshot{Tasks: []*Task{&theirs}}
+
+	result, _ := ImportSnapshot(store, snap)
+	if result.Queued != 1 {
+		t.Fatalf("Expected 1 queued conflict, got %+v", result)
+	}
+
+	// Importing the same version again doesn't queue a duplicate
+	result, _ = ImportSnapshot(store, snap)
+	if result.Queued != 0 {
+		t.Errorf("Expected no duplicate conflict, got %+v", result)
+	}
+
+	conflicts, _ := store.ListConflicts()
+	if len(conflicts) != 1 || conflicts[0].Kind != "task" || conflicts[0].EntityID != task.ID {
+		t.Fatalf("Unexpected conflict queue: %+v", conflicts)
+	}
+	if err := store.DeleteConflict(conflicts[0].ID); err != nil {
+		t.Errorf("Failed to delete conflict: %v", err)
+	}
+}
+
+func TestMergeTasks(t *testing.T) {
+	early := time.Date(2025, 1, 10, 0, 0, 0, 0, time.UTC)
+	late := time.Date(2025, 1, 20, 0, 0, 0, 0, time.UTC)
+
+	mine := &Task{ID: "t1", ProjectID: "p1", Name: "Mine", DueDate: &late, Duration: Duration30m, Tags: []string{"a"}}
+	theirs := &Task{ID: "t1", ProjectID: "p1", Name: "Theirs", Done: true, DueDate: &early, Duration: Duration2h, Tags: []string{"a", "b"}}
+
+	merged := MergeTasks(mine, theirs)
+	if merged.Name != "Mine" {
+		t.Errorf("Expected mine's name, got %q", merged.Name)
+	}
+	if !merged.Done {
+		t.Error("Expected merged task to be done")
+	}
+	if !merged.DueDate.Equal(early) {
+		t.Errorf("Expected earlier due date, got %v", merged.DueDate)
+	}
+	if merged.Duration != Duration2h {
+		t.Errorf("Expected longer duration, got %s", merged.Duration)
+	}
+	if len(merged.Tags) != 2 {
+		t.Errorf("Expected union of tags, got %v", merged.Tags)
+	}
+	if len(mine.Tags) != 1 {
+		t.Errorf("Merge should not modify mine, got tags %v", mine.Tags)
+	}
+}
@@ -0,0 +1,395 @@
+package storage
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Apps whose CSV exports /import can read
+const (
+	SourceTodoist = "todoist"
+	SourceAsana   = "asana"
+)
+
+// ExternalSources lists the apps /import reads exports from
+var ExternalSources = []string{SourceTodoist, SourceAsana}
+
+// IsExternalSource reports whether s names an app in ExternalSources
+func IsExternalSource(s string) bool {
+	for _, source := range ExternalSources {
+		if strings.EqualFold(s, source) {
+			return true
+		}
+	}
+	return false
+}
+
+// MappingReport explains how an external export became projects and tasks:
+// which columns became which fields, and every value that was dropped or
+// had to be kept some other way
+type MappingReport struct {
+	Source   string
+	Fields   []string // "column -> field" lines
+	Notes    []string // line-by-line details, e.g. a date that couldn't be read
+	Projects int
+	Tasks    int
+}
+
+// note adds a detail about one line of the export
+func (r *MappingReport) note(line int, format string, args ...any) {
+	r.Notes = append(r.Notes, fmt.Sprintf("line %d: ", line)+fmt.Sprintf(format, args...))
+}
+
+// ReadExternal parses a CSV export from Todoist or Asana into a snapshot.
+// Every entry gets a generated ID, so importing the file again matches
+// projects and tasks by name instead of duplicating them. filename names
+// the project when the export doesn't, as Todoist's one-file-per-project
+// exports don't.
+func ReadExternal(r io.Reader, source, filename string) (*Snapshot, *MappingReport, error) {
+	header, rows, err := readExternalCSV(r)
+	if err != nil {
+		return nil, nil, err
+	}
+	project := strings.TrimSpace(strings.TrimSuffix(filepath.Base(filename), filepath.Ext(filename)))
+
+	switch strings.ToLower(source) {
+	case SourceTodoist:
+		return readTodoist(header, rows, project)
+	case SourceAsana:
+		return readAsana(header, rows, project)
+	default:
+		return nil, nil, fmt.Errorf("unknown source: %s (use %s)", source, strings.Join(ExternalSources, " or "))
+	}
+}
+
+// readExternalCSV reads a CSV file whose rows may be ragged, returning its
+// header keyed by upper-cased column name
+func readExternalCSV(r io.Reader) (map[string]int, [][]string, error) {
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = -1
+	cr.LazyQuotes = true
+	rows, err := cr.ReadAll()
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid CSV: %w", err)
+	}
+	if len(rows) == 0 {
+		return nil, nil, fmt.Errorf("invalid CSV: the file is empty")
+	}
+	header := make(map[string]int)
+	for i, name := range rows[0] {
+		// Exports written on Windows can start with a byte order mark
+		name = strings.TrimPrefix(name, "\ufeff")
+		header[strings.ToUpper(strings.TrimSpace(name))] = i
+	}
+	return header, rows[1:], nil
+}
+
+// externalGetter returns a function reading a row's value by column name
+func externalGetter(header map[string]int) func(row []string, name string) string {
+	return func(row []string, name string) string {
+		if i, ok := header[strings.ToUpper(name)]; ok && i < len(row) {
+			return strings.TrimSpace(row[i])
+		}
+		return ""
+	}
+}
+
+// parseExternalDate reads the dates exports write: twooms's own format, an
+// RFC 3339 timestamp, or a spelled-out date with a year
+func parseExternalDate(s string) (time.Time, bool) {
+	if due, err := ParseDue(s); err == nil {
+		return due, true
+	}
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC), true
+	}
+	for _, layout := range []string{"Jan 2 2006", "Jan 2, 2006", "January 2 2006", "January 2, 2006", "2 Jan 2006", "2 January 2006", "01/02/2006"} {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// addTag tags a task with a section, label, or tag name from an export,
+// as Slug spells it, unless that's empty or already there
+func addTag(t *Task, name string) {
+	tag := Slug(name)
+	if tag == "" || t.HasTag(tag) {
+		return
+	}
+	t.Tags = append(t.Tags, tag)
+}
+
+// addNote appends a paragraph to a task's note
+func addNote(t *Task, text string) {
+	if text == "" {
+		return
+	}
+	if t.Note != "" {
+		text = t.Note + "\n" + text
+	}
+	t.Note = text
+}
+
+// todoistPriorities maps Todoist's PRIORITY column, where 1 is p1, the
+// highest; p4 is Todoist's default and means no priority
+var todoistPriorities = map[string]Priority{
+	"1": PriorityUrgent,
+	"2": PriorityHigh,
+	"3": PriorityMedium,
+	"4": "",
+}
+
+// readTodoist reads Todoist's project CSV export: one file per project,
+// with TYPE rows of task, section, and note. Tasks follow the section
+// above them, and notes the task above them.
+func readTodoist(header map[string]int, rows [][]string, projectName string) (*Snapshot, *MappingReport, error) {
+	for _, required := range []string{"TYPE", "CONTENT"} {
+		if _, ok := header[required]; !ok {
+			return nil, nil, fmt.Errorf("not a Todoist export: missing %s column", required)
+		}
+	}
+	get := externalGetter(header)
+	report := &MappingReport{
+		Source: SourceTodoist,
+		Fields: []string{
+			"file name -> project \"" + projectName + "\"",
+			"CONTENT -> task name; @labels in it -> tags",
+			"section rows -> a tag on the tasks below them",
+			"DESCRIPTION and note rows -> note",
+			"PRIORITY 1/2/3/4 -> urgent/high/medium/none",
+			"DATE -> due date",
+			"DURATION in minutes -> duration",
+			"INDENT -> subtasks become tasks, with \"Subtask of\" in the note",
+		},
+	}
+
+	snap := &Snapshot{}
+	project := &Project{Name: projectName}
+	snap.fillProjectDefaults(project)
+	snap.Projects = append(snap.Projects, project)
+	report.Projects = 1
+
+	var section string
+	var last *Task
+	var parents []*Task // the open task at each indent level, for subtasks
+	for i, row := range rows {
+		lineNo := i + 2
+		content := get(row, "CONTENT")
+		switch strings.ToLower(get(row, "TYPE")) {
+		case "section":
+			section = content
+			last = nil
+			parents = nil
+		case "note":
+			if last == nil {
+				report.note(lineNo, "note with no task above it skipped")
+				continue
+			}
+			addNote(last, content)
+		case "task":
+			if content == "" {
+				report.note(lineNo, "task with no name skipped")
+				continue
+			}
+			task := &Task{ProjectID: project.ID}
+			var words []string
+			for _, word := range strings.Fields(content) {
+				if label, ok := strings.CutPrefix(word, "@"); ok && label != "" {
+					addTag(task, label)
+					continue
+				}
+				words = append(words, word)
+			}
+			task.Name = strings.Join(words, " ")
+			if task.Name == "" {
+				task.Name = content
+			}
+			addTag(task, section)
+			addNote(task, get(row, "DESCRIPTION"))
+
+			priority := get(row, "PRIORITY")
+			if p, ok := todoistPriorities[priority]; ok {
+				task.Priority = p
+			} else if priority != "" {
+				report.note(lineNo, "unknown priority %q left unset", priority)
+			}
+
+			if date := get(row, "DATE"); date != "" {
+				if due, ok := parseExternalDate(date); ok {
+					task.DueDate = &due
+				} else {
+					addNote(task, "Todoist date: "+date)
+					report.note(lineNo, "date %q (recurring or relative) kept in the note of %q", date, task.Name)
+				}
+			}
+
+			if duration := get(row, "DURATION"); duration != "" {
+				minutes, err := strconv.Atoi(duration)
+				unit := strings.ToLower(get(row, "DURATION_UNIT"))
+				switch {
+				case err != nil || minutes <= 0:
+					report.note(lineNo, "duration %q dropped", duration)
+				case unit != "" && unit != "minute":
+					report.note(lineNo, "duration of %s %s dropped; only minutes carry over", duration, unit)
+				case Duration(minutes) > MaxDuration:
+					report.note(lineNo, "duration of %d minutes dropped; the longest is %s", minutes, FormatMinutes(int(MaxDuration)))
+				default:
+					task.Duration = Duration(minutes)
+				}
+			}
+
+			indent, _ := strconv.Atoi(get(row, "INDENT"))
+			if indent < 1 {
+				indent = 1
+			}
+			if indent > len(parents)+1 {
+				indent = len(parents) + 1
+			}
+			parents = append(parents[:indent-1], task)
+			if indent > 1 {
+				addNote(task, "Subtask of: "+parents[indent-2].Name)
+			}
+
+			snap.fillTaskDefaults(task)
+			snap.Tasks = append(snap.Tasks, task)
+			last = task
+			report.Tasks++
+		case "":
+			// Blank lines separate sections in Todoist's files
+		default:
+			report.note(lineNo, "row of type %q skipped", get(row, "TYPE"))
+		}
+	}
+
+	return snap, report, nil
+}
+
+// asanaDefaultSection is the section Asana puts tasks in when a project
+// has none, so it doesn't become a tag
+const asanaDefaultSection = "Untitled section"
+
+// readAsana reads Asana's project CSV export: one row per task, naming the
+// task's projects, section, tags, and dependencies by column
+func readAsana(header map[string]int, rows [][]string, fileProject string) (*Snapshot, *MappingReport, error) {
+	if _, ok := header["NAME"]; !ok {
+		return nil, nil, fmt.Errorf("not an Asana export: missing Name column")
+	}
+	get := externalGetter(header)
+	report := &MappingReport{
+		Source: SourceAsana,
+		Fields: []string{
+			"Projects -> project (the first one; the file name when empty)",
+			"Name -> task name",
+			"Section/Column and Tags -> tags",
+			"Notes -> note",
+			"Due Date -> due date",
+			"Completed At -> done, with its completion date",
+			"Created At -> creation date",
+			"Blocked By (Dependencies) -> blocked by",
+			"Parent task -> subtasks become tasks, with \"Subtask of\" in the note",
+		},
+	}
+	if _, ok := header["ASSIGNEE"]; ok {
+		report.Fields = append(report.Fields, "Assignee, Start Date, and other columns are not imported")
+	}
+
+	snap := &Snapshot{}
+	projects := make(map[string]*Project) // by lower-cased name
+	byAsanaID := make(map[string]*Task)
+	blockers := make(map[*Task][]string) // Asana IDs or names, resolved once every task is read
+	blockerLines := make(map[*Task]int)
+	for i, row := range rows {
+		lineNo := i + 2
+		name := get(row, "Name")
+		if name == "" {
+			continue
+		}
+
+		projectName := fileProject
+		if listed := get(row, "Projects"); listed != "" {
+			names := strings.Split(listed, ",")
+			projectName = strings.TrimSpace(names[0])
+			if len(names) > 1 {
+				report.note(lineNo, "%q is also in %s; imported into %s only", name, strings.TrimSpace(strings.Join(names[1:], ",")), projectName)
+			}
+		}
+		project, ok := projects[strings.ToLower(projectName)]
+		if !ok {
+			project = &Project{Name: projectName}
+			snap.fillProjectDefaults(project)
+			snap.Projects = append(snap.Projects, project)
+			projects[strings.ToLower(projectName)] = project
+			report.Projects++
+		}
+
+		task := &Task{ProjectID: project.ID, Name: name, Note: get(row, "Notes")}
+		if section := get(row, "Section/Column"); section != "" && !strings.EqualFold(section, asanaDefaultSection) {
+			addTag(task, section)
+		}
+		for _, tag := range strings.Split(get(row, "Tags"), ",") {
+			addTag(task, tag)
+		}
+		if parent := get(row, "Parent task"); parent != "" {
+			addNote(task, "Subtask of: "+parent)
+		}
+
+		if due := get(row, "Due Date"); due != "" {
+			if dueDate, ok := parseExternalDate(due); ok {
+				task.DueDate = &dueDate
+			} else {
+				report.note(lineNo, "due date %q of %q couldn't be read and was dropped", due, name)
+			}
+		}
+		if completed := get(row, "Completed At"); completed != "" {
+			task.Done = true
+			if completedAt, ok := parseExternalDate(completed); ok {
+				task.CompletedAt = &completedAt
+			}
+		}
+		if created := get(row, "Created At"); created != "" {
+			if createdAt, ok := parseExternalDate(created); ok {
+				task.CreatedAt = createdAt
+			}
+		}
+
+		if deps := get(row, "Blocked By (Dependencies)"); deps != "" {
+			for _, dep := range strings.Split(deps, ",") {
+				if dep = strings.TrimSpace(dep); dep != "" {
+					blockers[task] = append(blockers[task], dep)
+				}
+			}
+			blockerLines[task] = lineNo
+		}
+
+		snap.fillTaskDefaults(task)
+		snap.Tasks = append(snap.Tasks, task)
+		if id := get(row, "Task ID"); id != "" {
+			byAsanaID[id] = task
+		}
+		report.Tasks++
+	}
+
+	// Dependencies name Asana task IDs, or in older exports task names
+	for _, task := range snap.Tasks {
+		for _, dep := range blockers[task] {
+			blocker := byAsanaID[dep]
+			if blocker == nil {
+				blocker = findTask(snap.Tasks, func(t *Task) bool { return strings.EqualFold(t.Name, dep) })
+			}
+			if blocker == nil || blocker == task {
+				report.note(blockerLines[task], "blocker %q of %q isn't in the export and was dropped", dep, task.Name)
+				continue
+			}
+			task.BlockedBy = append(task.BlockedBy, blocker.ID)
+		}
+	}
+
+	return snap, report, nil
+}
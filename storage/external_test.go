@@ -0,0 +1,92 @@
+package storage
+
+import (
+	"strings"
+	"testing"
+)
+
+const todoistExport = "\ufeffTYPE,CONTENT,DESCRIPTION,PRIORITY,INDENT,AUTHOR,RESPONSIBLE,DATE,DATE_LANG,TIMEZONE,DURATION,DURATION_UNIT\n" +
+	"section,Groceries,,,,,,,,,,\n" +
+	"task,Buy milk @store,Whole milk,1,1,Sam (1),,2030-03-14,en,UTC,15,minute\n" +
+	"note,Two liters,,,,,,,,,,\n" +
+	"task,Oat milk too,,4,2,Sam (1),,,en,UTC,,\n" +
+	",,,,,,,,,,,\n" +
+	"section,Home,,,,,,,,,,\n" +
+	"task,Water plants,,2,1,Sam (1),,every monday,en,UTC,1,day\n"
+
+func TestReadTodoist(t *testing.T) {
+	snap, report, err := ReadExternal(strings.NewReader(todoistExport), SourceTodoist, "/exports/Errands.csv")
+	if err != nil {
+		t.Fatalf("Failed to read: %v", err)
+	}
+	if len(snap.Projects) != 1 || snap.Projects[0].Name != "Errands" {
+		t.Fatalf("Expected the file name as the project, got %+v", snap.Projects)
+	}
+	if len(snap.Tasks) != 3 || report.Tasks != 3 {
+		t.Fatalf("Expected 3 tasks, got %d (report %d)", len(snap.Tasks), report.Tasks)
+	}
+
+	milk := snap.Tasks[0]
+	if milk.Name != "Buy milk" || milk.Priority != PriorityUrgent || milk.Duration != 15 ||
+		milk.DueDate == nil || milk.DueDate.Format("2006-01-02") != "2030-03-14" {
+		t.Errorf("Unexpected first task: %+v", milk)
+	}
+	if !milk.HasTag("store") || !milk.HasTag("groceries") || milk.Note != "Whole milk\nTwo liters" {
+		t.Errorf("Expected label and section tags and a note, got tags %v note %q", milk.Tags, milk.Note)
+	}
+	if oat := snap.Tasks[1]; oat.Priority != "" || oat.Note != "Subtask of: Buy milk" {
+		t.Errorf("Expected a flattened subtask with no priority, got %+v", oat)
+	}
+
+	plants := snap.Tasks[2]
+	if plants.DueDate != nil || plants.Duration != 0 || !strings.Contains(plants.Note, "Todoist date: every monday") || !plants.HasTag("home") {
+		t.Errorf("Expected the recurring date kept in the note, got %+v", plants)
+	}
+	if len(report.Notes) != 2 {
+		t.Errorf("Expected notes on the date and the duration, got %v", report.Notes)
+	}
+
+	if _, _, err := ReadExternal(strings.NewReader("Name,Notes\nx,y\n"), SourceTodoist, "x.csv"); err == nil {
+		t.Error("Expected an error for a file that isn't a Todoist export")
+	}
+}
+
+func TestReadAsanaAndReimport(t *testing.T) {
+	export := "Task ID,Created At,Completed At,Last Modified,Name,Section/Column,Assignee,Assignee Email,Start Date,Due Date,Tags,Notes,Projects,Parent task,Blocked By (Dependencies),Blocking (Dependencies)\n" +
+		"101,2030-01-02,,2030-01-03,Draft plan,Untitled section,,,,2030-02-01,\"Q3 Launch,docs\",First pass,Launch,,,102\n" +
+		"102,2030-01-02,2030-01-10,2030-01-10,Review plan,Doing,,,,,,,\"Launch, Marketing\",,101,\n" +
+		"103,2030-01-04,,,Book venue,,,,,,,,,Draft plan,999,\n"
+
+	snap, report, err := ReadExternal(strings.NewReader(export), SourceAsana, "asana-export.csv")
+	if err != nil {
+		t.Fatalf("Failed to read: %v", err)
+	}
+	if len(snap.Projects) != 2 || snap.Projects[0].Name != "Launch" || snap.Projects[1].Name != "asana-export" {
+		t.Fatalf("Expected Launch, and the file name for the task with no project, got %+v", snap.Projects)
+	}
+
+	draft, review, venue := snap.Tasks[0], snap.Tasks[1], snap.Tasks[2]
+	if draft.Done || draft.DueDate == nil || !draft.HasTag("q3-launch") || !draft.HasTag("docs") || draft.HasTag("untitled-section") {
+		t.Errorf("Unexpected first task: %+v", draft)
+	}
+	if !review.Done || review.CompletedAt == nil || !review.HasTag("doing") ||
+		len(review.BlockedBy) != 1 || review.BlockedBy[0] != draft.ID {
+		t.Errorf("Expected a done task blocked by the first, got %+v", review)
+	}
+	if venue.Note != "Subtask of: Draft plan" || len(venue.BlockedBy) != 0 {
+		t.Errorf("Expected a flattened subtask with the unknown blocker dropped, got %+v", venue)
+	}
+	if len(report.Notes) != 2 {
+		t.Errorf("Expected notes on the second project and the unknown blocker, got %v", report.Notes)
+	}
+
+	// Importing the export again matches everything by name
+	store := newTestStore(t)
+	if result, err := ImportSnapshot(store, snap); err != nil || result.ProjectsCreated != 2 || result.TasksCreated != 3 {
+		t.Fatalf("Expected 2 projects and 3 tasks, got %+v, %v", result, err)
+	}
+	again, _, _ := ReadExternal(strings.NewReader(export), SourceAsana, "asana-export.csv")
+	if result, err := ImportSnapshot(store, again); err != nil || result.ProjectsCreated != 0 || result.TasksCreated != 0 {
+		t.Errorf("Expected nothing new on a second import, got %+v, %v", result, err)
+	}
+}
@@ -0,0 +1,165 @@
+package storage
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+// icsPriorities maps priorities to iCalendar's 1 (highest) to 9 (lowest)
+var icsPriorities = map[Priority]int{
+	PriorityUrgent: 1,
+	PriorityHigh:   3,
+	PriorityMedium: 5,
+	PriorityLow:    9,
+}
+
+// WriteICS writes the snapshot's tasks that have due dates as an iCalendar
+// file. Tasks become VTODOs due on their date, with STATUS:COMPLETED once
+// done (IN-PROCESS while in progress); with events set they become all-day VEVENTs instead, for calendar
+// apps that don't show to-dos (done ones are prefixed "Done: ", since events
+// have no completed status). Nothing depends on the current time, so an
+// unchanged store always produces the same file.
+func WriteICS(w io.Writer, snap *Snapshot, events bool) error {
+	projects := make(map[string]*Project)
+	for _, p := range snap.Projects {
+		projects[p.ID] = p
+	}
+
+	var tasks []*Task
+	for _, t := range snap.Tasks {
+		if t.DueDate != nil {
+			tasks = append(tasks, t)
+		}
+	}
+	sort.SliceStable(tasks, func(i, j int) bool {
+		if !tasks[i].DueDate.Equal(*tasks[j].DueDate) {
+			return tasks[i].DueDate.Before(*tasks[j].DueDate)
+		}
+		if !tasks[i].CreatedAt.Equal(tasks[j].CreatedAt) {
+			return tasks[i].CreatedAt.Before(tasks[j].CreatedAt)
+		}
+		return tasks[i].ID < tasks[j].ID
+	})
+
+	name := "Twooms"
+	if len(snap.Projects) == 1 {
+		name += ": " + snap.Projects[0].Name
+	}
+
+	bw := bufio.NewWriter(w)
+	line := func(s string) { writeICSLine(bw, s) }
+	line("BEGIN:VCALENDAR")
+	line("VERSION:2.0")
+	line("PRODID:-//twooms//twooms//EN")
+	line("CALSCALE:GREGORIAN")
+	line("X-WR-CALNAME:" + escapeICSText(name))
+
+	for _, t := range tasks {
+		due := t.DueDate.Format("20060102")
+		component := "VTODO"
+		if events {
+			component = "VEVENT"
+		}
+
+		line("BEGIN:" + component)
+		line("UID:" + t.ID + "@twooms")
+		line("DTSTAMP:" + t.CreatedAt.UTC().Format("20060102T150405Z"))
+		summary := CleanName(t.Name)
+		if events && t.Done {
+			summary = "Done: " + summary
+		}
+		line("SUMMARY:" + escapeICSText(summary))
+		// A due time is written as floating local time, as the app shows it
+		timed := HasDueTime(*t.DueDate)
+		if events {
+			if timed {
+				line("DTSTART:" + t.DueDate.Format("20060102T150405"))
+				if minutes := t.Duration.ToMinutes(); minutes > 0 {
+					line(fmt.Sprintf("DURATION:PT%dM", minutes))
+				}
+			} else {
+				line("DTSTART;VALUE=DATE:" + due)
+				line("DTEND;VALUE=DATE:" + t.DueDate.AddDate(0, 0, 1).Format("20060102"))
+			}
+			line("TRANSP:TRANSPARENT")
+		} else {
+			if timed {
+				line("DUE:" + t.DueDate.Format("20060102T150405"))
+			} else {
+				line("DUE;VALUE=DATE:" + due)
+			}
+			switch t.CurrentStatus() {
+			case StatusDone:
+				line("STATUS:COMPLETED")
+			case StatusInProgress:
+				line("STATUS:IN-PROCESS")
+			default:
+				line("STATUS:NEEDS-ACTION")
+			}
+		}
+		if priority, ok := icsPriorities[t.Priority]; ok {
+			line(fmt.Sprintf("PRIORITY:%d", priority))
+		}
+
+		var categories []string
+		if p := projects[t.ProjectID]; p != nil {
+			categories = append(categories, escapeICSText(CleanName(p.Name)))
+		}
+		for _, tag := range t.Tags {
+			categories = append(categories, escapeICSText(tag))
+		}
+		if len(categories) > 0 {
+			line("CATEGORIES:" + strings.Join(categories, ","))
+		}
+		if description := icsDescription(t, projects[t.ProjectID]); description != "" {
+			line("DESCRIPTION:" + escapeICSText(description))
+		}
+		line("END:" + component)
+	}
+
+	line("END:VCALENDAR")
+	return bw.Flush()
+}
+
+// icsDescription lists the task details a calendar has no field for
+func icsDescription(t *Task, project *Project) string {
+	var lines []string
+	if project != nil {
+		lines = append(lines, "Project: "+project.Name)
+	}
+	if t.Duration != 0 {
+		lines = append(lines, "Estimate: "+t.Duration.String())
+	}
+	if t.Context != "" {
+		lines = append(lines, "Context: @"+t.Context)
+	}
+	if t.Note != "" {
+		lines = append(lines, "", t.Note)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// escapeICSText escapes a TEXT value (RFC 5545 section 3.3.11)
+func escapeICSText(s string) string {
+	return strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\r\n", `\n`, "\n", `\n`).Replace(s)
+}
+
+// writeICSLine writes a content line with CRLF, folding it into 75-octet
+// pieces without splitting a UTF-8 character
+func writeICSLine(w *bufio.Writer, s string) {
+	limit := 75
+	for len(s) > limit {
+		cut := limit
+		for cut > 0 && !utf8.RuneStart(s[cut]) {
+			cut--
+		}
+		w.WriteString(s[:cut] + "\r\n ")
+		s = s[cut:]
+		limit = 74 // continuation lines start with a space
+	}
+	w.WriteString(s + "\r\n")
+}
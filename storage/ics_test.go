@@ -0,0 +1,85 @@
+package storage
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWriteICS(t *testing.T) {
+	created := time.Date(2026, 1, 5, 9, 30, 0, 0, time.UTC)
+	later := time.Date(2026, 3, 2, 0, 0, 0, 0, time.UTC)
+	sooner := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	deadline := time.Date(2026, 3, 3, 14, 30, 0, 0, time.UTC)
+	snap := &Snapshot{
+		Projects: []*Project{{ID: "p1", Name: "Work"}},
+		Tasks: []*Task{
+			{ID: "t1", ProjectID: "p1", Name: "Send invoice; then, file it", DueDate: &later, Done: true, CreatedAt: created},
+			{ID: "t2", ProjectID: "p1", Name: "Write report", DueDate: &sooner, Priority: PriorityHigh, Duration: Duration2h,
+				Tags: []string{"q4"}, Note: "Ask Sam\n" + strings.Repeat("x", 100), CreatedAt: created},
+			{ID: "t3", ProjectID: "p1", Name: "Someday", CreatedAt: created},
+			{ID: "t4", ProjectID: "p1", Name: "Submit grant", DueDate: &deadline, Duration: Duration30m, Status: StatusInProgress, CreatedAt: created},
+		},
+	}
+
+	var buf bytes.Buffer
+	if err := WriteICS(&buf, snap, false); err != nil {
+		t.Fatalf("Failed to write ICS: %v", err)
+	}
+	out := buf.String()
+
+	var again bytes.Buffer
+	WriteICS(&again, snap, false)
+	if again.String() != out {
+		t.Error("Expected identical output for the same snapshot")
+	}
+
+	for _, want := range []string{
+		"BEGIN:VCALENDAR\r\n",
+		"X-WR-CALNAME:Twooms: Work\r\n",
+		"UID:t2@twooms\r\nDTSTAMP:20260105T093000Z\r\nSUMMARY:Write report\r\nDUE;VALUE=DATE:20260301\r\nSTATUS:NEEDS-ACTION\r\nPRIORITY:3\r\nCATEGORIES:Work,q4\r\n",
+		`SUMMARY:Send invoice\; then\, file it`,
+		"DUE;VALUE=DATE:20260302\r\nSTATUS:COMPLETED\r\n",
+		"SUMMARY:Submit grant\r\nDUE:20260303T143000\r\nSTATUS:IN-PROCESS\r\n", // a due time is floating
+		"END:VCALENDAR\r\n",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("Expected output to contain %q, got:\n%s", want, out)
+		}
+	}
+	if strings.Contains(out, "Someday") {
+		t.Error("Expected tasks without a due date to be left out")
+	}
+	if strings.Index(out, "UID:t2") > strings.Index(out, "UID:t1") {
+		t.Error("Expected tasks in due date order")
+	}
+
+	// Long lines are folded, and unfold back to the escaped description
+	for _, line := range strings.Split(strings.TrimSuffix(out, "\r\n"), "\r\n") {
+		if len(line) > 75 {
+			t.Errorf("Expected lines of at most 75 octets, got %d: %q", len(line), line)
+		}
+	}
+	unfolded := strings.ReplaceAll(out, "\r\n ", "")
+	if !strings.Contains(unfolded, `DESCRIPTION:Project: Work\nEstimate: 2h\n\nAsk Sam\n`+strings.Repeat("x", 100)+"\r\n") {
+		t.Errorf("Expected the unfolded description, got:\n%s", unfolded)
+	}
+
+	buf.Reset()
+	WriteICS(&buf, snap, true)
+	events := buf.String()
+	for _, want := range []string{
+		"BEGIN:VEVENT\r\n",
+		"SUMMARY:Done: Send invoice",
+		"DTSTART;VALUE=DATE:20260302\r\nDTEND;VALUE=DATE:20260303\r\n",
+		"DTSTART:20260303T143000\r\nDURATION:PT30M\r\n",
+	} {
+		if !strings.Contains(events, want) {
+			t.Errorf("Expected events output to contain %q, got:\n%s", want, events)
+		}
+	}
+	if strings.Contains(events, "VTODO") || strings.Contains(events, "STATUS:") {
+		t.Errorf("Expected only VEVENTs without a status, got:\n%s", events)
+	}
+}
@@ -0,0 +1,277 @@
+package storage
+
+import (
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// ImportAction describes what an import will do with a single entry
+type ImportAction string
+
+const (
+	ImportCreate    ImportAction = "create"    // new entry, will be inserted
+	ImportUnchanged ImportAction = "unchanged" // same ID already present with identical contents
+	ImportSkip      ImportAction = "skip"      // duplicate by name, or its project can't be imported
+	ImportConflict  ImportAction = "conflict"  // same ID or shortcut present with different contents
+)
+
+// ImportPlanEntry is one planned action for a project or task in a snapshot
+type ImportPlanEntry struct {
+	Action ImportAction
+	Kind   string // "project" or "task"
+	Name   string
+	Key    string // dedupe key: "id:<uuid>" or "name:<project>[/<task>]"
+	Reason string
+
+	project *Project
+	task    *Task
+	differs bool // same ID exists with different contents (queued as a conflict on apply)
+}
+
+// ImportPlan lists the actions an import would take, without touching the store
+type ImportPlan struct {
+	Source  string // recorded on queued conflicts (e.g., the imported file path)
+	Entries []*ImportPlanEntry
+}
+
+// ImportResult summarizes what an import did
+type ImportResult struct {
+	ProjectsCreated int
+	TasksCreated    int
+	Unchanged       int      // entries already present with identical contents
+	Skipped         []string // entries skipped as duplicates or because their project was skipped
+	Conflicts       []string // entries skipped because they differ from the store
+	Queued          int      // conflicts queued for resolution with /conflicts
+}
+
+// Count returns how many entries in the plan have the given action
+func (p *ImportPlan) Count(action ImportAction) int {
+	n := 0
+	for _, e := range p.Entries {
+		if e.Action == action {
+			n++
+		}
+	}
+	return n
+}
+
+// PlanImport works out how a snapshot would merge into a store. Entries are
+// matched by ID; entries whose IDs were generated during parsing (e.g. a
+// hand-written Markdown checklist) are matched by name instead so that
+// re-importing the same file doesn't create duplicates.
+func PlanImport(s Store, snap *Snapshot) (*ImportPlan, error) {
+	plan := &ImportPlan{}
+
+	existingProjects, err := s.ListProjects()
+	if err != nil {
+		return nil, err
+	}
+	existingTasks, err := listEveryTask(s, "")
+	if err != nil {
+		return nil, err
+	}
+
+	// projectTargets maps snapshot project IDs to the store project their tasks belong to
+	projectTargets := map[string]string{"": ""} // inbox tasks stay in the inbox
+	projectNames := map[string]string{"": InboxName}
+
+	for _, p := range snap.Projects {
+		entry := &ImportPlanEntry{Kind: "project", Name: p.Name, Key: "id:" + p.ID, project: p}
+		plan.Entries = append(plan.Entries, entry)
+		projectNames[p.ID] = p.Name
+
+		if existing := findProject(existingProjects, func(e *Project) bool { return e.ID == p.ID }); existing != nil {
+			projectTargets[p.ID] = existing.ID
+			if sameJSON(existing, p) {
+				entry.Action = ImportUnchanged
+			} else {
+				entry.Action = ImportConflict
+				entry.Reason = fmt.Sprintf("differs from existing project %q", existing.Name)
+				entry.differs = true
+			}
+			continue
+		}
+
+		if snap.generated[p.ID] {
+			entry.Key = "name:" + p.Name
+			if existing := findProject(existingProjects, func(e *Project) bool { return strings.EqualFold(e.Name, p.Name) }); existing != nil {
+				projectTargets[p.ID] = existing.ID
+				entry.Action = ImportSkip
+				entry.Reason = "project with this name already exists"
+				continue
+			}
+		}
+
+		if existing := findProject(existingProjects, func(e *Project) bool { return e.Shortcut == p.Shortcut }); existing != nil {
+			entry.Action = ImportConflict
+			entry.Reason = fmt.Sprintf("shortcut already in use by project: %s", existing.Name)
+			continue
+		}
+
+		projectTargets[p.ID] = p.ID
+		entry.Action = ImportCreate
+	}
+
+	for _, t := range snap.Tasks {
+		entry := &ImportPlanEntry{Kind: "task", Name: t.Name, Key: "id:" + t.ID, task: t}
+		plan.Entries = append(plan.Entries, entry)
+
+		if existing := findTask(existingTasks, func(e *Task) bool { return e.ID == t.ID }); existing != nil {
+			if sameJSON(existing, t) {
+				entry.Action = ImportUnchanged
+			} else {
+				entry.Action = ImportConflict
+				entry.Reason = fmt.Sprintf("differs from existing task %q", existing.Name)
+				entry.differs = true
+			}
+			continue
+		}
+
+		// Tasks may belong to a project outside the snapshot that already exists in the store
+		target, ok := projectTargets[t.ProjectID]
+		if !ok {
+			if _, err := s.GetProject(t.ProjectID); err == nil {
+				target, ok = t.ProjectID, true
+			}
+		}
+		if !ok {
+			entry.Action = ImportSkip
+			entry.Reason = "project not imported"
+			continue
+		}
+
+		if snap.generated[t.ID] {
+			entry.Key = "name:" + projectNames[t.ProjectID] + "/" + t.Name
+			if findTask(existingTasks, func(e *Task) bool { return e.ProjectID == target && strings.EqualFold(e.Name, t.Name) }) != nil {
+				entry.Action = ImportSkip
+				entry.Reason = "task with this name already exists in the project"
+				continue
+			}
+		}
+
+		entry.Action = ImportCreate
+		if target != t.ProjectID {
+			remapped := *t
+			remapped.ProjectID = target
+			entry.task = &remapped
+		}
+	}
+
+	return plan, nil
+}
+
+// ApplyImport carries out the create actions of a plan and queues conflicts
+// for entries that differ from the stored version, as one transaction
+// journaled as one import
+func ApplyImport(s Store, plan *ImportPlan) (*ImportResult, error) {
+	result := &ImportResult{}
+
+	queued, err := s.ListConflicts()
+	if err != nil {
+		return nil, err
+	}
+
+	op := "import"
+	if plan.Source != "" {
+		op = "import " + filepath.Base(plan.Source)
+	}
+	err = s.Transact(op, func(tx Store) error {
+		for _, e := range plan.Entries {
+			label := fmt.Sprintf("%s %s (%s)", e.Kind, e.Name, e.Key)
+			switch e.Action {
+			case ImportUnchanged:
+				result.Unchanged++
+			case ImportSkip:
+				result.Skipped = append(result.Skipped, label+": "+e.Reason)
+			case ImportConflict:
+				result.Conflicts = append(result.Conflicts, label+": "+e.Reason)
+				if e.differs && !alreadyQueued(queued, e) {
+					if err := tx.AddConflict(NewConflict(plan.Source, e.project, e.task)); err != nil {
+						return err
+					}
+					result.Queued++
+				}
+			case ImportCreate:
+				var err error
+				if e.project != nil {
+					err = tx.ImportProject(e.project)
+				} else {
+					err = tx.ImportTask(e.task)
+				}
+				if err != nil {
+					result.Conflicts = append(result.Conflicts, fmt.Sprintf("%s: %v", label, err))
+					continue
+				}
+				if e.project != nil {
+					result.ProjectsCreated++
+				} else {
+					result.TasksCreated++
+				}
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// ImportSnapshot merges a snapshot into a store. Entries whose IDs already
+// exist are left alone: identical entries count as unchanged, differing
+// entries are queued as conflicts rather than overwritten.
+func ImportSnapshot(s Store, snap *Snapshot) (*ImportResult, error) {
+	plan, err := PlanImport(s, snap)
+	if err != nil {
+		return nil, err
+	}
+	return ApplyImport(s, plan)
+}
+
+// alreadyQueued reports whether an identical incoming version is already in the conflict queue
+func alreadyQueued(queued []*Conflict, e *ImportPlanEntry) bool {
+	for _, c := range queued {
+		if e.project != nil && c.Project != nil && sameJSON(c.Project, e.project) {
+			return true
+		}
+		if e.task != nil && c.Task != nil && sameJSON(c.Task, e.task) {
+			return true
+		}
+	}
+	return false
+}
+
+func findProject(projects []*Project, match func(*Project) bool) *Project {
+	for _, p := range projects {
+		if match(p) {
+			return p
+		}
+	}
+	return nil
+}
+
+func findTask(tasks []*Task, match func(*Task) bool) *Task {
+	for _, t := range tasks {
+		if match(t) {
+			return t
+		}
+	}
+	return nil
+}
+
+// sameJSON reports whether two values serialize identically
+func sameJSON(a, b any) bool {
+	aj, errA := json.Marshal(a)
+	bj, errB := json.Marshal(b)
+	return errA == nil && errB == nil && string(aj) == string(bj)
+}
+
+// shortID returns the first 8 characters of an ID for display
+func shortID(id string) string {
+	if len(id) > 8 {
+		return id[:8]
+	}
+	return id
+}
@@ -0,0 +1,130 @@
+package storage
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// inlinePriorities maps !p1-!p4 shorthands to priorities
+var inlinePriorities = map[string]Priority{
+	"p1": PriorityUrgent,
+	"p2": PriorityHigh,
+	"p3": PriorityMedium,
+	"p4": PriorityLow,
+}
+
+// inlineDurationRegex matches words that look like a duration after '~'
+var inlineDurationRegex = regexp.MustCompile(`^\d+(?:h\d+m|[mh])$`)
+
+// ParseInlineMetadata pulls metadata tokens out of a task name typed on one
+// line, e.g. "Ship report !p1 @office due:fri ~2h #q3". It recognizes:
+//
+//	!p1..!p4 or !urgent/!high/!medium/!low  priority (p1 is urgent)
+//	@word                                   context
+//	due:<date>                              due date (YYYY-MM-DD, today, tomorrow, or a weekday)
+//	~<duration>                             duration (15m, 45m, 3h, 1h30m)
+//	#word                                   tag
+//
+// Tokens must be whole words. The returned task has the remaining words as its
+// name and the parsed fields set; ID, ProjectID, and CreatedAt are left empty.
+func ParseInlineMetadata(input string, now time.Time) (*Task, error) {
+	return ParseInlineWords(strings.Fields(input), now)
+}
+
+// ParseInlineWords is ParseInlineMetadata for a name already split into
+// words, e.g. command arguments. A word with spaces in it was quoted, so it
+// is kept as part of the name even if it contains metadata-like words.
+func ParseInlineWords(input []string, now time.Time) (*Task, error) {
+	task := &Task{}
+	var words []string
+
+	for _, word := range input {
+		lower := strings.ToLower(word)
+		switch {
+		case word == "":
+			continue
+
+		case strings.ContainsFunc(word, unicode.IsSpace):
+			words = append(words, word)
+
+		case strings.HasPrefix(lower, "!") && len(lower) > 1:
+			p, ok := inlinePriorities[lower[1:]]
+			if !ok && IsValidPriority(lower[1:]) {
+				p, ok = Priority(lower[1:]), true
+			}
+			if !ok {
+				words = append(words, word) // e.g. "!!" or "!important" is just text
+				continue
+			}
+			task.Priority = p
+
+		case strings.HasPrefix(lower, "@") && len(lower) > 1:
+			if !tagRegex.MatchString(lower[1:]) {
+				return nil, fmt.Errorf("invalid context: %s", word)
+			}
+			task.Context = lower[1:]
+
+		case strings.HasPrefix(lower, "due:"):
+			due, err := ParseDueWord(lower[len("due:"):], now)
+			if err != nil {
+				return nil, err
+			}
+			task.DueDate = &due
+
+		case strings.HasPrefix(lower, "~") && inlineDurationRegex.MatchString(lower[1:]):
+			duration, err := ParseDuration(lower[1:])
+			if err != nil {
+				return nil, err
+			}
+			task.Duration = duration
+
+		case strings.HasPrefix(lower, "#") && len(lower) > 1:
+			tag := NormalizeTag(lower)
+			if !tagRegex.MatchString(tag) {
+				return nil, fmt.Errorf("invalid tag: %s", word)
+			}
+			if !task.HasTag(tag) {
+				task.Tags = append(task.Tags, tag)
+			}
+
+		default:
+			words = append(words, word)
+		}
+	}
+
+	task.Name = strings.Join(words, " ")
+	if task.Name == "" {
+		return nil, fmt.Errorf("task name is empty")
+	}
+	return task, nil
+}
+
+// ParseDueWord parses a due date written as YYYY-MM-DD, "today", "tomorrow",
+// or a weekday name (the next one, counting today)
+func ParseDueWord(s string, now time.Time) (time.Time, error) {
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	s = strings.ToLower(s)
+
+	switch s {
+	case "today":
+		return today, nil
+	case "tomorrow", "tmr":
+		return today.AddDate(0, 0, 1), nil
+	case "sat":
+		return nextWeekday(today, time.Saturday), nil
+	case "sun":
+		return nextWeekday(today, time.Sunday), nil
+	}
+	if day, ok := weekdays[s]; ok {
+		return nextWeekday(today, day), nil
+	}
+
+	due, err := time.Parse("2006-01-02", s)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid due date: %s (use YYYY-MM-DD, today, tomorrow, or a weekday)", s)
+	}
+	return due, nil
+}
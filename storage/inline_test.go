@@ -0,0 +1,70 @@
+package storage
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseInlineMetadata(t *testing.T) {
+	// A Wednesday
+	now := time.Date(2025, 3, 12, 15, 0, 0, 0, time.UTC)
+
+	task, err := ParseInlineMetadata("Ship report !p1 @Office due:fri ~2h #q3 #Q3", now)
+	if err != nil {
+		t.Fatalf("Failed to parse: %v", err)
+	}
+	if task.Name != "Ship report" {
+		t.Errorf("Expected name 'Ship report', got %q", task.Name)
+	}
+	if task.Priority != PriorityUrgent || task.Context != "office" || task.Duration != Duration2h {
+		t.Errorf("Expected urgent/office/2h, got %+v", task)
+	}
+	if len(task.Tags) != 1 || task.Tags[0] != "q3" {
+		t.Errorf("Expected tags [q3], got %v", task.Tags)
+	}
+	if want := time.Date(2025, 3, 14, 0, 0, 0, 0, time.UTC); task.DueDate == nil || !task.DueDate.Equal(want) {
+		t.Errorf("Expected due %v, got %v", want, task.DueDate)
+	}
+
+	// Words that only resemble metadata stay in the name
+	task, err = ParseInlineMetadata("Fix bug!! ~soon !important", now)
+	if err != nil || task.Name != "Fix bug!! ~soon !important" || task.Priority != "" {
+		t.Errorf("Expected plain name, got %+v, %v", task, err)
+	}
+
+	task, _ = ParseInlineMetadata("Write grant ~1h30m", now)
+	if task.Name != "Write grant" || task.Duration != 90 {
+		t.Errorf("Expected 1h30m, got %+v", task)
+	}
+
+	task, _ = ParseInlineMetadata("Pay rent !high due:2025-04-01", now)
+	if task.Priority != PriorityHigh || task.DueDate.Format("2006-01-02") != "2025-04-01" {
+		t.Errorf("Expected high priority due 2025-04-01, got %+v", task)
+	}
+
+	errorCases := []string{
+		"Ship report due:someday",
+		"Ship report ~0m",
+		"Ship report ~1h75m",
+		"Ship report @a/b",
+		"!p1 #q3",
+	}
+	for _, input := range errorCases {
+		if _, err := ParseInlineMetadata(input, now); err == nil {
+			t.Errorf("%q: expected error", input)
+		}
+	}
+}
+
+func TestParseInlineWords(t *testing.T) {
+	now := time.Date(2025, 3, 12, 15, 0, 0, 0, time.UTC)
+
+	// A quoted phrase (a word with spaces) stays in the name as typed
+	task, err := ParseInlineWords([]string{"Fix bug #12: crash", "!p2", "", "#bugs"}, now)
+	if err != nil {
+		t.Fatalf("Failed to parse: %v", err)
+	}
+	if task.Name != "Fix bug #12: crash" || task.Priority != PriorityHigh || len(task.Tags) != 1 || task.Tags[0] != "bugs" {
+		t.Errorf("Expected the quoted name with priority and tag, got %+v", task)
+	}
+}
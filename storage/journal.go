@@ -0,0 +1,251 @@
+package storage
+
+import (
+	"fmt"
+	"time"
+)
+
+// maxJournalEntries caps how many operations can be undone
+const maxJournalEntries = 100
+
+// JournalEntry records one mutation so it can be undone or redone. It keeps
+// full copies of every affected project and task before and after the change;
+// an entity missing from a state means it did not exist at that point.
+type JournalEntry struct {
+	Op         string        `json:"op"`
+	Time       time.Time     `json:"time"`
+	ProjectIDs []string      `json:"project_ids,omitempty"`
+	TaskIDs    []string      `json:"task_ids,omitempty"`
+	Before     *JournalState `json:"before"`
+	After      *JournalState `json:"after"`
+}
+
+// JournalState is a copy of the affected entities at one point in time
+type JournalState struct {
+	Projects []*Project `json:"projects,omitempty"`
+	Tasks    []*Task    `json:"tasks,omitempty"`
+}
+
+// Undo reverses the most recent journaled operation
+func (s *JSONStore) Undo() (*JournalEntry, error) {
+	if s.inTx {
+		return nil, errInTransaction
+	}
+	release, err := s.beginWrite()
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	if len(s.data.Journal) == 0 {
+		return nil, fmt.Errorf("nothing to undo")
+	}
+
+	entry := s.data.Journal[len(s.data.Journal)-1]
+	s.data.Journal = s.data.Journal[:len(s.data.Journal)-1]
+	s.restore(entry, entry.Before)
+	s.data.Redo = append(s.data.Redo, entry)
+
+	return entry, s.save()
+}
+
+// Redo re-applies the most recently undone operation
+func (s *JSONStore) Redo() (*JournalEntry, error) {
+	if s.inTx {
+		return nil, errInTransaction
+	}
+	release, err := s.beginWrite()
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	if len(s.data.Redo) == 0 {
+		return nil, fmt.Errorf("nothing to redo")
+	}
+
+	entry := s.data.Redo[len(s.data.Redo)-1]
+	s.data.Redo = s.data.Redo[:len(s.data.Redo)-1]
+	s.restore(entry, entry.After)
+	s.data.Journal = append(s.data.Journal, entry)
+
+	return entry, s.save()
+}
+
+// journaled runs a mutation, records it in the journal, and saves.
+// The caller must hold the write lock. If mutate fails nothing is recorded,
+// so mutate should validate before changing anything.
+func (s *JSONStore) journaled(op string, projectIDs, taskIDs []string, mutate func() error) error {
+	before := s.capture(projectIDs, taskIDs)
+	if err := mutate(); err != nil {
+		return err
+	}
+
+	s.data.Journal = append(s.data.Journal, &JournalEntry{
+		Op:         op,
+		Time:       time.Now(),
+		ProjectIDs: projectIDs,
+		TaskIDs:    taskIDs,
+		Before:     before,
+		After:      s.capture(projectIDs, taskIDs),
+	})
+	// A transaction trims once it has folded its entries into one
+	if !s.inTx && len(s.data.Journal) > maxJournalEntries {
+		s.data.Journal = s.data.Journal[len(s.data.Journal)-maxJournalEntries:]
+	}
+	// A new change invalidates anything that was undone
+	s.data.Redo = nil
+
+	return s.save()
+}
+
+// updateTask applies fn to a single task as a journaled operation
+func (s *JSONStore) updateTask(id, op string, fn func(t *Task) error) error {
+	task := s.taskByID(id)
+	if task == nil {
+		return fmt.Errorf("task not found: %s", id)
+	}
+	return s.journaled(fmt.Sprintf("%s %q", op, task.Name), nil, []string{id}, func() error {
+		if err := fn(task); err != nil {
+			return err
+		}
+		task.touch(time.Now())
+		return nil
+	})
+}
+
+// capture copies the current state of the given projects and tasks
+func (s *JSONStore) capture(projectIDs, taskIDs []string) *JournalState {
+	state := &JournalState{}
+	for _, id := range projectIDs {
+		if p := s.projectByID(id); p != nil {
+			state.Projects = append(state.Projects, copyProject(p))
+		}
+	}
+	for _, id := range taskIDs {
+		if t := s.taskByID(id); t != nil {
+			state.Tasks = append(state.Tasks, copyTask(t))
+		}
+	}
+	return state
+}
+
+// restore puts the entry's affected entities back into the given state
+func (s *JSONStore) restore(entry *JournalEntry, state *JournalState) {
+	for _, id := range entry.ProjectIDs {
+		if p := findProject(state.Projects, func(p *Project) bool { return p.ID == id }); p != nil {
+			s.upsertProject(copyProject(p))
+		} else {
+			s.removeProject(id)
+		}
+	}
+	for _, id := range entry.TaskIDs {
+		if t := findTask(state.Tasks, func(t *Task) bool { return t.ID == id }); t != nil {
+			s.upsertTask(copyTask(t))
+		} else {
+			s.removeTask(id)
+		}
+	}
+}
+
+func (s *JSONStore) projectByID(id string) *Project {
+	for _, p := range s.data.Projects {
+		if p.ID == id {
+			return p
+		}
+	}
+	return nil
+}
+
+// hasProject reports whether tasks can go in projectID: an existing project,
+// or the inbox ("")
+func (s *JSONStore) hasProject(projectID string) bool {
+	return projectID == "" || s.projectByID(projectID) != nil
+}
+
+func (s *JSONStore) taskByID(id string) *Task {
+	for _, t := range s.data.Tasks {
+		if t.ID == id {
+			return t
+		}
+	}
+	return nil
+}
+
+// upsertProject replaces the project with the same ID in place, or appends it
+func (s *JSONStore) upsertProject(project *Project) {
+	for i, p := range s.data.Projects {
+		if p.ID == project.ID {
+			s.data.Projects[i] = project
+			return
+		}
+	}
+	s.data.Projects = append(s.data.Projects, project)
+}
+
+// upsertTask replaces the task with the same ID in place, or appends it
+func (s *JSONStore) upsertTask(task *Task) {
+	for i, t := range s.data.Tasks {
+		if t.ID == task.ID {
+			s.data.Tasks[i] = task
+			return
+		}
+	}
+	s.data.Tasks = append(s.data.Tasks, task)
+}
+
+func (s *JSONStore) removeProject(id string) {
+	for i, p := range s.data.Projects {
+		if p.ID == id {
+			s.data.Projects = append(s.data.Projects[:i], s.data.Projects[i+1:]...)
+			return
+		}
+	}
+}
+
+func (s *JSONStore) removeTask(id string) {
+	for i, t := range s.data.Tasks {
+		if t.ID == id {
+			s.data.Tasks = append(s.data.Tasks[:i], s.data.Tasks[i+1:]...)
+			return
+		}
+	}
+}
+
+// copyProject returns a copy of a project that shares no memory with the original
+func copyProject(p *Project) *Project {
+	copied := *p
+	if p.DueDate != nil {
+		due := *p.DueDate
+		copied.DueDate = &due
+	}
+	return &copied
+}
+
+// copyTask returns a copy of a task that shares no memory with the original
+func copyTask(t *Task) *Task {
+	copied := *t
+	if t.DueDate != nil {
+		due := *t.DueDate
+		copied.DueDate = &due
+	}
+	if t.ArchivedAt != nil {
+		archived := *t.ArchivedAt
+		copied.ArchivedAt = &archived
+	}
+	if t.CompletedAt != nil {
+		completed := *t.CompletedAt
+		copied.CompletedAt = &completed
+	}
+	if t.UpdatedAt != nil {
+		updated := *t.UpdatedAt
+		copied.UpdatedAt = &updated
+	}
+	if t.Tags != nil {
+		copied.Tags = append([]string{}, t.Tags...)
+	}
+	if t.BlockedBy != nil {
+		copied.BlockedBy = append([]string{}, t.BlockedBy...)
+	}
+	return &copied
+}
@@ -3,6 +3,7 @@ package storage
 import (
 	"crypto/rand"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
 	"regexp"
@@ -11,19 +12,33 @@ import (
 	"time"
 )
 
-// JSONStore implements Store using a JSON file
+// JSONStore implements Store using a JSON file. Several processes can share
+// the file: writes hold an advisory lock on a sidecar lock file, and every
+// operation reloads the file first if another process has replaced it.
 type JSONStore struct {
 	filename string
 	data     *jsonData
 	mu       sync.RWMutex
+	lock     *os.File    // sidecar file holding the advisory lock
+	info     os.FileInfo // the file as of our last load or save
+
+	// inTx marks the store Transact hands its closure: it shares the data
+	// of the store that holds the locks, and neither locks nor saves
+	inTx bool
 }
 
 type jsonData struct {
-	Projects   []*Project `json:"projects"`
-	Tasks      []*Task    `json:"tasks"`
-	NextProjID int        `json:"next_proj_id"`
-	NextTaskID int        `json:"next_task_id"`
-	Migrated   bool       `json:"migrated"`
+	Projects    []*Project      `json:"projects"`
+	Tasks       []*Task         `json:"tasks"`
+	Conflicts   []*Conflict     `json:"conflicts,omitempty"`
+	Shares      []*Share        `json:"shares,omitempty"`
+	TimeEntries []*TimeEntry    `json:"time_entries,omitempty"`
+	Trash       []*TrashItem    `json:"trash,omitempty"`
+	Journal     []*JournalEntry `json:"journal,omitempty"`
+	Redo        []*JournalEntry `json:"redo,omitempty"`
+	NextProjID  int             `json:"next_proj_id"`
+	NextTaskID  int             `json:"next_task_id"`
+	Migrated    bool            `json:"migrated"`
 }
 
 // generateUUID generates a UUID v4 using crypto/rand
@@ -41,9 +56,28 @@ func generateUUID() string {
 		uuid[0:4], uuid[4:6], uuid[6:8], uuid[8:10], uuid[10:16])
 }
 
+// newBatchTasks copies tasks for CreateTasks, assigning IDs and creation
+// times a nanosecond apart so listings keep the batch's order
+func newBatchTasks(fields []*Task, now time.Time) ([]*Task, []string) {
+	tasks := make([]*Task, 0, len(fields))
+	ids := make([]string, 0, len(fields))
+	for i, f := range fields {
+		task := copyTask(f)
+		task.ID = generateUUID()
+		task.Name = CleanName(task.Name)
+		task.CreatedAt = now.Add(time.Duration(i))
+		tasks = append(tasks, task)
+		ids = append(ids, task.ID)
+	}
+	return tasks, ids
+}
+
 // shortcutRegex validates shortcut format: alphanumeric and hyphens, 1-20 chars
 var shortcutRegex = regexp.MustCompile(`^[a-zA-Z0-9-]{1,20}$`)
 
+// tagRegex validates normalized tags: lowercase alphanumeric, hyphens, underscores, 1-30 chars
+var tagRegex = regexp.MustCompile(`^[a-z0-9_-]{1,30}$`)
+
 // NewJSONStore creates or opens a JSON-backed store
 func NewJSONStore(filename string) (*JSONStore, error) {
 	store := &JSONStore{
@@ -57,15 +91,29 @@ func NewJSONStore(filename string) (*JSONStore, error) {
 		},
 	}
 
+	lock, err := os.OpenFile(filename+".lock", os.O_RDWR|os.O_CREATE, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open lock file: %w", err)
+	}
+	store.lock = lock
+
+	if err := lockFile(lock, true); err != nil {
+		lock.Close()
+		return nil, fmt.Errorf("failed to lock store: %w", err)
+	}
+	defer unlockFile(lock)
+
 	// Try to load existing file
 	if _, err := os.Stat(filename); err == nil {
 		// Create fresh data struct for loading (Migrated defaults to false)
 		store.data = &jsonData{}
 		if err := store.load(); err != nil {
+			lock.Close()
 			return nil, fmt.Errorf("failed to load store: %w", err)
 		}
 		// Migrate old-style IDs to UUIDs
 		if err := store.migrate(); err != nil {
+			lock.Close()
 			return nil, fmt.Errorf("failed to migrate store: %w", err)
 		}
 	}
@@ -108,28 +156,142 @@ func (s *JSONStore) migrate() error {
 }
 
 func (s *JSONStore) load() error {
+	info, err := os.Stat(s.filename)
+	if err != nil {
+		return err
+	}
 	data, err := os.ReadFile(s.filename)
 	if err != nil {
 		return err
 	}
 
-	return json.Unmarshal(data, s.data)
+	if err := json.Unmarshal(data, s.data); err != nil {
+		return err
+	}
+	s.info = info
+	return nil
 }
 
+// save writes to a temporary file, flushes it to disk, and renames it into
+// place, so other processes never read a half-written file and a crash
+// leaves either the old file or the new one. The caller must hold the write
+// lock from beginWrite. Inside a transaction it does nothing: Transact
+// saves once at the end.
 func (s *JSONStore) save() error {
+	if s.inTx {
+		return nil
+	}
 	data, err := json.MarshalIndent(s.data, "", "  ")
 	if err != nil {
 		return err
 	}
 
-	return os.WriteFile(s.filename, data, 0644)
+	tmp := s.filename + ".tmp"
+	f, err := os.OpenFile(tmp, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0644)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, s.filename); err != nil {
+		return err
+	}
+
+	info, err := os.Stat(s.filename)
+	if err != nil {
+		return err
+	}
+	s.info = info
+	return nil
+}
+
+// reloadIfChanged reloads the file if another process has saved since our
+// last load or save. Every save renames a new file into place, so a
+// different file identity means someone else wrote it.
+func (s *JSONStore) reloadIfChanged() error {
+	info, err := os.Stat(s.filename)
+	if os.IsNotExist(err) {
+		return nil // nothing saved yet
+	}
+	if err != nil {
+		return err
+	}
+	if s.info != nil && os.SameFile(s.info, info) && s.info.ModTime().Equal(info.ModTime()) && s.info.Size() == info.Size() {
+		return nil
+	}
+
+	previous := s.data
+	s.data = &jsonData{}
+	if err := s.load(); err != nil {
+		s.data = previous
+		return fmt.Errorf("failed to reload store: %w", err)
+	}
+	return nil
+}
+
+// beginWrite locks the store against other goroutines and processes and
+// brings it up to date, so the change applies on top of theirs instead of
+// overwriting it. The returned func releases both locks.
+func (s *JSONStore) beginWrite() (func(), error) {
+	if s.inTx {
+		return func() {}, nil
+	}
+	s.mu.Lock()
+	if err := lockFile(s.lock, true); err != nil {
+		s.mu.Unlock()
+		return nil, fmt.Errorf("failed to lock store: %w", err)
+	}
+	if err := s.reloadIfChanged(); err != nil {
+		unlockFile(s.lock)
+		s.mu.Unlock()
+		return nil, err
+	}
+	return func() {
+		unlockFile(s.lock)
+		s.mu.Unlock()
+	}, nil
+}
+
+// beginRead brings the store up to date and takes a read lock. The returned
+// func releases it.
+func (s *JSONStore) beginRead() (func(), error) {
+	if s.inTx {
+		return func() {}, nil
+	}
+	s.mu.Lock()
+	if err := lockFile(s.lock, false); err != nil {
+		s.mu.Unlock()
+		return nil, fmt.Errorf("failed to lock store: %w", err)
+	}
+	err := s.reloadIfChanged()
+	unlockFile(s.lock)
+	s.mu.Unlock()
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.RLock()
+	return s.mu.RUnlock, nil
 }
 
 // CreateProject creates a new project
 func (s *JSONStore) CreateProject(name string) (*Project, error) {
-	s.mu.Lock()
-	defer s.mu.Unlock()
+	release, err := s.beginWrite()
+	if err != nil {
+		return nil, err
+	}
+	defer release()
 
+	name = CleanName(name)
 	id := generateUUID()
 	project := &Project{
 		ID:        id,
@@ -137,34 +299,46 @@ func (s *JSONStore) CreateProject(name string) (*Project, error) {
 		Shortcut:  id[:8], // Default shortcut is first 8 chars of UUID
 		CreatedAt: time.Now(),
 	}
-	s.data.Projects = append(s.data.Projects, project)
 
-	if err := s.save(); err != nil {
+	err = s.journaled(fmt.Sprintf("create project %q", name), []string{id}, nil, func() error {
+		s.data.Projects = append(s.data.Projects, project)
+		return nil
+	})
+	if err != nil {
 		return nil, err
 	}
 
-	return project, nil
+	return copyProject(project), nil
 }
 
 // ListProjects returns all projects
 func (s *JSONStore) ListProjects() ([]*Project, error) {
-	s.mu.RLock()
-	defer s.mu.RUnlock()
+	release, err := s.beginRead()
+	if err != nil {
+		return nil, err
+	}
+	defer release()
 
-	// Return a copy to prevent external modification
+	// Return copies, so callers on other goroutines (serve mode, the notify
+	// daemon) never read a project while it's being written
 	projects := make([]*Project, len(s.data.Projects))
-	copy(projects, s.data.Projects)
+	for i, p := range s.data.Projects {
+		projects[i] = copyProject(p)
+	}
 	return projects, nil
 }
 
 // GetProject retrieves a project by ID
 func (s *JSONStore) GetProject(id string) (*Project, error) {
-	s.mu.RLock()
-	defer s.mu.RUnlock()
+	release, err := s.beginRead()
+	if err != nil {
+		return nil, err
+	}
+	defer release()
 
 	for _, p := range s.data.Projects {
 		if p.ID == id {
-			return p, nil
+			return copyProject(p), nil
 		}
 	}
 
@@ -173,102 +347,152 @@ func (s *JSONStore) GetProject(id string) (*Project, error) {
 
 // DeleteProject removes a project and its tasks
 func (s *JSONStore) DeleteProject(id string) error {
-	s.mu.Lock()
-	defer s.mu.Unlock()
-
-	// Find and remove project
-	found := false
-	for i, p := range s.data.Projects {
-		if p.ID == id {
-			s.data.Projects = append(s.data.Projects[:i], s.data.Projects[i+1:]...)
-			found = true
-			break
-		}
+	release, err := s.beginWrite()
+	if err != nil {
+		return err
 	}
+	defer release()
 
-	if !found {
+	project := s.projectByID(id)
+	if project == nil {
 		return fmt.Errorf("project not found: %s", id)
 	}
 
-	// Remove all tasks in this project
-	newTasks := []*Task{}
+	var taskIDs []string
 	for _, t := range s.data.Tasks {
-		if t.ProjectID != id {
-			newTasks = append(newTasks, t)
+		if t.ProjectID == id {
+			taskIDs = append(taskIDs, t.ID)
 		}
 	}
-	s.data.Tasks = newTasks
 
-	return s.save()
+	return s.journaled(fmt.Sprintf("delete project %q", project.Name), []string{id}, taskIDs, func() error {
+		s.removeProject(id)
+
+		// Remove all tasks in this project
+		newTasks := []*Task{}
+		for _, t := range s.data.Tasks {
+			if t.ProjectID != id {
+				newTasks = append(newTasks, t)
+			}
+		}
+		s.data.Tasks = newTasks
+		return nil
+	})
 }
 
 // CreateTask creates a new task in a project
 func (s *JSONStore) CreateTask(projectID, name string) (*Task, error) {
-	s.mu.Lock()
-	defer s.mu.Unlock()
+	return s.CreateTaskFrom(&Task{ProjectID: projectID, Name: name})
+}
+
+// CreateTaskFrom creates a new task with the given fields, assigning a fresh
+// ID and creation time
+func (s *JSONStore) CreateTaskFrom(fields *Task) (*Task, error) {
+	release, err := s.beginWrite()
+	if err != nil {
+		return nil, err
+	}
+	defer release()
 
 	// Verify project exists
-	projectExists := false
-	for _, p := range s.data.Projects {
-		if p.ID == projectID {
-			projectExists = true
-			break
-		}
+	if !s.hasProject(fields.ProjectID) {
+		return nil, fmt.Errorf("project not found: %s", fields.ProjectID)
 	}
 
-	if !projectExists {
-		return nil, fmt.Errorf("project not found: %s", projectID)
+	task := copyTask(fields)
+	task.ID = generateUUID()
+	task.Name = CleanName(task.Name)
+	task.CreatedAt = time.Now()
+
+	err = s.journaled(fmt.Sprintf("create task %q", task.Name), nil, []string{task.ID}, func() error {
+		s.data.Tasks = append(s.data.Tasks, task)
+		return nil
+	})
+	if err != nil {
+		return nil, err
 	}
 
-	task := &Task{
-		ID:        generateUUID(),
-		ProjectID: projectID,
-		Name:      name,
-		Done:      false,
-		CreatedAt: time.Now(),
+	return copyTask(task), nil
+}
+
+// CreateTasks creates several tasks as one journaled change, in order
+func (s *JSONStore) CreateTasks(fields []*Task) ([]*Task, error) {
+	release, err := s.beginWrite()
+	if err != nil {
+		return nil, err
 	}
-	s.data.Tasks = append(s.data.Tasks, task)
+	defer release()
 
-	if err := s.save(); err != nil {
+	for _, f := range fields {
+		if !s.hasProject(f.ProjectID) {
+			return nil, fmt.Errorf("project not found: %s", f.ProjectID)
+		}
+	}
+
+	tasks, ids := newBatchTasks(fields, time.Now())
+	err = s.journaled(fmt.Sprintf("create %d tasks", len(tasks)), nil, ids, func() error {
+		s.data.Tasks = append(s.data.Tasks, tasks...)
+		return nil
+	})
+	if err != nil {
 		return nil, err
 	}
 
-	return task, nil
+	created := make([]*Task, len(tasks))
+	for i, t := range tasks {
+		created[i] = copyTask(t)
+	}
+	return created, nil
 }
 
-// ListTasks returns all tasks for a project
+// ListTasks returns all tasks for a project, or the inbox's for ""
 func (s *JSONStore) ListTasks(projectID string) ([]*Task, error) {
-	s.mu.RLock()
-	defer s.mu.RUnlock()
+	release, err := s.beginRead()
+	if err != nil {
+		return nil, err
+	}
+	defer release()
 
 	tasks := []*Task{}
 	for _, t := range s.data.Tasks {
-		if t.ProjectID == projectID {
-			tasks = append(tasks, t)
+		if t.ProjectID == projectID && !t.IsArchived() {
+			tasks = append(tasks, copyTask(t))
 		}
 	}
 
+	sortByOrder(tasks)
 	return tasks, nil
 }
 
 // ListAllTasks returns all tasks across all projects
 func (s *JSONStore) ListAllTasks() ([]*Task, error) {
-	s.mu.RLock()
-	defer s.mu.RUnlock()
+	release, err := s.beginRead()
+	if err != nil {
+		return nil, err
+	}
+	defer release()
 
-	tasks := make([]*Task, len(s.data.Tasks))
-	copy(tasks, s.data.Tasks)
+	tasks := []*Task{}
+	for _, t := range s.data.Tasks {
+		if !t.IsArchived() {
+			tasks = append(tasks, copyTask(t))
+		}
+	}
+	sortByOrder(tasks)
 	return tasks, nil
 }
 
 // GetTask retrieves a task by ID
 func (s *JSONStore) GetTask(id string) (*Task, error) {
-	s.mu.RLock()
-	defer s.mu.RUnlock()
+	release, err := s.beginRead()
+	if err != nil {
+		return nil, err
+	}
+	defer release()
 
 	for _, t := range s.data.Tasks {
 		if t.ID == id {
-			return t, nil
+			return copyTask(t), nil
 		}
 	}
 
@@ -277,69 +501,532 @@ func (s *JSONStore) GetTask(id string) (*Task, error) {
 
 // UpdateTask updates a task's done status
 func (s *JSONStore) UpdateTask(id string, done bool) error {
-	s.mu.Lock()
-	defer s.mu.Unlock()
+	release, err := s.beginWrite()
+	if err != nil {
+		return err
+	}
+	defer release()
 
-	for _, t := range s.data.Tasks {
-		if t.ID == id {
-			t.Done = done
-			return s.save()
+	op := "mark done"
+	if !done {
+		op = "mark not done"
+	}
+	return s.updateTask(id, op, func(t *Task) error {
+		t.markDone(done, time.Now())
+		return nil
+	})
+}
+
+// UpdateTasks marks several tasks done or not done at once
+func (s *JSONStore) UpdateTasks(ids []string, done bool) error {
+	release, err := s.beginWrite()
+	if err != nil {
+		return err
+	}
+	defer release()
+
+	for _, id := range ids {
+		if s.taskByID(id) == nil {
+			return fmt.Errorf("task not found: %s", id)
 		}
 	}
 
-	return fmt.Errorf("task not found: %s", id)
+	op := fmt.Sprintf("mark %d tasks done", len(ids))
+	if !done {
+		op = fmt.Sprintf("mark %d tasks not done", len(ids))
+	}
+	return s.journaled(op, nil, ids, func() error {
+		now := time.Now()
+		for _, id := range ids {
+			t := s.taskByID(id)
+			t.markDone(done, now)
+			t.touch(now)
+		}
+		return nil
+	})
 }
 
 // SetTaskDueDate sets or clears a task's due date
 func (s *JSONStore) SetTaskDueDate(id string, dueDate *time.Time) error {
-	s.mu.Lock()
-	defer s.mu.Unlock()
+	release, err := s.beginWrite()
+	if err != nil {
+		return err
+	}
+	defer release()
 
-	for _, t := range s.data.Tasks {
-		if t.ID == id {
-			t.DueDate = dueDate
-			return s.save()
+	return s.updateTask(id, "set due date of", func(t *Task) error {
+		if dueDate == nil {
+			t.DueDate = nil
+			return nil
 		}
+		setDueDate(t, *dueDate)
+		return nil
+	})
+}
+
+// SetTaskDueDates sets the due dates of several tasks at once
+func (s *JSONStore) SetTaskDueDates(dates map[string]time.Time) error {
+	release, err := s.beginWrite()
+	if err != nil {
+		return err
 	}
+	defer release()
 
-	return fmt.Errorf("task not found: %s", id)
+	ids := sortedKeys(dates)
+	for _, id := range ids {
+		if s.taskByID(id) == nil {
+			return fmt.Errorf("task not found: %s", id)
+		}
+	}
+
+	return s.journaled(fmt.Sprintf("set due dates of %d tasks", len(ids)), nil, ids, func() error {
+		now := time.Now()
+		for _, id := range ids {
+			t := s.taskByID(id)
+			setDueDate(t, dates[id])
+			t.touch(now)
+		}
+		return nil
+	})
+}
+
+// SetTaskDurations sets the durations of several tasks at once
+func (s *JSONStore) SetTaskDurations(durations map[string]Duration) error {
+	release, err := s.beginWrite()
+	if err != nil {
+		return err
+	}
+	defer release()
+
+	ids := sortedKeys(durations)
+	for _, id := range ids {
+		if s.taskByID(id) == nil {
+			return fmt.Errorf("task not found: %s", id)
+		}
+	}
+
+	return s.journaled(fmt.Sprintf("set durations of %d tasks", len(ids)), nil, ids, func() error {
+		now := time.Now()
+		for _, id := range ids {
+			t := s.taskByID(id)
+			t.Duration = durations[id]
+			t.touch(now)
+		}
+		return nil
+	})
+}
+
+// Reorganize moves tasks, merges projects, and changes shortcuts as one
+// journal entry; nothing changes if any part is invalid
+func (s *JSONStore) Reorganize(r *Reorg) error {
+	release, err := s.beginWrite()
+	if err != nil {
+		return err
+	}
+	defer release()
+
+	c, err := resolveReorg(r, s.data.Projects, s.data.Tasks)
+	if err != nil {
+		return err
+	}
+
+	return s.journaled(c.op(), c.projectIDs, c.taskIDs, func() error {
+		now := time.Now()
+		for id, projectID := range c.moves {
+			t := s.taskByID(id)
+			t.moveTo(projectID)
+			t.touch(now)
+		}
+		for id, shortcut := range c.shortcuts {
+			s.projectByID(id).Shortcut = shortcut
+		}
+		for id := range c.deleted {
+			s.removeProject(id)
+		}
+		return nil
+	})
+}
+
+// MoveTask moves a task to another project, or to the inbox when projectID
+// is empty, keeping its due date, duration, done status, and everything else
+func (s *JSONStore) MoveTask(id, projectID string) error {
+	release, err := s.beginWrite()
+	if err != nil {
+		return err
+	}
+	defer release()
+
+	if !s.hasProject(projectID) {
+		return fmt.Errorf("project not found: %s", projectID)
+	}
+	return s.updateTask(id, "move", func(t *Task) error {
+		if t.ProjectID == projectID {
+			return fmt.Errorf("%q is already in %s", t.Name, s.projectLabel(projectID))
+		}
+		t.moveTo(projectID)
+		return nil
+	})
 }
 
 // SetTaskDuration sets a task's duration
 func (s *JSONStore) SetTaskDuration(id string, duration Duration) error {
-	s.mu.Lock()
-	defer s.mu.Unlock()
+	release, err := s.beginWrite()
+	if err != nil {
+		return err
+	}
+	defer release()
 
-	for _, t := range s.data.Tasks {
-		if t.ID == id {
-			t.Duration = duration
-			return s.save()
+	return s.updateTask(id, "set duration of", func(t *Task) error {
+		t.Duration = duration
+		return nil
+	})
+}
+
+// SetTaskPriority sets a task's priority (empty clears it)
+func (s *JSONStore) SetTaskPriority(id string, priority Priority) error {
+	release, err := s.beginWrite()
+	if err != nil {
+		return err
+	}
+	defer release()
+
+	return s.updateTask(id, "set priority of", func(t *Task) error {
+		t.Priority = priority
+		return nil
+	})
+}
+
+// SetTaskStatus moves a task to a status; done and not done also mark it so
+func (s *JSONStore) SetTaskStatus(id string, status Status) error {
+	release, err := s.beginWrite()
+	if err != nil {
+		return err
+	}
+	defer release()
+
+	return s.updateTask(id, "set status of", func(t *Task) error {
+		t.setStatus(status, time.Now())
+		return nil
+	})
+}
+
+// SetTaskNote sets a task's note (empty clears it)
+func (s *JSONStore) SetTaskNote(id, note string) error {
+	release, err := s.beginWrite()
+	if err != nil {
+		return err
+	}
+	defer release()
+
+	return s.updateTask(id, "set note of", func(t *Task) error {
+		t.Note = note
+		return nil
+	})
+}
+
+// ArchiveTasks moves done tasks out of the main list
+func (s *JSONStore) ArchiveTasks(ids []string) error {
+	release, err := s.beginWrite()
+	if err != nil {
+		return err
+	}
+	defer release()
+
+	var tasks []*Task
+	for _, id := range ids {
+		task := s.taskByID(id)
+		if task == nil {
+			return fmt.Errorf("task not found: %s", id)
+		}
+		if err := checkArchivable(task); err != nil {
+			return err
+		}
+		tasks = append(tasks, task)
+	}
+
+	return s.journaled(archiveOp(tasks), nil, ids, func() error {
+		now := time.Now()
+		for _, t := range tasks {
+			t.ArchivedAt = &now
 		}
+		return nil
+	})
+}
+
+// ListArchivedTasks returns archived tasks for a project, or for all projects
+// when projectID is empty
+func (s *JSONStore) ListArchivedTasks(projectID string) ([]*Task, error) {
+	release, err := s.beginRead()
+	if err != nil {
+		return nil, err
 	}
+	defer release()
 
-	return fmt.Errorf("task not found: %s", id)
+	tasks := []*Task{}
+	for _, t := range s.data.Tasks {
+		if t.IsArchived() && (projectID == "" || t.ProjectID == projectID) {
+			tasks = append(tasks, copyTask(t))
+		}
+	}
+	sortByOrder(tasks)
+	return tasks, nil
 }
 
 // DeleteTask removes a task
 func (s *JSONStore) DeleteTask(id string) error {
-	s.mu.Lock()
-	defer s.mu.Unlock()
+	release, err := s.beginWrite()
+	if err != nil {
+		return err
+	}
+	defer release()
 
-	for i, t := range s.data.Tasks {
-		if t.ID == id {
-			s.data.Tasks = append(s.data.Tasks[:i], s.data.Tasks[i+1:]...)
+	task := s.taskByID(id)
+	if task == nil {
+		return fmt.Errorf("task not found: %s", id)
+	}
+
+	return s.journaled(fmt.Sprintf("delete task %q", task.Name), nil, []string{id}, func() error {
+		s.removeTask(id)
+		return nil
+	})
+}
+
+// DeleteTasks deletes several tasks as one journaled operation
+func (s *JSONStore) DeleteTasks(ids []string) error {
+	release, err := s.beginWrite()
+	if err != nil {
+		return err
+	}
+	defer release()
+
+	for _, id := range ids {
+		if s.taskByID(id) == nil {
+			return fmt.Errorf("task not found: %s", id)
+		}
+	}
+
+	return s.journaled(fmt.Sprintf("delete %d tasks", len(ids)), nil, ids, func() error {
+		for _, id := range ids {
+			s.removeTask(id)
+		}
+		return nil
+	})
+}
+
+// AddTaskTag adds a tag to a task (no-op if already present)
+func (s *JSONStore) AddTaskTag(id, tag string) error {
+	release, err := s.beginWrite()
+	if err != nil {
+		return err
+	}
+	defer release()
+
+	tag = NormalizeTag(tag)
+	if !tagRegex.MatchString(tag) {
+		return fmt.Errorf("invalid tag: must be 1-30 alphanumeric characters, hyphens, or underscores")
+	}
+
+	task := s.taskByID(id)
+	if task == nil {
+		return fmt.Errorf("task not found: %s", id)
+	}
+	if task.HasTag(tag) {
+		return nil
+	}
+
+	return s.updateTask(id, "tag #"+tag+" on", func(t *Task) error {
+		t.Tags = append(t.Tags, tag)
+		return nil
+	})
+}
+
+// RemoveTaskTag removes a tag from a task
+func (s *JSONStore) RemoveTaskTag(id, tag string) error {
+	release, err := s.beginWrite()
+	if err != nil {
+		return err
+	}
+	defer release()
+
+	tag = NormalizeTag(tag)
+	return s.updateTask(id, "untag #"+tag+" from", func(t *Task) error {
+		for i, existing := range t.Tags {
+			if existing == tag {
+				t.Tags = append(t.Tags[:i], t.Tags[i+1:]...)
+				return nil
+			}
+		}
+		return fmt.Errorf("task %s does not have tag: %s", t.Name, tag)
+	})
+}
+
+// ListTasksByTag returns all tasks across all projects carrying the given
+// tag. It scans every task on purpose; see the Store interface.
+func (s *JSONStore) ListTasksByTag(tag string) ([]*Task, error) {
+	release, err := s.beginRead()
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	tasks := []*Task{}
+	for _, t := range s.data.Tasks {
+		if t.HasTag(tag) && !t.IsArchived() {
+			tasks = append(tasks, copyTask(t))
+		}
+	}
+
+	sortByOrder(tasks)
+	return tasks, nil
+}
+
+// ImportProject inserts a project with its existing ID and shortcut
+func (s *JSONStore) ImportProject(project *Project) error {
+	release, err := s.beginWrite()
+	if err != nil {
+		return err
+	}
+	defer release()
+
+	for _, p := range s.data.Projects {
+		if p.ID == project.ID {
+			return fmt.Errorf("project ID already exists: %s", project.ID)
+		}
+		if p.Shortcut == project.Shortcut {
+			return fmt.Errorf("shortcut already in use by project: %s", p.Name)
+		}
+	}
+
+	return s.journaled(fmt.Sprintf("import project %q", project.Name), []string{project.ID}, nil, func() error {
+		s.data.Projects = append(s.data.Projects, copyProject(project))
+		return nil
+	})
+}
+
+// projectLabel names a project in messages; the inbox is InboxName
+func (s *JSONStore) projectLabel(projectID string) string {
+	if p := s.projectByID(projectID); p != nil {
+		return p.Name
+	}
+	return InboxName
+}
+
+// ImportTask inserts a task with its existing ID into an existing project or the inbox
+func (s *JSONStore) ImportTask(task *Task) error {
+	release, err := s.beginWrite()
+	if err != nil {
+		return err
+	}
+	defer release()
+
+	if !s.hasProject(task.ProjectID) {
+		return fmt.Errorf("project not found: %s", task.ProjectID)
+	}
+	if s.taskByID(task.ID) != nil {
+		return fmt.Errorf("task ID already exists: %s", task.ID)
+	}
+
+	return s.journaled(fmt.Sprintf("import task %q", task.Name), nil, []string{task.ID}, func() error {
+		s.data.Tasks = append(s.data.Tasks, copyTask(task))
+		return nil
+	})
+}
+
+// ReplaceProject overwrites an existing project with the given version
+func (s *JSONStore) ReplaceProject(project *Project) error {
+	release, err := s.beginWrite()
+	if err != nil {
+		return err
+	}
+	defer release()
+
+	for _, p := range s.data.Projects {
+		if p.ID != project.ID && p.Shortcut == project.Shortcut {
+			return fmt.Errorf("shortcut already in use by project: %s", p.Name)
+		}
+	}
+	if s.projectByID(project.ID) == nil {
+		return fmt.Errorf("project not found: %s", project.ID)
+	}
+
+	return s.journaled(fmt.Sprintf("replace project %q", project.Name), []string{project.ID}, nil, func() error {
+		s.upsertProject(copyProject(project))
+		return nil
+	})
+}
+
+// ReplaceTask overwrites an existing task with the given version
+func (s *JSONStore) ReplaceTask(task *Task) error {
+	release, err := s.beginWrite()
+	if err != nil {
+		return err
+	}
+	defer release()
+
+	if !s.hasProject(task.ProjectID) {
+		return fmt.Errorf("project not found: %s", task.ProjectID)
+	}
+	if s.taskByID(task.ID) == nil {
+		return fmt.Errorf("task not found: %s", task.ID)
+	}
+
+	return s.journaled(fmt.Sprintf("replace task %q", task.Name), nil, []string{task.ID}, func() error {
+		s.upsertTask(copyTask(task))
+		return nil
+	})
+}
+
+// AddConflict queues a conflict for later resolution
+func (s *JSONStore) AddConflict(conflict *Conflict) error {
+	release, err := s.beginWrite()
+	if err != nil {
+		return err
+	}
+	defer release()
+
+	s.data.Conflicts = append(s.data.Conflicts, conflict)
+	return s.save()
+}
+
+// ListConflicts returns all queued conflicts, oldest first
+func (s *JSONStore) ListConflicts() ([]*Conflict, error) {
+	release, err := s.beginRead()
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	conflicts := make([]*Conflict, len(s.data.Conflicts))
+	copy(conflicts, s.data.Conflicts)
+	return conflicts, nil
+}
+
+// DeleteConflict removes a conflict from the queue
+func (s *JSONStore) DeleteConflict(id string) error {
+	release, err := s.beginWrite()
+	if err != nil {
+		return err
+	}
+	defer release()
+
+	for i, c := range s.data.Conflicts {
+		if c.ID == id {
+			s.data.Conflicts = append(s.data.Conflicts[:i], s.data.Conflicts[i+1:]...)
 			return s.save()
 		}
 	}
 
-	return fmt.Errorf("task not found: %s", id)
+	return fmt.Errorf("conflict not found: %s", id)
 }
 
 // ResolveProjectID resolves a project identifier to its full UUID
-// It checks: exact UUID match → shortcut match → UUID prefix (min 6 chars)
+// It checks: exact UUID match → shortcut match → UUID prefix (min 6 chars) → name → part of a name
 func (s *JSONStore) ResolveProjectID(idOrShortcut string) (string, error) {
-	s.mu.RLock()
-	defer s.mu.RUnlock()
+	release, err := s.beginRead()
+	if err != nil {
+		return "", err
+	}
+	defer release()
 
 	// First, try exact UUID match
 	for _, p := range s.data.Projects {
@@ -367,18 +1054,26 @@ func (s *JSONStore) ResolveProjectID(idOrShortcut string) (string, error) {
 			return matches[0].ID, nil
 		}
 		if len(matches) > 1 {
-			return "", fmt.Errorf("ambiguous project ID prefix: %s (matches %d projects)", idOrShortcut, len(matches))
+			return "", fmt.Errorf("%w project ID prefix: %s (matches %d projects)", ErrAmbiguous, idOrShortcut, len(matches))
 		}
 	}
 
+	// Finally, try the project's name
+	if id, err := projectNamed(s.data.Projects, idOrShortcut); id != "" || err != nil {
+		return id, err
+	}
+
 	return "", fmt.Errorf("project not found: %s", idOrShortcut)
 }
 
 // ResolveTaskID resolves a task identifier to its full UUID
-// It checks: exact UUID match → UUID prefix (min 6 chars)
+// It checks: exact UUID match → UUID prefix (min 6 chars) → name → part of a name
 func (s *JSONStore) ResolveTaskID(idOrPrefix string) (string, error) {
-	s.mu.RLock()
-	defer s.mu.RUnlock()
+	release, err := s.beginRead()
+	if err != nil {
+		return "", err
+	}
+	defer release()
 
 	// First, try exact UUID match
 	for _, t := range s.data.Tasks {
@@ -399,21 +1094,29 @@ func (s *JSONStore) ResolveTaskID(idOrPrefix string) (string, error) {
 			return matches[0].ID, nil
 		}
 		if len(matches) > 1 {
-			return "", fmt.Errorf("ambiguous task ID prefix: %s (matches %d tasks)", idOrPrefix, len(matches))
+			return "", fmt.Errorf("%w task ID prefix: %s (matches %d tasks)", ErrAmbiguous, idOrPrefix, len(matches))
 		}
 	}
 
+	// Finally, try the task's name
+	if id, err := taskNamed(s.data.Tasks, idOrPrefix); id != "" || err != nil {
+		return id, err
+	}
+
 	return "", fmt.Errorf("task not found: %s", idOrPrefix)
 }
 
 // SetProjectShortcut sets a custom shortcut for a project
 func (s *JSONStore) SetProjectShortcut(projectID, shortcut string) error {
-	s.mu.Lock()
-	defer s.mu.Unlock()
+	release, err := s.beginWrite()
+	if err != nil {
+		return err
+	}
+	defer release()
 
-	// Validate shortcut format
-	if !shortcutRegex.MatchString(shortcut) {
-		return fmt.Errorf("invalid shortcut: must be 1-20 alphanumeric characters or hyphens")
+	// Validate shortcut format and reserved words
+	if err := validateShortcut(shortcut); err != nil {
+		return err
 	}
 
 	// Check for shortcut conflicts
@@ -424,18 +1127,99 @@ func (s *JSONStore) SetProjectShortcut(projectID, shortcut string) error {
 	}
 
 	// Find and update the project
-	for _, p := range s.data.Projects {
-		if p.ID == projectID {
-			p.Shortcut = shortcut
-			return s.save()
-		}
+	project := s.projectByID(projectID)
+	if project == nil {
+		return fmt.Errorf("project not found: %s", projectID)
 	}
 
-	return fmt.Errorf("project not found: %s", projectID)
+	return s.journaled(fmt.Sprintf("set shortcut of %q", project.Name), []string{projectID}, nil, func() error {
+		project.Shortcut = shortcut
+		return nil
+	})
+}
+
+// SetProjectDueDate sets or clears a project's due date
+func (s *JSONStore) SetProjectDueDate(projectID string, dueDate *time.Time) error {
+	release, err := s.beginWrite()
+	if err != nil {
+		return err
+	}
+	defer release()
+
+	project := s.projectByID(projectID)
+	if project == nil {
+		return fmt.Errorf("project not found: %s", projectID)
+	}
+
+	return s.journaled(fmt.Sprintf("set due date of project %q", project.Name), []string{projectID}, nil, func() error {
+		project.DueDate = dueDate
+		return nil
+	})
+}
+
+// SetProjectDefaults sets the duration and due shift given to tasks
+// created in a project
+func (s *JSONStore) SetProjectDefaults(projectID string, duration Duration, due string) error {
+	release, err := s.beginWrite()
+	if err != nil {
+		return err
+	}
+	defer release()
+
+	project := s.projectByID(projectID)
+	if project == nil {
+		return fmt.Errorf("project not found: %s", projectID)
+	}
+
+	return s.journaled(fmt.Sprintf("set defaults of project %q", project.Name), []string{projectID}, nil, func() error {
+		project.DefaultDuration = duration
+		project.DefaultDue = due
+		return nil
+	})
+}
+
+// Size returns the size of the data file in bytes
+func (s *JSONStore) Size() (int64, error) {
+	info, err := os.Stat(s.filename)
+	if errors.Is(err, os.ErrNotExist) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	return info.Size(), nil
+}
+
+// Compact drops the undo and redo history, usually the bulk of a large file
+// since every entry holds copies of the tasks it changed
+func (s *JSONStore) Compact() (*Compaction, error) {
+	if s.inTx {
+		return nil, errInTransaction
+	}
+	release, err := s.beginWrite()
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	c := &Compaction{UndoEntries: len(s.data.Journal) + len(s.data.Redo)}
+	if c.SizeBefore, err = s.Size(); err != nil {
+		return nil, err
+	}
+	s.data.Journal, s.data.Redo = nil, nil
+	if err := s.save(); err != nil {
+		return nil, err
+	}
+	if c.SizeAfter, err = s.Size(); err != nil {
+		return nil, err
+	}
+	return c, nil
 }
 
 // Close closes the store
 func (s *JSONStore) Close() error {
-	// JSON store doesn't need cleanup, but interface requires it
-	return nil
+	if s.inTx {
+		return errInTransaction
+	}
+	return s.lock.Close()
 }
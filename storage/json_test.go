@@ -2,9 +2,11 @@ package storage
 
 import (
 	"encoding/json"
+	"fmt"
 	"os"
 	"path/filepath"
 	"strings"
+	"sync"
 	"testing"
 	"time"
 )
@@ -284,6 +286,101 @@ func TestSetProjectShortcut(t *testing.T) {
 	if err == nil {
 		t.Error("Should fail with too long shortcut")
 	}
+
+	// Should fail with a reserved word, whatever its case
+	for _, word := range []string{"Today", "yesterday", "Next-Week", "inbox", "done", "OFF"} {
+		err = store.SetProjectShortcut(project1.ID, word)
+		if err == nil || !strings.Contains(err.Error(), "reserved word") {
+			t.Errorf("Expected reserved word error for %q, got: %v", word, err)
+		}
+	}
+}
+
+func TestFixShortcuts(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "test.json")
+	now := time.Now()
+	data, err := json.Marshal(&jsonData{
+		Projects: []*Project{
+			{ID: "11111111-aaaa", Name: "Work", Shortcut: "work", CreatedAt: now},
+			{ID: "22222222-bbbb", Name: "Side", Shortcut: "work", CreatedAt: now.Add(time.Second)},
+			{ID: "33333333-cccc", Name: "Chores", Shortcut: "all", CreatedAt: now.Add(2 * time.Second)},
+			{ID: "44444444-dddd", Name: "Home", Shortcut: "home", CreatedAt: now.Add(3 * time.Second)},
+		},
+		Migrated: true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(dbPath, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	store, err := NewJSONStore(dbPath)
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+	defer store.Close()
+
+	renames, err := FixShortcuts(store)
+	if err != nil {
+		t.Fatalf("FixShortcuts failed: %v", err)
+	}
+	if len(renames) != 2 {
+		t.Fatalf("Expected 2 renames, got %d", len(renames))
+	}
+	if r := renames[0]; r.Project.Name != "Side" || r.Old != "work" || r.Reason != "duplicate" || r.Project.Shortcut != "22222222" {
+		t.Errorf("Unexpected first rename: %+v", r)
+	}
+	if r := renames[1]; r.Project.Name != "Chores" || r.Old != "all" || r.Reason != "reserved" || r.Project.Shortcut != "33333333" {
+		t.Errorf("Unexpected second rename: %+v", r)
+	}
+
+	// The oldest project keeps the shared shortcut
+	if id, err := store.ResolveProjectID("work"); err != nil || id != "11111111-aaaa" {
+		t.Errorf("work should resolve to the oldest project, got %q (%v)", id, err)
+	}
+	if id, err := store.ResolveProjectID("33333333"); err != nil || id != "33333333-cccc" {
+		t.Errorf("Renamed shortcut should resolve, got %q (%v)", id, err)
+	}
+
+	// A second run has nothing to do
+	if renames, err := FixShortcuts(store); err != nil || len(renames) != 0 {
+		t.Errorf("Expected no renames on a second run, got %d (%v)", len(renames), err)
+	}
+}
+
+func TestStatusFromDone(t *testing.T) {
+	// Tasks saved before statuses existed only have Done
+	dbPath := filepath.Join(t.TempDir(), "test.json")
+	data := []byte(`{"projects": [{"id": "11111111-aaaa", "name": "Work"}], "tasks": [
+		{"id": "aaaaaaaa-1111", "project_id": "11111111-aaaa", "name": "Open", "done": false},
+		{"id": "bbbbbbbb-2222", "project_id": "11111111-aaaa", "name": "Finished", "done": true}
+	], "migrated": true}`)
+	if err := os.WriteFile(dbPath, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	store, err := NewJSONStore(dbPath)
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+	defer store.Close()
+
+	if task, _ := store.GetTask("aaaaaaaa-1111"); task.CurrentStatus() != StatusTodo {
+		t.Errorf("Expected an old open task to be todo, got %q", task.CurrentStatus())
+	}
+	if task, _ := store.GetTask("bbbbbbbb-2222"); task.CurrentStatus() != StatusDone {
+		t.Errorf("Expected an old done task to be done, got %q", task.CurrentStatus())
+	}
+
+	// Marking an open task not done leaves its status alone
+	store.SetTaskStatus("aaaaaaaa-1111", StatusInProgress)
+	if err := store.UpdateTask("aaaaaaaa-1111", false); err != nil {
+		t.Fatal(err)
+	}
+	if task, _ := store.GetTask("aaaaaaaa-1111"); task.CurrentStatus() != StatusInProgress {
+		t.Errorf("Expected /undone to keep an open task's status, got %q", task.CurrentStatus())
+	}
 }
 
 func TestUUIDGeneration(t *testing.T) {
@@ -309,3 +406,323 @@ func TestUUIDGeneration(t *testing.T) {
 		seen[uuid] = true
 	}
 }
+
+func TestUndoRedo(t *testing.T) {
+	tmpDir := t.TempDir()
+	dbPath := filepath.Join(tmpDir, "test.json")
+
+	store, err := NewJSONStore(dbPath)
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+
+	project, _ := store.CreateProject("Work")
+	task1, _ := store.CreateTask(project.ID, "Task A")
+	store.CreateTask(project.ID, "Task B")
+	store.UpdateTask(task1.ID, true)
+
+	// Undo a done flag
+	entry, err := store.Undo()
+	if err != nil {
+		t.Fatalf("Failed to undo: %v", err)
+	}
+	if !strings.Contains(entry.Op, "mark done") {
+		t.Errorf("Expected mark done op, got %q", entry.Op)
+	}
+	task, _ := store.GetTask(task1.ID)
+	if task.Done {
+		t.Error("Task should be not done after undo")
+	}
+
+	// Redo it
+	if _, err := store.Redo(); err != nil {
+		t.Fatalf("Failed to redo: %v", err)
+	}
+	task, _ = store.GetTask(task1.ID)
+	if !task.Done {
+		t.Error("Task should be done after redo")
+	}
+
+	// Delete the project, then reopen the store: undo must survive a restart
+	if err := store.DeleteProject(project.ID); err != nil {
+		t.Fatalf("Failed to delete project: %v", err)
+	}
+	store.Close()
+
+	store, err = NewJSONStore(dbPath)
+	if err != nil {
+		t.Fatalf("Failed to reopen store: %v", err)
+	}
+	defer store.Close()
+
+	if _, err := store.Undo(); err != nil {
+		t.Fatalf("Failed to undo after reopen: %v", err)
+	}
+	tasks, _ := store.ListTasks(project.ID)
+	if len(tasks) != 2 {
+		t.Errorf("Expected 2 tasks restored with project, got %d", len(tasks))
+	}
+	task, _ = store.GetTask(task1.ID)
+	if task == nil || !task.Done {
+		t.Error("Restored task should keep its done status")
+	}
+
+	// A new change clears the redo stack
+	store.CreateTask(project.ID, "Task C")
+	if _, err := store.Redo(); err == nil {
+		t.Error("Redo should fail after a new change")
+	}
+
+	// Undo everything back to an empty store
+	for {
+		if _, err := store.Undo(); err != nil {
+			break
+		}
+	}
+	projects, _ := store.ListProjects()
+	if len(projects) != 0 {
+		t.Errorf("Expected no projects after undoing everything, got %d", len(projects))
+	}
+}
+
+func TestCopyTaskCopiesTimes(t *testing.T) {
+	want := time.Date(2026, 1, 2, 9, 0, 0, 0, time.UTC)
+	completed, updated := want, want
+	task := &Task{ID: "t1", Name: "Task", CompletedAt: &completed, UpdatedAt: &updated}
+
+	// Journal snapshots must not change when the task is edited in place
+	snapshot := copyTask(task)
+	*task.CompletedAt = want.AddDate(0, 0, 1)
+	*task.UpdatedAt = want.AddDate(0, 0, 1)
+	if !snapshot.CompletedAt.Equal(want) || !snapshot.UpdatedAt.Equal(want) {
+		t.Errorf("Expected the copy to keep its times, got %v and %v", snapshot.CompletedAt, snapshot.UpdatedAt)
+	}
+}
+
+func TestJSONStoreReturnsCopies(t *testing.T) {
+	store, err := NewJSONStore(filepath.Join(t.TempDir(), "test.json"))
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+	defer store.Close()
+
+	project, _ := store.CreateProject("Work")
+	created, _ := store.CreateTask(project.ID, "Write report")
+	store.AddTaskTag(created.ID, "urgent")
+
+	// Editing what the store hands out changes nothing in it
+	task, _ := store.GetTask(created.ID)
+	task.Name = "Changed"
+	task.Tags[0] = "changed"
+	listed, _ := store.ListTasks(project.ID)
+	listed[0].Done = true
+	got, _ := store.GetProject(project.ID)
+	got.Name = "Changed"
+	projects, _ := store.ListProjects()
+	projects[0].Shortcut = "changed"
+
+	// And what it handed out earlier doesn't change with it
+	store.UpdateTask(created.ID, true)
+	if task.Done {
+		t.Error("Expected an earlier copy to stay as it was")
+	}
+
+	task, _ = store.GetTask(created.ID)
+	if task.Name != "Write report" || task.Tags[0] != "urgent" {
+		t.Errorf("Expected the task unchanged, got %q %v", task.Name, task.Tags)
+	}
+	got, _ = store.GetProject(project.ID)
+	if got.Name != "Work" || got.Shortcut != project.Shortcut {
+		t.Errorf("Expected the project unchanged, got %q [%s]", got.Name, got.Shortcut)
+	}
+}
+
+func TestEscalate(t *testing.T) {
+	tmpDir := t.TempDir()
+	store, err := NewJSONStore(filepath.Join(tmpDir, "test.json"))
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+	defer store.Close()
+
+	now := time.Date(2030, 6, 10, 9, 0, 0, 0, time.UTC)
+	project, _ := store.CreateProject("Work")
+
+	overdue, _ := store.CreateTask(project.ID, "Overdue")
+	yesterday := now.AddDate(0, 0, -1)
+	store.SetTaskDueDate(overdue.ID, &yesterday)
+
+	soon, _ := store.CreateTask(project.ID, "Soon")
+	inTwo := now.AddDate(0, 0, 2)
+	store.SetTaskDueDate(soon.ID, &inTwo)
+
+	later, _ := store.CreateTask(project.ID, "Later")
+	inTen := now.AddDate(0, 0, 10)
+	store.SetTaskDueDate(later.ID, &inTen)
+
+	// Pushing a due date back twice counts as two snoozes
+	snoozed, _ := store.CreateTask(project.ID, "Snoozed")
+	for i := 20; i <= 22; i++ {
+		due := now.AddDate(0, 0, i)
+		store.SetTaskDueDate(snoozed.ID, &due)
+	}
+
+	// A task already at urgent is never lowered
+	urgent, _ := store.CreateTask(project.ID, "Urgent")
+	store.SetTaskPriority(urgent.ID, PriorityUrgent)
+	store.SetTaskDueDate(urgent.ID, &inTwo)
+
+	// Disabled policy does nothing
+	if applied, _ := store.Escalate(EscalationPolicy{}, now); len(applied) != 0 {
+		t.Fatalf("Expected no escalations with empty policy, got %d", len(applied))
+	}
+
+	policy := EscalationPolicy{DueWithinDays: 3, SnoozeLimit: 2}
+	applied, err := store.Escalate(policy, now)
+	if err != nil {
+		t.Fatalf("Failed to escalate: %v", err)
+	}
+	if len(applied) != 3 {
+		t.Fatalf("Expected 3 escalations, got %d", len(applied))
+	}
+
+	want := map[string]Priority{
+		overdue.ID: PriorityUrgent,
+		soon.ID:    PriorityHigh,
+		later.ID:   "",
+		snoozed.ID: PriorityHigh,
+		urgent.ID:  PriorityUrgent,
+	}
+	for id, priority := range want {
+		task, _ := store.GetTask(id)
+		if task.Priority != priority {
+			t.Errorf("Task %s: expected priority %q, got %q", task.Name, priority, task.Priority)
+		}
+	}
+
+	// Rules set a floor, so a second run is a no-op
+	if applied, _ := store.Escalate(policy, now); len(applied) != 0 {
+		t.Errorf("Expected re-run to apply nothing, got %d", len(applied))
+	}
+
+	// Escalations are journaled and can be undone
+	entry, err := store.Undo()
+	if err != nil {
+		t.Fatalf("Failed to undo: %v", err)
+	}
+	if !strings.Contains(entry.Op, "escalate") {
+		t.Errorf("Expected escalate op in journal, got %q", entry.Op)
+	}
+}
+
+func TestShareExpiry(t *testing.T) {
+	store, err := NewJSONStore(filepath.Join(t.TempDir(), "test.json"))
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+	defer store.Close()
+
+	project, _ := store.CreateProject("Client")
+	share, err := store.CreateShare(project.ID, time.Hour)
+	if err != nil {
+		t.Fatalf("Failed to create share: %v", err)
+	}
+	if _, err := store.GetShare(share.Token); err != nil {
+		t.Errorf("Expected active share, got %v", err)
+	}
+
+	// Age the share past its expiry
+	store.data.Shares[0].ExpiresAt = time.Now().Add(-time.Minute)
+	if _, err := store.GetShare(share.Token); err == nil {
+		t.Error("Expected expired share to be rejected")
+	}
+	if shares, _ := store.ListShares(); len(shares) != 0 {
+		t.Errorf("Expected no active shares, got %d", len(shares))
+	}
+}
+
+func TestTimerSurvivesRestart(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "test.json")
+	store, err := NewJSONStore(path)
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+
+	project, _ := store.CreateProject("Work")
+	task, _ := store.CreateTask(project.ID, "Write report")
+	if _, err := store.StartTimer(task.ID); err != nil {
+		t.Fatalf("Failed to start timer: %v", err)
+	}
+	if _, err := store.StartTimer(task.ID); err == nil {
+		t.Error("Expected second timer to be rejected")
+	}
+	store.Close()
+
+	store, err = NewJSONStore(path)
+	if err != nil {
+		t.Fatalf("Failed to reopen store: %v", err)
+	}
+	defer store.Close()
+
+	active, err := store.ActiveTimer()
+	if err != nil || active == nil || active.TaskID != task.ID {
+		t.Fatalf("Expected running timer after restart, got %v, %v", active, err)
+	}
+
+	entry, err := store.StopTimer()
+	if err != nil {
+		t.Fatalf("Failed to stop timer: %v", err)
+	}
+	if entry.Running() {
+		t.Error("Expected stopped entry")
+	}
+	if entries, _ := store.ListTimeEntries(); len(entries) != 1 {
+		t.Errorf("Expected 1 time entry, got %d", len(entries))
+	}
+}
+
+func TestConcurrentSessions(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "test.json")
+	a, err := NewJSONStore(path)
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+	defer a.Close()
+	b, err := NewJSONStore(path)
+	if err != nil {
+		t.Fatalf("Failed to open second store: %v", err)
+	}
+	defer b.Close()
+
+	// Each session sees and builds on the other's changes
+	work, _ := a.CreateProject("Work")
+	if _, err := b.CreateTask(work.ID, "Write report"); err != nil {
+		t.Fatalf("Expected second session to see the new project: %v", err)
+	}
+	b.CreateProject("Home")
+	if projects, _ := a.ListProjects(); len(projects) != 2 {
+		t.Errorf("Expected 2 projects, got %d", len(projects))
+	}
+	if tasks, _ := a.ListTasks(work.ID); len(tasks) != 1 {
+		t.Errorf("Expected 1 task, got %d", len(tasks))
+	}
+
+	// Simultaneous writers don't clobber each other
+	var wg sync.WaitGroup
+	for _, store := range []*JSONStore{a, b} {
+		wg.Add(1)
+		go func(store *JSONStore) {
+			defer wg.Done()
+			for i := 0; i < 20; i++ {
+				if _, err := store.CreateTask(work.ID, fmt.Sprintf("Task %d", i)); err != nil {
+					t.Errorf("Failed to create task: %v", err)
+				}
+			}
+		}(store)
+	}
+	wg.Wait()
+
+	if tasks, _ := b.ListTasks(work.ID); len(tasks) != 41 {
+		t.Errorf("Expected 41 tasks, got %d", len(tasks))
+	}
+}
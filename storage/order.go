@@ -0,0 +1,108 @@
+package storage
+
+import (
+	"fmt"
+	"sort"
+)
+
+// A task's Order is its place in its project's list, counting from 1. Tasks
+// get one when a project is first reordered; until then, and for tasks added
+// or moved in since, it's 0 and they follow the ordered ones by creation.
+
+// orderedBefore reports whether a is listed before b: tasks with an Order
+// come first by it, then the rest by creation time and ID, so listings are
+// the same in every backend
+func orderedBefore(a, b *Task) bool {
+	switch {
+	case a.Order != b.Order && (a.Order == 0 || b.Order == 0):
+		return b.Order == 0
+	case a.Order != b.Order:
+		return a.Order < b.Order
+	case !a.CreatedAt.Equal(b.CreatedAt):
+		return a.CreatedAt.Before(b.CreatedAt)
+	}
+	return a.ID < b.ID
+}
+
+// sortByOrder sorts tasks into list order
+func sortByOrder(tasks []*Task) {
+	sort.SliceStable(tasks, func(i, j int) bool { return orderedBefore(tasks[i], tasks[j]) })
+}
+
+// moveTo puts a task in another project ("" for the inbox), at the end of
+// the list there
+func (t *Task) moveTo(projectID string) {
+	t.ProjectID = projectID
+	t.Order = 0
+}
+
+// reorder moves the task with the given ID to position (from 1; out of
+// range goes to the top or bottom) of list, a project's tasks in list
+// order. It numbers the whole list and returns the IDs of the tasks whose
+// Order changes, in their new order, with their new Orders.
+func reorder(list []*Task, id string, position int) ([]string, map[string]int) {
+	var moved *Task
+	rest := make([]*Task, 0, len(list))
+	for _, t := range list {
+		if t.ID == id {
+			moved = t
+		} else {
+			rest = append(rest, t)
+		}
+	}
+	if moved == nil {
+		return nil, nil
+	}
+	position = max(1, min(position, len(list)))
+	ordered := append(append(append([]*Task{}, rest[:position-1]...), moved), rest[position-1:]...)
+
+	var ids []string
+	orders := make(map[string]int)
+	for i, t := range ordered {
+		if t.Order != i+1 {
+			ids = append(ids, t.ID)
+			orders[t.ID] = i + 1
+		}
+	}
+	return ids, orders
+}
+
+// reorderOp names a reorder in the journal
+func reorderOp(task *Task) string {
+	return fmt.Sprintf("reorder %q", task.Name)
+}
+
+// ReorderTask moves a task to a position in its project's list
+func (s *JSONStore) ReorderTask(id string, position int) error {
+	release, err := s.beginWrite()
+	if err != nil {
+		return err
+	}
+	defer release()
+
+	task := s.taskByID(id)
+	if task == nil {
+		return fmt.Errorf("task not found: %s", id)
+	}
+	if task.IsArchived() {
+		return fmt.Errorf("%q is archived", task.Name)
+	}
+	var list []*Task
+	for _, t := range s.data.Tasks {
+		if t.ProjectID == task.ProjectID && !t.IsArchived() {
+			list = append(list, t)
+		}
+	}
+	sortByOrder(list)
+
+	ids, orders := reorder(list, id, position)
+	if len(ids) == 0 {
+		return nil
+	}
+	return s.journaled(reorderOp(task), nil, ids, func() error {
+		for _, id := range ids {
+			s.taskByID(id).Order = orders[id]
+		}
+		return nil
+	})
+}
@@ -0,0 +1,74 @@
+package storage
+
+import "testing"
+
+func TestReorderTask(t *testing.T) {
+	for name, open := range testBackends {
+		t.Run(name, func(t *testing.T) {
+			store := open(t)
+			work, _ := store.CreateProject("Work")
+			home, _ := store.CreateProject("Home")
+			a, _ := store.CreateTask(work.ID, "A")
+			b, _ := store.CreateTask(work.ID, "B")
+			c, _ := store.CreateTask(work.ID, "C")
+
+			names := func() string {
+				tasks, err := store.ListTasks(work.ID)
+				if err != nil {
+					t.Fatalf("Failed to list tasks: %v", err)
+				}
+				s := ""
+				for _, task := range tasks {
+					s += task.Name
+				}
+				return s
+			}
+
+			if got := names(); got != "ABC" {
+				t.Fatalf("Expected creation order before any reorder, got %s", got)
+			}
+			if err := store.ReorderTask(c.ID, 1); err != nil {
+				t.Fatalf("Failed to reorder: %v", err)
+			}
+			if got := names(); got != "CAB" {
+				t.Errorf("Expected C moved to the top, got %s", got)
+			}
+
+			// Out of range goes to the bottom; new tasks follow the ordered ones
+			store.ReorderTask(a.ID, 10)
+			store.CreateTask(work.ID, "D")
+			if got := names(); got != "CBAD" {
+				t.Errorf("Expected A at the bottom and D after it, got %s", got)
+			}
+
+			// A reorder is one journal entry
+			if _, err := store.Undo(); err != nil {
+				t.Fatalf("Failed to undo: %v", err)
+			}
+			if _, err := store.Undo(); err != nil {
+				t.Fatalf("Failed to undo: %v", err)
+			}
+			if got := names(); got != "CAB" {
+				t.Errorf("Expected undo to put A back, got %s", got)
+			}
+
+			// Moving to another project puts a task at the end of its list
+			store.ReorderTask(b.ID, 1)
+			h, _ := store.CreateTask(home.ID, "H")
+			store.ReorderTask(h.ID, 1)
+			if err := store.MoveTask(b.ID, home.ID); err != nil {
+				t.Fatalf("Failed to move: %v", err)
+			}
+			if tasks, _ := store.ListTasks(home.ID); len(tasks) != 2 || tasks[0].ID != h.ID || tasks[1].Order != 0 {
+				t.Errorf("Expected the moved task last and unordered, got %v", tasks)
+			}
+			if got := names(); got != "CA" {
+				t.Errorf("Expected the rest of Work to keep its order, got %s", got)
+			}
+
+			if err := store.ReorderTask("missing", 1); err == nil {
+				t.Error("Expected an error for an unknown task")
+			}
+		})
+	}
+}
@@ -0,0 +1,176 @@
+package storage
+
+import (
+	"slices"
+	"sort"
+	"time"
+)
+
+// DayPlan is a proposed set of open tasks that fits within a time budget
+type DayPlan struct {
+	Budget      int     // minutes available
+	Used        int     // minutes taken by Tasks
+	Tasks       []*Task // in the order they should be worked
+	Deferred    []*Task // ready tasks that did not fit, most urgent first
+	Unestimated []*Task // ready tasks with no duration, which can't be budgeted
+	Blocked     int     // open tasks waiting on another open task
+
+	// Set by PlanDayAround: when each of Tasks is worked, in time order, and
+	// the overdue or due-today tasks that no free time can hold
+	Slots     []PlanSlot
+	Conflicts []*Task
+}
+
+// PlanSlot is when a planned task is worked
+type PlanSlot struct {
+	TimeSpan
+	Task *Task
+}
+
+// PlanDay picks tasks for a day with budget minutes available. Tasks are
+// ranked by urgency: overdue, then due today, then priority, then earliest
+// due date, then shortest first. Each task that still fits in the remaining
+// budget is taken in that order, so a long task never crowds out the
+// shorter ones behind it. Tasks blocked by an open task are left out.
+func PlanDay(tasks []*Task, budget int, now time.Time) *DayPlan {
+	plan := &DayPlan{Budget: budget}
+	for _, t := range readyTasks(plan, tasks, now) {
+		minutes := t.Duration.ToMinutes()
+		if plan.Used+minutes > budget {
+			plan.Deferred = append(plan.Deferred, t)
+			continue
+		}
+		plan.Tasks = append(plan.Tasks, t)
+		plan.Used += minutes
+	}
+
+	return plan
+}
+
+// PlanDayAround is PlanDay for a day with meetings: tasks are taken in the
+// same order, and each goes into the earliest free span that holds it whole,
+// so a task never straddles a meeting. A budget of 0 means all the free
+// time. Overdue and due-today tasks that don't fit in any free span are
+// Conflicts as well as Deferred.
+func PlanDayAround(tasks []*Task, budget int, free []TimeSpan, now time.Time) *DayPlan {
+	total := 0
+	for _, span := range free {
+		total += span.Minutes()
+	}
+	if budget <= 0 || budget > total {
+		budget = total
+	}
+	plan := &DayPlan{Budget: budget}
+
+	gaps := append([]TimeSpan(nil), free...)
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	for _, t := range readyTasks(plan, tasks, now) {
+		length := time.Duration(t.Duration.ToMinutes()) * time.Minute
+		gap := slices.IndexFunc(gaps, func(g TimeSpan) bool { return g.End.Sub(g.Start) >= length })
+		if gap < 0 {
+			plan.Deferred = append(plan.Deferred, t)
+			if dueBucket(t, today) < 2 {
+				plan.Conflicts = append(plan.Conflicts, t)
+			}
+			continue
+		}
+		if plan.Used+t.Duration.ToMinutes() > budget {
+			plan.Deferred = append(plan.Deferred, t)
+			continue
+		}
+
+		slot := PlanSlot{TimeSpan: TimeSpan{Start: gaps[gap].Start, End: gaps[gap].Start.Add(length)}, Task: t}
+		gaps[gap].Start = slot.End
+		plan.Slots = append(plan.Slots, slot)
+		plan.Used += t.Duration.ToMinutes()
+	}
+
+	sort.SliceStable(plan.Slots, func(i, j int) bool { return plan.Slots[i].Start.Before(plan.Slots[j].Start) })
+	for _, slot := range plan.Slots {
+		plan.Tasks = append(plan.Tasks, slot.Task)
+	}
+	return plan
+}
+
+// readyTasks returns the open tasks a plan can use, most urgent first, and
+// records the blocked and unestimated ones in plan
+func readyTasks(plan *DayPlan, tasks []*Task, now time.Time) []*Task {
+	open := make(map[string]bool)
+	for _, t := range tasks {
+		if !t.Done {
+			open[t.ID] = true
+		}
+	}
+
+	var ready []*Task
+	for _, t := range tasks {
+		if t.Done {
+			continue
+		}
+		if hasOpenBlocker(t, open) {
+			plan.Blocked++
+			continue
+		}
+		if t.Duration.ToMinutes() == 0 {
+			plan.Unestimated = append(plan.Unestimated, t)
+			continue
+		}
+		ready = append(ready, t)
+	}
+
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	sort.SliceStable(ready, func(i, j int) bool {
+		return planLess(ready[i], ready[j], today)
+	})
+	sort.SliceStable(plan.Unestimated, func(i, j int) bool {
+		return planLess(plan.Unestimated[i], plan.Unestimated[j], today)
+	})
+	return ready
+}
+
+func hasOpenBlocker(t *Task, open map[string]bool) bool {
+	for _, id := range t.BlockedBy {
+		if open[id] {
+			return true
+		}
+	}
+	return false
+}
+
+// dueBucket groups tasks as overdue (0), due today (1), or later/undated (2)
+func dueBucket(t *Task, today time.Time) int {
+	if t.DueDate == nil {
+		return 2
+	}
+	due := time.Date(t.DueDate.Year(), t.DueDate.Month(), t.DueDate.Day(), 0, 0, 0, 0, time.UTC)
+	switch {
+	case due.Before(today):
+		return 0
+	case due.Equal(today):
+		return 1
+	default:
+		return 2
+	}
+}
+
+// planLess reports whether a is more urgent than b
+func planLess(a, b *Task, today time.Time) bool {
+	if ba, bb := dueBucket(a, today), dueBucket(b, today); ba != bb {
+		return ba < bb
+	}
+	if ra, rb := a.Priority.Rank(), b.Priority.Rank(); ra != rb {
+		return ra > rb
+	}
+	switch {
+	case a.DueDate != nil && b.DueDate == nil:
+		return true
+	case a.DueDate == nil && b.DueDate != nil:
+		return false
+	case a.DueDate != nil && !a.DueDate.Equal(*b.DueDate):
+		return a.DueDate.Before(*b.DueDate)
+	}
+	if ma, mb := a.Duration.ToMinutes(), b.Duration.ToMinutes(); ma != mb {
+		return ma < mb
+	}
+	return a.CreatedAt.Before(b.CreatedAt)
+}
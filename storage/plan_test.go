@@ -0,0 +1,113 @@
+package storage
+
+import (
+	"testing"
+	"time"
+)
+
+func TestPlanDay(t *testing.T) {
+	now := time.Date(2025, 3, 12, 9, 0, 0, 0, time.UTC)
+	day := func(offset int) *time.Time {
+		d := time.Date(2025, 3, 12+offset, 0, 0, 0, 0, time.UTC)
+		return &d
+	}
+
+	tasks := []*Task{
+		{ID: "later", Name: "Later", Duration: Duration30m, DueDate: day(5)},
+		{ID: "big", Name: "Big", Duration: Duration4h, Priority: PriorityHigh},
+		{ID: "overdue", Name: "Overdue", Duration: Duration1h, DueDate: day(-2)},
+		{ID: "today", Name: "Today", Duration: Duration1h, DueDate: day(0)},
+		{ID: "urgent", Name: "Urgent", Duration: Duration30m, Priority: PriorityUrgent},
+		{ID: "quick", Name: "Quick", Duration: Duration15m},
+		{ID: "blocked", Name: "Blocked", Duration: Duration15m, BlockedBy: []string{"today"}},
+		{ID: "unblocked", Name: "Unblocked", Duration: Duration15m, BlockedBy: []string{"finished"}},
+		{ID: "finished", Name: "Finished", Duration: Duration15m, Done: true},
+		{ID: "vague", Name: "Vague"},
+	}
+
+	plan := PlanDay(tasks, 4*60, now)
+
+	// Overdue and due-today come first, then priority, then due date, then
+	// shortest; the 4h task can't fit after those, but shorter ones still do
+	want := []string{"overdue", "today", "urgent", "later", "quick", "unblocked"}
+	if len(plan.Tasks) != len(want) {
+		t.Fatalf("Expected %d planned tasks, got %d", len(want), len(plan.Tasks))
+	}
+	for i, id := range want {
+		if plan.Tasks[i].ID != id {
+			t.Errorf("Position %d: expected %s, got %s", i, id, plan.Tasks[i].ID)
+		}
+	}
+	if plan.Used != 3*60+30 {
+		t.Errorf("Expected 3h 30m used, got %d minutes", plan.Used)
+	}
+
+	if len(plan.Deferred) != 1 || plan.Deferred[0].ID != "big" {
+		t.Errorf("Expected Big to be deferred, got %v", plan.Deferred)
+	}
+	if len(plan.Unestimated) != 1 || plan.Unestimated[0].ID != "vague" {
+		t.Errorf("Expected Vague to be unestimated, got %v", plan.Unestimated)
+	}
+	if plan.Blocked != 1 {
+		t.Errorf("Expected 1 blocked task, got %d", plan.Blocked)
+	}
+
+	// A bigger budget takes everything ready
+	plan = PlanDay(tasks, 8*60, now)
+	if len(plan.Tasks) != 7 || len(plan.Deferred) != 0 {
+		t.Errorf("Expected all 7 ready tasks planned, got %d (%d deferred)", len(plan.Tasks), len(plan.Deferred))
+	}
+}
+
+func TestPlanDayAround(t *testing.T) {
+	now := time.Date(2025, 3, 12, 9, 0, 0, 0, time.UTC)
+	at := func(hour, minute int) time.Time { return time.Date(2025, 3, 12, hour, minute, 0, 0, time.UTC) }
+	today := time.Date(2025, 3, 12, 0, 0, 0, 0, time.UTC)
+
+	tasks := []*Task{
+		{ID: "big", Name: "Big", Duration: Duration2h, DueDate: &today},
+		{ID: "short", Name: "Short", Duration: Duration30m, Priority: PriorityHigh},
+		{ID: "medium", Name: "Medium", Duration: Duration1h},
+		{ID: "later", Name: "Later", Duration: Duration1h},
+	}
+	// 9:00-10:00 and 11:00-12:30 are free, around meetings
+	busy := []Busy{
+		{TimeSpan: TimeSpan{Start: at(10, 0), End: at(11, 0)}, Summary: "Standup"},
+		{TimeSpan: TimeSpan{Start: at(12, 30), End: at(17, 0)}, Summary: "Offsite"},
+	}
+	free := FreeTime(busy, now, at(17, 0))
+	if len(free) != 2 || free[1].Minutes() != 90 {
+		t.Fatalf("Expected two free spans, got %v", free)
+	}
+
+	plan := PlanDayAround(tasks, 0, free, now)
+	if plan.Budget != 150 {
+		t.Errorf("Expected all 2h30m of free time as the budget, got %d", plan.Budget)
+	}
+
+	// The 2h task fits nowhere; the others go in the earliest gap that holds them
+	want := []struct {
+		id    string
+		start time.Time
+	}{{"short", at(9, 0)}, {"medium", at(11, 0)}}
+	if len(plan.Slots) != len(want) {
+		t.Fatalf("Expected %d slots, got %v", len(want), plan.Slots)
+	}
+	for i, w := range want {
+		if plan.Slots[i].Task.ID != w.id || !plan.Slots[i].Start.Equal(w.start) {
+			t.Errorf("Slot %d: expected %s at %s, got %s at %s", i, w.id, w.start.Format("15:04"), plan.Slots[i].Task.ID, plan.Slots[i].Start.Format("15:04"))
+		}
+	}
+	if len(plan.Conflicts) != 1 || plan.Conflicts[0].ID != "big" {
+		t.Errorf("Expected the due 2h task as a conflict, got %v", plan.Conflicts)
+	}
+	if len(plan.Deferred) != 2 {
+		t.Errorf("Expected big and later deferred, got %d", len(plan.Deferred))
+	}
+
+	// A budget smaller than the free time still limits the plan
+	plan = PlanDayAround(tasks, 30, free, now)
+	if len(plan.Tasks) != 1 || plan.Tasks[0].ID != "short" {
+		t.Errorf("Expected only the 30m task within a 30m budget, got %v", plan.Tasks)
+	}
+}
@@ -0,0 +1,148 @@
+package storage
+
+import (
+	"fmt"
+	"sort"
+)
+
+// Reorg is a batch of project cleanups applied by Store.Reorganize as one
+// change, so a single undo reverts all of it
+type Reorg struct {
+	Moves     map[string]string // task ID to the project it moves to
+	Merges    map[string]string // project ID to the project that takes its tasks; the merged project is deleted
+	Shortcuts map[string]string // project ID to its new shortcut
+}
+
+// NewReorg returns an empty Reorg ready to fill in
+func NewReorg() *Reorg {
+	return &Reorg{
+		Moves:     make(map[string]string),
+		Merges:    make(map[string]string),
+		Shortcuts: make(map[string]string),
+	}
+}
+
+// Empty reports whether the reorg changes nothing
+func (r *Reorg) Empty() bool {
+	return len(r.Moves) == 0 && len(r.Merges) == 0 && len(r.Shortcuts) == 0
+}
+
+// reorgChanges is a Reorg checked against the store's projects and tasks
+type reorgChanges struct {
+	projectIDs []string          // every project touched, for the journal
+	taskIDs    []string          // every task that moves, for the journal
+	moves      map[string]string // task ID to project, including tasks of merged projects
+	deleted    map[string]bool   // merged projects
+	shortcuts  map[string]string
+}
+
+// resolveReorg validates a reorg and works out every task it moves: those
+// named in Moves, and the rest of each merged project's tasks. A task moved
+// explicitly goes where Moves says, even out of a merged project.
+func resolveReorg(r *Reorg, projects []*Project, tasks []*Task) (*reorgChanges, error) {
+	if r.Empty() {
+		return nil, fmt.Errorf("nothing to reorganize")
+	}
+
+	byID := make(map[string]*Project)
+	for _, p := range projects {
+		byID[p.ID] = p
+	}
+	taskByID := make(map[string]*Task)
+	for _, t := range tasks {
+		taskByID[t.ID] = t
+	}
+
+	c := &reorgChanges{
+		moves:     make(map[string]string),
+		deleted:   make(map[string]bool),
+		shortcuts: make(map[string]string),
+	}
+	touched := make(map[string]bool)
+
+	for from, into := range r.Merges {
+		if byID[from] == nil {
+			return nil, fmt.Errorf("project not found: %s", from)
+		}
+		if byID[into] == nil {
+			return nil, fmt.Errorf("project not found: %s", into)
+		}
+		if from == into {
+			return nil, fmt.Errorf("can't merge %s into itself", byID[from].Name)
+		}
+		c.deleted[from] = true
+		touched[from], touched[into] = true, true
+	}
+	for from, into := range r.Merges {
+		if c.deleted[into] {
+			return nil, fmt.Errorf("can't merge %s into %s, which is itself being merged", byID[from].Name, byID[into].Name)
+		}
+	}
+
+	for _, t := range tasks {
+		if into, ok := r.Merges[t.ProjectID]; ok {
+			c.moves[t.ID] = into
+		}
+	}
+	for taskID, projectID := range r.Moves {
+		t := taskByID[taskID]
+		if t == nil {
+			return nil, fmt.Errorf("task not found: %s", taskID)
+		}
+		if byID[projectID] == nil {
+			return nil, fmt.Errorf("project not found: %s", projectID)
+		}
+		if c.deleted[projectID] {
+			return nil, fmt.Errorf("can't move %q to %s, which is being merged", t.Name, byID[projectID].Name)
+		}
+		if projectID == t.ProjectID {
+			delete(c.moves, taskID)
+			continue
+		}
+		c.moves[taskID] = projectID
+		touched[t.ProjectID], touched[projectID] = true, true
+	}
+
+	// Shortcuts must be unique among the projects left afterwards
+	final := make(map[string]string) // shortcut to project ID
+	for _, p := range projects {
+		if !c.deleted[p.ID] && r.Shortcuts[p.ID] == "" {
+			final[p.Shortcut] = p.ID
+		}
+	}
+	for projectID, shortcut := range r.Shortcuts {
+		p := byID[projectID]
+		if p == nil {
+			return nil, fmt.Errorf("project not found: %s", projectID)
+		}
+		if c.deleted[projectID] {
+			return nil, fmt.Errorf("can't set a shortcut for %s, which is being merged", p.Name)
+		}
+		if err := validateShortcut(shortcut); err != nil {
+			return nil, err
+		}
+		if other, ok := final[shortcut]; ok {
+			return nil, fmt.Errorf("shortcut %s is used by both %s and %s", shortcut, byID[other].Name, p.Name)
+		}
+		final[shortcut] = projectID
+		c.shortcuts[projectID] = shortcut
+		touched[projectID] = true
+	}
+
+	delete(touched, "") // tasks moved out of the inbox; it isn't a project
+	for id := range touched {
+		c.projectIDs = append(c.projectIDs, id)
+	}
+	sort.Strings(c.projectIDs)
+	for id := range c.moves {
+		c.taskIDs = append(c.taskIDs, id)
+	}
+	sort.Strings(c.taskIDs)
+	return c, nil
+}
+
+// op describes the changes for the journal
+func (c *reorgChanges) op() string {
+	return fmt.Sprintf("reorganize projects (%d tasks moved, %d projects merged, %d shortcuts changed)",
+		len(c.moves), len(c.deleted), len(c.shortcuts))
+}
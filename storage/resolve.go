@@ -0,0 +1,98 @@
+package storage
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrAmbiguous is wrapped by resolve errors for a reference that matches
+// more than one project or task
+var ErrAmbiguous = errors.New("ambiguous")
+
+// minNamePart is how long a reference must be to match part of a name, so
+// a stray short word doesn't pick a project or task at random
+const minNamePart = 3
+
+// maxCandidates is how many matches an ambiguous name error lists
+const maxCandidates = 5
+
+// matchNames returns the items named ref, ignoring case, or if none is,
+// the items whose names contain it
+func matchNames[T any](items []T, ref string, name func(T) string) []T {
+	ref = strings.TrimSpace(ref)
+	if ref == "" {
+		return nil
+	}
+	var exact, partial []T
+	lower := strings.ToLower(ref)
+	for _, item := range items {
+		switch n := name(item); {
+		case strings.EqualFold(n, ref):
+			exact = append(exact, item)
+		case len(ref) >= minNamePart && strings.Contains(strings.ToLower(n), lower):
+			partial = append(partial, item)
+		}
+	}
+	if len(exact) > 0 {
+		return exact
+	}
+	return partial
+}
+
+// candidates lists the labels of ambiguous matches, up to maxCandidates
+func candidates(labels []string) string {
+	if len(labels) > maxCandidates {
+		return strings.Join(labels[:maxCandidates], ", ") + fmt.Sprintf(", and %d more", len(labels)-maxCandidates)
+	}
+	return strings.Join(labels, ", ")
+}
+
+// projectNamed finds the project a name refers to, ignoring case: the one
+// with that name, or else the only one whose name contains it. It's
+// ResolveProjectID's last resort, so "Home Renovation" or just "renov"
+// works wherever a project ID does. It returns "" if no project matches.
+func projectNamed(projects []*Project, name string) (string, error) {
+	found := matchNames(projects, name, func(p *Project) string { return p.Name })
+	switch len(found) {
+	case 0:
+		return "", nil
+	case 1:
+		return found[0].ID, nil
+	}
+	labels := make([]string, len(found))
+	for i, p := range found {
+		labels[i] = fmt.Sprintf("%s [%s]", p.Name, p.Shortcut)
+	}
+	return "", fmt.Errorf("%w project name %q matches %s; use a shortcut", ErrAmbiguous, name, candidates(labels))
+}
+
+// taskNamed finds the task a name refers to the way projectNamed does. Of
+// several matches, open tasks win over done or archived ones, so "milk"
+// picks this week's "Buy milk" over last week's. It returns "" if no task
+// matches.
+func taskNamed(tasks []*Task, name string) (string, error) {
+	found := matchNames(tasks, name, func(t *Task) string { return t.Name })
+	if len(found) > 1 {
+		var open []*Task
+		for _, t := range found {
+			if !t.Done && !t.IsArchived() {
+				open = append(open, t)
+			}
+		}
+		if len(open) > 0 {
+			found = open
+		}
+	}
+	switch len(found) {
+	case 0:
+		return "", nil
+	case 1:
+		return found[0].ID, nil
+	}
+	labels := make([]string, len(found))
+	for i, t := range found {
+		labels[i] = fmt.Sprintf("%s [%s]", DisplayName(t.Name), shortID(t.ID))
+	}
+	return "", fmt.Errorf("%w task name %q matches %s; use an ID", ErrAmbiguous, name, candidates(labels))
+}
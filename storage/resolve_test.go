@@ -0,0 +1,53 @@
+package storage
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestResolveNames(t *testing.T) {
+	for name, open := range testBackends {
+		t.Run(name, func(t *testing.T) {
+			store := open(t)
+			groceries, _ := store.CreateProject("Groceries")
+			growth, _ := store.CreateProject("Growth Plan")
+			store.CreateProject("Home")
+
+			// Part of a name, ignoring case, when nothing else matches
+			if id, err := store.ResolveProjectID("grocer"); err != nil || id != groceries.ID {
+				t.Errorf("Expected grocer to resolve to Groceries, got %s, %v", id, err)
+			}
+			if id, err := store.ResolveProjectID("PLAN"); err != nil || id != growth.ID {
+				t.Errorf("Expected PLAN to resolve to Growth Plan, got %s, %v", id, err)
+			}
+			_, err := store.ResolveProjectID("gro")
+			if err == nil || !strings.Contains(err.Error(), "Groceries [") || !strings.Contains(err.Error(), "Growth Plan [") {
+				t.Errorf("Expected an ambiguity error listing both, got %v", err)
+			}
+			if _, err := store.ResolveProjectID("gr"); err == nil || !strings.Contains(err.Error(), "project not found") {
+				t.Errorf("Expected a two-letter reference not to match part of a name, got %v", err)
+			}
+
+			// Tasks the same way; an exact name beats a longer one containing it,
+			// and an open task beats a done one
+			milk, _ := store.CreateTask(groceries.ID, "Milk")
+			store.CreateTask(groceries.ID, "Oat milk")
+			oldBread, _ := store.CreateTask(groceries.ID, "Buy bread")
+			store.UpdateTask(oldBread.ID, true)
+			bread, _ := store.CreateTask(groceries.ID, "Buy bread")
+			if id, err := store.ResolveTaskID("milk"); err != nil || id != milk.ID {
+				t.Errorf("Expected the exact name to win, got %s, %v", id, err)
+			}
+			if id, err := store.ResolveTaskID("bread"); err != nil || id != bread.ID {
+				t.Errorf("Expected the open task to win, got %s, %v", id, err)
+			}
+			store.UpdateTask(bread.ID, true)
+			if _, err := store.ResolveTaskID("bread"); err == nil || !strings.Contains(err.Error(), "ambiguous task name") {
+				t.Errorf("Expected two done tasks to be ambiguous, got %v", err)
+			}
+			if _, err := store.ResolveTaskID("cheese"); err == nil || !strings.Contains(err.Error(), "task not found") {
+				t.Errorf("Expected no match, got %v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,50 @@
+package storage
+
+import (
+	"sort"
+	"time"
+)
+
+// Review sorts a project's open tasks into the groups a weekly review walks
+// through. Each task is in at most one group, checked in this order.
+type Review struct {
+	Overdue []*Task // due before today
+	Stale   []*Task // not edited for the stale period
+	Undated []*Task // no due date, but edited recently
+}
+
+// Len returns how many tasks the review covers
+func (r *Review) Len() int {
+	return len(r.Overdue) + len(r.Stale) + len(r.Undated)
+}
+
+// ReviewTasks groups the open tasks for a review. A task is stale when its
+// last activity (see LastActivity) is at least staleDays old. Groups are
+// ordered oldest first: overdue by due date, the rest by last activity.
+func ReviewTasks(tasks []*Task, staleDays int, now time.Time) *Review {
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	staleBefore := now.AddDate(0, 0, -staleDays)
+
+	r := &Review{}
+	for _, t := range tasks {
+		switch {
+		case t.Done:
+		case dueBucket(t, today) == 0:
+			r.Overdue = append(r.Overdue, t)
+		case t.LastActivity().Before(staleBefore):
+			r.Stale = append(r.Stale, t)
+		case t.DueDate == nil:
+			r.Undated = append(r.Undated, t)
+		}
+	}
+
+	sort.SliceStable(r.Overdue, func(i, j int) bool {
+		return r.Overdue[i].DueDate.Before(*r.Overdue[j].DueDate)
+	})
+	for _, group := range [][]*Task{r.Stale, r.Undated} {
+		sort.SliceStable(group, func(i, j int) bool {
+			return group[i].LastActivity().Before(group[j].LastActivity())
+		})
+	}
+	return r
+}
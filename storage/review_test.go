@@ -0,0 +1,42 @@
+package storage
+
+import (
+	"testing"
+	"time"
+)
+
+func TestReviewTasks(t *testing.T) {
+	now := time.Date(2026, 10, 15, 12, 0, 0, 0, time.UTC)
+	day := func(d int) time.Time { return time.Date(2026, 10, d, 9, 0, 0, 0, time.UTC) }
+	at := func(d int) *time.Time { t := day(d); return &t }
+
+	tasks := []*Task{
+		{Name: "Overdue later", CreatedAt: day(14), DueDate: at(13)},
+		{Name: "Overdue first", CreatedAt: day(14), DueDate: at(10)},
+		{Name: "Old, edited last week", CreatedAt: day(1), UpdatedAt: at(8), DueDate: at(20)},
+		{Name: "Old and untouched", CreatedAt: day(1)},
+		{Name: "Old and done", CreatedAt: day(1), Done: true},
+		{Name: "New, no date", CreatedAt: day(12)},
+		{Name: "Due today", CreatedAt: day(12), DueDate: at(15)},
+	}
+
+	r := ReviewTasks(tasks, 7, now)
+	names := func(group []*Task) []string {
+		var out []string
+		for _, t := range group {
+			out = append(out, t.Name)
+		}
+		return out
+	}
+	check := func(label string, got []*Task, want ...string) {
+		if g := names(got); len(g) != len(want) || (len(g) > 0 && g[0] != want[0]) || (len(g) > 1 && g[1] != want[1]) {
+			t.Errorf("%s: expected %v, got %v", label, want, g)
+		}
+	}
+	check("overdue", r.Overdue, "Overdue first", "Overdue later")
+	check("stale", r.Stale, "Old and untouched", "Old, edited last week")
+	check("undated", r.Undated, "New, no date")
+	if r.Len() != 5 {
+		t.Errorf("Expected 5 tasks to review, got %d", r.Len())
+	}
+}
@@ -0,0 +1,131 @@
+package storage
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"time"
+)
+
+// Share is a read-only link to a single project. Anyone holding the token can
+// view the project until it expires or is revoked; it grants no write access.
+type Share struct {
+	Token     string    `json:"token"`
+	ProjectID string    `json:"project_id"`
+	CreatedAt time.Time `json:"created_at"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// Expired returns true once the share is past its expiry time
+func (sh *Share) Expired(now time.Time) bool {
+	return !now.Before(sh.ExpiresAt)
+}
+
+// newShareToken returns 128 random bits as hex, unguessable enough for a URL
+func newShareToken() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		panic(err)
+	}
+	return hex.EncodeToString(b)
+}
+
+// CreateShare mints a read-only share link for a project that expires after ttl
+func (s *JSONStore) CreateShare(projectID string, ttl time.Duration) (*Share, error) {
+	release, err := s.beginWrite()
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	if s.projectByID(projectID) == nil {
+		return nil, fmt.Errorf("project not found: %s", projectID)
+	}
+	if ttl <= 0 {
+		return nil, fmt.Errorf("share lifetime must be positive")
+	}
+
+	now := time.Now()
+	share := &Share{
+		Token:     newShareToken(),
+		ProjectID: projectID,
+		CreatedAt: now,
+		ExpiresAt: now.Add(ttl),
+	}
+
+	// Drop expired shares while we're here so the list doesn't grow forever
+	active := s.data.Shares[:0]
+	for _, sh := range s.data.Shares {
+		if !sh.Expired(now) {
+			active = append(active, sh)
+		}
+	}
+	s.data.Shares = append(active, share)
+
+	copied := *share
+	return &copied, s.save()
+}
+
+// GetShare returns the share for a token, or an error if it is unknown or expired
+func (s *JSONStore) GetShare(token string) (*Share, error) {
+	release, err := s.beginRead()
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	for _, sh := range s.data.Shares {
+		if sh.Token == token {
+			if sh.Expired(time.Now()) {
+				return nil, fmt.Errorf("share link expired")
+			}
+			copied := *sh
+			return &copied, nil
+		}
+	}
+	return nil, fmt.Errorf("share not found")
+}
+
+// ListShares returns all unexpired shares, oldest first
+func (s *JSONStore) ListShares() ([]*Share, error) {
+	release, err := s.beginRead()
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	now := time.Now()
+	var shares []*Share
+	for _, sh := range s.data.Shares {
+		if !sh.Expired(now) {
+			copied := *sh
+			shares = append(shares, &copied)
+		}
+	}
+	return shares, nil
+}
+
+// DeleteShare revokes a share; the token may be abbreviated to a unique prefix
+func (s *JSONStore) DeleteShare(token string) error {
+	release, err := s.beginWrite()
+	if err != nil {
+		return err
+	}
+	defer release()
+
+	match := -1
+	for i, sh := range s.data.Shares {
+		if sh.Token == token || (len(token) >= 6 && len(sh.Token) > len(token) && sh.Token[:len(token)] == token) {
+			if match >= 0 {
+				return fmt.Errorf("ambiguous share token: %s", token)
+			}
+			match = i
+		}
+	}
+	if match < 0 {
+		return fmt.Errorf("share not found: %s", token)
+	}
+
+	s.data.Shares = append(s.data.Shares[:match], s.data.Shares[match+1:]...)
+	return s.save()
+}
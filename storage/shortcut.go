@@ -0,0 +1,104 @@
+package storage
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// reservedShortcuts are words commands give a meaning of their own where a
+// project ID is accepted (/digest all, /due ... none, /today, /log
+// yesterday, /move ... inbox, /focus off), so a project can't use them as
+// its shortcut. Matching ignores case.
+var reservedShortcuts = map[string]bool{
+	"all":       true,
+	"none":      true,
+	"today":     true,
+	"tomorrow":  true,
+	"yesterday": true,
+	"week":      true,
+	"next-week": true,
+	"help":      true,
+	"inbox":     true,
+	"done":      true,
+	"off":       true,
+}
+
+// IsReservedShortcut reports whether shortcut is a reserved word
+func IsReservedShortcut(shortcut string) bool {
+	return reservedShortcuts[strings.ToLower(shortcut)]
+}
+
+// validateShortcut checks a shortcut's format and that it isn't reserved
+func validateShortcut(shortcut string) error {
+	if !shortcutRegex.MatchString(shortcut) {
+		// Names with spaces, accents, or emoji are the usual cause; offer an ASCII spelling
+		if slug := Slug(shortcut); slug != "" && !IsReservedShortcut(slug) {
+			return fmt.Errorf("invalid shortcut: must be 1-20 alphanumeric characters or hyphens, e.g. %q", slug)
+		}
+		return fmt.Errorf("invalid shortcut: must be 1-20 alphanumeric characters or hyphens")
+	}
+	if IsReservedShortcut(shortcut) {
+		return fmt.Errorf("%q is a reserved word that commands use (reserved: %s); pick another shortcut, e.g. %q",
+			shortcut, strings.Join(ReservedShortcuts(), ", "), strings.ToLower(shortcut)+"-tasks")
+	}
+	return nil
+}
+
+// ReservedShortcuts lists the reserved words in alphabetical order
+func ReservedShortcuts() []string {
+	words := make([]string, 0, len(reservedShortcuts))
+	for w := range reservedShortcuts {
+		words = append(words, w)
+	}
+	sort.Strings(words)
+	return words
+}
+
+// ShortcutRename records a shortcut FixShortcuts changed
+type ShortcutRename struct {
+	Project *Project
+	Old     string
+	Reason  string // "reserved" or "duplicate"
+}
+
+// FixShortcuts renames project shortcuts that are reserved words or shared
+// with an older project (stores written before reservation, or merged from
+// imports), giving each the default ID-prefix shortcut. Shortcuts are unique
+// per store, so each store file is its own namespace. Renames go through
+// SetProjectShortcut and can be undone.
+func FixShortcuts(s Store) ([]*ShortcutRename, error) {
+	projects, err := s.ListProjects()
+	if err != nil {
+		return nil, err
+	}
+	// The oldest project keeps a shared shortcut
+	sort.SliceStable(projects, func(i, j int) bool {
+		return projects[i].CreatedAt.Before(projects[j].CreatedAt)
+	})
+
+	var renames []*ShortcutRename
+	seen := make(map[string]bool)
+	for _, p := range projects {
+		reason := ""
+		switch {
+		case IsReservedShortcut(p.Shortcut):
+			reason = "reserved"
+		case seen[p.Shortcut]:
+			reason = "duplicate"
+		}
+		if reason == "" {
+			seen[p.Shortcut] = true
+			continue
+		}
+
+		old := p.Shortcut
+		if err := s.SetProjectShortcut(p.ID, shortID(p.ID)); err != nil {
+			return renames, fmt.Errorf("renaming shortcut %q of %s: %w", old, p.Name, err)
+		}
+		p.Shortcut = shortID(p.ID)
+		seen[p.Shortcut] = true
+		renames = append(renames, &ShortcutRename{Project: p, Old: old, Reason: reason})
+	}
+	return renames, nil
+}
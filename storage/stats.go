@@ -0,0 +1,87 @@
+package storage
+
+import "time"
+
+// WeekStats counts one week's activity, for trends and burndown charts
+type WeekStats struct {
+	Start     time.Time // Monday of the week
+	Created   int
+	Completed int
+	Open      int // open tasks at the end of the week (now, for the current week)
+}
+
+// Stats summarizes a set of tasks over time
+type Stats struct {
+	Total         int
+	Done          int
+	OpenMinutes   int           // estimated time of the open tasks
+	Timed         int           // done tasks with a completion time
+	AvgTimeToDone time.Duration // average creation to completion over Timed tasks
+	Weeks         []WeekStats   // oldest first, ending with the current week
+}
+
+// CompletionRate returns the fraction of tasks that are done
+func (s *Stats) CompletionRate() float64 {
+	if s.Total == 0 {
+		return 0
+	}
+	return float64(s.Done) / float64(s.Total)
+}
+
+// ComputeStats summarizes tasks, with the given number of weeks of history
+// ending with the week containing now. Tasks marked done before completion
+// times were recorded count as done from their creation.
+func ComputeStats(tasks []*Task, now time.Time, weeks int) *Stats {
+	s := &Stats{Total: len(tasks)}
+
+	var open []*Task
+	var timeToDone time.Duration
+	for _, t := range tasks {
+		if !t.Done {
+			open = append(open, t)
+			continue
+		}
+		s.Done++
+		if t.CompletedAt != nil {
+			s.Timed++
+			timeToDone += t.CompletedAt.Sub(t.CreatedAt)
+		}
+	}
+	s.OpenMinutes = TotalDuration(open)
+	if s.Timed > 0 {
+		s.AvgTimeToDone = timeToDone / time.Duration(s.Timed)
+	}
+
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	monday := today.AddDate(0, 0, -((int(today.Weekday()) + 6) % 7))
+	for i := weeks - 1; i >= 0; i-- {
+		start := monday.AddDate(0, 0, -7*i)
+		end := start.AddDate(0, 0, 7)
+		if end.After(now) {
+			end = now
+		}
+
+		week := WeekStats{Start: start}
+		for _, t := range tasks {
+			if !t.CreatedAt.Before(start) && t.CreatedAt.Before(end) {
+				week.Created++
+			}
+			if t.CompletedAt != nil && !t.CompletedAt.Before(start) && t.CompletedAt.Before(end) {
+				week.Completed++
+			}
+			if t.CreatedAt.Before(end) && !completedBy(t, end) {
+				week.Open++
+			}
+		}
+		s.Weeks = append(s.Weeks, week)
+	}
+	return s
+}
+
+// completedBy reports whether t was done before the given time
+func completedBy(t *Task, at time.Time) bool {
+	if !t.Done {
+		return false
+	}
+	return t.CompletedAt == nil || t.CompletedAt.Before(at)
+}
@@ -0,0 +1,54 @@
+package storage
+
+import (
+	"testing"
+	"time"
+)
+
+func TestComputeStats(t *testing.T) {
+	// Thursday; the current week started Monday the 12th
+	now := time.Date(2026, 10, 15, 12, 0, 0, 0, time.UTC)
+	day := func(d int) time.Time { return time.Date(2026, 10, d, 9, 0, 0, 0, time.UTC) }
+	at := func(d int) *time.Time { t := day(d); return &t }
+
+	tasks := []*Task{
+		{Name: "Old, done last week", CreatedAt: day(1), Done: true, CompletedAt: at(7)},
+		{Name: "Done this week", CreatedAt: day(6), Done: true, CompletedAt: at(13), Duration: Duration1h},
+		{Name: "Open from last week", CreatedAt: day(8), Duration: Duration2h},
+		{Name: "Open this week", CreatedAt: day(14), Duration: Duration30m},
+		{Name: "Done before tracking", CreatedAt: day(2), Done: true},
+	}
+
+	s := ComputeStats(tasks, now, 3)
+	if s.Total != 5 || s.Done != 3 || s.Timed != 2 {
+		t.Errorf("Expected 5 tasks, 3 done, 2 timed, got %+v", s)
+	}
+	if got := s.CompletionRate(); got != 0.6 {
+		t.Errorf("Expected completion rate 0.6, got %v", got)
+	}
+	if s.OpenMinutes != 150 {
+		t.Errorf("Expected 150 open minutes, got %d", s.OpenMinutes)
+	}
+	// (6 days + 7 days) / 2
+	if s.AvgTimeToDone != 156*time.Hour {
+		t.Errorf("Expected 6.5 days to done, got %v", s.AvgTimeToDone)
+	}
+
+	want := []WeekStats{
+		{Start: time.Date(2026, 9, 28, 0, 0, 0, 0, time.UTC), Created: 2, Completed: 0, Open: 1},
+		{Start: time.Date(2026, 10, 5, 0, 0, 0, 0, time.UTC), Created: 2, Completed: 1, Open: 2},
+		{Start: time.Date(2026, 10, 12, 0, 0, 0, 0, time.UTC), Created: 1, Completed: 1, Open: 2},
+	}
+	if len(s.Weeks) != len(want) {
+		t.Fatalf("Expected %d weeks, got %+v", len(want), s.Weeks)
+	}
+	for i, w := range want {
+		if s.Weeks[i] != w {
+			t.Errorf("Week %d: expected %+v, got %+v", i, w, s.Weeks[i])
+		}
+	}
+
+	if empty := ComputeStats(nil, now, 2); empty.CompletionRate() != 0 || len(empty.Weeks) != 2 {
+		t.Errorf("Expected empty stats with 2 weeks, got %+v", empty)
+	}
+}
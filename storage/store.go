@@ -11,21 +11,102 @@ type Store interface {
 	GetProject(id string) (*Project, error)
 	DeleteProject(id string) error
 	SetProjectShortcut(projectID, shortcut string) error
+	SetProjectDueDate(projectID string, dueDate *time.Time) error
+	// SetProjectDefaults sets the defaults for tasks created in a project;
+	// zero values clear them
+	SetProjectDefaults(projectID string, duration Duration, due string) error
+	Reorganize(r *Reorg) error // task moves, merges, and shortcut changes as one journal entry
 
-	// ID resolution - resolves shortcuts/prefixes to full UUIDs
+	// ID resolution - resolves shortcuts, prefixes, and names (or part of one) to full UUIDs
 	ResolveProjectID(idOrShortcut string) (string, error)
 	ResolveTaskID(idOrPrefix string) (string, error)
 
-	// Task operations
+	// Task operations - listings leave out archived tasks and are in list
+	// order (see orderedBefore). A task with an empty ProjectID is in the
+	// inbox, which ListTasks("") lists.
 	CreateTask(projectID, name string) (*Task, error)
+	CreateTaskFrom(task *Task) (*Task, error)   // ID and CreatedAt are assigned; other fields are kept
+	CreateTasks(tasks []*Task) ([]*Task, error) // like CreateTaskFrom, as one journal entry
 	ListTasks(projectID string) ([]*Task, error)
 	ListAllTasks() ([]*Task, error)
 	GetTask(id string) (*Task, error)
 	UpdateTask(id string, done bool) error
+	UpdateTasks(ids []string, done bool) error // one save and one journal entry
 	SetTaskDueDate(id string, dueDate *time.Time) error
+	SetTaskDueDates(dates map[string]time.Time) error // one save and one journal entry; a zero time clears a date
 	SetTaskDuration(id string, duration Duration) error
+	SetTaskDurations(durations map[string]Duration) error // one save and one journal entry
+	SetTaskPriority(id string, priority Priority) error
+	SetTaskNote(id, note string) error
+	SetTaskStatus(id string, status Status) error        // keeps Done in step
+	MoveTask(id, projectID string) error                 // to another project ("" for the inbox), at the end of its list
+	ReorderTask(id string, position int) error           // to a place in its project's list, from 1, as one journal entry
+	ArchiveTasks(ids []string) error                     // done tasks only, as one journal entry
+	ListArchivedTasks(projectID string) ([]*Task, error) // empty projectID lists all projects
 	DeleteTask(id string) error
 
+	// Tag operations - tags are stored lower-case without "#", and every tag
+	// argument is normalized the same way (NormalizeTag). There is no tag
+	// index: ListTasksByTag scans the tasks like the other listings, which
+	// already read all of them, so there's nothing to keep in step on undo,
+	// sync, or import.
+	AddTaskTag(id, tag string) error
+	RemoveTaskTag(id, tag string) error
+	ListTasksByTag(tag string) ([]*Task, error)
+
+	// Dependency operations - taskID is blocked until blockerID is done
+	AddTaskDependency(taskID, blockerID string) error
+	RemoveTaskDependency(taskID, blockerID string) error
+
+	// Escalation - raise priorities of tasks matching the policy (journaled)
+	Escalate(policy EscalationPolicy, now time.Time) ([]*Escalation, error)
+
+	// Import operations - insert entities with existing IDs (fail if the ID is taken)
+	ImportProject(project *Project) error
+	ImportTask(task *Task) error
+
+	// Replace operations - overwrite an entity wholesale (used to resolve conflicts)
+	ReplaceProject(project *Project) error
+	ReplaceTask(task *Task) error
+
+	// Conflict queue - incoming versions awaiting resolution
+	AddConflict(conflict *Conflict) error
+	ListConflicts() ([]*Conflict, error)
+	DeleteConflict(id string) error
+
+	// Time tracking - at most one timer runs at a time
+	StartTimer(taskID string) (*TimeEntry, error)
+	StopTimer() (*TimeEntry, error)
+	ActiveTimer() (*TimeEntry, error)
+	ListTimeEntries() ([]*TimeEntry, error)
+
+	// Share links - expiring read-only access to a single project
+	CreateShare(projectID string, ttl time.Duration) (*Share, error)
+	GetShare(token string) (*Share, error)
+	ListShares() ([]*Share, error)
+	DeleteShare(token string) error
+
+	// Trash - deletes that /restore can bring back until they're purged
+	TrashProject(id string) error                     // the project and its tasks, as one journal entry
+	TrashTasks(ids []string) error                    // as one journal entry
+	ListTrash() ([]*TrashItem, error)                 // restorable items, newest first
+	RestoreTrash(idPrefix string) (*TrashItem, error) // journaled; returns the item as restored
+	PurgeTrash(before time.Time) (int, error)         // a zero time empties the trash; can't be undone
+
+	// Transactions - the changes fn makes through tx are saved together, as
+	// one journal entry named op, or not at all if fn returns an error. The
+	// store is locked until fn returns, so fn must only use tx.
+	Transact(op string, fn func(tx Store) error) error
+
+	// Undo/redo - reverse or re-apply journaled mutations
+	Undo() (*JournalEntry, error)
+	Redo() (*JournalEntry, error)
+
+	// Maintenance - keeping the data file small
+	Size() (int64, error)           // bytes on disk
+	DeleteTasks(ids []string) error // as one journal entry
+	Compact() (*Compaction, error)  // drops undo/redo history, which can't be undone
+
 	// Lifecycle
 	Close() error
 }
@@ -0,0 +1,181 @@
+package storage
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// DueSuggestion is a proposed due date for a new task and why it was chosen
+type DueSuggestion struct {
+	Date   time.Time
+	Reason string
+}
+
+const (
+	// DailyLoadLimit is how many minutes of open work can be due on one day
+	// before suggestions move to a later day; it's also the default daily
+	// capacity
+	DailyLoadLimit = 240
+
+	// unestimatedMinutes is the load assumed for a task with no duration
+	unestimatedMinutes = 30
+
+	// minHistory is how many past due dates are needed to trust a lead time
+	minHistory = 3
+)
+
+var weekdays = map[string]time.Weekday{
+	"sunday": time.Sunday,
+	"monday": time.Monday, "mon": time.Monday,
+	"tuesday": time.Tuesday, "tue": time.Tuesday, "tues": time.Tuesday,
+	"wednesday": time.Wednesday, "wed": time.Wednesday,
+	"thursday": time.Thursday, "thu": time.Thursday, "thurs": time.Thursday,
+	"friday": time.Friday, "fri": time.Friday,
+	"saturday": time.Saturday,
+}
+
+// SuggestDueDate proposes a due date for a new task in project, or returns
+// nil if there is nothing to go on. Deadline words in the name ("tomorrow",
+// "friday", "end of week") win outright. Otherwise the typical lead time of
+// past tasks is used, moved past days that are already full and capped at
+// the project's due date. tasks should be every task in the store.
+func SuggestDueDate(name string, project *Project, tasks []*Task, now time.Time) *DueSuggestion {
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+
+	if s := deadlineFromName(name, today); s != nil {
+		return s
+	}
+
+	var projectDue *time.Time
+	if project != nil && project.DueDate != nil {
+		due := time.Date(project.DueDate.Year(), project.DueDate.Month(), project.DueDate.Day(), 0, 0, 0, 0, time.UTC)
+		if !due.Before(today) {
+			projectDue = &due
+		}
+	}
+
+	lead, fromProject, ok := typicalLeadDays(project, tasks)
+	if !ok {
+		if projectDue != nil {
+			return &DueSuggestion{Date: *projectDue, Reason: "project due date"}
+		}
+		return nil
+	}
+
+	date := today.AddDate(0, 0, lead)
+	reason := fmt.Sprintf("tasks here are usually due %s", formatLead(lead))
+	if !fromProject {
+		reason = fmt.Sprintf("your tasks are usually due %s", formatLead(lead))
+	}
+
+	// Skip days that already have a full load, but never past the project deadline
+	load := dueLoad(tasks)
+	for load[date] >= DailyLoadLimit && (projectDue == nil || date.Before(*projectDue)) {
+		date = date.AddDate(0, 0, 1)
+		reason = "next day with room in your schedule"
+	}
+
+	if projectDue != nil && date.After(*projectDue) {
+		return &DueSuggestion{Date: *projectDue, Reason: "project due date"}
+	}
+	return &DueSuggestion{Date: date, Reason: reason}
+}
+
+// deadlineFromName looks for deadline words in a task name
+func deadlineFromName(name string, today time.Time) *DueSuggestion {
+	words := strings.FieldsFunc(strings.ToLower(name), func(r rune) bool {
+		return !(r >= 'a' && r <= 'z' || r >= '0' && r <= '9')
+	})
+
+	for i, w := range words {
+		next := ""
+		if i+1 < len(words) {
+			next = words[i+1]
+		}
+
+		switch {
+		case w == "today" || w == "tonight" || w == "asap" || w == "eod":
+			return &DueSuggestion{Date: today, Reason: fmt.Sprintf("name says %q", w)}
+		case w == "tomorrow":
+			return &DueSuggestion{Date: today.AddDate(0, 0, 1), Reason: `name says "tomorrow"`}
+		case w == "eow" || (w == "end" && i+2 < len(words) && words[i+1] == "of" && words[i+2] == "week"):
+			return &DueSuggestion{Date: nextWeekday(today, time.Friday), Reason: "name says end of week"}
+		case w == "eom" || (w == "end" && i+2 < len(words) && words[i+1] == "of" && words[i+2] == "month"):
+			lastDay := time.Date(today.Year(), today.Month()+1, 0, 0, 0, 0, 0, time.UTC)
+			return &DueSuggestion{Date: lastDay, Reason: "name says end of month"}
+		case w == "next" && next == "week":
+			return &DueSuggestion{Date: nextWeekday(today.AddDate(0, 0, 1), time.Monday), Reason: `name says "next week"`}
+		}
+
+		if day, ok := weekdays[w]; ok {
+			return &DueSuggestion{Date: nextWeekday(today, day), Reason: fmt.Sprintf("name says %q", w)}
+		}
+	}
+	return nil
+}
+
+// nextWeekday returns the first date on or after from that falls on day
+func nextWeekday(from time.Time, day time.Weekday) time.Time {
+	offset := (int(day) - int(from.Weekday()) + 7) % 7
+	return from.AddDate(0, 0, offset)
+}
+
+// typicalLeadDays returns the median days between creating a task and its
+// due date, preferring the project's own history when there is enough of it
+func typicalLeadDays(project *Project, tasks []*Task) (int, bool, bool) {
+	var all, inProject []int
+	for _, t := range tasks {
+		if t.DueDate == nil {
+			continue
+		}
+		created := time.Date(t.CreatedAt.Year(), t.CreatedAt.Month(), t.CreatedAt.Day(), 0, 0, 0, 0, time.UTC)
+		due := time.Date(t.DueDate.Year(), t.DueDate.Month(), t.DueDate.Day(), 0, 0, 0, 0, time.UTC)
+		days := int(due.Sub(created).Hours() / 24)
+		if days < 0 {
+			continue
+		}
+		all = append(all, days)
+		if project != nil && t.ProjectID == project.ID {
+			inProject = append(inProject, days)
+		}
+	}
+
+	if len(inProject) >= minHistory {
+		return median(inProject), true, true
+	}
+	if len(all) >= minHistory {
+		return median(all), false, true
+	}
+	return 0, false, false
+}
+
+func median(values []int) int {
+	sort.Ints(values)
+	return values[len(values)/2]
+}
+
+// dueLoad totals the minutes of open work due on each day
+func dueLoad(tasks []*Task) map[time.Time]int {
+	load := make(map[time.Time]int)
+	for _, t := range tasks {
+		if t.Done || t.DueDate == nil {
+			continue
+		}
+		day := time.Date(t.DueDate.Year(), t.DueDate.Month(), t.DueDate.Day(), 0, 0, 0, 0, time.UTC)
+		load[day] += TaskLoad(t)
+	}
+	return load
+}
+
+func formatLead(days int) string {
+	switch days {
+	case 0:
+		return "the same day"
+	case 1:
+		return "the next day"
+	default:
+		return fmt.Sprintf("%d days out", days)
+	}
+}
@@ -0,0 +1,72 @@
+package storage
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSuggestDueDate(t *testing.T) {
+	// A Wednesday
+	now := time.Date(2025, 3, 12, 9, 0, 0, 0, time.UTC)
+	day := func(offset int) time.Time {
+		return time.Date(2025, 3, 12+offset, 0, 0, 0, 0, time.UTC)
+	}
+	at := func(offset int) *time.Time {
+		d := day(offset)
+		return &d
+	}
+
+	project := &Project{ID: "p1", Name: "Work"}
+
+	// Deadline words in the name
+	nameCases := []struct {
+		name string
+		want time.Time
+	}{
+		{"Send invoice today", day(0)},
+		{"Call dentist tomorrow", day(1)},
+		{"Report due Friday", day(2)},
+		{"Standup notes (wed)", day(0)},
+		{"Plan sprint next week", day(5)},
+		{"Wrap up by end of week", day(2)},
+		{"Close books EOM", day(19)},
+	}
+	for _, tc := range nameCases {
+		s := SuggestDueDate(tc.name, project, nil, now)
+		if s == nil || !s.Date.Equal(tc.want) {
+			t.Errorf("%q: expected %s, got %v", tc.name, tc.want.Format("2006-01-02"), s)
+		}
+	}
+
+	// Nothing to go on
+	if s := SuggestDueDate("Tidy desk", project, nil, now); s != nil {
+		t.Errorf("Expected no suggestion, got %v", s)
+	}
+
+	// Historical lead time: tasks in this project are usually due 3 days out
+	history := []*Task{
+		{ID: "h1", ProjectID: "p1", CreatedAt: day(-20), DueDate: at(-17), Done: true},
+		{ID: "h2", ProjectID: "p1", CreatedAt: day(-10), DueDate: at(-7), Done: true},
+		{ID: "h3", ProjectID: "p1", CreatedAt: day(-6), DueDate: at(-2), Done: true},
+	}
+	s := SuggestDueDate("Tidy desk", project, history, now)
+	if s == nil || !s.Date.Equal(day(3)) {
+		t.Fatalf("Expected lead-time suggestion of %s, got %v", day(3).Format("2006-01-02"), s)
+	}
+
+	// A full day pushes the suggestion later
+	busy := append(history,
+		&Task{ID: "b1", ProjectID: "p1", Duration: Duration4h, DueDate: at(3)},
+	)
+	s = SuggestDueDate("Tidy desk", project, busy, now)
+	if s == nil || !s.Date.Equal(day(4)) {
+		t.Errorf("Expected suggestion moved to %s, got %v", day(4).Format("2006-01-02"), s)
+	}
+
+	// ...but never past the project's due date
+	project.DueDate = at(2)
+	s = SuggestDueDate("Tidy desk", project, history, now)
+	if s == nil || !s.Date.Equal(day(2)) || s.Reason != "project due date" {
+		t.Errorf("Expected project due date, got %v", s)
+	}
+}
@@ -0,0 +1,147 @@
+package storage
+
+import "sort"
+
+// MergeSnapshots merges two versions of a store that both started out as
+// base, matching projects and tasks by ID. An entity changed on one side
+// takes that side's version, and one deleted on one side is dropped unless
+// the other side changed it. A task changed on both sides is merged with
+// MergeTasks; a project changed on both keeps mine, and theirs comes back as
+// a conflict for /conflicts. Tasks whose project is gone go to the inbox, and
+// a shortcut both sides gave to different projects stays with the older one.
+func MergeSnapshots(base, mine, theirs *Snapshot, source string) (*Snapshot, []*Conflict) {
+	merged := &Snapshot{}
+	var conflicts []*Conflict
+
+	baseProjects := make(map[string]*Project)
+	for _, p := range base.Projects {
+		baseProjects[p.ID] = p
+	}
+	myProjects := make(map[string]*Project)
+	for _, p := range mine.Projects {
+		myProjects[p.ID] = p
+	}
+	theirProjects := make(map[string]*Project)
+	for _, p := range theirs.Projects {
+		theirProjects[p.ID] = p
+	}
+	for _, id := range mergeOrder(projectIDs(mine.Projects), projectIDs(theirs.Projects)) {
+		b, m, t := baseProjects[id], myProjects[id], theirProjects[id]
+		switch {
+		case m == nil && t == nil:
+		case t == nil:
+			if b == nil || !sameJSON(b, m) {
+				merged.Projects = append(merged.Projects, m)
+			}
+		case m == nil:
+			if b == nil || !sameJSON(b, t) {
+				merged.Projects = append(merged.Projects, t)
+			}
+		case sameJSON(m, t) || sameJSON(b, t):
+			merged.Projects = append(merged.Projects, m)
+		case sameJSON(b, m):
+			merged.Projects = append(merged.Projects, t)
+		default:
+			merged.Projects = append(merged.Projects, m)
+			conflicts = append(conflicts, NewConflict(source, t, nil))
+		}
+	}
+
+	baseTasks := make(map[string]*Task)
+	for _, t := range base.Tasks {
+		baseTasks[t.ID] = t
+	}
+	myTasks := make(map[string]*Task)
+	for _, t := range mine.Tasks {
+		myTasks[t.ID] = t
+	}
+	theirTasks := make(map[string]*Task)
+	for _, t := range theirs.Tasks {
+		theirTasks[t.ID] = t
+	}
+	for _, id := range mergeOrder(taskIDs(mine.Tasks), taskIDs(theirs.Tasks)) {
+		b, m, t := baseTasks[id], myTasks[id], theirTasks[id]
+		var task *Task
+		switch {
+		case m == nil && t == nil:
+		case t == nil:
+			if b == nil || !sameJSON(b, m) {
+				task = m
+			}
+		case m == nil:
+			if b == nil || !sameJSON(b, t) {
+				task = t
+			}
+		case sameJSON(m, t) || sameJSON(b, t):
+			task = m
+		case sameJSON(b, m):
+			task = t
+		default:
+			task = MergeTasks(m, t)
+		}
+		if task != nil {
+			merged.Tasks = append(merged.Tasks, task)
+		}
+	}
+
+	kept := make(map[string]bool)
+	for _, p := range merged.Projects {
+		kept[p.ID] = true
+	}
+	for i, t := range merged.Tasks {
+		if t.ProjectID != "" && !kept[t.ProjectID] {
+			moved := copyTask(t)
+			moved.moveTo("")
+			merged.Tasks[i] = moved
+		}
+	}
+
+	byAge := append([]*Project{}, merged.Projects...)
+	sort.SliceStable(byAge, func(i, j int) bool { return byAge[i].CreatedAt.Before(byAge[j].CreatedAt) })
+	taken := make(map[string]bool)
+	for _, p := range byAge {
+		if !taken[p.Shortcut] {
+			taken[p.Shortcut] = true
+			continue
+		}
+		renamed := copyProject(p)
+		renamed.Shortcut = shortID(p.ID)
+		taken[renamed.Shortcut] = true
+		for i := range merged.Projects {
+			if merged.Projects[i] == p {
+				merged.Projects[i] = renamed
+			}
+		}
+	}
+
+	return merged, conflicts
+}
+
+// mergeOrder lists mine's IDs, then those only theirs has
+func mergeOrder(mine, theirs []string) []string {
+	seen := make(map[string]bool)
+	var ids []string
+	for _, id := range append(mine, theirs...) {
+		if !seen[id] {
+			seen[id] = true
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
+
+func projectIDs(projects []*Project) []string {
+	ids := make([]string, len(projects))
+	for i, p := range projects {
+		ids[i] = p.ID
+	}
+	return ids
+}
+
+func taskIDs(tasks []*Task) []string {
+	ids := make([]string, len(tasks))
+	for i, t := range tasks {
+		ids[i] = t.ID
+	}
+	return ids
+}
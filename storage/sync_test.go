@@ -0,0 +1,86 @@
+package storage
+
+import (
+	"testing"
+	"time"
+)
+
+func TestMergeSnapshots(t *testing.T) {
+	now := time.Date(2025, 1, 1, 9, 0, 0, 0, time.UTC)
+	work := &Project{ID: "p-work", Name: "Work", Shortcut: "work", CreatedAt: now}
+	home := &Project{ID: "p-home", Name: "Home", Shortcut: "home", CreatedAt: now}
+	task := func(id, project, name string) *Task {
+		return &Task{ID: id, ProjectID: project, Name: name, CreatedAt: now}
+	}
+	base := &Snapshot{
+		Projects: []*Project{work, home},
+		Tasks: []*Task{
+			task("t-report", "p-work", "Write report"),
+			task("t-review", "p-work", "Review PR"),
+			task("t-lawn", "p-home", "Mow lawn"),
+			task("t-bank", "", "Call bank"),
+		},
+	}
+
+	// Mine: finish the report, delete the PR review, rename Home, add a task
+	mine := &Snapshot{Projects: []*Project{work, {ID: "p-home", Name: "House", Shortcut: "home", CreatedAt: now}}}
+	report := task("t-report", "p-work", "Write report")
+	report.Done = true
+	mine.Tasks = []*Task{report, task("t-lawn", "p-home", "Mow lawn"), task("t-bank", "", "Call bank"), task("t-mine", "p-work", "Plan offsite")}
+
+	// Theirs: tag the report, delete the lawn task, rename Home and the inbox
+	// task, and add a project, using Work's shortcut, with a task
+	theirReport := task("t-report", "p-work", "Write report")
+	theirReport.Tags = []string{"q1"}
+	theirs := &Snapshot{
+		Projects: []*Project{work, {ID: "p-home", Name: "Household", Shortcut: "home", CreatedAt: now}, {ID: "p-new12345", Name: "New", Shortcut: "work", CreatedAt: now.Add(time.Hour)}},
+		Tasks:    []*Task{theirReport, task("t-review", "p-work", "Review PR"), task("t-bank", "", "Call the bank"), task("t-theirs", "p-new12345", "Their task")},
+	}
+
+	merged, conflicts := MergeSnapshots(base, mine, theirs, "sync")
+
+	got := make(map[string]*Task)
+	for _, t := range merged.Tasks {
+		got[t.ID] = t
+	}
+	if r := got["t-report"]; r == nil || !r.Done || !r.HasTag("q1") {
+		t.Errorf("Expected the report merged from both sides, got %+v", r)
+	}
+	if got["t-review"] != nil {
+		t.Error("Expected the task I deleted to stay deleted")
+	}
+	if got["t-lawn"] != nil {
+		t.Error("Expected the task they deleted to stay deleted")
+	}
+	if b := got["t-bank"]; b == nil || b.Name != "Call the bank" {
+		t.Errorf("Expected their rename of the inbox task, got %+v", b)
+	}
+	if got["t-mine"] == nil || got["t-theirs"] == nil {
+		t.Error("Expected tasks added on either side kept")
+	}
+
+	if len(merged.Projects) != 3 || merged.Projects[1].Name != "House" {
+		t.Fatalf("Expected Work, my House, and New, got %+v", merged.Projects)
+	}
+	if len(conflicts) != 1 || conflicts[0].Project.Name != "Household" || conflicts[0].Source != "sync" {
+		t.Errorf("Expected their Home rename queued as a conflict, got %+v", conflicts)
+	}
+	if merged.Projects[2].Shortcut != "p-new123" || merged.Projects[0].Shortcut != "work" {
+		t.Errorf("Expected the newer project's shortcut reset, got %q and %q", merged.Projects[0].Shortcut, merged.Projects[2].Shortcut)
+	}
+}
+
+func TestMergeSnapshotsOrphans(t *testing.T) {
+	now := time.Now()
+	work := &Project{ID: "p-work", Name: "Work", Shortcut: "work", CreatedAt: now}
+	report := &Task{ID: "t-report", ProjectID: "p-work", Name: "Write report", CreatedAt: now}
+	base := &Snapshot{Projects: []*Project{work}, Tasks: []*Task{report}}
+
+	// I delete the project while they edit its task; the task survives in the inbox
+	edited := *report
+	edited.Note = "draft done"
+	merged, _ := MergeSnapshots(base, &Snapshot{}, &Snapshot{Projects: []*Project{work}, Tasks: []*Task{&edited}}, "sync")
+	if len(merged.Projects) != 0 || len(merged.Tasks) != 1 || merged.Tasks[0].ProjectID != "" || merged.Tasks[0].Note != "draft done" {
+		t.Errorf("Expected the edited task kept in the inbox, got %+v", merged)
+	}
+}
@@ -0,0 +1,263 @@
+package storage
+
+import (
+	"strings"
+	"unicode"
+)
+
+// Invisible characters that matter when measuring and cleaning names
+const (
+	zeroWidthJoiner = '\u200d'
+	emojiPresent    = '\ufe0f' // variation selector 16: show the previous character as emoji
+	firstStrongIso  = '\u2068' // starts a run laid out by its own direction
+	popDirIso       = '\u2069' // ends it
+)
+
+// CleanName makes a project or task name safe to print on one line: control
+// characters (newlines, tabs, escape sequences) become a single space, and
+// bidi embeddings, overrides, and isolates, which can make the rest of a
+// line read backwards, are dropped. Emoji and right-to-left letters are kept.
+func CleanName(name string) string {
+	var b strings.Builder
+	space := false
+	for _, r := range name {
+		switch {
+		case isBidiControl(r):
+			continue
+		case unicode.IsControl(r):
+			if !space {
+				b.WriteRune(' ')
+			}
+			space = true
+			continue
+		}
+		b.WriteRune(r)
+		space = false
+	}
+	return strings.TrimSpace(b.String())
+}
+
+// isBidiControl reports whether r is an explicit direction embedding,
+// override, or isolate (U+202A-U+202E, U+2066-U+2069)
+func isBidiControl(r rune) bool {
+	return (r >= '\u202a' && r <= '\u202e') || (r >= '\u2066' && r <= '\u2069')
+}
+
+// DisplayName is CleanName for names printed next to IDs and other text: a
+// name with right-to-left letters is isolated, so terminals that lay out
+// bidi text don't pull the surrounding brackets and IDs into it
+func DisplayName(name string) string {
+	name = CleanName(name)
+	for _, r := range name {
+		if unicode.In(r, unicode.Hebrew, unicode.Arabic, unicode.Syriac, unicode.Thaana, unicode.Nko) {
+			return string(firstStrongIso) + name + string(popDirIso)
+		}
+	}
+	return name
+}
+
+// TextWidth returns how many terminal columns s takes up: wide characters
+// (CJK, most emoji) take two, and combining marks, joiners, and variation
+// selectors take none. An emoji sequence joined with ZWJ counts as one emoji.
+func TextWidth(s string) int {
+	width := 0
+	for _, c := range textClusters(s) {
+		width += clusterWidth(c)
+	}
+	return width
+}
+
+// TruncateText shortens s to at most width columns, ending it with "…" when
+// anything was cut. Emoji sequences and letters with accents aren't split.
+func TruncateText(s string, width int) string {
+	if TextWidth(s) <= width {
+		return s
+	}
+	if width < 1 {
+		return ""
+	}
+	var b strings.Builder
+	used := 0
+	for _, c := range textClusters(s) {
+		w := clusterWidth(c)
+		if used+w > width-1 {
+			break
+		}
+		b.WriteString(c)
+		used += w
+	}
+	return b.String() + "…"
+}
+
+// PadText pads s with spaces to width columns, like %-*s does for ASCII
+func PadText(s string, width int) string {
+	if pad := width - TextWidth(s); pad > 0 {
+		return s + strings.Repeat(" ", pad)
+	}
+	return s
+}
+
+// textClusters splits s into what a terminal draws as one character: a rune
+// with the combining marks, variation selectors, and skin tones after it,
+// and anything joined to it with ZWJ
+func textClusters(s string) []string {
+	var clusters []string
+	start, joined := -1, false
+	for i, r := range s {
+		if start >= 0 && (joined || zeroWidth(r) || isSkinTone(r)) {
+			joined = r == zeroWidthJoiner
+			continue
+		}
+		if start >= 0 {
+			clusters = append(clusters, s[start:i])
+		}
+		start, joined = i, r == zeroWidthJoiner
+	}
+	if start >= 0 {
+		clusters = append(clusters, s[start:])
+	}
+	return clusters
+}
+
+// clusterWidth is the width of the cluster's first rune, or two for a
+// narrow symbol shown as emoji
+func clusterWidth(c string) int {
+	first := []rune(c)[0]
+	if zeroWidth(first) {
+		return 0
+	}
+	if wideRune(first) {
+		return 2
+	}
+	if strings.ContainsRune(c, emojiPresent) {
+		return 2
+	}
+	return 1
+}
+
+func zeroWidth(r rune) bool {
+	return unicode.In(r, unicode.Mn, unicode.Me, unicode.Cf) ||
+		unicode.IsControl(r) ||
+		(r >= '\ufe00' && r <= '\ufe0f') ||
+		(r >= 0xe0100 && r <= 0xe01ef)
+}
+
+func isSkinTone(r rune) bool {
+	return r >= 0x1f3fb && r <= 0x1f3ff
+}
+
+// wideRanges are the East Asian wide and fullwidth blocks and the emoji
+// blocks terminals draw two columns wide
+var wideRanges = [][2]rune{
+	{0x1100, 0x115f},   // Hangul Jamo initials
+	{0x231a, 0x231b},   // watch, hourglass
+	{0x23e9, 0x23ec},   // media buttons
+	{0x23f0, 0x23f0},   // alarm clock
+	{0x23f3, 0x23f3},   // hourglass with sand
+	{0x25fd, 0x25fe},   // small squares
+	{0x2614, 0x2615},   // umbrella, hot beverage
+	{0x2648, 0x2653},   // zodiac
+	{0x267f, 0x267f},   // wheelchair
+	{0x2693, 0x2693},   // anchor
+	{0x26a1, 0x26a1},   // high voltage
+	{0x26aa, 0x26ab},   // circles
+	{0x26bd, 0x26be},   // soccer, baseball
+	{0x26c4, 0x26c5},   // snowman, sun behind cloud
+	{0x26ce, 0x26ce},   // Ophiuchus
+	{0x26d4, 0x26d4},   // no entry
+	{0x26ea, 0x26ea},   // church
+	{0x26f2, 0x26f3},   // fountain, golf
+	{0x26f5, 0x26f5},   // sailboat
+	{0x26fa, 0x26fa},   // tent
+	{0x26fd, 0x26fd},   // fuel pump
+	{0x2705, 0x2705},   // check mark button
+	{0x270a, 0x270b},   // fists
+	{0x2728, 0x2728},   // sparkles
+	{0x274c, 0x274c},   // cross mark
+	{0x274e, 0x274e},   // cross mark button
+	{0x2753, 0x2755},   // question and exclamation marks
+	{0x2757, 0x2757},   // exclamation mark
+	{0x2795, 0x2797},   // plus, minus, divide
+	{0x27b0, 0x27b0},   // curly loop
+	{0x27bf, 0x27bf},   // double curly loop
+	{0x2b1b, 0x2b1c},   // large squares
+	{0x2b50, 0x2b50},   // star
+	{0x2b55, 0x2b55},   // circle
+	{0x2e80, 0x303e},   // CJK radicals, punctuation
+	{0x3041, 0x33ff},   // kana, CJK compatibility
+	{0x3400, 0x4dbf},   // CJK extension A
+	{0x4e00, 0x9fff},   // CJK unified ideographs
+	{0xa000, 0xa4cf},   // Yi
+	{0xa960, 0xa97f},   // Hangul Jamo extended A
+	{0xac00, 0xd7a3},   // Hangul syllables
+	{0xf900, 0xfaff},   // CJK compatibility ideographs
+	{0xfe10, 0xfe19},   // vertical forms
+	{0xfe30, 0xfe6f},   // CJK compatibility forms, small forms
+	{0xff00, 0xff60},   // fullwidth forms
+	{0xffe0, 0xffe6},   // fullwidth signs
+	{0x16fe0, 0x16fe4}, // ideographic symbols
+	{0x17000, 0x18cff}, // Tangut, Khitan
+	{0x1b000, 0x1b2ff}, // kana supplement, Nushu
+	{0x1f004, 0x1f004}, // mahjong
+	{0x1f0cf, 0x1f0cf}, // joker
+	{0x1f18e, 0x1f18e}, // AB button
+	{0x1f191, 0x1f19a}, // squared words
+	{0x1f200, 0x1f2ff}, // enclosed ideographs
+	{0x1f300, 0x1f64f}, // pictographs, emoticons
+	{0x1f680, 0x1f6ff}, // transport and map
+	{0x1f7e0, 0x1f7eb}, // colored circles and squares
+	{0x1f90c, 0x1f9ff}, // supplemental symbols and pictographs
+	{0x1fa70, 0x1faff}, // symbols and pictographs extended A
+	{0x20000, 0x3fffd}, // CJK extensions B and later
+}
+
+func wideRune(r rune) bool {
+	for _, span := range wideRanges {
+		if r < span[0] {
+			return false
+		}
+		if r <= span[1] {
+			return true
+		}
+	}
+	return false
+}
+
+// slugFolds spells accented Latin letters without their accents
+var slugFolds = strings.NewReplacer(
+	"à", "a", "á", "a", "â", "a", "ã", "a", "ä", "a", "å", "a", "ā", "a", "ą", "a",
+	"æ", "ae", "ç", "c", "ć", "c", "č", "c", "ď", "d", "đ", "d", "ð", "d",
+	"è", "e", "é", "e", "ê", "e", "ë", "e", "ē", "e", "ę", "e", "ě", "e",
+	"ğ", "g", "ì", "i", "í", "i", "î", "i", "ï", "i", "ī", "i", "ı", "i",
+	"ł", "l", "ñ", "n", "ń", "n", "ň", "n",
+	"ò", "o", "ó", "o", "ô", "o", "õ", "o", "ö", "o", "ø", "o", "ō", "o", "ő", "o", "œ", "oe",
+	"ř", "r", "ś", "s", "š", "s", "ş", "s", "ß", "ss", "ť", "t", "þ", "th",
+	"ù", "u", "ú", "u", "û", "u", "ü", "u", "ū", "u", "ů", "u", "ű", "u",
+	"ý", "y", "ÿ", "y", "ź", "z", "ż", "z", "ž", "z",
+)
+
+// Slug turns a name into something usable as a project shortcut: lowercase
+// ASCII letters and digits with hyphens between words, accents dropped, and
+// at most 20 characters. Letters without an ASCII spelling, like emoji or
+// CJK, are left out, so the result may be empty.
+func Slug(name string) string {
+	folded := slugFolds.Replace(strings.ToLower(name))
+	var b strings.Builder
+	hyphen := false
+	for _, r := range folded {
+		if (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') {
+			if hyphen && b.Len() > 0 {
+				b.WriteByte('-')
+			}
+			b.WriteRune(r)
+			hyphen = false
+			continue
+		}
+		hyphen = true
+	}
+	slug := b.String()
+	if len(slug) > 20 {
+		slug = strings.TrimRight(slug[:20], "-")
+	}
+	return slug
+}
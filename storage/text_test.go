@@ -0,0 +1,97 @@
+package storage
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCleanName(t *testing.T) {
+	tests := []struct {
+		name string
+		want string
+	}{
+		{"Pay rent", "Pay rent"},
+		{"Line one\nline two", "Line one line two"},
+		{"Tab\t\tseparated", "Tab separated"},
+		{"\x1b[31mRed\x1b[0m", "[31mRed [0m"},
+		{"invoice\u202efdp.exe", "invoicefdp.exe"}, // right-to-left override
+		{"  Trailing\r\n", "Trailing"},
+		{"Café ☕ 🎉", "Café ☕ 🎉"},
+		{"שלום world", "שלום world"},
+	}
+	for _, tt := range tests {
+		if got := CleanName(tt.name); got != tt.want {
+			t.Errorf("CleanName(%q) = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+
+	if got := DisplayName("שלום"); got != "\u2068שלום\u2069" {
+		t.Errorf("Expected a right-to-left name isolated, got %q", got)
+	}
+	if got := DisplayName("Groceries 🛒"); got != "Groceries 🛒" {
+		t.Errorf("Expected a left-to-right name unchanged, got %q", got)
+	}
+}
+
+func TestTextWidth(t *testing.T) {
+	tests := []struct {
+		text  string
+		width int
+	}{
+		{"abc", 3},
+		{"Café", 4},
+		{"Cafe\u0301", 4}, // combining accent
+		{"日本語", 6},
+		{"🎉 Party", 8},
+		{"❤\ufe0f", 2},     // narrow heart shown as emoji
+		{"👩\u200d💻", 2},    // joined with ZWJ
+		{"👍\U0001f3fd", 2}, // skin tone
+		{"\u2068שלום\u2069", 4},
+	}
+	for _, tt := range tests {
+		if got := TextWidth(tt.text); got != tt.width {
+			t.Errorf("TextWidth(%q) = %d, want %d", tt.text, got, tt.width)
+		}
+	}
+
+	if got := TruncateText("Short", 10); got != "Short" {
+		t.Errorf("Expected short text unchanged, got %q", got)
+	}
+	if got := TruncateText("Quarterly planning", 10); got != "Quarterly…" {
+		t.Errorf("Expected truncated text, got %q", got)
+	}
+	// A wide character that doesn't fit is left out whole
+	if got := TruncateText("日本語テキスト", 6); got != "日本…" || TextWidth(got) > 6 {
+		t.Errorf("Expected wide text cut at a character, got %q", got)
+	}
+	if got := TruncateText("👩\u200d💻👩\u200d💻👩\u200d💻", 5); got != "👩\u200d💻👩\u200d💻…" {
+		t.Errorf("Expected emoji sequences kept whole, got %q", got)
+	}
+
+	padded := PadText("🎉 x", 8)
+	if TextWidth(padded) != 8 || !strings.HasPrefix(padded, "🎉 x") {
+		t.Errorf("Expected padding to 8 columns, got %q", padded)
+	}
+}
+
+func TestSlug(t *testing.T) {
+	tests := []struct {
+		name string
+		slug string
+	}{
+		{"Home Renovation", "home-renovation"},
+		{"Café Crème", "cafe-creme"},
+		{"🎉 Party!", "party"},
+		{"Straße 12", "strasse-12"},
+		{"日本語", ""},
+		{"A very long project name indeed", "a-very-long-project"},
+	}
+	for _, tt := range tests {
+		if got := Slug(tt.name); got != tt.slug {
+			t.Errorf("Slug(%q) = %q, want %q", tt.name, got, tt.slug)
+		}
+		if got := Slug(tt.name); got != "" && validateShortcut(got) != nil {
+			t.Errorf("Slug(%q) = %q is not a valid shortcut", tt.name, got)
+		}
+	}
+}
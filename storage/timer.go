@@ -0,0 +1,118 @@
+package storage
+
+import (
+	"fmt"
+	"time"
+)
+
+// TimeEntry is a span of time worked on a task. A nil End means the timer is
+// still running; it is persisted that way so timers survive restarts.
+type TimeEntry struct {
+	ID     string     `json:"id"`
+	TaskID string     `json:"task_id"`
+	Start  time.Time  `json:"start"`
+	End    *time.Time `json:"end,omitempty"`
+}
+
+// Running returns true if the timer has not been stopped
+func (e *TimeEntry) Running() bool {
+	return e.End == nil
+}
+
+// Minutes returns the whole minutes logged, counting a running timer up to now
+func (e *TimeEntry) Minutes(now time.Time) int {
+	end := now
+	if e.End != nil {
+		end = *e.End
+	}
+	return int(end.Sub(e.Start).Minutes())
+}
+
+// StartTimer starts a timer on a task. Only one timer can run at a time.
+func (s *JSONStore) StartTimer(taskID string) (*TimeEntry, error) {
+	release, err := s.beginWrite()
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	if s.taskByID(taskID) == nil {
+		return nil, fmt.Errorf("task not found: %s", taskID)
+	}
+	if active := s.activeTimer(); active != nil {
+		name := active.TaskID
+		if t := s.taskByID(active.TaskID); t != nil {
+			name = t.Name
+		}
+		return nil, fmt.Errorf("a timer is already running for %s", name)
+	}
+
+	entry := &TimeEntry{
+		ID:     generateUUID(),
+		TaskID: taskID,
+		Start:  time.Now(),
+	}
+	s.data.TimeEntries = append(s.data.TimeEntries, entry)
+
+	copied := *entry
+	return &copied, s.save()
+}
+
+// StopTimer stops the running timer and returns the finished entry
+func (s *JSONStore) StopTimer() (*TimeEntry, error) {
+	release, err := s.beginWrite()
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	active := s.activeTimer()
+	if active == nil {
+		return nil, fmt.Errorf("no timer is running")
+	}
+	now := time.Now()
+	active.End = &now
+
+	copied := *active
+	return &copied, s.save()
+}
+
+// ActiveTimer returns the running timer, or nil if none is running
+func (s *JSONStore) ActiveTimer() (*TimeEntry, error) {
+	release, err := s.beginRead()
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	if active := s.activeTimer(); active != nil {
+		copied := *active
+		return &copied, nil
+	}
+	return nil, nil
+}
+
+// ListTimeEntries returns all time entries, oldest first
+func (s *JSONStore) ListTimeEntries() ([]*TimeEntry, error) {
+	release, err := s.beginRead()
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	entries := make([]*TimeEntry, len(s.data.TimeEntries))
+	for i, e := range s.data.TimeEntries {
+		copied := *e
+		entries[i] = &copied
+	}
+	return entries, nil
+}
+
+func (s *JSONStore) activeTimer() *TimeEntry {
+	for _, e := range s.data.TimeEntries {
+		if e.Running() {
+			return e
+		}
+	}
+	return nil
+}
@@ -0,0 +1,292 @@
+package storage
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// TrashItem is a deleted project, with the tasks it held, or a deleted task.
+// Items stay in the trash until they're purged, so /restore can bring them
+// back after /undo no longer reaches the delete.
+type TrashItem struct {
+	Kind      string    `json:"kind"` // "project" or "task"
+	DeletedAt time.Time `json:"deleted_at"`
+	Project   *Project  `json:"project,omitempty"`
+	Tasks     []*Task   `json:"tasks,omitempty"` // the deleted task, or the deleted project's tasks
+}
+
+// ID returns the ID of the deleted project or task
+func (item *TrashItem) ID() string {
+	if item.Project != nil {
+		return item.Project.ID
+	}
+	if len(item.Tasks) > 0 {
+		return item.Tasks[0].ID
+	}
+	return ""
+}
+
+// Name returns the name of the deleted project or task
+func (item *TrashItem) Name() string {
+	if item.Project != nil {
+		return item.Project.Name
+	}
+	if len(item.Tasks) > 0 {
+		return item.Tasks[0].Name
+	}
+	return ""
+}
+
+// newTrashItem copies a project or task and its tasks into a trash item
+func newTrashItem(project *Project, tasks []*Task, now time.Time) *TrashItem {
+	item := &TrashItem{Kind: "task", DeletedAt: now}
+	if project != nil {
+		item.Kind = "project"
+		item.Project = copyProject(project)
+	}
+	for _, t := range tasks {
+		item.Tasks = append(item.Tasks, copyTask(t))
+	}
+	return item
+}
+
+// restorableTrash returns the items /trash lists, newest first. Undoing a
+// delete or restoring an item puts the entity back without touching the
+// trash, so an item whose project or task exists again is hidden (and shown
+// again if that is undone); of several items for one entity only the
+// newest counts.
+func restorableTrash(items []*TrashItem, exists func(item *TrashItem) bool) []*TrashItem {
+	var restorable []*TrashItem
+	seen := make(map[string]bool)
+	for i := len(items) - 1; i >= 0; i-- {
+		item := items[i]
+		if seen[item.ID()] {
+			continue
+		}
+		seen[item.ID()] = true
+		if !exists(item) {
+			restorable = append(restorable, item)
+		}
+	}
+	sort.SliceStable(restorable, func(i, j int) bool { return restorable[i].DeletedAt.After(restorable[j].DeletedAt) })
+	return restorable
+}
+
+// findTrash finds the restorable item whose ID starts with ref
+func findTrash(items []*TrashItem, ref string) (*TrashItem, error) {
+	ref = strings.ToLower(strings.TrimSpace(ref))
+	if ref == "" {
+		return nil, fmt.Errorf("not in the trash: %q", ref)
+	}
+	var matches []*TrashItem
+	for _, item := range items {
+		if strings.HasPrefix(item.ID(), ref) {
+			matches = append(matches, item)
+		}
+	}
+	switch len(matches) {
+	case 0:
+		return nil, fmt.Errorf("not in the trash: %s", ref)
+	case 1:
+		return matches[0], nil
+	default:
+		return nil, fmt.Errorf("ambiguous ID %q matches %d items in the trash", ref, len(matches))
+	}
+}
+
+// keepTrash returns the items to keep when purging everything deleted
+// before cutoff, along with how many restorable items were purged. Items
+// that can no longer be restored go too, whatever their age.
+func keepTrash(items []*TrashItem, cutoff time.Time, exists func(item *TrashItem) bool) ([]*TrashItem, int) {
+	restorable := make(map[*TrashItem]bool)
+	for _, item := range restorableTrash(items, exists) {
+		restorable[item] = true
+	}
+	var kept []*TrashItem
+	purged := 0
+	for _, item := range items {
+		switch {
+		case !restorable[item]:
+		case item.DeletedAt.Before(cutoff) || cutoff.IsZero():
+			purged++
+		default:
+			kept = append(kept, item)
+		}
+	}
+	return kept, purged
+}
+
+// restoredEntities returns copies of an item's project and tasks as they
+// go back into the store: a project whose shortcut has been taken since
+// gets its default one, and a task whose project is gone goes to the inbox.
+func restoredEntities(item *TrashItem, shortcutTaken func(shortcut string) bool, hasProject func(id string) bool) (*Project, []*Task) {
+	var project *Project
+	if item.Project != nil {
+		project = copyProject(item.Project)
+		if shortcutTaken(project.Shortcut) {
+			project.Shortcut = shortID(project.ID)
+		}
+	}
+	tasks := make([]*Task, len(item.Tasks))
+	for i, t := range item.Tasks {
+		tasks[i] = copyTask(t)
+		if project == nil && !hasProject(t.ProjectID) {
+			tasks[i].moveTo("")
+		}
+	}
+	return project, tasks
+}
+
+// trashIDs returns the IDs of an item's project and tasks
+func trashIDs(item *TrashItem) ([]string, []string) {
+	var projectIDs, taskIDs []string
+	if item.Project != nil {
+		projectIDs = []string{item.Project.ID}
+	}
+	for _, t := range item.Tasks {
+		taskIDs = append(taskIDs, t.ID)
+	}
+	return projectIDs, taskIDs
+}
+
+// trashOp names a trash operation in the journal, e.g. delete task "x"
+func trashOp(verb string, item *TrashItem) string {
+	return fmt.Sprintf("%s %s %q", verb, item.Kind, item.Name())
+}
+
+// TrashProject moves a project and its tasks, archived ones included, to
+// the trash
+func (s *JSONStore) TrashProject(id string) error {
+	release, err := s.beginWrite()
+	if err != nil {
+		return err
+	}
+	defer release()
+
+	project := s.projectByID(id)
+	if project == nil {
+		return fmt.Errorf("project not found: %s", id)
+	}
+	var tasks []*Task
+	for _, t := range s.data.Tasks {
+		if t.ProjectID == id {
+			tasks = append(tasks, t)
+		}
+	}
+
+	item := newTrashItem(project, tasks, time.Now())
+	projectIDs, taskIDs := trashIDs(item)
+	return s.journaled(trashOp("delete", item), projectIDs, taskIDs, func() error {
+		s.removeProject(id)
+		for _, taskID := range taskIDs {
+			s.removeTask(taskID)
+		}
+		s.data.Trash = append(s.data.Trash, item)
+		return nil
+	})
+}
+
+// TrashTasks moves tasks to the trash, each as its own item
+func (s *JSONStore) TrashTasks(ids []string) error {
+	release, err := s.beginWrite()
+	if err != nil {
+		return err
+	}
+	defer release()
+
+	now := time.Now()
+	var items []*TrashItem
+	for _, id := range ids {
+		task := s.taskByID(id)
+		if task == nil {
+			return fmt.Errorf("task not found: %s", id)
+		}
+		items = append(items, newTrashItem(nil, []*Task{task}, now))
+	}
+
+	op := fmt.Sprintf("delete %d tasks", len(ids))
+	if len(items) == 1 {
+		op = trashOp("delete", items[0])
+	}
+	return s.journaled(op, nil, ids, func() error {
+		for _, id := range ids {
+			s.removeTask(id)
+		}
+		s.data.Trash = append(s.data.Trash, items...)
+		return nil
+	})
+}
+
+// ListTrash returns the items that can be restored, newest first
+func (s *JSONStore) ListTrash() ([]*TrashItem, error) {
+	release, err := s.beginRead()
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	return restorableTrash(s.data.Trash, s.inStore), nil
+}
+
+// RestoreTrash puts the item whose ID starts with idPrefix back
+func (s *JSONStore) RestoreTrash(idPrefix string) (*TrashItem, error) {
+	release, err := s.beginWrite()
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	item, err := findTrash(restorableTrash(s.data.Trash, s.inStore), idPrefix)
+	if err != nil {
+		return nil, err
+	}
+	project, tasks := restoredEntities(item, func(shortcut string) bool {
+		for _, p := range s.data.Projects {
+			if p.Shortcut == shortcut {
+				return true
+			}
+		}
+		return false
+	}, s.hasProject)
+
+	projectIDs, taskIDs := trashIDs(item)
+	err = s.journaled(trashOp("restore", item), projectIDs, taskIDs, func() error {
+		if project != nil {
+			s.upsertProject(copyProject(project))
+		}
+		for _, t := range tasks {
+			s.upsertTask(copyTask(t))
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &TrashItem{Kind: item.Kind, DeletedAt: item.DeletedAt, Project: project, Tasks: tasks}, nil
+}
+
+// PurgeTrash permanently removes the items deleted before the given time
+func (s *JSONStore) PurgeTrash(before time.Time) (int, error) {
+	release, err := s.beginWrite()
+	if err != nil {
+		return 0, err
+	}
+	defer release()
+
+	kept, purged := keepTrash(s.data.Trash, before, s.inStore)
+	if len(kept) == len(s.data.Trash) {
+		return 0, nil
+	}
+	s.data.Trash = kept
+	return purged, s.save()
+}
+
+// inStore reports whether a trash item's project or task exists again
+func (s *JSONStore) inStore(item *TrashItem) bool {
+	if item.Project != nil {
+		return s.projectByID(item.Project.ID) != nil
+	}
+	return s.taskByID(item.ID()) != nil
+}
@@ -0,0 +1,91 @@
+package storage
+
+import (
+	"testing"
+	"time"
+)
+
+func TestTrash(t *testing.T) {
+	for name, open := range testBackends {
+		t.Run(name, func(t *testing.T) {
+			store := open(t)
+
+			work, _ := store.CreateProject("Work")
+			report, _ := store.CreateTask(work.ID, "Write report")
+			review, _ := store.CreateTask(work.ID, "Review PR")
+			milk, _ := store.CreateTask("", "Buy milk")
+
+			// Deleting a task moves it to the trash in one journal entry
+			if err := store.TrashTasks([]string{milk.ID}); err != nil {
+				t.Fatalf("Failed to trash task: %v", err)
+			}
+			if _, err := store.GetTask(milk.ID); err == nil {
+				t.Error("Expected the trashed task gone from the store")
+			}
+			if items, _ := store.ListTrash(); len(items) != 1 || items[0].Kind != "task" || items[0].Name() != "Buy milk" {
+				t.Fatalf("Expected the task in the trash, got %v", items)
+			}
+
+			// Undoing the delete hides the item; redoing shows it again
+			if entry, err := store.Undo(); err != nil || entry.Op != `delete task "Buy milk"` {
+				t.Fatalf("Expected the delete undone, got %v, %v", entry, err)
+			}
+			if items, _ := store.ListTrash(); len(items) != 0 {
+				t.Errorf("Expected the trash empty after undo, got %v", items)
+			}
+			store.Redo()
+
+			// A project goes with its tasks, and comes back with them
+			if err := store.TrashProject(work.ID); err != nil {
+				t.Fatalf("Failed to trash project: %v", err)
+			}
+			items, _ := store.ListTrash()
+			if len(items) != 2 || items[0].Kind != "project" || len(items[0].Tasks) != 2 {
+				t.Fatalf("Expected the project newest with its 2 tasks, got %v", items)
+			}
+			if _, err := store.GetTask(report.ID); err == nil {
+				t.Error("Expected the project's tasks gone from the store")
+			}
+
+			// Another project has taken the shortcut in the meantime
+			other, _ := store.CreateProject("Other")
+			store.SetProjectShortcut(other.ID, work.Shortcut)
+			restored, err := store.RestoreTrash(work.ID[:8])
+			if err != nil {
+				t.Fatalf("Failed to restore project: %v", err)
+			}
+			if restored.Project.Shortcut != work.ID[:8] {
+				t.Errorf("Expected the default shortcut after a clash, got %q", restored.Project.Shortcut)
+			}
+			if got, err := store.GetTask(review.ID); err != nil || got.ProjectID != work.ID {
+				t.Errorf("Expected the task back in its project, got %v, %v", got, err)
+			}
+			if _, err := store.RestoreTrash(work.ID[:8]); err == nil {
+				t.Error("Expected a restored project to leave the trash")
+			}
+
+			// A task whose project is gone goes to the inbox
+			store.TrashTasks([]string{report.ID})
+			store.TrashProject(work.ID)
+			restored, err = store.RestoreTrash(report.ID)
+			if err != nil || restored.Tasks[0].ProjectID != "" {
+				t.Fatalf("Expected the task restored to the inbox, got %v, %v", restored, err)
+			}
+			if got, _ := store.GetTask(report.ID); got == nil || got.ProjectID != "" {
+				t.Errorf("Expected the task in the inbox, got %v", got)
+			}
+
+			// Purging drops items deleted before the cutoff, and nothing
+			// newer; a zero time empties the trash
+			if n, err := store.PurgeTrash(time.Now().Add(-time.Hour)); err != nil || n != 0 {
+				t.Errorf("Expected nothing purged, got %d, %v", n, err)
+			}
+			if n, err := store.PurgeTrash(time.Time{}); err != nil || n != 2 {
+				t.Errorf("Expected the task and the project purged, got %d, %v", n, err)
+			}
+			if items, _ := store.ListTrash(); len(items) != 0 {
+				t.Errorf("Expected the trash empty, got %v", items)
+			}
+		})
+	}
+}
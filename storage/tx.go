@@ -0,0 +1,87 @@
+package storage
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// errInTransaction is returned by the Store methods a Transact closure
+// can't call: Undo, Redo, Compact, and Close
+var errInTransaction = errors.New("not allowed inside a transaction")
+
+// foldJournal combines the entries a transaction recorded into one entry
+// named op, keeping for each project and task the state from before the
+// first entry that touched it. The caller captures After. It returns nil
+// if there are no entries.
+func foldJournal(op string, entries []*JournalEntry) *JournalEntry {
+	if len(entries) == 0 {
+		return nil
+	}
+	folded := &JournalEntry{Op: op, Time: time.Now(), Before: &JournalState{}}
+	seen := make(map[string]bool)
+	for _, e := range entries {
+		for _, id := range e.ProjectIDs {
+			if seen["p:"+id] {
+				continue
+			}
+			seen["p:"+id] = true
+			folded.ProjectIDs = append(folded.ProjectIDs, id)
+			if p := findProject(e.Before.Projects, func(p *Project) bool { return p.ID == id }); p != nil {
+				folded.Before.Projects = append(folded.Before.Projects, p)
+			}
+		}
+		for _, id := range e.TaskIDs {
+			if seen["t:"+id] {
+				continue
+			}
+			seen["t:"+id] = true
+			folded.TaskIDs = append(folded.TaskIDs, id)
+			if t := findTask(e.Before.Tasks, func(t *Task) bool { return t.ID == id }); t != nil {
+				folded.Before.Tasks = append(folded.Before.Tasks, t)
+			}
+		}
+	}
+	return folded
+}
+
+// Transact runs fn against a store whose changes are saved together, as
+// one journal entry named op, when fn returns nil, and thrown away, side
+// data included, when it returns an error. tx is only valid inside fn.
+// Calling Transact on tx runs fn as part of the outer transaction.
+func (s *JSONStore) Transact(op string, fn func(tx Store) error) error {
+	if s.inTx {
+		return fn(s)
+	}
+
+	release, err := s.beginWrite()
+	if err != nil {
+		return err
+	}
+	defer release()
+
+	saved, err := json.Marshal(s.data)
+	if err != nil {
+		return fmt.Errorf("failed to start transaction: %w", err)
+	}
+	mark := len(s.data.Journal)
+
+	if err := fn(&JSONStore{filename: s.filename, data: s.data, inTx: true}); err != nil {
+		rolledBack := &jsonData{}
+		if jerr := json.Unmarshal(saved, rolledBack); jerr != nil {
+			return fmt.Errorf("%w (and rolling back failed: %v)", err, jerr)
+		}
+		s.data = rolledBack
+		return err
+	}
+
+	if folded := foldJournal(op, s.data.Journal[mark:]); folded != nil {
+		folded.After = s.capture(folded.ProjectIDs, folded.TaskIDs)
+		s.data.Journal = append(s.data.Journal[:mark], folded)
+		if len(s.data.Journal) > maxJournalEntries {
+			s.data.Journal = s.data.Journal[len(s.data.Journal)-maxJournalEntries:]
+		}
+	}
+	return s.save()
+}
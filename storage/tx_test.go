@@ -0,0 +1,101 @@
+package storage
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestTransact(t *testing.T) {
+	for name, open := range testBackends {
+		t.Run(name, func(t *testing.T) {
+			store := open(t)
+			work, _ := store.CreateProject("Work")
+			report, _ := store.CreateTask(work.ID, "Write report")
+
+			// Changes commit together as one journal entry
+			var created *Task
+			err := store.Transact("plan report", func(tx Store) error {
+				if err := tx.SetTaskPriority(report.ID, PriorityHigh); err != nil {
+					return err
+				}
+				var err error
+				if created, err = tx.CreateTask(work.ID, "Review report"); err != nil {
+					return err
+				}
+				// A nested transaction joins this one
+				return tx.Transact("inner", func(tx Store) error {
+					return tx.SetTaskNote(created.ID, "after the draft")
+				})
+			})
+			if err != nil {
+				t.Fatalf("Failed to commit: %v", err)
+			}
+			if got, _ := store.GetTask(created.ID); got == nil || got.Note != "after the draft" {
+				t.Errorf("Expected the new task with its note, got %v", got)
+			}
+			entry, err := store.Undo()
+			if err != nil || entry.Op != "plan report" {
+				t.Fatalf("Expected one entry for the transaction, got %v, %v", entry, err)
+			}
+			if got, _ := store.GetTask(report.ID); got.Priority != "" {
+				t.Errorf("Expected the priority undone, got %q", got.Priority)
+			}
+			if _, err := store.GetTask(created.ID); err == nil {
+				t.Error("Expected the created task undone")
+			}
+			if entry, err := store.Undo(); err != nil || entry.Op != `create task "Write report"` {
+				t.Errorf("Expected the earlier history intact, got %v, %v", entry, err)
+			}
+			store.Redo()
+			store.Redo()
+
+			// An error rolls everything back, side data included
+			failed := errors.New("stop")
+			err = store.Transact("delete work", func(tx Store) error {
+				if err := tx.TrashProject(work.ID); err != nil {
+					return err
+				}
+				if _, err := tx.Undo(); err == nil {
+					t.Error("Expected undo refused inside a transaction")
+				}
+				return failed
+			})
+			if !errors.Is(err, failed) {
+				t.Fatalf("Expected the closure's error, got %v", err)
+			}
+			if _, err := store.GetProject(work.ID); err != nil {
+				t.Errorf("Expected the project kept, got %v", err)
+			}
+			if items, _ := store.ListTrash(); len(items) != 0 {
+				t.Errorf("Expected nothing in the trash, got %v", items)
+			}
+			if entry, _ := store.Undo(); entry == nil || entry.Op != "plan report" {
+				t.Errorf("Expected no journal entry for the rolled back transaction, got %v", entry)
+			}
+		})
+	}
+}
+
+func TestJSONStoreSaveLeavesNoTempFile(t *testing.T) {
+	dir := t.TempDir()
+	store, err := NewJSONStore(filepath.Join(dir, "test.json"))
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+	defer store.Close()
+
+	store.CreateProject("Work")
+	if _, err := os.Stat(filepath.Join(dir, "test.json.tmp")); !os.IsNotExist(err) {
+		t.Errorf("Expected the temporary file renamed into place, got %v", err)
+	}
+	reopened, err := NewJSONStore(filepath.Join(dir, "test.json"))
+	if err != nil {
+		t.Fatalf("Failed to reopen: %v", err)
+	}
+	defer reopened.Close()
+	if projects, _ := reopened.ListProjects(); len(projects) != 1 {
+		t.Errorf("Expected the saved project, got %v", projects)
+	}
+}
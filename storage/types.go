@@ -1,50 +1,160 @@
 package storage
 
 import (
+	"encoding/json"
 	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
 	"time"
 )
 
-// Duration represents a task duration
-type Duration string
+// Duration is how long a task is expected to take, in minutes; zero means
+// no estimate. It is written as text like "45m", "2h", or "1h30m".
+type Duration int
 
+// Common durations, offered by tab completion
 const (
-	Duration15m Duration = "15m"
-	Duration30m Duration = "30m"
-	Duration1h  Duration = "1h"
-	Duration2h  Duration = "2h"
-	Duration4h  Duration = "4h"
+	Duration15m Duration = 15
+	Duration30m Duration = 30
+	Duration1h  Duration = 60
+	Duration2h  Duration = 120
+	Duration4h  Duration = 240
 )
 
-// ValidDurations lists all valid duration values
+// ValidDurations lists the common durations, shortest first. Any whole
+// number of minutes up to MaxDuration is valid.
 var ValidDurations = []Duration{Duration15m, Duration30m, Duration1h, Duration2h, Duration4h}
 
+// MaxDuration is the longest estimate a task can have
+const MaxDuration Duration = 100 * 60
+
+// durationRegex matches Nm, Nh, and NhMm, allowing a space before the minutes
+var durationRegex = regexp.MustCompile(`^(?:(\d+)h)?\s*(?:(\d+)m)?$`)
+
+// ParseDuration reads a duration written as Nm, Nh, or NhMm, e.g. "45m",
+// "3h", or "1h30m" ("1h 30m" as FormatMinutes writes it works too)
+func ParseDuration(s string) (Duration, error) {
+	s = strings.ToLower(strings.TrimSpace(s))
+	m := durationRegex.FindStringSubmatch(s)
+	if s == "" || m == nil {
+		return 0, fmt.Errorf("invalid duration: %s (use minutes or hours, like 45m, 3h, or 1h30m)", s)
+	}
+	hours, _ := strconv.Atoi(m[1])
+	minutes, _ := strconv.Atoi(m[2])
+	if m[1] != "" && minutes >= 60 {
+		return 0, fmt.Errorf("invalid duration: %s (minutes after hours must be under 60)", s)
+	}
+	d := Duration(hours*60 + minutes)
+	if d <= 0 || d > MaxDuration {
+		return 0, fmt.Errorf("invalid duration: %s (must be between 1m and %s)", s, MaxDuration)
+	}
+	return d, nil
+}
+
 // IsValidDuration checks if a string is a valid duration
 func IsValidDuration(s string) bool {
-	for _, d := range ValidDurations {
-		if string(d) == s {
+	_, err := ParseDuration(s)
+	return err == nil
+}
+
+// ToMinutes converts a Duration to minutes
+func (d Duration) ToMinutes() int {
+	return int(d)
+}
+
+// String writes a duration the way ParseDuration reads it, e.g. "1h30m";
+// no estimate is ""
+func (d Duration) String() string {
+	switch {
+	case d <= 0:
+		return ""
+	case d%60 == 0:
+		return fmt.Sprintf("%dh", d/60)
+	case d < 60:
+		return fmt.Sprintf("%dm", d)
+	}
+	return fmt.Sprintf("%dh%dm", d/60, d%60)
+}
+
+// MarshalJSON saves a duration as text, as it was before durations were
+// minutes, so older versions and synced machines still read the common ones
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(d.String())
+}
+
+// UnmarshalJSON reads a duration saved as text or as a number of minutes.
+// An unreadable one is dropped rather than failing the whole file, as the
+// fixed set of durations used to treat unknown values as none.
+func (d *Duration) UnmarshalJSON(data []byte) error {
+	var minutes int
+	if err := json.Unmarshal(data, &minutes); err == nil {
+		*d = Duration(minutes)
+		return nil
+	}
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return err
+	}
+	*d, _ = ParseDuration(s)
+	return nil
+}
+
+// Priority represents how urgently a task needs attention
+type Priority string
+
+const (
+	PriorityLow    Priority = "low"
+	PriorityMedium Priority = "medium"
+	PriorityHigh   Priority = "high"
+	PriorityUrgent Priority = "urgent"
+)
+
+// ValidPriorities lists all valid priority values, lowest first
+var ValidPriorities = []Priority{PriorityLow, PriorityMedium, PriorityHigh, PriorityUrgent}
+
+// IsValidPriority checks if a string is a valid priority
+func IsValidPriority(s string) bool {
+	for _, p := range ValidPriorities {
+		if string(p) == s {
 			return true
 		}
 	}
 	return false
 }
 
-// ToMinutes converts a Duration to minutes
-func (d Duration) ToMinutes() int {
-	switch d {
-	case Duration15m:
-		return 15
-	case Duration30m:
-		return 30
-	case Duration1h:
-		return 60
-	case Duration2h:
-		return 120
-	case Duration4h:
-		return 240
-	default:
-		return 0
+// Rank orders priorities for comparison; an unset priority ranks lowest (0)
+func (p Priority) Rank() int {
+	for i, valid := range ValidPriorities {
+		if p == valid {
+			return i + 1
+		}
 	}
+	return 0
+}
+
+// Status is where a task stands on a kanban board. Tasks saved before there
+// were statuses have none; Task.CurrentStatus reads theirs from Done.
+type Status string
+
+const (
+	StatusTodo       Status = "todo"
+	StatusInProgress Status = "in-progress"
+	StatusBlocked    Status = "blocked"
+	StatusDone       Status = "done"
+)
+
+// ValidStatuses lists all valid status values, in board order
+var ValidStatuses = []Status{StatusTodo, StatusInProgress, StatusBlocked, StatusDone}
+
+// IsValidStatus checks if a string is a valid status
+func IsValidStatus(s string) bool {
+	for _, st := range ValidStatuses {
+		if string(st) == s {
+			return true
+		}
+	}
+	return false
 }
 
 // FormatMinutes formats a number of minutes as a human-readable string (e.g., "2h 30m")
@@ -74,19 +184,132 @@ func TotalDuration(tasks []*Task) int {
 
 // Project represents a parent container for tasks
 type Project struct {
-	ID        string    `json:"id"`
-	Name      string    `json:"name"`
-	Shortcut  string    `json:"shortcut,omitempty"`
-	CreatedAt time.Time `json:"created_at"`
-}
-
-// Task represents a child item within a project
-type Task struct {
 	ID        string     `json:"id"`
-	ProjectID string     `json:"project_id"`
 	Name      string     `json:"name"`
-	Done      bool       `json:"done"`
+	Shortcut  string     `json:"shortcut,omitempty"`
 	CreatedAt time.Time  `json:"created_at"`
 	DueDate   *time.Time `json:"due_date,omitempty"`
-	Duration  Duration   `json:"duration,omitempty"`
+
+	// Defaults for tasks created in the project (see ApplyDefaults)
+	DefaultDuration Duration `json:"default_duration,omitempty"`
+	DefaultDue      string   `json:"default_due,omitempty"` // a shift from the day it's created, e.g. "+7d"
+}
+
+// ApplyDefaults gives a new task the project's default duration and due
+// date, where the task doesn't set its own. The due date is DefaultDue
+// counted from now.
+func (p *Project) ApplyDefaults(t *Task, now time.Time) error {
+	if t.Duration == 0 {
+		t.Duration = p.DefaultDuration
+	}
+	if t.DueDate == nil && p.DefaultDue != "" {
+		due, err := DeferDue(nil, p.DefaultDue, now)
+		if err != nil {
+			return fmt.Errorf("project %s default due %q: %w", p.Name, p.DefaultDue, err)
+		}
+		t.DueDate = &due
+	}
+	return nil
+}
+
+// InboxName is what lists and exports call the inbox: the tasks with no
+// project, captured before deciding where they belong
+const InboxName = "Inbox"
+
+// Task represents a child item within a project
+type Task struct {
+	ID          string     `json:"id"`
+	ProjectID   string     `json:"project_id"` // empty for a task in the inbox
+	Name        string     `json:"name"`
+	Done        bool       `json:"done"`
+	Status      Status     `json:"status,omitempty"` // empty for tasks saved before statuses; see CurrentStatus
+	CreatedAt   time.Time  `json:"created_at"`
+	DueDate     *time.Time `json:"due_date,omitempty"`
+	Duration    Duration   `json:"duration,omitempty"`
+	Tags        []string   `json:"tags,omitempty"`
+	BlockedBy   []string   `json:"blocked_by,omitempty"` // IDs of tasks that must be done first
+	Priority    Priority   `json:"priority,omitempty"`
+	Postponed   int        `json:"postponed,omitempty"`    // times the due date was pushed later
+	Context     string     `json:"context,omitempty"`      // where the task can be done, e.g. "office"
+	ArchivedAt  *time.Time `json:"archived_at,omitempty"`  // set when a done task is moved out of the main list
+	Note        string     `json:"note,omitempty"`         // free-form annotation, may span lines
+	CompletedAt *time.Time `json:"completed_at,omitempty"` // set when the task is marked done, cleared when reopened
+	UpdatedAt   *time.Time `json:"updated_at,omitempty"`   // last edit; nil until the task is first changed
+	Order       int        `json:"order,omitempty"`        // place in its project's list from 1, or 0 for after the ordered tasks
+}
+
+// IsArchived returns true if the task has been archived
+func (t *Task) IsArchived() bool {
+	return t.ArchivedAt != nil
+}
+
+// CurrentStatus returns the task's status. Done decides whether it's done,
+// so tasks saved before statuses existed, or marked done by older clients,
+// read as todo or done.
+func (t *Task) CurrentStatus() Status {
+	if t.Done {
+		return StatusDone
+	}
+	if t.Status == "" || t.Status == StatusDone {
+		return StatusTodo
+	}
+	return t.Status
+}
+
+// setStatus moves a task to a status, keeping Done and CompletedAt in step;
+// leaving done brings an archived task back to the main list
+func (t *Task) setStatus(status Status, now time.Time) {
+	done := status == StatusDone
+	if done && !t.Done {
+		t.CompletedAt = &now
+	}
+	t.Done = done
+	if !done {
+		t.CompletedAt = nil
+		t.ArchivedAt = nil
+	}
+	t.Status = status
+}
+
+// markDone marks a task done or not done. Reopening a done task makes it
+// todo; an open one keeps its status.
+func (t *Task) markDone(done bool, now time.Time) {
+	status := StatusDone
+	if !done {
+		status = t.CurrentStatus()
+		if status == StatusDone {
+			status = StatusTodo
+		}
+	}
+	t.setStatus(status, now)
+}
+
+// LastActivity returns when the task was last edited, or created if never
+func (t *Task) LastActivity() time.Time {
+	if t.UpdatedAt != nil {
+		return *t.UpdatedAt
+	}
+	return t.CreatedAt
+}
+
+// touch records an edit made by the user. Automatic changes (escalation,
+// imports, archiving) leave UpdatedAt alone.
+func (t *Task) touch(now time.Time) {
+	t.UpdatedAt = &now
+}
+
+// NormalizeTag lowercases a tag and strips a leading '#'
+func NormalizeTag(tag string) string {
+	return strings.ToLower(strings.TrimPrefix(strings.TrimSpace(tag), "#"))
+}
+
+// HasTag returns true if the task carries the given tag
+func (t *Task) HasTag(tag string) bool {
+	tag = NormalizeTag(tag)
+	for _, existing := range t.Tags {
+		if existing == tag {
+			return true
+		}
+	}
+	return false
 }
@@ -0,0 +1,88 @@
+package storage
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func TestParseDuration(t *testing.T) {
+	tests := []struct {
+		in   string
+		want Duration
+		text string // String of the result
+	}{
+		{"15m", Duration15m, "15m"},
+		{"4h", Duration4h, "4h"},
+		{"45m", 45, "45m"},
+		{"3h", 180, "3h"},
+		{"1h30m", 90, "1h30m"},
+		{"1H 05m", 65, "1h5m"},
+		{"90m", 90, "1h30m"},
+		{"100h", MaxDuration, "100h"},
+	}
+	for _, tt := range tests {
+		got, err := ParseDuration(tt.in)
+		if err != nil || got != tt.want {
+			t.Errorf("ParseDuration(%q) = %d, %v; want %d", tt.in, got, err, tt.want)
+			continue
+		}
+		if got.String() != tt.text {
+			t.Errorf("Duration(%d).String() = %q, want %q", got, got.String(), tt.text)
+		}
+	}
+	for _, bad := range []string{"", "0m", "1d", "h", "1h60m", "101h", "1.5h", "30m1h", "soon"} {
+		if _, err := ParseDuration(bad); err == nil {
+			t.Errorf("Expected an error for %q", bad)
+		}
+	}
+}
+
+func TestDurationJSON(t *testing.T) {
+	data, err := json.Marshal(&Task{ID: "t1", Duration: 90})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var task Task
+	if err := json.Unmarshal(data, &task); err != nil || task.Duration != 90 {
+		t.Errorf("Round trip of %s gave %d, %v", data, task.Duration, err)
+	}
+
+	// Files from before durations were minutes hold text; unknown text is no estimate
+	for in, want := range map[string]Duration{`"2h"`: Duration2h, `"45m"`: 45, `75`: 75, `"forever"`: 0} {
+		var task Task
+		if err := json.Unmarshal([]byte(`{"id":"t1","duration":`+in+`}`), &task); err != nil || task.Duration != want {
+			t.Errorf("Reading duration %s gave %d, %v; want %d", in, task.Duration, err, want)
+		}
+	}
+	if data, _ := json.Marshal(&Task{ID: "t1"}); string(data) != `{"id":"t1","project_id":"","name":"","done":false,"created_at":"0001-01-01T00:00:00Z"}` {
+		t.Errorf("Expected no duration saved, got %s", data)
+	}
+}
+
+func TestApplyDefaults(t *testing.T) {
+	now := time.Date(2026, 3, 2, 9, 0, 0, 0, time.Local) // a Monday
+	p := &Project{Name: "Work", DefaultDuration: 30, DefaultDue: "+7d"}
+
+	task := &Task{Name: "Plain"}
+	if err := p.ApplyDefaults(task, now); err != nil {
+		t.Fatal(err)
+	}
+	if task.Duration != 30 || task.DueDate == nil || FormatDue(*task.DueDate) != "2026-03-09" {
+		t.Errorf("Expected 30m due 2026-03-09, got %s due %v", task.Duration, task.DueDate)
+	}
+
+	// A task's own values win
+	own := time.Date(2026, 3, 4, 0, 0, 0, 0, time.Local)
+	task = &Task{Name: "Own", Duration: Duration2h, DueDate: &own}
+	if err := p.ApplyDefaults(task, now); err != nil {
+		t.Fatal(err)
+	}
+	if task.Duration != Duration2h || task.DueDate != &own {
+		t.Errorf("Expected the task's own values kept, got %s due %v", task.Duration, task.DueDate)
+	}
+
+	if err := (&Project{DefaultDue: "someday"}).ApplyDefaults(&Task{}, now); err == nil {
+		t.Error("Expected an error for a bad default due")
+	}
+}
@@ -0,0 +1,115 @@
+package storage
+
+import (
+	"fmt"
+	"sort"
+	"time"
+)
+
+// WeekPlan holds due dates assigned during weekly planning until they are
+// saved with SetTaskDueDates. Each day's load starts with the open work
+// already due that day, and assignments are refused once a day is full.
+type WeekPlan struct {
+	Days     []time.Time          // the seven days being planned, starting today
+	Capacity int                  // minutes of work that fit in one day
+	Tasks    []*Task              // overdue and undated open tasks, most urgent first
+	Assigned map[string]time.Time // task ID to its new due date
+
+	load map[time.Time]int
+}
+
+// NewWeekPlan sets up planning for the seven days starting at now. A
+// capacity of 0 uses the same daily limit as due-date suggestions.
+func NewWeekPlan(tasks []*Task, capacity int, now time.Time) *WeekPlan {
+	if capacity <= 0 {
+		capacity = DailyLoadLimit
+	}
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+
+	w := &WeekPlan{Capacity: capacity, Assigned: make(map[string]time.Time)}
+	for i := 0; i < 7; i++ {
+		w.Days = append(w.Days, today.AddDate(0, 0, i))
+	}
+
+	var scheduled []*Task
+	for _, t := range tasks {
+		if t.Done {
+			continue
+		}
+		if t.DueDate == nil || dueBucket(t, today) == 0 {
+			w.Tasks = append(w.Tasks, t)
+		} else {
+			scheduled = append(scheduled, t)
+		}
+	}
+	sort.SliceStable(w.Tasks, func(i, j int) bool {
+		return planLess(w.Tasks[i], w.Tasks[j], today)
+	})
+	w.load = dueLoad(scheduled)
+
+	return w
+}
+
+// Load returns the minutes due on a day, including assignments
+func (w *WeekPlan) Load(day time.Time) int {
+	return w.load[day]
+}
+
+// Assign gives a task a due date on one of the planned days, if it fits
+func (w *WeekPlan) Assign(t *Task, day time.Time) error {
+	day = time.Date(day.Year(), day.Month(), day.Day(), 0, 0, 0, 0, time.UTC)
+	if day.Before(w.Days[0]) || day.After(w.Days[len(w.Days)-1]) {
+		return fmt.Errorf("%s is outside this week (%s to %s)", day.Format("Mon 2006-01-02"),
+			w.Days[0].Format("Mon 01-02"), w.Days[len(w.Days)-1].Format("Mon 01-02"))
+	}
+
+	// Reassigning a task to the same day doesn't count it twice
+	minutes := TaskLoad(t)
+	load := w.load[day]
+	if previous, ok := w.Assigned[t.ID]; ok && previous.Equal(day) {
+		load -= minutes
+	}
+	if load+minutes > w.Capacity {
+		return fmt.Errorf("%s is full: %s of %s left, task needs %s", day.Format("Mon"),
+			FormatMinutes(max(w.Capacity-load, 0)), FormatMinutes(w.Capacity), FormatMinutes(minutes))
+	}
+
+	if previous, ok := w.Assigned[t.ID]; ok {
+		w.load[previous] -= minutes
+	}
+	w.Assigned[t.ID] = day
+	w.load[day] += minutes
+	return nil
+}
+
+// TaskLoad is the minutes a task counts for in a day's load; a task with
+// no duration counts as 30m
+func TaskLoad(t *Task) int {
+	if minutes := t.Duration.ToMinutes(); minutes > 0 {
+		return minutes
+	}
+	return unestimatedMinutes
+}
+
+// setDueDate sets a task's due date, counting it as postponed if it moves
+// later; a zero time clears it
+func setDueDate(t *Task, due time.Time) {
+	if due.IsZero() {
+		t.DueDate = nil
+		return
+	}
+	if t.DueDate != nil && due.After(*t.DueDate) {
+		t.Postponed++
+	}
+	t.DueDate = &due
+}
+
+// sortedKeys returns a map's task IDs in a stable order for the journal
+func sortedKeys[V any](m map[string]V) []string {
+	ids := make([]string, 0, len(m))
+	for id := range m {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
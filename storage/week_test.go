@@ -0,0 +1,55 @@
+package storage
+
+import (
+	"testing"
+	"time"
+)
+
+func TestWeekPlan(t *testing.T) {
+	// A Wednesday
+	now := time.Date(2025, 3, 12, 9, 0, 0, 0, time.UTC)
+	day := func(offset int) time.Time {
+		return time.Date(2025, 3, 12+offset, 0, 0, 0, 0, time.UTC)
+	}
+	at := func(offset int) *time.Time {
+		d := day(offset)
+		return &d
+	}
+
+	tasks := []*Task{
+		{ID: "undated", Name: "Undated", Duration: Duration2h},
+		{ID: "overdue", Name: "Overdue", Duration: Duration1h, DueDate: at(-2)},
+		{ID: "friday", Name: "Due Friday", Duration: Duration2h, DueDate: at(2)},
+		{ID: "done", Name: "Done", Done: true},
+	}
+	week := NewWeekPlan(tasks, 0, now)
+
+	if week.Capacity != DailyLoadLimit || len(week.Days) != 7 || !week.Days[0].Equal(day(0)) {
+		t.Fatalf("Expected 7 days from today at the default capacity, got %v, %d", week.Days, week.Capacity)
+	}
+	if len(week.Tasks) != 2 || week.Tasks[0].ID != "overdue" || week.Tasks[1].ID != "undated" {
+		t.Errorf("Expected overdue then undated, got %v", week.Tasks)
+	}
+	if week.Load(day(2)) != 120 {
+		t.Errorf("Expected Friday to start with 2h, got %d", week.Load(day(2)))
+	}
+
+	// Friday has 2h left: the 2h task fits, then the day is full
+	if err := week.Assign(tasks[0], day(2)); err != nil {
+		t.Errorf("Expected 2h task to fit: %v", err)
+	}
+	if err := week.Assign(tasks[1], day(2)); err == nil {
+		t.Error("Expected full day to be refused")
+	}
+	if err := week.Assign(tasks[1], day(7)); err == nil {
+		t.Error("Expected day outside the week to be refused")
+	}
+
+	// Moving a task frees its old day
+	if err := week.Assign(tasks[0], day(3)); err != nil || week.Load(day(2)) != 120 || week.Load(day(3)) != 120 {
+		t.Errorf("Expected task moved to Saturday, got %v, loads %d/%d", err, week.Load(day(2)), week.Load(day(3)))
+	}
+	if !week.Assigned["undated"].Equal(day(3)) {
+		t.Errorf("Expected assignment recorded, got %v", week.Assigned)
+	}
+}
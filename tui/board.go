@@ -0,0 +1,362 @@
+// Package tui is the full-screen dashboard opened with /board or `twooms
+// tui`. It reads projects and tasks straight from the store, and makes every
+// change by running ordinary commands, so the board and the REPL behave the
+// same way: journaling, rules, and automatic backups all still apply.
+package tui
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+
+	"twooms/storage"
+)
+
+// RunFunc runs a command line, e.g. "/done 1a2b3c4d", and returns its output.
+// quit is true when the command asks to exit, which closes the board.
+type RunFunc func(line string) (quit bool, output string, err error)
+
+// Run shows the board until the user quits it
+func Run(store storage.Store, run RunFunc) error {
+	_, err := tea.NewProgram(newBoard(store, run), tea.WithAltScreen()).Run()
+	return err
+}
+
+// pane is one of the board's three columns
+type pane int
+
+const (
+	projectsPane pane = iota
+	tasksPane
+	todayPane
+	paneCount
+)
+
+// outputLines is how much of the last command's output the board shows
+const outputLines = 6
+
+// board is the bubbletea model behind the dashboard
+type board struct {
+	store storage.Store
+	run   RunFunc
+	now   func() time.Time
+
+	width, height int
+	focus         pane
+	cursor        [paneCount]int
+
+	projects []*storage.Project // the inbox last, with an empty ID
+	tasks    []*storage.Task    // the selected project's tasks
+	today    []*storage.Task    // open tasks due today or overdue, in any project
+	names    map[string]string  // project names by ID, for the today pane
+
+	palette bool   // the command palette is open
+	input   []rune // what has been typed into it
+	output  string // the last command's output, or an error
+}
+
+func newBoard(store storage.Store, run RunFunc) *board {
+	b := &board{store: store, run: run, now: time.Now, width: 80, height: 24}
+	b.reload()
+	return b
+}
+
+func (b *board) Init() tea.Cmd {
+	return nil
+}
+
+// reload reads projects and tasks again, keeping the cursors in range
+func (b *board) reload() {
+	projects, err := b.store.ListProjects()
+	if err != nil {
+		b.output = fmt.Sprintf("Error: %v", err)
+	}
+	b.projects = append(projects, &storage.Project{Name: storage.InboxName})
+	b.names = map[string]string{"": storage.InboxName}
+	for _, p := range projects {
+		b.names[p.ID] = p.Name
+	}
+	b.cursor[projectsPane] = clamp(b.cursor[projectsPane], len(b.projects))
+
+	b.tasks, err = b.store.ListTasks(b.projects[b.cursor[projectsPane]].ID)
+	if err != nil {
+		b.output = fmt.Sprintf("Error: %v", err)
+	}
+	b.cursor[tasksPane] = clamp(b.cursor[tasksPane], len(b.tasks))
+
+	b.today = nil
+	all, err := b.store.ListAllTasks()
+	if err != nil {
+		b.output = fmt.Sprintf("Error: %v", err)
+	}
+	end := b.now().Format("2006-01-02")
+	for _, t := range all {
+		if !t.Done && t.DueDate != nil && t.DueDate.Format("2006-01-02") <= end {
+			b.today = append(b.today, t)
+		}
+	}
+	sort.SliceStable(b.today, func(i, j int) bool {
+		return storage.DueAt(*b.today[i].DueDate, time.Local).Before(storage.DueAt(*b.today[j].DueDate, time.Local))
+	})
+	b.cursor[todayPane] = clamp(b.cursor[todayPane], len(b.today))
+}
+
+// clamp keeps a cursor within a list of n items
+func clamp(cursor, n int) int {
+	if cursor >= n {
+		cursor = n - 1
+	}
+	if cursor < 0 {
+		cursor = 0
+	}
+	return cursor
+}
+
+// selectedTask is the task under the cursor in the tasks or today pane
+func (b *board) selectedTask() *storage.Task {
+	switch b.focus {
+	case tasksPane:
+		if len(b.tasks) > 0 {
+			return b.tasks[b.cursor[tasksPane]]
+		}
+	case todayPane:
+		if len(b.today) > 0 {
+			return b.today[b.cursor[todayPane]]
+		}
+	}
+	return nil
+}
+
+func (b *board) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		b.width, b.height = msg.Width, msg.Height
+	case tea.KeyMsg:
+		if b.palette {
+			return b, b.paletteKey(msg)
+		}
+		return b, b.key(msg)
+	}
+	return b, nil
+}
+
+// key handles a key press on the board itself
+func (b *board) key(msg tea.KeyMsg) tea.Cmd {
+	switch msg.String() {
+	case "q", "ctrl+c", "ctrl+d":
+		return tea.Quit
+	case "tab", "right", "l":
+		b.focus = (b.focus + 1) % paneCount
+	case "shift+tab", "left", "h":
+		b.focus = (b.focus + paneCount - 1) % paneCount
+	case "up", "k":
+		b.move(-1)
+	case "down", "j":
+		b.move(1)
+	case "enter":
+		if b.focus == projectsPane {
+			b.focus = tasksPane
+		}
+	case " ", "space", "x":
+		if t := b.selectedTask(); t != nil {
+			command := "/done "
+			if t.Done {
+				command = "/undone "
+			}
+			return b.runCommand(command + t.ID)
+		}
+	case ":":
+		b.palette, b.input = true, nil
+	case "/":
+		b.palette, b.input = true, []rune("/")
+	case "r":
+		b.reload()
+	}
+	return nil
+}
+
+// move steps the cursor in the focused pane; a new project shows its tasks
+func (b *board) move(step int) {
+	lengths := [paneCount]int{len(b.projects), len(b.tasks), len(b.today)}
+	b.cursor[b.focus] = clamp(b.cursor[b.focus]+step, lengths[b.focus])
+	if b.focus == projectsPane {
+		b.cursor[tasksPane] = 0
+		b.reload()
+	}
+}
+
+// paletteKey handles a key press while the command palette is open
+func (b *board) paletteKey(msg tea.KeyMsg) tea.Cmd {
+	switch msg.Type {
+	case tea.KeyEsc, tea.KeyCtrlC:
+		b.palette = false
+	case tea.KeyEnter:
+		b.palette = false
+		line := strings.TrimSpace(string(b.input))
+		if line == "" {
+			return nil
+		}
+		if !strings.HasPrefix(line, "/") {
+			line = "/" + line
+		}
+		return b.runCommand(line)
+	case tea.KeyBackspace:
+		if len(b.input) > 0 {
+			b.input = b.input[:len(b.input)-1]
+		}
+	case tea.KeySpace:
+		b.input = append(b.input, ' ')
+	case tea.KeyRunes:
+		b.input = append(b.input, msg.Runes...)
+	}
+	return nil
+}
+
+// runCommand runs a command line, shows its output, and reloads the panes
+func (b *board) runCommand(line string) tea.Cmd {
+	quit, output, err := b.run(line)
+	switch {
+	case err != nil:
+		b.output = fmt.Sprintf("Error: %v", err)
+	default:
+		b.output = strings.TrimRight(output, "\n")
+	}
+	b.reload()
+	if quit {
+		return tea.Quit
+	}
+	return nil
+}
+
+var (
+	paneStyle    = lipgloss.NewStyle().Border(lipgloss.RoundedBorder()).Padding(0, 1)
+	focusedStyle = paneStyle.BorderForeground(lipgloss.Color("12"))
+	titleStyle   = lipgloss.NewStyle().Bold(true)
+	cursorStyle  = lipgloss.NewStyle().Reverse(true)
+	doneStyle    = lipgloss.NewStyle().Faint(true)
+	overdueStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("9"))
+	helpStyle    = lipgloss.NewStyle().Faint(true)
+)
+
+func (b *board) View() string {
+	// Borders and padding take four columns and two rows per pane
+	footer := b.footer()
+	rows := b.height - lipgloss.Height(footer) - 2
+	if rows < 1 {
+		rows = 1
+	}
+	widths := [paneCount]int{b.width / 4, b.width * 2 / 5, 0}
+	widths[todayPane] = b.width - widths[projectsPane] - widths[tasksPane]
+
+	projectLines := make([]string, len(b.projects))
+	for i, p := range b.projects {
+		projectLines[i] = p.Name
+		if p.Shortcut != "" {
+			projectLines[i] += " [" + p.Shortcut + "]"
+		}
+	}
+	taskLines := make([]string, len(b.tasks))
+	for i, t := range b.tasks {
+		taskLines[i] = b.taskLine(t, false)
+	}
+	todayLines := make([]string, len(b.today))
+	for i, t := range b.today {
+		todayLines[i] = b.taskLine(t, true)
+	}
+
+	title := b.projects[b.cursor[projectsPane]].Name
+	panes := []string{
+		b.renderPane(projectsPane, "Projects", projectLines, widths[projectsPane], rows),
+		b.renderPane(tasksPane, title, taskLines, widths[tasksPane], rows),
+		b.renderPane(todayPane, "Today", todayLines, widths[todayPane], rows),
+	}
+	return lipgloss.JoinVertical(lipgloss.Left, lipgloss.JoinHorizontal(lipgloss.Top, panes...), footer)
+}
+
+// taskLine is how a task reads in a pane; the today pane adds its due time
+// and project
+func (b *board) taskLine(t *storage.Task, today bool) string {
+	mark := "[ ]"
+	if t.Done {
+		mark = "[✓]"
+	}
+	line := fmt.Sprintf("%s %s", mark, storage.DisplayName(t.Name))
+	if today {
+		// Today's tasks show their time, overdue ones their date
+		due := storage.FormatDue(*t.DueDate)
+		if strings.HasPrefix(due, b.now().Format("2006-01-02")) {
+			due = strings.TrimSpace(due[10:])
+		}
+		if due != "" {
+			line = due + " " + line
+		}
+		line += " (" + b.names[t.ProjectID] + ")"
+	}
+	switch {
+	case t.Done:
+		return doneStyle.Render(line)
+	case t.DueDate != nil && storage.DueAt(*t.DueDate, time.Local).Before(b.now()):
+		return overdueStyle.Render(line)
+	}
+	return line
+}
+
+// renderPane draws a bordered column of lines, scrolled so the cursor shows
+func (b *board) renderPane(p pane, title string, lines []string, width, rows int) string {
+	inner := width - 4
+	if inner < 1 {
+		inner = 1
+	}
+	cursor := b.cursor[p]
+	start := 0
+	if cursor >= rows-1 {
+		start = cursor - rows + 2
+	}
+
+	body := []string{titleStyle.Render(truncate(title, inner))}
+	if len(lines) == 0 {
+		body = append(body, helpStyle.Render("(empty)"))
+	}
+	for i := start; i < len(lines) && len(body) < rows; i++ {
+		line := truncate(lines[i], inner)
+		if i == cursor && p == b.focus {
+			line = cursorStyle.Render(line)
+		}
+		body = append(body, line)
+	}
+
+	style := paneStyle
+	if p == b.focus {
+		style = focusedStyle
+	}
+	return style.Width(width - 2).Height(rows).Render(strings.Join(body, "\n"))
+}
+
+// truncate cuts a line to width cells
+func truncate(s string, width int) string {
+	return lipgloss.NewStyle().MaxWidth(width).Render(s)
+}
+
+// footer is the command palette or the last output, then the key help
+func (b *board) footer() string {
+	var lines []string
+	if b.output != "" {
+		out := strings.Split(b.output, "\n")
+		if len(out) > outputLines {
+			out = out[len(out)-outputLines:]
+		}
+		for _, line := range out {
+			lines = append(lines, truncate(line, b.width))
+		}
+	}
+	if b.palette {
+		lines = append(lines, "> "+string(b.input)+"█")
+	} else {
+		lines = append(lines, helpStyle.Render(truncate("tab switch pane · ↑↓ move · space done/undone · : or / run a command · r reload · q quit", b.width)))
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,137 @@
+package tui
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"twooms/storage"
+)
+
+func key(s string) tea.KeyMsg {
+	switch s {
+	case "tab":
+		return tea.KeyMsg{Type: tea.KeyTab}
+	case "enter":
+		return tea.KeyMsg{Type: tea.KeyEnter}
+	case "esc":
+		return tea.KeyMsg{Type: tea.KeyEsc}
+	case " ":
+		return tea.KeyMsg{Type: tea.KeySpace, Runes: []rune(" ")}
+	}
+	return tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(s)}
+}
+
+func TestBoard(t *testing.T) {
+	store, err := storage.NewJSONStore(filepath.Join(t.TempDir(), "test.json"))
+	if err != nil {
+		t.Fatalf("Failed to create test store: %v", err)
+	}
+	defer store.Close()
+
+	work, _ := store.CreateProject("Work")
+	home, _ := store.CreateProject("Home")
+	report, _ := store.CreateTask(work.ID, "Write report")
+	store.CreateTask(home.ID, "Water plants")
+	inbox, _ := store.CreateTask("", "Call the bank")
+	today := time.Now()
+	store.SetTaskDueDate(report.ID, &today)
+
+	// The fake runner marks tasks done itself, as /done would
+	var ran []string
+	run := func(line string) (bool, string, error) {
+		ran = append(ran, line)
+		if id, ok := strings.CutPrefix(line, "/done "); ok {
+			store.UpdateTask(id, true)
+		}
+		return line == "/quit", "ran " + line + "\n", nil
+	}
+	b := newBoard(store, run)
+	press := func(keys ...string) tea.Cmd {
+		var cmd tea.Cmd
+		for _, k := range keys {
+			_, cmd = b.Update(key(k))
+		}
+		return cmd
+	}
+
+	t.Run("panes", func(t *testing.T) {
+		view := b.View()
+		for _, want := range []string{"Projects", "Work", "Home", storage.InboxName, "Today", "Write report"} {
+			if !strings.Contains(view, want) {
+				t.Errorf("View() missing %q:\n%s", want, view)
+			}
+		}
+		if len(b.today) != 1 || b.today[0].ID != report.ID {
+			t.Errorf("today = %v, want only the report", b.today)
+		}
+	})
+
+	t.Run("navigation", func(t *testing.T) {
+		press("j", "j")
+		if got := b.projects[b.cursor[projectsPane]].Name; got != storage.InboxName {
+			t.Errorf("selected project = %q, want the inbox", got)
+		}
+		if len(b.tasks) != 1 || b.tasks[0].ID != inbox.ID {
+			t.Errorf("tasks = %v, want the inbox task", b.tasks)
+		}
+		press("j")
+		if b.cursor[projectsPane] != 2 {
+			t.Errorf("cursor moved past the last project: %d", b.cursor[projectsPane])
+		}
+		press("enter")
+		if b.focus != tasksPane {
+			t.Errorf("focus = %d after enter, want the tasks pane", b.focus)
+		}
+	})
+
+	t.Run("toggle", func(t *testing.T) {
+		ran = nil
+		press(" ")
+		if len(ran) != 1 || ran[0] != "/done "+inbox.ID {
+			t.Fatalf("ran %v, want /done %s", ran, inbox.ID)
+		}
+		if !b.tasks[0].Done {
+			t.Error("board did not reload after the toggle")
+		}
+		press("x")
+		if ran[1] != "/undone "+inbox.ID {
+			t.Errorf("ran %v, want /undone for a done task", ran)
+		}
+
+		// From the today pane, done tasks leave it
+		press("tab", " ")
+		if len(b.today) != 0 {
+			t.Errorf("today = %v after marking it done", b.today)
+		}
+	})
+
+	t.Run("palette", func(t *testing.T) {
+		ran = nil
+		press(":", "l", "s", "enter")
+		if len(ran) != 1 || ran[0] != "/ls" {
+			t.Errorf("ran %v, want /ls", ran)
+		}
+		if !strings.Contains(b.View(), "ran /ls") {
+			t.Errorf("View() missing the command output:\n%s", b.View())
+		}
+
+		press("/", "a", "esc")
+		if b.palette || len(ran) != 1 {
+			t.Errorf("esc should close the palette without running: ran %v", ran)
+		}
+
+		if cmd := press("/", "q", "u", "i", "t", "enter"); cmd == nil {
+			t.Error("/quit from the palette should close the board")
+		}
+	})
+
+	t.Run("quit", func(t *testing.T) {
+		if cmd := press("q"); cmd == nil {
+			t.Error("q should close the board")
+		}
+	})
+}